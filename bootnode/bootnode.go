@@ -29,6 +29,13 @@ type Config struct {
 	Libp2pPort    uint16
 	Libp2pKeyFile string
 	RPCPort       uint16
+
+	// IndexEnv, if set, is the swap environment whose makers the bootnode
+	// crawls for an offer index, served read-only on IndexRPCAddress. The
+	// index has its own libp2p host on IndexLibp2pPort.
+	IndexEnv        common.Environment
+	IndexLibp2pPort uint16
+	IndexRPCAddress string // "IP:port"
 }
 
 // RunBootnode assembles and runs a bootnode instance, blocking until the node is
@@ -38,7 +45,6 @@ func RunBootnode(ctx context.Context, cfg *Config) error {
 	host, err := net.NewHost(&net.Config{
 		Ctx:       ctx,
 		Env:       common.Bootnode,
-		DataDir:   cfg.DataDir,
 		Port:      cfg.Libp2pPort,
 		KeyFile:   cfg.Libp2pKeyFile,
 		Bootnodes: cfg.Bootnodes,
@@ -58,6 +64,19 @@ func RunBootnode(ctx context.Context, cfg *Config) error {
 		return err
 	}
 
+	if cfg.IndexEnv != common.Undefined {
+		index, indexErr := startOfferIndex(ctx, cfg)
+		if indexErr != nil {
+			return fmt.Errorf("failed to start the offer index: %w", indexErr)
+		}
+		// runs before the bootnode's host is stopped
+		defer func() {
+			if indexErr := index.stop(); indexErr != nil {
+				err = multierror.Append(err, indexErr)
+			}
+		}()
+	}
+
 	rpcServer, err := rpc.NewServer(&rpc.Config{
 		Ctx:             ctx,
 		Env:             common.Bootnode,
@@ -0,0 +1,137 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package bootnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/db"
+	"github.com/athanorlabs/atomic-swap/net"
+	"github.com/athanorlabs/atomic-swap/orderbook"
+	"github.com/athanorlabs/atomic-swap/rpc"
+)
+
+// indexDirName is the name of the folder, located in the bootnode's data-dir,
+// with a subfolder for the host key and database of each indexed environment.
+const indexDirName = "index"
+
+// offerIndex crawls the makers of a swap environment and serves their offers
+// through the index RPC namespace. The bootnode's own host can't do it, as
+// swap environments use other protocol IDs than bootnodes.
+type offerIndex struct {
+	cancel    context.CancelFunc
+	db        *db.Database
+	host      *net.Host
+	book      *orderbook.Book
+	rpcServer *rpc.Server
+}
+
+// startOfferIndex starts the offer index of the environment in cfg.IndexEnv.
+// The index RPC server is public, so it only serves the index namespace.
+func startOfferIndex(ctx context.Context, cfg *Config) (_ *offerIndex, err error) {
+	if cfg.IndexEnv == common.Undefined || cfg.IndexEnv == common.Bootnode {
+		return nil, fmt.Errorf("can't index offers of the %s environment", cfg.IndexEnv)
+	}
+
+	indexCtx, cancel := context.WithCancel(ctx)
+	idx := &offerIndex{cancel: cancel}
+	defer func() {
+		if err != nil {
+			if stopErr := idx.stop(); stopErr != nil {
+				err = multierror.Append(err, stopErr)
+			}
+		}
+	}()
+
+	dataDir := path.Join(cfg.DataDir, indexDirName, cfg.IndexEnv.String())
+	if err = common.MakeDir(dataDir); err != nil {
+		return nil, err
+	}
+
+	idx.db, err = db.NewDatabase(&chaindb.Config{
+		DataDir: path.Join(dataDir, "db"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idx.host, err = net.NewHost(&net.Config{
+		Ctx:       indexCtx,
+		Env:       cfg.IndexEnv,
+		Port:      cfg.IndexLibp2pPort,
+		KeyFile:   path.Join(dataDir, common.DefaultLibp2pKeyFileName),
+		Bootnodes: cfg.Bootnodes,
+		ListenIP:  cfg.P2PListenIP,
+		IsIndexer: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = idx.host.Start(); err != nil {
+		return nil, err
+	}
+
+	idx.book, err = orderbook.NewBook(indexCtx, &orderbook.Config{
+		Net: idx.host,
+		DB:  idx.db,
+	})
+	if err != nil {
+		return nil, err
+	}
+	idx.book.Start()
+
+	idx.rpcServer, err = rpc.NewServer(&rpc.Config{
+		Ctx:        indexCtx,
+		Env:        common.Bootnode,
+		Address:    cfg.IndexRPCAddress,
+		OfferIndex: idx.book,
+		Namespaces: map[string]struct{}{
+			rpc.IndexNamespace: {},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := idx.rpcServer.Start(); err != nil && !errors.Is(err, context.Canceled) &&
+			!errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("offer index RPC server failed: %s", err)
+		}
+	}()
+
+	log.Infof("indexing the offers of the %s environment with peer ID %s", cfg.IndexEnv, idx.host.PeerID())
+	return idx, nil
+}
+
+// stop shuts down the offer index. The book is stopped before the host and
+// the database.
+func (idx *offerIndex) stop() error {
+	idx.cancel()
+	if idx.book != nil {
+		idx.book.Wait()
+	}
+
+	var err error
+	if idx.host != nil {
+		if hostErr := idx.host.Stop(); hostErr != nil {
+			err = multierror.Append(err, fmt.Errorf("error shutting down the offer index host: %w", hostErr))
+		}
+	}
+	if idx.db != nil {
+		if dbErr := idx.db.Close(); dbErr != nil {
+			err = multierror.Append(err, fmt.Errorf("error closing the offer index database: %w", dbErr))
+		}
+	}
+
+	return err
+}
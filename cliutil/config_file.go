@@ -0,0 +1,241 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package cliutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FlagConfig is the flag of the configuration file.
+	FlagConfig = "config"
+
+	// EnvRPCUsername and EnvRPCPassword override the RPC credentials of the
+	// configuration file.
+	EnvRPCUsername = "SWAPD_RPC_USERNAME"
+	EnvRPCPassword = "SWAPD_RPC_PASSWORD" //nolint:gosec
+)
+
+// Sections of the configuration file. All other top level keys are flag names.
+const (
+	sectionMoneroNodes = "monero-nodes"
+	sectionChains      = "chains"
+	sectionRelayer     = "relayer"
+	sectionRPC         = "rpc"
+)
+
+// ConfigFile is a TOML or YAML configuration file of swapd or swapcli. Its top
+// level keys are flag names with the values the flags would be passed, except
+// for the sections below, which hold settings that flags can't express well.
+type ConfigFile struct {
+	Path string `toml:"-" yaml:"-"`
+	// Flags are the values of the top level keys, keyed by flag name.
+	Flags map[string]any `toml:"-" yaml:"-"`
+
+	MoneroNodes []*MoneroNodeConfig `toml:"monero-nodes" yaml:"monero-nodes"`
+	Chains      []*ChainConfig      `toml:"chains" yaml:"chains"`
+	Relayer     *RelayerConfig      `toml:"relayer" yaml:"relayer"`
+	RPC         *RPCConfig          `toml:"rpc" yaml:"rpc"`
+}
+
+// MoneroNodeConfig is a monerod node that swapd can use, in the order of
+// preference.
+type MoneroNodeConfig struct {
+	Host string `toml:"host" yaml:"host"`
+	Port uint   `toml:"port" yaml:"port"`
+}
+
+// ChainConfig is an additional chain that swaps are enabled on. Name is a
+// chain name or chain ID.
+type ChainConfig struct {
+	Name               string `toml:"name" yaml:"name"`
+	Endpoint           string `toml:"endpoint" yaml:"endpoint"`
+	SwapCreatorAddress string `toml:"swap-creator-address" yaml:"swap-creator-address"`
+}
+
+// RelayerConfig is the relayer policy of swapd.
+type RelayerConfig struct {
+	// Enabled advertises that swapd relays claims for any XMR maker, the
+	// same as the relayer flag.
+	Enabled bool `toml:"enabled" yaml:"enabled"`
+}
+
+// RPCConfig holds the credentials of swapd's RPC server.
+type RPCConfig struct {
+	Username string `toml:"username" yaml:"username"`
+	Password string `toml:"password" yaml:"password"`
+}
+
+// LoadConfigFile loads the configuration file at the path. The format is
+// TOML, or YAML if the file has a .yaml or .yml extension.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cf := &ConfigFile{Path: path}
+	var keys map[string]any
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err = yaml.Unmarshal(data, cf); err == nil {
+			err = yaml.Unmarshal(data, &keys)
+		}
+	default:
+		if _, err = toml.Decode(string(data), cf); err == nil {
+			_, err = toml.Decode(string(data), &keys)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	cf.Flags = make(map[string]any)
+	for key, value := range keys {
+		switch key {
+		case sectionMoneroNodes, sectionChains, sectionRelayer, sectionRPC:
+			continue
+		}
+		cf.Flags[key] = value
+	}
+
+	return cf, nil
+}
+
+// LoadConfigFileFromContext loads the file of the config flag. It returns nil
+// if the flag is not set.
+func LoadConfigFileFromContext(c *cli.Context) (*ConfigFile, error) {
+	path := c.String(FlagConfig)
+	if path == "" {
+		return nil, nil
+	}
+	return LoadConfigFile(path)
+}
+
+// FlagValue returns the value of the flag in the file as it would be passed
+// on the command line. Lists return one value per element.
+func (cf *ConfigFile) FlagValue(name string) ([]string, bool, error) {
+	if cf == nil {
+		return nil, false, nil
+	}
+
+	value, ok := cf.Flags[name]
+	if !ok {
+		return nil, false, nil
+	}
+
+	var values []string
+	switch v := value.(type) {
+	case []any:
+		for _, elem := range v {
+			s, err := flagValueString(name, elem)
+			if err != nil {
+				return nil, false, err
+			}
+			values = append(values, s)
+		}
+	default:
+		s, err := flagValueString(name, v)
+		if err != nil {
+			return nil, false, err
+		}
+		values = []string{s}
+	}
+
+	return values, true, nil
+}
+
+func flagValueString(name string, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("config file value of %q has unsupported type %T", name, value)
+	}
+}
+
+// ApplyFlags sets every flag of the command that is in the file but was not
+// set on the command line or by an environment variable, so that both
+// override the file. Keys that are not flags of the command are an error.
+func (cf *ConfigFile) ApplyFlags(c *cli.Context, flags []cli.Flag) error {
+	if cf == nil {
+		return nil
+	}
+
+	known := make(map[string]string) // any name or alias to the flag's name
+	for _, f := range flags {
+		names := f.Names()
+		for _, n := range names {
+			known[n] = names[0]
+		}
+	}
+
+	// apply in a fixed order, so that errors are deterministic
+	var keys []string
+	for key := range cf.Flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, ok := known[key]
+		if !ok || name == FlagConfig {
+			return fmt.Errorf("config file %s: unknown key %q", cf.Path, key)
+		}
+
+		if c.IsSet(name) {
+			continue
+		}
+
+		values, _, err := cf.FlagValue(key)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range values {
+			if err = c.Set(name, v); err != nil {
+				return fmt.Errorf("config file %s: invalid value %q for %q: %w", cf.Path, v, key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RPCCredentials returns the RPC username and password of the file, with the
+// environment variables taking precedence. Both are empty if no credentials
+// are configured.
+func (cf *ConfigFile) RPCCredentials() (string, string) {
+	var username, password string
+	if cf != nil && cf.RPC != nil {
+		username, password = cf.RPC.Username, cf.RPC.Password
+	}
+
+	if v, ok := os.LookupEnv(EnvRPCUsername); ok {
+		username = v
+	}
+	if v, ok := os.LookupEnv(EnvRPCPassword); ok {
+		password = v
+	}
+
+	return username, password
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package cliutil
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+const testConfigTOML = `
+port = 5000
+name = "alice"
+verbose = true
+peers = ["a", "b"]
+
+[[monero-nodes]]
+host = "node.example.org"
+port = 18081
+
+[[chains]]
+name = "base"
+endpoint = "https://base.example.org"
+swap-creator-address = "0x377ed3a60007048DF00135637521170628De89E5"
+
+[relayer]
+enabled = true
+
+[rpc]
+username = "swap"
+password = "secret"
+`
+
+const testConfigYAML = `
+port: 5000
+name: alice
+verbose: true
+peers: [a, b]
+monero-nodes:
+  - host: node.example.org
+    port: 18081
+chains:
+  - name: base
+    endpoint: https://base.example.org
+    swap-creator-address: "0x377ed3a60007048DF00135637521170628De89E5"
+relayer:
+  enabled: true
+rpc:
+  username: swap
+  password: secret
+`
+
+func writeConfigFile(t *testing.T, name string, contents string) string {
+	filePath := path.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(filePath, []byte(contents), 0600))
+	return filePath
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	tomlConf, err := LoadConfigFile(writeConfigFile(t, "swapd.toml", testConfigTOML))
+	require.NoError(t, err)
+	yamlConf, err := LoadConfigFile(writeConfigFile(t, "swapd.yml", testConfigYAML))
+	require.NoError(t, err)
+
+	for _, cf := range []*ConfigFile{tomlConf, yamlConf} {
+		require.Len(t, cf.Flags, 4) // the sections are not flags
+		require.Equal(t, []*MoneroNodeConfig{{Host: "node.example.org", Port: 18081}}, cf.MoneroNodes)
+		require.Len(t, cf.Chains, 1)
+		require.Equal(t, "https://base.example.org", cf.Chains[0].Endpoint)
+		require.True(t, cf.Relayer.Enabled)
+
+		port, ok, err := cf.FlagValue("port")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"5000"}, port)
+
+		peers, _, err := cf.FlagValue("peers")
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, peers)
+
+		_, ok, err = cf.FlagValue("missing")
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	_, err = LoadConfigFile(writeConfigFile(t, "bad.toml", "port = "))
+	require.ErrorContains(t, err, "failed to parse config file")
+}
+
+func TestConfigFile_ApplyFlags(t *testing.T) {
+	cf, err := LoadConfigFile(writeConfigFile(t, "swapd.toml", testConfigTOML))
+	require.NoError(t, err)
+
+	var port uint
+	var name string
+	var verbose bool
+	var peers []string
+
+	app := &cli.App{
+		Flags: []cli.Flag{
+			&cli.UintFlag{Name: "port", Value: 1},
+			&cli.StringFlag{Name: "name", EnvVars: []string{"TEST_CONFIG_NAME"}},
+			&cli.BoolFlag{Name: "verbose"},
+			&cli.StringSliceFlag{Name: "peers", Aliases: []string{"p"}},
+		},
+		Action: func(c *cli.Context) error {
+			if err := cf.ApplyFlags(c, c.App.Flags); err != nil {
+				return err
+			}
+			port, name, verbose, peers = c.Uint("port"), c.String("name"), c.Bool("verbose"), c.StringSlice("peers")
+			return nil
+		},
+	}
+
+	// flags and environment variables override the file
+	t.Setenv("TEST_CONFIG_NAME", "bob")
+	require.NoError(t, app.Run([]string{"test", "--port=6000"}))
+	require.Equal(t, uint(6000), port)
+	require.Equal(t, "bob", name)
+	require.True(t, verbose)
+	require.Equal(t, []string{"a", "b"}, peers)
+
+	require.NoError(t, app.Run([]string{"test", "-p", "c"}))
+	require.Equal(t, uint(5000), port)
+	require.Equal(t, []string{"c"}, peers)
+
+	cf.Flags["unknown"] = "value"
+	err = app.Run([]string{"test"})
+	require.ErrorContains(t, err, `unknown key "unknown"`)
+}
+
+func TestConfigFile_RPCCredentials(t *testing.T) {
+	var cf *ConfigFile
+	username, password := cf.RPCCredentials()
+	require.Empty(t, username)
+	require.Empty(t, password)
+
+	cf, err := LoadConfigFile(writeConfigFile(t, "swapd.yaml", testConfigYAML))
+	require.NoError(t, err)
+	username, password = cf.RPCCredentials()
+	require.Equal(t, "swap", username)
+	require.Equal(t, "secret", password)
+
+	t.Setenv(EnvRPCPassword, "override")
+	_, password = cf.RPCCredentials()
+	require.Equal(t, "override", password)
+}
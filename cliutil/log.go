@@ -17,6 +17,18 @@ const (
 
 // SetLogLevelsFromContext sets the log levels for all packages from the CLI context.
 func SetLogLevelsFromContext(c *cli.Context) error {
+	level := c.String(FlagLogLevel)
+	if err := ValidateLogLevel(level); err != nil {
+		return err
+	}
+
+	SetLogLevels(level)
+	return nil
+}
+
+// ValidateLogLevel returns an error if the level is not one of the levels that
+// SetLogLevels accepts.
+func ValidateLogLevel(level string) error {
 	const (
 		levelError = "error"
 		levelWarn  = "warn"
@@ -24,15 +36,12 @@ func SetLogLevelsFromContext(c *cli.Context) error {
 		levelDebug = "debug"
 	)
 
-	level := c.String(FlagLogLevel)
 	switch level {
 	case levelError, levelWarn, levelInfo, levelDebug:
+		return nil
 	default:
 		return fmt.Errorf("invalid log level %q", level)
 	}
-
-	SetLogLevels(level)
-	return nil
 }
 
 // SetLogLevels sets the log levels for all packages.
@@ -50,13 +59,18 @@ func SetLogLevels(level string) {
 	_ = logging.SetLogLevel("ethereum/extethclient", level)
 	_ = logging.SetLogLevel("monero", level)
 	_ = logging.SetLogLevel("net", level)
+	_ = logging.SetLogLevel("netproxy", level)
 	_ = logging.SetLogLevel("offers", level)
-	_ = logging.SetLogLevel("p2pnet", level) // external
+	_ = logging.SetLogLevel("orderbook", level)
 	_ = logging.SetLogLevel("pricefeed", level)
 	_ = logging.SetLogLevel("protocol", level)
+	_ = logging.SetLogLevel("recovery", level)
 	_ = logging.SetLogLevel("relayer", level) // external and internal
 	_ = logging.SetLogLevel("rpc", level)
+	_ = logging.SetLogLevel("swap", level)
+	_ = logging.SetLogLevel("tokenpolicy", level)
 	_ = logging.SetLogLevel("txsender", level)
+	_ = logging.SetLogLevel("webhook", level)
 	_ = logging.SetLogLevel("xmrmaker", level)
 	_ = logging.SetLogLevel("xmrtaker", level)
 }
@@ -0,0 +1,27 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package cliutil
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+)
+
+const (
+	// FlagProxy is the SOCKS5 proxy URL flag.
+	FlagProxy = "proxy"
+	// FlagProxyStrict is the flag that refuses connections which can't go
+	// through the proxy.
+	FlagProxyStrict = "proxy-strict"
+)
+
+// ProxyFromContext returns the proxy of the --proxy and --proxy-strict flags,
+// which is passed to the clients that make outbound connections.
+func ProxyFromContext(c *cli.Context) (*netproxy.Proxy, error) {
+	return netproxy.New(&netproxy.Config{
+		URL:    c.String(FlagProxy),
+		Strict: c.Bool(FlagProxyStrict),
+	})
+}
@@ -15,21 +15,49 @@ import (
 
 // SignalHandler handles OS signals and shuts down the program if necessary.
 func SignalHandler(ctx context.Context, cancel context.CancelFunc, log *logging.ZapEventLogger) {
+	SignalHandlerWithReload(ctx, cancel, log, nil)
+}
+
+// SignalHandlerWithReload is SignalHandler, but SIGHUP sends a request to
+// reload the configuration on the passed channel instead of being ignored.
+// Requests are dropped while an earlier one is still pending.
+func SignalHandlerWithReload(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	log *logging.ZapEventLogger,
+	reload chan<- struct{},
+) {
 	sigc := make(chan os.Signal, 1)
-	signal.Ignore(syscall.SIGHUP)
+	sighup := make(chan os.Signal, 1)
+	if reload == nil {
+		signal.Ignore(syscall.SIGHUP)
+	} else {
+		signal.Notify(sighup, syscall.SIGHUP)
+	}
 	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
 	defer func() {
 		// Hopefully, we'll exit our main() before this sleep ends, but if not remove the
 		// signal handler allowing the next signal to kill us.
 		time.Sleep(1 * time.Second)
 		signal.Stop(sigc)
+		signal.Stop(sighup)
 	}()
 
-	select {
-	case s := <-sigc:
-		log.Infof("Received signal %s(%d), shutting down...", s, s)
-		cancel()
-	case <-ctx.Done():
-		log.Info("Protocol complete, shutting down...")
+	for {
+		select {
+		case <-sighup:
+			log.Info("Received SIGHUP, reloading the configuration...")
+			select {
+			case reload <- struct{}{}:
+			default:
+			}
+		case s := <-sigc:
+			log.Infof("Received signal %s(%d), shutting down...", s, s)
+			cancel()
+			return
+		case <-ctx.Done():
+			log.Info("Protocol complete, shutting down...")
+			return
+		}
 	}
 }
@@ -21,8 +21,10 @@ import (
 )
 
 const (
-	defaultLibp2pPort = 9909
-	defaultRPCPort    = common.DefaultSwapdPort
+	defaultLibp2pPort      = 9909
+	defaultRPCPort         = common.DefaultSwapdPort
+	defaultIndexLibp2pPort = 9910
+	defaultIndexRPCAddress = "127.0.0.1:5010"
 
 	flagDataDir    = "data-dir"
 	flagLibp2pKey  = "libp2p-key"
@@ -30,6 +32,10 @@ const (
 	flagBootnodes  = "bootnodes"
 	flagRPCPort    = "rpc-port"
 	flagLibp2pIP   = "libp2p-ip"
+
+	flagIndexEnv        = "index-env"
+	flagIndexLibp2pPort = "index-libp2p-port"
+	flagIndexRPCAddress = "index-rpc-address"
 )
 
 var log = logging.Logger("cmd")
@@ -76,6 +82,21 @@ func cliApp() *cli.App {
 				Usage: "Libp2p bind IP, can set to 127.0.0.1 for testing",
 				Value: "0.0.0.0",
 			},
+			&cli.StringFlag{
+				Name:    flagIndexEnv,
+				Usage:   "Crawl the makers of this environment and serve an index of their offers: mainnet, stagenet or dev",
+				EnvVars: []string{"SWAPD_INDEX_ENV"},
+			},
+			&cli.UintFlag{
+				Name:  flagIndexLibp2pPort,
+				Usage: "libp2p port of the offer index to listen on",
+				Value: defaultIndexLibp2pPort,
+			},
+			&cli.StringFlag{
+				Name:  flagIndexRPCAddress,
+				Usage: "IP:port of the read-only offer index RPC server, which can be public",
+				Value: defaultIndexRPCAddress,
+			},
 			&cli.StringFlag{
 				Name:    cliutil.FlagLogLevel,
 				Usage:   "Set log level: one of [error|warn|info|debug]",
@@ -125,16 +146,30 @@ func runBootnode(c *cli.Context) error {
 		libp2pKeyFile = path.Join(config.DataDir, common.DefaultLibp2pKeyFileName)
 	}
 
+	indexEnv := common.Undefined
+	if c.IsSet(flagIndexEnv) {
+		indexEnv, err = common.NewEnv(c.String(flagIndexEnv))
+		if err != nil {
+			return err
+		}
+		if indexEnv == common.Bootnode {
+			return fmt.Errorf("flag %q must be a swap environment", flagIndexEnv)
+		}
+	}
+
 	log.Infof("starting bootnode")
 
 	rpcPort := uint16(c.Uint(flagRPCPort))
 	return bootnode.RunBootnode(c.Context, &bootnode.Config{
-		DataDir:       config.DataDir,
-		Bootnodes:     config.Bootnodes,
-		P2PListenIP:   c.String(flagLibp2pIP),
-		Libp2pPort:    uint16(c.Uint(flagLibp2pPort)),
-		Libp2pKeyFile: libp2pKeyFile,
-		RPCPort:       rpcPort,
+		DataDir:         config.DataDir,
+		Bootnodes:       config.Bootnodes,
+		P2PListenIP:     c.String(flagLibp2pIP),
+		Libp2pPort:      uint16(c.Uint(flagLibp2pPort)),
+		Libp2pKeyFile:   libp2pKeyFile,
+		RPCPort:         rpcPort,
+		IndexEnv:        indexEnv,
+		IndexLibp2pPort: uint16(c.Uint(flagIndexLibp2pPort)),
+		IndexRPCAddress: c.String(flagIndexRPCAddress),
 	})
 }
 
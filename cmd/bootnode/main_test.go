@@ -28,6 +28,7 @@ func TestBootnode(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	rpcPort := getFreePort(t)
+	indexRPCPort := getFreePort(t)
 	dataDir := t.TempDir()
 
 	flags := []string{
@@ -37,6 +38,9 @@ func TestBootnode(t *testing.T) {
 		fmt.Sprintf("--%s=%s", flagDataDir, dataDir),
 		fmt.Sprintf("--%s=%d", flagRPCPort, rpcPort),
 		fmt.Sprintf("--%s=0", flagLibp2pPort),
+		fmt.Sprintf("--%s=dev", flagIndexEnv),
+		fmt.Sprintf("--%s=0", flagIndexLibp2pPort),
+		fmt.Sprintf("--%s=127.0.0.1:%d", flagIndexRPCAddress, indexRPCPort),
 	}
 
 	var wg sync.WaitGroup
@@ -63,6 +67,11 @@ func TestBootnode(t *testing.T) {
 	require.NoError(t, err)
 	require.Greater(t, len(addressResp.Addrs), 1)
 
+	// the offer index of the dev environment is served separately
+	indexStats, err := rpcclient.NewClient(ctx, indexRPCPort).IndexStats()
+	require.NoError(t, err)
+	require.Zero(t, indexStats.Offers)
+
 	// We check the contract code below, but we don't need the daemon for that
 	cli.Shutdown()
 	wg.Wait()
@@ -0,0 +1,65 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/cliutil"
+)
+
+const (
+	flagConfig = cliutil.FlagConfig
+
+	// metadataClientConfig is the key of the clientConfig in the app metadata
+	metadataClientConfig = "clientConfig"
+
+	// keyRPCPort is swapd's flag for its RPC port, so that swapcli can read
+	// swapd's config file
+	keyRPCPort = "rpc-port"
+)
+
+// clientConfig holds the settings of the config file that swapcli uses to
+// connect to swapd.
+type clientConfig struct {
+	swapdPort uint // zero if not set
+	username  string
+	password  string
+}
+
+// loadConfig loads the config file, which is either a swapcli config file or
+// the config file of the swapd instance, and stores the settings in the app's
+// metadata. The swapd-port key takes precedence over swapd's rpc-port key.
+func loadConfig(c *cli.Context) error {
+	cf, err := cliutil.LoadConfigFileFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	conf := new(clientConfig)
+
+	for _, key := range []string{flagSwapdPort, keyRPCPort} {
+		values, ok, err := cf.FlagValue(key) //nolint:govet
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		port, err := strconv.ParseUint(values[0], 10, 16)
+		if err != nil || port == 0 {
+			return fmt.Errorf("config file %s: invalid port %q for %q", cf.Path, values[0], key)
+		}
+		conf.swapdPort = uint(port)
+		break
+	}
+
+	conf.username, conf.password = cf.RPCCredentials()
+	c.App.Metadata[metadataClientConfig] = conf
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/rpc"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+
+	dateFmt = "2006-01-02"
+)
+
+var historyCSVHeader = []string{
+	"id",
+	"role",
+	"status",
+	"start_time",
+	"end_time",
+	"provided_amount",
+	"provided_symbol",
+	"received_amount",
+	"received_symbol",
+	"relayer_fee",
+	"exchange_rate",
+	"eth_tx_hashes",
+	"xmr_tx_ids",
+	"xmr_usd_price",
+	"usd_value",
+}
+
+// readTimeRangeFlags returns the values of the --from and --to flags, or nil
+// for flags that are not set. A plain date passed to --to includes the whole
+// day.
+func readTimeRangeFlags(ctx *cli.Context) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+
+	if ctx.IsSet(flagFrom) {
+		t, err := parseTimeFlagValue(ctx.String(flagFrom))
+		if err != nil {
+			return nil, nil, errInvalidFlagValue(flagFrom, err)
+		}
+		from = &t
+	}
+
+	if ctx.IsSet(flagTo) {
+		t, err := parseTimeFlagValue(ctx.String(flagTo))
+		if err != nil {
+			return nil, nil, errInvalidFlagValue(flagTo, err)
+		}
+		if _, err = time.Parse(dateFmt, ctx.String(flagTo)); err == nil {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		to = &t
+	}
+
+	return from, to, nil
+}
+
+// parseTimeFlagValue accepts either a date (2006-01-02, interpreted in the
+// local time zone) or a full RFC 3339 timestamp.
+func parseTimeFlagValue(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(dateFmt, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func writeHistory(w io.Writer, format string, swaps []*rpc.HistoryEntry) error {
+	switch format {
+	case exportFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(swaps)
+	case exportFormatCSV:
+		return writeHistoryCSV(w, swaps)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func writeHistoryCSV(w io.Writer, swaps []*rpc.HistoryEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(historyCSVHeader); err != nil {
+		return err
+	}
+
+	for _, s := range swaps {
+		role := "taker"
+		providedSym, receivedSym := s.EthAssetSymbol, "XMR"
+		if s.Provided == coins.ProvidesXMR {
+			role = "maker"
+			providedSym, receivedSym = "XMR", s.EthAssetSymbol
+		}
+
+		endTime := ""
+		if s.EndTime != nil {
+			endTime = s.EndTime.Format(time.RFC3339)
+		}
+
+		ethTxHashes := make([]string, len(s.EthTxHashes))
+		for i, h := range s.EthTxHashes {
+			ethTxHashes[i] = h.String()
+		}
+
+		err := cw.Write([]string{
+			s.ID.String(),
+			role,
+			s.Status.String(),
+			s.StartTime.Format(time.RFC3339),
+			endTime,
+			decimalOrEmpty(s.ProvidedAmount),
+			providedSym,
+			decimalOrEmpty(s.ExpectedAmount),
+			receivedSym,
+			decimalOrEmpty(s.RelayerFee),
+			decimalOrEmpty(s.ExchangeRate.Decimal()),
+			strings.Join(ethTxHashes, " "),
+			strings.Join(s.XMRTxIDs, " "),
+			decimalOrEmpty(s.XMRUSDPrice),
+			decimalOrEmpty(s.USDValue),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func decimalOrEmpty(d *apd.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.Text('f')
+}
+
+func decimalOrUnknown(d *apd.Decimal) string {
+	if d == nil {
+		return "unknown"
+	}
+	return d.Text('f')
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/rpc"
+)
+
+func testHistoryEntries() []*rpc.HistoryEntry {
+	start := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	return []*rpc.HistoryEntry{
+		{
+			ID:             types.Hash{0x1},
+			Provided:       coins.ProvidesXMR,
+			EthAsset:       types.EthAssetETH,
+			EthAssetSymbol: "ETH",
+			ProvidedAmount: coins.StrToDecimal("2"),
+			ExpectedAmount: coins.StrToDecimal("0.3"),
+			ExchangeRate:   coins.ToExchangeRate(coins.StrToDecimal("0.15")),
+			Status:         types.CompletedSuccess,
+			StartTime:      start,
+			EndTime:        &end,
+			EthTxHashes:    []types.Hash{{0xa}, {0xb}},
+			XMRTxIDs:       []string{"c0ffee"},
+			XMRUSDPrice:    coins.StrToDecimal("150"),
+			USDValue:       coins.StrToDecimal("300"),
+		},
+		{
+			ID:             types.Hash{0x2},
+			Provided:       coins.ProvidesETH,
+			EthAssetSymbol: "USDT",
+			Status:         types.CompletedAbort,
+			StartTime:      start,
+			Rebuilt:        true,
+		},
+	}
+}
+
+func Test_writeHistory_csv(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeHistory(buf, exportFormatCSV, testHistoryEntries()))
+
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, historyCSVHeader, records[0])
+
+	maker := records[1]
+	require.Equal(t, []string{
+		types.Hash{0x1}.String(),
+		"maker",
+		types.CompletedSuccess.String(),
+		"2023-03-01T12:00:00Z",
+		"2023-03-01T13:00:00Z",
+		"2",
+		"XMR",
+		"0.3",
+		"ETH",
+		"",
+		"0.15",
+		types.Hash{0xa}.String() + " " + types.Hash{0xb}.String(),
+		"c0ffee",
+		"150",
+		"300",
+	}, maker)
+
+	// unknown values of rebuilt swaps are left empty
+	taker := records[2]
+	require.Equal(t, "taker", taker[1])
+	require.Equal(t, "", taker[4])
+	require.Equal(t, "", taker[5])
+	require.Equal(t, "USDT", taker[6])
+	require.Equal(t, "XMR", taker[8])
+	require.Equal(t, "", taker[10])
+	require.Equal(t, "", taker[14])
+}
+
+func Test_writeHistory_json(t *testing.T) {
+	entries := testHistoryEntries()
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeHistory(buf, exportFormatJSON, entries))
+
+	var decoded []*rpc.HistoryEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded, 2)
+	require.Equal(t, entries[0].ID, decoded[0].ID)
+	require.Equal(t, "300", decoded[0].USDValue.String())
+	require.True(t, decoded[1].Rebuilt)
+}
+
+func Test_writeHistory_badFormat(t *testing.T) {
+	err := writeHistory(new(bytes.Buffer), "xml", nil)
+	require.ErrorContains(t, err, `unsupported export format "xml"`)
+}
+
+func Test_readTimeRangeFlags(t *testing.T) {
+	newContext := func(args ...string) *cli.Context {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String(flagFrom, "", "")
+		fs.String(flagTo, "", "")
+		require.NoError(t, fs.Parse(args))
+		return cli.NewContext(cliApp(), fs, nil)
+	}
+
+	from, to, err := readTimeRangeFlags(newContext())
+	require.NoError(t, err)
+	require.Nil(t, from)
+	require.Nil(t, to)
+
+	// a plain --to date includes the whole day
+	from, to, err = readTimeRangeFlags(newContext("--from", "2023-03-01", "--to", "2023-03-02"))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 3, 1, 0, 0, 0, 0, time.Local), *from)
+	require.Equal(t, time.Date(2023, 3, 3, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond), *to)
+
+	_, to, err = readTimeRangeFlags(newContext("--to", "2023-03-02T10:00:00Z"))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 3, 2, 10, 0, 0, 0, time.UTC), to.UTC())
+
+	_, _, err = readTimeRangeFlags(newContext("--from", "yesterday"))
+	require.ErrorContains(t, err, flagFrom)
+}
+
+func (s *swapCLITestSuite) Test_runExportHistory() {
+	for _, format := range []string{exportFormatCSV, exportFormatJSON} {
+		args := []string{"swapcli", "export-history", "--format", format, "--from", "2023-01-01"}
+		err := cliApp().RunContext(context.Background(), args)
+		require.NoError(s.T(), err)
+	}
+
+	args := []string{"swapcli", "export-history", "--format", "xml"}
+	err := cliApp().RunContext(context.Background(), args)
+	require.ErrorContains(s.T(), err, `unsupported format "xml"`)
+}
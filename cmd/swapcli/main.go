@@ -6,6 +6,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -25,6 +26,8 @@ import (
 	"github.com/athanorlabs/atomic-swap/common/types"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/net"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
 	"github.com/athanorlabs/atomic-swap/rpc"
 	"github.com/athanorlabs/atomic-swap/rpcclient"
 )
@@ -48,6 +51,15 @@ const (
 	flagTo             = "to"
 	flagAmount         = "amount"
 	flagGasLimit       = "gas-limit"
+	flagFormat         = "format"
+	flagFrom           = "from"
+	flagStatus         = "status"
+	flagOrder          = "order"
+	flagLimit          = "limit"
+	flagCursor         = "cursor"
+	flagSources        = "sources"
+	flagAllowOffMarket = "allow-off-market"
+	flagChainID        = "chain-id"
 )
 
 func cliApp() *cli.App {
@@ -57,6 +69,14 @@ func cliApp() *cli.App {
 		Version:              cliutil.GetVersion(),
 		EnableBashCompletion: true,
 		Suggest:              true,
+		Before:               loadConfig,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    flagConfig,
+				Usage:   "TOML or YAML (.yaml or .yml extension) config file of swapcli or of swapd",
+				EnvVars: []string{"SWAPCLI_CONFIG"},
+			},
+		},
 		Commands: []*cli.Command{
 			{
 				Name:    "addresses",
@@ -175,6 +195,28 @@ func cliApp() *cli.App {
 					swapdPortFlag,
 				},
 			},
+			{
+				Name:    "order-book",
+				Aliases: []string{"book"},
+				Usage:   "Show the offers that swapd has seen on the network, best exchange rate first",
+				Action:  runOrderBook,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  flagToken,
+						Usage: "Ethereum ERC20 token address of the offers to show, instead of ETH",
+					},
+					&cli.Uint64Flag{
+						Name:  flagChainID,
+						Usage: "ID of the chain of the offers to show, if not swapd's primary chain",
+					},
+					&cli.StringFlag{
+						Name:    flagProvidesAmount,
+						Aliases: []string{"pa"},
+						Usage:   "Only show offers that can be taken by sending this amount of the asset",
+					},
+					swapdPortFlag,
+				},
+			},
 			{
 				Name:    "make",
 				Aliases: []string{"m"},
@@ -206,6 +248,10 @@ func cliApp() *cli.App {
 						Name:  flagToken,
 						Usage: "Ethereum ERC20 token address to receive instead of ETH",
 					},
+					&cli.Uint64Flag{
+						Name:  flagChainID,
+						Usage: "ID of the chain to receive the ETH asset on, if not swapd's primary chain",
+					},
 					&cli.BoolFlag{
 						Name:   flagUseRelayer,
 						Usage:  "Use the relayer even if the receiving account has enough ETH to claim",
@@ -236,6 +282,10 @@ func cliApp() *cli.App {
 						Usage:    "Amount of coin to send in the swap",
 						Required: true,
 					},
+					&cli.BoolFlag{
+						Name:  flagAllowOffMarket,
+						Usage: "Take the offer even if its exchange rate is too far from the market rate",
+					},
 					&cli.BoolFlag{
 						Name:  flagDetached,
 						Usage: "Exit immediately without subscribing to status notifications",
@@ -264,6 +314,60 @@ func cliApp() *cli.App {
 						Name:  flagOfferID,
 						Usage: "ID of swap to retrieve info for",
 					},
+					&cli.StringSliceFlag{
+						Name:  flagStatus,
+						Usage: "Only show swaps with this final status: Success, Refunded or Aborted (may be repeated)",
+					},
+					&cli.StringFlag{
+						Name:  flagPeerID,
+						Usage: "Only show swaps with this peer",
+					},
+					&cli.StringFlag{
+						Name:  flagToken,
+						Usage: "Only show swaps of this ERC20 token address, or ETH",
+					},
+					&cli.StringFlag{
+						Name:  flagFrom,
+						Usage: "Only show swaps started at or after this date (YYYY-MM-DD or RFC 3339 time)",
+					},
+					&cli.StringFlag{
+						Name:  flagTo,
+						Usage: "Only show swaps started at or before this date (YYYY-MM-DD or RFC 3339 time)",
+					},
+					&cli.StringFlag{
+						Name:  flagOrder,
+						Usage: fmt.Sprintf("Sort order by start time: one of [%s, %s]", swap.SortNewestFirst, swap.SortOldestFirst),
+						Value: string(swap.SortNewestFirst),
+					},
+					&cli.UintFlag{
+						Name:  flagLimit,
+						Usage: "Maximum number of swaps to show, 0 for no limit",
+					},
+					&cli.StringFlag{
+						Name:  flagCursor,
+						Usage: "Cursor printed by the previous page of results",
+					},
+					swapdPortFlag,
+				},
+			},
+			{
+				Name:   "export-history",
+				Usage:  "Export past swaps with their USD value at completion time",
+				Action: runExportHistory,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  flagFormat,
+						Usage: fmt.Sprintf("Output format: one of [%s, %s]", exportFormatCSV, exportFormatJSON),
+						Value: exportFormatCSV,
+					},
+					&cli.StringFlag{
+						Name:  flagFrom,
+						Usage: "Only export swaps completed at or after this date (YYYY-MM-DD or RFC 3339 time)",
+					},
+					&cli.StringFlag{
+						Name:  flagTo,
+						Usage: "Only export swaps completed at or before this date (YYYY-MM-DD or RFC 3339 time)",
+					},
 					swapdPortFlag,
 				},
 			},
@@ -312,6 +416,19 @@ func cliApp() *cli.App {
 					swapdPortFlag,
 				},
 			},
+			{
+				Name:      "journal",
+				Usage:     "Show the journal of state transitions and on-chain actions of a swap.",
+				ArgsUsage: "<offer-id>",
+				Action:    runGetJournal,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  flagOfferID,
+						Usage: "ID of swap to retrieve the journal for, if not passed as an argument",
+					},
+					swapdPortFlag,
+				},
+			},
 			{
 				Name:   "set-swap-timeout",
 				Usage:  "Set the duration between swap initiation and t1 and t1 and t2, in seconds",
@@ -330,7 +447,17 @@ func cliApp() *cli.App {
 				Aliases: []string{"suggested-exchange-rate"},
 				Usage:   "Returns the current mainnet exchange rate based on ETH/USD and XMR/USD price feeds.",
 				Action:  runSuggestedExchangeRate,
-				Flags:   []cli.Flag{swapdPortFlag},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  flagToken,
+						Usage: "Ethereum ERC20 token address to get the exchange rate of XMR in, instead of ETH",
+					},
+					&cli.BoolFlag{
+						Name:  flagSources,
+						Usage: "Show the price of each source that the USD prices are the median of",
+					},
+					swapdPortFlag,
+				},
 			},
 			{
 				Name:   "get-swap-timeout",
@@ -422,6 +549,15 @@ func cliApp() *cli.App {
 					swapdPortFlag,
 				},
 			},
+			{
+				Name: "reload",
+				Usage: "Make swapd re-read its config file and apply the settings that can change\n" +
+					"without a restart. Nothing is applied if a setting that needs a restart changed.",
+				Action: runReload,
+				Flags: []cli.Flag{
+					swapdPortFlag,
+				},
+			},
 			{
 				Name:  "recovery",
 				Usage: "Methods that should only be used as a last resort in the case of an unrecoverable swap error.",
@@ -513,7 +649,18 @@ func main() {
 
 func newClient(ctx *cli.Context) *rpcclient.Client {
 	swapdPort := ctx.Uint(flagSwapdPort)
-	return rpcclient.NewClient(ctx.Context, uint16(swapdPort))
+
+	conf, _ := ctx.App.Metadata[metadataClientConfig].(*clientConfig)
+	if conf != nil && conf.swapdPort != 0 && !ctx.IsSet(flagSwapdPort) {
+		swapdPort = conf.swapdPort
+	}
+
+	c := rpcclient.NewClient(ctx.Context, uint16(swapdPort))
+	if conf != nil && (conf.username != "" || conf.password != "") {
+		c.SetCredentials(conf.username, conf.password)
+	}
+
+	return c
 }
 
 func runAddresses(ctx *cli.Context) error {
@@ -530,6 +677,7 @@ func runAddresses(ctx *cli.Context) error {
 	if len(resp.Addrs) == 0 {
 		fmt.Println("[none]")
 	}
+	fmt.Printf("Reachability: %s\n", resp.Reachability)
 	return nil
 }
 
@@ -682,7 +830,7 @@ func runQuery(ctx *cli.Context) error {
 	}
 
 	for i, o := range res.Offers {
-		err = printOffer(c, o, i, "")
+		err = printOffer(c, o, res.Premiums[o.ID], i, "")
 		if err != nil {
 			return err
 		}
@@ -712,7 +860,7 @@ func runQueryAll(ctx *cli.Context) error {
 		fmt.Printf("  Peer ID: %v\n", po.PeerID)
 		fmt.Printf("  Offers:\n")
 		for j, o := range po.Offers {
-			err = printOffer(c, o, j, "    ")
+			err = printOffer(c, o, po.Premiums[o.ID], j, "    ")
 			if err != nil {
 				return err
 			}
@@ -722,6 +870,49 @@ func runQueryAll(ctx *cli.Context) error {
 	return nil
 }
 
+func runOrderBook(ctx *cli.Context) error {
+	req := &rpctypes.GetOrderBookRequest{
+		ChainID: ctx.Uint64(flagChainID),
+	}
+
+	if ethAssetStr := ctx.String(flagToken); ethAssetStr != "" {
+		req.EthAsset = types.EthAsset(ethcommon.HexToAddress(ethAssetStr))
+	}
+
+	if ctx.IsSet(flagProvidesAmount) {
+		var err error
+		req.ProvidesAmount, err = cliutil.ReadPositiveUnsignedDecimalFlag(ctx, flagProvidesAmount)
+		if err != nil {
+			return err
+		}
+	}
+
+	c := newClient(ctx)
+	offers, err := c.GetOrderBook(req)
+	if err != nil {
+		return err
+	}
+
+	if len(offers) == 0 {
+		fmt.Println("No offers")
+		return nil
+	}
+
+	for i, o := range offers {
+		err = printOffer(c, o.Offer, o.Premium, i, "")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Peer ID: %s\n", o.PeerID)
+		fmt.Printf("Last Seen: %s\n", o.LastSeen.Format(time.RFC3339))
+		if o.ExpectedAmount != nil {
+			fmt.Printf("Expected Amount: %s XMR\n", o.ExpectedAmount.Text('f'))
+		}
+	}
+
+	return nil
+}
+
 func runMake(ctx *cli.Context) error {
 	c := newClient(ctx)
 
@@ -746,6 +937,7 @@ func runMake(ctx *cli.Context) error {
 		return err
 	}
 	exchangeRate := coins.ToExchangeRate(exchangeRateDec)
+	chainID := ctx.Uint64(flagChainID)
 
 	var otherMin, otherMax *apd.Decimal
 	var symbol string
@@ -761,7 +953,7 @@ func runMake(ctx *cli.Context) error {
 			return err
 		}
 	} else {
-		tokenInfo, err := c.TokenInfo(ethAsset.Address()) //nolint:govet
+		tokenInfo, err := c.TokenInfo(ethAsset.Address(), chainID) //nolint:govet
 		if err != nil {
 			return err
 		}
@@ -784,6 +976,9 @@ func runMake(ctx *cli.Context) error {
 		fmt.Printf("\tPeer ID:   %s\n", offerResp.PeerID)
 		fmt.Printf("\tTaker Min: %s %s\n", otherMin.Text('f'), symbol)
 		fmt.Printf("\tTaker Max: %s %s\n", otherMax.Text('f'), symbol)
+		if chainID != 0 {
+			fmt.Printf("\tChain ID:  %d\n", chainID)
+		}
 	}
 
 	alwaysUseRelayer := ctx.Bool(flagUseRelayer)
@@ -797,6 +992,7 @@ func runMake(ctx *cli.Context) error {
 			exchangeRate,
 			ethAsset,
 			alwaysUseRelayer,
+			chainID,
 		)
 		if err != nil {
 			return err
@@ -814,7 +1010,7 @@ func runMake(ctx *cli.Context) error {
 		return nil
 	}
 
-	resp, err := c.MakeOffer(min, max, exchangeRate, ethAsset, alwaysUseRelayer)
+	resp, err := c.MakeOffer(min, max, exchangeRate, ethAsset, alwaysUseRelayer, chainID)
 	if err != nil {
 		return err
 	}
@@ -838,10 +1034,17 @@ func runTake(ctx *cli.Context) error {
 		return err
 	}
 
+	req := &rpctypes.TakeOfferRequest{
+		PeerID:         peerID,
+		OfferID:        offerID,
+		ProvidesAmount: providesAmount,
+		AllowOffMarket: ctx.Bool(flagAllowOffMarket),
+	}
+
 	if !ctx.Bool(flagDetached) {
 		wsc := newClient(ctx)
 
-		statusCh, err := wsc.TakeOfferAndSubscribe(peerID, offerID, providesAmount)
+		statusCh, err := wsc.TakeOfferAndSubscribeWithRequest(req)
 		if err != nil {
 			return err
 		}
@@ -859,7 +1062,7 @@ func runTake(ctx *cli.Context) error {
 	}
 
 	c := newClient(ctx)
-	if err := c.TakeOffer(peerID, offerID, providesAmount); err != nil {
+	if err := c.TakeOfferWithRequest(req); err != nil {
 		return err
 	}
 
@@ -895,7 +1098,7 @@ func runGetOngoingSwap(ctx *cli.Context) error {
 			fmt.Printf("---\n")
 		}
 
-		providedCoin, receivedCoin, err := providedAndReceivedSymbols(c, info.Provided, info.EthAsset)
+		providedCoin, receivedCoin, err := providedAndReceivedSymbols(c, info.Provided, info.EthAsset, info.ChainID)
 		if err != nil {
 			return err
 		}
@@ -918,18 +1121,52 @@ func runGetOngoingSwap(ctx *cli.Context) error {
 }
 
 func runGetPastSwap(ctx *cli.Context) error {
-	var offerID *types.Hash
+	req := &rpc.GetPastRequest{
+		Order:  swap.SortOrder(ctx.String(flagOrder)),
+		Limit:  int(ctx.Uint(flagLimit)),
+		Cursor: ctx.String(flagCursor),
+	}
 
 	if ctx.IsSet(flagOfferID) {
 		hash, err := types.HexToHash(ctx.String(flagOfferID))
 		if err != nil {
 			return errInvalidFlagValue(flagOfferID, err)
 		}
-		offerID = &hash
+		req.OfferID = &hash
+	}
+
+	for _, statusStr := range ctx.StringSlice(flagStatus) {
+		var status types.Status
+		if err := status.UnmarshalText([]byte(statusStr)); err != nil {
+			return errInvalidFlagValue(flagStatus, err)
+		}
+		req.Statuses = append(req.Statuses, status)
+	}
+
+	if ctx.IsSet(flagPeerID) {
+		peerID, err := peer.Decode(ctx.String(flagPeerID))
+		if err != nil {
+			return errInvalidFlagValue(flagPeerID, err)
+		}
+		req.PeerID = peerID
+	}
+
+	if ctx.IsSet(flagToken) {
+		ethAsset := new(types.EthAsset)
+		if err := ethAsset.UnmarshalText([]byte(ctx.String(flagToken))); err != nil {
+			return errInvalidFlagValue(flagToken, err)
+		}
+		req.EthAsset = ethAsset
+	}
+
+	var err error
+	req.From, req.To, err = readTimeRangeFlags(ctx)
+	if err != nil {
+		return err
 	}
 
 	c := newClient(ctx)
-	resp, err := c.GetPastSwap(offerID)
+	resp, err := c.GetPastSwaps(req)
 	if err != nil {
 		return err
 	}
@@ -945,7 +1182,7 @@ func runGetPastSwap(ctx *cli.Context) error {
 			fmt.Printf("---\n")
 		}
 
-		providedCoin, receivedCoin, err := providedAndReceivedSymbols(c, info.Provided, info.EthAsset)
+		providedCoin, receivedCoin, err := providedAndReceivedSymbols(c, info.Provided, info.EthAsset, info.ChainID)
 		if err != nil {
 			return err
 		}
@@ -956,7 +1193,7 @@ func runGetPastSwap(ctx *cli.Context) error {
 		}
 
 		receivedAmt := info.ExpectedAmount
-		if info.RelayerFee != nil {
+		if info.RelayerFee != nil && receivedAmt != nil {
 			receivedAmt = new(apd.Decimal)
 			_, err = coins.DecimalCtx().Sub(receivedAmt, info.ExpectedAmount, info.RelayerFee)
 			if err != nil {
@@ -967,22 +1204,56 @@ func runGetPastSwap(ctx *cli.Context) error {
 		fmt.Printf("ID: %s\n", info.ID)
 		fmt.Printf("Start time: %s\n", info.StartTime.Format(common.TimeFmtSecs))
 		fmt.Printf("End time: %s\n", endTime)
-		fmt.Printf("Provided: %s %s\n", info.ProvidedAmount.Text('f'), providedCoin)
-		fmt.Printf("Received: %s %s", receivedAmt.Text('f'), receivedCoin)
-		if info.RelayerFee != nil {
+		fmt.Printf("Provided: %s %s\n", decimalOrUnknown(info.ProvidedAmount), providedCoin)
+		fmt.Printf("Received: %s %s", decimalOrUnknown(receivedAmt), receivedCoin)
+		if info.RelayerFee != nil && info.ExpectedAmount != nil {
 			fmt.Printf(" (%s %s - %s %s relayer fee)",
 				info.ExpectedAmount.Text('f'), receivedCoin,
 				info.RelayerFee.Text('f'), receivedCoin,
 			)
 		}
 		fmt.Printf("\n")
-		fmt.Printf("Exchange Rate: %s XMR/ETH\n", info.ExchangeRate)
+		exchangeRate := "unknown"
+		if info.ExchangeRate != nil {
+			exchangeRate = info.ExchangeRate.String()
+		}
+		fmt.Printf("Exchange Rate: %s XMR/ETH\n", exchangeRate)
 		fmt.Printf("Status: %s\n", info.Status)
+		if info.Rebuilt {
+			fmt.Printf("Rebuilt from chain events: yes\n")
+		}
+		if info.PeerAbort != nil {
+			fmt.Printf("Aborted by peer: %s (peer status %s)\n", info.PeerAbort.Reason, info.PeerAbort.Status)
+		}
+	}
+
+	if resp.NextCursor != "" {
+		fmt.Printf("---\nMore swaps may be available, use --%s %s for the next page\n", flagCursor, resp.NextCursor)
 	}
 
 	return nil
 }
 
+func runExportHistory(ctx *cli.Context) error {
+	format := ctx.String(flagFormat)
+	if format != exportFormatCSV && format != exportFormatJSON {
+		return errInvalidFlagValue(flagFormat, fmt.Errorf("unsupported format %q", format))
+	}
+
+	from, to, err := readTimeRangeFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	c := newClient(ctx)
+	resp, err := c.ExportHistory(from, to)
+	if err != nil {
+		return err
+	}
+
+	return writeHistory(os.Stdout, format, resp.Swaps)
+}
+
 func runCancel(ctx *cli.Context) error {
 	offerID, err := types.HexToHash(ctx.String(flagOfferID))
 	if err != nil {
@@ -1041,7 +1312,7 @@ func runGetOffers(ctx *cli.Context) error {
 	fmt.Println("Peer ID (self):", resp.PeerID)
 	fmt.Println("Offers:")
 	for i, offer := range resp.Offers {
-		err = printOffer(c, offer, i, "  ")
+		err = printOffer(c, offer, nil, i, "  ")
 		if err != nil {
 			return err
 		}
@@ -1070,6 +1341,60 @@ func runGetStatus(ctx *cli.Context) error {
 	return nil
 }
 
+func runGetJournal(ctx *cli.Context) error {
+	offerIDStr := ctx.Args().First()
+	if offerIDStr == "" {
+		offerIDStr = ctx.String(flagOfferID)
+	}
+	if offerIDStr == "" {
+		return errors.New("offer ID is required")
+	}
+
+	offerID, err := types.HexToHash(offerIDStr)
+	if err != nil {
+		return errInvalidFlagValue(flagOfferID, err)
+	}
+
+	c := newClient(ctx)
+	resp, err := c.GetJournal(offerID)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Entries) == 0 {
+		fmt.Println("[none]")
+		return nil
+	}
+
+	for _, e := range resp.Entries {
+		fmt.Printf("%s  %-16s %s\n", e.Time.Format(common.TimeFmtSecs), e.Type, journalEntryDetail(e))
+	}
+
+	return nil
+}
+
+func journalEntryDetail(e *swap.JournalEntry) string {
+	switch e.Type {
+	case swap.JournalStatus:
+		if e.Status != nil {
+			return e.Status.String()
+		}
+	case swap.JournalMessageSent, swap.JournalMessageReceived:
+		return e.MessageType
+	case swap.JournalEthTx:
+		if e.TxHash != nil {
+			return fmt.Sprintf("%s %s", e.TxType, e.TxHash)
+		}
+		return e.TxType
+	case swap.JournalError:
+		if e.ErrorClass != "" {
+			return fmt.Sprintf("%s: %s", e.ErrorClass, e.Detail)
+		}
+	}
+
+	return e.Detail
+}
+
 func runClaim(ctx *cli.Context) error {
 	offerID, err := types.HexToHash(ctx.String(flagOfferID))
 	if err != nil {
@@ -1130,19 +1455,62 @@ func runGetSwapTimeout(ctx *cli.Context) error {
 }
 
 func runSuggestedExchangeRate(ctx *cli.Context) error {
+	ethAsset := types.EthAssetETH
+	if ctx.IsSet(flagToken) {
+		tokenAddr, err := cliutil.ReadETHAddress(ctx, flagToken)
+		if err != nil {
+			return err
+		}
+		ethAsset = types.EthAsset(tokenAddr)
+	}
+
 	c := newClient(ctx)
-	resp, err := c.SuggestedExchangeRate()
+	resp, err := c.SuggestedExchangeRate(ethAsset)
 	if err != nil {
 		return err
 	}
 
+	if resp.Token != nil {
+		tokenPair := resp.TokenSymbol + "/USD"
+		fmt.Printf("Exchange rate: %s %s/XMR\n", resp.ExchangeRate, resp.Token.SanitizedSymbol())
+		fmt.Printf("XMR/USD Price: %-13s (%s)\n", resp.XMRPrice, resp.XMRUpdatedAt)
+		fmt.Printf("%-8s Price: %-13s (%s)\n", tokenPair, resp.TokenPrice, resp.TokenUpdatedAt)
+
+		if ctx.Bool(flagSources) {
+			printPriceSources("XMR/USD", resp.XMRSources)
+			printPriceSources(tokenPair, resp.TokenSources)
+		}
+		return nil
+	}
+
 	fmt.Printf("Exchange rate: %s\n", resp.ExchangeRate)
 	fmt.Printf("XMR/USD Price: %-13s (%s)\n", resp.XMRPrice, resp.XMRUpdatedAt)
 	fmt.Printf("ETH/USD Price: %-13s (%s)\n", resp.ETHPrice, resp.ETHUpdatedAt)
 
+	if ctx.Bool(flagSources) {
+		printPriceSources("XMR/USD", resp.XMRSources)
+		printPriceSources("ETH/USD", resp.ETHSources)
+	}
+
 	return nil
 }
 
+func printPriceSources(pair string, sources []*pricefeed.SourcePrice) {
+	fmt.Printf("\n%s sources:\n", pair)
+	for _, src := range sources {
+		if src.Price == nil {
+			fmt.Printf("\t%-20s failed: %s\n", src.Source, src.Error)
+			continue
+		}
+
+		fmt.Printf("\t%-20s %-13s (%s)", src.Source, src.Price, src.UpdatedAt)
+		if !src.Used {
+			fmt.Printf(" rejected: %s", src.Error)
+		}
+		fmt.Println()
+	}
+}
+
 func runGetVersions(ctx *cli.Context) error {
 	fmt.Printf("swapcli: %s\n", cliutil.GetVersion())
 
@@ -1172,6 +1540,22 @@ func runShutdown(ctx *cli.Context) error {
 	return nil
 }
 
+func runReload(ctx *cli.Context) error {
+	c := newClient(ctx)
+	resp, err := c.Reload()
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Changed) == 0 {
+		fmt.Println("Configuration reloaded, nothing changed")
+		return nil
+	}
+
+	fmt.Printf("Configuration reloaded, changed: %s\n", strings.Join(resp.Changed, ", "))
+	return nil
+}
+
 func runGetContractSwapInfo(ctx *cli.Context) error {
 	offerID, err := types.HexToHash(ctx.String(flagOfferID))
 	if err != nil {
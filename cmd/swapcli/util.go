@@ -12,31 +12,39 @@ import (
 	"github.com/athanorlabs/atomic-swap/rpcclient"
 )
 
+type tokenKey struct {
+	chainID uint64
+	addr    ethcommon.Address
+}
+
 // _tokenCache should only be directly accessed by lookupToken
-var _tokenCache = make(map[ethcommon.Address]*coins.ERC20TokenInfo)
+var _tokenCache = make(map[tokenKey]*coins.ERC20TokenInfo)
 
-func lookupToken(c *rpcclient.Client, tokenAddr ethcommon.Address) (*coins.ERC20TokenInfo, error) {
-	token, ok := _tokenCache[tokenAddr]
+// lookupToken returns the token's metadata on the chain with the given ID,
+// where zero is the primary chain of swapd.
+func lookupToken(c *rpcclient.Client, tokenAddr ethcommon.Address, chainID uint64) (*coins.ERC20TokenInfo, error) {
+	key := tokenKey{chainID: chainID, addr: tokenAddr}
+	token, ok := _tokenCache[key]
 	if ok {
 		return token, nil
 	}
 
-	token, err := c.TokenInfo(tokenAddr)
+	token, err := c.TokenInfo(tokenAddr, chainID)
 	if err != nil {
 		return nil, err
 	}
 
-	_tokenCache[tokenAddr] = token
+	_tokenCache[key] = token
 
 	return token, nil
 }
 
-func ethAssetSymbol(c *rpcclient.Client, ethAsset types.EthAsset) (string, error) {
+func ethAssetSymbol(c *rpcclient.Client, ethAsset types.EthAsset, chainID uint64) (string, error) {
 	if ethAsset.IsETH() {
 		return "ETH", nil
 	}
 
-	token, err := lookupToken(c, ethAsset.Address())
+	token, err := lookupToken(c, ethAsset.Address(), chainID)
 	if err != nil {
 		return "", err
 	}
@@ -50,8 +58,9 @@ func providedAndReceivedSymbols(
 	c *rpcclient.Client,
 	provides coins.ProvidesCoin, // determines whether we are the maker or taker
 	ethAsset types.EthAsset, // determines provided or received ETH asset symbol
+	chainID uint64, // chain of the ETH asset, zero for the primary chain
 ) (string, string, error) {
-	ethAssetSymbol, err := ethAssetSymbol(c, ethAsset)
+	ethAssetSymbol, err := ethAssetSymbol(c, ethAsset, chainID)
 	if err != nil {
 		return "", "", err
 	}
@@ -66,7 +75,9 @@ func providedAndReceivedSymbols(
 	}
 }
 
-func printOffer(c *rpcclient.Client, o *types.Offer, index int, indent string) error {
+// printOffer prints the offer. The premium of the offer's exchange rate over
+// the market rate, in percent, is printed if it is not nil.
+func printOffer(c *rpcclient.Client, o *types.Offer, premium *apd.Decimal, index int, indent string) error {
 	if index > 0 {
 		fmt.Printf("%s---\n", indent)
 	}
@@ -88,7 +99,7 @@ func printOffer(c *rpcclient.Client, o *types.Offer, index int, indent string) e
 			return err
 		}
 	} else {
-		token, err := lookupToken(c, o.EthAsset.Address()) //nolint:govet
+		token, err := lookupToken(c, o.EthAsset.Address(), o.ChainID) //nolint:govet
 		if err != nil {
 			return err
 		}
@@ -107,7 +118,7 @@ func printOffer(c *rpcclient.Client, o *types.Offer, index int, indent string) e
 	// At the current time, offers always have the "Provides" field set to
 	// ProvidesXMR, so the Provides/Takes fields below are always from the
 	// perspective of the Maker.
-	providedCoin, receivedCoin, err := providedAndReceivedSymbols(c, o.Provides, o.EthAsset)
+	providedCoin, receivedCoin, err := providedAndReceivedSymbols(c, o.Provides, o.EthAsset, o.ChainID)
 	if err != nil {
 		return err
 	}
@@ -115,10 +126,16 @@ func printOffer(c *rpcclient.Client, o *types.Offer, index int, indent string) e
 	fmt.Printf("%sOffer ID: %s\n", indent, o.ID)
 	fmt.Printf("%sProvides: %s\n", indent, providedCoin)
 	fmt.Printf("%sTakes: %s\n", indent, o.EthAsset)
+	if o.ChainID != 0 {
+		fmt.Printf("%sChain ID: %d\n", indent, o.ChainID)
+	}
 	if o.EthAsset.IsToken() {
 		fmt.Printf("%s       %s (self reported symbol)\n", indent, receivedCoin)
 	}
 	fmt.Printf("%sExchange Rate: %s %s/%s\n", indent, o.ExchangeRate, receivedCoin, providedCoin)
+	if premium != nil {
+		fmt.Printf("%sMarket Premium: %s%%\n", indent, formatPremium(premium))
+	}
 	fmt.Printf("%sMaker Min: %s %s\n", indent, o.MinAmount.Text('f'), providedCoin)
 	fmt.Printf("%sMaker Max: %s %s\n", indent, o.MaxAmount.Text('f'), providedCoin)
 	fmt.Printf("%sTaker Min: %s %s\n", indent, minTake.Text('f'), receivedCoin)
@@ -126,6 +143,14 @@ func printOffer(c *rpcclient.Client, o *types.Offer, index int, indent string) e
 	return nil
 }
 
+// formatPremium formats a premium percentage with an explicit sign.
+func formatPremium(premium *apd.Decimal) string {
+	if premium.Sign() > 0 {
+		return "+" + premium.Text('f')
+	}
+	return premium.Text('f')
+}
+
 func queryEnv(c *rpcclient.Client) (common.Environment, error) {
 	verResp, err := c.Version()
 	if err != nil {
@@ -12,12 +12,12 @@ func (s *swapCLITestSuite) Test_lookupToken() {
 	c := s.aliceClient()
 
 	// First call triggers a lookup (assuming not cached yet)
-	token1, err := lookupToken(c, s.mockDaiAddr())
+	token1, err := lookupToken(c, s.mockDaiAddr(), 0)
 	require.NoError(s.T(), err)
 	require.NotNil(s.T(), token1)
 
 	// Second call hits the cache
-	token2, err := lookupToken(c, s.mockDaiAddr())
+	token2, err := lookupToken(c, s.mockDaiAddr(), 0)
 	require.NoError(s.T(), err)
 	require.NotNil(s.T(), token1)
 
@@ -25,17 +25,17 @@ func (s *swapCLITestSuite) Test_lookupToken() {
 	require.True(s.T(), token1 == token2)
 
 	invalidAddr := ethcommon.Address{0x1}
-	_, err = lookupToken(c, invalidAddr)
+	_, err = lookupToken(c, invalidAddr, 0)
 	require.ErrorContains(s.T(), err, "no contract code at given address")
 }
 
 func (s *swapCLITestSuite) Test_ethAssetSymbol() {
 	c := s.aliceClient()
-	symbol, err := ethAssetSymbol(c, types.EthAssetETH)
+	symbol, err := ethAssetSymbol(c, types.EthAssetETH, 0)
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), symbol, "ETH")
 
-	symbol, err = ethAssetSymbol(c, types.EthAsset(s.mockTetherAddr()))
+	symbol, err = ethAssetSymbol(c, types.EthAsset(s.mockTetherAddr()), 0)
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), symbol, `"USDT"`) // quoted at the current time
 }
@@ -44,14 +44,14 @@ func (s *swapCLITestSuite) Test_providedAndReceivedSymbols() {
 	c := s.bobClient()
 
 	// 2nd parameter says we are the maker
-	providedSym, receivedSym, err := providedAndReceivedSymbols(c, coins.ProvidesXMR, types.EthAssetETH)
+	providedSym, receivedSym, err := providedAndReceivedSymbols(c, coins.ProvidesXMR, types.EthAssetETH, 0)
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), providedSym, "XMR")
 	require.Equal(s.T(), receivedSym, "ETH")
 
 	// 2nd parameter says we are the taker, but not necessarily that the ETH asset is ETH
 	ethAsset := types.EthAsset(s.mockTetherAddr())
-	providedSym, receivedSym, err = providedAndReceivedSymbols(c, coins.ProvidesETH, ethAsset)
+	providedSym, receivedSym, err = providedAndReceivedSymbols(c, coins.ProvidesETH, ethAsset, 0)
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), providedSym, `"USDT"`)
 	require.Equal(s.T(), receivedSym, "XMR")
@@ -68,6 +68,6 @@ func (s *swapCLITestSuite) Test_printOffer() {
 		types.EthAsset(s.mockTetherAddr()),
 	)
 
-	err := printOffer(c, o, 0, "")
+	err := printOffer(c, o, coins.StrToDecimal("-1.25"), 0, "")
 	require.NoError(s.T(), err)
 }
@@ -0,0 +1,133 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/cliutil"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+)
+
+// parseChainFlags parses the values of the --chain flag, which have the form
+// NAME:ENDPOINT:SWAP_CREATOR_ADDRESS. The name can also be a chain ID. The
+// endpoint can contain colons, so the name is everything before the first
+// colon and the address everything after the last one.
+func parseChainFlags(env common.Environment, values []string) ([]*common.Chain, error) {
+	var configs []*cliutil.ChainConfig
+
+	for _, value := range values {
+		first := strings.Index(value, ":")
+		last := strings.LastIndex(value, ":")
+		if first < 0 || first == last {
+			return nil, fmt.Errorf("--%s value %q is not of the form NAME:ENDPOINT:SWAP_CREATOR_ADDRESS",
+				flagChain, value)
+		}
+
+		configs = append(configs, &cliutil.ChainConfig{
+			Name:               value[:first],
+			Endpoint:           value[first+1 : last],
+			SwapCreatorAddress: value[last+1:],
+		})
+	}
+
+	return resolveChains(env, configs)
+}
+
+// resolveChains returns the registered chains of the configs, from the --chain
+// flag or the config file, with their endpoints and SwapCreator addresses set.
+func resolveChains(env common.Environment, configs []*cliutil.ChainConfig) ([]*common.Chain, error) {
+	var chains []*common.Chain
+	seen := make(map[uint64]bool)
+
+	for _, cfg := range configs {
+		var chain *common.Chain
+		var err error
+		if chainID, parseErr := strconv.ParseUint(cfg.Name, 10, 64); parseErr == nil {
+			chain, err = common.ChainByID(env, chainID)
+		} else {
+			chain, err = common.ChainByName(env, cfg.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if chain.ChainID == common.PrimaryChain(env).ChainID {
+			return nil, fmt.Errorf("%s is the primary chain, use --%s and --%s to configure it",
+				chain, flagEthEndpoint, flagContractAddress)
+		}
+		if seen[chain.ChainID] {
+			return nil, fmt.Errorf("chain set more than once for %s", chain)
+		}
+		seen[chain.ChainID] = true
+
+		if cfg.Endpoint == "" {
+			return nil, fmt.Errorf("chain %s is missing the endpoint", chain)
+		}
+		if !ethcommon.IsHexAddress(cfg.SwapCreatorAddress) {
+			return nil, fmt.Errorf("chain %s requires a valid SwapCreator address", chain)
+		}
+
+		chain.EthEndpoint = cfg.Endpoint
+		chain.SwapCreatorAddr = ethcommon.HexToAddress(cfg.SwapCreatorAddress)
+		chains = append(chains, chain)
+	}
+
+	return chains, nil
+}
+
+// createChainClients creates an ethereum client, with the same key as the
+// client of the primary chain, for each additional chain of the config and
+// validates the chain's SwapCreator contract. The clients use the gas price and
+// limit flags like the primary client. They are keyed by chain ID.
+func createChainClients(
+	c *cli.Context,
+	envConf *common.Config,
+	proxy *netproxy.Proxy,
+	ec extethclient.EthClient,
+) (map[uint64]extethclient.EthClient, error) {
+	ctx := c.Context
+	clients := make(map[uint64]extethclient.EthClient)
+
+	closeAll := func() {
+		for _, client := range clients {
+			client.Close()
+		}
+	}
+
+	for _, chain := range envConf.Chains {
+		client, err := extethclient.NewEthClientForChain(
+			ctx,
+			envConf.Env,
+			chain.ChainID,
+			chain.EthEndpoint,
+			proxy,
+			ec.PrivateKey(),
+		)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to connect to %s: %w", chain, err)
+		}
+		clients[chain.ChainID] = client
+		client.SetGasPrice(uint64(c.Uint(flagGasPrice)))
+		client.SetGasLimit(uint64(c.Uint(flagGasLimit)))
+
+		if err = contracts.CheckSwapCreatorContractCode(ctx, client.Raw(), chain.SwapCreatorAddr); err != nil {
+			closeAll()
+			return nil, fmt.Errorf("invalid SwapCreator contract on %s: %w", chain, err)
+		}
+
+		log.Infof("Swaps enabled on %s with SwapCreator %s", chain, chain.SwapCreatorAddr)
+	}
+
+	return clients, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common"
+)
+
+func TestParseChainFlags(t *testing.T) {
+	const addr = "0x377ed3a60007048DF00135637521170628De89E5"
+
+	chains, err := parseChainFlags(common.Mainnet, []string{
+		"arbitrum:https://arb1.example.org:8545:" + addr,
+		"8453:http://127.0.0.1:9545:" + addr,
+	})
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+
+	require.Equal(t, uint64(common.ArbitrumChainID), chains[0].ChainID)
+	require.Equal(t, "https://arb1.example.org:8545", chains[0].EthEndpoint)
+	require.Equal(t, ethcommon.HexToAddress(addr), chains[0].SwapCreatorAddr)
+
+	require.Equal(t, "Base", chains[1].Name)
+	require.Equal(t, "http://127.0.0.1:9545", chains[1].EthEndpoint)
+}
+
+func TestParseChainFlags_errors(t *testing.T) {
+	const addr = "0x377ed3a60007048DF00135637521170628De89E5"
+
+	for _, tc := range []struct {
+		values      []string
+		errContains string
+	}{
+		{[]string{"arbitrum"}, "is not of the form NAME:ENDPOINT:SWAP_CREATOR_ADDRESS"},
+		{[]string{"arbitrum:" + addr}, "is not of the form NAME:ENDPOINT:SWAP_CREATOR_ADDRESS"},
+		{[]string{"sepolia:http://localhost:" + addr}, `chain "sepolia" is not supported in the mainnet environment`},
+		{[]string{"mainnet:http://localhost:" + addr}, "is the primary chain"},
+		{[]string{"base::" + addr}, "missing the endpoint"},
+		{[]string{"base:http://localhost:0x1234"}, "requires a valid SwapCreator address"},
+		{[]string{"base:http://a:" + addr, "BASE:http://b:" + addr}, "set more than once for Base"},
+	} {
+		_, err := parseChainFlags(common.Mainnet, tc.values)
+		require.ErrorContains(t, err, tc.errContains, tc.values)
+	}
+}
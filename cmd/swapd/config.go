@@ -0,0 +1,209 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/cliutil"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/rpc"
+)
+
+// redacted replaces the values of secrets in the printed configuration.
+const redacted = "<redacted>"
+
+// applyConfigFile loads the config file, if one was passed, and sets every
+// flag that the file sets and that was not set on the command line or by an
+// environment variable.
+func applyConfigFile(c *cli.Context) (*cliutil.ConfigFile, error) {
+	cf, err := cliutil.LoadConfigFileFromContext(c)
+	if err != nil || cf == nil {
+		return nil, err
+	}
+
+	_, hasChainFlag := cf.Flags[flagChain]
+	if hasChainFlag && len(cf.Chains) > 0 {
+		return nil, fmt.Errorf("config file %s: %q and %q are mutually exclusive", cf.Path, flagChain, "chains")
+	}
+
+	_, hasHost := cf.Flags[flagMoneroDaemonHost]
+	_, hasPort := cf.Flags[flagMoneroDaemonPort]
+	if (hasHost || hasPort) && len(cf.MoneroNodes) > 0 {
+		return nil, fmt.Errorf("config file %s: %q and %q are mutually exclusive, use %q for all nodes",
+			cf.Path, flagMoneroDaemonHost, "monero-nodes", "monero-nodes")
+	}
+
+	if err = cf.ApplyFlags(c, c.App.Flags); err != nil {
+		return nil, err
+	}
+
+	// The relayer section replaces the relayer flag in the file
+	if cf.Relayer != nil && !c.IsSet(flagRelayer) {
+		if err = c.Set(flagRelayer, strconv.FormatBool(cf.Relayer.Enabled)); err != nil {
+			return nil, err
+		}
+	}
+
+	return cf, nil
+}
+
+// getRPCCredentials returns the credentials that RPC requests must provide,
+// or nil if none are configured.
+func getRPCCredentials(cf *cliutil.ConfigFile) (*rpc.Credentials, error) {
+	username, password := cf.RPCCredentials()
+	if username == "" && password == "" {
+		return nil, nil
+	}
+
+	if username == "" || password == "" {
+		return nil, errors.New("RPC credentials require both a username and a password")
+	}
+
+	return &rpc.Credentials{Username: username, Password: password}, nil
+}
+
+// printConfig prints the effective configuration, after the config file, flags
+// and environment variables are applied, as a TOML config file. Secrets are
+// redacted.
+func printConfig(c *cli.Context, cf *cliutil.ConfigFile) error {
+	effective, _, err := effectiveConfig(c, cf, true)
+	if err != nil {
+		return err
+	}
+
+	return toml.NewEncoder(c.App.Writer).Encode(effective)
+}
+
+// effectiveConfig returns the effective configuration, keyed like a config
+// file, and the environment config it resolves to. Secrets are replaced with
+// `redacted` if redact is set.
+func effectiveConfig(
+	c *cli.Context,
+	cf *cliutil.ConfigFile,
+	redact bool,
+) (map[string]any, *common.Config, error) {
+	devXMRMaker := c.Bool(flagDevXMRMaker)
+	devXMRTaker := c.Bool(flagDevXMRTaker)
+	if devXMRMaker && devXMRTaker {
+		return nil, nil, errFlagsMutuallyExclusive(flagDevXMRMaker, flagDevXMRTaker)
+	}
+
+	envConf, err := resolveEnvConfig(c, cf, devXMRMaker, devXMRTaker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	effective := make(map[string]any)
+
+	for _, f := range c.App.Flags {
+		name := f.Names()[0]
+		switch name {
+		case flagConfig, flagPrintConfig, cli.HelpFlag.Names()[0], cli.VersionFlag.Names()[0]:
+			continue
+		case flagMoneroDaemonHost, flagMoneroDaemonPort, flagChain, flagRelayer:
+			continue // printed in their config file sections below
+		}
+
+		if vf, ok := f.(cli.VisibleFlag); ok && !vf.IsVisible() && !c.IsSet(name) {
+			continue
+		}
+
+		switch f.(type) {
+		case *cli.StringFlag:
+			effective[name] = c.String(name)
+		case *cli.UintFlag:
+			effective[name] = c.Uint(name)
+		case *cli.BoolFlag:
+			effective[name] = c.Bool(name)
+		case *cli.StringSliceFlag:
+			effective[name] = append([]string{}, c.StringSlice(name)...)
+		default:
+			return nil, nil, fmt.Errorf("flag %q has unsupported type %T", name, f)
+		}
+	}
+
+	// Replace the flag defaults that swapd resolves at startup
+	rpcPort, libp2pPort := getPorts(c)
+	effective[flagRPCPort] = rpcPort
+	effective[flagLibp2pPort] = libp2pPort
+	effective[flagEnv] = envConf.Env.String()
+	effective[flagDataDir] = envConf.DataDir
+	effective[flagBootnodes] = append([]string{}, envConf.Bootnodes...)
+
+	defaults := map[string]string{
+		flagLibp2pKey:        envConf.LibP2PKeyFile(),
+		flagSwapSeed:         envConf.SwapKeySeedFile(),
+		flagMoneroWalletPath: envConf.MoneroWalletPath(),
+		flagEthEndpoint:      envConf.EthEndpoint,
+		flagEthPrivKey:       envConf.EthKeyFileName(),
+		flagMaxOfferPremium:  defaultMaxOfferPremium,
+	}
+	if envConf.Env == common.Development {
+		defaults[flagMaxOfferPremium] = "0"
+	}
+	for name, value := range defaults {
+		if !c.IsSet(name) {
+			effective[name] = value
+		}
+	}
+
+	if c.Bool(flagUseExternalSigner) {
+		delete(effective, flagEthPrivKey)
+	}
+	if c.Bool(flagDeploy) {
+		delete(effective, flagContractAddress)
+	} else {
+		effective[flagContractAddress] = envConf.SwapCreatorAddr.Hex()
+	}
+
+	if redact && c.String(flagMoneroWalletPassword) != "" {
+		effective[flagMoneroWalletPassword] = redacted
+	}
+	if redact && strings.Contains(c.String(flagProxy), "@") {
+		effective[flagProxy] = redacted // has proxy credentials
+	}
+
+	var nodes []*cliutil.MoneroNodeConfig
+	for _, n := range envConf.MoneroNodes {
+		nodes = append(nodes, &cliutil.MoneroNodeConfig{Host: n.Host, Port: n.Port})
+	}
+	if len(nodes) > 0 {
+		effective["monero-nodes"] = nodes
+	}
+
+	var chains []*cliutil.ChainConfig
+	for _, chain := range envConf.Chains {
+		chains = append(chains, &cliutil.ChainConfig{
+			Name:               chain.Name,
+			Endpoint:           chain.EthEndpoint,
+			SwapCreatorAddress: chain.SwapCreatorAddr.Hex(),
+		})
+	}
+	if len(chains) > 0 {
+		effective["chains"] = chains
+	}
+
+	effective["relayer"] = &cliutil.RelayerConfig{Enabled: c.Bool(flagRelayer)}
+
+	creds, err := getRPCCredentials(cf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if creds != nil {
+		rpcConf := &cliutil.RPCConfig{Username: creds.Username, Password: creds.Password}
+		if redact {
+			rpcConf.Password = redacted
+		}
+		effective["rpc"] = rpcConf
+	}
+
+	return effective, envConf, nil
+}
@@ -6,24 +6,31 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 
+	"github.com/cockroachdb/apd/v3"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/urfave/cli/v2"
 
 	"github.com/athanorlabs/atomic-swap/cliutil"
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/daemon"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
 	"github.com/athanorlabs/atomic-swap/monero"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
 )
 
 const (
@@ -36,6 +43,10 @@ const (
 	defaultRPCPort         = common.DefaultSwapdPort
 	defaultXMRTakerRPCPort = defaultRPCPort
 	defaultXMRMakerRPCPort = defaultXMRTakerRPCPort + 1
+
+	// defaultMaxOfferPremium is the default maximum percentage difference
+	// between the exchange rate of a taken offer and the market rate
+	defaultMaxOfferPremium = "10"
 )
 
 var (
@@ -54,6 +65,7 @@ const (
 	flagRPCPort    = "rpc-port"
 	flagDataDir    = "data-dir"
 	flagLibp2pKey  = "libp2p-key"
+	flagSwapSeed   = "swap-seed"
 	flagLibp2pPort = "libp2p-port"
 	flagBootnodes  = "bootnodes"
 
@@ -66,18 +78,33 @@ const (
 	flagEthEndpoint          = "eth-endpoint"
 	flagEthPrivKey           = "eth-privkey"
 	flagContractAddress      = "contract-address"
+	flagChain                = "chain"
 	flagGasPrice             = "gas-price"
 	flagGasLimit             = "gas-limit"
 	flagUseExternalSigner    = "external-signer"
 	flagRelayer              = "relayer"
+	flagPriceHistoryFile     = "price-history-file"
+	flagPriceSourcesFile     = "price-sources-file"
+	flagWebhookURL           = "webhook-url"
+	flagWebhookSecretFile    = "webhook-secret-file"
+	flagLowBalanceAlert      = "low-balance-alert"
+	flagMaxOfferPremium      = "max-offer-premium"
+	flagAllowPeer            = "allow-peer"
+	flagDenyPeer             = "deny-peer"
+	flagAllowToken           = "allow-token"
+	flagDenyToken            = "deny-token"
+	flagProxy                = cliutil.FlagProxy
+	flagProxyStrict          = cliutil.FlagProxyStrict
 
 	flagDevXMRTaker    = "dev-xmrtaker"
 	flagDevXMRMaker    = "dev-xmrmaker"
 	flagDeploy         = "deploy"
 	flagNoTransferBack = "no-transfer-back"
 
-	flagLogLevel = cliutil.FlagLogLevel
-	flagProfile  = "profile"
+	flagConfig      = cliutil.FlagConfig
+	flagPrintConfig = "print-config"
+	flagLogLevel    = cliutil.FlagLogLevel
+	flagProfile     = "profile"
 )
 
 func cliApp() *cli.App {
@@ -89,6 +116,15 @@ func cliApp() *cli.App {
 		EnableBashCompletion: true,
 		Suggest:              true,
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    flagConfig,
+				Usage:   "TOML or YAML (.yaml or .yml extension) config file, flags and environment variables override it",
+				EnvVars: []string{"SWAPD_CONFIG"},
+			},
+			&cli.BoolFlag{
+				Name:  flagPrintConfig,
+				Usage: "Print the effective configuration, with secrets redacted, and exit",
+			},
 			&cli.UintFlag{
 				Name:    flagRPCPort,
 				Usage:   "Port for the daemon RPC server to run on",
@@ -105,6 +141,11 @@ func cliApp() *cli.App {
 				Usage: "libp2p private key",
 				Value: fmt.Sprintf("{DATA_DIR}/%s", common.DefaultLibp2pKeyFileName),
 			},
+			&cli.StringFlag{
+				Name:  flagSwapSeed,
+				Usage: "File containing the hex encoded master seed that swap keys are derived from",
+				Value: fmt.Sprintf("{DATA_DIR}/%s", common.DefaultSwapKeySeedFileName),
+			},
 			&cli.UintFlag{
 				Name:    flagLibp2pPort,
 				Usage:   "libp2p port to listen on",
@@ -160,6 +201,11 @@ func cliApp() *cli.App {
 				Name:  flagContractAddress,
 				Usage: "Address of instance of SwapCreator.sol already deployed on-chain",
 			},
+			&cli.StringSliceFlag{
+				Name: flagChain,
+				Usage: "Additional chain to make and take offers on, as NAME:ENDPOINT:SWAP_CREATOR_ADDRESS" +
+					" (eg. arbitrum:https://arb1.example.org:0x...). Can be passed multiple times",
+			},
 			&cli.StringSliceFlag{
 				Name:    flagBootnodes,
 				Aliases: []string{"bn"},
@@ -208,6 +254,64 @@ func cliApp() *cli.App {
 				),
 				Value: false,
 			},
+			&cli.StringFlag{
+				Name:  flagPriceHistoryFile,
+				Usage: "JSON file of historical USD prices used to value past swaps when on-chain history is unavailable",
+			},
+			&cli.StringFlag{
+				Name:  flagPriceSourcesFile,
+				Usage: "JSON file of price sources added to the defaults, and of their aggregation settings",
+			},
+			&cli.StringSliceFlag{
+				Name:    flagWebhookURL,
+				Usage:   "URL to POST swap and offer event notifications to, comma separated if passing multiple",
+				EnvVars: []string{"SWAPD_WEBHOOK_URLS"},
+			},
+			&cli.StringFlag{
+				Name:  flagWebhookSecretFile,
+				Usage: "File containing the shared secret that webhook notifications are signed with",
+			},
+			&cli.StringFlag{
+				Name:  flagLowBalanceAlert,
+				Usage: "Send a webhook notification when the ETH balance drops below this amount",
+			},
+			&cli.StringFlag{
+				Name: flagMaxOfferPremium,
+				Usage: fmt.Sprintf(
+					"Refuse to take offers whose exchange rate differs from the market rate by more than this "+
+						"percentage, 0 disables the check (default: %s, disabled with --%s=dev)",
+					defaultMaxOfferPremium, flagEnv,
+				),
+			},
+			&cli.StringSliceFlag{
+				Name:  flagAllowPeer,
+				Usage: "Only accept swaps and queries from this peer ID, comma separated if passing multiple",
+			},
+			&cli.StringSliceFlag{
+				Name:  flagDenyPeer,
+				Usage: "Refuse swaps and queries from this peer ID, comma separated if passing multiple",
+			},
+			&cli.StringSliceFlag{
+				Name: flagAllowToken,
+				Usage: "Only swap these ERC20 tokens, as ADDRESS on the primary chain or CHAIN:ADDRESS, " +
+					"comma separated if passing multiple",
+			},
+			&cli.StringSliceFlag{
+				Name: flagDenyToken,
+				Usage: "Never swap these ERC20 tokens, as ADDRESS on the primary chain or CHAIN:ADDRESS, " +
+					"comma separated if passing multiple",
+			},
+			&cli.StringFlag{
+				Name: flagProxy,
+				Usage: "SOCKS5 proxy, like a local Tor daemon, for the ethereum, price feed, monerod, webhook " +
+					"and outbound libp2p connections, as socks5://[USER:PASSWORD@]HOST:PORT",
+				EnvVars: []string{"SWAPD_PROXY"},
+			},
+			&cli.BoolFlag{
+				Name:    flagProxyStrict,
+				Usage:   fmt.Sprintf("Refuse connections that can't go through the --%s", flagProxy),
+				EnvVars: []string{"SWAPD_PROXY_STRICT"},
+			},
 			&cli.StringFlag{
 				Name:   flagProfile,
 				Usage:  "BIND_IP:PORT to provide profiling information on",
@@ -221,7 +325,7 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go cliutil.SignalHandler(ctx, cancel, log)
+	go cliutil.SignalHandlerWithReload(ctx, cancel, log, reloadRequests)
 
 	err := cliApp().RunContext(ctx, os.Args)
 	if err != nil {
@@ -235,11 +339,31 @@ func runDaemon(c *cli.Context) error {
 		return fmt.Errorf("unknown command %q", c.Args().First())
 	}
 
-	if err := cliutil.SetLogLevelsFromContext(c); err != nil {
+	// recorded before the config file sets more flags
+	args, err := overrideArgs(c)
+	if err != nil {
 		return err
 	}
 
-	if err := maybeStartProfiler(c); err != nil {
+	cf, err := applyConfigFile(c)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool(flagPrintConfig) {
+		return printConfig(c, cf)
+	}
+
+	if err = cliutil.SetLogLevelsFromContext(c); err != nil {
+		return err
+	}
+
+	if err = maybeStartProfiler(c); err != nil {
+		return err
+	}
+
+	proxy, err := cliutil.ProxyFromContext(c)
+	if err != nil {
 		return err
 	}
 
@@ -249,12 +373,12 @@ func runDaemon(c *cli.Context) error {
 		return errFlagsMutuallyExclusive(flagDevXMRMaker, flagDevXMRTaker)
 	}
 
-	envConf, err := getEnvConfig(c, devXMRMaker, devXMRTaker)
+	envConf, err := getEnvConfig(c, cf, devXMRMaker, devXMRTaker)
 	if err != nil {
 		return err
 	}
 
-	mc, err := createMoneroClient(c, envConf)
+	mc, err := createMoneroClient(c, envConf, proxy)
 	if err != nil {
 		return err
 	}
@@ -264,7 +388,7 @@ func runDaemon(c *cli.Context) error {
 		return err
 	}
 
-	ec, err := createEthClient(c, envConf)
+	ec, err := createEthClient(c, envConf, proxy)
 	if err != nil {
 		return err
 	}
@@ -274,10 +398,35 @@ func runDaemon(c *cli.Context) error {
 		return err
 	}
 
-	conf, err := createSwapdConf(c, envConf, mc, ec)
+	chainClients, err := createChainClients(c, envConf, proxy, ec)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		for _, client := range chainClients {
+			client.Close()
+		}
+	}()
+
+	conf, err := createSwapdConf(c, envConf, proxy, mc, ec)
+	if err != nil {
+		return err
+	}
+	conf.ChainClients = chainClients
+
+	conf.RPCCredentials, err = getRPCCredentials(cf)
+	if err != nil {
+		return err
+	}
+
+	current, _, err := effectiveConfig(c, cf, false)
+	if err != nil {
+		return err
+	}
+	reloader := &configReloader{args: args, current: current}
+	conf.Reload = reloader.load
+	conf.ReloadApplied = reloader.applied
+	conf.ReloadRequests = reloadRequests
 
 	err = daemon.RunSwapDaemon(c.Context, conf)
 	if err != nil && !errors.Is(err, context.Canceled) {
@@ -288,8 +437,35 @@ func runDaemon(c *cli.Context) error {
 }
 
 // getEnvConfig returns the environment specific config, adjusting all values changed by
-// command line options.
-func getEnvConfig(c *cli.Context, devXMRMaker bool, devXMRTaker bool) (*common.Config, error) {
+// command line options or the config file, and creates the data directory.
+func getEnvConfig(
+	c *cli.Context,
+	cf *cliutil.ConfigFile,
+	devXMRMaker bool,
+	devXMRTaker bool,
+) (*common.Config, error) {
+	conf, err := resolveEnvConfig(c, cf, devXMRMaker, devXMRTaker)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("starting swapd, environment: %s", conf.Env)
+
+	if err = common.MakeDir(conf.DataDir); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// resolveEnvConfig returns the environment specific config, adjusting all values
+// changed by command line options or the config file, without side effects.
+func resolveEnvConfig(
+	c *cli.Context,
+	cf *cliutil.ConfigFile,
+	devXMRMaker bool,
+	devXMRTaker bool,
+) (*common.Config, error) {
 	if c.IsSet(flagEnv) {
 		if c.String(flagEnv) != common.Development.String() && (devXMRMaker || devXMRTaker) {
 			return nil, errors.New("--dev-xmrmaker and --dev-xmrtaker are only valid with --env=dev")
@@ -309,7 +485,6 @@ func getEnvConfig(c *cli.Context, devXMRMaker bool, devXMRTaker bool) (*common.C
 		env = common.Development
 	}
 
-	log.Infof("starting swapd, environment: %s", env)
 	conf := common.ConfigDefaultsForEnv(env)
 
 	// cfg.DataDir already has a default set, so only override if the user explicitly set the flag
@@ -327,7 +502,9 @@ func getEnvConfig(c *cli.Context, devXMRMaker bool, devXMRTaker bool) (*common.C
 			conf.DataDir = defaultXMRMakerDataDir
 		}
 	}
-	if err = common.MakeDir(conf.DataDir); err != nil {
+
+	conf.MoneroNodes, err = getMoneroNodes(c, cf, env, conf.MoneroNodes)
+	if err != nil {
 		return nil, err
 	}
 
@@ -335,6 +512,15 @@ func getEnvConfig(c *cli.Context, devXMRMaker bool, devXMRTaker bool) (*common.C
 		conf.Bootnodes = cliutil.ExpandBootnodes(c.StringSlice(flagBootnodes))
 	}
 
+	if c.IsSet(flagChain) {
+		conf.Chains, err = parseChainFlags(env, c.StringSlice(flagChain))
+	} else if cf != nil {
+		conf.Chains, err = resolveChains(env, cf.Chains)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	deploy := c.Bool(flagDeploy)
 	if deploy {
 		if c.IsSet(flagContractAddress) {
@@ -382,11 +568,18 @@ func validateOrDeployContracts(c *cli.Context, envConf *common.Config, ec exteth
 	return nil
 }
 
-func createMoneroClient(c *cli.Context, envConf *common.Config) (monero.WalletClient, error) {
+// getMoneroNodes returns the monerod nodes set by flags, or else by the config
+// file, or else the passed defaults.
+func getMoneroNodes(
+	c *cli.Context,
+	cf *cliutil.ConfigFile,
+	env common.Environment,
+	defaults []*common.MoneroNode,
+) ([]*common.MoneroNode, error) {
 	if c.IsSet(flagMoneroDaemonHost) || c.IsSet(flagMoneroDaemonPort) {
 		node := &common.MoneroNode{
 			Host: "127.0.0.1",
-			Port: common.DefaultMoneroPortFromEnv(envConf.Env),
+			Port: common.DefaultMoneroPortFromEnv(env),
 		}
 		if c.IsSet(flagMoneroDaemonHost) {
 			node.Host = c.String(flagMoneroDaemonHost)
@@ -400,9 +593,29 @@ func createMoneroClient(c *cli.Context, envConf *common.Config) (monero.WalletCl
 				return nil, errFlagValueZero(flagMoneroDaemonPort)
 			}
 		}
-		envConf.MoneroNodes = []*common.MoneroNode{node}
+		return []*common.MoneroNode{node}, nil
+	}
+
+	if cf == nil || len(cf.MoneroNodes) == 0 {
+		return defaults, nil
+	}
+
+	var nodes []*common.MoneroNode
+	for _, n := range cf.MoneroNodes {
+		if n.Host == "" || n.Port == 0 {
+			return nil, fmt.Errorf("config file %s: monero nodes require a host and a port", cf.Path)
+		}
+		nodes = append(nodes, &common.MoneroNode{Host: n.Host, Port: n.Port})
 	}
 
+	return nodes, nil
+}
+
+func createMoneroClient(
+	c *cli.Context,
+	envConf *common.Config,
+	proxy *netproxy.Proxy,
+) (monero.WalletClient, error) {
 	walletFilePath := envConf.MoneroWalletPath()
 	if c.IsSet(flagMoneroWalletPath) {
 		walletFilePath = c.String(flagMoneroWalletPath)
@@ -418,10 +631,15 @@ func createMoneroClient(c *cli.Context, envConf *common.Config) (monero.WalletCl
 		MoneroWalletRPCPath: "", // look for it in "./monero-bin/monero-wallet-rpc" and then the user's path
 		WalletPassword:      c.String(flagMoneroWalletPassword),
 		WalletPort:          c.Uint(flagMoneroWalletPort),
+		Proxy:               proxy,
 	})
 }
 
-func createEthClient(c *cli.Context, envConf *common.Config) (extethclient.EthClient, error) {
+func createEthClient(
+	c *cli.Context,
+	envConf *common.Config,
+	proxy *netproxy.Proxy,
+) (extethclient.EthClient, error) {
 	env := envConf.Env
 
 	ethEndpoint := envConf.EthEndpoint
@@ -464,7 +682,7 @@ func createEthClient(c *cli.Context, envConf *common.Config) (extethclient.EthCl
 		}
 	}
 
-	extendedEC, err := extethclient.NewEthClient(c.Context, env, ethEndpoint, ethPrivKey)
+	extendedEC, err := extethclient.NewEthClient(c.Context, env, ethEndpoint, proxy, ethPrivKey)
 	if err != nil {
 		return nil, err
 	}
@@ -479,6 +697,7 @@ func createEthClient(c *cli.Context, envConf *common.Config) (extethclient.EthCl
 func createSwapdConf(
 	c *cli.Context,
 	envConf *common.Config,
+	proxy *netproxy.Proxy,
 	mc monero.WalletClient,
 	ec extethclient.EthClient,
 ) (*daemon.SwapdConfig, error) {
@@ -491,16 +710,90 @@ func createSwapdConf(
 		}
 	}
 
-	libp2pPort := c.Uint(flagLibp2pPort)
-	if !c.IsSet(flagLibp2pPort) {
-		switch {
-		case c.Bool(flagDevXMRMaker):
-			libp2pPort = defaultXMRMakerLibp2pPort
-		case c.Bool(flagDevXMRTaker):
-			libp2pPort = defaultXMRTakerLibp2pPort
+	swapKeySeedFile := envConf.SwapKeySeedFile()
+	if c.IsSet(flagSwapSeed) {
+		swapKeySeedFile = c.String(flagSwapSeed)
+		if swapKeySeedFile == "" {
+			return nil, errFlagValueEmpty(flagSwapSeed)
+		}
+	}
+
+	rpcPort, libp2pPort := getPorts(c)
+
+	var priceHistory pricefeed.HistoricalPriceSource
+	if c.IsSet(flagPriceHistoryFile) {
+		priceHistoryFile := c.String(flagPriceHistoryFile)
+		if priceHistoryFile == "" {
+			return nil, errFlagValueEmpty(flagPriceHistoryFile)
+		}
+
+		var err error
+		priceHistory, err = pricefeed.LoadFileHistory(priceHistoryFile)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	var priceSources *pricefeed.SourcesConfig
+	if c.IsSet(flagPriceSourcesFile) {
+		priceSourcesFile := c.String(flagPriceSourcesFile)
+		if priceSourcesFile == "" {
+			return nil, errFlagValueEmpty(flagPriceSourcesFile)
+		}
+
+		var err error
+		priceSources, err = pricefeed.LoadSourcesFile(priceSourcesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	webhookURLs, webhookSecret, lowBalanceAlert, err := getWebhookConf(c)
+	if err != nil {
+		return nil, err
+	}
+
+	maxOfferPremium, err := getMaxOfferPremium(c, envConf.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedPeers, deniedPeers, err := getPeerLists(c)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenLists, err := getTokenLists(c, envConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &daemon.SwapdConfig{
+		EnvConf:         envConf,
+		Libp2pPort:      uint16(libp2pPort),
+		Libp2pKeyfile:   libp2pKeyFile,
+		SwapKeySeedFile: swapKeySeedFile,
+		RPCPort:         uint16(rpcPort),
+		IsRelayer:       c.Bool(flagRelayer),
+		NoTransferBack:  c.Bool(flagNoTransferBack),
+		MoneroClient:    mc,
+		EthereumClient:  ec,
+		Proxy:           proxy,
+		PriceHistory:    priceHistory,
+		PriceSources:    priceSources,
+		WebhookURLs:     webhookURLs,
+		WebhookSecret:   webhookSecret,
+		LowBalanceAlert: lowBalanceAlert,
+		MaxOfferPremium: maxOfferPremium,
+		AllowedPeers:    allowedPeers,
+		DeniedPeers:     deniedPeers,
+		TokenLists:      tokenLists,
+	}, nil
+}
+
+// getPorts returns the RPC and libp2p ports, which have different defaults
+// for the dev XMR maker and taker.
+func getPorts(c *cli.Context) (uint, uint) {
 	rpcPort := c.Uint(flagRPCPort)
 	if !c.IsSet(flagRPCPort) {
 		switch {
@@ -511,16 +804,118 @@ func createSwapdConf(
 		}
 	}
 
-	return &daemon.SwapdConfig{
-		EnvConf:        envConf,
-		Libp2pPort:     uint16(libp2pPort),
-		Libp2pKeyfile:  libp2pKeyFile,
-		RPCPort:        uint16(rpcPort),
-		IsRelayer:      c.Bool(flagRelayer),
-		NoTransferBack: c.Bool(flagNoTransferBack),
-		MoneroClient:   mc,
-		EthereumClient: ec,
-	}, nil
+	libp2pPort := c.Uint(flagLibp2pPort)
+	if !c.IsSet(flagLibp2pPort) {
+		switch {
+		case c.Bool(flagDevXMRMaker):
+			libp2pPort = defaultXMRMakerLibp2pPort
+		case c.Bool(flagDevXMRTaker):
+			libp2pPort = defaultXMRTakerLibp2pPort
+		}
+	}
+
+	return rpcPort, libp2pPort
+}
+
+// getMaxOfferPremium returns the maximum offer premium as a fraction, or nil
+// if the check is disabled. The dev environment's fake prices don't match the
+// exchange rates of test offers, so the check is off there unless the flag is
+// set.
+func getMaxOfferPremium(c *cli.Context, env common.Environment) (*apd.Decimal, error) {
+	percent, _, err := apd.NewFromString(defaultMaxOfferPremium)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.IsSet(flagMaxOfferPremium) {
+		percent, err = cliutil.ReadUnsignedDecimalFlag(c, flagMaxOfferPremium)
+		if err != nil {
+			return nil, err
+		}
+	} else if env == common.Development {
+		return nil, nil
+	}
+
+	if percent.IsZero() {
+		return nil, nil
+	}
+
+	fraction := new(apd.Decimal)
+	if _, err = coins.DecimalCtx().Quo(fraction, percent, apd.New(100, 0)); err != nil {
+		return nil, err
+	}
+
+	return fraction, nil
+}
+
+// getWebhookConf returns the webhook URLs, the shared secret read from the
+// secret file, and the optional low balance threshold.
+func getWebhookConf(c *cli.Context) ([]string, []byte, *apd.Decimal, error) {
+	urls := c.StringSlice(flagWebhookURL)
+	if len(urls) == 0 {
+		if c.IsSet(flagWebhookSecretFile) || c.IsSet(flagLowBalanceAlert) {
+			return nil, nil, nil, fmt.Errorf("flag %q is required for webhook notifications", flagWebhookURL)
+		}
+		return nil, nil, nil, nil
+	}
+
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, nil, nil, fmt.Errorf("invalid webhook URL %q", u)
+		}
+	}
+
+	secretFile := c.String(flagWebhookSecretFile)
+	if secretFile == "" {
+		return nil, nil, nil, errFlagValueEmpty(flagWebhookSecretFile)
+	}
+	data, err := os.ReadFile(filepath.Clean(secretFile))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read webhook secret file: %w", err)
+	}
+	secret := bytes.TrimSpace(data)
+	if len(secret) == 0 {
+		return nil, nil, nil, fmt.Errorf("webhook secret file %s is empty", secretFile)
+	}
+
+	var lowBalanceAlert *apd.Decimal
+	if c.IsSet(flagLowBalanceAlert) {
+		lowBalanceAlert, err = cliutil.ReadPositiveUnsignedDecimalFlag(c, flagLowBalanceAlert)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return urls, secret, lowBalanceAlert, nil
+}
+
+// getPeerLists returns the peers that are allowed and denied to open streams
+// with swapd.
+func getPeerLists(c *cli.Context) ([]peer.ID, []peer.ID, error) {
+	parse := func(flag string) ([]peer.ID, error) {
+		var ids []peer.ID
+		for _, s := range c.StringSlice(flag) {
+			id, err := peer.Decode(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid peer ID %q for flag %q: %w", s, flag, err)
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+
+	allowed, err := parse(flagAllowPeer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	denied, err := parse(flagDenyPeer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return allowed, denied, nil
 }
 
 func maybeBackgroundMine(ctx context.Context, devXMRMaker bool, address *mcrypto.Address) error {
@@ -195,6 +195,21 @@ func TestDaemon_BadFlags(t *testing.T) {
 			},
 			expectErr: `"bootnode" is not a valid environment for swapd`,
 		},
+		{
+			description: "strict proxy mode without a proxy",
+			extraFlags:  []string{fmt.Sprintf("--%s", flagProxyStrict)},
+			expectErr:   "strict proxy mode needs a proxy URL",
+		},
+		{
+			// libp2p connections go through the proxy, so swapd gets to the
+			// contract checks
+			description: "strict proxy mode",
+			extraFlags: []string{
+				fmt.Sprintf("--%s=socks5://127.0.0.1:9050", flagProxy),
+				fmt.Sprintf("--%s", flagProxyStrict),
+			},
+			expectErr: `flag "deploy" or "contract-address" is required for env=dev`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -267,7 +282,7 @@ func TestDaemon_PersistOffers(t *testing.T) {
 	maxXMRAmt := one
 	xRate := coins.ToExchangeRate(one)
 
-	offerResp, err := client.MakeOffer(minXMRAmt, maxXMRAmt, xRate, types.EthAssetETH, false)
+	offerResp, err := client.MakeOffer(minXMRAmt, maxXMRAmt, xRate, types.EthAssetETH, false, 0)
 	require.NoError(t, err)
 
 	// shut down the daemon to verify that the offer still exists on restart
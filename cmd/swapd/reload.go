@@ -0,0 +1,226 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/cliutil"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/daemon"
+)
+
+// reloadRequests receives a request to reload the configuration on every
+// SIGHUP.
+var reloadRequests = make(chan struct{}, 1)
+
+// reloadableSettings are the keys of the effective configuration that can
+// change without restarting swapd. Changing any other key fails the reload.
+var reloadableSettings = map[string]struct{}{
+	daemon.SettingLogLevel:        {},
+	daemon.SettingGasPrice:        {},
+	daemon.SettingGasLimit:        {},
+	daemon.SettingRelayer:         {}, // the relayer section
+	daemon.SettingMaxOfferPremium: {},
+	daemon.SettingWebhookURL:      {},
+	daemon.SettingWebhookSecret:   {},
+	daemon.SettingLowBalanceAlert: {},
+	daemon.SettingAllowPeer:       {},
+	daemon.SettingDenyPeer:        {},
+	daemon.SettingAllowToken:      {},
+	daemon.SettingDenyToken:       {},
+}
+
+// configReloader re-reads the configuration of a running swapd.
+type configReloader struct {
+	// args are the flags that were set on the command line or by environment
+	// variables when swapd started, and keep overriding the config file.
+	args []string
+	// current is the effective configuration in use.
+	current map[string]any
+	// pending is the effective configuration of the last load, which becomes
+	// current once its settings were applied.
+	pending map[string]any
+}
+
+// overrideArgs returns the flags that are set on the command line or by
+// environment variables as command line arguments. It must be called before
+// the config file is applied.
+func overrideArgs(c *cli.Context) ([]string, error) {
+	var args []string
+	for _, f := range c.App.Flags {
+		name := f.Names()[0]
+		if name == flagPrintConfig || !c.IsSet(name) {
+			continue
+		}
+
+		switch f.(type) {
+		case *cli.StringFlag:
+			args = append(args, fmt.Sprintf("--%s=%s", name, c.String(name)))
+		case *cli.UintFlag:
+			args = append(args, fmt.Sprintf("--%s=%d", name, c.Uint(name)))
+		case *cli.BoolFlag:
+			args = append(args, fmt.Sprintf("--%s=%t", name, c.Bool(name)))
+		case *cli.StringSliceFlag:
+			for _, value := range c.StringSlice(name) {
+				args = append(args, fmt.Sprintf("--%s=%s", name, value))
+			}
+		default:
+			return nil, fmt.Errorf("flag %q has unsupported type %T", name, f)
+		}
+	}
+
+	return args, nil
+}
+
+// load re-reads the config file and returns the settings to apply. It fails
+// if a setting that needs a restart changed. The configuration in use only
+// changes when applied is called.
+func (r *configReloader) load() (*daemon.RuntimeSettings, error) {
+	settings, next, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	settings.Changed, err = diffConfigs(r.current, next)
+	if err != nil {
+		return nil, err
+	}
+
+	r.pending = next
+	return settings, nil
+}
+
+// applied makes the configuration of the last load the one in use, after the
+// daemon applied its settings.
+func (r *configReloader) applied(_ *daemon.RuntimeSettings) {
+	if r.pending != nil {
+		r.current = r.pending
+		r.pending = nil
+	}
+}
+
+// read runs the flag parsing of swapd again with the override arguments, which
+// applies the current config file, and returns the runtime settings and the
+// effective configuration.
+func (r *configReloader) read() (*daemon.RuntimeSettings, map[string]any, error) {
+	var (
+		settings  *daemon.RuntimeSettings
+		effective map[string]any
+	)
+
+	app := cliApp()
+	app.Writer = io.Discard
+	app.ErrWriter = io.Discard
+	app.Action = func(c *cli.Context) error {
+		cf, err := applyConfigFile(c)
+		if err != nil {
+			return err
+		}
+
+		var envConf *common.Config
+		effective, envConf, err = effectiveConfig(c, cf, false)
+		if err != nil {
+			return err
+		}
+
+		settings, err = getRuntimeSettings(c, envConf)
+		return err
+	}
+
+	if err := app.Run(append([]string{app.Name}, r.args...)); err != nil {
+		return nil, nil, err
+	}
+
+	return settings, effective, nil
+}
+
+// getRuntimeSettings returns the settings that the reload path applies.
+func getRuntimeSettings(c *cli.Context, envConf *common.Config) (*daemon.RuntimeSettings, error) {
+	logLevel := c.String(flagLogLevel)
+	if err := cliutil.ValidateLogLevel(logLevel); err != nil {
+		return nil, err
+	}
+
+	webhookURLs, webhookSecret, lowBalanceAlert, err := getWebhookConf(c)
+	if err != nil {
+		return nil, err
+	}
+
+	maxOfferPremium, err := getMaxOfferPremium(c, envConf.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedPeers, deniedPeers, err := getPeerLists(c)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenLists, err := getTokenLists(c, envConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &daemon.RuntimeSettings{
+		LogLevel:        logLevel,
+		GasPrice:        uint64(c.Uint(flagGasPrice)),
+		GasLimit:        uint64(c.Uint(flagGasLimit)),
+		IsRelayer:       c.Bool(flagRelayer),
+		MaxOfferPremium: maxOfferPremium,
+		WebhookURLs:     webhookURLs,
+		WebhookSecret:   webhookSecret,
+		LowBalanceAlert: lowBalanceAlert,
+		AllowedPeers:    allowedPeers,
+		DeniedPeers:     deniedPeers,
+		TokenLists:      tokenLists,
+	}, nil
+}
+
+// diffConfigs returns the sorted keys of the reloadable settings that differ
+// between the effective configurations, or an error if any other key differs.
+func diffConfigs(current map[string]any, next map[string]any) ([]string, error) {
+	currentURLs, _ := current[flagWebhookURL].([]string)
+	nextURLs, _ := next[flagWebhookURL].([]string)
+	if (len(currentURLs) > 0) != (len(nextURLs) > 0) {
+		return nil, errors.New("enabling or disabling webhook notifications requires a restart")
+	}
+
+	var keys []string
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range next {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changed := []string{}
+	var needRestart []string
+	for _, key := range keys {
+		if reflect.DeepEqual(current[key], next[key]) {
+			continue
+		}
+		if _, ok := reloadableSettings[key]; ok {
+			changed = append(changed, key)
+		} else {
+			needRestart = append(needRestart, key)
+		}
+	}
+
+	if len(needRestart) > 0 {
+		return nil, fmt.Errorf("changing %s requires a restart", strings.Join(needRestart, ", "))
+	}
+
+	return changed, nil
+}
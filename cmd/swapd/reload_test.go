@@ -0,0 +1,114 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common"
+)
+
+func TestConfigReloader(t *testing.T) {
+	const testPeerID = "12D3KooWAYn1T8Lu122Pav4zAogjpeU61usLTNZpLRNh9gCqY6X2"
+
+	configFile := path.Join(t.TempDir(), "swapd.toml")
+	base := fmt.Sprintf("env = \"dev\"\ndeploy = true\ndata-dir = %q\n", t.TempDir())
+	writeConfig := func(contents string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(base+contents), 0600))
+	}
+
+	writeConfig("log-level = \"info\"\nrpc-port = 6000\n")
+	r := &configReloader{args: []string{"--config", configFile, "--gas-limit=100000"}}
+	_, current, err := r.read()
+	require.NoError(t, err)
+	r.current = current
+
+	settings, err := r.load()
+	require.NoError(t, err)
+	require.Empty(t, settings.Changed)
+	r.applied(settings)
+	require.Equal(t, "info", settings.LogLevel)
+	require.Nil(t, settings.MaxOfferPremium) // disabled by default in dev
+	require.False(t, settings.IsRelayer)
+
+	writeConfig(fmt.Sprintf(`
+log-level = "debug"
+rpc-port = 6000
+gas-limit = 200000
+max-offer-premium = "5"
+allow-peer = [%q]
+deny-token = ["0xdAC17F958D2ee523a2206206994597C13D831ec7"]
+
+[relayer]
+enabled = true
+`, testPeerID))
+	settings, err = r.load()
+	require.NoError(t, err)
+	require.Equal(t, []string{"allow-peer", "deny-token", "log-level", "max-offer-premium", "relayer"},
+		settings.Changed)
+	require.Equal(t, "debug", settings.LogLevel)
+	require.Equal(t, uint64(100000), settings.GasLimit) // the flag overrides the file
+	require.Zero(t, settings.MaxOfferPremium.Cmp(apd.New(5, -2)))
+	require.True(t, settings.IsRelayer)
+	require.Len(t, settings.AllowedPeers, 1)
+	require.Equal(t, testPeerID, settings.AllowedPeers[0].String())
+	devChainID := common.PrimaryChain(common.Development).ChainID
+	require.Len(t, settings.TokenLists[devChainID].Denied, 1)
+
+	// the loaded configuration is not in use until it was applied
+	settings, err = r.load()
+	require.NoError(t, err)
+	require.Equal(t, []string{"allow-peer", "deny-token", "log-level", "max-offer-premium", "relayer"},
+		settings.Changed)
+	r.applied(settings)
+
+	// nothing is applied if a setting that needs a restart changed
+	writeConfig("log-level = \"warn\"\nrpc-port = 7000\n")
+	_, err = r.load()
+	require.ErrorContains(t, err, "changing rpc-port requires a restart")
+
+	secretFile := path.Join(t.TempDir(), "webhook-secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("secret"), 0600))
+	writeConfig(fmt.Sprintf("rpc-port = 6000\nwebhook-url = [\"http://127.0.0.1:1\"]\nwebhook-secret-file = %q\n",
+		secretFile))
+	_, err = r.load()
+	require.ErrorContains(t, err, "enabling or disabling webhook notifications requires a restart")
+
+	writeConfig("log-level = \"verbose\"\nrpc-port = 6000\n")
+	_, err = r.load()
+	require.ErrorContains(t, err, `invalid log level "verbose"`)
+
+	writeConfig("rpc-port = 6000\ndeny-peer = [\"abc\"]\n")
+	_, err = r.load()
+	require.ErrorContains(t, err, `invalid peer ID "abc"`)
+
+	writeConfig("rpc-port = 6000\nallow-token = [\"base:0xdAC17F958D2ee523a2206206994597C13D831ec7\"]\n")
+	_, err = r.load()
+	require.ErrorContains(t, err, `chain "base" is not supported in the dev environment`)
+
+	// the failed reloads left the configuration in use as it was
+	writeConfig(fmt.Sprintf("log-level = \"debug\"\nrpc-port = 6000\nmax-offer-premium = \"5\"\n"+
+		"allow-peer = [%q]\ndeny-token = [\"0xdAC17F958D2ee523a2206206994597C13D831ec7\"]\n"+
+		"[relayer]\nenabled = true\n", testPeerID))
+	settings, err = r.load()
+	require.NoError(t, err)
+	require.Empty(t, settings.Changed)
+}
+
+func TestReloadableSettings_areFlags(t *testing.T) {
+	flags := make(map[string]bool)
+	for _, f := range cliApp().Flags {
+		flags[f.Names()[0]] = true
+	}
+
+	for name := range reloadableSettings {
+		require.True(t, flags[name], "%s is not a swapd flag", name)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/protocol/tokenpolicy"
+)
+
+// getTokenLists returns the ERC20 tokens that are allowed and denied on each
+// enabled chain, keyed by chain ID.
+func getTokenLists(c *cli.Context, envConf *common.Config) (map[uint64]*tokenpolicy.Lists, error) {
+	lists := make(map[uint64]*tokenpolicy.Lists)
+
+	for _, flag := range []string{flagAllowToken, flagDenyToken} {
+		for _, value := range c.StringSlice(flag) {
+			chainID, token, err := parseTokenFlag(envConf, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --%s value %q: %w", flag, value, err)
+			}
+
+			l, ok := lists[chainID]
+			if !ok {
+				l = new(tokenpolicy.Lists)
+				lists[chainID] = l
+			}
+
+			if flag == flagAllowToken {
+				l.Allowed = append(l.Allowed, token)
+			} else {
+				l.Denied = append(l.Denied, token)
+			}
+		}
+	}
+
+	return lists, nil
+}
+
+// parseTokenFlag parses a token flag value, which has the form ADDRESS for
+// tokens on the primary chain or CHAIN:ADDRESS, where the chain is the name
+// or ID of an enabled chain.
+func parseTokenFlag(envConf *common.Config, value string) (uint64, ethcommon.Address, error) {
+	chainName, addr := "", value
+	if i := strings.Index(value, ":"); i >= 0 {
+		chainName, addr = value[:i], value[i+1:]
+	}
+
+	if !ethcommon.IsHexAddress(addr) {
+		return 0, ethcommon.Address{}, fmt.Errorf("%q is not a token address", addr)
+	}
+
+	var chainID uint64
+	if chainName != "" {
+		var chain *common.Chain
+		var err error
+		if id, parseErr := strconv.ParseUint(chainName, 10, 64); parseErr == nil {
+			chain, err = common.ChainByID(envConf.Env, id)
+		} else {
+			chain, err = common.ChainByName(envConf.Env, chainName)
+		}
+		if err != nil {
+			return 0, ethcommon.Address{}, err
+		}
+		chainID = chain.ChainID
+	}
+
+	chain, err := envConf.EnabledChain(chainID)
+	if err != nil {
+		return 0, ethcommon.Address{}, err
+	}
+
+	return chain.ChainID, ethcommon.HexToAddress(addr), nil
+}
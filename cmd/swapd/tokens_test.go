@@ -0,0 +1,48 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common"
+)
+
+func TestParseTokenFlag(t *testing.T) {
+	const addr = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+	envConf := common.ConfigDefaultsForEnv(common.Mainnet)
+	arbitrum, err := common.ChainByName(common.Mainnet, "arbitrum")
+	require.NoError(t, err)
+	envConf.Chains = []*common.Chain{arbitrum}
+
+	for _, value := range []string{addr, "mainnet:" + addr, "1:" + addr} {
+		chainID, token, err := parseTokenFlag(envConf, value) //nolint:govet
+		require.NoError(t, err, value)
+		require.Equal(t, uint64(common.MainnetChainID), chainID, value)
+		require.Equal(t, ethcommon.HexToAddress(addr), token, value)
+	}
+
+	for _, value := range []string{"Arbitrum:" + addr, "42161:" + addr} {
+		chainID, _, err := parseTokenFlag(envConf, value) //nolint:govet
+		require.NoError(t, err, value)
+		require.Equal(t, uint64(common.ArbitrumChainID), chainID, value)
+	}
+
+	for _, tc := range []struct {
+		value       string
+		errContains string
+	}{
+		{"0x1234", `"0x1234" is not a token address`},
+		{"arbitrum:", `"" is not a token address`},
+		{"sepolia:" + addr, `chain "sepolia" is not supported in the mainnet environment`},
+		{"base:" + addr, "chain ID 8453 is not enabled"},
+	} {
+		_, _, err = parseTokenFlag(envConf, tc.value)
+		require.ErrorContains(t, err, tc.errContains, tc.value)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
+	"github.com/athanorlabs/atomic-swap/recovery"
+)
+
+func runHistory(c *cli.Context) (err error) {
+	if c.Args().Present() {
+		return fmt.Errorf("unknown argument %q", c.Args().First())
+	}
+
+	r, err := openRecoverer(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := r.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	if c.IsSet(flagEthAddress) {
+		if r.cfg.EthClient.HasPrivateKey() {
+			return fmt.Errorf("flag %q can't be used when the ethereum key file exists", flagEthAddress)
+		}
+		addr := c.String(flagEthAddress)
+		if !ethcommon.IsHexAddress(addr) {
+			return errInvalidFlagValue(flagEthAddress, errors.New("invalid ethereum address"))
+		}
+		r.cfg.EthClient.SetAddress(ethcommon.HexToAddress(addr))
+	}
+	if (r.cfg.EthClient.Address() == ethcommon.Address{}) {
+		return fmt.Errorf("--%s flag required when the ethereum key file does not exist", flagEthAddress)
+	}
+
+	hc := &recovery.HistoryConfig{
+		SwapCreatorAddr: r.envConf.SwapCreatorAddr,
+		FromBlock:       c.Uint64(flagFromBlock),
+		ToBlock:         c.Uint64(flagToBlock),
+		BlockRange:      c.Uint64(flagBlockRange),
+	}
+	if c.IsSet(flagContractAddress) {
+		addr := c.String(flagContractAddress)
+		if !ethcommon.IsHexAddress(addr) {
+			return errInvalidFlagValue(flagContractAddress, errors.New("invalid ethereum address"))
+		}
+		hc.SwapCreatorAddr = ethcommon.HexToAddress(addr)
+	}
+	if (hc.SwapCreatorAddr == ethcommon.Address{}) {
+		return fmt.Errorf("--%s flag required in the %s environment", flagContractAddress, r.envConf.Env)
+	}
+	if hc.BlockRange == 0 {
+		return errInvalidFlagValue(flagBlockRange, errors.New("must be non-zero"))
+	}
+
+	seed, err := loadSwapSeed(c, r.envConf)
+	if err != nil {
+		return err
+	}
+	gapLimit := c.Uint(flagGapLimit)
+	if gapLimit == 0 || gapLimit > math.MaxUint32 {
+		return errInvalidFlagValue(flagGapLimit, fmt.Errorf("must be between 1 and %d", uint32(math.MaxUint32)))
+	}
+
+	fmt.Printf("Scanning swap contract %s for swaps of %s\n", hc.SwapCreatorAddr, r.cfg.EthClient.Address())
+	findings, err := r.AuditHistory(hc)
+	if err != nil {
+		return err
+	}
+
+	var nextKeyIndex uint32
+	if seed != nil {
+		nextKeyIndex, err = r.FindSeedSecrets(findings, seed, uint32(gapLimit))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(findings) == 0 {
+		fmt.Println("No swaps found")
+	}
+
+	var rebuildable int
+	for _, f := range findings {
+		fmt.Println(f)
+		if f.Kind == recovery.FindingMissing && f.Chain.Rebuildable() {
+			rebuildable++
+		}
+	}
+
+	if !c.Bool(flagRebuild) {
+		if rebuildable > 0 {
+			fmt.Printf("Run with --%s to add the %d swap(s) missing from the database\n", flagRebuild, rebuildable)
+		}
+		return nil
+	}
+
+	for _, f := range findings {
+		if f.Kind != recovery.FindingMissing || !f.Chain.Rebuildable() {
+			continue
+		}
+
+		info, err := r.RebuildSwap(f.Chain)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rebuilt swap %s, status: %s\n", info.OfferID, info.Status)
+		if info.Status.IsOngoing() {
+			fmt.Println("Its funds are still locked, run swaprecover without a command to recover them")
+		}
+	}
+
+	if seed == nil {
+		return nil
+	}
+
+	// keys derived from the seed must never be reused by new swaps
+	if err = r.cfg.RecoveryDB.AdvanceSwapKeyIndex(nextKeyIndex); err != nil {
+		return err
+	}
+	nextKeyIndex, err = r.cfg.RecoveryDB.SwapKeyIndex()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("New swaps will derive their keys from swap key %d\n", nextKeyIndex)
+
+	return nil
+}
+
+// loadSwapSeed returns swapd's swap key seed, or nil if the default seed file
+// does not exist.
+func loadSwapSeed(c *cli.Context, envConf *common.Config) (*swapkeys.Seed, error) {
+	seedFile := envConf.SwapKeySeedFile()
+	if c.IsSet(flagSwapSeed) {
+		seedFile = c.String(flagSwapSeed)
+		if seedFile == "" {
+			return nil, errFlagValueEmpty(flagSwapSeed)
+		}
+	}
+
+	exists, err := common.FileExists(seedFile)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if c.IsSet(flagSwapSeed) {
+			return nil, fmt.Errorf("swap key seed file %s not found", seedFile)
+		}
+		log.Warnf("swap key seed file %s not found, swaps with locked funds can't be rebuilt", seedFile)
+		return nil, nil
+	}
+
+	return swapkeys.LoadSeedFile(seedFile)
+}
+
+func errInvalidFlagValue(flag string, err error) error {
+	return fmt.Errorf("invalid value for flag %q: %w", flag, err)
+}
@@ -0,0 +1,498 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package main provides the entrypoint of the swaprecover executable, an
+// offline tool that recovers the funds of swaps that swapd did not finish,
+// using only swapd's database, an ethereum endpoint and a monerod.
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/ChainSafe/chaindb"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/urfave/cli/v2"
+
+	"github.com/athanorlabs/atomic-swap/cliutil"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/db"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/monero"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
+	"github.com/athanorlabs/atomic-swap/recovery"
+)
+
+const (
+	// databaseDirName is the name of swapd's database directory inside its
+	// data directory
+	databaseDirName = "swap-db"
+
+	flagEnv                  = "env"
+	flagDataDir              = "data-dir"
+	flagDBDir                = "db-dir"
+	flagEthEndpoint          = "eth-endpoint"
+	flagEthPrivKey           = "eth-privkey"
+	flagMoneroDaemonHost     = "monerod-host"
+	flagMoneroDaemonPort     = "monerod-port"
+	flagMoneroWalletPath     = "wallet-file"
+	flagMoneroWalletPassword = "wallet-password"
+	flagOfferID              = "offer-id"
+	flagExecute              = "execute"
+	flagNoTransferBack       = "no-transfer-back"
+	flagLogLevel             = cliutil.FlagLogLevel
+	flagProxy                = cliutil.FlagProxy
+	flagProxyStrict          = cliutil.FlagProxyStrict
+
+	flagContractAddress = "contract-address"
+	flagEthAddress      = "eth-address"
+	flagFromBlock       = "from-block"
+	flagToBlock         = "to-block"
+	flagBlockRange      = "block-range"
+	flagRebuild         = "rebuild"
+	flagSwapSeed        = "swap-seed"
+	flagGapLimit        = "gap-limit"
+)
+
+var log = logging.Logger("cmd")
+
+func cliApp() *cli.App {
+	return &cli.App{
+		Name: "swaprecover",
+		Usage: "Recover the funds of unfinished swaps from a swapd data dir or database backup, " +
+			"without running swapd",
+		Version:              cliutil.GetVersion(),
+		Action:               runRecover,
+		EnableBashCompletion: true,
+		Suggest:              true,
+		Commands: []*cli.Command{
+			{
+				Name: "history",
+				Usage: "Compare the swaps of our ethereum address in the swap contract's events with the " +
+					"database, and optionally rebuild the swaps missing from it",
+				Action: runHistory,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  flagContractAddress,
+						Usage: "Address of the swap contract to scan, defaults to the environment's contract",
+					},
+					&cli.StringFlag{
+						Name:  flagEthAddress,
+						Usage: "Ethereum address to find swaps of, if the ethereum key file is not available",
+					},
+					&cli.Uint64Flag{
+						Name:     flagFromBlock,
+						Usage:    "First block to scan",
+						Required: true,
+					},
+					&cli.Uint64Flag{
+						Name:  flagToBlock,
+						Usage: "Last block to scan, defaults to the latest block",
+					},
+					&cli.Uint64Flag{
+						Name:  flagBlockRange,
+						Usage: "Maximum number of blocks to query events of at once",
+						Value: recovery.DefaultBlockRange,
+					},
+					&cli.BoolFlag{
+						Name: flagRebuild,
+						Usage: "Add minimal records for the swaps missing from the database. Swaps with funds " +
+							"still locked are added as unfinished swaps if our secret is found from the swap " +
+							"key seed, and the database's swap key index is advanced past the seed's swaps",
+					},
+					&cli.StringFlag{
+						Name:  flagSwapSeed,
+						Usage: "File containing swapd's swap key seed as hex, to find our secrets of the swaps",
+						Value: fmt.Sprintf("{DATA-DIR}/%s", common.DefaultSwapKeySeedFileName),
+					},
+					&cli.UintFlag{
+						Name:  flagGapLimit,
+						Usage: "Number of consecutive swap keys without a swap after which the seed search stops",
+						Value: swapkeys.DefaultGapLimit,
+					},
+				},
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    flagEnv,
+				Usage:   "Environment to use: one of mainnet, stagenet, or dev: Default: mainnet",
+				EnvVars: []string{"SWAPD_ENV"},
+				Value:   "mainnet",
+			},
+			&cli.StringFlag{
+				Name:  flagDataDir,
+				Usage: "swapd's data directory",
+				Value: "{HOME}/.atomicswap/{ENV}", // For --help only, actual default replaces variables
+			},
+			&cli.StringFlag{
+				Name:  flagDBDir,
+				Usage: "Database directory or database backup to recover from",
+				Value: fmt.Sprintf("{DATA-DIR}/%s", databaseDirName),
+			},
+			&cli.StringFlag{
+				Name:    flagEthEndpoint,
+				Usage:   "Ethereum client endpoint",
+				EnvVars: []string{"SWAPD_ETH_ENDPOINT"},
+			},
+			&cli.StringFlag{
+				Name:    flagEthPrivKey,
+				Usage:   "File containing the swap's ethereum private key as hex, required to claim or refund ETH",
+				EnvVars: []string{"SWAPD_ETH_PRIVKEY"},
+				Value:   fmt.Sprintf("{DATA-DIR}/%s", common.DefaultEthKeyFileName),
+			},
+			&cli.StringFlag{
+				Name:    flagMoneroDaemonHost,
+				Usage:   "monerod host",
+				EnvVars: []string{"SWAPD_MONEROD_HOST"},
+			},
+			&cli.UintFlag{
+				Name:    flagMoneroDaemonPort,
+				Usage:   "monerod port",
+				EnvVars: []string{"SWAPD_MONEROD_PORT"},
+			},
+			&cli.StringFlag{
+				Name:  flagMoneroWalletPath,
+				Usage: "Path to the Monero wallet file that recovered XMR is swept to",
+				Value: fmt.Sprintf("{DATA-DIR}/wallet/%s", common.DefaultMoneroWalletName),
+			},
+			&cli.StringFlag{
+				Name:  flagMoneroWalletPassword,
+				Usage: "Password of monero wallet file",
+			},
+			&cli.StringFlag{
+				Name:  flagOfferID,
+				Usage: "Only recover the swap with this ID",
+			},
+			&cli.BoolFlag{
+				Name:  flagExecute,
+				Usage: "Perform the recovery actions, instead of only reporting them",
+			},
+			&cli.BoolFlag{
+				Name:  flagNoTransferBack,
+				Usage: "Leave recovered XMR in the rebuilt swap wallet instead of sweeping it to the primary wallet",
+			},
+			&cli.StringFlag{
+				Name:    flagLogLevel,
+				Usage:   "Set log level: one of [error|warn|info|debug]",
+				EnvVars: []string{"SWAPD_LOG_LEVEL"},
+				Value:   "info",
+			},
+			&cli.StringFlag{
+				Name:    flagProxy,
+				Usage:   "SOCKS5 proxy for the ethereum and monerod connections, as socks5://[USER:PASSWORD@]HOST:PORT",
+				EnvVars: []string{"SWAPD_PROXY"},
+			},
+			&cli.BoolFlag{
+				Name:    flagProxyStrict,
+				Usage:   fmt.Sprintf("Refuse connections that can't go through the --%s", flagProxy),
+				EnvVars: []string{"SWAPD_PROXY_STRICT"},
+			},
+		},
+	}
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go cliutil.SignalHandler(ctx, cancel, log)
+
+	err := cliApp().RunContext(ctx, os.Args)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// recoverer holds the resources opened for a swaprecover command.
+type recoverer struct {
+	*recovery.Recoverer
+	cfg     *recovery.Config
+	envConf *common.Config
+	proxy   *netproxy.Proxy
+	db      *db.Database
+}
+
+// close closes the resources, returning an error if the database fails to
+// sync.
+func (r *recoverer) close() error {
+	r.cfg.EthClient.Close()
+	if r.cfg.MoneroClient != nil {
+		r.cfg.MoneroClient.Close()
+	}
+	if err := r.db.Close(); err != nil {
+		return fmt.Errorf("syncing database: %w", err)
+	}
+	return nil
+}
+
+// openRecoverer opens the database and the ethereum client selected by the
+// global flags.
+func openRecoverer(c *cli.Context) (*recoverer, error) {
+	if err := cliutil.SetLogLevelsFromContext(c); err != nil {
+		return nil, err
+	}
+
+	proxy, err := cliutil.ProxyFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	envConf, err := getEnvConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	dbDir := path.Join(envConf.DataDir, databaseDirName)
+	if c.IsSet(flagDBDir) {
+		dbDir = c.String(flagDBDir)
+		if dbDir == "" {
+			return nil, errFlagValueEmpty(flagDBDir)
+		}
+	}
+
+	exists, err := common.FileExists(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("database directory %s not found", dbDir)
+	}
+
+	sdb, err := db.NewDatabase(&chaindb.Config{DataDir: dbDir})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s, make sure swapd is not running: %w", dbDir, err)
+	}
+
+	sm, err := swap.NewManager(sdb)
+	if err != nil {
+		_ = sdb.Close()
+		return nil, err
+	}
+
+	ec, err := createEthClient(c, envConf, proxy)
+	if err != nil {
+		_ = sdb.Close()
+		return nil, err
+	}
+
+	rcfg := &recovery.Config{
+		Env:            envConf.Env,
+		SwapManager:    sm,
+		RecoveryDB:     sdb.RecoveryDB(),
+		EthClient:      ec,
+		NoTransferBack: c.Bool(flagNoTransferBack),
+	}
+
+	return &recoverer{
+		Recoverer: recovery.NewRecoverer(c.Context, rcfg),
+		cfg:       rcfg,
+		envConf:   envConf,
+		proxy:     proxy,
+		db:        sdb,
+	}, nil
+}
+
+func runRecover(c *cli.Context) (err error) {
+	// Fail if any non-flag arguments were passed
+	if c.Args().Present() {
+		return fmt.Errorf("unknown command %q", c.Args().First())
+	}
+
+	r, err := openRecoverer(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := r.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	plans, err := getPlans(c, r.Recoverer)
+	if err != nil {
+		return err
+	}
+
+	if len(plans) == 0 {
+		fmt.Println("No unfinished swaps found")
+		return nil
+	}
+
+	for _, p := range plans {
+		fmt.Println(p)
+	}
+
+	if !c.Bool(flagExecute) {
+		fmt.Printf("Run with --%s to perform the actions above\n", flagExecute)
+		return nil
+	}
+
+	if needsMonero(plans) {
+		r.cfg.MoneroClient, err = createMoneroClient(c, r.envConf, r.proxy)
+		if err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	for _, p := range plans {
+		switch p.Action {
+		case recovery.ActionWait, recovery.ActionManual:
+			continue
+		}
+
+		if err = r.Execute(p); err != nil {
+			log.Errorf("failed to recover swap %s: %s", p.OfferID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Recovered swap %s, status: %s\n", p.OfferID, p.FinalStatus)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to recover %d swap(s)", failed)
+	}
+
+	return nil
+}
+
+func getPlans(c *cli.Context, r *recovery.Recoverer) ([]*recovery.Plan, error) {
+	if !c.IsSet(flagOfferID) {
+		return r.Plans()
+	}
+
+	offerID, err := types.HexToHash(c.String(flagOfferID))
+	if err != nil {
+		return nil, errInvalidFlagValue(flagOfferID, err)
+	}
+
+	p, err := r.Plan(offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*recovery.Plan{p}, nil
+}
+
+func needsMonero(plans []*recovery.Plan) bool {
+	for _, p := range plans {
+		if p.Action == recovery.ActionClaimXMR || p.Action == recovery.ActionReclaimXMR {
+			return true
+		}
+	}
+	return false
+}
+
+func getEnvConfig(c *cli.Context) (*common.Config, error) {
+	env, err := common.NewEnv(c.String(flagEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	if env == common.Bootnode {
+		return nil, fmt.Errorf("%q is not a valid environment for swaprecover", env.String())
+	}
+
+	conf := common.ConfigDefaultsForEnv(env)
+
+	if c.IsSet(flagDataDir) {
+		conf.DataDir = c.String(flagDataDir)
+		if conf.DataDir == "" {
+			return nil, errFlagValueEmpty(flagDataDir)
+		}
+	}
+
+	return conf, nil
+}
+
+// createEthClient returns an ethereum client with the swap's private key, if
+// the key file exists. Without it, recovery actions can still be reported.
+func createEthClient(
+	c *cli.Context,
+	envConf *common.Config,
+	proxy *netproxy.Proxy,
+) (extethclient.EthClient, error) {
+	ethEndpoint := envConf.EthEndpoint
+	if c.IsSet(flagEthEndpoint) {
+		ethEndpoint = c.String(flagEthEndpoint)
+	}
+	if ethEndpoint == "" {
+		return nil, fmt.Errorf("--%s flag required", flagEthEndpoint)
+	}
+
+	ethPrivKeyFile := envConf.EthKeyFileName()
+	if c.IsSet(flagEthPrivKey) {
+		ethPrivKeyFile = c.String(flagEthPrivKey)
+		if ethPrivKeyFile == "" {
+			return nil, errFlagValueEmpty(flagEthPrivKey)
+		}
+	}
+
+	var ethPrivKey *ecdsa.PrivateKey
+	exists, err := common.FileExists(ethPrivKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		ethPrivKey, err = cliutil.GetEthereumPrivateKey(ethPrivKeyFile, envConf.Env, false, false)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		log.Warnf("ethereum key file %s not found, ETH can't be claimed or refunded", ethPrivKeyFile)
+	}
+
+	return extethclient.NewEthClient(c.Context, envConf.Env, ethEndpoint, proxy, ethPrivKey)
+}
+
+func createMoneroClient(
+	c *cli.Context,
+	envConf *common.Config,
+	proxy *netproxy.Proxy,
+) (monero.WalletClient, error) {
+	if c.IsSet(flagMoneroDaemonHost) || c.IsSet(flagMoneroDaemonPort) {
+		node := &common.MoneroNode{
+			Host: "127.0.0.1",
+			Port: common.DefaultMoneroPortFromEnv(envConf.Env),
+		}
+		if c.IsSet(flagMoneroDaemonHost) {
+			node.Host = c.String(flagMoneroDaemonHost)
+		}
+		if c.IsSet(flagMoneroDaemonPort) {
+			node.Port = c.Uint(flagMoneroDaemonPort)
+		}
+		envConf.MoneroNodes = []*common.MoneroNode{node}
+	}
+
+	walletFilePath := envConf.MoneroWalletPath()
+	if c.IsSet(flagMoneroWalletPath) {
+		walletFilePath = c.String(flagMoneroWalletPath)
+		if walletFilePath == "" {
+			return nil, errFlagValueEmpty(flagMoneroWalletPath)
+		}
+	}
+
+	mc, err := monero.NewWalletClient(&monero.WalletClientConf{
+		Env:            envConf.Env,
+		WalletFilePath: walletFilePath,
+		MonerodNodes:   envConf.MoneroNodes,
+		WalletPassword: c.String(flagMoneroWalletPassword),
+		Proxy:          proxy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the monero wallet: %w", err)
+	}
+
+	return mc, nil
+}
+
+func errFlagValueEmpty(flag string) error {
+	return fmt.Errorf("flag %q requires a non-empty value", flag)
+}
@@ -30,6 +30,38 @@ func CalcExchangeRate(xmrPrice *apd.Decimal, ethPrice *apd.Decimal) (*ExchangeRa
 	return ToExchangeRate(rate), nil
 }
 
+// CalcTokenExchangeRate computes and returns the exchange rate of XMR in units
+// of an ERC20 token, using XMR and token prices relative to the same alternate
+// currency. The rate is rounded to the token's decimal places when it has
+// fewer than MaxExchangeRateDecimals, so that it never implies amounts that
+// the token can't represent.
+func CalcTokenExchangeRate(
+	xmrPrice *apd.Decimal,
+	tokenPrice *apd.Decimal,
+	token *ERC20TokenInfo,
+) (*ExchangeRate, error) {
+	rate := new(apd.Decimal)
+	_, err := decimalCtx.Quo(rate, xmrPrice, tokenPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals := uint8(MaxExchangeRateDecimals)
+	if token.NumDecimals < decimals {
+		decimals = token.NumDecimals
+	}
+
+	if rate, err = roundToDecimalPlace(rate, decimals); err != nil {
+		return nil, err
+	}
+	if rate.IsZero() {
+		return nil, fmt.Errorf("exchange rate rounds to zero with the %d decimals of %s",
+			token.NumDecimals, token.SanitizedSymbol())
+	}
+
+	return ToExchangeRate(rate), nil
+}
+
 // ToExchangeRate casts an *apd.Decimal to *ExchangeRate
 func ToExchangeRate(rate *apd.Decimal) *ExchangeRate {
 	return (*ExchangeRate)(rate)
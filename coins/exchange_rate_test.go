@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/apd/v3"
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -95,3 +96,24 @@ func TestCalcExchangeRate_fail(t *testing.T) {
 	_, err := CalcExchangeRate(xmrPrice, ethPrice)
 	require.ErrorContains(t, err, "division by zero")
 }
+
+func TestCalcTokenExchangeRate(t *testing.T) {
+	xmrPrice := StrToDecimal("150.123456789")
+	usdcPrice := StrToDecimal("0.9999")
+
+	usdc := NewERC20TokenInfo(ethcommon.Address{0x1}, 6, "USD Coin", "USDC")
+	rate, err := CalcTokenExchangeRate(xmrPrice, usdcPrice, usdc)
+	require.NoError(t, err)
+	assert.Equal(t, "150.138471", rate.String())
+
+	// the rate is limited to the token's 2 decimal places
+	twoDecimals := NewERC20TokenInfo(ethcommon.Address{0x2}, 2, "Two", "TWO")
+	rate, err = CalcTokenExchangeRate(xmrPrice, usdcPrice, twoDecimals)
+	require.NoError(t, err)
+	assert.Equal(t, "150.14", rate.String())
+
+	// 150 / 30000 = 0.005, which is zero with no decimals
+	noDecimals := NewERC20TokenInfo(ethcommon.Address{0x3}, 0, "None", "NONE")
+	_, err = CalcTokenExchangeRate(StrToDecimal("150"), StrToDecimal("30000"), noDecimals)
+	require.ErrorContains(t, err, "rounds to zero")
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// Chain is an EVM chain that swaps can be made on. Each environment has a
+// primary chain, whose endpoint and SwapCreator address are the ones in
+// Config, and can have additional chains that offers are made on.
+type Chain struct {
+	ChainID         uint64            `json:"chainID"`
+	Name            string            `json:"name"`
+	NativeSymbol    string            `json:"nativeSymbol"`
+	Env             Environment       `json:"-"`
+	EthEndpoint     string            `json:"ethEndpoint,omitempty"`
+	SwapCreatorAddr ethcommon.Address `json:"swapCreatorAddress"`
+}
+
+// registeredChains are all the chains known to swapd. The first chain of each
+// environment is its primary chain. There is no default endpoint or
+// SwapCreator address for the additional chains, they are provided when the
+// chain is enabled.
+var registeredChains = []Chain{
+	{ChainID: MainnetChainID, Name: "Mainnet", NativeSymbol: "ETH", Env: Mainnet},
+	{ChainID: OpMainnetChainID, Name: "Optimism", NativeSymbol: "ETH", Env: Mainnet},
+	{ChainID: ArbitrumChainID, Name: "Arbitrum", NativeSymbol: "ETH", Env: Mainnet},
+	{ChainID: BaseChainID, Name: "Base", NativeSymbol: "ETH", Env: Mainnet},
+	{ChainID: PolygonChainID, Name: "Polygon", NativeSymbol: "POL", Env: Mainnet},
+	{ChainID: SepoliaChainID, Name: "Sepolia", NativeSymbol: "ETH", Env: Stagenet},
+	{ChainID: OpSepoliaChainID, Name: "OptimismSepolia", NativeSymbol: "ETH", Env: Stagenet},
+	{ChainID: ArbitrumSepoliaChainID, Name: "ArbitrumSepolia", NativeSymbol: "ETH", Env: Stagenet},
+	{ChainID: BaseSepoliaChainID, Name: "BaseSepolia", NativeSymbol: "ETH", Env: Stagenet},
+	{ChainID: PolygonAmoyChainID, Name: "PolygonAmoy", NativeSymbol: "POL", Env: Stagenet},
+	{ChainID: GanacheChainID, Name: "Ganache", NativeSymbol: "ETH", Env: Development},
+}
+
+// ChainsForEnv returns copies of the chains registered for the environment,
+// starting with its primary chain.
+func ChainsForEnv(env Environment) []*Chain {
+	var chains []*Chain
+	for i := range registeredChains {
+		if registeredChains[i].Env == env {
+			c := registeredChains[i]
+			chains = append(chains, &c)
+		}
+	}
+	return chains
+}
+
+// PrimaryChain returns a copy of the primary chain of the environment. It
+// returns nil for environments without chains, like the bootnode environment.
+func PrimaryChain(env Environment) *Chain {
+	chains := ChainsForEnv(env)
+	if len(chains) == 0 {
+		return nil
+	}
+	return chains[0]
+}
+
+// ChainByID returns a copy of the registered chain with the given ID. It is
+// an error if the chain is not registered for the environment.
+func ChainByID(env Environment, chainID uint64) (*Chain, error) {
+	for _, c := range ChainsForEnv(env) {
+		if c.ChainID == chainID {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("chain ID %d is not supported in the %s environment", chainID, env)
+}
+
+// ChainByName returns a copy of the registered chain with the given name,
+// ignoring case. It is an error if the chain is not registered for the
+// environment.
+func ChainByName(env Environment, name string) (*Chain, error) {
+	for _, c := range ChainsForEnv(env) {
+		if strings.EqualFold(c.Name, name) {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("chain %q is not supported in the %s environment", name, env)
+}
+
+// String returns the name and ID of the chain.
+func (c *Chain) String() string {
+	return fmt.Sprintf("%s (chain ID %d)", c.Name, c.ChainID)
+}
@@ -4,6 +4,7 @@
 package common
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"time"
@@ -20,6 +21,10 @@ const (
 
 	// DefaultEthKeyFileName is the default ethereum private key file name in {DATA_DIR}
 	DefaultEthKeyFileName = "eth.key"
+
+	// DefaultSwapKeySeedFileName is the default file name of the master seed
+	// that swap keys are derived from in {DATA_DIR}
+	DefaultSwapKeySeedFileName = "swap-seed.key"
 )
 
 var homeDir, _ = os.UserHomeDir()
@@ -50,6 +55,9 @@ type Config struct {
 	MoneroNodes     []*MoneroNode
 	SwapCreatorAddr ethcommon.Address
 	Bootnodes       []string
+	// Chains are the chains, other than the primary chain of the environment,
+	// that swaps are enabled on.
+	Chains []*Chain
 }
 
 // MainnetConfig is the mainnet ethereum and monero configuration
@@ -143,6 +151,36 @@ func (c Config) EthKeyFileName() string {
 	return path.Join(c.DataDir, DefaultEthKeyFileName)
 }
 
+// SwapKeySeedFile returns the path to the swap key seed file, whose default
+// value depends on current value of the data dir.
+func (c Config) SwapKeySeedFile() string {
+	return path.Join(c.DataDir, DefaultSwapKeySeedFileName)
+}
+
+// EnabledChain returns the enabled chain with the given chain ID. Zero is the
+// primary chain, whose endpoint and SwapCreator address are the ones of the
+// config.
+func (c Config) EnabledChain(chainID uint64) (*Chain, error) {
+	primary := PrimaryChain(c.Env)
+	if primary == nil {
+		return nil, fmt.Errorf("no chains in the %s environment", c.Env)
+	}
+
+	if chainID == 0 || chainID == primary.ChainID {
+		primary.EthEndpoint = c.EthEndpoint
+		primary.SwapCreatorAddr = c.SwapCreatorAddr
+		return primary, nil
+	}
+
+	for _, chain := range c.Chains {
+		if chain.ChainID == chainID {
+			return chain, nil
+		}
+	}
+
+	return nil, fmt.Errorf("chain ID %d is not enabled", chainID)
+}
+
 // ConfigDefaultsForEnv returns the configuration defaults for the given environment.
 func ConfigDefaultsForEnv(env Environment) *Config {
 	switch env {
@@ -49,3 +49,65 @@ func TestPublicBootnodes(t *testing.T) {
 	t.Logf("%d of %d bootnodes are offline", failures, len(publicBootnodes))
 	require.Zero(t, failures)
 }
+
+func TestChainsForEnv(t *testing.T) {
+	for _, env := range []Environment{Development, Stagenet, Mainnet} {
+		chains := ChainsForEnv(env)
+		require.NotEmpty(t, chains)
+		require.Equal(t, PrimaryChain(env), chains[0])
+		for _, c := range chains {
+			require.Equal(t, env, c.Env)
+		}
+	}
+
+	require.Nil(t, PrimaryChain(Bootnode))
+	require.Equal(t, uint64(MainnetChainID), PrimaryChain(Mainnet).ChainID)
+	require.Equal(t, uint64(SepoliaChainID), PrimaryChain(Stagenet).ChainID)
+	require.Equal(t, uint64(GanacheChainID), PrimaryChain(Development).ChainID)
+
+	// callers get copies that they can modify
+	PrimaryChain(Mainnet).Name = "changed"
+	require.Equal(t, "Mainnet", PrimaryChain(Mainnet).Name)
+}
+
+func TestChainLookups(t *testing.T) {
+	chain, err := ChainByName(Mainnet, "arbitrum")
+	require.NoError(t, err)
+	require.Equal(t, uint64(ArbitrumChainID), chain.ChainID)
+	require.Equal(t, "ETH", chain.NativeSymbol)
+
+	chain, err = ChainByID(Mainnet, PolygonChainID)
+	require.NoError(t, err)
+	require.Equal(t, "POL", chain.NativeSymbol)
+
+	_, err = ChainByID(Stagenet, ArbitrumChainID)
+	require.ErrorContains(t, err, "chain ID 42161 is not supported in the stagenet environment")
+
+	_, err = ChainByName(Development, "base")
+	require.ErrorContains(t, err, `chain "base" is not supported in the dev environment`)
+}
+
+func TestConfig_EnabledChain(t *testing.T) {
+	conf := MainnetConfig()
+	conf.EthEndpoint = "http://localhost:8545"
+
+	base, err := ChainByID(Mainnet, BaseChainID)
+	require.NoError(t, err)
+	base.EthEndpoint = "http://localhost:8546"
+	conf.Chains = []*Chain{base}
+
+	for _, chainID := range []uint64{0, MainnetChainID} {
+		chain, err := conf.EnabledChain(chainID) //nolint:govet
+		require.NoError(t, err)
+		require.Equal(t, uint64(MainnetChainID), chain.ChainID)
+		require.Equal(t, conf.EthEndpoint, chain.EthEndpoint)
+		require.Equal(t, conf.SwapCreatorAddr, chain.SwapCreatorAddr)
+	}
+
+	chain, err := conf.EnabledChain(BaseChainID)
+	require.NoError(t, err)
+	require.Equal(t, base, chain)
+
+	_, err = conf.EnabledChain(ArbitrumChainID)
+	require.ErrorContains(t, err, "chain ID 42161 is not enabled")
+}
@@ -28,9 +28,16 @@ const (
 
 // Ethereum chain IDs
 const (
-	MainnetChainID   = 1
-	OpMainnetChainID = 10 // Optimism
-	SepoliaChainID   = 11155111
-	GanacheChainID   = 1337
-	HardhatChainID   = 31337
+	MainnetChainID         = 1
+	OpMainnetChainID       = 10 // Optimism
+	PolygonChainID         = 137
+	BaseChainID            = 8453
+	ArbitrumChainID        = 42161
+	SepoliaChainID         = 11155111
+	OpSepoliaChainID       = 11155420
+	BaseSepoliaChainID     = 84532
+	ArbitrumSepoliaChainID = 421614
+	PolygonAmoyChainID     = 80002
+	GanacheChainID         = 1337
+	HardhatChainID         = 31337
 )
@@ -0,0 +1,215 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package netproxy routes the outbound connections of swapd through a SOCKS5
+// proxy, like a local Tor daemon. Connections to loopback hosts, like a local
+// monerod or monero-wallet-rpc, are always made directly.
+package netproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/net/proxy"
+)
+
+const dialTimeout = 30 * time.Second
+
+var log = logging.Logger("netproxy")
+
+var (
+	errStrictWithoutProxy = errors.New("strict proxy mode needs a proxy URL")
+	// ErrDirectConnection is returned in strict mode for connections that
+	// can't be made through the proxy.
+	ErrDirectConnection = errors.New("direct connections are refused in strict proxy mode")
+)
+
+// Config is the proxy configuration of swapd.
+type Config struct {
+	// URL of the SOCKS5 proxy, like socks5://127.0.0.1:9050, optionally with
+	// a username and password. Connections are direct if it is empty.
+	URL string
+	// Strict refuses connections that can't go through the proxy, instead of
+	// making them directly.
+	Strict bool
+}
+
+// Proxy dials connections through a SOCKS5 proxy. Host names are resolved by
+// the proxy, so that DNS requests don't leak either. A nil or zero Proxy dials
+// directly.
+type Proxy struct {
+	addr        string // host:port of the proxy
+	credentials bool
+	strict      bool
+	dialer      proxy.ContextDialer
+}
+
+// New returns the Proxy of the configuration.
+func New(cfg *Config) (*Proxy, error) {
+	if cfg.URL == "" {
+		if cfg.Strict {
+			return nil, errStrictWithoutProxy
+		}
+		return &Proxy{}, nil
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	// socks5h is what curl calls a SOCKS5 proxy that resolves host names,
+	// which ours always does
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("proxy URL %q is not a socks5:// URL", u.Redacted())
+	}
+	if _, _, err = net.SplitHostPort(u.Host); err != nil {
+		return nil, fmt.Errorf("proxy URL %q needs a host and port: %w", u.Redacted(), err)
+	}
+
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", u.Host, auth, &net.Dialer{Timeout: dialTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proxy{
+		addr:        u.Host,
+		credentials: auth != nil,
+		strict:      cfg.Strict,
+		dialer:      dialer.(proxy.ContextDialer),
+	}, nil
+}
+
+// Enabled returns whether connections go through a proxy.
+func (p *Proxy) Enabled() bool {
+	return p != nil && p.dialer != nil
+}
+
+// Strict returns whether connections that can't go through the proxy are
+// refused.
+func (p *Proxy) Strict() bool {
+	return p != nil && p.strict
+}
+
+// DialContext connects to the address through the proxy, or directly if the
+// host is a loopback address or the proxy is not enabled.
+func (p *Proxy) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	if !p.Enabled() || isLoopbackAddr(addr) {
+		d := &net.Dialer{Timeout: dialTimeout}
+		return d.DialContext(ctx, network, addr)
+	}
+
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("can't make %s connections through a SOCKS5 proxy", network)
+	}
+
+	return p.dialer.DialContext(ctx, "tcp", addr)
+}
+
+// HTTPClient returns an HTTP client whose connections go through the proxy.
+// A timeout of zero means no timeout.
+func (p *Proxy) HTTPClient(timeout time.Duration) *http.Client {
+	if !p.Enabled() {
+		return &http.Client{Timeout: timeout}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // the HTTP_PROXY variables would bypass our dialer
+	transport.DialContext = p.DialContext
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// DialEthereum connects to an Ethereum RPC endpoint through the proxy. HTTP
+// and websocket endpoints are supported, IPC endpoints are local.
+func (p *Proxy) DialEthereum(ctx context.Context, endpoint string) (*ethclient.Client, error) {
+	if !p.Enabled() {
+		return ethclient.DialContext(ctx, endpoint)
+	}
+
+	c, err := rpc.DialOptions(ctx, endpoint,
+		rpc.WithHTTPClient(p.HTTPClient(0)),
+		rpc.WithWebsocketDialer(websocket.Dialer{
+			NetDialContext:   p.DialContext,
+			HandshakeTimeout: dialTimeout,
+			ReadBufferSize:   1024,
+			WriteBufferSize:  1024,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ethclient.NewClient(c), nil
+}
+
+// CheckDirect is called before making connections that can't go through the
+// proxy, described by conns. It returns ErrDirectConnection in strict mode, and
+// otherwise logs that the connections are direct.
+func (p *Proxy) CheckDirect(conns string) error {
+	if !p.Enabled() {
+		return nil
+	}
+	if p.strict {
+		return fmt.Errorf("%w: %s", ErrDirectConnection, conns)
+	}
+	log.Warnf("%s are not made through the proxy", conns)
+	return nil
+}
+
+// MoneroWalletProxy returns the value of the --proxy flag of monero-wallet-rpc
+// for a daemon on the given host, or an empty string if the wallet connects to
+// the daemon directly. monero-wallet-rpc needs the IP address and port of the
+// proxy, and doesn't support proxy credentials.
+func (p *Proxy) MoneroWalletProxy(daemonHost string) (string, error) {
+	if !p.Enabled() || isLoopbackHost(daemonHost) {
+		return "", nil
+	}
+
+	if p.credentials {
+		err := p.CheckDirect("monerod connections of monero-wallet-rpc (proxy credentials are not supported)")
+		return "", err
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", p.addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the proxy address for monero-wallet-rpc: %w", err)
+	}
+
+	return addr.String(), nil
+}
+
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	return isLoopbackHost(host)
+}
+
+// isLoopbackHost returns whether the host is localhost or a loopback IP
+// address. Other host names are resolved by the proxy.
+func isLoopbackHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
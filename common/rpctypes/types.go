@@ -6,6 +6,8 @@
 package rpctypes
 
 import (
+	"time"
+
 	"github.com/cockroachdb/apd/v3"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -53,12 +55,28 @@ type QueryPeerRequest struct {
 // QueryPeerResponse ...
 type QueryPeerResponse struct {
 	Offers []*types.Offer `json:"offers" validate:"dive,required"`
+	// Premiums is the percentage by which the exchange rate of each offer is
+	// above (positive) or below (negative) the market rate of its asset, keyed
+	// by offer ID. Offers whose market rate is unavailable have no premium.
+	Premiums map[types.Hash]*apd.Decimal `json:"premiums,omitempty"`
+	// Capabilities are not set for peers that don't advertise them.
+	Capabilities *PeerCapabilities `json:"capabilities,omitempty"`
+}
+
+// PeerCapabilities are the p2p protocol versions and optional features that a
+// peer supports.
+type PeerCapabilities struct {
+	Versions []uint16 `json:"versions"`
+	Features []string `json:"features"`
 }
 
 // PeerWithOffers ...
 type PeerWithOffers struct {
 	PeerID peer.ID        `json:"peerID" validate:"required"`
 	Offers []*types.Offer `json:"offers" validate:"dive,required"`
+	// Premiums and Capabilities have the same meaning as in QueryPeerResponse
+	Premiums     map[types.Hash]*apd.Decimal `json:"premiums,omitempty"`
+	Capabilities *PeerCapabilities           `json:"capabilities,omitempty"`
 }
 
 // QueryAllRequest ...
@@ -69,11 +87,100 @@ type QueryAllResponse struct {
 	PeersWithOffers []*PeerWithOffers `json:"peersWithOffers" validate:"dive,required"`
 }
 
+// GetOrderBookRequest ...
+type GetOrderBookRequest struct {
+	EthAsset types.EthAsset `json:"ethAsset,omitempty"`
+	ChainID  uint64         `json:"chainID,omitempty"` // defaults to the primary chain
+	// ProvidesAmount, if set, leaves out the offers that can't be taken by
+	// providing this amount of the asset.
+	ProvidesAmount *apd.Decimal `json:"providesAmount,omitempty"`
+}
+
+// OrderBookOffer is an offer of a remote peer in the order book.
+type OrderBookOffer struct {
+	PeerID    peer.ID      `json:"peerID" validate:"required"`
+	Offer     *types.Offer `json:"offer" validate:"required"`
+	FirstSeen time.Time    `json:"firstSeen"`
+	LastSeen  time.Time    `json:"lastSeen"`
+	// ExpectedAmount is the XMR amount received for the request's
+	// ProvidesAmount, if it was set.
+	ExpectedAmount *apd.Decimal `json:"expectedAmount,omitempty"`
+	// Premium has the same meaning as the Premiums of QueryPeerResponse.
+	Premium *apd.Decimal `json:"premium,omitempty"`
+}
+
+// GetOrderBookResponse ...
+type GetOrderBookResponse struct {
+	// Offers are sorted by exchange rate, best rate first.
+	Offers []*OrderBookOffer `json:"offers" validate:"dive,required"`
+}
+
+// GetIndexOffersRequest ...
+type GetIndexOffersRequest struct {
+	EthAsset types.EthAsset `json:"ethAsset,omitempty"`
+	ChainID  uint64         `json:"chainID,omitempty"` // defaults to the primary chain of the indexed environment
+}
+
+// GetIndexOffersResponse ...
+type GetIndexOffersResponse struct {
+	// Offers are sorted by exchange rate, best rate first.
+	Offers []*OrderBookOffer `json:"offers" validate:"dive,required"`
+}
+
+// IndexAssetStats summarizes the offers for an asset in the offer index.
+type IndexAssetStats struct {
+	ChainID  uint64         `json:"chainID"`
+	EthAsset types.EthAsset `json:"ethAsset"`
+	Offers   int            `json:"offers"`
+	Makers   int            `json:"makers"`
+	// TotalXMR is the sum of the maximum amounts of the offers, and
+	// TotalEthAsset the same in standard units of the ETH asset, at the
+	// exchange rate of each offer.
+	TotalXMR      *apd.Decimal `json:"totalXMR" validate:"required"`
+	TotalEthAsset *apd.Decimal `json:"totalEthAsset" validate:"required"`
+}
+
+// IndexStatsResponse ...
+type IndexStatsResponse struct {
+	Offers int `json:"offers"`
+	// Makers is the number of makers in the index, and OnlineMakers the
+	// number of them that answered their latest liveness check.
+	Makers       int                `json:"makers"`
+	OnlineMakers int                `json:"onlineMakers"`
+	Assets       []*IndexAssetStats `json:"assets" validate:"dive,required"`
+}
+
+// IndexLivenessCheck is the result of querying a maker.
+type IndexLivenessCheck struct {
+	Time   time.Time `json:"time"`
+	Online bool      `json:"online"`
+}
+
+// IndexMaker is the liveness history of a maker in the offer index.
+type IndexMaker struct {
+	PeerID    peer.ID   `json:"peerID" validate:"required"`
+	FirstSeen time.Time `json:"firstSeen"`
+	// LastSeen is zero if the maker never answered a query.
+	LastSeen time.Time `json:"lastSeen"`
+	Online   bool      `json:"online"`
+	// Checks are the latest liveness checks, oldest first.
+	Checks []*IndexLivenessCheck `json:"checks" validate:"dive,required"`
+}
+
+// IndexMakersResponse ...
+type IndexMakersResponse struct {
+	// Makers are sorted by when they were last seen, most recent first.
+	Makers []*IndexMaker `json:"makers" validate:"dive,required"`
+}
+
 // TakeOfferRequest ...
 type TakeOfferRequest struct {
 	PeerID         peer.ID      `json:"peerID" validate:"required"`
 	OfferID        types.Hash   `json:"offerID" validate:"required"`
 	ProvidesAmount *apd.Decimal `json:"providesAmount" validate:"required"` // eth asset amount
+	// AllowOffMarket takes the offer even if its exchange rate is further from
+	// the market rate than the daemon's maximum premium.
+	AllowOffMarket bool `json:"allowOffMarket,omitempty"`
 }
 
 // MakeOfferRequest ...
@@ -83,6 +190,7 @@ type MakeOfferRequest struct {
 	ExchangeRate *coins.ExchangeRate `json:"exchangeRate" validate:"required"`
 	EthAsset     types.EthAsset      `json:"ethAsset,omitempty"`
 	UseRelayer   bool                `json:"useRelayer,omitempty"`
+	ChainID      uint64              `json:"chainID,omitempty"` // defaults to the primary chain
 }
 
 // MakeOfferResponse ...
@@ -115,6 +223,7 @@ type SignerTxSigned struct {
 // TokenInfoRequest is used to request lookup of the token's metadata.
 type TokenInfoRequest struct {
 	TokenAddr ethcommon.Address `json:"tokenAddr" validate:"required"`
+	ChainID   uint64            `json:"chainID,omitempty"` // defaults to the primary chain
 }
 
 // TokenInfoResponse contains the metadata for the requested token
@@ -141,6 +250,9 @@ type BalancesResponse struct {
 // AddressesResponse ...
 type AddressesResponse struct {
 	Addrs []string `json:"addresses" validate:"dive,required"`
+	// Reachability is "public" if peers could connect to the node, "private"
+	// if they could not, and "unknown" until it was checked.
+	Reachability string `json:"reachability"`
 }
 
 // PeersResponse ...
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/crypto/sha3"
 
 	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/vcbor"
 	"github.com/athanorlabs/atomic-swap/common/vjson"
 )
 
@@ -44,15 +45,32 @@ type Offer struct {
 	ExchangeRate *coins.ExchangeRate `json:"exchangeRate" validate:"required"`
 	EthAsset     EthAsset            `json:"ethAsset"`
 	Nonce        uint64              `json:"nonce" validate:"required"`
+	// ChainID is the ID of the chain that the ETH side of the swap is on. Zero
+	// is the primary chain of the environment.
+	ChainID uint64 `json:"chainID,omitempty"`
 }
 
 // NewOffer creates and returns an Offer with an initialised ID and Version fields
+// on the primary chain of the environment.
 func NewOffer(
 	coin coins.ProvidesCoin,
 	minAmount *apd.Decimal,
 	maxAmount *apd.Decimal,
 	exRate *coins.ExchangeRate,
 	ethAsset EthAsset,
+) *Offer {
+	return NewOfferOnChain(0, coin, minAmount, maxAmount, exRate, ethAsset)
+}
+
+// NewOfferOnChain creates and returns an Offer with an initialised ID and
+// Version fields on the chain with the given ID.
+func NewOfferOnChain(
+	chainID uint64,
+	coin coins.ProvidesCoin,
+	minAmount *apd.Decimal,
+	maxAmount *apd.Decimal,
+	exRate *coins.ExchangeRate,
+	ethAsset EthAsset,
 ) *Offer {
 	var n [8]byte
 	if _, err := rand.Read(n[:]); err != nil {
@@ -74,6 +92,7 @@ func NewOffer(
 		ExchangeRate: exRate,
 		EthAsset:     ethAsset,
 		Nonce:        binary.BigEndian.Uint64(n[:]),
+		ChainID:      chainID,
 	}
 
 	offer.setID()
@@ -100,12 +119,18 @@ func (o *Offer) hash() Hash {
 	b = append(b, []byte(o.EthAsset.String())...)
 	b = append(b, []byte(",")...)
 	b = append(b, []byte(fmt.Sprintf("%d", o.Nonce))...)
+	// the chain ID is only part of the hash when set, so the IDs of offers on
+	// the primary chain are unchanged
+	if o.ChainID != 0 {
+		b = append(b, []byte(fmt.Sprintf(",%d", o.ChainID))...)
+	}
 	return sha3.Sum256(b)
 }
 
 // String ...
 func (o *Offer) String() string {
-	return fmt.Sprintf("OfferID:%s Provides:%s MinAmount:%s MaxAmount:%s ExchangeRate:%s EthAsset:%s Nonce:%d",
+	return fmt.Sprintf(
+		"OfferID:%s Provides:%s MinAmount:%s MaxAmount:%s ExchangeRate:%s EthAsset:%s Nonce:%d ChainID:%d",
 		o.ID,
 		o.Provides,
 		o.MinAmount.String(),
@@ -113,6 +138,7 @@ func (o *Offer) String() string {
 		o.ExchangeRate.String(),
 		o.EthAsset,
 		o.Nonce,
+		o.ChainID,
 	)
 }
 
@@ -227,6 +253,26 @@ func (o *Offer) UnmarshalJSON(data []byte) error {
 	return o.validate()
 }
 
+// MarshalCBOR provides CBOR marshalling for the Offer type
+func (o *Offer) MarshalCBOR() ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	// Do standard CBOR marshal without recursion
+	type _Offer Offer
+	return vcbor.MarshalStruct((*_Offer)(o))
+}
+
+// UnmarshalCBOR provides CBOR unmarshalling the Offer type
+func (o *Offer) UnmarshalCBOR(data []byte) error {
+	// Do standard CBOR unmarshal without recursion
+	type _Offer Offer
+	if err := vcbor.UnmarshalStruct(data, (*_Offer)(o)); err != nil {
+		return err
+	}
+	return o.validate()
+}
+
 // OfferExtra represents extra data that is passed when an offer is made.
 type OfferExtra struct {
 	// UseRelayer forces the XMR maker to claim using the relayer even when he
@@ -119,6 +119,34 @@ func TestOffer_MarshalJSON_RoundTrip(t *testing.T) {
 	assert.EqualValues(t, offer1, &offer2)
 }
 
+func TestOffer_ChainID(t *testing.T) {
+	min := apd.New(100, 0)
+	max := apd.New(200, 0)
+	rate := coins.ToExchangeRate(apd.New(15, -1)) // 1.5
+	offer1 := NewOfferOnChain(42161, coins.ProvidesXMR, min, max, rate, EthAssetETH)
+	require.Equal(t, uint64(42161), offer1.ChainID)
+
+	offerJSON, err := vjson.MarshalStruct(offer1)
+	require.NoError(t, err)
+	require.Contains(t, string(offerJSON), `"chainID":42161`)
+	offer2, err := UnmarshalOffer(offerJSON)
+	require.NoError(t, err)
+	require.Equal(t, offer1.ID, offer2.ID)
+	require.Equal(t, offer1.ChainID, offer2.ChainID)
+
+	// the chain ID is covered by the offer ID
+	offer2.ChainID = 10
+	require.NotEqual(t, offer2.ID, offer2.hash())
+
+	// offers on the primary chain don't serialise a chain ID, and their IDs
+	// don't depend on it
+	offer3 := NewOffer(coins.ProvidesXMR, min, max, rate, EthAssetETH)
+	offerJSON, err = vjson.MarshalStruct(offer3)
+	require.NoError(t, err)
+	require.NotContains(t, string(offerJSON), "chainID")
+	require.Equal(t, offer3.ID, offer3.hash())
+}
+
 func TestOffer_UnmarshalJSON_BadID(t *testing.T) {
 	offerJSON := []byte(`{
 		"version": "0.1.0",
@@ -0,0 +1,74 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package vcbor or "validated CBOR" encodes structures in the deterministic
+// CBOR format of RFC 8949, with the same validation annotations as the vjson
+// package. Structures are encoded as maps keyed by their JSON field names, so
+// that a value has the same fields in both encodings. Byte arrays and types
+// with a binary encoding are encoded as byte strings, and other types with a
+// text encoding as text strings.
+package vcbor
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-playground/validator/v10"
+)
+
+// Limits on the CBOR data that we decode, which comes from peers. Messages are
+// also limited in size before they are decoded.
+const (
+	maxNestedLevels  = 32
+	maxArrayElements = 4096
+)
+
+// Documentation on the validator package is here:
+// https://pkg.go.dev/github.com/go-playground/validator/v10
+var validate = validator.New()
+
+var (
+	encMode = mustEncMode()
+	decMode = mustDecMode()
+)
+
+func mustEncMode() cbor.EncMode {
+	opts := cbor.CoreDetEncOptions()
+	opts.TextMarshaler = cbor.TextMarshalerTextString
+
+	em, err := opts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return em
+}
+
+func mustDecMode() cbor.DecMode {
+	dm, err := cbor.DecOptions{
+		DupMapKey:        cbor.DupMapKeyEnforcedAPF,
+		IndefLength:      cbor.IndefLengthForbidden,
+		MaxNestedLevels:  maxNestedLevels,
+		MaxArrayElements: maxArrayElements,
+		TextUnmarshaler:  cbor.TextUnmarshalerTextString,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return dm
+}
+
+// MarshalStruct adds additional validation on top of encoding v in
+// deterministic CBOR. Input type should be a struct pointer.
+func MarshalStruct(v any) ([]byte, error) {
+	if err := validate.Struct(v); err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(v)
+}
+
+// UnmarshalStruct adds additional validation on top of decoding CBOR data into
+// v. Target object be a struct pointer.
+func UnmarshalStruct(cborData []byte, v any) error {
+	if err := decMode.Unmarshal(cborData, v); err != nil {
+		return err
+	}
+	return validate.Struct(v)
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package vcbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/require"
+)
+
+type SomeStruct struct {
+	Decimal *apd.Decimal `json:"decimal" validate:"required"`
+	Hex     string       `json:"hex,omitempty" validate:"omitempty,hexadecimal"`
+}
+
+func TestMarshalStruct(t *testing.T) {
+	s := &SomeStruct{Decimal: apd.New(11, -1)}
+
+	data, err := MarshalStruct(s)
+	require.NoError(t, err)
+	// {"decimal": "1.1"}
+	require.Equal(t, "a167646563696d616c63312e31", hex.EncodeToString(data))
+}
+
+func TestMarshalStruct_notValid(t *testing.T) {
+	s := &SomeStruct{Decimal: nil}
+	errMsg := `'SomeStruct.Decimal' Error:Field validation for 'Decimal' failed on the 'required' tag`
+
+	_, err := MarshalStruct(s)
+	require.ErrorContains(t, err, errMsg)
+}
+
+func TestUnmarshalStruct(t *testing.T) {
+	data, err := encMode.Marshal(&SomeStruct{Decimal: apd.New(0, 0), Hex: "0x12ab"})
+	require.NoError(t, err)
+
+	s := new(SomeStruct)
+	err = UnmarshalStruct(data, s)
+	require.NoError(t, err)
+	require.Equal(t, "0x12ab", s.Hex)
+}
+
+func TestUnmarshalStruct_notValid(t *testing.T) {
+	data, err := encMode.Marshal(&SomeStruct{Decimal: apd.New(0, 0), Hex: "xyz"})
+	require.NoError(t, err)
+
+	err = UnmarshalStruct(data, new(SomeStruct))
+	errMsg := `Key: 'SomeStruct.Hex' Error:Field validation for 'Hex' failed on the 'hexadecimal' tag`
+	require.ErrorContains(t, err, errMsg)
+}
+
+func TestUnmarshalStruct_limits(t *testing.T) {
+	// {"nested": [[...[0]...]]}, nested one level too deep
+	nested := append([]byte{0xa1, 0x66}, "nested"...)
+	nested = append(nested, bytes.Repeat([]byte{0x81}, maxNestedLevels)...)
+	nested = append(nested, 0x00)
+	err := UnmarshalStruct(nested, &struct {
+		Nested any `json:"nested"`
+	}{})
+	require.ErrorContains(t, err, "exceeded max nested level")
+
+	data, err := encMode.Marshal(map[string][]int{"elements": make([]int, maxArrayElements+1)})
+	require.NoError(t, err)
+	err = UnmarshalStruct(data, &struct {
+		Elements []int `json:"elements"`
+	}{})
+	require.ErrorContains(t, err, "exceeded max number of elements")
+
+	// {"hex": "a", "hex": "b"}
+	err = UnmarshalStruct(
+		[]byte{0xa2, 0x63, 'h', 'e', 'x', 0x61, 'a', 0x63, 'h', 'e', 'x', 0x61, 'b'},
+		new(SomeStruct),
+	)
+	require.ErrorContains(t, err, "duplicate map key")
+}
@@ -20,10 +20,10 @@ type mPoint ed25519.Point
 
 // MarshalText returns the 64-symbol hex representation of the 32-byte k in little endian.
 func (s *mScalar) MarshalText() ([]byte, error) {
-	if s == nil {
-		return nil, errors.New("cannot marshal uninitialized scalar")
+	sBytes, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
 	}
-	sBytes := (*ed25519.Scalar)(s).Bytes()
 	return []byte(fmt.Sprintf("0x%x", sBytes)), nil
 }
 
@@ -35,18 +35,35 @@ func (s *mScalar) UnmarshalText(hexStr []byte) error {
 	if err != nil {
 		return err
 	}
+	return s.UnmarshalBinary(sBytes)
+}
+
+// MarshalBinary returns the 32-byte little endian representation of s.
+func (s *mScalar) MarshalBinary() ([]byte, error) {
+	if s == nil {
+		return nil, errors.New("cannot marshal uninitialized scalar")
+	}
+	return (*ed25519.Scalar)(s).Bytes(), nil
+}
+
+// UnmarshalBinary assigns the scalar from exactly 32 bytes in little endian,
+// which must already be reduced or we return an error.
+func (s *mScalar) UnmarshalBinary(sBytes []byte) error {
 	// SetCanonicalBytes will verify that we passed exactly 32 bytes
 	sNew, err := (*ed25519.Scalar)(s).SetCanonicalBytes(sBytes)
 	if err != nil {
 		return err
 	}
 	*(*ed25519.Scalar)(s) = *sNew
-	return err
+	return nil
 }
 
 // MarshalText returns the 64-symbol hex representation of the 32-byte k in little endian.
 func (p *mPoint) MarshalText() ([]byte, error) {
-	pBytes := (*ed25519.Point)(p).Bytes()
+	pBytes, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
 	return []byte(fmt.Sprintf("0x%x", pBytes)), nil
 }
 
@@ -58,7 +75,18 @@ func (p *mPoint) UnmarshalText(hexStr []byte) error {
 	if err != nil {
 		return err
 	}
-	_, err = (*ed25519.Point)(p).SetBytes(pointBytes)
+	return p.UnmarshalBinary(pointBytes)
+}
+
+// MarshalBinary returns the 32-byte little endian encoding of p.
+func (p *mPoint) MarshalBinary() ([]byte, error) {
+	return (*ed25519.Point)(p).Bytes(), nil
+}
+
+// UnmarshalBinary assigns the point from its 32-byte encoding, returning an
+// error if it is not a valid point.
+func (p *mPoint) UnmarshalBinary(pointBytes []byte) error {
+	_, err := (*ed25519.Point)(p).SetBytes(pointBytes)
 	return err
 }
 
@@ -73,6 +101,17 @@ func (k *PrivateSpendKey) UnmarshalText(input []byte) error {
 	return (*mScalar)(k.key).UnmarshalText(input)
 }
 
+// MarshalBinary returns the 32-byte LE representation of k
+func (k *PrivateSpendKey) MarshalBinary() ([]byte, error) {
+	return (*mScalar)(k.key).MarshalBinary()
+}
+
+// UnmarshalBinary assigns k from LE input of 32 bytes.
+func (k *PrivateSpendKey) UnmarshalBinary(input []byte) error {
+	k.key = ed25519.NewScalar()
+	return (*mScalar)(k.key).UnmarshalBinary(input)
+}
+
 // MarshalText returns the 64-symbol LE hex representation of k
 func (k *PrivateViewKey) MarshalText() ([]byte, error) {
 	return (*mScalar)(k.key).MarshalText()
@@ -84,6 +123,17 @@ func (k *PrivateViewKey) UnmarshalText(input []byte) error {
 	return (*mScalar)(k.key).UnmarshalText(input)
 }
 
+// MarshalBinary returns the 32-byte LE representation of k
+func (k *PrivateViewKey) MarshalBinary() ([]byte, error) {
+	return (*mScalar)(k.key).MarshalBinary()
+}
+
+// UnmarshalBinary assigns k from LE input of 32 bytes.
+func (k *PrivateViewKey) UnmarshalBinary(input []byte) error {
+	k.key = ed25519.NewScalar()
+	return (*mScalar)(k.key).UnmarshalBinary(input)
+}
+
 // MarshalText returns the 64-symbol LE hex representation of k
 func (k *PublicKey) MarshalText() ([]byte, error) {
 	return (*mPoint)(k.key).MarshalText()
@@ -95,6 +145,17 @@ func (k *PublicKey) UnmarshalText(input []byte) error {
 	return (*mPoint)(k.key).UnmarshalText(input)
 }
 
+// MarshalBinary returns the 32-byte LE representation of k
+func (k *PublicKey) MarshalBinary() ([]byte, error) {
+	return (*mPoint)(k.key).MarshalBinary()
+}
+
+// UnmarshalBinary assigns k from LE input of 32 bytes.
+func (k *PublicKey) UnmarshalBinary(input []byte) error {
+	k.key = new(ed25519.Point)
+	return (*mPoint)(k.key).UnmarshalBinary(input)
+}
+
 // _PrivateKeyPair is a non-exported type with exported fields so it can be marshaled.
 // Underscore used so name is mostly identical in error messages.
 type _PrivateKeyPair struct {
@@ -91,6 +91,17 @@ func (k *PublicKey) UnmarshalText(keyData []byte) error {
 		return err
 	}
 
+	return k.UnmarshalBinary(keyBytes)
+}
+
+// MarshalBinary returns the uncompressed 64-byte public key
+func (k *PublicKey) MarshalBinary() ([]byte, error) {
+	return k.Bytes(), nil
+}
+
+// UnmarshalBinary converts an uncompressed 64-byte public key into the
+// PublicKey type.
+func (k *PublicKey) UnmarshalBinary(keyBytes []byte) error {
 	if len(keyBytes) != 64 {
 		return errInvalidPubkeyLength
 	}
@@ -51,7 +51,7 @@ func TestXMRTakerCancelOrRefundAfterKeyExchange(t *testing.T) {
 	bc := rpcclient.NewClient(context.Background(), bobConf.RPCPort)
 	ac := rpcclient.NewClient(context.Background(), aliceConf.RPCPort)
 
-	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, false)
+	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, false, 0)
 	require.NoError(t, err)
 
 	aliceStatusCh, err := ac.TakeOfferAndSubscribe(makeResp.PeerID, makeResp.OfferID, providesAmt)
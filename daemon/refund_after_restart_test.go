@@ -37,7 +37,7 @@ func TestXMRNotLockedAndETHRefundedAfterAliceRestarts(t *testing.T) {
 	ac := rpcclient.NewClient(clientCtx, aliceConf.RPCPort)
 
 	// Bob makes an offer
-	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, false)
+	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, false, 0)
 	require.NoError(t, err)
 
 	// Alice takes the offer
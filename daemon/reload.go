@@ -0,0 +1,154 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package daemon
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/athanorlabs/atomic-swap/cliutil"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/net"
+	"github.com/athanorlabs/atomic-swap/protocol/tokenpolicy"
+	"github.com/athanorlabs/atomic-swap/rpc"
+	"github.com/athanorlabs/atomic-swap/webhook"
+)
+
+// The names of the runtime settings, as they appear in RuntimeSettings.Changed.
+// They are the keys of the settings in the swapd configuration.
+const (
+	SettingLogLevel        = "log-level"
+	SettingGasPrice        = "gas-price"
+	SettingGasLimit        = "gas-limit"
+	SettingRelayer         = "relayer"
+	SettingMaxOfferPremium = "max-offer-premium"
+	SettingWebhookURL      = "webhook-url"
+	SettingWebhookSecret   = "webhook-secret-file"
+	SettingLowBalanceAlert = "low-balance-alert"
+	SettingAllowPeer       = "allow-peer"
+	SettingDenyPeer        = "deny-peer"
+	SettingAllowToken      = "allow-token"
+	SettingDenyToken       = "deny-token"
+)
+
+// RuntimeSettings are the settings of swapd that can change without a
+// restart. Ongoing swaps continue with the new settings.
+type RuntimeSettings struct {
+	LogLevel        string
+	GasPrice        uint64 // zero uses the suggested gas price
+	GasLimit        uint64 // zero estimates the gas limit of each transaction
+	IsRelayer       bool
+	MaxOfferPremium *apd.Decimal // nil disables the check
+	// WebhookURLs and WebhookSecret replace the webhook targets. They are
+	// ignored if swapd was started without webhook notifications.
+	WebhookURLs     []string
+	WebhookSecret   []byte
+	LowBalanceAlert *apd.Decimal
+	AllowedPeers    []peer.ID
+	DeniedPeers     []peer.ID
+	TokenLists      map[uint64]*tokenpolicy.Lists
+	// Changed lists the names of the settings that differ from the ones in
+	// use. Only these settings are applied, so a reload does not undo
+	// changes made at runtime through the RPC API to the other settings.
+	Changed []string
+}
+
+// reloader applies the RuntimeSettings returned by SwapdConfig.Reload to the
+// running swapd components.
+type reloader struct {
+	mu      sync.Mutex
+	load    func() (*RuntimeSettings, error)
+	applied func(*RuntimeSettings) // optional
+	// ethClients are the clients of the primary and the additional chains
+	ethClients  []extethclient.EthClient
+	host        *net.Host
+	notifier    *webhook.Notifier // nil without webhook notifications
+	rpcServer   *rpc.Server
+	tokenPolicy *tokenpolicy.Policy
+}
+
+// reload loads the settings and applies them, returning the names of the
+// settings that changed.
+func (r *reloader) reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings, err := r.load()
+	if err != nil {
+		log.Warnf("failed to reload the configuration: %s", err)
+		return nil, err
+	}
+
+	changed := make(map[string]bool, len(settings.Changed))
+	for _, name := range settings.Changed {
+		changed[name] = true
+	}
+
+	// the webhook targets are set first, as they are the only setting that
+	// can fail to apply
+	if r.notifier != nil {
+		if changed[SettingWebhookURL] || changed[SettingWebhookSecret] {
+			if err = r.notifier.SetTargets(settings.WebhookURLs, settings.WebhookSecret); err != nil {
+				log.Warnf("failed to reload the configuration: %s", err)
+				return nil, err
+			}
+		}
+		if changed[SettingLowBalanceAlert] {
+			r.notifier.SetLowBalance(settings.LowBalanceAlert)
+		}
+	}
+
+	if changed[SettingLogLevel] {
+		cliutil.SetLogLevels(settings.LogLevel)
+	}
+	for _, ec := range r.ethClients {
+		if changed[SettingGasPrice] {
+			ec.SetGasPrice(settings.GasPrice)
+		}
+		if changed[SettingGasLimit] {
+			ec.SetGasLimit(settings.GasLimit)
+		}
+	}
+	if changed[SettingAllowPeer] || changed[SettingDenyPeer] {
+		r.host.SetPeerLists(settings.AllowedPeers, settings.DeniedPeers)
+	}
+	if changed[SettingRelayer] {
+		r.host.SetRelayer(settings.IsRelayer)
+	}
+	if changed[SettingMaxOfferPremium] {
+		r.rpcServer.SetMaxOfferPremium(settings.MaxOfferPremium)
+	}
+	if changed[SettingAllowToken] || changed[SettingDenyToken] {
+		r.tokenPolicy.SetLists(settings.TokenLists)
+	}
+
+	if r.applied != nil {
+		r.applied(settings)
+	}
+
+	if len(settings.Changed) == 0 {
+		log.Info("configuration reloaded, nothing changed")
+	} else {
+		log.Infof("configuration reloaded, changed: %s", strings.Join(settings.Changed, ", "))
+	}
+
+	return settings.Changed, nil
+}
+
+// serve reloads the configuration for every request until the context is
+// cancelled.
+func (r *reloader) serve(ctx context.Context, requests <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-requests:
+			_, _ = r.reload() // failures are logged
+		}
+	}
+}
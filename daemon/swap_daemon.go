@@ -13,20 +13,29 @@ import (
 	"path"
 
 	"github.com/ChainSafe/chaindb"
+	"github.com/cockroachdb/apd/v3"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/hashicorp/go-multierror"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
 
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	"github.com/athanorlabs/atomic-swap/db"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
 	"github.com/athanorlabs/atomic-swap/monero"
 	"github.com/athanorlabs/atomic-swap/net"
+	"github.com/athanorlabs/atomic-swap/orderbook"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
 	"github.com/athanorlabs/atomic-swap/protocol/backend"
 	"github.com/athanorlabs/atomic-swap/protocol/swap"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
+	"github.com/athanorlabs/atomic-swap/protocol/tokenpolicy"
 	"github.com/athanorlabs/atomic-swap/protocol/xmrmaker"
 	"github.com/athanorlabs/atomic-swap/protocol/xmrtaker"
+	"github.com/athanorlabs/atomic-swap/recovery"
 	"github.com/athanorlabs/atomic-swap/rpc"
+	"github.com/athanorlabs/atomic-swap/webhook"
 )
 
 const (
@@ -42,11 +51,55 @@ type SwapdConfig struct {
 	EnvConf        *common.Config
 	MoneroClient   monero.WalletClient
 	EthereumClient extethclient.EthClient
-	Libp2pPort     uint16
-	Libp2pKeyfile  string
-	RPCPort        uint16
-	IsRelayer      bool
-	NoTransferBack bool
+	// Proxy is the proxy of the outbound connections that swapd makes, like
+	// libp2p and price feed connections. Nil means direct connections.
+	Proxy           *netproxy.Proxy
+	Libp2pPort      uint16
+	Libp2pKeyfile   string
+	SwapKeySeedFile string
+	RPCPort         uint16
+	IsRelayer       bool
+	NoTransferBack  bool
+	PriceHistory    pricefeed.HistoricalPriceSource // optional, used to backfill swap valuations
+	PriceSources    *pricefeed.SourcesConfig        // optional, added to the default price sources
+	// MaxOfferPremium is the maximum relative difference, as a fraction,
+	// between the exchange rate of a taken offer and the market rate. Offers
+	// beyond it are refused unless the taker overrides the check. Nil disables
+	// the check.
+	MaxOfferPremium *apd.Decimal
+	// ChainClients are the ethereum clients of the additional chains of
+	// EnvConf, keyed by chain ID.
+	ChainClients map[uint64]extethclient.EthClient
+	// RPCCredentials, when set, are required from every RPC and websocket
+	// request.
+	RPCCredentials *rpc.Credentials
+
+	// WebhookURLs receive the notifications of swap and offer lifecycle
+	// events, signed with WebhookSecret. No notifications are sent if empty.
+	WebhookURLs   []string
+	WebhookSecret []byte
+	// LowBalanceAlert is the ETH balance below which a low balance
+	// notification is sent. Optional.
+	LowBalanceAlert *apd.Decimal
+
+	// AllowedPeers and DeniedPeers restrict the peers that can open streams
+	// with swapd, see net.Config.
+	AllowedPeers []peer.ID
+	DeniedPeers  []peer.ID
+
+	// TokenLists are the allowed and denied ERC20 tokens by chain ID, see
+	// tokenpolicy.Config.
+	TokenLists map[uint64]*tokenpolicy.Lists
+
+	// Reload, when set, re-reads the configuration on the daemon_reload RPC
+	// method and on every ReloadRequests message, and its RuntimeSettings
+	// are applied without a restart. ReloadApplied, when set, is called
+	// after all the changed settings were applied, so the configuration that
+	// Reload compares against is only updated once it is in use.
+	// ReloadRequests is optional.
+	Reload         func() (*RuntimeSettings, error)
+	ReloadApplied  func(*RuntimeSettings)
+	ReloadRequests <-chan struct{}
 }
 
 // RunSwapDaemon assembles and runs a swapd instance blocking until swapd is
@@ -59,6 +112,10 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 		conf.Libp2pKeyfile = path.Join(conf.EnvConf.DataDir, common.DefaultLibp2pKeyFileName)
 	}
 
+	if conf.SwapKeySeedFile == "" {
+		conf.SwapKeySeedFile = conf.EnvConf.SwapKeySeedFile()
+	}
+
 	if conf.EnvConf.SwapCreatorAddr == (ethcommon.Address{}) {
 		panic("swap creator address not specified")
 	}
@@ -82,6 +139,47 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 		return err
 	}
 
+	var notifier *webhook.Notifier
+	if len(conf.WebhookURLs) > 0 {
+		notifierCtx, cancel := context.WithCancel(ctx)
+		notifier, err = webhook.NewNotifier(notifierCtx, &webhook.Config{
+			URLs:   conf.WebhookURLs,
+			Secret: conf.WebhookSecret,
+			DB:     sdb,
+			Proxy:  conf.Proxy,
+		})
+		if err != nil {
+			cancel()
+			return err
+		}
+		// runs before the database is closed
+		defer func() {
+			cancel()
+			notifier.Wait()
+		}()
+
+		sm.AddListener(notifier)
+		notifier.Start()
+		notifier.StartWatcher(&webhook.WatcherConfig{
+			Swaps:      sm,
+			Balance:    conf.EthereumClient,
+			LowBalance: conf.LowBalanceAlert,
+		})
+		log.Infof("sending webhook notifications to %d URL(s)", len(conf.WebhookURLs))
+	}
+
+	swapKeySeed, created, err := swapkeys.LoadOrCreateSeedFile(conf.SwapKeySeedFile)
+	if err != nil {
+		return err
+	}
+	if err = recoverSwapKeyIndex(ctx, conf, sdb.RecoveryDB(), swapKeySeed, created); err != nil {
+		return err
+	}
+	if created {
+		log.Infof("New swap key seed generated in %s, back it up to be able to recover swaps without the database",
+			conf.SwapKeySeedFile)
+	}
+
 	hostListenIP := "0.0.0.0"
 	if conf.EnvConf.Env == common.Development {
 		hostListenIP = "127.0.0.1"
@@ -90,12 +188,15 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 	host, err := net.NewHost(&net.Config{
 		Ctx:       ctx,
 		Env:       conf.EnvConf.Env,
-		DataDir:   conf.EnvConf.DataDir,
 		Port:      conf.Libp2pPort,
 		KeyFile:   conf.Libp2pKeyfile,
 		Bootnodes: conf.EnvConf.Bootnodes,
 		ListenIP:  hostListenIP,
 		IsRelayer: conf.IsRelayer,
+		Proxy:     conf.Proxy,
+
+		AllowedPeers: conf.AllowedPeers,
+		DeniedPeers:  conf.DeniedPeers,
 	})
 	if err != nil {
 		return err
@@ -106,6 +207,26 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 		}
 	}()
 
+	// Tokens that can't be probed are accepted in dev environments, as
+	// ganache does not support the state overrides that probing needs
+	tokenPolicy := tokenpolicy.NewPolicy(&tokenpolicy.Config{
+		DB:            sdb,
+		Lists:         conf.TokenLists,
+		AllowUnprobed: conf.EnvConf.Env == common.Development,
+	})
+
+	var chains []*backend.ChainConfig
+	for _, chain := range conf.EnvConf.Chains {
+		ec, ok := conf.ChainClients[chain.ChainID]
+		if !ok {
+			return fmt.Errorf("no ethereum client for %s", chain)
+		}
+		chains = append(chains, &backend.ChainConfig{
+			EthereumClient:  ec,
+			SwapCreatorAddr: chain.SwapCreatorAddr,
+		})
+	}
+
 	swapBackend, err := backend.NewBackend(&backend.Config{
 		Ctx:             ctx,
 		MoneroClient:    conf.MoneroClient,
@@ -115,6 +236,9 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 		SwapManager:     sm,
 		RecoveryDB:      sdb.RecoveryDB(),
 		Net:             host,
+		SwapKeySeed:     swapKeySeed,
+		Chains:          chains,
+		TokenPolicy:     tokenPolicy,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to make backend: %w", err)
@@ -150,6 +274,40 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 		return err
 	}
 
+	bookCtx, cancelBook := context.WithCancel(ctx)
+	book, err := orderbook.NewBook(bookCtx, &orderbook.Config{
+		Net: host,
+		DB:  sdb,
+	})
+	if err != nil {
+		cancelBook()
+		return err
+	}
+	// runs before the host is stopped and the database is closed
+	defer func() {
+		cancelBook()
+		book.Wait()
+	}()
+	book.Start()
+
+	var reload rpc.ReloadFunc
+	var r *reloader
+	if conf.Reload != nil {
+		ethClients := []extethclient.EthClient{conf.EthereumClient}
+		for _, ec := range conf.ChainClients {
+			ethClients = append(ethClients, ec)
+		}
+		r = &reloader{
+			load:        conf.Reload,
+			applied:     conf.ReloadApplied,
+			ethClients:  ethClients,
+			host:        host,
+			notifier:    notifier,
+			tokenPolicy: tokenPolicy,
+		}
+		reload = r.reload
+	}
+
 	rpcServer, err := rpc.NewServer(&rpc.Config{
 		Ctx:             ctx,
 		Env:             conf.EnvConf.Env,
@@ -159,12 +317,26 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 		XMRMaker:        xmrMaker,
 		ProtocolBackend: swapBackend,
 		RecoveryDB:      sdb.RecoveryDB(),
+		PriceHistory:    conf.PriceHistory,
+		PriceSources:    conf.PriceSources,
+		Proxy:           conf.Proxy,
+		MaxOfferPremium: conf.MaxOfferPremium,
+		Credentials:     conf.RPCCredentials,
+		Reload:          reload,
+		OrderBook:       book,
 		Namespaces:      rpc.AllNamespaces(),
 	})
 	if err != nil {
 		return err
 	}
 
+	if r != nil {
+		r.rpcServer = rpcServer
+		if conf.ReloadRequests != nil {
+			go r.serve(ctx, conf.ReloadRequests)
+		}
+	}
+
 	log.Infof("starting swapd with data-dir %s", conf.EnvConf.DataDir)
 	err = rpcServer.Start()
 
@@ -178,3 +350,65 @@ func RunSwapDaemon(ctx context.Context, conf *SwapdConfig) (err error) {
 	// return statement below (not nil)
 	return err
 }
+
+// recoverSwapKeyIndex makes sure that swap keys derived from the seed are
+// never reused. A database without a swap key index, next to a seed that
+// already existed, is most likely a new database for a restored seed, so the
+// index is recovered from our swaps in the events of the swap contracts.
+func recoverSwapKeyIndex(
+	ctx context.Context,
+	conf *SwapdConfig,
+	rdb *db.RecoveryDB,
+	seed *swapkeys.Seed,
+	seedCreated bool,
+) error {
+	_, err := rdb.SwapKeyIndex()
+	switch {
+	case err == nil:
+		return nil
+	case !errors.Is(err, chaindb.ErrKeyNotFound):
+		return fmt.Errorf("failed to get swap key index: %w", err)
+	case seedCreated:
+		// no keys were derived from the new seed yet
+		return rdb.AdvanceSwapKeyIndex(0)
+	}
+
+	log.Infof("The database has no swap key index for the existing swap key seed %s, "+
+		"recovering it from the swap contract events, which can take a while", conf.SwapKeySeedFile)
+
+	scans := []*recovery.HistoryConfig{{SwapCreatorAddr: conf.EnvConf.SwapCreatorAddr}}
+	ethClients := []extethclient.EthClient{conf.EthereumClient}
+	for _, chain := range conf.EnvConf.Chains {
+		ec, ok := conf.ChainClients[chain.ChainID]
+		if !ok {
+			return fmt.Errorf("no ethereum client for %s", chain)
+		}
+		scans = append(scans, &recovery.HistoryConfig{SwapCreatorAddr: chain.SwapCreatorAddr})
+		ethClients = append(ethClients, ec)
+	}
+
+	// swap key indexes are shared by all chains, so their swaps are searched
+	// together
+	var swaps []*recovery.ChainSwap
+	for i, hc := range scans {
+		r := recovery.NewRecoverer(ctx, &recovery.Config{
+			Env:        conf.EnvConf.Env,
+			RecoveryDB: rdb,
+			EthClient:  ethClients[i],
+		})
+		chainSwaps, err := r.ScanChainSwaps(hc) //nolint:govet
+		if err != nil {
+			return fmt.Errorf("failed to recover swap key index: %w", err)
+		}
+		swaps = append(swaps, chainSwaps...)
+	}
+
+	next, err := recovery.NextSwapKeyIndex(swaps, seed, swapkeys.DefaultGapLimit)
+	if err != nil {
+		return fmt.Errorf("failed to recover swap key index: %w", err)
+	}
+
+	log.Infof("Recovered swap key index %d from %d swaps on chain, "+
+		"run `swaprecover history --rebuild` to rebuild the swaps themselves", next, len(swaps))
+	return rdb.AdvanceSwapKeyIndex(next)
+}
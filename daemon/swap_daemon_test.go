@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ChainSafe/chaindb"
 	"github.com/cockroachdb/apd/v3"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -21,10 +22,13 @@ import (
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
 	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/db"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
 	"github.com/athanorlabs/atomic-swap/monero"
 	"github.com/athanorlabs/atomic-swap/net"
+	pcommon "github.com/athanorlabs/atomic-swap/protocol"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
 	"github.com/athanorlabs/atomic-swap/rpcclient"
 	"github.com/athanorlabs/atomic-swap/tests"
 )
@@ -81,7 +85,7 @@ func TestRunSwapDaemon_SwapBobHasNoEth_AliceRelaysClaim(t *testing.T) {
 	ac := rpcclient.NewClient(ctx, aliceConf.RPCPort)
 
 	useRelayer := false // Bob will use the relayer regardless, because he has no ETH
-	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer)
+	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer, 0)
 	require.NoError(t, err)
 
 	aliceStatusCh, err := ac.TakeOfferAndSubscribe(makeResp.PeerID, makeResp.OfferID, providesAmt)
@@ -172,7 +176,7 @@ func TestRunSwapDaemon_NoRelayersAvailable_Refund(t *testing.T) {
 	ac := rpcclient.NewClient(ctx, aliceConf.RPCPort)
 
 	useRelayer := false // Bob will use unsuccessfully use the relayer regardless, because he has no ETH
-	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer)
+	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer, 0)
 	require.NoError(t, err)
 
 	aliceStatusCh, err := ac.TakeOfferAndSubscribe(makeResp.PeerID, makeResp.OfferID, providesAmt)
@@ -255,7 +259,7 @@ func TestRunSwapDaemon_CharlieRelays(t *testing.T) {
 	ac := rpcclient.NewClient(ctx, aliceConf.RPCPort)
 
 	useRelayer := false // Bob will use the relayer regardless, because he has no ETH
-	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer)
+	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer, 0)
 	require.NoError(t, err)
 
 	aliceStatusCh, err := ac.TakeOfferAndSubscribe(makeResp.PeerID, makeResp.OfferID, providesAmt)
@@ -357,7 +361,7 @@ func TestRunSwapDaemon_CharlieIsBroke_AliceRelays(t *testing.T) {
 	bc := rpcclient.NewClient(ctx, bobConf.RPCPort)
 	ac := rpcclient.NewClient(ctx, aliceConf.RPCPort)
 	useRelayer := false // Bob will use the relayer regardless, because he has no ETH
-	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer)
+	makeResp, bobStatusCh, err := bc.MakeOfferAndSubscribe(minXMR, maxXMR, exRate, types.EthAssetETH, useRelayer, 0)
 	require.NoError(t, err)
 
 	aliceStatusCh, err := ac.TakeOfferAndSubscribe(makeResp.PeerID, makeResp.OfferID, providesAmt)
@@ -447,3 +451,72 @@ func TestRunSwapDaemon_RPC_Shutdown(t *testing.T) {
 	err = c.Shutdown()
 	require.ErrorIs(t, err, syscall.ECONNREFUSED)
 }
+
+func TestRecoverSwapKeyIndex(t *testing.T) {
+	ctx := context.Background()
+	pk := tests.GetTakerTestKey(t)
+	ec := extethclient.CreateTestClient(t, pk)
+	swapCreatorAddr, swapCreator := contracts.DevDeploySwapCreator(t, ec.Raw(), pk)
+
+	// swap keys 0 to 2 were used by swaps that failed before any ETH was
+	// locked, and swap key 3 by a swap whose ETH is still locked
+	seed, err := swapkeys.NewSeed()
+	require.NoError(t, err)
+	offerID := types.Hash{0x1}
+	kp, err := pcommon.DeriveKeysAndProof(seed, offerID, 3)
+	require.NoError(t, err)
+
+	value := big.NewInt(1e15)
+	timeout := big.NewInt(3600)
+	tx, err := swapCreator.NewSwap(
+		tests.TxOptsWithValue(t, pk, value),
+		[32]byte{0x1},
+		kp.Secp256k1PublicKey.Keccak256(),
+		common.EthereumPrivateKeyToAddress(tests.GetMakerTestKey(t)),
+		timeout,
+		timeout,
+		types.EthAssetETH.Address(),
+		value,
+		offerID.Big(),
+	)
+	require.NoError(t, err)
+	tests.MineTransaction(t, ec.Raw(), tx)
+
+	envConf := new(common.Config)
+	*envConf = *common.ConfigDefaultsForEnv(common.Development)
+	envConf.SwapCreatorAddr = swapCreatorAddr
+	conf := &SwapdConfig{
+		EnvConf:         envConf,
+		EthereumClient:  ec,
+		SwapKeySeedFile: "swap-seed.key",
+	}
+
+	newRecoveryDB := func() *db.RecoveryDB {
+		sdb, err := db.NewDatabase(&chaindb.Config{DataDir: t.TempDir()}) //nolint:govet
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, sdb.Close())
+		})
+		return sdb.RecoveryDB()
+	}
+
+	// a new seed starts from index 0
+	rdb := newRecoveryDB()
+	require.NoError(t, recoverSwapKeyIndex(ctx, conf, rdb, seed, true))
+	index, err := rdb.SwapKeyIndex()
+	require.NoError(t, err)
+	require.Zero(t, index)
+
+	// an existing index is kept
+	require.NoError(t, recoverSwapKeyIndex(ctx, conf, rdb, seed, false))
+	index, err = rdb.SwapKeyIndex()
+	require.NoError(t, err)
+	require.Zero(t, index)
+
+	// the index of a restored seed is recovered from the swaps on chain
+	rdb = newRecoveryDB()
+	require.NoError(t, recoverSwapKeyIndex(ctx, conf, rdb, seed, false))
+	index, err = rdb.SwapKeyIndex()
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), index)
+}
@@ -35,7 +35,7 @@ import (
 // for testing
 func CreateTestConf(t *testing.T, ethKey *ecdsa.PrivateKey) *SwapdConfig {
 	ctx := context.Background()
-	ec, err := extethclient.NewEthClient(ctx, common.Development, common.DefaultGanacheEndpoint, ethKey)
+	ec, err := extethclient.NewEthClient(ctx, common.Development, common.DefaultGanacheEndpoint, nil, ethKey)
 	require.NoError(t, err)
 	t.Cleanup(func() {
 		ec.Close()
@@ -6,6 +6,7 @@ package db
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/ChainSafe/chaindb"
 	logging "github.com/ipfs/go-log/v2"
@@ -16,9 +17,16 @@ import (
 )
 
 const (
-	offerPrefix = "offer"
-	swapPrefix  = "swap"
-	idLength    = len(types.Hash{})
+	offerPrefix   = "offer"
+	swapPrefix    = "swap"
+	journalPrefix = "journal"
+	webhookPrefix = "webhook"
+	tokenPrefix   = "token"
+	bookPrefix    = "orderbook"
+	// swapIndexPrefix must not start with any other table's prefix, or that
+	// table's iterators would also visit the index keys
+	swapIndexPrefix = "pastidx"
+	idLength        = len(types.Hash{})
 )
 
 var (
@@ -27,6 +35,9 @@ var (
 
 // Database is the persistent datastore used by swapd.
 type Database struct {
+	// db is the underlying database that all the tables below share
+	db *chaindb.BadgerDB
+
 	// offerTable is a key-value store where all the keys are prefixed by offerPrefix
 	// in the underlying database.
 	// the key is the 32-byte offer ID and the value is a JSON-marshalled *types.Offer.
@@ -42,13 +53,65 @@ type Database struct {
 	// only their `Status` field within *swap.Info may be updated.
 	swapTable chaindb.Database
 
+	// indexTable is a key-value store where all the keys are prefixed by
+	// swapIndexPrefix in the underlying database.
+	// it holds the secondary indexes of past swaps, by start time, status, peer
+	// ID and ETH asset. The values are empty; see pastSwapIndexKeys for the
+	// format of the keys. Ongoing swaps are not indexed.
+	indexTable chaindb.Database
+
+	// journalTable is a key-value store where all the keys are prefixed by
+	// journalPrefix in the underlying database.
+	// the key is the 32-byte swap ID followed by the 8-byte big endian
+	// sequence number of the entry, and the value is the JSON-marshalled
+	// *swap.JournalEntry. Entries are only ever appended to a journal, and
+	// journals are never deleted.
+	journalTable chaindb.Database
+	journalMu    sync.Mutex
+	journalSeqs  map[types.Hash]uint64 // next sequence number of each journal appended to
+
+	// webhookTable is a key-value store where all the keys are prefixed by
+	// webhookPrefix in the underlying database.
+	// the key is the 32-byte delivery ID and the value is a JSON-marshalled
+	// *webhook.Delivery. Deliveries are removed once they succeed or run out
+	// of attempts.
+	webhookTable chaindb.Database
+
+	// tokenTable is a key-value store where all the keys are prefixed by
+	// tokenPrefix in the underlying database.
+	// the key is the 8-byte big endian chain ID followed by the 20-byte token
+	// address, and the value is the JSON-marshalled *tokenpolicy.Probe of the
+	// token. Probes are replaced when a token is probed again.
+	tokenTable chaindb.Database
+
+	// bookTable is a key-value store where all the keys are prefixed by
+	// bookPrefix in the underlying database.
+	// the key is the 32-byte offer ID followed by the peer ID of the maker, and
+	// the value is a JSON-marshalled *orderbook.Entry. Entries are removed when
+	// the maker no longer has the offer or it was not seen for a while.
+	bookTable chaindb.Database
+
+	// metaTable is a key-value store where all the keys are prefixed by
+	// metaPrefix in the underlying database. It holds information about the
+	// database itself, such as its schema version.
+	metaTable chaindb.Database
+
+	// invalidTable is a key-value store where all the keys are prefixed by
+	// invalidPrefix in the underlying database. Records that can no longer be
+	// decoded are moved here, keyed by their original table prefix and ID, so
+	// that they can be inspected manually.
+	invalidTable chaindb.Database
+
 	// recoveryDB contains a db table prefixed by recoveryPrefix.
 	// it contains information about ongoing swaps required to recover funds
 	// in case of a node crash, or any other problem.
 	recoveryDB *RecoveryDB
 }
 
-// NewDatabase returns a new *Database.
+// NewDatabase returns a new *Database. Older databases are migrated to the
+// current schema version, after being backed up. An error wrapping
+// ErrDatabaseTooNew is returned if the database was written by a newer version
+// of swapd.
 func NewDatabase(cfg *chaindb.Config) (*Database, error) {
 	db, err := chaindb.NewBadgerDB(cfg)
 	if err != nil {
@@ -57,11 +120,27 @@ func NewDatabase(cfg *chaindb.Config) (*Database, error) {
 
 	recoveryDB := newRecoveryDB(chaindb.NewTable(db, recoveryPrefix))
 
-	return &Database{
-		offerTable: chaindb.NewTable(db, offerPrefix),
-		swapTable:  chaindb.NewTable(db, swapPrefix),
-		recoveryDB: recoveryDB,
-	}, nil
+	database := &Database{
+		db:           db,
+		offerTable:   chaindb.NewTable(db, offerPrefix),
+		swapTable:    chaindb.NewTable(db, swapPrefix),
+		indexTable:   chaindb.NewTable(db, swapIndexPrefix),
+		journalTable: chaindb.NewTable(db, journalPrefix),
+		journalSeqs:  make(map[types.Hash]uint64),
+		webhookTable: chaindb.NewTable(db, webhookPrefix),
+		tokenTable:   chaindb.NewTable(db, tokenPrefix),
+		bookTable:    chaindb.NewTable(db, bookPrefix),
+		metaTable:    chaindb.NewTable(db, metaPrefix),
+		invalidTable: chaindb.NewTable(db, invalidPrefix),
+		recoveryDB:   recoveryDB,
+	}
+
+	if err = database.runMigrations(cfg); err != nil {
+		_ = database.Close()
+		return nil, err
+	}
+
+	return database, nil
 }
 
 // Close flushes and closes the database.
@@ -76,6 +155,41 @@ func (db *Database) Close() error {
 		return err
 	}
 
+	err = db.indexTable.Close()
+	if err != nil {
+		return err
+	}
+
+	err = db.journalTable.Close()
+	if err != nil {
+		return err
+	}
+
+	err = db.webhookTable.Close()
+	if err != nil {
+		return err
+	}
+
+	err = db.tokenTable.Close()
+	if err != nil {
+		return err
+	}
+
+	err = db.bookTable.Close()
+	if err != nil {
+		return err
+	}
+
+	err = db.metaTable.Close()
+	if err != nil {
+		return err
+	}
+
+	err = db.invalidTable.Close()
+	if err != nil {
+		return err
+	}
+
 	return db.recoveryDB.close()
 }
 
@@ -116,12 +230,13 @@ func (db *Database) GetOffer(id types.Hash) (*types.Offer, error) {
 	return types.UnmarshalOffer(val)
 }
 
-// purgeInvalidOffer purges an offer after its JSON entry failed to decode when GetAllOffers
-// is called on start. We also purge any swap entry with the same offer ID.
+// purgeInvalidOffer moves an offer to the invalid table after its JSON entry failed to
+// decode when GetAllOffers is called on start. We also purge any swap entry with the same
+// offer ID.
 func (db *Database) purgeInvalidOffer(id []byte, encodedOffer string, reasonErr error) error {
 	log.Warnf("removing invalid offer with ID=0x%X from database: %s", id, reasonErr)
 	log.Warnf("invalid offer JSON was: %s", encodedOffer)
-	if err := db.offerTable.Del(id[:]); err != nil {
+	if err := db.quarantine(db.offerTable, offerPrefix, id, []byte(encodedOffer)); err != nil {
 		return err
 	}
 	swapEncoded, err := db.swapTable.Get(id[:])
@@ -132,7 +247,7 @@ func (db *Database) purgeInvalidOffer(id []byte, encodedOffer string, reasonErr
 		return err
 	}
 	log.Warnf("removing invalid offer's swap entry: %s", swapEncoded)
-	return db.swapTable.Del(id[:])
+	return db.quarantine(db.swapTable, swapPrefix, id, swapEncoded)
 }
 
 // GetAllOffers returns all offers in the database.
@@ -185,19 +300,31 @@ func (db *Database) ClearAllOffers() error {
 
 // PutSwap puts the given swap in the database.
 // If a swap with the same ID is already in the database, it overwrites it.
+// The past swap index is updated to match.
 func (db *Database) PutSwap(s *swap.Info) error {
 	val, err := vjson.MarshalStruct(s)
 	if err != nil {
 		return err
 	}
 
+	prev, err := db.GetSwap(s.OfferID)
+	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+		// an undecodable record has no index keys to remove
+		prev = nil
+	}
+
 	key := s.OfferID
 	err = db.swapTable.Put(key[:], val)
 	if err != nil {
 		return err
 	}
 
-	return db.swapTable.Flush()
+	err = db.swapTable.Flush()
+	if err != nil {
+		return err
+	}
+
+	return db.updateSwapIndex(prev, s)
 }
 
 // HasSwap returns whether the db contains a swap with the given ID.
@@ -242,7 +369,7 @@ func (db *Database) GetAllSwaps() ([]*swap.Info, error) {
 		if err != nil {
 			log.Warnf("removing invalid swap info with offerID=0x%X: %s", id, err)
 			log.Warnf("invalid swap info JSON was: %s", string(encodedSwap))
-			if err = db.swapTable.Del(id[:]); err != nil {
+			if err = db.quarantine(db.swapTable, swapPrefix, id, encodedSwap); err != nil {
 				return nil, err
 			}
 		} else {
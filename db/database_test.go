@@ -162,6 +162,15 @@ func TestDatabase_GetAllOffers_InvalidEntry(t *testing.T) {
 	exists, err = db.swapTable.Has(badOfferID[:])
 	require.NoError(t, err)
 	require.False(t, exists) // swap info tied to removed offer pruned
+
+	// both pruned entries are kept in the invalid table for manual inspection
+	exists, err = db.invalidTable.Has(append([]byte(offerPrefix), badOfferID[:]...))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = db.invalidTable.Has(append([]byte(swapPrefix), badOfferID[:]...))
+	require.NoError(t, err)
+	require.True(t, exists)
 }
 
 func TestDatabase_SwapTable(t *testing.T) {
@@ -0,0 +1,101 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+// journalKeyLength is the length of the keys in the journal table, which are
+// the 32-byte swap ID followed by the 8-byte big endian sequence number of the
+// entry within the swap's journal.
+const journalKeyLength = idLength + 8
+
+var _ swap.JournalDatabase = (*Database)(nil)
+
+func journalEntryKey(id types.Hash, seq uint64) []byte {
+	key := make([]byte, journalKeyLength)
+	copy(key, id[:])
+	binary.BigEndian.PutUint64(key[idLength:], seq)
+	return key
+}
+
+// AppendJournalEntry appends the entry to the journal of the swap with the
+// given ID. Each entry is stored under its own key, so earlier entries are
+// never rewritten.
+func (db *Database) AppendJournalEntry(id types.Hash, entry *swap.JournalEntry) error {
+	db.journalMu.Lock()
+	defer db.journalMu.Unlock()
+
+	seq, ok := db.journalSeqs[id]
+	if !ok {
+		db.scanJournal(id, func(entrySeq uint64, _ []byte) {
+			seq = entrySeq + 1
+		})
+	}
+
+	val, err := vjson.MarshalStruct(entry)
+	if err != nil {
+		return err
+	}
+
+	err = db.journalTable.Put(journalEntryKey(id, seq), val)
+	if err != nil {
+		return err
+	}
+
+	if err = db.journalTable.Flush(); err != nil {
+		return err
+	}
+
+	db.journalSeqs[id] = seq + 1
+	return nil
+}
+
+// GetJournal returns the journal of the swap with the given ID, oldest entry
+// first. A swap without any journal entries returns an empty journal. Entries
+// that can't be decoded are skipped.
+func (db *Database) GetJournal(id types.Hash) ([]*swap.JournalEntry, error) {
+	db.journalMu.Lock()
+	defer db.journalMu.Unlock()
+
+	entries := []*swap.JournalEntry{}
+	db.scanJournal(id, func(seq uint64, val []byte) {
+		entry := new(swap.JournalEntry)
+		if err := vjson.UnmarshalStruct(val, entry); err != nil {
+			log.Warnf("skipping invalid journal entry %d of swap %s: %s", seq, id, err)
+			return
+		}
+		entries = append(entries, entry)
+	})
+
+	return entries, nil
+}
+
+// scanJournal calls fn with the sequence number and value of each entry in
+// the swap's journal, in order.
+func (db *Database) scanJournal(id types.Hash, fn func(seq uint64, val []byte)) {
+	iter := db.journalTable.NewIterator()
+	defer iter.Release()
+
+	// Seek takes the key including the table prefix
+	iter.(seeker).Seek(append([]byte(journalPrefix), id[:]...))
+
+	for iter.Valid() {
+		key := iter.Key()
+
+		// stop once we're past the swap's entries, or past the table
+		if len(key) != journalKeyLength || !bytes.HasPrefix(key, id[:]) {
+			break
+		}
+
+		fn(binary.BigEndian.Uint64(key[idLength:]), iter.Value())
+		iter.Next()
+	}
+}
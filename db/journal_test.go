@@ -0,0 +1,102 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+func TestDatabase_Journal(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	offerID := types.Hash{0x1}
+
+	entries, err := db.GetJournal(offerID)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	txHash := types.Hash{0x2}
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewStatusJournalEntry(types.ExpectingKeys)))
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewEthTxJournalEntry("new_swap", txHash)))
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewErrorJournalEntry(errors.New("failed"))))
+
+	// other swaps' journals are independent
+	require.NoError(t, db.AppendJournalEntry(types.Hash{0x3}, swap.NewTimeoutJournalEntry("t1")))
+
+	entries, err = db.GetJournal(offerID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, swap.JournalStatus, entries[0].Type)
+	require.Equal(t, types.ExpectingKeys, *entries[0].Status)
+	require.Equal(t, swap.JournalEthTx, entries[1].Type)
+	require.Equal(t, txHash, *entries[1].TxHash)
+	require.Equal(t, swap.JournalError, entries[2].Type)
+	require.Equal(t, "failed", entries[2].Detail)
+}
+
+func TestDatabase_Journal_appendAfterReopen(t *testing.T) {
+	cfg := &chaindb.Config{DataDir: t.TempDir()}
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+
+	offerID := types.Hash{0x1}
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewStatusJournalEntry(types.ExpectingKeys)))
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewStatusJournalEntry(types.KeysExchanged)))
+	require.NoError(t, db.Close())
+
+	db, err = NewDatabase(cfg)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	// the sequence continues after the entries already on disk
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewTimeoutJournalEntry("t0")))
+
+	entries, err := db.GetJournal(offerID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, types.ExpectingKeys, *entries[0].Status)
+	require.Equal(t, types.KeysExchanged, *entries[1].Status)
+	require.Equal(t, "t0", entries[2].Detail)
+
+	for seq := uint64(0); seq < 3; seq++ {
+		exists, err := db.journalTable.Has(journalEntryKey(offerID, seq))
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+}
+
+func TestDatabase_Journal_invalidEntry(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	offerID := types.Hash{0x1}
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewStatusJournalEntry(types.ExpectingKeys)))
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewTimeoutJournalEntry("t0")))
+	require.NoError(t, db.AppendJournalEntry(offerID, swap.NewTimeoutJournalEntry("t1")))
+
+	// a bad write only loses the entry that was written
+	require.NoError(t, db.journalTable.Put(journalEntryKey(offerID, 1), []byte("{")))
+
+	entries, err := db.GetJournal(offerID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, swap.JournalStatus, entries[0].Type)
+	require.Equal(t, "t1", entries[1].Detail)
+}
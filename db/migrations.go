@@ -0,0 +1,239 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+const (
+	metaPrefix       = "meta"
+	invalidPrefix    = "invalid"
+	schemaVersionKey = "schemaVersion"
+
+	backupDirTimeFmt = "20060102-150405"
+)
+
+// CurSchemaVersion is the version of the database schema written by this
+// version of swapd. It must be incremented, and a migration added to
+// `migrations`, whenever the format of a stored record changes in a way that
+// older records need to be rewritten.
+const CurSchemaVersion uint64 = 1
+
+// ErrDatabaseTooNew is returned when opening a database that was written by a
+// newer version of swapd.
+var ErrDatabaseTooNew = errors.New("database was written by a newer version of swapd")
+
+// migration upgrades the database from version-1 to version.
+type migration struct {
+	version     uint64
+	description string
+	migrate     func(db *Database) error
+}
+
+// migrations must be in ascending version order, with no gaps, ending in
+// CurSchemaVersion.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "quarantine unparsable records and build the past swap index",
+		migrate:     migrateV1,
+	},
+}
+
+// getSchemaVersion returns the schema version of the database. Databases
+// created before schema versioning was added have version 0. For a new,
+// empty database, isNew is true.
+func (db *Database) getSchemaVersion() (version uint64, isNew bool, err error) {
+	val, err := db.metaTable.Get([]byte(schemaVersionKey))
+	if err == nil {
+		version, err = strconv.ParseUint(string(val), 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid database schema version %q: %w", val, err)
+		}
+		return version, false, nil
+	}
+	if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, false, err
+	}
+
+	iter := db.db.NewIterator()
+	defer iter.Release()
+	return 0, !iter.Next(), nil
+}
+
+func (db *Database) putSchemaVersion(version uint64) error {
+	err := db.metaTable.Put([]byte(schemaVersionKey), []byte(strconv.FormatUint(version, 10)))
+	if err != nil {
+		return err
+	}
+
+	return db.metaTable.Flush()
+}
+
+// runMigrations brings the database up to CurSchemaVersion, backing up the
+// database first if there is anything to migrate.
+func (db *Database) runMigrations(cfg *chaindb.Config) error {
+	version, isNew, err := db.getSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if isNew {
+		return db.putSchemaVersion(CurSchemaVersion)
+	}
+
+	if version > CurSchemaVersion {
+		return fmt.Errorf("%w: database schema version is %d, latest supported is %d",
+			ErrDatabaseTooNew, version, CurSchemaVersion)
+	}
+
+	if version == CurSchemaVersion {
+		return nil
+	}
+
+	if !cfg.InMemory {
+		backupDir := fmt.Sprintf("%s-backup-v%d-%s", cfg.DataDir, version, time.Now().Format(backupDirTimeFmt))
+		log.Infof("backing up database before migrating from schema version %d to %d: %s",
+			version, CurSchemaVersion, backupDir)
+		if err = db.backup(backupDir); err != nil {
+			return fmt.Errorf("failed to back up database: %w", err)
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		log.Infof("migrating database to schema version %d: %s", m.version, m.description)
+		if err = m.migrate(db); err != nil {
+			return fmt.Errorf("database migration to schema version %d failed: %w", m.version, err)
+		}
+
+		if err = db.putSchemaVersion(m.version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backup copies every key in the database into a new database in dir. To
+// restore the backup, replace the database directory with dir.
+func (db *Database) backup(dir string) error {
+	backupDB, err := chaindb.NewBadgerDB(&chaindb.Config{DataDir: dir})
+	if err != nil {
+		return err
+	}
+
+	iter := db.db.NewIterator()
+	defer iter.Release()
+
+	batch := backupDB.NewBatch()
+	for iter.Next() {
+		if err = batch.Put(iter.Key(), iter.Value()); err != nil {
+			_ = backupDB.Close()
+			return err
+		}
+	}
+
+	if err = batch.Flush(); err != nil {
+		_ = backupDB.Close()
+		return err
+	}
+
+	return backupDB.Close()
+}
+
+// quarantine moves a record that can't be decoded out of its table into the
+// invalid table, so that it no longer interferes with swapd, but is still
+// available for manual inspection.
+func (db *Database) quarantine(table chaindb.Database, prefix string, id []byte, value []byte) error {
+	key := append([]byte(prefix), id...)
+	if err := db.invalidTable.Put(key, value); err != nil {
+		return err
+	}
+
+	if err := db.invalidTable.Flush(); err != nil {
+		return err
+	}
+
+	return table.Del(id)
+}
+
+// migrateV1 moves offers and swaps that can't be decoded to the invalid table,
+// and then adds the remaining past swaps to the past swap index. Databases
+// created before schema versioning can have such records, which would make
+// building the index fail. Swap records with an older Info version are not
+// rewritten, since they are decoded as the current version.
+func migrateV1(db *Database) error {
+	offers, err := getRawRecords(db.offerTable)
+	if err != nil {
+		return err
+	}
+
+	for id, value := range offers {
+		if _, err = types.UnmarshalOffer(value); err == nil {
+			continue
+		}
+
+		log.Warnf("quarantining invalid offer with ID=0x%X: %s", id, err)
+		if err = db.quarantine(db.offerTable, offerPrefix, []byte(id), value); err != nil {
+			return err
+		}
+	}
+
+	swaps, err := getRawRecords(db.swapTable)
+	if err != nil {
+		return err
+	}
+
+	for id, value := range swaps {
+		s, err := swap.UnmarshalInfo(value) //nolint:govet
+		if err != nil {
+			log.Warnf("quarantining invalid swap with ID=0x%X: %s", id, err)
+			if err = db.quarantine(db.swapTable, swapPrefix, []byte(id), value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = db.updateSwapIndex(nil, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getRawRecords returns the undecoded values of a table whose keys are
+// 32-byte IDs.
+func getRawRecords(table chaindb.Database) (map[string][]byte, error) {
+	iter := table.NewIterator()
+	defer iter.Release()
+
+	records := make(map[string][]byte)
+	for iter.Valid() {
+		id := iter.Key()
+
+		// if the key becomes longer than 32, we're not iterating over the table
+		if len(id) > idLength {
+			break
+		}
+
+		records[string(id)] = iter.Value()
+		iter.Next()
+	}
+
+	return records, nil
+}
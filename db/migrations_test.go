@@ -0,0 +1,125 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+func TestDatabase_newDatabaseHasCurrentSchema(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	version, isNew, err := db.getSchemaVersion()
+	require.NoError(t, err)
+	require.False(t, isNew)
+	require.Equal(t, CurSchemaVersion, version)
+}
+
+func TestDatabase_migrateFromV0(t *testing.T) {
+	dataDir := path.Join(t.TempDir(), "db")
+	cfg := &chaindb.Config{DataDir: dataDir}
+
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+
+	// Turn the database into one created before schema versioning, with an
+	// unindexed swap at an older Info version, and an offer and a swap that
+	// can't be decoded.
+	require.NoError(t, db.metaTable.Del([]byte(schemaVersionKey)))
+
+	oldInfo := &swap.Info{
+		Version:              swap.CurInfoVersion,
+		PeerID:               testPeerID,
+		OfferID:              types.Hash{0x1},
+		Provides:             coins.ProvidesXMR,
+		ProvidedAmount:       coins.StrToDecimal("0.1"),
+		ExpectedAmount:       coins.StrToDecimal("1"),
+		ExchangeRate:         coins.StrToExchangeRate("0.1"),
+		EthAsset:             types.EthAsset{},
+		Status:               types.CompletedSuccess,
+		LastStatusUpdateTime: time.Now(),
+		MoneroStartHeight:    12345,
+		StartTime:            time.Now().Add(-30 * time.Minute),
+	}
+	infoJSON, err := vjson.MarshalStruct(oldInfo)
+	require.NoError(t, err)
+	infoJSON = []byte(strings.Replace(string(infoJSON), swap.CurInfoVersion.String(), "0.2.0", 1))
+	require.NoError(t, db.swapTable.Put(oldInfo.OfferID[:], infoJSON))
+
+	badOfferID := types.Hash{0x2}
+	require.NoError(t, db.offerTable.Put(badOfferID[:], []byte(`{"key":"value"}`)))
+	badSwapID := types.Hash{0x3}
+	require.NoError(t, db.swapTable.Put(badSwapID[:], []byte(`{"key":"value"}`)))
+	require.NoError(t, db.Close())
+
+	db, err = NewDatabase(cfg)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	version, _, err := db.getSchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, CurSchemaVersion, version)
+
+	// the swap at an older Info version is readable and indexed
+	_, err = db.GetSwap(oldInfo.OfferID)
+	require.NoError(t, err)
+	page, err := db.QueryPastSwaps(&swap.PastSwapsQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []types.Hash{oldInfo.OfferID}, offerIDs(page.Swaps))
+
+	exists, err := db.offerTable.Has(badOfferID[:])
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	exists, err = db.invalidTable.Has(append([]byte(offerPrefix), badOfferID[:]...))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = db.invalidTable.Has(append([]byte(swapPrefix), badSwapID[:]...))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// the pre-migration database was backed up
+	backups, err := filepath.Glob(dataDir + "-backup-v0-*")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	backupDB, err := chaindb.NewBadgerDB(&chaindb.Config{DataDir: backups[0]})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, backupDB.Close()) }()
+	backupInfoJSON, err := backupDB.Get(append([]byte(swapPrefix), oldInfo.OfferID[:]...))
+	require.NoError(t, err)
+	require.Equal(t, infoJSON, backupInfoJSON)
+}
+
+func TestDatabase_refuseNewerSchema(t *testing.T) {
+	cfg := &chaindb.Config{DataDir: t.TempDir()}
+
+	db, err := NewDatabase(cfg)
+	require.NoError(t, err)
+	require.NoError(t, db.putSchemaVersion(CurSchemaVersion+1))
+	require.NoError(t, db.Close())
+
+	_, err = NewDatabase(cfg)
+	require.ErrorIs(t, err, ErrDatabaseTooNew)
+	require.ErrorContains(t, err, strconv.FormatUint(CurSchemaVersion+1, 10))
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+	"github.com/athanorlabs/atomic-swap/orderbook"
+)
+
+var _ orderbook.Database = (*Database)(nil)
+
+func bookEntryKey(peerID peer.ID, offerID types.Hash) []byte {
+	return append(offerID[:], []byte(peerID)...)
+}
+
+// PutOrderBookEntry stores an offer of a remote peer, replacing the earlier
+// entry of the offer if there is one.
+func (db *Database) PutOrderBookEntry(e *orderbook.Entry) error {
+	val, err := vjson.MarshalStruct(e)
+	if err != nil {
+		return err
+	}
+
+	err = db.bookTable.Put(bookEntryKey(e.PeerID, e.Offer.ID), val)
+	if err != nil {
+		return err
+	}
+
+	return db.bookTable.Flush()
+}
+
+// DeleteOrderBookEntry deletes an offer of a remote peer.
+func (db *Database) DeleteOrderBookEntry(peerID peer.ID, offerID types.Hash) error {
+	return db.bookTable.Del(bookEntryKey(peerID, offerID))
+}
+
+// GetAllOrderBookEntries returns all stored offers of remote peers. Entries
+// that can no longer be decoded are moved to the invalid table.
+func (db *Database) GetAllOrderBookEntries() ([]*orderbook.Entry, error) {
+	iter := db.bookTable.NewIterator()
+	defer iter.Release()
+
+	var entries []*orderbook.Entry
+	for iter.Valid() {
+		key := iter.Key()
+
+		// if the key is not an offer ID followed by a peer ID, we're not
+		// iterating over order book entries
+		if len(key) <= idLength {
+			break
+		}
+		if _, err := peer.IDFromBytes(key[idLength:]); err != nil {
+			break
+		}
+
+		encoded := iter.Value()
+
+		e := new(orderbook.Entry)
+		if err := vjson.UnmarshalStruct(encoded, e); err != nil {
+			log.Warnf("removing invalid order book entry with key=0x%X: %s", key, err)
+			if err = db.quarantine(db.bookTable, bookPrefix, key, encoded); err != nil {
+				return nil, err
+			}
+		} else {
+			entries = append(entries, e)
+		}
+
+		iter.Next()
+	}
+
+	return entries, nil
+}
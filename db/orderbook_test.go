@@ -0,0 +1,89 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/orderbook"
+)
+
+func TestDatabase_OrderBookEntries(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	alice, err := peer.Decode("12D3KooWAYn1T8Lu122Pav4zAogjpeU61usLTNZpLRNh9gCqY6X2")
+	require.NoError(t, err)
+	bob, err := peer.Decode("12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5")
+	require.NoError(t, err)
+
+	entries, err := db.GetAllOrderBookEntries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	one := coins.StrToDecimal("1")
+	offer := types.NewOffer(coins.ProvidesXMR, one, one, coins.ToExchangeRate(one), types.EthAssetETH)
+	now := time.Now().Round(0)
+
+	// the same offer can be in the book for two peers
+	for _, peerID := range []peer.ID{alice, bob} {
+		require.NoError(t, db.PutOrderBookEntry(&orderbook.Entry{
+			PeerID:    peerID,
+			Offer:     offer,
+			FirstSeen: now,
+			LastSeen:  now,
+		}))
+	}
+
+	entries, err = db.GetAllOrderBookEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, offer.ID, entries[0].Offer.ID)
+	require.True(t, now.Equal(entries[0].LastSeen))
+
+	require.NoError(t, db.DeleteOrderBookEntry(alice, offer.ID))
+	entries, err = db.GetAllOrderBookEntries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, bob, entries[0].PeerID)
+}
+
+func TestDatabase_GetAllOrderBookEntries_otherTables(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	// the recovery table follows the empty book table
+	swapID := types.Hash{0x1}
+	txHash := types.Hash{0x2}
+	require.NoError(t, db.RecoveryDB().PutNewSwapTxHash(swapID, txHash))
+	require.NoError(t, db.RecoveryDB().AdvanceSwapKeyIndex(1))
+
+	entries, err := db.GetAllOrderBookEntries()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	recoveryKey := append([]byte(recoveryPrefix), getRecoveryDBKey(swapID, newSwapTxHashPrefix)...)
+	quarantined, err := db.invalidTable.Has(append([]byte(bookPrefix), recoveryKey...))
+	require.NoError(t, err)
+	require.False(t, quarantined)
+
+	storedHash, err := db.RecoveryDB().GetNewSwapTxHash(swapID)
+	require.NoError(t, err)
+	require.Equal(t, txHash, storedHash)
+}
@@ -4,6 +4,12 @@
 package db
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
 	"github.com/athanorlabs/atomic-swap/common/types"
 	"github.com/athanorlabs/atomic-swap/common/vjson"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
@@ -21,10 +27,17 @@ const (
 	newSwapTxHashPrefix              = "newswap"
 )
 
+// swapKeyIndexKey is the key of the swap key index, which isn't per swap
+var swapKeyIndexKey = []byte("swapkeyindex")
+
 // RecoveryDB contains information about ongoing swaps required for recovery
 // in case of shutdown.
 type RecoveryDB struct {
 	db chaindb.Database
+
+	// swapKeyIndexMu makes reading and storing the swap key index atomic, as
+	// maker and taker swaps derive their keys concurrently
+	swapKeyIndexMu sync.Mutex
 }
 
 func newRecoveryDB(db chaindb.Database) *RecoveryDB {
@@ -244,6 +257,74 @@ func (db *RecoveryDB) GetNewSwapTxHash(id types.Hash) (types.Hash, error) {
 	return txHash, nil
 }
 
+// SwapKeyIndex returns the index that the next swap keys are derived from
+// the swap key seed with. It returns chaindb.ErrKeyNotFound if the index was
+// never stored, which is the case for a new database.
+func (db *RecoveryDB) SwapKeyIndex() (uint32, error) {
+	value, err := db.db.Get(swapKeyIndexKey)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid swap key index length %d", len(value))
+	}
+
+	return binary.BigEndian.Uint32(value), nil
+}
+
+// NextSwapKeyIndex returns the index to derive the next swap keys with, and
+// increments the stored index. The index is global, so derived keys are never
+// reused, whatever the offer or our role in the swap.
+func (db *RecoveryDB) NextSwapKeyIndex() (uint32, error) {
+	db.swapKeyIndexMu.Lock()
+	defer db.swapKeyIndexMu.Unlock()
+
+	index, err := db.SwapKeyIndex()
+	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return 0, err
+	}
+
+	if index == math.MaxUint32 {
+		return 0, errors.New("no swap key indexes are left")
+	}
+
+	if err = db.putSwapKeyIndex(index + 1); err != nil {
+		return 0, err
+	}
+
+	return index, nil
+}
+
+// AdvanceSwapKeyIndex stores the given index as the index to derive the next
+// swap keys with, unless the stored index is already past it. It is called
+// with the index found by recovering swaps from the swap key seed.
+func (db *RecoveryDB) AdvanceSwapKeyIndex(next uint32) error {
+	db.swapKeyIndexMu.Lock()
+	defer db.swapKeyIndexMu.Unlock()
+
+	index, err := db.SwapKeyIndex()
+	if err != nil && !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return err
+	}
+
+	if err == nil && index > next {
+		next = index
+	}
+
+	return db.putSwapKeyIndex(next)
+}
+
+func (db *RecoveryDB) putSwapKeyIndex(index uint32) error {
+	var value [4]byte
+	binary.BigEndian.PutUint32(value[:], index)
+	if err := db.db.Put(swapKeyIndexKey, value[:]); err != nil {
+		return err
+	}
+
+	return db.db.Flush()
+}
+
 // DeleteSwap deletes all recovery info from the db for the given swap.
 // TODO: this is currently unimplemented
 func (db *RecoveryDB) DeleteSwap(_ types.Hash) error {
@@ -5,11 +5,13 @@ package db
 
 import (
 	"math/big"
+	"sync"
 	"testing"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
 
 	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/athanorlabs/atomic-swap/common/types"
@@ -139,6 +141,96 @@ func TestRecoveryDB_CounterpartySwapKeys(t *testing.T) {
 	require.Equal(t, kp.ViewKey().String(), resVk.String())
 }
 
+func TestRecoveryDB_NextSwapKeyIndex(t *testing.T) {
+	rdb := newTestRecoveryDB(t)
+
+	_, err := rdb.SwapKeyIndex()
+	require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+
+	for i := uint32(0); i < 3; i++ {
+		index, err := rdb.NextSwapKeyIndex() //nolint:govet
+		require.NoError(t, err)
+		require.Equal(t, i, index)
+	}
+
+	index, err := rdb.SwapKeyIndex()
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), index)
+}
+
+func TestRecoveryDB_AdvanceSwapKeyIndex(t *testing.T) {
+	rdb := newTestRecoveryDB(t)
+
+	// a recovered index of zero is still stored
+	require.NoError(t, rdb.AdvanceSwapKeyIndex(0))
+	index, err := rdb.SwapKeyIndex()
+	require.NoError(t, err)
+	require.Zero(t, index)
+
+	require.NoError(t, rdb.AdvanceSwapKeyIndex(10))
+	index, err = rdb.NextSwapKeyIndex()
+	require.NoError(t, err)
+	require.Equal(t, uint32(10), index)
+
+	// the index never goes back
+	require.NoError(t, rdb.AdvanceSwapKeyIndex(5))
+	index, err = rdb.SwapKeyIndex()
+	require.NoError(t, err)
+	require.Equal(t, uint32(11), index)
+}
+
+func TestRecoveryDB_NextSwapKeyIndex_concurrent(t *testing.T) {
+	rdb := newTestRecoveryDB(t)
+
+	const numGoroutines = 8
+	const numIndexes = 50
+
+	var wg sync.WaitGroup
+	indexes := make(chan uint32, numGoroutines*numIndexes)
+	for i := 0; i < numGoroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numIndexes; j++ {
+				index, err := rdb.NextSwapKeyIndex()
+				if !assert.NoError(t, err) {
+					return
+				}
+				indexes <- index
+			}
+		}()
+	}
+
+	// advancing the index concurrently may skip indexes, but never hands one
+	// out twice
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := uint32(0); j < numIndexes; j++ {
+			assert.NoError(t, rdb.AdvanceSwapKeyIndex(j))
+		}
+	}()
+
+	wg.Wait()
+	close(indexes)
+
+	seen := make(map[uint32]struct{})
+	var last uint32
+	for index := range indexes {
+		_, has := seen[index]
+		require.False(t, has, "index %d was handed out twice", index)
+		seen[index] = struct{}{}
+		if index > last {
+			last = index
+		}
+	}
+	require.Len(t, seen, numGoroutines*numIndexes)
+
+	next, err := rdb.SwapKeyIndex()
+	require.NoError(t, err)
+	require.Equal(t, last+1, next)
+}
+
 func TestRecoveryDB_DeleteSwap(t *testing.T) {
 	rdb := newTestRecoveryDB(t)
 	offerID := types.Hash{5, 6, 7, 8}
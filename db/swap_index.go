@@ -0,0 +1,309 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+// Sub-prefixes of the keys in the past swap index table. Each index key is the
+// index order, followed by the sub-prefix, followed by the indexed value (if
+// any), followed by the swap's sort key (see swap.CursorKey).
+const (
+	indexByTime     byte = 't'
+	indexByStatus   byte = 's'
+	indexByPeerID   byte = 'p'
+	indexByEthAsset byte = 'a'
+)
+
+// Orders of the index keys. chaindb's iterators only move forwards, so every
+// index is stored twice: once with the sort keys as they are, for oldest first
+// queries, and once with the bits of the sort keys inverted, for newest first
+// queries.
+const (
+	indexAscending  byte = '+'
+	indexDescending byte = '-'
+)
+
+// sortKeyLength is the length of swap.CursorKey
+var sortKeyLength = len(swap.CursorKey(time.Time{}, types.Hash{}))
+
+// seeker is implemented by chaindb's iterators
+type seeker interface {
+	Seek(key []byte)
+}
+
+var _ swap.PastSwapsDatabase = (*Database)(nil)
+
+// pastSwapIndexKeys returns the index keys of a swap. Ongoing swaps are not
+// indexed.
+func pastSwapIndexKeys(s *swap.Info) [][]byte {
+	if s.Status.IsOngoing() {
+		return nil
+	}
+
+	sortKey := swap.CursorKey(s.StartTime, s.OfferID)
+	peerHash := sha256.Sum256([]byte(s.PeerID))
+
+	indexes := [][]byte{
+		{indexByTime},
+		{indexByStatus, byte(s.Status)},
+		append([]byte{indexByPeerID}, peerHash[:]...),
+		append([]byte{indexByEthAsset}, s.EthAsset[:]...),
+	}
+
+	keys := make([][]byte, 0, 2*len(indexes))
+	for _, index := range indexes {
+		for _, descending := range []bool{false, true} {
+			keys = append(keys, indexKey(orderedIndexPrefix(index, descending), orderSortKey(sortKey, descending)))
+		}
+	}
+
+	return keys
+}
+
+func indexKey(indexPrefix []byte, sortKey []byte) []byte {
+	key := make([]byte, 0, len(indexPrefix)+len(sortKey))
+	key = append(key, indexPrefix...)
+	return append(key, sortKey...)
+}
+
+// orderedIndexPrefix returns the prefix of the keys of the index in the given
+// order.
+func orderedIndexPrefix(indexPrefix []byte, descending bool) []byte {
+	order := indexAscending
+	if descending {
+		order = indexDescending
+	}
+	return append([]byte{order}, indexPrefix...)
+}
+
+// orderSortKey converts a sort key to its position in the index of the given
+// order, or back.
+func orderSortKey(sortKey []byte, descending bool) []byte {
+	key := append([]byte{}, sortKey...)
+	if descending {
+		for i := range key {
+			key[i] = ^key[i]
+		}
+	}
+	return key
+}
+
+// updateSwapIndex replaces the index keys of the swap's previous record, if
+// any, with the keys of the new record.
+func (db *Database) updateSwapIndex(prev *swap.Info, s *swap.Info) error {
+	newKeys := pastSwapIndexKeys(s)
+
+	if prev != nil {
+		for _, key := range pastSwapIndexKeys(prev) {
+			if containsKey(newKeys, key) {
+				continue
+			}
+			if err := db.indexTable.Del(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range newKeys {
+		if err := db.indexTable.Put(key, []byte{}); err != nil {
+			return err
+		}
+	}
+
+	return db.indexTable.Flush()
+}
+
+func containsKey(keys [][]byte, key []byte) bool {
+	for _, k := range keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryIndexPrefixes returns the index prefixes to scan for the query, using
+// the most selective index the query allows.
+func queryIndexPrefixes(q *swap.PastSwapsQuery) [][]byte {
+	switch {
+	case q.PeerID != "":
+		peerHash := sha256.Sum256([]byte(q.PeerID))
+		return [][]byte{append([]byte{indexByPeerID}, peerHash[:]...)}
+	case q.EthAsset != nil:
+		return [][]byte{append([]byte{indexByEthAsset}, q.EthAsset[:]...)}
+	case len(q.Statuses) > 0:
+		prefixes := make([][]byte, 0, len(q.Statuses))
+		for _, status := range q.Statuses {
+			prefix := []byte{indexByStatus, byte(status)}
+			if !containsKey(prefixes, prefix) {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+		return prefixes
+	default:
+		return [][]byte{{indexByTime}}
+	}
+}
+
+// indexScanner iterates over the sort keys under one index prefix, in index
+// order.
+type indexScanner struct {
+	iter   chaindb.Iterator
+	prefix []byte
+	// key is the current sort key in index order, nil once the scanner is past
+	// the index prefix
+	key []byte
+}
+
+// newIndexScanner returns a scanner positioned at the first key under the
+// prefix that is at or after start, which is a sort key in index order.
+func (db *Database) newIndexScanner(prefix []byte, start []byte) *indexScanner {
+	s := &indexScanner{
+		iter:   db.indexTable.NewIterator(),
+		prefix: prefix,
+	}
+
+	// chaindb's table iterator can seek, but it's not part of the Iterator
+	// interface. Seek takes the key including the table prefix.
+	s.iter.(seeker).Seek(append([]byte(swapIndexPrefix), indexKey(prefix, start)...))
+	s.load()
+	return s
+}
+
+func (s *indexScanner) load() {
+	s.key = nil
+	if !s.iter.Valid() {
+		return
+	}
+
+	// stop once we're past the index prefix, or past the table
+	key := s.iter.Key()
+	if len(key) != len(s.prefix)+sortKeyLength || !bytes.HasPrefix(key, s.prefix) {
+		return
+	}
+
+	// the key is only valid until the iterator moves
+	s.key = append([]byte{}, key[len(s.prefix):]...)
+}
+
+func (s *indexScanner) next() {
+	s.iter.Next()
+	s.load()
+}
+
+// nextScanner returns the scanner with the lowest current key, or nil if all
+// scanners are done. Together with advancing the returned scanner, this
+// merges the scanners' keys in index order.
+func nextScanner(scanners []*indexScanner) *indexScanner {
+	var min *indexScanner
+	for _, s := range scanners {
+		if s.key == nil {
+			continue
+		}
+		if min == nil || bytes.Compare(s.key, min.key) < 0 {
+			min = s
+		}
+	}
+	return min
+}
+
+// QueryPastSwaps returns a page of the past swaps matching the query. The
+// candidate swaps are found by scanning the most selective index for the
+// query in the requested order, starting at the cursor, so only the swaps
+// that are candidates for the page get decoded.
+func (db *Database) QueryPastSwaps(q *swap.PastSwapsQuery) (*swap.PastSwapsPage, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	descending := q.Descending()
+
+	// The start of the scan, in index order, is the start of the time range
+	// in the query's order, or the cursor if that comes later.
+	var start []byte
+	rangeStart := q.From
+	if descending {
+		rangeStart = q.To
+	}
+	if rangeStart != nil {
+		start = make([]byte, 8)
+		binary.BigEndian.PutUint64(start, uint64(rangeStart.UnixNano()))
+		start = orderSortKey(start, descending)
+	}
+
+	var cursorKey []byte
+	if q.Cursor != "" {
+		cursorKey, _ = swap.DecodeCursor(q.Cursor)
+		if ordered := orderSortKey(cursorKey, descending); bytes.Compare(ordered, start) > 0 {
+			start = ordered
+		}
+	}
+
+	prefixes := queryIndexPrefixes(q)
+	scanners := make([]*indexScanner, len(prefixes))
+	for i, prefix := range prefixes {
+		scanners[i] = db.newIndexScanner(orderedIndexPrefix(prefix, descending), start)
+		defer scanners[i].iter.Release()
+	}
+
+	page := &swap.PastSwapsPage{Swaps: []*swap.Info{}}
+	var lastKey []byte
+	for {
+		scanner := nextScanner(scanners)
+		if scanner == nil {
+			break
+		}
+
+		sortKey := orderSortKey(scanner.key, descending)
+		scanner.next()
+
+		// the scan starts at the cursor, which itself was on the last page
+		if !q.AfterCursor(sortKey, cursorKey) {
+			continue
+		}
+
+		// the rest of the keys are past the end of the time range
+		if !q.InTimeRange(time.Unix(0, int64(binary.BigEndian.Uint64(sortKey)))) {
+			break
+		}
+
+		if q.Limit > 0 && len(page.Swaps) == q.Limit {
+			page.NextCursor = swap.EncodeCursor(lastKey)
+			break
+		}
+		lastKey = sortKey
+
+		var id types.Hash
+		copy(id[:], sortKey[8:])
+
+		s, err := db.GetSwap(id)
+		if errors.Is(err, chaindb.ErrKeyNotFound) {
+			log.Warnf("past swap index refers to missing swap %s", id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the index only covers one of the query's filters
+		if !q.Matches(s) {
+			continue
+		}
+
+		page.Swaps = append(page.Swaps, s)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+func newIndexTestSwap(
+	id byte,
+	peerID peer.ID,
+	asset types.EthAsset,
+	status types.Status,
+	startTime time.Time,
+) *swap.Info {
+	return &swap.Info{
+		Version:              swap.CurInfoVersion,
+		PeerID:               peerID,
+		OfferID:              types.Hash{id},
+		Provides:             coins.ProvidesXMR,
+		ProvidedAmount:       coins.StrToDecimal("1"),
+		ExpectedAmount:       coins.StrToDecimal("0.1"),
+		ExchangeRate:         coins.ToExchangeRate(coins.StrToDecimal("0.1")),
+		EthAsset:             asset,
+		Status:               status,
+		LastStatusUpdateTime: startTime,
+		MoneroStartHeight:    12345,
+		StartTime:            startTime,
+	}
+}
+
+func offerIDs(swaps []*swap.Info) []types.Hash {
+	ids := make([]types.Hash, len(swaps))
+	for i, s := range swaps {
+		ids[i] = s.OfferID
+	}
+	return ids
+}
+
+func TestDatabase_QueryPastSwaps(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	otherPeerID, err := peer.Decode("12D3KooWAYn1T8Lu122Pav4zAogjpeU61usLTNZpLRNh9gCqY6X2")
+	require.NoError(t, err)
+	token := types.EthAsset(ethcommon.HexToAddress("0xa1E32d14AC4B6d8c1791CAe8E9baD46a1E15B7a8"))
+
+	start := time.Now().Add(-time.Hour)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	swaps := []*swap.Info{
+		newIndexTestSwap(0x1, testPeerID, types.EthAssetETH, types.CompletedSuccess, at(1)),
+		newIndexTestSwap(0x2, otherPeerID, types.EthAssetETH, types.CompletedRefund, at(2)),
+		newIndexTestSwap(0x3, testPeerID, token, types.CompletedSuccess, at(3)),
+		newIndexTestSwap(0x4, otherPeerID, token, types.CompletedAbort, at(4)),
+		newIndexTestSwap(0x5, testPeerID, types.EthAssetETH, types.XMRLocked, at(5)), // ongoing
+	}
+	for _, s := range swaps {
+		require.NoError(t, db.PutSwap(s))
+	}
+
+	query := func(q *swap.PastSwapsQuery) []types.Hash {
+		page, err := db.QueryPastSwaps(q) //nolint:govet
+		require.NoError(t, err)
+		require.Empty(t, page.NextCursor)
+		return offerIDs(page.Swaps)
+	}
+
+	// newest first by default, ongoing swaps are excluded
+	require.Equal(t,
+		[]types.Hash{{0x4}, {0x3}, {0x2}, {0x1}},
+		query(&swap.PastSwapsQuery{}),
+	)
+	require.Equal(t,
+		[]types.Hash{{0x1}, {0x2}, {0x3}, {0x4}},
+		query(&swap.PastSwapsQuery{Order: swap.SortOldestFirst}),
+	)
+	require.Equal(t,
+		[]types.Hash{{0x3}, {0x1}},
+		query(&swap.PastSwapsQuery{PeerID: testPeerID}),
+	)
+	require.Equal(t,
+		[]types.Hash{{0x4}, {0x3}},
+		query(&swap.PastSwapsQuery{EthAsset: &token}),
+	)
+	require.Equal(t,
+		[]types.Hash{{0x4}, {0x2}},
+		query(&swap.PastSwapsQuery{Statuses: []types.Status{types.CompletedRefund, types.CompletedAbort}}),
+	)
+
+	// filters not covered by the scanned index are still applied
+	require.Equal(t,
+		[]types.Hash{{0x3}},
+		query(&swap.PastSwapsQuery{PeerID: testPeerID, EthAsset: &token}),
+	)
+
+	// the time range is inclusive
+	from, to := at(2), at(3)
+	require.Equal(t,
+		[]types.Hash{{0x3}, {0x2}},
+		query(&swap.PastSwapsQuery{From: &from, To: &to}),
+	)
+
+	// the index follows status updates of a swap
+	swaps[4].Status = types.CompletedSuccess
+	require.NoError(t, db.PutSwap(swaps[4]))
+	swaps[0].Status = types.CompletedRefund
+	require.NoError(t, db.PutSwap(swaps[0]))
+	require.Equal(t,
+		[]types.Hash{{0x5}, {0x3}},
+		query(&swap.PastSwapsQuery{Statuses: []types.Status{types.CompletedSuccess}}),
+	)
+}
+
+func TestDatabase_QueryPastSwaps_pagination(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	// swaps 2 and 3, and 4 and 5, share a start time, so the offer ID breaks
+	// the tie
+	start := time.Now().Add(-time.Hour)
+	for i := byte(1); i <= 5; i++ {
+		startTime := start.Add(time.Duration(i/2) * time.Minute)
+		s := newIndexTestSwap(i, testPeerID, types.EthAssetETH, types.CompletedSuccess, startTime)
+		require.NoError(t, db.PutSwap(s))
+	}
+
+	for _, order := range []swap.SortOrder{swap.SortNewestFirst, swap.SortOldestFirst} {
+		var ids []types.Hash
+		q := &swap.PastSwapsQuery{Order: order, Limit: 2}
+		pages := 0
+		for {
+			page, err := db.QueryPastSwaps(q)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Swaps), 2)
+			ids = append(ids, offerIDs(page.Swaps)...)
+			pages++
+
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+
+		// the last page is the only one without a next cursor
+		require.Equal(t, 3, pages)
+
+		expected := []types.Hash{{0x1}, {0x2}, {0x3}, {0x4}, {0x5}}
+		if order == swap.SortNewestFirst {
+			expected = []types.Hash{{0x5}, {0x4}, {0x3}, {0x2}, {0x1}}
+		}
+		require.Equal(t, expected, ids)
+	}
+
+	_, err = db.QueryPastSwaps(&swap.PastSwapsQuery{Cursor: "not-a-cursor"})
+	require.ErrorContains(t, err, "invalid cursor")
+}
+
+func TestDatabase_QueryPastSwaps_mergedStatusesPagination(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	// the statuses alternate, so every page mixes keys of both status indexes
+	statuses := []types.Status{types.CompletedSuccess, types.CompletedRefund, types.CompletedAbort}
+	start := time.Now().Add(-time.Hour)
+	for i := byte(1); i <= 9; i++ {
+		startTime := start.Add(time.Duration(i) * time.Minute)
+		s := newIndexTestSwap(i, testPeerID, types.EthAssetETH, statuses[int(i)%len(statuses)], startTime)
+		require.NoError(t, db.PutSwap(s))
+	}
+
+	from := start.Add(2 * time.Minute)
+	to := start.Add(8 * time.Minute)
+	for _, order := range []swap.SortOrder{swap.SortNewestFirst, swap.SortOldestFirst} {
+		q := &swap.PastSwapsQuery{
+			Statuses: []types.Status{types.CompletedSuccess, types.CompletedAbort},
+			From:     &from,
+			To:       &to,
+			Order:    order,
+			Limit:    2,
+		}
+
+		var ids []types.Hash
+		for {
+			page, err := db.QueryPastSwaps(q)
+			require.NoError(t, err)
+			ids = append(ids, offerIDs(page.Swaps)...)
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+
+		// swaps 2 to 8 without the refunded swaps 4 and 7
+		expected := []types.Hash{{0x2}, {0x3}, {0x5}, {0x6}, {0x8}}
+		if order == swap.SortNewestFirst {
+			expected = []types.Hash{{0x8}, {0x6}, {0x5}, {0x3}, {0x2}}
+		}
+		require.Equal(t, expected, ids)
+	}
+}
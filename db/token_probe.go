@@ -0,0 +1,52 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"encoding/binary"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+	"github.com/athanorlabs/atomic-swap/protocol/tokenpolicy"
+)
+
+var _ tokenpolicy.Database = (*Database)(nil)
+
+func tokenProbeKey(chainID uint64, token ethcommon.Address) []byte {
+	key := binary.BigEndian.AppendUint64(nil, chainID)
+	return append(key, token.Bytes()...)
+}
+
+// PutTokenProbe stores the probe of a token, replacing any earlier probe of
+// the token.
+func (db *Database) PutTokenProbe(p *tokenpolicy.Probe) error {
+	val, err := vjson.MarshalStruct(p)
+	if err != nil {
+		return err
+	}
+
+	err = db.tokenTable.Put(tokenProbeKey(p.ChainID, p.Token.Address), val)
+	if err != nil {
+		return err
+	}
+
+	return db.tokenTable.Flush()
+}
+
+// GetTokenProbe returns the probe of the token on the chain. Returns the error
+// chaindb.ErrKeyNotFound if the token was not probed.
+func (db *Database) GetTokenProbe(chainID uint64, token ethcommon.Address) (*tokenpolicy.Probe, error) {
+	val, err := db.tokenTable.Get(tokenProbeKey(chainID, token))
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(tokenpolicy.Probe)
+	if err = vjson.UnmarshalStruct(val, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
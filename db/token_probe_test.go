@@ -0,0 +1,55 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/protocol/tokenpolicy"
+)
+
+func TestDatabase_TokenProbes(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	token := ethcommon.Address{0x1}
+	_, err = db.GetTokenProbe(1, token)
+	require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+
+	p := &tokenpolicy.Probe{
+		ChainID:        1,
+		Token:          coins.NewERC20TokenInfo(token, 6, "Test Token", "TEST"),
+		FeeOnTransfer:  true,
+		TransferReturn: tokenpolicy.ReturnNone,
+		ProbedAt:       time.Now().Round(0),
+	}
+	require.NoError(t, db.PutTokenProbe(p))
+
+	res, err := db.GetTokenProbe(1, token)
+	require.NoError(t, err)
+	require.Equal(t, p.Token, res.Token)
+	require.True(t, res.FeeOnTransfer)
+	require.Equal(t, tokenpolicy.ReturnNone, res.TransferReturn)
+	require.True(t, p.ProbedAt.Equal(res.ProbedAt))
+
+	// probes are per chain
+	_, err = db.GetTokenProbe(10, token)
+	require.ErrorIs(t, err, chaindb.ErrKeyNotFound)
+
+	p.FeeOnTransfer = false
+	require.NoError(t, db.PutTokenProbe(p))
+	res, err = db.GetTokenProbe(1, token)
+	require.NoError(t, err)
+	require.False(t, res.FeeOnTransfer)
+}
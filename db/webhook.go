@@ -0,0 +1,65 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+	"github.com/athanorlabs/atomic-swap/webhook"
+)
+
+var _ webhook.Database = (*Database)(nil)
+
+// PutWebhookDelivery stores a webhook delivery that has not succeeded yet.
+func (db *Database) PutWebhookDelivery(d *webhook.Delivery) error {
+	val, err := vjson.MarshalStruct(d)
+	if err != nil {
+		return err
+	}
+
+	err = db.webhookTable.Put(d.ID[:], val)
+	if err != nil {
+		return err
+	}
+
+	return db.webhookTable.Flush()
+}
+
+// DeleteWebhookDelivery deletes a webhook delivery that succeeded or ran out
+// of attempts.
+func (db *Database) DeleteWebhookDelivery(id types.Hash) error {
+	return db.webhookTable.Del(id[:])
+}
+
+// GetAllWebhookDeliveries returns all queued webhook deliveries. Deliveries
+// that can no longer be decoded are moved to the invalid table.
+func (db *Database) GetAllWebhookDeliveries() ([]*webhook.Delivery, error) {
+	iter := db.webhookTable.NewIterator()
+	defer iter.Release()
+
+	var deliveries []*webhook.Delivery
+	for iter.Valid() {
+		id := iter.Key()
+
+		// if the key becomes longer than 32, we're not iterating over deliveries
+		if len(id) > idLength {
+			break
+		}
+
+		encoded := iter.Value()
+		d := new(webhook.Delivery)
+		if err := vjson.UnmarshalStruct(encoded, d); err != nil {
+			log.Warnf("removing invalid webhook delivery with ID=0x%X: %s", id, err)
+			if err = db.quarantine(db.webhookTable, webhookPrefix, id, encoded); err != nil {
+				return nil, err
+			}
+		} else {
+			deliveries = append(deliveries, d)
+		}
+
+		iter.Next()
+	}
+
+	return deliveries, nil
+}
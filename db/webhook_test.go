@@ -0,0 +1,58 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/webhook"
+)
+
+func TestDatabase_WebhookDeliveries(t *testing.T) {
+	db, err := NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	deliveries, err := db.GetAllWebhookDeliveries()
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+
+	status := types.XMRLocked
+	d := &webhook.Delivery{
+		ID:  types.Hash{0x1},
+		URL: "http://127.0.0.1:8000/hook",
+		Event: &webhook.Event{
+			ID:      types.Hash{0x2},
+			Type:    webhook.EventSwapStatus,
+			Time:    time.Now().Round(0),
+			OfferID: &types.Hash{0x3},
+			Status:  &status,
+		},
+		Attempts:    2,
+		NextAttempt: time.Now().Round(0),
+	}
+	require.NoError(t, db.PutWebhookDelivery(d))
+
+	deliveries, err = db.GetAllWebhookDeliveries()
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, d.ID, deliveries[0].ID)
+	require.Equal(t, d.Attempts, deliveries[0].Attempts)
+	require.Equal(t, d.Event.Type, deliveries[0].Event.Type)
+	require.Equal(t, status, *deliveries[0].Event.Status)
+	require.True(t, d.NextAttempt.Equal(deliveries[0].NextAttempt))
+
+	require.NoError(t, db.DeleteWebhookDelivery(d.ID))
+	deliveries, err = db.GetAllWebhookDeliveries()
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
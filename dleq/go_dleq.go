@@ -34,6 +34,13 @@ func (d *GoDLEq) Prove() (*Proof, error) {
 		return nil, err
 	}
 
+	return d.ProveWithSecret(x)
+}
+
+// ProveWithSecret generates a proof that the given secret scalar has a
+// corresponding public key on the secp256k1 and ed25519 curves. The secret is
+// little-endian and must be smaller than 2^252.
+func (d *GoDLEq) ProveWithSecret(x [32]byte) (*Proof, error) {
 	proof, err := dleq.NewProof(curveEthereum, curveMonero, x)
 	if err != nil {
 		return nil, err
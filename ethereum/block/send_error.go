@@ -0,0 +1,59 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package block
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+var (
+	// ErrReverted is matched by the errors of transactions that reverted, either
+	// when the node simulated them or after they were mined.
+	ErrReverted = errors.New("transaction reverted")
+	// ErrInsufficientFunds is matched by the errors of transactions that the node
+	// rejected, because the account can't pay for their value and gas.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrReceiptTimeout is returned when a transaction wasn't mined in time.
+	ErrReceiptTimeout = errors.New("failed to get receipt, timed out")
+)
+
+// classifiedError is an error that also matches one of the sentinel errors
+// above, without changing its message.
+type classifiedError struct {
+	err   error
+	class error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.err, e.class}
+}
+
+// SendError returns the error of creating or sending a transaction, which also
+// matches ErrReverted or ErrInsufficientFunds if the node rejected it for that
+// reason. Nodes only return the text of their errors over RPC, so the errors of
+// go-ethereum are matched by their text.
+func SendError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, core.ErrInsufficientFunds.Error()),
+		strings.Contains(msg, core.ErrInsufficientFundsForTransfer.Error()):
+		return &classifiedError{err: err, class: ErrInsufficientFunds}
+	case strings.Contains(msg, vm.ErrExecutionReverted.Error()):
+		return &classifiedError{err: err, class: ErrReverted}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package block
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendError(t *testing.T) {
+	require.NoError(t, SendError(nil))
+
+	err := SendError(errors.New("insufficient funds for gas * price + value: balance 0"))
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, "insufficient funds for gas * price + value: balance 0", err.Error())
+
+	err = SendError(errors.New("execution reverted: swap is not pending"))
+	require.ErrorIs(t, err, ErrReverted)
+	require.NotErrorIs(t, err, ErrInsufficientFunds)
+
+	original := errors.New("nonce too low")
+	require.Equal(t, original, SendError(original))
+}
@@ -5,7 +5,6 @@ package block
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
@@ -24,8 +23,7 @@ const (
 )
 
 var (
-	log               = logging.Logger("ethereum/block")
-	errReceiptTimeOut = errors.New("failed to get receipt, timed out")
+	log = logging.Logger("ethereum/block")
 )
 
 // WaitForReceipt waits for the transaction to be mined into a block. If the transaction was reverted when mined,
@@ -47,11 +45,11 @@ func WaitForReceipt(ctx context.Context, ec *ethclient.Client, txHash ethcommon.
 		if receipt.Status != ethtypes.ReceiptStatusSuccessful {
 			err = fmt.Errorf("failed transaction included in block (%s): %w",
 				common.ReceiptInfo(receipt), ErrorFromBlock(ctx, ec, receipt))
-			return nil, err
+			return nil, &classifiedError{err: err, class: ErrReverted}
 		}
 		log.Debugf("transaction included in chain %s", common.ReceiptInfo(receipt))
 		return receipt, nil
 	}
 
-	return nil, errReceiptTimeOut
+	return nil, ErrReceiptTimeout
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/block"
 )
@@ -93,15 +94,33 @@ type ethClient struct {
 	mu         sync.Mutex
 }
 
-// NewEthClient creates and returns our extended ethereum client/wallet. The passed context
-// is only used for creation. The privKey can be nil if you are using an external signer.
+// NewEthClient creates and returns our extended ethereum client/wallet for the
+// primary chain of the environment. The passed context is only used for
+// creation. The endpoint is dialed through the proxy, which can be nil for
+// direct connections. The privKey can be nil if you are using an external
+// signer.
 func NewEthClient(
 	ctx context.Context,
 	env common.Environment,
 	endpoint string,
+	proxy *netproxy.Proxy,
 	privKey *ecdsa.PrivateKey,
 ) (EthClient, error) {
-	ec, err := ethclient.Dial(endpoint)
+	return NewEthClientForChain(ctx, env, 0, endpoint, proxy, privKey)
+}
+
+// NewEthClientForChain creates and returns our extended ethereum client/wallet
+// for the chain with the given ID, which must be registered for the
+// environment. A chain ID of zero is the primary chain of the environment.
+func NewEthClientForChain(
+	ctx context.Context,
+	env common.Environment,
+	expectedChainID uint64,
+	endpoint string,
+	proxy *netproxy.Proxy,
+	privKey *ecdsa.PrivateKey,
+) (EthClient, error) {
+	ec, err := proxy.DialEthereum(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +130,7 @@ func NewEthClient(
 		return nil, err
 	}
 
-	if err = validateChainID(env, chainID); err != nil {
+	if err = validateChainID(env, expectedChainID, chainID); err != nil {
 		return nil, err
 	}
 
@@ -457,23 +476,26 @@ func (c *ethClient) isContractAddress(ctx context.Context, addr ethcommon.Addres
 	return isContract, nil
 }
 
-func validateChainID(env common.Environment, chainID *big.Int) error {
-	switch env {
-	case common.Mainnet:
-		if chainID.Cmp(big.NewInt(common.MainnetChainID)) != 0 {
-			return fmt.Errorf("expected Mainnet chain ID (%d), but found %s", common.MainnetChainID, chainID)
-		}
-	case common.Stagenet:
-		if chainID.Cmp(big.NewInt(common.SepoliaChainID)) != 0 {
-			return fmt.Errorf("expected Sepolia chain ID (%d), but found %s", common.SepoliaChainID, chainID)
-		}
-	case common.Development:
-		if chainID.Cmp(big.NewInt(common.GanacheChainID)) != 0 {
-			return fmt.Errorf("expected Ganache chain ID (%d), but found %s", common.GanacheChainID, chainID)
-		}
-	default:
+// validateChainID checks that the chain ID of an endpoint is the expected
+// chain ID, which must be registered for the environment. An expected chain ID
+// of zero is the primary chain of the environment.
+func validateChainID(env common.Environment, expectedChainID uint64, chainID *big.Int) error {
+	expected := common.PrimaryChain(env)
+	if expected == nil {
 		panic("unhandled environment type")
 	}
 
+	if expectedChainID != 0 {
+		var err error
+		expected, err = common.ChainByID(env, expectedChainID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !chainID.IsUint64() || chainID.Uint64() != expected.ChainID {
+		return fmt.Errorf("expected %s chain ID (%d), but found %s", expected.Name, expected.ChainID, chainID)
+	}
+
 	return nil
 }
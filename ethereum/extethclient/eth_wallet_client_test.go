@@ -121,16 +121,28 @@ func Test_ethClient_CancelTxWithNonce(t *testing.T) {
 }
 
 func Test_validateChainID_devSuccess(t *testing.T) {
-	err := validateChainID(common.Development, big.NewInt(common.GanacheChainID))
+	err := validateChainID(common.Development, 0, big.NewInt(common.GanacheChainID))
 	require.NoError(t, err)
 }
 
 func Test_validateChainID_mismatchedEnv(t *testing.T) {
-	err := validateChainID(common.Mainnet, big.NewInt(common.GanacheChainID))
+	err := validateChainID(common.Mainnet, 0, big.NewInt(common.GanacheChainID))
 	require.Error(t, err)
 	assert.ErrorContains(t, err, "expected Mainnet chain ID (1), but found 1337")
 
-	err = validateChainID(common.Stagenet, big.NewInt(common.GanacheChainID))
+	err = validateChainID(common.Stagenet, 0, big.NewInt(common.GanacheChainID))
 	require.Error(t, err)
 	assert.ErrorContains(t, err, "expected Sepolia chain ID (11155111), but found 1337")
 }
+
+func Test_validateChainID_additionalChain(t *testing.T) {
+	err := validateChainID(common.Mainnet, common.ArbitrumChainID, big.NewInt(common.ArbitrumChainID))
+	require.NoError(t, err)
+
+	err = validateChainID(common.Mainnet, common.ArbitrumChainID, big.NewInt(common.BaseChainID))
+	assert.ErrorContains(t, err, "expected Arbitrum chain ID (42161), but found 8453")
+
+	// Arbitrum is not a stagenet chain
+	err = validateChainID(common.Stagenet, common.ArbitrumChainID, big.NewInt(common.ArbitrumChainID))
+	assert.ErrorContains(t, err, "chain ID 42161 is not supported in the stagenet environment")
+}
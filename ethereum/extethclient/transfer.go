@@ -55,7 +55,7 @@ func transfer(cfg *transferConfig) (*ethtypes.Receipt, error) {
 
 	err = ec.SendTransaction(ctx, signedTx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transfer transaction: %w", err)
+		return nil, fmt.Errorf("failed to send transfer transaction: %w", block.SendError(err))
 	}
 
 	log.Infof("transfer of %s ETH to %s sent to mempool with txID %s, nonce %d, gas-price %s ETH",
@@ -9,11 +9,12 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vcbor"
 	"github.com/athanorlabs/atomic-swap/common/vjson"
 )
 
 // swap is the same as the auto-generated SwapCreatorSwap type, but with some type
-// adjustments and annotations for JSON marshalling.
+// adjustments and annotations for JSON and CBOR marshalling.
 type swap struct {
 	Owner            common.Address `json:"owner" validate:"required"`
 	Claimer          common.Address `json:"claimer" validate:"required"`
@@ -28,7 +29,36 @@ type swap struct {
 
 // MarshalJSON provides JSON marshalling for SwapCreatorSwap
 func (sfs *SwapCreatorSwap) MarshalJSON() ([]byte, error) {
-	return vjson.MarshalStruct(&swap{
+	return vjson.MarshalStruct(sfs.toSwap())
+}
+
+// UnmarshalJSON provides JSON unmarshalling for SwapCreatorSwap
+func (sfs *SwapCreatorSwap) UnmarshalJSON(data []byte) error {
+	s := &swap{}
+	if err := vjson.UnmarshalStruct(data, s); err != nil {
+		return err
+	}
+	sfs.fromSwap(s)
+	return nil
+}
+
+// MarshalCBOR provides CBOR marshalling for SwapCreatorSwap
+func (sfs *SwapCreatorSwap) MarshalCBOR() ([]byte, error) {
+	return vcbor.MarshalStruct(sfs.toSwap())
+}
+
+// UnmarshalCBOR provides CBOR unmarshalling for SwapCreatorSwap
+func (sfs *SwapCreatorSwap) UnmarshalCBOR(data []byte) error {
+	s := &swap{}
+	if err := vcbor.UnmarshalStruct(data, s); err != nil {
+		return err
+	}
+	sfs.fromSwap(s)
+	return nil
+}
+
+func (sfs *SwapCreatorSwap) toSwap() *swap {
+	return &swap{
 		Owner:            sfs.Owner,
 		Claimer:          sfs.Claimer,
 		ClaimCommitment:  sfs.ClaimCommitment,
@@ -38,15 +68,10 @@ func (sfs *SwapCreatorSwap) MarshalJSON() ([]byte, error) {
 		Asset:            sfs.Asset,
 		Value:            sfs.Value,
 		Nonce:            sfs.Nonce,
-	})
+	}
 }
 
-// UnmarshalJSON provides JSON unmarshalling for SwapCreatorSwap
-func (sfs *SwapCreatorSwap) UnmarshalJSON(data []byte) error {
-	s := &swap{}
-	if err := vjson.UnmarshalStruct(data, s); err != nil {
-		return err
-	}
+func (sfs *SwapCreatorSwap) fromSwap(s *swap) {
 	*sfs = SwapCreatorSwap{
 		Owner:            s.Owner,
 		Claimer:          s.Claimer,
@@ -58,5 +83,4 @@ func (sfs *SwapCreatorSwap) UnmarshalJSON(data []byte) error {
 		Value:            s.Value,
 		Nonce:            s.Nonce,
 	}
-	return nil
 }
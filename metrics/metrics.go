@@ -0,0 +1,199 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package metrics provides the event-driven prometheus metrics that are
+// recorded by the protocol layer while swaps are running. The metrics are
+// exposed by the RPC server's /metrics endpoint.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/ethereum/block"
+)
+
+const namespace = "swapdaemon"
+
+// TxType identifies the purpose of an ethereum transaction sent by swapd.
+type TxType string
+
+// Transaction types used as labels of the gas metrics
+const (
+	TxApprove      TxType = "approve"
+	TxNewSwap      TxType = "new_swap"
+	TxSetReady     TxType = "set_ready"
+	TxClaim        TxType = "claim"
+	TxRefund       TxType = "refund"
+	TxRelayedClaim TxType = "relayed_claim"
+)
+
+// Relayer types used as labels of the relayer metrics
+const (
+	RelayerCounterparty = "counterparty"
+	RelayerAdvertised   = "advertised"
+)
+
+// Error classes used as labels of the swap exit metric
+const (
+	ErrorClassNone              = "none"
+	ErrorClassCanceled          = "canceled"
+	ErrorClassTimeout           = "timeout"
+	ErrorClassReverted          = "reverted"
+	ErrorClassInsufficientFunds = "insufficient_funds"
+	ErrorClassNetwork           = "network"
+	ErrorClassOther             = "other"
+)
+
+var (
+	stageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "swap_stage_duration_seconds",
+			Help:      "Time spent by swaps in each ongoing status",
+			// 1s to ~4.5h
+			Buckets: prometheus.ExponentialBuckets(1, 2, 15),
+		},
+		[]string{"status"},
+	)
+
+	swapExits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "swap_exits_total",
+			Help:      "The number of swaps that exited, by final status and error class",
+		},
+		[]string{"status", "error_class"},
+	)
+
+	relayerClaimsSubmitted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "relayer_claims_submitted_total",
+			Help:      "The number of claims we submitted to relayers, by relayer type and result",
+		},
+		[]string{"relayer", "result"},
+	)
+
+	relayerClaimsRelayed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "relayer_claims_relayed_total",
+			Help:      "The number of claims we relayed for other peers, by result",
+		},
+		[]string{"result"},
+	)
+
+	gasUsed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_used_total",
+			Help:      "Gas used by successful transactions, by transaction type",
+		},
+		[]string{"tx_type"},
+	)
+
+	gasSpent = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "gas_spent_eth_total",
+			Help:      "ETH spent on gas by successful transactions, by transaction type",
+		},
+		[]string{"tx_type"},
+	)
+)
+
+// Collectors returns all the collectors of the package so they can be
+// registered with a prometheus registry.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		stageDuration,
+		swapExits,
+		relayerClaimsSubmitted,
+		relayerClaimsRelayed,
+		gasUsed,
+		gasSpent,
+	}
+}
+
+// ObserveStageDuration records the time a swap spent in the given status.
+func ObserveStageDuration(status types.Status, d time.Duration) {
+	stageDuration.WithLabelValues(status.String()).Observe(d.Seconds())
+}
+
+// RecordSwapExit records that a swap exited with the given final status. The
+// passed error, if any, is the reason the swap did not exit cleanly.
+func RecordSwapExit(status types.Status, err error) {
+	swapExits.WithLabelValues(status.String(), ErrorClass(err)).Inc()
+}
+
+// RecordRelayerClaimSubmitted records the result of submitting our claim to a
+// relayer.
+func RecordRelayerClaimSubmitted(relayer string, err error) {
+	relayerClaimsSubmitted.WithLabelValues(relayer, resultLabel(err)).Inc()
+}
+
+// RecordRelayerClaimRelayed records the result of relaying a claim for
+// another peer.
+func RecordRelayerClaimRelayed(err error) {
+	relayerClaimsRelayed.WithLabelValues(resultLabel(err)).Inc()
+}
+
+// RecordGas records the gas used by the transaction of the given receipt.
+func RecordGas(txType TxType, receipt *ethtypes.Receipt) {
+	if receipt == nil {
+		return
+	}
+
+	gasUsed.WithLabelValues(string(txType)).Add(float64(receipt.GasUsed))
+
+	if receipt.EffectiveGasPrice == nil {
+		return
+	}
+
+	costWei := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+	costETH, _ := new(big.Float).Quo(new(big.Float).SetInt(costWei), big.NewFloat(1e18)).Float64()
+	gasSpent.WithLabelValues(string(txType)).Add(costETH)
+}
+
+// ErrorClass returns a coarse, low-cardinality classification of err that is
+// suitable for use as a metric label. Errors are classified by the sentinel
+// errors that they wrap, any other error is ErrorClassOther.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, block.ErrReceiptTimeout):
+		return ErrorClassTimeout
+	case errors.Is(err, block.ErrReverted):
+		return ErrorClassReverted
+	case errors.Is(err, block.ErrInsufficientFunds):
+		return ErrorClassInsufficientFunds
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	default:
+		return ErrorClassOther
+	}
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/ethereum/block"
+)
+
+func TestErrorClass(t *testing.T) {
+	require.Equal(t, ErrorClassNone, ErrorClass(nil))
+	require.Equal(t, ErrorClassCanceled, ErrorClass(fmt.Errorf("wrapped: %w", context.Canceled)))
+	require.Equal(t, ErrorClassTimeout, ErrorClass(context.DeadlineExceeded))
+	require.Equal(t, ErrorClassTimeout, ErrorClass(fmt.Errorf("claim failed, %w", block.ErrReceiptTimeout)))
+	require.Equal(t, ErrorClassReverted, ErrorClass(block.SendError(errors.New("execution reverted: swap is completed"))))
+	require.Equal(t, ErrorClassInsufficientFunds,
+		ErrorClass(block.SendError(errors.New("insufficient funds for gas * price + value"))))
+	require.Equal(t, ErrorClassNetwork, ErrorClass(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+
+	// errors are only classified by the sentinel errors that they wrap
+	require.Equal(t, ErrorClassOther, ErrorClass(errors.New("execution reverted: swap is completed")))
+	require.Equal(t, ErrorClassOther, ErrorClass(errors.New("something else")))
+}
+
+func TestRecordSwapExit(t *testing.T) {
+	counter := swapExits.WithLabelValues(types.CompletedRefund.String(), ErrorClassReverted)
+	before := testutil.ToFloat64(counter)
+	RecordSwapExit(types.CompletedRefund, fmt.Errorf("refund failed, %w", block.ErrReverted))
+	require.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestRecordGas(t *testing.T) {
+	used := gasUsed.WithLabelValues(string(TxClaim))
+	spent := gasSpent.WithLabelValues(string(TxClaim))
+	usedBefore, spentBefore := testutil.ToFloat64(used), testutil.ToFloat64(spent)
+
+	RecordGas(TxClaim, &ethtypes.Receipt{
+		GasUsed:           50_000,
+		EffectiveGasPrice: big.NewInt(2e9), // 2 gwei
+	})
+
+	require.Equal(t, usedBefore+50_000, testutil.ToFloat64(used))
+	require.InDelta(t, spentBefore+0.0001, testutil.ToFloat64(spent), 1e-12)
+
+	RecordGas(TxClaim, nil) // no-op
+	require.Equal(t, usedBefore+50_000, testutil.ToFloat64(used))
+}
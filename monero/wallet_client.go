@@ -25,6 +25,7 @@ import (
 
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 )
 
@@ -76,6 +77,7 @@ type WalletClientConf struct {
 	MonerodNodes        []*common.MoneroNode // Optional, defaulted from environment if nil
 	MoneroWalletRPCPath string               // optional, path to monero-rpc-binary
 	LogPath             string               // optional, default is dir(WalletFilePath)/../monero-wallet-rpc.log
+	Proxy               *netproxy.Proxy      // optional, monerod connections are direct if nil
 }
 
 // Fill fills in the optional configuration values (Port, MonerodNodes, MoneroWalletRPCPath,
@@ -98,7 +100,7 @@ func (conf *WalletClientConf) Fill() error {
 		conf.MonerodNodes = common.ConfigDefaultsForEnv(conf.Env).MoneroNodes
 	}
 
-	validatedNode, err := findWorkingNode(conf.Env, conf.MonerodNodes)
+	validatedNode, err := findWorkingNode(conf.Env, conf.MonerodNodes, conf.Proxy)
 	if err != nil {
 		return err
 	}
@@ -161,12 +163,13 @@ func NewWalletClient(conf *WalletClientConf) (WalletClient, error) {
 		conf.WalletPort,
 		path.Dir(conf.WalletFilePath),
 		conf.LogPath,
-		validatedNode)
+		validatedNode,
+		conf.Proxy)
 	if err != nil {
 		return nil, err
 	}
 
-	c := NewThinWalletClient(validatedNode.Host, validatedNode.Port, conf.WalletPort).(*walletClient)
+	c := NewThinWalletClient(validatedNode.Host, validatedNode.Port, conf.WalletPort, conf.Proxy).(*walletClient)
 	c.rpcProcess = proc
 
 	walletName := path.Base(conf.WalletFilePath)
@@ -203,11 +206,17 @@ func NewWalletClient(conf *WalletClientConf) (WalletClient, error) {
 }
 
 // NewThinWalletClient returns a WalletClient for an existing monero-wallet-rpc process.
-func NewThinWalletClient(monerodHost string, monerodPort uint, walletPort uint) WalletClient {
+// Connections to monerod go through the proxy, which can be nil.
+func NewThinWalletClient(
+	monerodHost string,
+	monerodPort uint,
+	walletPort uint,
+	proxy *netproxy.Proxy,
+) WalletClient {
 	monerodEndpoint := fmt.Sprintf("http://%s:%d/json_rpc", monerodHost, monerodPort)
 	walletEndpoint := fmt.Sprintf("http://127.0.0.1:%d/json_rpc", walletPort)
 	return &walletClient{
-		dRPC:     monerorpc.New(monerodEndpoint, nil).Daemon,
+		dRPC:     monerorpc.New(monerodEndpoint, proxy.HTTPClient(0)).Daemon,
 		wRPC:     monerorpc.New(walletEndpoint, nil).Wallet,
 		endpoint: walletEndpoint,
 	}
@@ -385,6 +394,7 @@ func (c *walletClient) CreateWalletConf(walletNamePrefix string) *WalletClientCo
 		MonerodNodes:        c.conf.MonerodNodes,
 		MoneroWalletRPCPath: c.conf.MoneroWalletRPCPath,
 		LogPath:             c.conf.LogPath,
+		Proxy:               c.conf.Proxy,
 	}
 	return conf
 }
@@ -413,12 +423,13 @@ func createWalletFromKeys(
 		path.Dir(conf.WalletFilePath),
 		conf.LogPath,
 		monerodNode,
+		conf.Proxy,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	c := NewThinWalletClient(monerodNode.Host, monerodNode.Port, conf.WalletPort).(*walletClient)
+	c := NewThinWalletClient(monerodNode.Host, monerodNode.Port, conf.WalletPort, conf.Proxy).(*walletClient)
 	c.rpcProcess = proc
 	c.conf = conf
 	err = c.generateFromKeys(
@@ -631,14 +642,18 @@ func (c *walletClient) CloseAndRemoveWallet() {
 
 }
 
-func findWorkingNode(env common.Environment, nodes []*common.MoneroNode) (*common.MoneroNode, error) {
+func findWorkingNode(
+	env common.Environment,
+	nodes []*common.MoneroNode,
+	proxy *netproxy.Proxy,
+) (*common.MoneroNode, error) {
 	if len(nodes) == 0 {
 		return nil, errors.New("no monero nodes")
 	}
 
 	var err error
 	for _, n := range nodes {
-		err = validateMonerodNode(env, n)
+		err = validateMonerodNode(env, n, proxy)
 		if err != nil {
 			log.Warnf("Non-working node: %s", err)
 			continue
@@ -652,9 +667,9 @@ func findWorkingNode(env common.Environment, nodes []*common.MoneroNode) (*commo
 
 // validateMonerodNode validates the monerod node before we launch monero-wallet-rpc, as
 // doing the pre-checks creates more obvious error messages and faster failure.
-func validateMonerodNode(env common.Environment, node *common.MoneroNode) error {
+func validateMonerodNode(env common.Environment, node *common.MoneroNode, proxy *netproxy.Proxy) error {
 	endpoint := fmt.Sprintf("http://%s:%d/json_rpc", node.Host, node.Port)
-	daemonCli := monerorpc.New(endpoint, nil).Daemon
+	daemonCli := monerorpc.New(endpoint, proxy.HTTPClient(0)).Daemon
 
 	info, err := daemonCli.GetInfo()
 	if err != nil {
@@ -700,8 +715,19 @@ func createWalletRPCService(
 	walletDir string,
 	logFilePath string,
 	moneroNode *common.MoneroNode,
+	proxy *netproxy.Proxy,
 ) (*os.Process, error) {
 	walletRPCBinArgs := getWalletRPCFlags(env, walletPort, walletDir, logFilePath, moneroNode)
+
+	// monero-wallet-rpc makes its own connections to monerod
+	proxyAddr, err := proxy.MoneroWalletProxy(moneroNode.Host)
+	if err != nil {
+		return nil, err
+	}
+	if proxyAddr != "" {
+		walletRPCBinArgs = append(walletRPCBinArgs, fmt.Sprintf("--proxy=%s", proxyAddr))
+	}
+
 	proc, err := launchMoneroWalletRPCChild(walletRPCBinPath, walletRPCBinArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("%w, see %s for details", err, logFilePath)
@@ -285,21 +285,21 @@ func Test_getMoneroWalletRPCBin(t *testing.T) {
 
 func Test_validateMonerodConfigs_dev(t *testing.T) {
 	env := common.Development
-	node, err := findWorkingNode(env, common.ConfigDefaultsForEnv(env).MoneroNodes)
+	node, err := findWorkingNode(env, common.ConfigDefaultsForEnv(env).MoneroNodes, nil)
 	require.NoError(t, err)
 	require.NotNil(t, node)
 }
 
 func Test_validateMonerodConfigs_stagenet(t *testing.T) {
 	env := common.Stagenet
-	node, err := findWorkingNode(env, common.ConfigDefaultsForEnv(env).MoneroNodes)
+	node, err := findWorkingNode(env, common.ConfigDefaultsForEnv(env).MoneroNodes, nil)
 	require.NoError(t, err)
 	require.NotNil(t, node)
 }
 
 func Test_validateMonerodConfigs_mainnet(t *testing.T) {
 	env := common.Mainnet
-	node, err := findWorkingNode(env, common.ConfigDefaultsForEnv(env).MoneroNodes)
+	node, err := findWorkingNode(env, common.ConfigDefaultsForEnv(env).MoneroNodes, nil)
 	require.NoError(t, err)
 	require.NotNil(t, node)
 }
@@ -309,7 +309,7 @@ func Test_validateMonerodConfig_misMatchedEnv(t *testing.T) {
 		Host: "127.0.0.1",
 		Port: common.DefaultMoneroDaemonDevPort,
 	}
-	err := validateMonerodNode(common.Mainnet, node)
+	err := validateMonerodNode(common.Mainnet, node, nil)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "is not a mainnet node")
 }
@@ -321,7 +321,7 @@ func Test_validateMonerodConfig_invalidPort(t *testing.T) {
 		Host: "127.0.0.1",
 		Port: nonUsedPort,
 	}
-	err = validateMonerodNode(common.Development, node)
+	err = validateMonerodNode(common.Development, node, nil)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "connection refused")
 }
@@ -0,0 +1,555 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	libp2phost "github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/time/rate"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/net/message"
+)
+
+// Offer announcements are published with GossipSub, on a pubsub topic per
+// environment, chain and asset. Hosts join the mesh of the topics that they
+// subscribe to, and validate the announcements before they are delivered or
+// forwarded. Peers that forward invalid announcements lose score, until they
+// are no longer gossiped with and are eventually graylisted. This complements
+// finding makers in the DHT, which is slow. Bootnodes don't take part.
+const (
+	maxAnnouncementSize = 1 << 12
+	// announcements signed longer ago are dropped, it is longer than the
+	// interval at which makers re-announce their offers
+	maxAnnouncementAge = 15 * time.Minute
+	maxClockSkew       = time.Minute
+
+	offerCheckInterval      = 5 * time.Second
+	offerReannounceInterval = 5 * time.Minute
+
+	// peerAnnouncementRate limits the announcements that a peer can send us,
+	// whoever the maker is. makerAnnouncementRate limits the announcements of
+	// each maker, whichever peer forwards them.
+	peerAnnouncementRate   = rate.Limit(20)
+	peerAnnouncementBurst  = 100
+	makerAnnouncementRate  = rate.Limit(1)
+	makerAnnouncementBurst = 64
+
+	subscriptionBufferSize = 64
+)
+
+// peerScoreThresholds are the scores below which peers are no longer gossiped
+// with, don't get our announcements, and are ignored altogether. Each invalid
+// announcement that a peer forwards costs it at least 100.
+var peerScoreThresholds = &pubsub.PeerScoreThresholds{
+	GossipThreshold:             -100,
+	PublishThreshold:            -200,
+	GraylistThreshold:           -300,
+	AcceptPXThreshold:           10,
+	OpportunisticGraftThreshold: 5,
+}
+
+func peerScoreParams() *pubsub.PeerScoreParams {
+	return &pubsub.PeerScoreParams{
+		// the announcement topics are added when they are joined
+		Topics:                      make(map[string]*pubsub.TopicScoreParams),
+		TopicScoreCap:               50,
+		AppSpecificScore:            func(peer.ID) float64 { return 0 },
+		IPColocationFactorWeight:    -10,
+		IPColocationFactorThreshold: 10,
+		BehaviourPenaltyWeight:      -10,
+		BehaviourPenaltyThreshold:   6,
+		BehaviourPenaltyDecay:       pubsub.ScoreParameterDecay(10 * time.Minute),
+		DecayInterval:               pubsub.DefaultDecayInterval,
+		DecayToZero:                 pubsub.DefaultDecayToZero,
+		RetainScore:                 time.Hour,
+	}
+}
+
+// topicScoreParams rewards the peers of an announcement topic a little for
+// their time in the mesh and for the announcements they deliver first, and
+// penalizes them for the invalid announcements that they forward.
+func topicScoreParams() *pubsub.TopicScoreParams {
+	return &pubsub.TopicScoreParams{
+		TopicWeight:                    1,
+		TimeInMeshWeight:               0.01,
+		TimeInMeshQuantum:              time.Minute,
+		TimeInMeshCap:                  1000,
+		FirstMessageDeliveriesWeight:   1,
+		FirstMessageDeliveriesDecay:    pubsub.ScoreParameterDecay(time.Hour),
+		FirstMessageDeliveriesCap:      20,
+		InvalidMessageDeliveriesWeight: -100,
+		InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+	}
+}
+
+// Announcements that fail with these errors are ignored without penalizing
+// the peer that forwarded them, any other error rejects the announcement.
+var (
+	errDuplicateAnnouncement = errors.New("announcement was already seen")
+	errStaleAnnouncement     = errors.New("stale announcement")
+	errAnnouncementRateLimit = errors.New("exceeded the announcement rate limit")
+)
+
+type announcementKey struct {
+	maker   peer.ID
+	offerID types.Hash
+}
+
+type publishedOffer struct {
+	offer       *types.Offer
+	announcedAt time.Time
+}
+
+// announcements holds the state of the offer announcements of a Host.
+type announcements struct {
+	key   crypto.PrivKey
+	self  peer.ID
+	check chan struct{}  // requests an immediate check of our offers
+	ps    *pubsub.PubSub // nil for bootnodes
+
+	// topics are the joined pubsub topics
+	topicsMu sync.Mutex
+	topics   map[string]*pubsub.Topic
+
+	mu          sync.Mutex
+	lastSeq     map[announcementKey]uint64
+	peerLimits  map[peer.ID]*rate.Limiter
+	makerLimits map[peer.ID]*rate.Limiter
+	subs        map[string][]chan *message.OfferAnnouncement
+
+	// our offers as last announced, only used by the announceOffers routine
+	published map[types.Hash]*publishedOffer
+	seq       uint64
+}
+
+func newAnnouncements(key crypto.PrivKey) (*announcements, error) {
+	self, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &announcements{
+		key:         key,
+		self:        self,
+		check:       make(chan struct{}, 1),
+		topics:      make(map[string]*pubsub.Topic),
+		lastSeq:     make(map[announcementKey]uint64),
+		peerLimits:  make(map[peer.ID]*rate.Limiter),
+		makerLimits: make(map[peer.ID]*rate.Limiter),
+		subs:        make(map[string][]chan *message.OfferAnnouncement),
+		published:   make(map[types.Hash]*publishedOffer),
+	}, nil
+}
+
+// newGossipSub returns the GossipSub router of the offer announcements, which
+// only exchanges messages with the peers that can open streams with the host.
+func (h *Host) newGossipSub(ctx context.Context, host libp2phost.Host) (*pubsub.PubSub, error) {
+	return pubsub.NewGossipSub(ctx, host,
+		pubsub.WithMessageSignaturePolicy(pubsub.StrictSign),
+		pubsub.WithPeerScore(peerScoreParams(), peerScoreThresholds),
+		pubsub.WithPeerExchange(true),
+		pubsub.WithPeerFilter(func(id peer.ID, _ string) bool {
+			return h.IsPeerAllowed(id)
+		}),
+	)
+}
+
+// OfferTopic returns the topic on which the offers for the asset on the chain
+// with the given ID, zero being the primary chain, are announced.
+func OfferTopic(env common.Environment, chainID uint64, asset types.EthAsset) string {
+	return ChainProtocolID(env) + "/" + offerTopicName(chainID, asset)
+}
+
+func offerTopicName(chainID uint64, asset types.EthAsset) string {
+	assetStr := "ETH"
+	if asset.IsToken() {
+		assetStr = ethcommon.Address(asset).Hex()
+	}
+
+	if chainID != 0 {
+		return fmt.Sprintf("offers/%d/%s", chainID, assetStr)
+	}
+	return "offers/" + assetStr
+}
+
+// checkTopic checks that the announcement is on an offer topic of our
+// environment, and on the topic of its offer unless the offer was withdrawn.
+func (h *Host) checkTopic(m *message.OfferAnnouncement) error {
+	i := strings.Index(m.Topic, "/offers/")
+	if i < 0 {
+		return fmt.Errorf("unknown offer topic %s", m.Topic)
+	}
+
+	envPrefix, name := m.Topic[:i], m.Topic[i+1:]
+	if envPrefix != ChainProtocolID(h.env) {
+		return fmt.Errorf("offer topic %s is not of our environment", m.Topic)
+	}
+
+	if m.Offer != nil && name != offerTopicName(m.Offer.ChainID, m.Offer.EthAsset) {
+		return fmt.Errorf("offer %s was announced on the wrong topic %s", m.OfferID, m.Topic)
+	}
+
+	return nil
+}
+
+// SubscribeOffers returns a channel that receives the validated announcements
+// of other makers on the offer topic of the asset, until the host's context is
+// cancelled. Announcements are dropped if the channel is full.
+func (h *Host) SubscribeOffers(chainID uint64, asset types.EthAsset) <-chan *message.OfferAnnouncement {
+	topic := OfferTopic(h.env, chainID, asset)
+	ch := make(chan *message.OfferAnnouncement, subscriptionBufferSize)
+
+	h.ann.mu.Lock()
+	first := len(h.ann.subs[topic]) == 0
+	h.ann.subs[topic] = append(h.ann.subs[topic], ch)
+	h.ann.mu.Unlock()
+
+	if first && h.ann.ps != nil {
+		if err := h.subscribeTopic(topic); err != nil {
+			log.Warnf("failed to subscribe to offer topic %s: %s", topic, err)
+			return ch
+		}
+	}
+
+	log.Debugf("subscribed to offer topic %s", topic)
+	return ch
+}
+
+// joinTopic returns the pubsub topic, joining it and registering its validator
+// and score parameters the first time.
+func (h *Host) joinTopic(name string) (*pubsub.Topic, error) {
+	h.ann.topicsMu.Lock()
+	defer h.ann.topicsMu.Unlock()
+
+	if t, ok := h.ann.topics[name]; ok {
+		return t, nil
+	}
+
+	if err := h.ann.ps.RegisterTopicValidator(name, h.validateAnnouncement); err != nil {
+		return nil, err
+	}
+
+	t, err := h.ann.ps.Join(name)
+	if err != nil {
+		_ = h.ann.ps.UnregisterTopicValidator(name)
+		return nil, err
+	}
+
+	if err = t.SetScoreParams(topicScoreParams()); err != nil {
+		_ = t.Close()
+		_ = h.ann.ps.UnregisterTopicValidator(name)
+		return nil, err
+	}
+
+	h.ann.topics[name] = t
+	return t, nil
+}
+
+// subscribeTopic joins the mesh of the pubsub topic, and delivers its
+// announcements to the subscribers of the topic.
+func (h *Host) subscribeTopic(name string) error {
+	t, err := h.joinTopic(name)
+	if err != nil {
+		return err
+	}
+
+	sub, err := t.Subscribe(pubsub.WithBufferSize(subscriptionBufferSize))
+	if err != nil {
+		return err
+	}
+
+	go h.readAnnouncements(sub)
+	return nil
+}
+
+// readAnnouncements delivers the announcements of the subscription until the
+// host's context is cancelled.
+func (h *Host) readAnnouncements(sub *pubsub.Subscription) {
+	defer sub.Cancel()
+
+	for {
+		msg, err := sub.Next(h.ctx)
+		if err != nil {
+			return
+		}
+
+		// our own announcements don't have validator data
+		if m, ok := msg.ValidatorData.(*message.OfferAnnouncement); ok {
+			h.deliverAnnouncement(m)
+		}
+	}
+}
+
+// validateAnnouncement is the pubsub validator of the offer topics. Invalid
+// announcements are rejected, which penalizes the peer that forwarded them.
+func (h *Host) validateAnnouncement(_ context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	// our own announcements, signed by checkOffers
+	if from == h.ann.self {
+		return pubsub.ValidationAccept
+	}
+
+	m, err := decodeAnnouncement(msg)
+	if err == nil {
+		err = h.acceptAnnouncement(from, m, time.Now())
+	}
+
+	switch {
+	case err == nil:
+		msg.ValidatorData = m
+		return pubsub.ValidationAccept
+	case errors.Is(err, errDuplicateAnnouncement):
+		return pubsub.ValidationIgnore
+	case errors.Is(err, errStaleAnnouncement), errors.Is(err, errAnnouncementRateLimit):
+		log.Debugf("ignoring announcement from peer %s: %s", from, err)
+		return pubsub.ValidationIgnore
+	default:
+		log.Debugf("rejecting announcement from peer %s: %s", from, err)
+		return pubsub.ValidationReject
+	}
+}
+
+// decodeAnnouncement decodes the announcement of a pubsub message, which must
+// be published by the maker on the topic of the announcement.
+func decodeAnnouncement(msg *pubsub.Message) (*message.OfferAnnouncement, error) {
+	if len(msg.Data) > maxAnnouncementSize {
+		return nil, fmt.Errorf("announcement of %d bytes is too large", len(msg.Data))
+	}
+
+	decoded, err := message.DecodeMessage(msg.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := decoded.(*message.OfferAnnouncement)
+	if !ok {
+		return nil, fmt.Errorf("expected %s message but received %s",
+			message.TypeToString(message.OfferAnnouncementType), message.TypeToString(decoded.Type()))
+	}
+
+	if m.Topic != msg.GetTopic() {
+		return nil, fmt.Errorf("announcement for topic %s was published on topic %s", m.Topic, msg.GetTopic())
+	}
+	if m.Maker != msg.GetFrom() {
+		return nil, fmt.Errorf("announcement of maker %s was published by peer %s", m.Maker, msg.GetFrom())
+	}
+
+	return m, nil
+}
+
+// acceptAnnouncement validates an announcement received from a peer and
+// applies the rate limits. Announcements that are accepted are recorded, so
+// that the same announcement from other peers is a duplicate.
+func (h *Host) acceptAnnouncement(from peer.ID, m *message.OfferAnnouncement, now time.Time) error {
+	h.ann.mu.Lock()
+	defer h.ann.mu.Unlock()
+
+	if !allow(h.ann.peerLimits, from, peerAnnouncementRate, peerAnnouncementBurst, now) {
+		return fmt.Errorf("peer %s %w", from, errAnnouncementRateLimit)
+	}
+
+	if m.Maker == h.ann.self {
+		return errDuplicateAnnouncement
+	}
+
+	signedAt := time.Unix(0, int64(m.Seq))
+	if now.Sub(signedAt) > maxAnnouncementAge {
+		return fmt.Errorf("%w: signed at %s is too old", errStaleAnnouncement, signedAt)
+	}
+	if signedAt.Sub(now) > maxClockSkew {
+		return fmt.Errorf("%w: signed at %s is in the future", errStaleAnnouncement, signedAt)
+	}
+
+	key := announcementKey{m.Maker, m.OfferID}
+	if m.Seq <= h.ann.lastSeq[key] {
+		return errDuplicateAnnouncement
+	}
+
+	if m.Offer != nil && m.Offer.Provides != coins.ProvidesXMR {
+		return fmt.Errorf("announced offer provides %s", m.Offer.Provides)
+	}
+
+	if err := h.checkTopic(m); err != nil {
+		return err
+	}
+
+	if err := m.Verify(); err != nil {
+		return err
+	}
+
+	if !allow(h.ann.makerLimits, m.Maker, makerAnnouncementRate, makerAnnouncementBurst, now) {
+		return fmt.Errorf("maker %s %w", m.Maker, errAnnouncementRateLimit)
+	}
+
+	h.ann.lastSeq[key] = m.Seq
+	return nil
+}
+
+// allow takes a token from the rate limiter of the peer, creating it if
+// needed. The caller must hold the lock.
+func allow(limiters map[peer.ID]*rate.Limiter, id peer.ID, r rate.Limit, burst int, now time.Time) bool {
+	l, ok := limiters[id]
+	if !ok {
+		l = rate.NewLimiter(r, burst)
+		limiters[id] = l
+	}
+	return l.AllowN(now, 1)
+}
+
+func (h *Host) deliverAnnouncement(m *message.OfferAnnouncement) {
+	h.ann.mu.Lock()
+	defer h.ann.mu.Unlock()
+
+	for _, ch := range h.ann.subs[m.Topic] {
+		select {
+		case ch <- m:
+		default:
+			log.Debugf("subscriber of offer topic %s is slow, dropping announcement", m.Topic)
+		}
+	}
+}
+
+// publishAnnouncement publishes our announcement on its offer topic. Pubsub
+// queues the message for each peer, dropping it for peers that are too slow.
+func (h *Host) publishAnnouncement(m *message.OfferAnnouncement) {
+	t, err := h.joinTopic(m.Topic)
+	if err != nil {
+		log.Warnf("failed to join offer topic %s: %s", m.Topic, err)
+		return
+	}
+
+	b, err := m.Encode()
+	if err != nil {
+		log.Warnf("failed to encode announcement of offer %s: %s", m.OfferID, err)
+		return
+	}
+
+	if err = t.Publish(h.ctx, b); err != nil {
+		log.Warnf("failed to publish announcement of offer %s: %s", m.OfferID, err)
+	}
+}
+
+// checkOffersNow requests that our offers are announced right away instead of
+// at the next check.
+func (h *Host) checkOffersNow() {
+	select {
+	case h.ann.check <- struct{}{}:
+	default:
+	}
+}
+
+// announceOffers announces the changes of our offers until the host's context
+// is cancelled, and prunes the announcement state.
+func (h *Host) announceOffers() {
+	ticker := time.NewTicker(offerCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-h.ann.check:
+		}
+
+		now := time.Now()
+		h.ann.prune(now)
+		if h.makerHandler != nil && h.ann.ps != nil {
+			for _, m := range h.checkOffers(now) {
+				h.publishAnnouncement(m)
+			}
+		}
+	}
+}
+
+// checkOffers compares our offers with the offers that we announced, and
+// returns the signed announcements of the new and withdrawn offers, and of the
+// offers that are due to be re-announced.
+func (h *Host) checkOffers(now time.Time) []*message.OfferAnnouncement {
+	var anns []*message.OfferAnnouncement
+	announce := func(action message.OfferAction, offer *types.Offer) {
+		m := &message.OfferAnnouncement{
+			Topic:   OfferTopic(h.env, offer.ChainID, offer.EthAsset),
+			Action:  action,
+			OfferID: offer.ID,
+			Seq:     h.ann.nextSeq(now),
+		}
+		if action != message.OfferWithdrawn {
+			m.Offer = offer
+		}
+
+		if err := m.Sign(h.ann.key); err != nil {
+			log.Warnf("failed to sign announcement of offer %s: %s", offer.ID, err)
+			return
+		}
+		anns = append(anns, m)
+	}
+
+	current := make(map[types.Hash]struct{})
+	for _, offer := range h.makerHandler.GetOffers() {
+		current[offer.ID] = struct{}{}
+
+		p, ok := h.ann.published[offer.ID]
+		switch {
+		case !ok:
+			announce(message.OfferNew, offer)
+			h.ann.published[offer.ID] = &publishedOffer{offer: offer, announcedAt: now}
+		case now.Sub(p.announcedAt) >= offerReannounceInterval:
+			announce(message.OfferUpdated, offer)
+			p.announcedAt = now
+		}
+	}
+
+	for id, p := range h.ann.published {
+		if _, ok := current[id]; !ok {
+			announce(message.OfferWithdrawn, p.offer)
+			delete(h.ann.published, id)
+		}
+	}
+
+	return anns
+}
+
+// nextSeq returns the sequence number of our next announcement.
+func (a *announcements) nextSeq(now time.Time) uint64 {
+	seq := uint64(now.UnixNano())
+	if seq <= a.seq {
+		seq = a.seq + 1
+	}
+	a.seq = seq
+	return seq
+}
+
+// prune removes the sequence numbers of announcements that would be dropped as
+// too old anyway, and the rate limiters that are back to their full burst.
+func (a *announcements) prune(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, seq := range a.lastSeq {
+		if now.Sub(time.Unix(0, int64(seq))) > maxAnnouncementAge {
+			delete(a.lastSeq, key)
+		}
+	}
+
+	for _, limiters := range []map[peer.ID]*rate.Limiter{a.peerLimits, a.makerLimits} {
+		for id, l := range limiters {
+			if l.TokensAt(now) >= float64(l.Burst()) {
+				delete(limiters, id)
+			}
+		}
+	}
+}
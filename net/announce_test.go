@@ -0,0 +1,347 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/net/message"
+)
+
+var bob, _ = peer.Decode("12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5")
+
+type offersMakerHandler struct {
+	mockMakerHandler
+	offers []*types.Offer
+}
+
+func (h *offersMakerHandler) GetOffers() []*types.Offer {
+	return h.offers
+}
+
+func newTestKey(t *testing.T) crypto.PrivKey {
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+// newAnnouncementHost returns a host that can validate and create
+// announcements, without a p2p host.
+func newAnnouncementHost(t *testing.T, offers ...*types.Offer) *Host {
+	ann, err := newAnnouncements(newTestKey(t))
+	require.NoError(t, err)
+	return &Host{
+		ctx:          context.Background(),
+		env:          common.Development,
+		makerHandler: &offersMakerHandler{offers: offers},
+		ann:          ann,
+	}
+}
+
+func newTestOffer(asset types.EthAsset) *types.Offer {
+	return types.NewOffer(
+		coins.ProvidesXMR,
+		coins.StrToDecimal("1"),
+		coins.StrToDecimal("10"),
+		coins.ToExchangeRate(coins.StrToDecimal("0.05")),
+		asset,
+	)
+}
+
+func newTestAnnouncement(
+	t *testing.T,
+	key crypto.PrivKey,
+	action message.OfferAction,
+	offer *types.Offer,
+	signedAt time.Time,
+) *message.OfferAnnouncement {
+	m := &message.OfferAnnouncement{
+		Topic:   OfferTopic(common.Development, offer.ChainID, offer.EthAsset),
+		Action:  action,
+		OfferID: offer.ID,
+		Seq:     uint64(signedAt.UnixNano()),
+	}
+	if action != message.OfferWithdrawn {
+		m.Offer = offer
+	}
+	require.NoError(t, m.Sign(key))
+	return m
+}
+
+func TestOfferTopic(t *testing.T) {
+	token := types.EthAsset(ethcommon.HexToAddress("0xa1E32d14AC4B6d8c1791CAe8E9baD46a1E15B7a8"))
+	require.Equal(t, "/atomic-swap/mainnet/2/offers/ETH", OfferTopic(common.Mainnet, 0, types.EthAssetETH))
+	require.Equal(t,
+		"/atomic-swap/mainnet/2/offers/0xa1E32d14AC4B6d8c1791CAe8E9baD46a1E15B7a8",
+		OfferTopic(common.Mainnet, 0, token),
+	)
+	require.Equal(t, "/atomic-swap/mainnet/2/offers/10/ETH", OfferTopic(common.Mainnet, 10, types.EthAssetETH))
+}
+
+func TestHost_acceptAnnouncement(t *testing.T) {
+	h := newAnnouncementHost(t)
+	makerKey := newTestKey(t)
+	offer := newTestOffer(types.EthAssetETH)
+	now := time.Now()
+
+	m := newTestAnnouncement(t, makerKey, message.OfferNew, offer, now)
+
+	// the announcement survives encoding
+	b, err := m.Encode()
+	require.NoError(t, err)
+	decoded, err := message.DecodeMessage(b)
+	require.NoError(t, err)
+	require.NoError(t, h.acceptAnnouncement(bob, decoded.(*message.OfferAnnouncement), now))
+
+	// the same announcement from the same or another peer is a duplicate, and
+	// so are older ones
+	require.ErrorIs(t, h.acceptAnnouncement(bob, m, now), errDuplicateAnnouncement)
+	require.ErrorIs(t, h.acceptAnnouncement(h.ann.self, m, now), errDuplicateAnnouncement)
+	old := newTestAnnouncement(t, makerKey, message.OfferUpdated, offer, now.Add(-time.Second))
+	require.ErrorIs(t, h.acceptAnnouncement(bob, old, now), errDuplicateAnnouncement)
+
+	withdrawn := newTestAnnouncement(t, makerKey, message.OfferWithdrawn, offer, now.Add(time.Second))
+	require.NoError(t, h.acceptAnnouncement(bob, withdrawn, now))
+
+	// our own announcements are ignored
+	own := newTestAnnouncement(t, h.ann.key, message.OfferNew, offer, now)
+	require.ErrorIs(t, h.acceptAnnouncement(bob, own, now), errDuplicateAnnouncement)
+
+	other := newTestOffer(types.EthAssetETH)
+	tests := []struct {
+		name   string
+		modify func(m *message.OfferAnnouncement)
+		errMsg string
+	}{
+		{
+			name:   "too old",
+			modify: func(m *message.OfferAnnouncement) { m.Seq = uint64(now.Add(-maxAnnouncementAge - 1).UnixNano()) },
+			errMsg: "is too old",
+		},
+		{
+			name:   "in the future",
+			modify: func(m *message.OfferAnnouncement) { m.Seq = uint64(now.Add(2 * maxClockSkew).UnixNano()) },
+			errMsg: "is in the future",
+		},
+		{
+			name:   "other environment",
+			modify: func(m *message.OfferAnnouncement) { m.Topic = OfferTopic(common.Mainnet, 0, types.EthAssetETH) },
+			errMsg: "is not of our environment",
+		},
+		{
+			name: "wrong topic",
+			modify: func(m *message.OfferAnnouncement) {
+				m.Topic = OfferTopic(common.Development, 0, types.EthAsset(ethcommon.Address{0x1}))
+			},
+			errMsg: "announced on the wrong topic",
+		},
+		{
+			name: "unknown topic",
+			modify: func(m *message.OfferAnnouncement) {
+				m.Action = message.OfferWithdrawn
+				m.Offer = nil
+				m.Topic = "/other"
+			},
+			errMsg: "unknown offer topic",
+		},
+		{
+			name:   "different offer",
+			modify: func(m *message.OfferAnnouncement) { m.Offer = other },
+			errMsg: "does not have offer",
+		},
+		{
+			name:   "withdrawn with offer",
+			modify: func(m *message.OfferAnnouncement) { m.Action = message.OfferWithdrawn },
+			errMsg: "withdrawn announcement has an offer",
+		},
+		{
+			name:   "modified sequence number",
+			modify: func(m *message.OfferAnnouncement) { m.Seq++ },
+			errMsg: "invalid announcement signature",
+		},
+		{
+			name:   "other maker",
+			modify: func(m *message.OfferAnnouncement) { m.Maker = bob },
+			errMsg: "invalid announcement signature",
+		},
+	}
+
+	for _, tc := range tests {
+		m := newTestAnnouncement(t, makerKey, message.OfferNew, newTestOffer(types.EthAssetETH), now)
+		tc.modify(m)
+		require.ErrorContains(t, h.acceptAnnouncement(bob, m, now), tc.errMsg, tc.name)
+	}
+}
+
+func newPubsubMessage(t *testing.T, m *message.OfferAnnouncement, publisher peer.ID) *pubsub.Message {
+	b, err := m.Encode()
+	require.NoError(t, err)
+	topic := m.Topic
+	return &pubsub.Message{Message: &pb.Message{Data: b, Topic: &topic, From: []byte(publisher)}}
+}
+
+func TestHost_validateAnnouncement(t *testing.T) {
+	ctx := context.Background()
+	h := newAnnouncementHost(t)
+	makerKey := newTestKey(t)
+	now := time.Now()
+
+	m := newTestAnnouncement(t, makerKey, message.OfferNew, newTestOffer(types.EthAssetETH), now)
+	msg := newPubsubMessage(t, m, m.Maker)
+	require.Equal(t, pubsub.ValidationAccept, h.validateAnnouncement(ctx, bob, msg))
+	require.Equal(t, m.OfferID, msg.ValidatorData.(*message.OfferAnnouncement).OfferID)
+
+	// duplicates and stale announcements are ignored
+	require.Equal(t, pubsub.ValidationIgnore, h.validateAnnouncement(ctx, bob, newPubsubMessage(t, m, m.Maker)))
+	stale := newTestAnnouncement(t, makerKey, message.OfferNew, newTestOffer(types.EthAssetETH),
+		now.Add(-maxAnnouncementAge-time.Minute))
+	require.Equal(t, pubsub.ValidationIgnore, h.validateAnnouncement(ctx, bob, newPubsubMessage(t, stale, stale.Maker)))
+
+	// announcements that are not published by their maker, not on their topic,
+	// or not signed by their maker are rejected
+	other := newTestAnnouncement(t, makerKey, message.OfferNew, newTestOffer(types.EthAssetETH), now)
+	require.Equal(t, pubsub.ValidationReject, h.validateAnnouncement(ctx, bob, newPubsubMessage(t, other, bob)))
+
+	msg = newPubsubMessage(t, other, other.Maker)
+	tokenTopic := OfferTopic(common.Development, 0, types.EthAsset(ethcommon.Address{0x1}))
+	msg.Topic = &tokenTopic
+	require.Equal(t, pubsub.ValidationReject, h.validateAnnouncement(ctx, bob, msg))
+
+	other.Signature[0] ^= 0xff
+	require.Equal(t, pubsub.ValidationReject, h.validateAnnouncement(ctx, bob, newPubsubMessage(t, other, other.Maker)))
+
+	topic := m.Topic
+	garbage := &pubsub.Message{Message: &pb.Message{Data: []byte{0xff}, Topic: &topic, From: []byte(m.Maker)}}
+	require.Equal(t, pubsub.ValidationReject, h.validateAnnouncement(ctx, bob, garbage))
+
+	// our own announcements are accepted
+	own := newTestAnnouncement(t, h.ann.key, message.OfferNew, newTestOffer(types.EthAssetETH), now)
+	require.Equal(t, pubsub.ValidationAccept, h.validateAnnouncement(ctx, h.ann.self, newPubsubMessage(t, own, own.Maker)))
+}
+
+func TestHost_acceptAnnouncement_rateLimits(t *testing.T) {
+	h := newAnnouncementHost(t)
+	makerA := newTestKey(t)
+	makerB := newTestKey(t)
+	now := time.Now()
+
+	announce := func(from peer.ID, key crypto.PrivKey) error {
+		m := newTestAnnouncement(t, key, message.OfferNew, newTestOffer(types.EthAssetETH), now)
+		return h.acceptAnnouncement(from, m, now)
+	}
+
+	// makers are limited, whichever peer forwards their announcements
+	for i := 0; i < makerAnnouncementBurst; i++ {
+		require.NoError(t, announce(bob, makerA))
+	}
+	require.ErrorContains(t, announce(h.ann.self, makerA), "exceeded the announcement rate limit")
+
+	// and peers, whichever maker the announcements are from
+	for i := makerAnnouncementBurst; i < peerAnnouncementBurst; i++ {
+		require.NoError(t, announce(bob, makerB))
+	}
+	require.ErrorContains(t, announce(bob, makerB), "peer "+bob.String()+" exceeded")
+
+	// the limiters recover and are pruned once they are full again
+	later := now.Add(time.Hour)
+	h.ann.prune(later)
+	require.Empty(t, h.ann.peerLimits)
+	require.Empty(t, h.ann.makerLimits)
+	require.Empty(t, h.ann.lastSeq)
+}
+
+func TestHost_checkOffers(t *testing.T) {
+	ethOffer := newTestOffer(types.EthAssetETH)
+	tokenOffer := newTestOffer(types.EthAsset(ethcommon.Address{0x1}))
+	maker := newAnnouncementHost(t, ethOffer, tokenOffer)
+	taker := newAnnouncementHost(t)
+	ethAnns := taker.SubscribeOffers(0, types.EthAssetETH)
+
+	// announcements of the maker are accepted and delivered by the taker
+	receive := func(now time.Time) []*message.OfferAnnouncement {
+		anns := maker.checkOffers(now)
+		for _, m := range anns {
+			require.NoError(t, taker.acceptAnnouncement(maker.ann.self, m, now))
+			taker.deliverAnnouncement(m)
+		}
+		return anns
+	}
+
+	now := time.Now()
+	anns := receive(now)
+	require.Len(t, anns, 2)
+	for _, m := range anns {
+		require.Equal(t, message.OfferNew, m.Action)
+	}
+	m := <-ethAnns
+	require.Equal(t, ethOffer.ID, m.Offer.ID)
+	require.Equal(t, maker.ann.self, m.Maker)
+	require.Empty(t, ethAnns) // the token offer is on another topic
+
+	// nothing changed
+	require.Empty(t, receive(now.Add(time.Second)))
+
+	// offers are re-announced
+	now = now.Add(offerReannounceInterval)
+	anns = receive(now)
+	require.Len(t, anns, 2)
+	require.Equal(t, message.OfferUpdated, anns[0].Action)
+	require.Equal(t, message.OfferUpdated, (<-ethAnns).Action)
+
+	// and withdrawn, even within the same nanosecond
+	maker.makerHandler.(*offersMakerHandler).offers = []*types.Offer{tokenOffer}
+	anns = receive(now)
+	require.Len(t, anns, 1)
+	m = <-ethAnns
+	require.Equal(t, message.OfferWithdrawn, m.Action)
+	require.Equal(t, ethOffer.ID, m.OfferID)
+	require.Nil(t, m.Offer)
+}
+
+func TestHost_announcementsOverGossipSub(t *testing.T) {
+	offer := newTestOffer(types.EthAssetETH)
+	maker, err := NewHost(basicTestConfig(t))
+	require.NoError(t, err)
+	maker.SetHandlers(&offersMakerHandler{offers: []*types.Offer{offer}}, &mockRelayHandler{t: t})
+	t.Cleanup(func() {
+		require.NoError(t, maker.Stop())
+	})
+	taker := newHost(t, basicTestConfig(t))
+
+	anns := taker.SubscribeOffers(0, types.EthAssetETH)
+	require.NoError(t, taker.h.Connect(taker.ctx, maker.AddrInfo()))
+
+	// the maker publishes once it knows that the taker subscribed
+	topic, err := maker.joinTopic(OfferTopic(common.Development, 0, types.EthAssetETH))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(topic.ListPeers()) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+
+	published := maker.checkOffers(time.Now())
+	require.Len(t, published, 1)
+	maker.publishAnnouncement(published[0])
+
+	select {
+	case m := <-anns:
+		require.Equal(t, message.OfferNew, m.Action)
+		require.Equal(t, offer.ID, m.Offer.ID)
+		require.Equal(t, maker.PeerID(), m.Maker)
+	case <-time.After(10 * time.Second):
+		t.Fatal("announcement was not received")
+	}
+}
@@ -9,8 +9,16 @@ import (
 
 var (
 	errBootnodeCannotRelay   = errors.New("bootnode cannot be a relayer")
+	errCannotConnectToSelf   = errors.New("cannot connect to self")
+	errFailedToBootstrap     = errors.New("failed to bootstrap to any bootnode")
+	errIndexerNeedsSwapEnv   = errors.New("indexing hosts must be in a swap environment and can't relay")
+	errInvalidListenIP       = errors.New("invalid ListenIP")
 	errNilHandler            = errors.New("handler is nil")
+	errNoDNSThroughProxy     = errors.New("multiaddr DNS lookups are disabled with a proxy")
+	errMessageTooLarge       = errors.New("message too large")
+	errNilStream             = errors.New("stream is nil")
 	errNoOngoingSwap         = errors.New("no swap currently happening")
 	errOfferUnavailable      = errors.New("offer no longer available")
+	errResumeRejected        = errors.New("peer rejected resuming the swap")
 	errSwapAlreadyInProgress = errors.New("swap is already in progress")
 )
@@ -0,0 +1,150 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/athanorlabs/atomic-swap/net/message"
+)
+
+// Takers handshake with the maker before a swap. Peers that don't support the
+// handshake fail it, and the swap then uses message.ProtocolVersion1 and JSON.
+// The taker sends its capabilities again in the SendKeysMessage that opens the
+// swap stream, so the maker negotiates the same protocol for the swap without
+// keeping state between the handshake and the swap.
+const (
+	handshakeProtocolID     = "/handshake"
+	maxHandshakeMessageSize = 4096
+	handshakeTimeout        = 15 * time.Second
+)
+
+// peerProtocol is what the host negotiated with a peer. It is kept on the swap
+// for the lifetime of the swap.
+type peerProtocol struct {
+	version uint16
+	// encoding of the swap streams that we open with the peer
+	encoding message.Encoding
+	// resume is set if both of us resume swaps after their stream drops
+	resume bool
+}
+
+// protocolV1 is used with peers that didn't handshake
+var protocolV1 = peerProtocol{version: message.ProtocolVersion1, encoding: message.JSONEncoding}
+
+// Capabilities returns the protocol versions and features of the host, which
+// it advertises in handshakes and query responses.
+func (h *Host) Capabilities() *message.Capabilities {
+	caps := &message.Capabilities{
+		Versions: message.SupportedProtocolVersions,
+		Features: []message.Feature{message.FeatureCBOR, message.FeatureResume},
+	}
+	// the relay handler is only set on swap nodes
+	if h.relayHandler != nil {
+		caps.Features = append(caps.Features, message.FeatureCounterpartyRelay)
+		if h.isRelayer.Load() {
+			caps.Features = append(caps.Features, message.FeatureRelayer)
+		}
+	}
+	return caps
+}
+
+// negotiateProtocol negotiates the protocol version and stream encoding with
+// the capabilities of the peer, which are nil for peers that didn't handshake.
+func negotiateProtocol(local *message.Capabilities, remote *message.Capabilities) (peerProtocol, error) {
+	version, err := message.Negotiate(local, remote)
+	if err != nil {
+		return peerProtocol{}, err
+	}
+
+	p := peerProtocol{version: version, encoding: message.JSONEncoding}
+	if local.HasFeature(message.FeatureCBOR) && remote.HasFeature(message.FeatureCBOR) {
+		p.encoding = message.CBOREncoding
+	}
+	p.resume = local.HasFeature(message.FeatureResume) && remote.HasFeature(message.FeatureResume)
+	return p, nil
+}
+
+// Handshake exchanges capabilities with the peer. It returns the capabilities
+// of the peer.
+func (h *Host) Handshake(who peer.ID) (*message.Capabilities, error) {
+	caps, _, err := h.handshake(who)
+	return caps, err
+}
+
+// handshake is Handshake, which also returns the negotiated protocol.
+func (h *Host) handshake(who peer.ID) (*message.Capabilities, peerProtocol, error) {
+	ctx, cancel := context.WithTimeout(h.ctx, connectionTimeout)
+	defer cancel()
+
+	stream, err := h.h.NewStream(ctx, who, handshakeProtocolID)
+	if err != nil {
+		return nil, peerProtocol{}, fmt.Errorf("failed to open handshake stream with peer: %w", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	local := h.Capabilities()
+	if err = writeStreamMessage(stream, &message.Handshake{Capabilities: local}, message.JSONEncoding, who); err != nil {
+		return nil, peerProtocol{}, err
+	}
+
+	select {
+	case msg := <-nextStreamMessage(stream, maxHandshakeMessageSize):
+		if msg == nil {
+			return nil, peerProtocol{}, errors.New("failed to read Handshake")
+		}
+
+		resp, ok := msg.(*message.Handshake)
+		if !ok {
+			return nil, peerProtocol{}, fmt.Errorf("expected %s message but received %s",
+				message.TypeToString(message.HandshakeType),
+				message.TypeToString(msg.Type()))
+		}
+
+		p, err := negotiateProtocol(local, resp.Capabilities)
+		if err != nil {
+			return nil, peerProtocol{}, err
+		}
+
+		log.Debugf("negotiated protocol version %d and %s streams with peer %s", p.version, p.encoding, who)
+
+		return resp.Capabilities, p, nil
+	case <-time.After(handshakeTimeout):
+		return nil, peerProtocol{}, errors.New("timed out waiting for Handshake")
+	}
+}
+
+func (h *Host) handleHandshakeStream(stream libp2pnetwork.Stream) {
+	defer func() { _ = stream.Close() }()
+
+	remotePeer := stream.Conn().RemotePeer()
+	msg, err := readStreamMessage(stream, maxHandshakeMessageSize)
+	if err != nil {
+		log.Debugf("failed to read handshake from peer %s: %s", remotePeer, err)
+		return
+	}
+
+	req, ok := msg.(*message.Handshake)
+	if !ok {
+		log.Debugf("expected %s message from peer %s but received %s",
+			message.TypeToString(message.HandshakeType), remotePeer, message.TypeToString(msg.Type()))
+		return
+	}
+
+	// we answer even without a common version, so that the peer can tell why
+	local := h.Capabilities()
+	if _, err = negotiateProtocol(local, req.Capabilities); err != nil {
+		log.Debugf("handshake with peer %s failed: %s", remotePeer, err)
+	}
+
+	if err = writeStreamMessage(stream, &message.Handshake{Capabilities: local}, message.JSONEncoding, remotePeer); err != nil {
+		log.Debugf("failed to send Handshake to peer %s: %s", remotePeer, err)
+	}
+}
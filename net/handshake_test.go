@@ -0,0 +1,80 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/net/message"
+)
+
+func TestNegotiate(t *testing.T) {
+	local := &message.Capabilities{Versions: message.SupportedProtocolVersions}
+
+	// peers without capabilities only speak version 1
+	v, err := message.Negotiate(local, nil)
+	require.NoError(t, err)
+	require.Equal(t, message.ProtocolVersion1, v)
+
+	v, err = message.Negotiate(local, &message.Capabilities{Versions: []uint16{1, 2, 3}})
+	require.NoError(t, err)
+	require.Equal(t, message.ProtocolVersion2, v)
+
+	_, err = message.Negotiate(local, &message.Capabilities{Versions: []uint16{3}})
+	require.ErrorIs(t, err, message.ErrNoCommonProtocolVersion)
+}
+
+func TestNegotiateProtocol(t *testing.T) {
+	h := newHost(t, basicTestConfig(t))
+	local := h.Capabilities()
+	require.True(t, local.HasFeature(message.FeatureCBOR))
+
+	p, err := negotiateProtocol(local, &message.Capabilities{
+		Versions: []uint16{message.ProtocolVersion1},
+		Features: []message.Feature{message.FeatureCBOR},
+	})
+	require.NoError(t, err)
+	require.Equal(t, peerProtocol{version: message.ProtocolVersion1, encoding: message.CBOREncoding}, p)
+
+	p, err = negotiateProtocol(local, &message.Capabilities{
+		Versions: message.SupportedProtocolVersions,
+		Features: []message.Feature{message.FeatureResume},
+	})
+	require.NoError(t, err)
+	require.Equal(t, peerProtocol{version: message.ProtocolVersion2, encoding: message.JSONEncoding, resume: true}, p)
+
+	// peers without a handshake use version 1 and JSON
+	p, err = negotiateProtocol(local, nil)
+	require.NoError(t, err)
+	require.Equal(t, protocolV1, p)
+}
+
+func TestHost_Handshake(t *testing.T) {
+	ha := newHost(t, basicTestConfig(t))
+	require.NoError(t, ha.Start())
+
+	cfg := basicTestConfig(t)
+	cfg.IsRelayer = true
+	hb := newHost(t, cfg)
+	require.NoError(t, hb.Start())
+
+	require.NoError(t, ha.h.Connect(ha.ctx, hb.h.AddrInfo()))
+
+	caps, p, err := ha.handshake(hb.PeerID())
+	require.NoError(t, err)
+	require.True(t, caps.HasFeature(message.FeatureRelayer))
+	require.True(t, caps.HasFeature(message.FeatureCounterpartyRelay))
+
+	// the highest common version is used
+	expected := peerProtocol{version: message.ProtocolVersion2, encoding: message.CBOREncoding, resume: true}
+	require.Equal(t, expected, p)
+
+	// query responses advertise the capabilities too
+	resp, err := hb.Query(ha.PeerID())
+	require.NoError(t, err)
+	require.Equal(t, ha.Capabilities(), resp.Capabilities)
+	require.False(t, resp.Capabilities.HasFeature(message.FeatureRelayer))
+}
@@ -1,8 +1,8 @@
 // Copyright 2023 The AthanorLabs/atomic-swap Authors
 // SPDX-License-Identifier: LGPL-3.0-only
 
-// Package net adds swap-specific functionality to go-p2p-net/Host,
-// in particular the swap messages for querying and initiation.
+// Package net implements the peer-to-peer network of swap nodes on top of
+// libp2p, in particular the swap messages for querying and initiation.
 package net
 
 import (
@@ -11,10 +11,12 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	p2pnet "github.com/athanorlabs/go-p2p-net"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/event"
+	libp2phost "github.com/libp2p/go-libp2p/core/host"
 	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
@@ -22,6 +24,7 @@ import (
 
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	"github.com/athanorlabs/atomic-swap/common/types"
 	"github.com/athanorlabs/atomic-swap/net/message"
 )
@@ -60,16 +63,22 @@ type P2pHost interface {
 	Addresses() []ma.Multiaddr
 	PeerID() peer.ID
 	ConnectedPeers() []string
+
+	Libp2pHost() libp2phost.Host
 }
 
 // Host represents a p2p node that implements the atomic swap protocol.
 type Host struct {
 	ctx       context.Context
+	env       common.Environment
 	h         P2pHost
-	isRelayer bool
+	isRelayer atomic.Bool
 
 	// set to true if the node is a bootnode-only node
 	isBootnode bool
+	// set to true if the node only discovers and queries makers, see
+	// Config.IsIndexer
+	isIndexer bool
 
 	makerHandler MakerHandler
 	relayHandler RelayHandler
@@ -77,18 +86,41 @@ type Host struct {
 	// swap instance info
 	swapMu sync.RWMutex
 	swaps  map[types.Hash]*swap
+
+	// peers allowed or denied to open streams with us, see SetPeerLists
+	peersMu      sync.RWMutex
+	allowedPeers map[peer.ID]struct{}
+	deniedPeers  map[peer.ID]struct{}
+
+	// offer announcements, see announce.go
+	ann *announcements
+
+	// reachability detected by libp2p, see reachability.go
+	reachMu  sync.RWMutex
+	reach    Reachability
+	reachSub event.Subscription
 }
 
 // Config holds the initialization parameters for the NewHost constructor.
 type Config struct {
 	Ctx       context.Context
 	Env       common.Environment
-	DataDir   string
 	Port      uint16
 	KeyFile   string
 	Bootnodes []string
 	ListenIP  string
 	IsRelayer bool
+	// IsIndexer is set for hosts that only discover and query makers and
+	// receive offer announcements, like the offer index of a bootnode. They
+	// don't need handlers, and don't serve queries or swaps.
+	IsIndexer bool
+	// AllowedPeers, if not empty, are the only peers that can open streams
+	// with the host. DeniedPeers can't open streams with the host.
+	AllowedPeers []peer.ID
+	DeniedPeers  []peer.ID
+	// Proxy, if not nil, is the proxy that libp2p connections are dialed
+	// through.
+	Proxy *netproxy.Proxy
 }
 
 // ChainProtocolID returns the versioned p2p protocol ID that includes the
@@ -108,44 +140,81 @@ func NewHost(cfg *Config) (*Host, error) {
 	if isBootnode && cfg.IsRelayer {
 		return nil, errBootnodeCannotRelay
 	}
+	if cfg.IsIndexer && (isBootnode || cfg.IsRelayer) {
+		return nil, errIndexerNeedsSwapEnv
+	}
+
+	proxyOpts, err := proxyOptions(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
 
 	h := &Host{
 		ctx:        cfg.Ctx,
+		env:        cfg.Env,
 		h:          nil, // set below
-		isRelayer:  cfg.IsRelayer,
 		isBootnode: isBootnode,
+		isIndexer:  cfg.IsIndexer,
 		swaps:      make(map[types.Hash]*swap),
+		reach:      ReachabilityUnknown,
 	}
+	h.isRelayer.Store(cfg.IsRelayer)
+	h.SetPeerLists(cfg.AllowedPeers, cfg.DeniedPeers)
 
 	baseProtocolID := ChainProtocolID(cfg.Env)
 	log.Debugf("using base protocol %s", baseProtocolID)
 
-	var err error
-	h.h, err = p2pnet.NewHost(&p2pnet.Config{
+	// the libp2p key also signs our offer announcements
+	key, err := loadOrCreateKey(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	h.h, err = newP2pHost(&p2pHostConfig{
 		Ctx:                      cfg.Ctx,
-		DataDir:                  cfg.DataDir,
+		Key:                      key,
 		Port:                     cfg.Port,
-		KeyFile:                  cfg.KeyFile,
 		Bootnodes:                cfg.Bootnodes,
 		ProtocolID:               baseProtocolID,
 		ListenIP:                 cfg.ListenIP,
 		AdvertisedNamespacesFunc: h.advertisedNamespaces,
+		ProxyOptions:             proxyOpts,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	h.ann, err = newAnnouncements(key)
+	if err != nil {
+		return nil, err
+	}
+
+	h.reachSub, err = h.h.Libp2pHost().EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err != nil {
+		return nil, err
+	}
+
+	// bootnodes don't take part in offer announcements
+	if !isBootnode {
+		h.ann.ps, err = h.newGossipSub(cfg.Ctx, h.h.Libp2pHost())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h.h.SetStreamHandler(handshakeProtocolID, h.filterPeers(h.handleHandshakeStream))
+
 	return h, nil
 }
 
 func (h *Host) advertisedNamespaces() []string {
 	provides := []string{""}
 
-	if !h.isBootnode && len(h.makerHandler.GetOffers()) > 0 {
+	if h.makerHandler != nil && len(h.makerHandler.GetOffers()) > 0 {
 		provides = append(provides, string(coins.ProvidesXMR))
 	}
 
-	if !h.isBootnode && h.isRelayer {
+	if h.relayHandler != nil && h.isRelayer.Load() {
 		provides = append(provides, RelayerProvidesStr)
 	}
 
@@ -158,20 +227,26 @@ func (h *Host) SetHandlers(makerHandler MakerHandler, relayHandler RelayHandler)
 	h.makerHandler = makerHandler
 	h.relayHandler = relayHandler
 
-	h.h.SetStreamHandler(queryProtocolID, h.handleQueryStream)
-	h.h.SetStreamHandler(relayProtocolID, h.handleRelayStream)
-	h.h.SetStreamHandler(relayerQueryProtocolID, h.handleRelayerQueryStream)
-	h.h.SetStreamHandler(swapID, h.handleProtocolStream)
+	h.h.SetStreamHandler(queryProtocolID, h.filterPeers(h.handleQueryStream))
+	h.h.SetStreamHandler(relayProtocolID, h.filterPeers(h.handleRelayStream))
+	h.h.SetStreamHandler(relayerQueryProtocolID, h.filterPeers(h.handleRelayerQueryStream))
+	h.h.SetStreamHandler(swapID, h.filterPeers(h.handleProtocolStream))
 }
 
 // Start starts the bootstrap and discovery process.
 func (h *Host) Start() error {
-	if (h.makerHandler == nil || h.relayHandler == nil) && !h.isBootnode {
+	if (h.makerHandler == nil || h.relayHandler == nil) && !h.isBootnode && !h.isIndexer {
 		return errNilHandler
 	}
 
 	// Note: Start() is non-blocking
-	return h.h.Start()
+	if err := h.h.Start(); err != nil {
+		return err
+	}
+
+	go h.announceOffers()
+	go h.watchReachability(h.reachSub)
+	return nil
 }
 
 // Stop stops the host.
@@ -185,11 +260,34 @@ func (h *Host) SendSwapMessage(msg Message, id types.Hash) error {
 	defer h.swapMu.RUnlock()
 
 	swap, has := h.swaps[id]
-	if !has {
+	if !has || swap.stream == nil {
+		// there is no stream while Initiate opens it
 		return errNoOngoingSwap
 	}
 
-	return p2pnet.WriteStreamMessage(swap.stream, msg, swap.stream.Conn().RemotePeer())
+	// the peer checks that aborts are signed by the other peer of the stream
+	if abort, ok := msg.(*message.AbortSwap); ok {
+		if err := abort.Sign(h.ann.key); err != nil {
+			return err
+		}
+	}
+
+	// recorded even if the write fails, so that it is sent again if the swap
+	// resumes on a new stream
+	swap.messageSent(msg)
+	return writeStreamMessage(swap.stream, msg, swap.encoding, swap.peer)
+}
+
+// ProtocolVersion returns the protocol version of the swap, which was
+// negotiated in the handshake before its stream opened, or
+// message.ProtocolVersion1 if the swap is not ongoing.
+func (h *Host) ProtocolVersion(offerID types.Hash) uint16 {
+	h.swapMu.RLock()
+	defer h.swapMu.RUnlock()
+	if swap, has := h.swaps[offerID]; has {
+		return swap.version
+	}
+	return message.ProtocolVersion1
 }
 
 // CloseProtocolStream closes the current swap protocol stream.
@@ -231,9 +329,11 @@ func (h *Host) DeleteOngoingSwap(offerID types.Hash) {
 }
 
 // Advertise advertises the namespaces now instead of waiting for the next periodic
-// update. We use it when a new advertised namespace is added.
+// update. We use it when a new advertised namespace is added. New offers are
+// also announced right away.
 func (h *Host) Advertise() {
 	h.h.Advertise()
+	h.checkOffersNow()
 }
 
 // Discover searches the DHT for peers that advertise that they provide the given coin..
@@ -263,32 +363,57 @@ func (h *Host) PeerID() peer.ID {
 }
 
 func readStreamMessage(stream libp2pnetwork.Stream, maxMessageSize uint32) (common.Message, error) {
-	msgBytes, err := p2pnet.ReadStreamMessage(stream, maxMessageSize)
+	msg, _, err := readStreamMessageAndEncoding(stream, maxMessageSize)
+	return msg, err
+}
+
+// readStreamMessageAndEncoding reads the next message from the stream and
+// returns it with its encoding.
+func readStreamMessageAndEncoding(
+	stream libp2pnetwork.Stream,
+	maxMessageSize uint32,
+) (common.Message, message.Encoding, error) {
+	msgBytes, err := readStreamBytes(stream, maxMessageSize)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	return message.DecodeMessageAndEncoding(msgBytes)
+}
+
+// writeStreamMessage writes the message to the stream with the given encoding.
+// The peer ID is only used for logging.
+func writeStreamMessage(stream io.Writer, msg common.Message, enc message.Encoding, who peer.ID) error {
+	msgBytes, err := message.EncodeMessage(msg, enc)
+	if err != nil {
+		return err
+	}
+
+	if err = writeStreamBytes(stream, msgBytes); err != nil {
+		return err
 	}
 
-	return message.DecodeMessage(msgBytes)
+	log.Debugf("Sent %s message to peer=%s type=%s", enc, who, message.TypeToString(msg.Type()))
+	return nil
 }
 
 // nextStreamMessage returns a channel that will receive the next message from the stream.
 // if there is an error reading from the stream, the channel will be closed, thus
 // the received value will be nil.
+// Only one message is read, so that the stream can be read by others afterwards.
 func nextStreamMessage(stream libp2pnetwork.Stream, maxMessageSize uint32) <-chan common.Message {
-	ch := make(chan common.Message)
+	ch := make(chan common.Message, 1)
 	go func() {
-		for {
-			msg, err := readStreamMessage(stream, maxMessageSize)
-			if err != nil {
-				if !errors.Is(err, io.EOF) {
-					log.Warnf("failed to read stream message: %s", err)
-				}
-				close(ch)
-				return
+		msg, err := readStreamMessage(stream, maxMessageSize)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Warnf("failed to read stream message: %s", err)
 			}
-
-			ch <- msg
+			close(ch)
+			return
 		}
+
+		ch <- msg
 	}()
 
 	return ch
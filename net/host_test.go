@@ -20,7 +20,6 @@ import (
 
 func init() {
 	logging.SetLogLevel("net", "debug")
-	logging.SetLogLevel("p2pnet", "debug")
 }
 
 var (
@@ -31,6 +30,8 @@ var (
 type mockMakerHandler struct {
 	t  *testing.T
 	id types.Hash
+	// swapState, if set, is returned for initiated swaps
+	swapState SwapState
 }
 
 func (*mockMakerHandler) GetOffers() []*types.Offer {
@@ -38,6 +39,9 @@ func (*mockMakerHandler) GetOffers() []*types.Offer {
 }
 
 func (h *mockMakerHandler) HandleInitiateMessage(_ peer.ID, _ *message.SendKeysMessage) (s SwapState, err error) {
+	if h.swapState != nil {
+		return h.swapState, nil
+	}
 	if (h.id != types.Hash{}) {
 		return &mockSwapState{h.id}, nil
 	}
@@ -96,7 +100,6 @@ func basicTestConfig(t *testing.T) *Config {
 	return &Config{
 		Ctx:       ctx,
 		Env:       common.Development,
-		DataDir:   tmpDir,
 		Port:      0, // OS randomized libp2p port
 		KeyFile:   path.Join(tmpDir, "node.key"),
 		Bootnodes: nil,
@@ -10,12 +10,12 @@ import (
 	"io"
 	"time"
 
-	p2pnet "github.com/athanorlabs/go-p2p-net"
 	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
 	"github.com/athanorlabs/atomic-swap/net/message"
 )
 
@@ -26,49 +26,116 @@ const (
 // Initiate attempts to initiate a swap with the given peer by sending a SendKeysMessage,
 // the first message of the swap protocol.
 func (h *Host) Initiate(who peer.AddrInfo, sendKeysMessage common.Message, s common.SwapStateNet) error {
-	h.swapMu.Lock()
-	defer h.swapMu.Unlock()
-
 	id := s.OfferID()
+
+	// The swap is reserved while we connect to the peer, so that the lock
+	// isn't held during network operations. The reservation has no stream, so
+	// the other swap methods treat it like a swap whose stream closed.
+	reserved := &swap{
+		swapState:    s,
+		version:      message.ProtocolVersion1,
+		streamClosed: true,
+		peer:         who.ID,
+		opener:       true,
+		resumed:      make(chan struct{}),
+	}
+	h.swapMu.Lock()
 	if h.swaps[id] != nil {
+		h.swapMu.Unlock()
 		return errSwapAlreadyInProgress
 	}
+	h.swaps[id] = reserved
+	h.swapMu.Unlock()
+
+	stream, p, err := h.openSwapStream(who, sendKeysMessage)
+
+	h.swapMu.Lock()
+	defer h.swapMu.Unlock()
+
+	if h.swaps[id] != reserved {
+		// the swap exited in the meantime
+		if err == nil {
+			_ = stream.Reset()
+			err = errNoOngoingSwap
+		}
+		return err
+	}
 
+	if err != nil {
+		delete(h.swaps, id)
+		return err
+	}
+
+	h.swaps[id] = newSwap(s, stream, p, true)
+	h.swaps[id].messageSent(sendKeysMessage)
+
+	go h.receiveInitiateResponse(stream, s)
+	return nil
+}
+
+// openSwapStream connects and handshakes with the peer, and then opens a swap
+// stream with the initial SendKeysMessage. It returns the stream and the
+// negotiated protocol.
+func (h *Host) openSwapStream(who peer.AddrInfo, sendKeysMessage common.Message) (
+	libp2pnetwork.Stream,
+	peerProtocol,
+	error,
+) {
 	ctx, cancel := context.WithTimeout(h.ctx, connectionTimeout)
 	defer cancel()
 
 	if h.h.Connectedness(who.ID) != libp2pnetwork.Connected {
 		err := h.h.Connect(ctx, who)
 		if err != nil {
-			return err
+			return nil, peerProtocol{}, err
+		}
+	}
+
+	// the swap state picks the message formats of the negotiated version
+	_, p, err := h.handshake(who.ID)
+	switch {
+	case errors.Is(err, message.ErrNoCommonProtocolVersion):
+		return nil, peerProtocol{}, err
+	case err != nil:
+		log.Debugf("handshake with peer %s failed, using protocol version %d: %s",
+			who.ID, message.ProtocolVersion1, err)
+		p = protocolV1
+	default:
+		// the maker negotiates the swap's protocol with our capabilities
+		if m, ok := sendKeysMessage.(*message.SendKeysMessage); ok {
+			withCaps := *m
+			withCaps.Capabilities = h.Capabilities()
+			sendKeysMessage = &withCaps
 		}
 	}
 
 	stream, err := h.h.NewStream(ctx, who.ID, protocol.ID(swapID))
 	if err != nil {
-		return fmt.Errorf("failed to open stream with peer: err=%w", err)
+		return nil, peerProtocol{}, fmt.Errorf("failed to open stream with peer: err=%w", err)
 	}
 
 	log.Debug(
 		"opened protocol stream, peer=", who.ID,
 	)
 
-	if err := p2pnet.WriteStreamMessage(stream, sendKeysMessage, who.ID); err != nil {
+	// the maker replies in the encoding of our first message
+	if err := writeStreamMessage(stream, sendKeysMessage, p.encoding, who.ID); err != nil {
 		log.Warnf("failed to send initial SendKeysMessage to peer: err=%s", err)
-		return err
-	}
-
-	h.swaps[id] = &swap{
-		swapState: s,
-		stream:    stream,
+		_ = stream.Reset()
+		return nil, peerProtocol{}, err
 	}
 
-	go h.receiveInitiateResponse(stream, s)
-	return nil
+	return stream, p, nil
 }
 
 func (h *Host) receiveInitiateResponse(stream libp2pnetwork.Stream, s SwapState) {
-	defer h.handleProtocolStreamClose(stream, s)
+	// once the keys are exchanged, handleProtocolStreamInner closes the stream
+	keysExchanged := false
+	defer func() {
+		if !keysExchanged {
+			h.handleProtocolStreamClose(stream, s)
+		}
+	}()
 
 	const initiateResponseTimeout = time.Minute
 
@@ -78,10 +145,16 @@ func (h *Host) receiveInitiateResponse(stream libp2pnetwork.Stream, s SwapState)
 			log.Errorf("failed to read initial SendKeysMessage response")
 			return
 		}
+		h.messageReceived(s.OfferID())
 
 		log.Debugf("received protocol=%s message from peer=%s type=%s",
 			stream.Protocol(), stream.Conn().RemotePeer(), message.TypeToString(msg.Type()))
 
+		if err := verifySwapMessage(msg, stream.Conn().RemotePeer()); err != nil {
+			log.Warnf("failed to verify protocol message: %s", err)
+			return
+		}
+
 		err := s.HandleProtocolMessage(msg)
 		if err != nil {
 			log.Errorf("failed to handle protocol message: %s", err)
@@ -92,6 +165,7 @@ func (h *Host) receiveInitiateResponse(stream libp2pnetwork.Stream, s SwapState)
 		return
 	}
 
+	keysExchanged = true
 	h.handleProtocolStreamInner(stream, s)
 }
 
@@ -102,7 +176,7 @@ func (h *Host) handleProtocolStream(stream libp2pnetwork.Stream) {
 		return
 	}
 
-	msg, err := readStreamMessage(stream, maxMessageSize)
+	msg, enc, err := readStreamMessageAndEncoding(stream, maxMessageSize)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			log.Debugf("Peer closed stream-id=%s, protocol exited", stream.ID())
@@ -116,13 +190,29 @@ func (h *Host) handleProtocolStream(stream libp2pnetwork.Stream) {
 	curPeer := stream.Conn().RemotePeer()
 	log.Debugf("received message from peer=%s type=%s", curPeer, message.TypeToString(msg.Type()))
 
-	im, ok := msg.(*SendKeysMessage)
-	if !ok {
+	var im *SendKeysMessage
+	switch m := msg.(type) {
+	case *SendKeysMessage:
+		im = m
+	case *message.ResumeSwap:
+		h.handleResumeStream(stream, m)
+		return
+	default:
 		log.Warnf("failed to handle protocol message: message was not SendKeysMessage")
 		_ = stream.Close()
 		return
 	}
 
+	// the swap uses the protocol negotiated with the capabilities that the
+	// taker sent, and we reply in the encoding of its first message
+	p, err := negotiateProtocol(h.Capabilities(), im.Capabilities)
+	if err != nil {
+		log.Warnf("ignoring initiation from peer %s for swap %s: %s", curPeer, im.OfferID, err)
+		_ = stream.Close()
+		return
+	}
+	p.encoding = enc
+
 	h.swapMu.Lock()
 	if h.swaps[im.OfferID] != nil {
 		log.Warnf("ignoring initiation from peer %s for swap %s: %s", curPeer, im.OfferID, errOfferUnavailable)
@@ -134,9 +224,8 @@ func (h *Host) handleProtocolStream(stream libp2pnetwork.Stream) {
 	// set the stream here but not the swapState, since we don't have it yet
 	// HandleInitiateMessage requires the network to be aware of the swap's stream,
 	// since it sends the SendKeysMessage response using that stream.
-	h.swaps[im.OfferID] = &swap{
-		stream: stream,
-	}
+	h.swaps[im.OfferID] = newSwap(nil, stream, p, false)
+	h.swaps[im.OfferID].messageReceived()
 	h.swapMu.Unlock()
 
 	s, err := h.makerHandler.HandleInitiateMessage(curPeer, im)
@@ -159,23 +248,42 @@ func (h *Host) handleProtocolStream(stream libp2pnetwork.Stream) {
 
 // handleProtocolStreamInner is called to handle a protocol stream, in both ingoing and outgoing cases.
 func (h *Host) handleProtocolStreamInner(stream libp2pnetwork.Stream, s SwapState) {
-	defer h.handleProtocolStreamClose(stream, s)
+	// if the swap resumes on a new stream, the new stream is handled instead
+	resumed := false
+	defer func() {
+		if !resumed {
+			h.handleProtocolStreamClose(stream, s)
+		}
+	}()
 
 	for {
 		msg, err := readStreamMessage(stream, maxMessageSize)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
+			switch {
+			case errors.Is(err, io.EOF):
 				log.Debug("Peer closed stream with us, protocol exited")
-			} else {
-				log.Debugf("Failed to read message from peer, id=%s protocol=%s: %s",
+			case isTransportError(err):
+				log.Debugf("Stream with peer dropped, id=%s protocol=%s: %s",
+					stream.ID(), stream.Protocol(), err)
+				resumed = h.resumeSwap(stream, s)
+			default:
+				// the peer sent a message that is too large or that we can't
+				// decode, which it would only send again on a resumed stream
+				log.Warnf("Failed to read message from peer, id=%s protocol=%s: %s",
 					stream.ID(), stream.Protocol(), err)
 			}
 			return
 		}
+		h.messageReceived(s.OfferID())
 
 		log.Debugf("received protocol=%s message from peer=%s type=%s",
 			stream.Protocol(), stream.Conn().RemotePeer(), message.TypeToString(msg.Type()))
 
+		if err = verifySwapMessage(msg, stream.Conn().RemotePeer()); err != nil {
+			log.Warnf("failed to verify protocol message: %s", err)
+			return
+		}
+
 		err = s.HandleProtocolMessage(msg)
 		if err != nil {
 			log.Warnf("failed to handle protocol message: %s", err)
@@ -188,6 +296,41 @@ func (h *Host) handleProtocolStreamClose(stream libp2pnetwork.Stream, s SwapStat
 	log.Debugf("closing stream: peer=%s protocol=%s", stream.Conn().RemotePeer(), stream.Protocol())
 	_ = stream.Close()
 
+	h.swapMu.RLock()
+	swap, has := h.swaps[s.OfferID()]
+	resumed := has && swap.stream != stream
+	h.swapMu.RUnlock()
+	if resumed {
+		// the swap continues on the resumed stream
+		return
+	}
+
 	// notify swap state that the stream has closed
 	s.NotifyStreamClosed()
 }
+
+// messageReceived records a swap message that we received from the peer of the
+// swap.
+func (h *Host) messageReceived(id types.Hash) {
+	h.swapMu.RLock()
+	defer h.swapMu.RUnlock()
+	if swap, has := h.swaps[id]; has {
+		swap.messageReceived()
+	}
+}
+
+// verifySwapMessage checks that abort messages received on a swap stream were
+// signed by the remote peer of the stream. Other swap messages are checked by
+// the swap state.
+func verifySwapMessage(msg Message, who peer.ID) error {
+	abort, ok := msg.(*message.AbortSwap)
+	if !ok {
+		return nil
+	}
+
+	if abort.Sender != who {
+		return fmt.Errorf("abort of peer %s received from peer %s", abort.Sender, who)
+	}
+
+	return abort.Verify()
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
 
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common/types"
@@ -48,12 +49,76 @@ func TestHost_Initiate(t *testing.T) {
 	require.NoError(t, err)
 	time.Sleep(time.Millisecond * 500)
 
+	// the hosts negotiated CBOR in the handshake of Initiate
 	ha.swapMu.RLock()
 	require.NotNil(t, ha.swaps[testID])
+	require.Equal(t, message.CBOREncoding, ha.swaps[testID].encoding)
+	require.True(t, ha.swaps[testID].resume)
 	ha.swapMu.RUnlock()
 
 	hb.swapMu.RLock()
 	require.NotNil(t, hb.swaps[testID])
+	require.Equal(t, message.CBOREncoding, hb.swaps[testID].encoding)
+	require.True(t, hb.swaps[testID].resume)
+	hb.swapMu.RUnlock()
+
+	// the maker negotiated the version with the capabilities in the
+	// SendKeysMessage
+	require.Equal(t, message.ProtocolVersion2, ha.ProtocolVersion(testID))
+	require.Equal(t, message.ProtocolVersion2, hb.ProtocolVersion(testID))
+	require.Equal(t, message.ProtocolVersion1, hb.ProtocolVersion(types.Hash{98}))
+}
+
+func TestHost_Initiate_reserved(t *testing.T) {
+	h := newHost(t, basicTestConfig(t))
+
+	// a swap that Initiate reserved while it opens the stream
+	reserved := &swap{
+		swapState:    new(mockSwapState),
+		version:      message.ProtocolVersion1,
+		streamClosed: true,
+		resumed:      make(chan struct{}),
+	}
+	h.swapMu.Lock()
+	h.swaps[testID] = reserved
+	h.swapMu.Unlock()
+
+	err := h.Initiate(h.h.AddrInfo(), createSendKeysMessage(t), new(mockSwapState))
+	require.ErrorIs(t, err, errSwapAlreadyInProgress)
+
+	err = h.SendSwapMessage(&message.ResumeSwap{OfferID: testID}, testID)
+	require.ErrorIs(t, err, errNoOngoingSwap)
+	require.Equal(t, message.ProtocolVersion1, h.ProtocolVersion(testID))
+
+	// the swap can exit before its stream opened
+	h.CloseProtocolStream(testID)
+	h.DeleteOngoingSwap(testID)
+	h.swapMu.RLock()
+	require.Nil(t, h.swaps[testID])
+	h.swapMu.RUnlock()
+}
+
+func TestHost_Initiate_noCapabilities(t *testing.T) {
+	ha := newHost(t, basicTestConfig(t))
+	require.NoError(t, ha.Start())
+	hb := newHost(t, basicTestConfig(t))
+	require.NoError(t, hb.Start())
+	require.NoError(t, ha.h.Connect(ha.ctx, hb.h.AddrInfo()))
+
+	// takers that didn't handshake don't send their capabilities
+	stream, err := ha.h.NewStream(ha.ctx, hb.PeerID(), swapID)
+	require.NoError(t, err)
+	defer func() { _ = stream.Close() }()
+	require.NoError(t, writeStreamMessage(stream, createSendKeysMessage(t), message.JSONEncoding, hb.PeerID()))
+
+	require.Eventually(t, func() bool {
+		hb.swapMu.RLock()
+		defer hb.swapMu.RUnlock()
+		return hb.swaps[testID] != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, message.ProtocolVersion1, hb.ProtocolVersion(testID))
+	hb.swapMu.RLock()
+	require.False(t, hb.swaps[testID].resume)
 	hb.swapMu.RUnlock()
 }
 
@@ -99,3 +164,181 @@ func TestHost_ConcurrentSwaps(t *testing.T) {
 	require.NotNil(t, hb.swaps[testID])
 	hb.swapMu.RUnlock()
 }
+
+// abortSwapState is a swap state that records the aborts that it receives
+type abortSwapState struct {
+	mockSwapState
+	aborts chan *message.AbortSwap
+}
+
+func (s *abortSwapState) HandleProtocolMessage(msg Message) error {
+	if abort, ok := msg.(*message.AbortSwap); ok {
+		s.aborts <- abort
+	}
+	return nil
+}
+
+func TestHost_AbortSwap(t *testing.T) {
+	ha := newHost(t, basicTestConfig(t))
+	err := ha.Start()
+	require.NoError(t, err)
+	hb := newHost(t, basicTestConfig(t))
+	err = hb.Start()
+	require.NoError(t, err)
+
+	s := &abortSwapState{aborts: make(chan *message.AbortSwap, 1)}
+	err = ha.Initiate(hb.h.AddrInfo(), createSendKeysMessage(t), s)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 500)
+
+	// the maker aborts instead of replying with its keys
+	err = hb.SendSwapMessage(&message.AbortSwap{
+		OfferID: testID,
+		Reason:  message.AbortError,
+		Status:  types.KeysExchanged,
+	}, testID)
+	require.NoError(t, err)
+
+	select {
+	case abort := <-s.aborts:
+		require.Equal(t, hb.PeerID(), abort.Sender)
+		require.Equal(t, message.AbortError, abort.Reason)
+		require.Equal(t, types.KeysExchanged, abort.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("abort was not received")
+	}
+}
+
+// recordingSwapState is a swap state that records the messages that it
+// receives and whether its stream closed
+type recordingSwapState struct {
+	mockSwapState
+	msgs   chan Message
+	closed chan struct{}
+}
+
+func newRecordingSwapState() *recordingSwapState {
+	return &recordingSwapState{
+		msgs:   make(chan Message, 4),
+		closed: make(chan struct{}, 1),
+	}
+}
+
+func (s *recordingSwapState) HandleProtocolMessage(msg Message) error {
+	s.msgs <- msg
+	return nil
+}
+
+func (s *recordingSwapState) NotifyStreamClosed() {
+	select {
+	case s.closed <- struct{}{}:
+	default:
+	}
+}
+
+// initiateRecordedSwap initiates a swap between a taker ha and a maker hb
+// whose swap states record their messages, and exchanges the keys.
+func initiateRecordedSwap(t *testing.T) (*Host, *recordingSwapState, *Host, *recordingSwapState) {
+	ha := newHost(t, basicTestConfig(t))
+	require.NoError(t, ha.Start())
+	hb := newHost(t, basicTestConfig(t))
+	require.NoError(t, hb.Start())
+
+	taker, maker := newRecordingSwapState(), newRecordingSwapState()
+	hb.makerHandler.(*mockMakerHandler).swapState = maker
+
+	require.NoError(t, ha.Initiate(hb.h.AddrInfo(), createSendKeysMessage(t), taker))
+	require.Eventually(t, func() bool {
+		hb.swapMu.RLock()
+		defer hb.swapMu.RUnlock()
+		return hb.swaps[testID] != nil && hb.swaps[testID].swapState != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, hb.SendSwapMessage(createSendKeysMessage(t), testID))
+	require.IsType(t, &message.SendKeysMessage{}, requireMessage(t, taker.msgs))
+
+	return ha, taker, hb, maker
+}
+
+func requireMessage(t *testing.T, msgs <-chan Message) Message {
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+		return nil
+	}
+}
+
+// dropStream resets the stream of the swap, after recording the message as
+// sent without sending it, as if it was lost when the stream dropped.
+func dropStream(t *testing.T, h *Host, lost Message) libp2pnetwork.Stream {
+	h.swapMu.RLock()
+	defer h.swapMu.RUnlock()
+	swap := h.swaps[testID]
+	require.NotNil(t, swap)
+	swap.messageSent(lost)
+	require.NoError(t, swap.stream.Reset())
+	return swap.stream
+}
+
+func TestHost_ResumeSwap(t *testing.T) {
+	ha, taker, hb, maker := initiateRecordedSwap(t)
+
+	// the maker receives the lost message of the taker on the resumed stream
+	lost := &message.AbortSwap{OfferID: testID, Reason: message.AbortError, Status: types.ETHLocked}
+	require.NoError(t, lost.Sign(ha.ann.key))
+	dropped := dropStream(t, ha, lost)
+	require.Equal(t, lost, requireMessage(t, maker.msgs))
+
+	// both streams were replaced and the swaps continue on them
+	ha.swapMu.RLock()
+	require.NotEqual(t, dropped, ha.swaps[testID].stream)
+	require.Equal(t, uint32(2), ha.swaps[testID].sent)
+	ha.swapMu.RUnlock()
+
+	require.NoError(t, hb.SendSwapMessage(createSendKeysMessage(t), testID))
+	require.IsType(t, &message.SendKeysMessage{}, requireMessage(t, taker.msgs))
+	require.Empty(t, taker.closed)
+	require.Empty(t, maker.closed)
+
+	// the taker also reopens the stream when the maker noticed the drop first
+	dropStream(t, hb, createSendKeysMessage(t))
+	require.IsType(t, &message.SendKeysMessage{}, requireMessage(t, taker.msgs))
+	require.Empty(t, taker.closed)
+	require.Empty(t, maker.closed)
+}
+
+func TestHost_ResumeSwap_unknownSwap(t *testing.T) {
+	ha, taker, hb, _ := initiateRecordedSwap(t)
+
+	// the maker no longer has the swap, so it rejects resuming it
+	hb.swapMu.Lock()
+	delete(hb.swaps, testID)
+	hb.swapMu.Unlock()
+
+	dropStream(t, ha, createSendKeysMessage(t))
+	select {
+	case <-taker.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream close was not notified")
+	}
+}
+
+func TestVerifySwapMessage(t *testing.T) {
+	ha := newHost(t, basicTestConfig(t))
+	hb := newHost(t, basicTestConfig(t))
+
+	require.NoError(t, verifySwapMessage(createSendKeysMessage(t), hb.PeerID()))
+
+	abort := &message.AbortSwap{
+		OfferID: testID,
+		Reason:  message.AbortCancelled,
+		Status:  types.ExpectingKeys,
+	}
+	require.NoError(t, abort.Sign(hb.ann.key))
+	require.NoError(t, verifySwapMessage(abort, hb.PeerID()))
+
+	// a peer can't abort the swaps of other peers
+	err := verifySwapMessage(abort, ha.PeerID())
+	require.ErrorContains(t, err, "received from peer")
+}
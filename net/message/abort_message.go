@@ -0,0 +1,117 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+)
+
+// abortDomain prefixes the signed bytes of an AbortSwap, so that the signature
+// can't be used for anything else.
+const abortDomain = "atomic-swap abort"
+
+// AbortReason is the reason code of an AbortSwap message. Peers may send
+// reasons that we don't know yet, which we record as they are.
+type AbortReason string
+
+// The reasons for aborting a swap.
+const (
+	// AbortCancelled is sent when the user of the sender cancelled the swap.
+	AbortCancelled AbortReason = "cancelled"
+	// AbortError is sent when the sender exited the swap because of an error,
+	// like an invalid message or contract swap from the counterparty.
+	AbortError AbortReason = "error"
+	// AbortTimeout is sent when the sender exited the swap because one of the
+	// swap timeouts expired, like the taker refunding before timeout1.
+	AbortTimeout AbortReason = "timeout"
+)
+
+// AbortSwap is sent on the swap stream when a peer exits a swap before it
+// completed, so that the counterparty can refund or stop waiting right away
+// instead of when the stream closes. Status is the status of the swap for the
+// sender when it was sent. It is signed with the libp2p key of the sender.
+type AbortSwap struct {
+	OfferID   types.Hash   `json:"offerID" validate:"required"`
+	Reason    AbortReason  `json:"reason" validate:"required"`
+	Status    types.Status `json:"status" validate:"required"`
+	Sender    peer.ID      `json:"sender" validate:"required"`
+	Signature []byte       `json:"signature" validate:"required"`
+}
+
+// String converts the AbortSwap to a string usable for debugging purposes
+func (m *AbortSwap) String() string {
+	return fmt.Sprintf("AbortSwap OfferID=%s Reason=%s Status=%s Sender=%s",
+		m.OfferID,
+		m.Reason,
+		m.Status,
+		m.Sender,
+	)
+}
+
+// Encode implements the Encode() method of the common.Message interface which
+// prepends a message type byte before the message's JSON encoding.
+func (m *AbortSwap) Encode() ([]byte, error) {
+	b, err := vjson.MarshalStruct(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{AbortSwapType}, b...), nil
+}
+
+// Type implements the Type() method of the common.Message interface
+func (m *AbortSwap) Type() byte {
+	return AbortSwapType
+}
+
+// signedBytes returns the bytes that the sender signs. Variable length fields
+// are length prefixed.
+func (m *AbortSwap) signedBytes() []byte {
+	b := []byte(abortDomain)
+	b = append(b, m.OfferID[:]...)
+	for _, s := range []string{string(m.Reason), string(m.Sender)} {
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		b = append(b, s...)
+	}
+	return append(b, byte(m.Status))
+}
+
+// Sign sets the sender of the message to the peer ID of the key, and signs the
+// message.
+func (m *AbortSwap) Sign(key crypto.PrivKey) error {
+	sender, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	m.Sender = sender
+	m.Signature, err = key.Sign(m.signedBytes())
+	return err
+}
+
+// Verify checks that the message was signed by its sender.
+func (m *AbortSwap) Verify() error {
+	pubKey, err := m.Sender.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("failed to get the public key of sender %s: %w", m.Sender, err)
+	}
+
+	ok, err := pubKey.Verify(m.signedBytes(), m.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid abort signature")
+	}
+
+	return nil
+}
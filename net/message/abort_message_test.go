@@ -0,0 +1,47 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+)
+
+func TestAbortSwap_Verify(t *testing.T) {
+	g := newMessageGen(t, 0)
+	m := &AbortSwap{
+		OfferID: g.hash(),
+		Reason:  AbortCancelled,
+		Status:  types.ETHLocked,
+	}
+	require.NoError(t, m.Sign(g.key))
+	require.NoError(t, m.Verify())
+
+	data, err := m.Encode()
+	require.NoError(t, err)
+	decoded, err := DecodeMessage(data)
+	require.NoError(t, err)
+	require.NoError(t, decoded.(*AbortSwap).Verify())
+
+	// every signed field is covered by the signature
+	for _, change := range []func(m *AbortSwap){
+		func(m *AbortSwap) { m.OfferID[0]++ },
+		func(m *AbortSwap) { m.Reason = AbortError },
+		func(m *AbortSwap) { m.Status = types.CompletedRefund },
+	} {
+		changed := *m
+		change(&changed)
+		require.ErrorContains(t, changed.Verify(), "invalid abort signature")
+	}
+
+	// signed by a key that isn't the sender's
+	other := newMessageGen(t, 1)
+	forged := *m
+	require.NoError(t, forged.Sign(other.key))
+	forged.Sender = m.Sender
+	require.ErrorContains(t, forged.Verify(), "invalid abort signature")
+}
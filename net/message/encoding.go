@@ -0,0 +1,53 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"fmt"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/vcbor"
+)
+
+// Encoding is the format of a message after its type byte.
+type Encoding byte
+
+// The message encodings. Every peer decodes JSON, while CBOR is only sent to
+// peers that advertise FeatureCBOR.
+const (
+	JSONEncoding Encoding = iota
+	CBOREncoding
+)
+
+// cborTypeFlag is set in the type byte of CBOR encoded messages
+const cborTypeFlag byte = 0x80
+
+// String ...
+func (e Encoding) String() string {
+	switch e {
+	case JSONEncoding:
+		return "JSON"
+	case CBOREncoding:
+		return "CBOR"
+	default:
+		return fmt.Sprintf("Unknown(%d)", e)
+	}
+}
+
+// EncodeMessage encodes the message with the given encoding. The JSON encoding
+// is the same as the message's Encode method.
+func EncodeMessage(msg common.Message, enc Encoding) ([]byte, error) {
+	switch enc {
+	case JSONEncoding:
+		return msg.Encode()
+	case CBOREncoding:
+		b, err := vcbor.MarshalStruct(msg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{msg.Type() | cborTypeFlag}, b...), nil
+	default:
+		return nil, fmt.Errorf("invalid message encoding %s", enc)
+	}
+}
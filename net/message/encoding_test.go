@@ -0,0 +1,275 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
+	"github.com/athanorlabs/atomic-swap/crypto/secp256k1"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+)
+
+// messageGen generates random messages of every type
+type messageGen struct {
+	t   require.TestingT
+	r   *rand.Rand
+	key crypto.PrivKey
+}
+
+func newMessageGen(t require.TestingT, seed int64) *messageGen {
+	r := rand.New(rand.NewSource(seed))
+	key, _, err := crypto.GenerateEd25519Key(r)
+	require.NoError(t, err)
+	return &messageGen{t: t, r: r, key: key}
+}
+
+func (g *messageGen) bytes(n int) []byte {
+	b := make([]byte, n)
+	g.r.Read(b)
+	return b
+}
+
+func (g *messageGen) hash() types.Hash {
+	return types.Hash(g.bytes(32))
+}
+
+func (g *messageGen) address() ethcommon.Address {
+	return ethcommon.BytesToAddress(g.bytes(20))
+}
+
+// bigInt returns integers of up to 256 bits, so that both the integer and the
+// bignum encodings are used
+func (g *messageGen) bigInt() *big.Int {
+	return new(big.Int).SetBytes(g.bytes(g.r.Intn(33)))
+}
+
+func (g *messageGen) offer() *types.Offer {
+	asset := types.EthAssetETH
+	if g.r.Intn(2) == 0 {
+		asset = types.EthAsset(g.address())
+	}
+
+	minAmount := apd.New(g.r.Int63n(10)+1, 0)
+	maxAmount := apd.New(minAmount.Coeff.Int64()+g.r.Int63n(100), 0)
+	rate := coins.ToExchangeRate(apd.New(500+g.r.Int63n(1000), -4))
+	return types.NewOfferOnChain(uint64(g.r.Intn(2)), coins.ProvidesXMR, minAmount, maxAmount, rate, asset)
+}
+
+func (g *messageGen) capabilities() *Capabilities {
+	caps := &Capabilities{Versions: SupportedProtocolVersions}
+	for _, f := range []Feature{FeatureRelayer, FeatureCounterpartyRelay, FeatureCBOR, FeatureResume} {
+		if g.r.Intn(2) == 0 {
+			caps.Features = append(caps.Features, f)
+		}
+	}
+	return caps
+}
+
+func (g *messageGen) swap() contracts.SwapCreatorSwap {
+	return contracts.SwapCreatorSwap{
+		Owner:            g.address(),
+		Claimer:          g.address(),
+		ClaimCommitment:  g.hash(),
+		RefundCommitment: g.hash(),
+		Timeout1:         g.bigInt(),
+		Timeout2:         g.bigInt(),
+		Asset:            g.address(),
+		Value:            g.bigInt(),
+		Nonce:            g.bigInt(),
+	}
+}
+
+func (g *messageGen) messages() []common.Message {
+	t := g.t
+
+	var offers []*types.Offer
+	for i := g.r.Intn(4); i > 0; i-- {
+		offers = append(offers, g.offer())
+	}
+
+	var caps *Capabilities
+	if g.r.Intn(2) == 0 {
+		caps = g.capabilities()
+	}
+
+	kp, err := mcrypto.GenerateKeys()
+	require.NoError(t, err)
+
+	var offerID *types.Hash
+	if g.r.Intn(2) == 0 {
+		id := g.hash()
+		offerID = &id
+	}
+
+	announcement := &OfferAnnouncement{
+		Topic:   fmt.Sprintf("/atomic-swap/dev/2/offers/%d/ETH", g.r.Intn(10)),
+		Action:  OfferWithdrawn,
+		OfferID: g.hash(),
+		Seq:     g.r.Uint64() + 1,
+	}
+	if g.r.Intn(2) == 0 {
+		announcement.Action = OfferNew
+		announcement.Offer = g.offer()
+		announcement.OfferID = announcement.Offer.ID
+	}
+	require.NoError(t, announcement.Sign(g.key))
+
+	abort := &AbortSwap{
+		OfferID: g.hash(),
+		Reason:  []AbortReason{AbortCancelled, AbortError, AbortTimeout}[g.r.Intn(3)],
+		Status:  types.Status(g.r.Intn(int(types.CompletedAbort)) + 1),
+	}
+	require.NoError(t, abort.Sign(g.key))
+
+	return []common.Message{
+		&QueryResponse{Offers: offers, Capabilities: caps},
+		&RelayerQueryResponse{AddressHash: g.bytes(32)},
+		&RelayClaimRequest{
+			OfferID: offerID,
+			RelaySwap: &contracts.SwapCreatorRelaySwap{
+				Swap:        g.swap(),
+				Fee:         g.bigInt(),
+				RelayerHash: g.hash(),
+				SwapCreator: g.address(),
+			},
+			Secret:    g.bytes(32),
+			Signature: g.bytes(65),
+		},
+		&RelayClaimResponse{TxHash: g.hash()},
+		&SendKeysMessage{
+			OfferID:            g.hash(),
+			ProvidedAmount:     apd.New(g.r.Int63(), -int32(g.r.Intn(19))),
+			PublicSpendKey:     kp.PublicKeyPair().SpendKey(),
+			PrivateViewKey:     kp.ViewKey(),
+			DLEqProof:          g.bytes(g.r.Intn(512) + 1),
+			Secp256k1PublicKey: secp256k1.NewPublicKey(g.hash(), g.hash()),
+			EthAddress:         g.address(),
+			Capabilities:       g.capabilities(),
+		},
+		&NotifyETHLocked{
+			Address:        g.address(),
+			TxHash:         g.hash(),
+			ContractSwapID: g.hash(),
+			ContractSwap: func() *contracts.SwapCreatorSwap {
+				s := g.swap()
+				return &s
+			}(),
+		},
+		announcement,
+		&Handshake{Capabilities: g.capabilities()},
+		abort,
+		&ResumeSwap{OfferID: g.hash(), Received: g.r.Uint32()},
+	}
+}
+
+// requireEquivalent checks that the message decodes to the same message from
+// both its JSON and CBOR encodings, and that each decoded message has the same
+// encodings as the original.
+func requireEquivalent(t *testing.T, msg common.Message) {
+	jsonData, err := EncodeMessage(msg, JSONEncoding)
+	require.NoError(t, err)
+	cborData, err := EncodeMessage(msg, CBOREncoding)
+	require.NoError(t, err)
+	require.Equal(t, msg.Type()|cborTypeFlag, cborData[0])
+
+	fromJSON, enc, err := DecodeMessageAndEncoding(jsonData)
+	require.NoError(t, err)
+	require.Equal(t, JSONEncoding, enc)
+
+	fromCBOR, enc, err := DecodeMessageAndEncoding(cborData)
+	require.NoError(t, err)
+	require.Equal(t, CBOREncoding, enc)
+	require.Equal(t, msg.Type(), fromCBOR.Type())
+
+	for _, decoded := range []common.Message{fromJSON, fromCBOR} {
+		data, err := EncodeMessage(decoded, JSONEncoding)
+		require.NoError(t, err)
+		require.Equal(t, string(jsonData), string(data), TypeToString(msg.Type()))
+
+		data, err = EncodeMessage(decoded, CBOREncoding)
+		require.NoError(t, err)
+		require.Equal(t, cborData, data, TypeToString(msg.Type()))
+	}
+}
+
+func TestEncodeMessage_roundTrip(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		for _, msg := range newMessageGen(t, seed).messages() {
+			requireEquivalent(t, msg)
+		}
+	}
+}
+
+func TestEncodeMessage_smallerThanJSON(t *testing.T) {
+	for _, msg := range newMessageGen(t, 1).messages() {
+		jsonData, err := EncodeMessage(msg, JSONEncoding)
+		require.NoError(t, err)
+		cborData, err := EncodeMessage(msg, CBOREncoding)
+		require.NoError(t, err)
+		require.Less(t, len(cborData), len(jsonData), TypeToString(msg.Type()))
+
+		// the keys and proof of the maker and taker are encoded as bytes,
+		// while hashes and addresses keep their hex text
+		if msg.Type() == SendKeysType {
+			require.Less(t, len(cborData)*4, len(jsonData)*3, TypeToString(msg.Type()))
+		}
+	}
+}
+
+func TestEncodeMessage_invalid(t *testing.T) {
+	_, err := EncodeMessage(&Handshake{}, CBOREncoding)
+	require.ErrorContains(t, err, "'Handshake.Capabilities' Error:Field validation for 'Capabilities' failed")
+
+	_, err = EncodeMessage(&Handshake{Capabilities: &Capabilities{Versions: []uint16{1}}}, Encoding(2))
+	require.ErrorContains(t, err, "invalid message encoding Unknown(2)")
+}
+
+func TestDecodeMessage_invalidCBOR(t *testing.T) {
+	// a CBOR handshake without capabilities
+	_, err := DecodeMessage([]byte{HandshakeType | cborTypeFlag, 0xa0})
+	require.ErrorContains(t, err, "failed to decode CBOR Handshake message")
+
+	_, err = DecodeMessage([]byte{HandshakeType | cborTypeFlag})
+	require.ErrorContains(t, err, "invalid message bytes")
+
+	_, err = DecodeMessage([]byte{0x7f | cborTypeFlag, 0xa0})
+	require.ErrorContains(t, err, "invalid message type=127")
+}
+
+// FuzzDecodeMessage checks that any valid message that decodes has stable
+// encodings, so that the decoded message is equivalent in JSON and CBOR.
+func FuzzDecodeMessage(f *testing.F) {
+	for _, msg := range newMessageGen(f, 0).messages() {
+		for _, enc := range []Encoding{JSONEncoding, CBOREncoding} {
+			data, err := EncodeMessage(msg, enc)
+			require.NoError(f, err)
+			f.Add(data)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg, err := DecodeMessage(data)
+		if err != nil {
+			return
+		}
+		// some messages decode but can't be sent, like relay claim requests
+		// without the swap of the relay swap
+		if _, err = msg.Encode(); err != nil {
+			return
+		}
+		requireEquivalent(t, msg)
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+)
+
+// Versions of the swap protocol messages. Peers use the highest version that
+// both of them support, which they agree on with a Handshake.
+const (
+	// ProtocolVersion1 has the original message formats. It is used with peers
+	// that don't support the handshake.
+	ProtocolVersion1 uint16 = 1
+	// ProtocolVersion2 leaves the contract swap ID out of NotifyETHLocked, as
+	// the maker derives it from the contract swap. The nonce of the contract
+	// swap is the offer ID, so both peers can recover their swap secrets from
+	// their swap key seed.
+	ProtocolVersion2 uint16 = 2
+)
+
+// SupportedProtocolVersions are the protocol versions that this version of
+// swapd speaks.
+var SupportedProtocolVersions = []uint16{ProtocolVersion1, ProtocolVersion2}
+
+// ErrNoCommonProtocolVersion is returned by Negotiate when two peers don't
+// support a common protocol version.
+var ErrNoCommonProtocolVersion = errors.New("no common protocol version")
+
+// Feature is an optional capability of a peer.
+type Feature string
+
+// The features that peers advertise.
+const (
+	// FeatureRelayer is advertised by peers that relay the claims of any
+	// maker, see the is-relayer flag of swapd.
+	FeatureRelayer Feature = "relayer"
+	// FeatureCounterpartyRelay is advertised by takers that relay the claim
+	// of their swap's maker when no other relayer does.
+	FeatureCounterpartyRelay Feature = "counterparty-relay"
+	// FeatureCBOR is advertised by peers that decode CBOR encoded messages.
+	// Peers only open swap streams in CBOR with peers that advertise it.
+	FeatureCBOR Feature = "cbor"
+	// FeatureResume is advertised by peers that resume swaps on a new stream
+	// when the swap stream drops, see ResumeSwap.
+	FeatureResume Feature = "resume"
+)
+
+// Capabilities are the protocol versions and features of a peer.
+type Capabilities struct {
+	Versions []uint16  `json:"versions" validate:"required,max=64,dive,required"`
+	Features []Feature `json:"features,omitempty" validate:"max=64"`
+}
+
+// String ...
+func (c *Capabilities) String() string {
+	return fmt.Sprintf("Versions=%v Features=%v", c.Versions, c.Features)
+}
+
+// HasFeature returns whether the peer advertised the feature. Peers without
+// capabilities have no features.
+func (c *Capabilities) HasFeature(f Feature) bool {
+	if c == nil {
+		return false
+	}
+	for _, feature := range c.Features {
+		if feature == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns the highest protocol version of both local and remote.
+// A nil remote is a peer without capabilities, which only speaks
+// ProtocolVersion1.
+func Negotiate(local *Capabilities, remote *Capabilities) (uint16, error) {
+	remoteVersions := []uint16{ProtocolVersion1}
+	if remote != nil {
+		remoteVersions = remote.Versions
+	}
+
+	var version uint16
+	for _, v := range local.Versions {
+		for _, rv := range remoteVersions {
+			if v == rv && v > version {
+				version = v
+			}
+		}
+	}
+
+	if version == 0 {
+		return 0, fmt.Errorf("%w: ours are %v, the peer's are %v",
+			ErrNoCommonProtocolVersion, local.Versions, remoteVersions)
+	}
+
+	return version, nil
+}
+
+// Handshake is exchanged by peers before a swap, so that both sides know the
+// protocol version and features of the other.
+type Handshake struct {
+	Capabilities *Capabilities `json:"capabilities" validate:"required"`
+}
+
+// String converts the Handshake to a string usable for debugging purposes
+func (m *Handshake) String() string {
+	return fmt.Sprintf("Handshake %s", m.Capabilities)
+}
+
+// Encode implements the Encode() method of the common.Message interface which
+// prepends a message type byte before the message's JSON encoding.
+func (m *Handshake) Encode() ([]byte, error) {
+	b, err := vjson.MarshalStruct(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{HandshakeType}, b...), nil
+}
+
+// Type implements the Type() method of the common.Message interface
+func (m *Handshake) Type() byte {
+	return HandshakeType
+}
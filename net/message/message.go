@@ -13,6 +13,7 @@ import (
 
 	"github.com/athanorlabs/atomic-swap/common"
 	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vcbor"
 	"github.com/athanorlabs/atomic-swap/common/vjson"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/crypto/secp256k1"
@@ -20,7 +21,8 @@ import (
 )
 
 // Identifiers for our p2p message types. The first byte of a message has the
-// identifier below telling us which type to decode the JSON message as.
+// identifier below telling us which type to decode the message as, with the
+// high bit set for CBOR instead of JSON messages.
 const (
 	Unknown byte = iota // occupies the uninitialized value
 	QueryResponseType
@@ -29,8 +31,15 @@ const (
 	RelayClaimResponseType
 	SendKeysType
 	NotifyETHLockedType
+	OfferAnnouncementType
+	HandshakeType
+	AbortSwapType
+	ResumeSwapType
 )
 
+// redacted replaces key material in the String() output of messages
+const redacted = "[REDACTED]"
+
 // TypeToString converts a message type into a string.
 func TypeToString(t byte) string {
 	switch t {
@@ -46,6 +55,14 @@ func TypeToString(t byte) string {
 		return "RelayClaimRequestType"
 	case RelayClaimResponseType:
 		return "RelayClaimResponse"
+	case OfferAnnouncementType:
+		return "OfferAnnouncement"
+	case HandshakeType:
+		return "Handshake"
+	case AbortSwapType:
+		return "AbortSwap"
+	case ResumeSwapType:
+		return "ResumeSwap"
 	default:
 		return fmt.Sprintf("Unknown(%d)", t)
 	}
@@ -53,13 +70,27 @@ func TypeToString(t byte) string {
 
 // DecodeMessage decodes the given bytes into a Message
 func DecodeMessage(b []byte) (common.Message, error) {
-	// 1-byte type followed by at least 2-bytes of JSON (`{}`)
-	if len(b) < 3 {
-		return nil, errors.New("invalid message bytes")
+	msg, _, err := DecodeMessageAndEncoding(b)
+	return msg, err
+}
+
+// DecodeMessageAndEncoding decodes the given bytes into a Message and also
+// returns the encoding of the message, so that replies can use the same one.
+func DecodeMessageAndEncoding(b []byte) (common.Message, Encoding, error) {
+	// 1-byte type followed by at least 2-bytes of JSON (`{}`) or 1 byte of
+	// CBOR (an empty map)
+	if len(b) < 2 {
+		return nil, 0, errors.New("invalid message bytes")
+	}
+
+	msgType := b[0] &^ cborTypeFlag
+	enc := JSONEncoding
+	if b[0]&cborTypeFlag != 0 {
+		enc = CBOREncoding
+	} else if len(b) < 3 {
+		return nil, 0, errors.New("invalid message bytes")
 	}
 
-	msgType := b[0]
-	msgJSON := b[1:]
 	var msg common.Message
 
 	switch msgType {
@@ -75,26 +106,43 @@ func DecodeMessage(b []byte) (common.Message, error) {
 		msg = new(SendKeysMessage)
 	case NotifyETHLockedType:
 		msg = new(NotifyETHLocked)
+	case OfferAnnouncementType:
+		msg = new(OfferAnnouncement)
+	case HandshakeType:
+		msg = new(Handshake)
+	case AbortSwapType:
+		msg = new(AbortSwap)
+	case ResumeSwapType:
+		msg = new(ResumeSwap)
 	default:
-		return nil, fmt.Errorf("invalid message type=%d", msgType)
+		return nil, 0, fmt.Errorf("invalid message type=%d", msgType)
 	}
 
-	if err := vjson.UnmarshalStruct(msgJSON, msg); err != nil {
-		return nil, fmt.Errorf("failed to decode %s message: %w", TypeToString(msg.Type()), err)
+	var err error
+	if enc == CBOREncoding {
+		err = vcbor.UnmarshalStruct(b[1:], msg)
+	} else {
+		err = vjson.UnmarshalStruct(b[1:], msg)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode %s %s message: %w", enc, TypeToString(msg.Type()), err)
 	}
 
-	return msg, nil
+	return msg, enc, nil
 }
 
 // QueryResponse ...
 type QueryResponse struct {
 	Offers []*types.Offer `json:"offers" validate:"dive,required"`
+	// Capabilities are not sent by peers that don't support the handshake.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
 }
 
 // String ...
 func (m *QueryResponse) String() string {
-	return fmt.Sprintf("QueryResponse Offers=%v",
+	return fmt.Sprintf("QueryResponse Offers=%v Capabilities=%v",
 		m.Offers,
+		m.Capabilities,
 	)
 }
 
@@ -126,15 +174,19 @@ type SendKeysMessage struct {
 	DLEqProof          []byte                  `json:"dleqProof" validate:"required"`
 	Secp256k1PublicKey *secp256k1.PublicKey    `json:"secp256k1PublicKey" validate:"required"`
 	EthAddress         ethcommon.Address       `json:"ethAddress"` // not set by XMR Taker
+	// Capabilities of the XMR taker, which the maker negotiates the protocol
+	// of the swap with. Only set by takers that handshook with the maker.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
 }
 
-// String ...
+// String converts the SendKeysMessage to a string usable for debugging
+// purposes. The private view key is redacted.
 func (m *SendKeysMessage) String() string {
 	return fmt.Sprintf("SendKeysMessage OfferID=%s ProvidedAmount=%v PublicSpendKey=%s PrivateViewKey=%s DLEqProof=%s Secp256k1PublicKey=%s EthAddress=%s", //nolint:lll
 		m.OfferID,
 		m.ProvidedAmount,
 		m.PublicSpendKey,
-		m.PrivateViewKey,
+		redacted,
 		m.DLEqProof,
 		m.Secp256k1PublicKey,
 		m.EthAddress,
@@ -160,9 +212,11 @@ func (m *SendKeysMessage) Type() byte {
 // NotifyETHLocked is sent by XMRTaker to XMRMaker after deploying the swap contract
 // and locking her ether in it
 type NotifyETHLocked struct {
-	Address        ethcommon.Address          `json:"address" validate:"required"`
-	TxHash         types.Hash                 `json:"txHash" validate:"required"`
-	ContractSwapID types.Hash                 `json:"contractSwapID" validate:"required"`
+	Address ethcommon.Address `json:"address" validate:"required"`
+	TxHash  types.Hash        `json:"txHash" validate:"required"`
+	// ContractSwapID is zero from ProtocolVersion2 on, as the maker derives it
+	// from ContractSwap. Makers of ProtocolVersion1 require it.
+	ContractSwapID types.Hash                 `json:"contractSwapID"`
 	ContractSwap   *contracts.SwapCreatorSwap `json:"contractSwap" validate:"required"`
 }
 
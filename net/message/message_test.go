@@ -0,0 +1,30 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageString_redactsKeys(t *testing.T) {
+	g := newMessageGen(t, 0)
+	for _, msg := range g.messages() {
+		var secret string
+		switch m := msg.(type) {
+		case *RelayClaimRequest:
+			secret = fmt.Sprintf("%x", m.Secret)
+		case *SendKeysMessage:
+			secret = m.PrivateViewKey.Hex()
+		default:
+			continue
+		}
+
+		str := msg.String()
+		require.NotContains(t, str, secret)
+		require.Contains(t, str, redacted)
+	}
+}
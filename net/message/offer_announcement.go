@@ -0,0 +1,130 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+)
+
+// announcementDomain prefixes the signed bytes of an OfferAnnouncement, so
+// that the signature can't be used for anything else.
+const announcementDomain = "atomic-swap offer announcement"
+
+// OfferAction is the change to an offer that an OfferAnnouncement announces.
+type OfferAction string
+
+// The actions of offer announcements.
+const (
+	// OfferNew announces an offer that the maker just made.
+	OfferNew OfferAction = "new"
+	// OfferUpdated announces that an offer is still available. Makers
+	// re-announce their offers periodically, so that takers can evict the
+	// offers of makers that went offline.
+	OfferUpdated OfferAction = "updated"
+	// OfferWithdrawn announces that an offer was taken or cleared.
+	OfferWithdrawn OfferAction = "withdrawn"
+)
+
+// OfferAnnouncement is published by makers on the offer topic of an offer's
+// asset. It is signed with the libp2p key of the maker, so peers forwarding it
+// can't alter it.
+type OfferAnnouncement struct {
+	Topic   string      `json:"topic" validate:"required"`
+	Action  OfferAction `json:"action" validate:"required,oneof=new updated withdrawn"`
+	Maker   peer.ID     `json:"maker" validate:"required"`
+	OfferID types.Hash  `json:"offerID" validate:"required"`
+	// Offer is nil in withdrawn announcements
+	Offer *types.Offer `json:"offer,omitempty"`
+	// Seq is the Unix time in nanoseconds at which the maker signed the
+	// announcement. It increases with every announcement of a maker.
+	Seq       uint64 `json:"seq" validate:"required"`
+	Signature []byte `json:"signature" validate:"required"`
+}
+
+// String converts the OfferAnnouncement to a string usable for debugging purposes
+func (m *OfferAnnouncement) String() string {
+	return fmt.Sprintf("OfferAnnouncement Topic=%s Action=%s Maker=%s OfferID=%s Seq=%d",
+		m.Topic,
+		m.Action,
+		m.Maker,
+		m.OfferID,
+		m.Seq,
+	)
+}
+
+// Encode implements the Encode() method of the common.Message interface which
+// prepends a message type byte before the message's JSON encoding.
+func (m *OfferAnnouncement) Encode() ([]byte, error) {
+	b, err := vjson.MarshalStruct(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{OfferAnnouncementType}, b...), nil
+}
+
+// Type implements the Type() method of the common.Message interface
+func (m *OfferAnnouncement) Type() byte {
+	return OfferAnnouncementType
+}
+
+// signedBytes returns the bytes that the maker signs. Variable length fields
+// are length prefixed.
+func (m *OfferAnnouncement) signedBytes() []byte {
+	b := []byte(announcementDomain)
+	for _, s := range []string{m.Topic, string(m.Action), string(m.Maker)} {
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		b = append(b, s...)
+	}
+	b = append(b, m.OfferID[:]...)
+	return binary.BigEndian.AppendUint64(b, m.Seq)
+}
+
+// Sign sets the maker of the announcement to the peer ID of the key, and signs
+// the announcement.
+func (m *OfferAnnouncement) Sign(key crypto.PrivKey) error {
+	maker, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	m.Maker = maker
+	m.Signature, err = key.Sign(m.signedBytes())
+	return err
+}
+
+// Verify checks that the offer matches the action and offer ID of the
+// announcement, and that the announcement was signed by the maker.
+func (m *OfferAnnouncement) Verify() error {
+	if m.Action == OfferWithdrawn {
+		if m.Offer != nil {
+			return errors.New("withdrawn announcement has an offer")
+		}
+	} else if m.Offer == nil || m.Offer.ID != m.OfferID {
+		return fmt.Errorf("%s announcement does not have offer %s", m.Action, m.OfferID)
+	}
+
+	pubKey, err := m.Maker.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("failed to get the public key of maker %s: %w", m.Maker, err)
+	}
+
+	ok, err := pubKey.Verify(m.signedBytes(), m.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid announcement signature")
+	}
+
+	return nil
+}
@@ -51,9 +51,16 @@ type RelayClaimRequest struct {
 	Signature []byte                          `json:"signature" validate:"required,len=65"`
 }
 
-// String converts the RelayClaimRequest to a string usable for debugging purposes
+// String converts the RelayClaimRequest to a string usable for debugging
+// purposes. The secret is redacted, since the string can end up in logs and
+// swap journals.
 func (m *RelayClaimRequest) String() string {
-	return fmt.Sprintf("RelayClaimResponse=%#v", m)
+	return fmt.Sprintf("RelayClaimRequest OfferID=%v RelaySwap=%+v Secret=%s Signature=0x%x",
+		m.OfferID,
+		m.RelaySwap,
+		redacted,
+		m.Signature,
+	)
 }
 
 // Encode implements the Encode() method of the common.Message interface which
@@ -0,0 +1,41 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package message
+
+import (
+	"fmt"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+)
+
+// ResumeSwap is the first message of a swap stream that replaces a dropped
+// stream of an ongoing swap, and the reply of the peer that accepts it.
+// Received is the number of swap messages that the sender received from the
+// peer, so that the peer can send its last message again if it was lost.
+type ResumeSwap struct {
+	OfferID  types.Hash `json:"offerID" validate:"required"`
+	Received uint32     `json:"received"`
+}
+
+// String converts the ResumeSwap to a string usable for debugging purposes
+func (m *ResumeSwap) String() string {
+	return fmt.Sprintf("ResumeSwap OfferID=%s Received=%d", m.OfferID, m.Received)
+}
+
+// Encode implements the Encode() method of the common.Message interface which
+// prepends a message type byte before the message's JSON encoding.
+func (m *ResumeSwap) Encode() ([]byte, error) {
+	b, err := vjson.MarshalStruct(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{ResumeSwapType}, b...), nil
+}
+
+// Type implements the Type() method of the common.Message interface
+func (m *ResumeSwap) Type() byte {
+	return ResumeSwapType
+}
@@ -0,0 +1,412 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	kaddht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p-kad-dht/dual"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	libp2phost "github.com/libp2p/go-libp2p/core/host"
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/routing"
+	libp2prouting "github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	routedhost "github.com/libp2p/go-libp2p/p2p/host/routed"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/athanorlabs/atomic-swap/common"
+)
+
+// p2pHost is the libp2p host of a Host, which supports discovery via DHT,
+// bootstrapping and peer count management. It implements P2pHost.
+type p2pHost struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	protocolID string
+
+	h         libp2phost.Host
+	bootnodes []peer.AddrInfo
+	discovery *discovery
+}
+
+var _ P2pHost = (*p2pHost)(nil)
+
+// p2pHostConfig is used to configure the p2pHost.
+type p2pHostConfig struct {
+	Ctx        context.Context
+	Key        crypto.PrivKey
+	Port       uint16
+	Bootnodes  []string
+	ProtocolID string
+	ListenIP   string
+
+	// AdvertisedNamespacesFunc is called to determine which namespaces should
+	// be advertised in the DHT. It should, at minimum, return the empty ("")
+	// namespace.
+	AdvertisedNamespacesFunc func() []string
+
+	// ProxyOptions, if set, replace the default transports for hosts whose
+	// connections go through a proxy, see proxyOptions. Only TCP is listened
+	// on, and NAT port mapping and hole punching, which need direct
+	// connections, are disabled.
+	ProxyOptions []libp2p.Option
+}
+
+// QUIC will have better performance in high-bandwidth protocols if you increase a socket
+// receive buffer (sysctl -w net.core.rmem_max=2500000). We have a low-bandwidth protocol,
+// so setting this variable keeps a warning out of our logs. See this for more information:
+// https://github.com/lucas-clemente/quic-go/wiki/UDP-Receive-Buffer-Size
+func init() {
+	_ = os.Setenv("QUIC_GO_DISABLE_RECEIVE_BUFFER_WARNING", "true")
+}
+
+// newP2pHost returns a new p2pHost
+func newP2pHost(cfg *p2pHostConfig) (*p2pHost, error) {
+	listenIP := net.ParseIP(cfg.ListenIP)
+	if listenIP == nil {
+		return nil, errInvalidListenIP
+	}
+
+	opts := []libp2p.Option{
+		libp2p.Identity(cfg.Key),
+		libp2p.EnableRelayService(),
+		libp2p.EnableNATService(),
+		// Let this host use the DHT to find other hosts
+		libp2p.Routing(func(h libp2phost.Host) (routing.PeerRouting, error) {
+			return kaddht.New(cfg.Ctx, h)
+		}),
+	}
+
+	tcpAddr := fmt.Sprintf("/ip4/%s/tcp/%d", listenIP, cfg.Port)
+	if len(cfg.ProxyOptions) > 0 {
+		opts = append(opts, libp2p.ListenAddrStrings(tcpAddr))
+		opts = append(opts, cfg.ProxyOptions...)
+	} else {
+		opts = append(opts,
+			libp2p.ListenAddrStrings(
+				tcpAddr,
+				fmt.Sprintf("/ip4/%s/udp/%d/quic-v1", listenIP, cfg.Port),
+			),
+			libp2p.NATPortMap(),
+			libp2p.EnableHolePunching(),
+		)
+	}
+
+	bns, err := stringsToAddrInfos(cfg.Bootnodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format bootnodes: %w", err)
+	}
+
+	if len(bns) > 0 {
+		opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays(bns))
+	}
+
+	basicHost, err := libp2p.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// There is libp2p bug when calling `dual.New` with a cancelled context creating a panic,
+	// so we need the extra guard below:
+	// Panic:  https://github.com/jbenet/goprocess/blob/v0.1.4/impl-mutex.go#L99
+	// Caller: https://github.com/libp2p/go-libp2p-kad-dht/blob/v0.17.0/dht.go#L222
+	if cfg.Ctx.Err() != nil {
+		_ = basicHost.Close()
+		return nil, cfg.Ctx.Err()
+	}
+
+	// Note on ModeServer: The dual KAD DHT, by default, puts the LAN interface in server mode and
+	// the WAN interface in ModeClient if it is behind a NAT firewall. In our case, even nodes behind
+	// NAT firewalls should be servers, otherwise remote nodes will not be able to connect and list
+	// their offers.
+	dht, err := dual.New(cfg.Ctx, basicHost,
+		dual.DHTOption(kaddht.BootstrapPeers(bns...)),
+		dual.DHTOption(kaddht.Mode(kaddht.ModeServer)),
+	)
+	if err != nil {
+		_ = basicHost.Close()
+		return nil, err
+	}
+
+	routedHost := routedhost.Wrap(basicHost, dht)
+
+	ourCtx, cancel := context.WithCancel(cfg.Ctx)
+	return &p2pHost{
+		ctx:        ourCtx,
+		cancel:     cancel,
+		protocolID: cfg.ProtocolID,
+		h:          routedHost,
+		bootnodes:  bns,
+		discovery: &discovery{
+			ctx:                  ourCtx,
+			namespacePrefix:      cfg.ProtocolID,
+			dht:                  dht,
+			h:                    routedHost,
+			rd:                   libp2prouting.NewRoutingDiscovery(dht),
+			advertiseCh:          make(chan struct{}),
+			advertisedNamespaces: cfg.AdvertisedNamespacesFunc,
+		},
+	}, nil
+}
+
+// Start starts the bootstrap and discovery process.
+func (h *p2pHost) Start() error {
+	for _, addr := range h.h.Addrs() {
+		log.Info("started listening: address=", addr)
+	}
+
+	if err := h.bootstrap(); err != nil {
+		return err
+	}
+
+	go h.logPeers()
+
+	return h.discovery.start()
+}
+
+func (h *p2pHost) logPeers() {
+	const logPeersInterval = time.Minute * 5
+	timer := time.NewTicker(logPeersInterval)
+	defer timer.Stop()
+
+	for {
+		log.Debugf("peer count: %d", len(h.h.Network().Peers()))
+
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Stop closes host services and the libp2p host (host services first)
+func (h *p2pHost) Stop() error {
+	h.cancel()
+
+	if err := h.discovery.stop(); err != nil {
+		return err
+	}
+
+	if err := h.h.Close(); err != nil {
+		return fmt.Errorf("failed to close libp2p host: %w", err)
+	}
+
+	if err := h.h.Peerstore().Close(); err != nil {
+		return fmt.Errorf("failed to close peerstore: %w", err)
+	}
+
+	return nil
+}
+
+// Advertise advertises in the DHT.
+func (h *p2pHost) Advertise() {
+	h.discovery.advertiseCh <- struct{}{}
+}
+
+// Libp2pHost returns the underlying libp2p host, for protocols that attach to
+// it directly, like pubsub. Its stream handlers are not prefixed with the
+// protocol ID.
+func (h *p2pHost) Libp2pHost() libp2phost.Host {
+	return h.h
+}
+
+// Addresses returns the list of multiaddress the host is listening on.
+func (h *p2pHost) Addresses() []ma.Multiaddr {
+	addr := h.AddrInfo()
+	multiaddrs, err := peer.AddrInfoToP2pAddrs(&addr)
+	if err != nil {
+		// This shouldn't ever happen, but don't want to panic
+		log.Errorf("failed to convert AddrInfo=%q to Multiaddr: %s", addr, err)
+	}
+	return multiaddrs
+}
+
+// PeerID returns the host's peer ID.
+func (h *p2pHost) PeerID() peer.ID {
+	return h.h.ID()
+}
+
+// AddrInfo returns the host's AddrInfo.
+func (h *p2pHost) AddrInfo() peer.AddrInfo {
+	return peer.AddrInfo{
+		ID:    h.h.ID(),
+		Addrs: h.h.Addrs(),
+	}
+}
+
+// ConnectedPeers returns the multiaddresses of our currently connected peers.
+func (h *p2pHost) ConnectedPeers() []string {
+	var peers []string
+	for _, c := range h.h.Network().Conns() {
+		// the remote multi addr returned is just the transport
+		p := fmt.Sprintf("%s/p2p/%s", c.RemoteMultiaddr(), c.RemotePeer())
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// Discover searches the DHT for peers that advertise that they provide the given string.
+// It searches for up to `searchTime` duration of time.
+func (h *p2pHost) Discover(provides string, searchTime time.Duration) ([]peer.ID, error) {
+	return h.discovery.discover(provides, searchTime)
+}
+
+// SetStreamHandler sets the stream handler for the given protocol ID.
+func (h *p2pHost) SetStreamHandler(pid string, handler func(libp2pnetwork.Stream)) {
+	h.h.SetStreamHandler(protocol.ID(h.protocolID+pid), handler)
+	log.Debugf("supporting protocol %s", protocol.ID(h.protocolID+pid))
+}
+
+// Connectedness returns the connectedness state of a given peer.
+func (h *p2pHost) Connectedness(who peer.ID) libp2pnetwork.Connectedness {
+	return h.h.Network().Connectedness(who)
+}
+
+// Connect connects to the given peer.
+func (h *p2pHost) Connect(ctx context.Context, who peer.AddrInfo) error {
+	if who.ID == h.PeerID() {
+		return errCannotConnectToSelf
+	}
+	return h.h.Connect(ctx, who)
+}
+
+// NewStream opens a stream with the given peer on the given protocol ID.
+func (h *p2pHost) NewStream(ctx context.Context, p peer.ID, pid protocol.ID) (libp2pnetwork.Stream, error) {
+	return h.h.NewStream(ctx, p, protocol.ID(h.protocolID)+pid)
+}
+
+// bootstrap connects the host to the configured bootnodes
+func (h *p2pHost) bootstrap() error {
+	if len(h.bootnodes) == 0 {
+		log.Warnf("bootstrapping skipped, no bootnodes found")
+		return nil
+	}
+
+	selfID := h.PeerID()
+
+	var failed atomic.Uint64
+	var wg sync.WaitGroup
+	for _, bn := range h.bootnodes {
+		if bn.ID == selfID {
+			continue
+		}
+		h.h.Peerstore().AddAddrs(bn.ID, bn.Addrs, peerstore.PermanentAddrTTL)
+		log.Debugf("bootstrapping to peer: %s (%s)", bn, h.h.Network().Connectedness(bn.ID))
+		wg.Add(1)
+		go func(p peer.AddrInfo) {
+			defer wg.Done()
+			err := h.h.Connect(h.ctx, p)
+			if err != nil {
+				log.Debugf("failed to bootstrap to peer %s: err=%s", p.ID, err)
+				failed.Add(1)
+			}
+			for _, c := range h.h.Network().ConnsToPeer(p.ID) {
+				log.Debugf("connected to %s/p2p/%s", c.RemoteMultiaddr(), p.ID)
+			}
+		}(bn)
+	}
+	wg.Wait()
+
+	if failed.Load() == uint64(len(h.bootnodes)) {
+		return errFailedToBootstrap
+	}
+
+	return nil
+}
+
+// stringsToAddrInfos converts a string of peers in multiaddress format to a
+// minimal set of multiaddr addresses.
+func stringsToAddrInfos(peers []string) ([]peer.AddrInfo, error) {
+	madders := make([]ma.Multiaddr, len(peers))
+	for i, p := range peers {
+		addr, err := ma.NewMultiaddr(p)
+		if err != nil {
+			return nil, err
+		}
+		madders[i] = addr
+	}
+	return peer.AddrInfosFromP2pAddrs(madders...)
+}
+
+// loadOrCreateKey loads the hex encoded ed25519 libp2p key from the given
+// file. If the file does not exist, a new key is created and written to it.
+func loadOrCreateKey(keyFile string) (crypto.PrivKey, error) {
+	exists, err := common.FileExists(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return loadKey(keyFile)
+	}
+
+	log.Debugf("libp2p key not found, generating key %s", keyFile)
+	return generateKey(keyFile)
+}
+
+// generateKey generates an ed25519 private key and writes it to the given
+// file, which must not exist yet.
+func generateKey(keyFile string) (crypto.PrivKey, error) {
+	key, _, err := crypto.GenerateEd25519Key(crand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := key.Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Clean(keyFile), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = f.Write([]byte(hex.EncodeToString(raw))); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// loadKey loads the hex encoded ed25519 libp2p key from the given file.
+func loadKey(keyFile string) (crypto.PrivKey, error) {
+	keyHex, err := os.ReadFile(filepath.Clean(keyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode libp2p key %s: %w", keyFile, err)
+	}
+
+	key, err := crypto.UnmarshalEd25519PrivateKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid libp2p key %s: %w", keyFile, err)
+	}
+
+	return key, nil
+}
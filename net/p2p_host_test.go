@@ -0,0 +1,47 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrCreateKey(t *testing.T) {
+	keyFile := path.Join(t.TempDir(), "net.key")
+
+	key, err := loadOrCreateKey(keyFile)
+	require.NoError(t, err)
+
+	loaded, err := loadOrCreateKey(keyFile)
+	require.NoError(t, err)
+	require.True(t, key.Equals(loaded))
+
+	// an existing key is never overwritten
+	_, err = generateKey(keyFile)
+	require.ErrorContains(t, err, "file exists")
+
+	other, err := loadOrCreateKey(path.Join(t.TempDir(), "net.key"))
+	require.NoError(t, err)
+	require.False(t, key.Equals(other))
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("not hex"), 0600))
+	_, err = loadOrCreateKey(keyFile)
+	require.ErrorContains(t, err, "failed to decode libp2p key")
+}
+
+func TestStringsToAddrInfos(t *testing.T) {
+	bootnodes := []string{
+		"/ip4/192.168.0.101/udp/9934/quic-v1/p2p/12D3KooWC547RfLcveQi1vBxACjnT6Uv15V11ortDTuxRWuhubGv",
+		"/ip4/192.168.0.101/tcp/9934/p2p/12D3KooWC547RfLcveQi1vBxACjnT6Uv15V11ortDTuxRWuhubGv",
+	}
+	addrInfos, err := stringsToAddrInfos(bootnodes)
+	require.NoError(t, err)
+	require.Len(t, addrInfos, 1) // both were combined into one AddrInfo
+	require.Equal(t, "12D3KooWC547RfLcveQi1vBxACjnT6Uv15V11ortDTuxRWuhubGv", addrInfos[0].ID.String())
+	require.Len(t, addrInfos[0].Addrs, 2)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// SetPeerLists replaces the peers that can open streams with the host. If
+// allowed is not empty, only its peers can. Denied peers never can, even if
+// they are also allowed. Ongoing swap streams are not affected.
+func (h *Host) SetPeerLists(allowed []peer.ID, denied []peer.ID) {
+	allowedSet := make(map[peer.ID]struct{}, len(allowed))
+	for _, id := range allowed {
+		allowedSet[id] = struct{}{}
+	}
+
+	deniedSet := make(map[peer.ID]struct{}, len(denied))
+	for _, id := range denied {
+		deniedSet[id] = struct{}{}
+	}
+
+	h.peersMu.Lock()
+	defer h.peersMu.Unlock()
+	h.allowedPeers = allowedSet
+	h.deniedPeers = deniedSet
+}
+
+// IsPeerAllowed returns whether the peer can open streams with the host.
+func (h *Host) IsPeerAllowed(id peer.ID) bool {
+	h.peersMu.RLock()
+	defer h.peersMu.RUnlock()
+
+	if _, denied := h.deniedPeers[id]; denied {
+		return false
+	}
+
+	if len(h.allowedPeers) == 0 {
+		return true
+	}
+
+	_, allowed := h.allowedPeers[id]
+	return allowed
+}
+
+// SetRelayer sets whether the host relays claims for any XMR maker, and
+// advertises the change.
+func (h *Host) SetRelayer(isRelayer bool) {
+	if h.isRelayer.Swap(isRelayer) != isRelayer {
+		h.Advertise()
+	}
+}
+
+// filterPeers wraps the stream handler so that streams from peers that are not
+// allowed are reset.
+func (h *Host) filterPeers(handler func(libp2pnetwork.Stream)) func(libp2pnetwork.Stream) {
+	return func(stream libp2pnetwork.Stream) {
+		remotePeer := stream.Conn().RemotePeer()
+		if !h.IsPeerAllowed(remotePeer) {
+			log.Debugf("refusing %s stream from peer %s", stream.Protocol(), remotePeer)
+			_ = stream.Reset()
+			return
+		}
+
+		handler(stream)
+	}
+}
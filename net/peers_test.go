@@ -0,0 +1,54 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHost_IsPeerAllowed(t *testing.T) {
+	alice, err := peer.Decode("12D3KooWAYn1T8Lu122Pav4zAogjpeU61usLTNZpLRNh9gCqY6X2")
+	require.NoError(t, err)
+	bob, err := peer.Decode("12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5")
+	require.NoError(t, err)
+
+	h := new(Host)
+	require.True(t, h.IsPeerAllowed(alice))
+
+	h.SetPeerLists(nil, []peer.ID{bob})
+	require.True(t, h.IsPeerAllowed(alice))
+	require.False(t, h.IsPeerAllowed(bob))
+
+	h.SetPeerLists([]peer.ID{alice, bob}, []peer.ID{bob})
+	require.True(t, h.IsPeerAllowed(alice))
+	require.False(t, h.IsPeerAllowed(bob)) // denied takes precedence
+
+	h.SetPeerLists([]peer.ID{bob}, nil)
+	require.False(t, h.IsPeerAllowed(alice))
+	require.True(t, h.IsPeerAllowed(bob))
+}
+
+func TestHost_Query_deniedPeer(t *testing.T) {
+	ha := newHost(t, basicTestConfig(t))
+	err := ha.Start()
+	require.NoError(t, err)
+
+	hb := newHost(t, basicTestConfig(t))
+	err = hb.Start()
+	require.NoError(t, err)
+
+	err = ha.h.Connect(ha.ctx, hb.h.AddrInfo())
+	require.NoError(t, err)
+
+	hb.SetPeerLists(nil, []peer.ID{ha.PeerID()})
+	_, err = ha.Query(hb.PeerID())
+	require.Error(t, err)
+
+	hb.SetPeerLists(nil, nil)
+	_, err = ha.Query(hb.PeerID())
+	require.NoError(t, err)
+}
@@ -0,0 +1,195 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/libp2p/go-libp2p"
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	ma "github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+	mafmt "github.com/multiformats/go-multiaddr-fmt"
+	manet "github.com/multiformats/go-multiaddr/net"
+
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+)
+
+// proxyDialMatcher matches the TCP addresses that are dialed through the
+// proxy.
+var proxyDialMatcher = mafmt.And(mafmt.IP, mafmt.Base(ma.P_TCP))
+
+// proxyOptions returns the libp2p options of hosts whose connections go
+// through the proxy, or nil if connections are direct. libp2p resolves /dns
+// and /dnsaddr multiaddrs itself before choosing a transport, so their lookups
+// fail instead of leaking DNS requests, and only IP addresses are dialed.
+func proxyOptions(p *netproxy.Proxy) ([]libp2p.Option, error) {
+	if !p.Enabled() {
+		return nil, nil
+	}
+
+	resolver, err := madns.NewResolver(madns.WithDefaultResolver(noDNSResolver{}))
+	if err != nil {
+		return nil, err
+	}
+
+	return []libp2p.Option{
+		libp2p.Transport(func(u transport.Upgrader, rcmgr libp2pnetwork.ResourceManager) (*proxyTransport, error) {
+			return newProxyTransport(p, u, rcmgr)
+		}),
+		libp2p.MultiaddrResolver(resolver),
+	}, nil
+}
+
+// proxyTransport is a libp2p TCP transport that dials through the proxy.
+// Listening is done by the regular TCP transport.
+type proxyTransport struct {
+	proxy    *netproxy.Proxy
+	upgrader transport.Upgrader
+	rcmgr    libp2pnetwork.ResourceManager
+	tcp      *tcp.TcpTransport
+}
+
+var _ transport.Transport = (*proxyTransport)(nil)
+
+func newProxyTransport(
+	p *netproxy.Proxy,
+	u transport.Upgrader,
+	rcmgr libp2pnetwork.ResourceManager,
+) (*proxyTransport, error) {
+	if rcmgr == nil {
+		rcmgr = &libp2pnetwork.NullResourceManager{}
+	}
+
+	tcpTransport, err := tcp.NewTCPTransport(u, rcmgr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proxyTransport{
+		proxy:    p,
+		upgrader: u,
+		rcmgr:    rcmgr,
+		tcp:      tcpTransport,
+	}, nil
+}
+
+// Dial dials the peer at the remote address through the proxy.
+func (t *proxyTransport) Dial(ctx context.Context, raddr ma.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	addr, err := proxyDialAddr(raddr)
+	if err != nil {
+		return nil, err
+	}
+
+	connScope, err := t.rcmgr.OpenConnection(libp2pnetwork.DirOutbound, true, raddr)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := t.dialWithScope(ctx, addr, raddr, p, connScope)
+	if err != nil {
+		connScope.Done()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (t *proxyTransport) dialWithScope(
+	ctx context.Context,
+	addr string,
+	raddr ma.Multiaddr,
+	p peer.ID,
+	connScope libp2pnetwork.ConnManagementScope,
+) (transport.CapableConn, error) {
+	if err := connScope.SetPeer(p); err != nil {
+		return nil, err
+	}
+
+	conn, err := t.proxy.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	laddr, err := manet.FromNetAddr(conn.LocalAddr())
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// the remote address of the connection is the one of the proxy
+	c := &proxiedConn{Conn: conn, laddr: laddr, raddr: raddr}
+	return t.upgrader.Upgrade(ctx, t, c, libp2pnetwork.DirOutbound, p, connScope)
+}
+
+// CanDial returns whether the address is a TCP address that the proxy can
+// connect to.
+func (t *proxyTransport) CanDial(addr ma.Multiaddr) bool {
+	return proxyDialMatcher.Matches(addr)
+}
+
+// Listen listens on the given multiaddr with the regular TCP transport.
+func (t *proxyTransport) Listen(laddr ma.Multiaddr) (transport.Listener, error) {
+	return t.tcp.Listen(laddr)
+}
+
+// Protocols returns the protocols that the transport dials.
+func (t *proxyTransport) Protocols() []int {
+	return []int{ma.P_TCP}
+}
+
+// Proxy returns true, as connections go through the proxy.
+func (t *proxyTransport) Proxy() bool {
+	return true
+}
+
+func (t *proxyTransport) String() string {
+	return "TCP through proxy"
+}
+
+// proxiedConn is a connection made through the proxy, whose remote multiaddr
+// is the dialed one.
+type proxiedConn struct {
+	net.Conn
+	laddr ma.Multiaddr
+	raddr ma.Multiaddr
+}
+
+func (c *proxiedConn) LocalMultiaddr() ma.Multiaddr {
+	return c.laddr
+}
+
+func (c *proxiedConn) RemoteMultiaddr() ma.Multiaddr {
+	return c.raddr
+}
+
+// proxyDialAddr returns the host:port that the proxy connects to for the
+// multiaddr.
+func proxyDialAddr(addr ma.Multiaddr) (string, error) {
+	if !proxyDialMatcher.Matches(addr) {
+		return "", fmt.Errorf("can't dial %s through the proxy", addr)
+	}
+
+	netAddr, err := manet.ToNetAddr(addr)
+	if err != nil {
+		return "", err
+	}
+
+	return netAddr.String(), nil
+}
+
+// noDNSResolver fails every lookup, see proxyOptions.
+type noDNSResolver struct{}
+
+func (noDNSResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	return nil, errNoDNSThroughProxy
+}
+
+func (noDNSResolver) LookupTXT(context.Context, string) ([]string, error) {
+	return nil, errNoDNSThroughProxy
+}
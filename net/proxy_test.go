@@ -0,0 +1,90 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	libp2phost "github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+)
+
+func TestProxyDialAddr(t *testing.T) {
+	addr, err := proxyDialAddr(ma.StringCast("/ip4/1.2.3.4/tcp/9900"))
+	require.NoError(t, err)
+	require.Equal(t, "1.2.3.4:9900", addr)
+
+	addr, err = proxyDialAddr(ma.StringCast("/ip6/2001:db8::1/tcp/9900"))
+	require.NoError(t, err)
+	require.Equal(t, "[2001:db8::1]:9900", addr)
+
+	for _, s := range []string{
+		"/ip4/1.2.3.4/udp/9900/quic-v1",
+		"/dns4/example.com/tcp/9900",
+		"/ip4/1.2.3.4/tcp/9900/p2p/12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5/p2p-circuit",
+	} {
+		_, err = proxyDialAddr(ma.StringCast(s))
+		require.ErrorContains(t, err, "can't dial", s)
+	}
+}
+
+func TestProxyOptions_direct(t *testing.T) {
+	opts, err := proxyOptions(&netproxy.Proxy{})
+	require.NoError(t, err)
+	require.Nil(t, opts)
+}
+
+func newTestLibp2pHost(t *testing.T, opts ...libp2p.Option) libp2phost.Host {
+	opts = append([]libp2p.Option{libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0")}, opts...)
+	h, err := libp2p.New(opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, h.Close()) })
+	return h
+}
+
+func TestProxyTransport(t *testing.T) {
+	// nothing listens on the proxy port, so only dials that don't go through
+	// the proxy can succeed
+	proxy, err := netproxy.New(&netproxy.Config{URL: "socks5://127.0.0.1:1"})
+	require.NoError(t, err)
+	opts, err := proxyOptions(proxy)
+	require.NoError(t, err)
+
+	h := newTestLibp2pHost(t, opts...)
+	target := newTestLibp2pHost(t)
+	port, err := target.Addrs()[0].ValueForProtocol(ma.P_TCP)
+	require.NoError(t, err)
+
+	connect := func(addr string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		h.Peerstore().ClearAddrs(target.ID())
+		return h.Connect(ctx, peer.AddrInfo{ID: target.ID(), Addrs: []ma.Multiaddr{ma.StringCast(addr)}})
+	}
+
+	// remote addresses are dialed through the proxy
+	err = connect(fmt.Sprintf("/ip4/192.0.2.1/tcp/%s", port))
+	require.ErrorContains(t, err, "127.0.0.1:1")
+
+	// host names aren't looked up
+	err = connect(fmt.Sprintf("/dns4/localhost/tcp/%s", port))
+	require.Error(t, err)
+	require.NotEqual(t, network.Connected, h.Network().Connectedness(target.ID()))
+
+	// loopback addresses are dialed directly
+	require.NoError(t, connect(fmt.Sprintf("/ip4/127.0.0.1/tcp/%s", port)))
+	conns := h.Network().ConnsToPeer(target.ID())
+	require.Len(t, conns, 1)
+	require.True(t, manet.IsIPLoopback(conns[0].RemoteMultiaddr()))
+}
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"time"
 
-	p2pnet "github.com/athanorlabs/go-p2p-net"
 	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 
@@ -24,10 +23,11 @@ func (h *Host) handleQueryStream(stream libp2pnetwork.Stream) {
 	defer func() { _ = stream.Close() }()
 
 	resp := &QueryResponse{
-		Offers: h.makerHandler.GetOffers(),
+		Offers:       h.makerHandler.GetOffers(),
+		Capabilities: h.Capabilities(),
 	}
 
-	if err := p2pnet.WriteStreamMessage(stream, resp, stream.Conn().RemotePeer()); err != nil {
+	if err := writeStreamMessage(stream, resp, message.JSONEncoding, stream.Conn().RemotePeer()); err != nil {
 		log.Warnf("failed to send QueryResponse message to peer: err=%s", err)
 	}
 }
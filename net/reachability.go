@@ -0,0 +1,75 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"github.com/libp2p/go-libp2p/core/event"
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+)
+
+// Reachability is whether peers can open connections to the host.
+type Reachability string
+
+// The reachability values reported by Host.Reachability.
+const (
+	// ReachabilityUnknown is reported until the AutoNAT client of the libp2p
+	// host found out the reachability.
+	ReachabilityUnknown Reachability = "unknown"
+	// ReachabilityPublic means that peers could connect to one of the
+	// addresses of the host.
+	ReachabilityPublic Reachability = "public"
+	// ReachabilityPrivate means that peers could not connect to the host,
+	// which is then only reachable through relays and hole punching.
+	ReachabilityPrivate Reachability = "private"
+)
+
+func reachabilityFromLibp2p(r libp2pnetwork.Reachability) Reachability {
+	switch r {
+	case libp2pnetwork.ReachabilityPublic:
+		return ReachabilityPublic
+	case libp2pnetwork.ReachabilityPrivate:
+		return ReachabilityPrivate
+	default:
+		return ReachabilityUnknown
+	}
+}
+
+// Reachability returns whether peers can connect to the host, as last reported
+// by the AutoNAT client of the libp2p host.
+func (h *Host) Reachability() Reachability {
+	h.reachMu.RLock()
+	defer h.reachMu.RUnlock()
+	return h.reach
+}
+
+// watchReachability updates the reachability from the events of the libp2p
+// host until the host's context is cancelled.
+func (h *Host) watchReachability(sub event.Subscription) {
+	defer func() { _ = sub.Close() }()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case e, ok := <-sub.Out():
+			if !ok {
+				return
+			}
+			evt, ok := e.(event.EvtLocalReachabilityChanged)
+			if !ok {
+				continue
+			}
+			h.setReachability(reachabilityFromLibp2p(evt.Reachability))
+		}
+	}
+}
+
+func (h *Host) setReachability(r Reachability) {
+	h.reachMu.Lock()
+	defer h.reachMu.Unlock()
+	if h.reach != r {
+		log.Infof("reachability changed from %s to %s", h.reach, r)
+		h.reach = r
+	}
+}
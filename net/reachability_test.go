@@ -0,0 +1,37 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/p2p/host/eventbus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReachabilityFromLibp2p(t *testing.T) {
+	require.Equal(t, ReachabilityUnknown, reachabilityFromLibp2p(libp2pnetwork.ReachabilityUnknown))
+	require.Equal(t, ReachabilityPublic, reachabilityFromLibp2p(libp2pnetwork.ReachabilityPublic))
+	require.Equal(t, ReachabilityPrivate, reachabilityFromLibp2p(libp2pnetwork.ReachabilityPrivate))
+}
+
+func TestHost_Reachability(t *testing.T) {
+	h := newHost(t, basicTestConfig(t))
+	require.NoError(t, h.Start())
+	require.Equal(t, ReachabilityUnknown, h.Reachability())
+
+	emitter, err := h.h.Libp2pHost().EventBus().Emitter(new(event.EvtLocalReachabilityChanged), eventbus.Stateful)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = emitter.Close() })
+
+	for _, r := range []libp2pnetwork.Reachability{libp2pnetwork.ReachabilityPrivate, libp2pnetwork.ReachabilityPublic} {
+		require.NoError(t, emitter.Emit(event.EvtLocalReachabilityChanged{Reachability: r}))
+		require.Eventually(t, func() bool {
+			return h.Reachability() == reachabilityFromLibp2p(r)
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"time"
 
-	p2pnet "github.com/athanorlabs/go-p2p-net"
 	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 
@@ -40,7 +39,7 @@ func (h *Host) DiscoverRelayers() ([]peer.ID, error) {
 func (h *Host) handleRelayerQueryStream(stream libp2pnetwork.Stream) {
 	defer func() { _ = stream.Close() }()
 
-	if !h.isRelayer {
+	if !h.isRelayer.Load() {
 		err := h.relayHandler.HasOngoingSwapAsTaker(stream.Conn().RemotePeer())
 		if err != nil {
 			// the returned error logs the peer ID
@@ -60,7 +59,7 @@ func (h *Host) handleRelayerQueryStream(stream libp2pnetwork.Stream) {
 	}
 
 	log.Debugf("sending RelayerQueryResponse to peer %s", stream.Conn().RemotePeer())
-	if err := p2pnet.WriteStreamMessage(stream, addrResp, stream.Conn().RemotePeer()); err != nil {
+	if err := writeStreamMessage(stream, addrResp, message.JSONEncoding, stream.Conn().RemotePeer()); err != nil {
 		log.Warnf("failed to send RelayClaimResponse message to peer: %s", err)
 	}
 }
@@ -143,7 +142,7 @@ func (h *Host) handleRelayStream(stream libp2pnetwork.Stream) {
 	//     (a) The swap should exist in our swaps map
 	//     (b) The peerID who sent us the request must match the peerID with
 	//         whom we are performing the swap.
-	if req.OfferID == nil && !h.isRelayer {
+	if req.OfferID == nil && !h.isRelayer.Load() {
 		return
 	}
 
@@ -154,7 +153,7 @@ func (h *Host) handleRelayStream(stream libp2pnetwork.Stream) {
 	}
 
 	log.Debugf("Relayed claim for %s with tx=%s", req.RelaySwap.Swap.Claimer, resp.TxHash)
-	if err := p2pnet.WriteStreamMessage(stream, resp, stream.Conn().RemotePeer()); err != nil {
+	if err := writeStreamMessage(stream, resp, message.JSONEncoding, stream.Conn().RemotePeer()); err != nil {
 		log.Warnf("failed to send RelayClaimResponse message to peer: %s", err)
 		return
 	}
@@ -176,7 +175,7 @@ func (h *Host) SubmitRelayRequest(relayerID peer.ID, request *RelayClaimRequest)
 	defer func() { _ = stream.Close() }()
 	log.Debugf("opened relay stream with peer %s", relayerID)
 
-	if err := p2pnet.WriteStreamMessage(stream, request, relayerID); err != nil {
+	if err := writeStreamMessage(stream, request, message.JSONEncoding, relayerID); err != nil {
 		log.Warnf("failed to send RelayClaimRequest to peer: err=%s", err)
 		return nil, err
 	}
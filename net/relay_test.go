@@ -44,13 +44,13 @@ func TestHost_DiscoverRelayers(t *testing.T) {
 
 	peerIDs, err := ha.DiscoverRelayers()
 	require.NoError(t, err)
-	require.True(t, hb.isRelayer)
+	require.True(t, hb.isRelayer.Load())
 	require.Len(t, peerIDs, 1) // discovers hb
 	require.Equal(t, hb.PeerID(), peerIDs[0])
 
 	peerIDs, err = hb.DiscoverRelayers()
 	require.NoError(t, err)
-	require.False(t, ha.isRelayer)
+	require.False(t, ha.isRelayer.Load())
 	require.Len(t, peerIDs, 0) // ha is not a relayer and not discovered
 }
 
@@ -0,0 +1,242 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/net/message"
+)
+
+// When the stream of a swap drops after the keys were exchanged, and both peers
+// advertised message.FeatureResume, the swap is resumed on a new stream. The
+// peer that opened the dropped stream opens the new one and sends a ResumeSwap,
+// and the other peer replies with a ResumeSwap. Both peers then send their last
+// swap message again if the other peer didn't receive it. The swap state is only
+// told that the stream closed if the swap didn't resume within resumeTimeout.
+const (
+	resumeTimeout       = 2 * time.Minute
+	resumeRetryInterval = 5 * time.Second
+	resumeReplyTimeout  = 15 * time.Second
+)
+
+// resumeSwap is called when the stream of a swap dropped without the peer
+// closing it. It waits until the swap resumes on a new stream, which we open if
+// we opened the dropped one. It returns true if the swap resumed, in which case
+// the new stream is handled by another goroutine.
+func (h *Host) resumeSwap(dropped libp2pnetwork.Stream, s SwapState) bool {
+	id := s.OfferID()
+
+	h.swapMu.RLock()
+	swap, has := h.swaps[id]
+	if !has || swap.streamClosed {
+		h.swapMu.RUnlock()
+		return false
+	}
+	if swap.stream != dropped {
+		// the peer resumed the swap before we noticed that the stream dropped
+		h.swapMu.RUnlock()
+		return true
+	}
+	who, opener, resumed, canResume := swap.peer, swap.opener, swap.resumed, swap.resume
+	h.swapMu.RUnlock()
+
+	if !canResume {
+		return false
+	}
+
+	log.Infof("stream of swap %s with peer %s dropped, resuming the swap", id, who)
+
+	ctx, cancel := context.WithTimeout(h.ctx, resumeTimeout)
+	defer cancel()
+
+	if opener {
+		return h.reopenSwapStream(ctx, dropped, s)
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		log.Warnf("peer %s didn't resume swap %s", who, id)
+		return false
+	}
+}
+
+// reopenSwapStream opens new streams with the peer until one of them resumes
+// the swap, the peer rejects resuming it, or the context is done.
+func (h *Host) reopenSwapStream(ctx context.Context, dropped libp2pnetwork.Stream, s SwapState) bool {
+	id := s.OfferID()
+	who := dropped.Conn().RemotePeer()
+	_ = dropped.Reset()
+
+	for {
+		stream, peerReceived, err := h.openResumeStream(ctx, id, who)
+		if err == nil {
+			err = h.replaceStream(id, dropped, stream, peerReceived, false)
+			if err == nil {
+				log.Infof("resumed swap %s with peer %s", id, who)
+				go h.handleProtocolStreamInner(stream, s)
+				return true
+			}
+			_ = stream.Reset()
+		}
+
+		switch {
+		case errors.Is(err, errNoOngoingSwap):
+			// the swap exited while we were resuming it
+			return false
+		case errors.Is(err, errResumeRejected):
+			log.Warnf("failed to resume swap %s with peer %s: %s", id, who, err)
+			return false
+		}
+		log.Debugf("failed to resume swap %s with peer %s, retrying: %s", id, who, err)
+
+		select {
+		case <-time.After(resumeRetryInterval):
+		case <-ctx.Done():
+			log.Warnf("failed to resume swap %s with peer %s before the timeout: %s", id, who, err)
+			return false
+		}
+
+		if h.streamReplaced(id, dropped) {
+			return false
+		}
+	}
+}
+
+// openResumeStream opens a new swap stream with the peer and sends it a
+// ResumeSwap. It returns the stream and the number of swap messages that the
+// peer received once the peer replied with a ResumeSwap.
+func (h *Host) openResumeStream(
+	ctx context.Context,
+	id types.Hash,
+	who peer.ID,
+) (libp2pnetwork.Stream, uint32, error) {
+	h.swapMu.RLock()
+	swap, has := h.swaps[id]
+	if !has {
+		h.swapMu.RUnlock()
+		return nil, 0, errNoOngoingSwap
+	}
+	enc, req := swap.encoding, swap.resumeMessage(id)
+	h.swapMu.RUnlock()
+
+	connCtx, cancel := context.WithTimeout(ctx, connectionTimeout)
+	defer cancel()
+
+	stream, err := h.h.NewStream(connCtx, who, protocol.ID(swapID))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open stream with peer: %w", err)
+	}
+
+	if err = writeStreamMessage(stream, req, enc, who); err != nil {
+		_ = stream.Reset()
+		return nil, 0, err
+	}
+
+	_ = stream.SetReadDeadline(time.Now().Add(resumeReplyTimeout))
+	msg, err := readStreamMessage(stream, maxMessageSize)
+	if err != nil {
+		_ = stream.Reset()
+		if errors.Is(err, io.EOF) {
+			// the peer closes the stream if it has no such swap with us
+			return nil, 0, errResumeRejected
+		}
+		return nil, 0, fmt.Errorf("failed to read %s: %w", message.TypeToString(message.ResumeSwapType), err)
+	}
+	_ = stream.SetReadDeadline(time.Time{})
+
+	resp, ok := msg.(*message.ResumeSwap)
+	if !ok || resp.OfferID != id {
+		_ = stream.Reset()
+		return nil, 0, fmt.Errorf("%w: expected %s message but received %s", errResumeRejected,
+			message.TypeToString(message.ResumeSwapType), message.TypeToString(msg.Type()))
+	}
+
+	return stream, resp.Received, nil
+}
+
+// handleResumeStream handles a swap stream that the peer opened to resume a
+// swap whose stream dropped. Only the peer of the swap can resume it.
+func (h *Host) handleResumeStream(stream libp2pnetwork.Stream, req *message.ResumeSwap) {
+	who := stream.Conn().RemotePeer()
+
+	h.swapMu.RLock()
+	swap, has := h.swaps[req.OfferID]
+	if !has || swap.swapState == nil || swap.streamClosed || swap.peer != who {
+		h.swapMu.RUnlock()
+		log.Warnf("ignoring resume of swap %s from peer %s: %s", req.OfferID, who, errNoOngoingSwap)
+		_ = stream.Close()
+		return
+	}
+	dropped, s := swap.stream, swap.swapState
+	h.swapMu.RUnlock()
+
+	if err := h.replaceStream(req.OfferID, dropped, stream, req.Received, true); err != nil {
+		log.Warnf("failed to resume swap %s with peer %s: %s", req.OfferID, who, err)
+		_ = stream.Reset()
+		return
+	}
+
+	// the reader of the dropped stream, if it's still reading, stops now
+	_ = dropped.Reset()
+
+	log.Infof("resumed swap %s with peer %s", req.OfferID, who)
+	h.handleProtocolStreamInner(stream, s)
+}
+
+// replaceStream replaces the dropped stream of the swap with the resumed
+// stream, unless the swap exited or the stream was replaced already. Before
+// that, it replies with a ResumeSwap if reply is set, and then sends our last
+// swap message again if the peer didn't receive it.
+func (h *Host) replaceStream(
+	id types.Hash,
+	dropped libp2pnetwork.Stream,
+	stream libp2pnetwork.Stream,
+	peerReceived uint32,
+	reply bool,
+) error {
+	h.swapMu.Lock()
+	defer h.swapMu.Unlock()
+
+	swap, has := h.swaps[id]
+	if !has || swap.streamClosed || swap.stream != dropped {
+		return errNoOngoingSwap
+	}
+
+	// nothing else writes to the stream before it is the swap's stream
+	if reply {
+		if err := writeStreamMessage(stream, swap.resumeMessage(id), swap.encoding, swap.peer); err != nil {
+			return err
+		}
+	}
+	if err := swap.replayLastMessage(stream, peerReceived); err != nil {
+		return err
+	}
+
+	swap.stream = stream
+	close(swap.resumed)
+	swap.resumed = make(chan struct{})
+	return nil
+}
+
+// streamReplaced returns true if the stream is no longer the stream of the
+// swap, because the swap exited or resumed on another stream.
+func (h *Host) streamReplaced(id types.Hash, stream libp2pnetwork.Stream) bool {
+	h.swapMu.RLock()
+	defer h.swapMu.RUnlock()
+
+	swap, has := h.swaps[id]
+	return !has || swap.streamClosed || swap.stream != stream
+}
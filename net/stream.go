@@ -0,0 +1,97 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+)
+
+// writeStreamBytes writes the given message bytes to the stream, after their
+// 4-byte little-endian size.
+func writeStreamBytes(s io.Writer, msg []byte) error {
+	if err := binary.Write(s, binary.LittleEndian, uint32(len(msg))); err != nil {
+		return err
+	}
+
+	_, err := s.Write(msg)
+	return err
+}
+
+// readStreamBytes reads the 4-byte little-endian size header and message body,
+// returning the message body bytes. io.EOF is returned if the stream is closed
+// before any bytes are received. If a partial message is received before the
+// stream closes, io.ErrUnexpectedEOF is returned.
+func readStreamBytes(s io.Reader, maxMessageSize uint32) ([]byte, error) {
+	if s == nil {
+		return nil, errNilStream
+	}
+
+	var lenBuf [4]byte
+	n, err := io.ReadFull(s, lenBuf[:])
+	if err != nil {
+		if isEOF(err) {
+			if n > 0 {
+				err = io.ErrUnexpectedEOF
+			} else {
+				err = io.EOF
+			}
+		}
+		return nil, err
+	}
+	msgLen := binary.LittleEndian.Uint32(lenBuf[:])
+
+	if msgLen > maxMessageSize {
+		log.Warnf("received message longer than max allowed size: msg size=%d, max=%d",
+			msgLen, maxMessageSize)
+		return nil, fmt.Errorf("%w: %d bytes", errMessageTooLarge, msgLen)
+	}
+
+	msgBuf := make([]byte, msgLen)
+	if _, err = io.ReadFull(s, msgBuf); err != nil {
+		if isEOF(err) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return msgBuf, nil
+}
+
+// isEOF returns whether the error means that the stream was closed.
+func isEOF(err error) bool {
+	switch {
+	case
+		errors.Is(err, net.ErrClosed), // what libp2p with QUIC usually generates
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.ErrClosedPipe):
+		return true
+	default:
+		return false
+	}
+}
+
+// isTransportError returns whether a read error means that the stream dropped,
+// rather than that the peer closed it or sent a message that we can't decode.
+func isTransportError(err error) bool {
+	var netErr net.Error
+	switch {
+	case
+		errors.Is(err, libp2pnetwork.ErrReset), // what both yamux and QUIC streams return
+		errors.Is(err, io.ErrUnexpectedEOF),    // the stream closed in the middle of a message
+		errors.Is(err, os.ErrDeadlineExceeded):
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	default:
+		return false
+	}
+}
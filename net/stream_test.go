@@ -0,0 +1,68 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteStreamBytes(t *testing.T) {
+	msg := []byte("testmessage")
+	var stream bytes.Buffer
+	require.NoError(t, writeStreamBytes(&stream, msg))
+	require.Equal(t, uint32(len(msg)), binary.LittleEndian.Uint32(stream.Bytes()))
+
+	read, err := readStreamBytes(&stream, maxMessageSize)
+	require.NoError(t, err)
+	require.Equal(t, msg, read)
+}
+
+func TestReadStreamBytes_errors(t *testing.T) {
+	// the stream closed before any bytes were received
+	_, err := readStreamBytes(bytes.NewReader(nil), maxMessageSize)
+	require.ErrorIs(t, err, io.EOF)
+
+	// truncated length and body
+	for _, data := range [][]byte{{0x1}, {0x1, 0, 0, 0}} {
+		_, err = readStreamBytes(bytes.NewReader(data), maxMessageSize)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	}
+
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, maxMessageSize+1)
+	_, err = readStreamBytes(bytes.NewReader(buf), maxMessageSize)
+	require.ErrorIs(t, err, errMessageTooLarge)
+	require.False(t, isTransportError(err))
+
+	_, err = readStreamBytes(nil, maxMessageSize)
+	require.ErrorIs(t, err, errNilStream)
+}
+
+func TestIsTransportError(t *testing.T) {
+	for _, err := range []error{
+		libp2pnetwork.ErrReset,
+		fmt.Errorf("read: %w", libp2pnetwork.ErrReset),
+		io.ErrUnexpectedEOF,
+		os.ErrDeadlineExceeded,
+	} {
+		require.True(t, isTransportError(err), err)
+	}
+
+	for _, err := range []error{
+		io.EOF,
+		errMessageTooLarge,
+		errors.New("failed to decode message"),
+	} {
+		require.False(t, isTransportError(err), err)
+	}
+}
@@ -4,6 +4,9 @@
 package net
 
 import (
+	"io"
+	"sync"
+
 	libp2pnetwork "github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 
@@ -40,7 +43,87 @@ type RelayHandler interface {
 }
 
 type swap struct {
-	swapState    SwapState
-	stream       libp2pnetwork.Stream
+	swapState SwapState
+	stream    libp2pnetwork.Stream
+	// the protocol version of the swap messages, negotiated in the handshake
+	// before the stream opened
+	version uint16
+	// encoding of the messages that we send on the stream, which is the
+	// encoding of the first message of the stream
+	encoding message.Encoding
+	// resume is set if both peers resume the swap after its stream drops
+	resume       bool
 	streamClosed bool
+
+	// the other peer of the swap. Only it can resume the swap on a new stream.
+	peer peer.ID
+	// opener is set if we opened the stream, in which case we also open the
+	// new stream when resuming the swap
+	opener bool
+	// resumed is closed when the stream is replaced by a resumed stream
+	resumed chan struct{}
+
+	// mu protects the fields below, which tell what to send again when the
+	// swap resumes
+	mu sync.Mutex
+	// the number of swap messages that we sent and received on the streams
+	// of the swap
+	sent     uint32
+	received uint32
+	// lastSent is the last swap message that we sent
+	lastSent Message
+}
+
+func newSwap(s SwapState, stream libp2pnetwork.Stream, p peerProtocol, opener bool) *swap {
+	return &swap{
+		swapState: s,
+		stream:    stream,
+		version:   p.version,
+		encoding:  p.encoding,
+		resume:    p.resume,
+		peer:      stream.Conn().RemotePeer(),
+		opener:    opener,
+		resumed:   make(chan struct{}),
+	}
+}
+
+// messageSent records a swap message that we send to the peer.
+func (s *swap) messageSent(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent++
+	s.lastSent = msg
+}
+
+// messageReceived records a swap message that we received from the peer.
+func (s *swap) messageReceived() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received++
+}
+
+// resumeMessage returns the ResumeSwap that we send to resume the swap.
+func (s *swap) resumeMessage(id types.Hash) *message.ResumeSwap {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &message.ResumeSwap{OfferID: id, Received: s.received}
+}
+
+// replayLastMessage sends our last swap message on the stream again, if the
+// peer didn't receive it. Once the keys are exchanged, the only swap messages
+// are the taker's NotifyETHLocked and an AbortSwap from either peer, which is
+// sent last, so the last message is the only one that the peer still needs: a
+// peer that aborted exits the swap whatever it sent before.
+func (s *swap) replayLastMessage(stream io.Writer, peerReceived uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if peerReceived >= s.sent || s.lastSent == nil {
+		return nil
+	}
+
+	if missed := s.sent - peerReceived; missed > 1 {
+		log.Warnf("peer %s missed %d swap messages, only the last one is sent again", s.peer, missed)
+	}
+
+	return writeStreamMessage(stream, s.lastSent, s.encoding, s.peer)
 }
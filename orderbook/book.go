@@ -0,0 +1,511 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package orderbook keeps a persistent book of the offers of remote peers,
+// which is refreshed in the background by discovering and querying makers, and
+// by the offer announcements of makers. The book also keeps the liveness history
+// of the makers it queries.
+package orderbook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/net/message"
+)
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	defaultMaxAge          = 30 * time.Minute
+	defaultSearchTime      = 12 * time.Second
+
+	// maxLivenessChecks is the number of checks kept per maker, 4 hours worth
+	// at the default refresh interval
+	maxLivenessChecks = 48
+)
+
+var log = logging.Logger("orderbook")
+
+// Entry is an offer of a remote peer in the order book.
+type Entry struct {
+	PeerID peer.ID      `json:"peerID" validate:"required"`
+	Offer  *types.Offer `json:"offer" validate:"required"`
+	// FirstSeen and LastSeen are the times of the first and the latest query
+	// responses or announcements of the peer that had the offer.
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// LivenessCheck is the result of querying a maker during a refresh.
+type LivenessCheck struct {
+	Time   time.Time `json:"time"`
+	Online bool      `json:"online"`
+}
+
+// Maker is the liveness history of a maker that the book queried.
+type Maker struct {
+	PeerID peer.ID `json:"peerID"`
+	// FirstSeen is when the maker was first discovered, and LastSeen when it
+	// last answered a query. LastSeen is zero if it never did.
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// Checks are the latest checks of the maker, oldest first.
+	Checks []LivenessCheck `json:"checks"`
+}
+
+// Online returns whether the maker answered the latest check.
+func (m *Maker) Online() bool {
+	return len(m.Checks) > 0 && m.Checks[len(m.Checks)-1].Online
+}
+
+// AssetSummary summarizes the offers for an asset on a chain.
+type AssetSummary struct {
+	ChainID  uint64
+	EthAsset types.EthAsset
+	Offers   int
+	Makers   int
+	// TotalXMR is the sum of the maximum amounts of the offers, and
+	// TotalEthAsset the same in standard units of the ETH asset, at the
+	// exchange rate of each offer.
+	TotalXMR      *apd.Decimal
+	TotalEthAsset *apd.Decimal
+}
+
+// Net contains the network functions that the order book needs. It is
+// implemented by *net.Host.
+type Net interface {
+	Discover(provides string, searchTime time.Duration) ([]peer.ID, error)
+	Query(who peer.ID) (*message.QueryResponse, error)
+	SubscribeOffers(chainID uint64, asset types.EthAsset) <-chan *message.OfferAnnouncement
+}
+
+// Database persists the order book, so that it is available right after a
+// restart.
+type Database interface {
+	PutOrderBookEntry(e *Entry) error
+	DeleteOrderBookEntry(peerID peer.ID, offerID types.Hash) error
+	GetAllOrderBookEntries() ([]*Entry, error)
+}
+
+// Config contains the configuration of a Book.
+type Config struct {
+	Net Net
+	DB  Database
+	// RefreshInterval is the time between refreshes, 5 minutes by default.
+	RefreshInterval time.Duration
+	// MaxAge is how long an offer stays in the book after it was last seen,
+	// 30 minutes by default.
+	MaxAge time.Duration
+	// SearchTime is the time that each refresh spends discovering makers, 12
+	// seconds by default.
+	SearchTime time.Duration
+}
+
+type entryKey struct {
+	peerID  peer.ID
+	offerID types.Hash
+}
+
+type topicKey struct {
+	chainID uint64
+	asset   types.EthAsset
+}
+
+// Book is the order book of the offers of remote peers.
+type Book struct {
+	ctx context.Context
+	cfg Config
+	wg  sync.WaitGroup
+
+	mu      sync.RWMutex
+	entries map[entryKey]*Entry
+	makers  map[peer.ID]*Maker
+
+	subMu      sync.Mutex
+	subscribed map[topicKey]struct{}
+}
+
+// NewBook returns a Book with the entries that were stored when swapd last
+// stopped, without the stale ones. Call Start to refresh it in the background.
+func NewBook(ctx context.Context, cfg *Config) (*Book, error) {
+	c := *cfg
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = defaultRefreshInterval
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = defaultMaxAge
+	}
+	if c.SearchTime == 0 {
+		c.SearchTime = defaultSearchTime
+	}
+
+	stored, err := c.DB.GetAllOrderBookEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Book{
+		ctx:        ctx,
+		cfg:        c,
+		entries:    make(map[entryKey]*Entry, len(stored)),
+		makers:     make(map[peer.ID]*Maker),
+		subscribed: make(map[topicKey]struct{}),
+	}
+	for _, e := range stored {
+		b.entries[entryKey{e.PeerID, e.Offer.ID}] = e
+	}
+
+	b.evict(time.Now())
+	if len(b.entries) > 0 {
+		log.Infof("loaded %d offers into the order book", len(b.entries))
+	}
+
+	return b, nil
+}
+
+// Start refreshes the book in the background until the context passed to
+// NewBook is cancelled, and subscribes to the announcements of ETH offers on the
+// primary chain.
+func (b *Book) Start() {
+	b.subscribe(0, types.EthAssetETH)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.run()
+	}()
+}
+
+// Wait blocks until the background routine of the Book has exited, after its
+// context is cancelled. The database must not be closed before.
+func (b *Book) Wait() {
+	b.wg.Wait()
+}
+
+func (b *Book) run() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		b.Refresh()
+		timer.Reset(b.cfg.RefreshInterval)
+	}
+}
+
+// Refresh discovers makers, queries each of them, and the makers that were
+// queried before, and updates the book with their offers. Stale entries are
+// evicted.
+func (b *Book) Refresh() {
+	peerIDs, err := b.cfg.Net.Discover(string(coins.ProvidesXMR), b.cfg.SearchTime)
+	if err != nil {
+		log.Warnf("failed to discover makers: %s", err)
+	}
+
+	for _, peerID := range b.withKnownMakers(peerIDs) {
+		if b.ctx.Err() != nil {
+			return
+		}
+
+		resp, err := b.cfg.Net.Query(peerID)
+		b.recordCheck(peerID, err == nil, time.Now())
+		if err != nil {
+			log.Debugf("failed to query peer ID %s: %s", peerID, err)
+			continue
+		}
+		b.Update(peerID, resp.Offers)
+	}
+
+	b.evict(time.Now())
+}
+
+// withKnownMakers adds the makers with a liveness history to the discovered
+// peer IDs, so that makers that went offline are checked too.
+func (b *Book) withKnownMakers(discovered []peer.ID) []peer.ID {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	peerIDs := append([]peer.ID{}, discovered...)
+	seen := make(map[peer.ID]struct{}, len(discovered))
+	for _, id := range discovered {
+		seen[id] = struct{}{}
+	}
+	for id := range b.makers {
+		if _, ok := seen[id]; !ok {
+			peerIDs = append(peerIDs, id)
+		}
+	}
+
+	return peerIDs
+}
+
+// recordCheck adds a check to the liveness history of the maker.
+func (b *Book) recordCheck(peerID peer.ID, online bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m, ok := b.makers[peerID]
+	if !ok {
+		m = &Maker{PeerID: peerID, FirstSeen: now}
+		b.makers[peerID] = m
+	}
+	if online {
+		m.LastSeen = now
+	}
+
+	m.Checks = append(m.Checks, LivenessCheck{Time: now, Online: online})
+	if len(m.Checks) > maxLivenessChecks {
+		m.Checks = append([]LivenessCheck{}, m.Checks[len(m.Checks)-maxLivenessChecks:]...)
+	}
+}
+
+// Update records the offers of a query response of the peer. Offers of the
+// peer that are not in the response were taken or withdrawn, and are removed.
+func (b *Book) Update(peerID peer.ID, offers []*types.Offer) {
+	now := time.Now()
+	current := make(map[types.Hash]struct{}, len(offers))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, o := range offers {
+		current[o.ID] = struct{}{}
+		key := entryKey{peerID, o.ID}
+
+		e, ok := b.entries[key]
+		if !ok {
+			e = &Entry{PeerID: peerID, Offer: o, FirstSeen: now}
+			b.entries[key] = e
+		}
+		e.LastSeen = now
+
+		if err := b.cfg.DB.PutOrderBookEntry(e); err != nil {
+			log.Warnf("failed to store offer %s of peer ID %s: %s", o.ID, peerID, err)
+		}
+	}
+
+	for key := range b.entries {
+		if key.peerID != peerID {
+			continue
+		}
+		if _, ok := current[key.offerID]; !ok {
+			b.remove(key)
+		}
+	}
+}
+
+// subscribe merges the announcements of offers for the asset into the book,
+// unless they already are.
+func (b *Book) subscribe(chainID uint64, asset types.EthAsset) {
+	b.subMu.Lock()
+	defer b.subMu.Unlock()
+
+	key := topicKey{chainID, asset}
+	if _, ok := b.subscribed[key]; ok {
+		return
+	}
+	b.subscribed[key] = struct{}{}
+
+	ch := b.cfg.Net.SubscribeOffers(chainID, asset)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for {
+			select {
+			case <-b.ctx.Done():
+				return
+			case m := <-ch:
+				b.Merge(m)
+			}
+		}
+	}()
+}
+
+// Merge applies an offer announcement of a maker to the book. Unlike Update,
+// it leaves the other offers of the maker alone.
+func (b *Book) Merge(m *message.OfferAnnouncement) {
+	key := entryKey{m.Maker, m.OfferID}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if m.Action == message.OfferWithdrawn {
+		if _, ok := b.entries[key]; ok {
+			b.remove(key)
+		}
+		return
+	}
+
+	now := time.Now()
+	e, ok := b.entries[key]
+	if !ok {
+		e = &Entry{PeerID: m.Maker, Offer: m.Offer, FirstSeen: now}
+		b.entries[key] = e
+	}
+	e.LastSeen = now
+
+	if err := b.cfg.DB.PutOrderBookEntry(e); err != nil {
+		log.Warnf("failed to store offer %s of peer ID %s: %s", m.OfferID, m.Maker, err)
+	}
+}
+
+// evict removes the entries that were not seen within the max age, and
+// forgets the makers that did not answer a query within the max age.
+func (b *Book) evict(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key, e := range b.entries {
+		if now.Sub(e.LastSeen) >= b.cfg.MaxAge {
+			b.remove(key)
+		}
+	}
+
+	for id, m := range b.makers {
+		lastSeen := m.LastSeen
+		if lastSeen.IsZero() {
+			lastSeen = m.FirstSeen
+		}
+		if now.Sub(lastSeen) >= b.cfg.MaxAge {
+			delete(b.makers, id)
+		}
+	}
+}
+
+// remove deletes an entry from the book and the database. The caller must
+// hold the lock.
+func (b *Book) remove(key entryKey) {
+	delete(b.entries, key)
+	if err := b.cfg.DB.DeleteOrderBookEntry(key.peerID, key.offerID); err != nil {
+		log.Warnf("failed to delete offer %s of peer ID %s: %s", key.offerID, key.peerID, err)
+	}
+}
+
+// Offers returns the offers for the asset on the chain with the given ID, zero
+// being the primary chain, sorted by exchange rate with the best rate for a
+// taker first. Offers with the same rate are sorted by when they were last
+// seen, most recent first. From then on, the announcements of offers for the
+// asset are merged into the book.
+func (b *Book) Offers(chainID uint64, asset types.EthAsset) []*Entry {
+	b.subscribe(chainID, asset)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var offers []*Entry
+	for _, e := range b.entries {
+		if e.Offer.ChainID == chainID && e.Offer.EthAsset == asset {
+			c := *e
+			offers = append(offers, &c)
+		}
+	}
+
+	sort.Slice(offers, func(i, j int) bool {
+		cmp := offers[i].Offer.ExchangeRate.Decimal().Cmp(offers[j].Offer.ExchangeRate.Decimal())
+		if cmp != 0 {
+			return cmp < 0
+		}
+		return offers[i].LastSeen.After(offers[j].LastSeen)
+	})
+
+	return offers
+}
+
+// Len returns the number of offers in the book.
+func (b *Book) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.entries)
+}
+
+// Makers returns the liveness history of the makers that the book queried
+// within the max age, most recently seen first. The history is not persisted,
+// so it starts over with every new Book.
+func (b *Book) Makers() []*Maker {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	makers := make([]*Maker, 0, len(b.makers))
+	for _, m := range b.makers {
+		c := *m
+		c.Checks = append([]LivenessCheck{}, m.Checks...)
+		makers = append(makers, &c)
+	}
+
+	sort.Slice(makers, func(i, j int) bool {
+		if !makers[i].LastSeen.Equal(makers[j].LastSeen) {
+			return makers[i].LastSeen.After(makers[j].LastSeen)
+		}
+		return makers[i].PeerID < makers[j].PeerID
+	})
+
+	return makers
+}
+
+// Summaries returns a summary of the offers for each asset in the book,
+// sorted by chain ID and asset.
+func (b *Book) Summaries() ([]*AssetSummary, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	summaries := make(map[topicKey]*AssetSummary)
+	makers := make(map[topicKey]map[peer.ID]struct{})
+	for _, e := range b.entries {
+		key := topicKey{e.Offer.ChainID, e.Offer.EthAsset}
+		s, ok := summaries[key]
+		if !ok {
+			s = &AssetSummary{
+				ChainID:       key.chainID,
+				EthAsset:      key.asset,
+				TotalXMR:      new(apd.Decimal),
+				TotalEthAsset: new(apd.Decimal),
+			}
+			summaries[key] = s
+			makers[key] = make(map[peer.ID]struct{})
+		}
+
+		s.Offers++
+		makers[key][e.PeerID] = struct{}{}
+
+		ethAssetAmount := new(apd.Decimal)
+		_, err := coins.DecimalCtx().Mul(ethAssetAmount, e.Offer.MaxAmount, e.Offer.ExchangeRate.Decimal())
+		if err != nil {
+			return nil, err
+		}
+		if _, err = coins.DecimalCtx().Add(s.TotalXMR, s.TotalXMR, e.Offer.MaxAmount); err != nil {
+			return nil, err
+		}
+		if _, err = coins.DecimalCtx().Add(s.TotalEthAsset, s.TotalEthAsset, ethAssetAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	sorted := make([]*AssetSummary, 0, len(summaries))
+	for key, s := range summaries {
+		s.Makers = len(makers[key])
+		sorted = append(sorted, s)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].ChainID != sorted[j].ChainID {
+			return sorted[i].ChainID < sorted[j].ChainID
+		}
+		// ETH, the zero address, comes first
+		return sorted[i].EthAsset.Address().Cmp(sorted[j].EthAsset.Address()) < 0
+	})
+
+	return sorted, nil
+}
@@ -0,0 +1,344 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/net/message"
+)
+
+var (
+	alice, _   = peer.Decode("12D3KooWAYn1T8Lu122Pav4zAogjpeU61usLTNZpLRNh9gCqY6X2")
+	bob, _     = peer.Decode("12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5")
+	charlie, _ = peer.Decode("12D3KooWC547RfLcveQi1vBxACjnT6Uv15V11ortDTuxRWuhubGv")
+)
+
+type memDB struct {
+	mu      sync.Mutex
+	entries map[entryKey]Entry
+}
+
+func newMemDB() *memDB {
+	return &memDB{entries: make(map[entryKey]Entry)}
+}
+
+func (db *memDB) PutOrderBookEntry(e *Entry) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.entries[entryKey{e.PeerID, e.Offer.ID}] = *e
+	return nil
+}
+
+func (db *memDB) DeleteOrderBookEntry(peerID peer.ID, offerID types.Hash) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.entries, entryKey{peerID, offerID})
+	return nil
+}
+
+func (db *memDB) GetAllOrderBookEntries() ([]*Entry, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var entries []*Entry
+	for _, e := range db.entries {
+		e := e
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+func (db *memDB) len() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return len(db.entries)
+}
+
+// mockNet discovers alice and bob unless other peers are set, answers queries
+// with the offers of each peer, and fails queries of other peers.
+type mockNet struct {
+	mu         sync.Mutex
+	discovered []peer.ID
+	offers     map[peer.ID][]*types.Offer
+	subs       map[topicKey]chan *message.OfferAnnouncement
+}
+
+func (n *mockNet) Discover(_ string, _ time.Duration) ([]peer.ID, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]peer.ID{}, n.discovered...), nil
+}
+
+func (n *mockNet) Query(who peer.ID) (*message.QueryResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	offers, ok := n.offers[who]
+	if !ok {
+		return nil, errors.New("peer is offline")
+	}
+	return &message.QueryResponse{Offers: offers}, nil
+}
+
+func (n *mockNet) SubscribeOffers(chainID uint64, asset types.EthAsset) <-chan *message.OfferAnnouncement {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ch := make(chan *message.OfferAnnouncement)
+	n.subs[topicKey{chainID, asset}] = ch
+	return ch
+}
+
+// announce sends the announcement to the subscriber of the offer's topic, if
+// there is one.
+func (n *mockNet) announce(chainID uint64, asset types.EthAsset, m *message.OfferAnnouncement) bool {
+	n.mu.Lock()
+	ch, ok := n.subs[topicKey{chainID, asset}]
+	n.mu.Unlock()
+	if ok {
+		ch <- m
+	}
+	return ok
+}
+
+func newMockNet() *mockNet {
+	return &mockNet{
+		discovered: []peer.ID{alice, bob},
+		offers:     make(map[peer.ID][]*types.Offer),
+		subs:       make(map[topicKey]chan *message.OfferAnnouncement),
+	}
+}
+
+func (n *mockNet) setOffers(peerID peer.ID, offers ...*types.Offer) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if offers == nil {
+		delete(n.offers, peerID)
+		return
+	}
+	n.offers[peerID] = offers
+}
+
+func newTestOffer(rate string, asset types.EthAsset) *types.Offer {
+	return types.NewOffer(
+		coins.ProvidesXMR,
+		coins.StrToDecimal("1"),
+		coins.StrToDecimal("10"),
+		coins.ToExchangeRate(coins.StrToDecimal(rate)),
+		asset,
+	)
+}
+
+func TestBook_Refresh(t *testing.T) {
+	token := types.EthAsset(ethcommon.Address{0x1})
+	aliceOffer := newTestOffer("0.05", types.EthAssetETH)
+	bobOffer := newTestOffer("0.04", types.EthAssetETH)
+	bobTokenOffer := newTestOffer("100", token)
+
+	n := newMockNet()
+	n.setOffers(alice, aliceOffer)
+	n.setOffers(bob, bobOffer, bobTokenOffer)
+
+	db := newMemDB()
+	b, err := NewBook(context.Background(), &Config{Net: n, DB: db})
+	require.NoError(t, err)
+
+	b.Refresh()
+	require.Equal(t, 3, b.Len())
+	require.Equal(t, 3, db.len())
+
+	offers := b.Offers(0, types.EthAssetETH)
+	require.Len(t, offers, 2)
+	require.Equal(t, bobOffer.ID, offers[0].Offer.ID) // lower rate first
+	require.Equal(t, bob, offers[0].PeerID)
+	require.Equal(t, aliceOffer.ID, offers[1].Offer.ID)
+	require.Len(t, b.Offers(0, token), 1)
+	require.Empty(t, b.Offers(10, types.EthAssetETH))
+
+	// offers that a peer no longer has are removed, but offers of peers that
+	// are offline stay until they are stale
+	firstSeen := offers[0].FirstSeen
+	n.setOffers(bob, bobOffer)
+	n.setOffers(alice)
+	b.Refresh()
+	require.Equal(t, 2, b.Len())
+	require.Equal(t, 2, db.len())
+	require.Empty(t, b.Offers(0, token))
+
+	offers = b.Offers(0, types.EthAssetETH)
+	require.Equal(t, firstSeen, offers[0].FirstSeen)
+	require.True(t, offers[0].LastSeen.After(offers[1].LastSeen))
+
+	b.cfg.MaxAge = time.Since(offers[1].LastSeen)
+	b.Refresh()
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, bob, b.Offers(0, types.EthAssetETH)[0].PeerID)
+
+	// the book is loaded from the database, without stale entries
+	b, err = NewBook(context.Background(), &Config{Net: n, DB: db})
+	require.NoError(t, err)
+	require.Equal(t, 1, b.Len())
+
+	_, err = NewBook(context.Background(), &Config{Net: n, DB: db, MaxAge: time.Nanosecond})
+	require.NoError(t, err)
+	require.Zero(t, db.len())
+}
+
+func TestBook_Makers(t *testing.T) {
+	n := newMockNet()
+	n.discovered = []peer.ID{alice, charlie}
+	n.setOffers(alice, newTestOffer("0.05", types.EthAssetETH))
+
+	b, err := NewBook(context.Background(), &Config{Net: n, DB: newMemDB()})
+	require.NoError(t, err)
+
+	b.Refresh()
+	makers := b.Makers()
+	require.Len(t, makers, 2)
+	require.Equal(t, alice, makers[0].PeerID)
+	require.True(t, makers[0].Online())
+	require.Equal(t, makers[0].FirstSeen, makers[0].LastSeen)
+	require.Equal(t, charlie, makers[1].PeerID) // never answered
+	require.False(t, makers[1].Online())
+	require.True(t, makers[1].LastSeen.IsZero())
+
+	// makers that are no longer discovered are still checked
+	n.discovered = nil
+	n.setOffers(alice)
+	b.Refresh()
+	makers = b.Makers()
+	require.Len(t, makers, 2)
+	require.Equal(t, []bool{true, false}, []bool{makers[0].Checks[0].Online, makers[0].Checks[1].Online})
+	require.False(t, makers[0].Online())
+	require.Equal(t, 1, b.Len()) // until they are stale, offers of offline makers stay
+
+	// the history is limited
+	for i := 0; i < maxLivenessChecks; i++ {
+		b.Refresh()
+	}
+	makers = b.Makers()
+	require.Len(t, makers[0].Checks, maxLivenessChecks)
+	require.False(t, makers[0].Checks[0].Online)
+
+	// and makers are forgotten once they were offline for the max age. Charlie,
+	// who never answered, was discovered after alice was last seen, so the max
+	// age counts from then.
+	require.Equal(t, charlie, makers[1].PeerID)
+	require.True(t, makers[1].FirstSeen.After(makers[0].LastSeen))
+	b.cfg.MaxAge = time.Since(makers[1].FirstSeen)
+	b.Refresh()
+	require.Empty(t, b.Makers())
+	require.Zero(t, b.Len())
+}
+
+func TestBook_Summaries(t *testing.T) {
+	token := types.EthAsset(ethcommon.Address{0x1})
+	n := newMockNet()
+	n.setOffers(alice, newTestOffer("0.05", types.EthAssetETH), newTestOffer("100", token))
+	n.setOffers(bob, newTestOffer("0.04", types.EthAssetETH))
+
+	b, err := NewBook(context.Background(), &Config{Net: n, DB: newMemDB()})
+	require.NoError(t, err)
+
+	summaries, err := b.Summaries()
+	require.NoError(t, err)
+	require.Empty(t, summaries)
+
+	b.Refresh()
+	summaries, err = b.Summaries()
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+
+	eth := summaries[0]
+	require.Equal(t, types.EthAssetETH, eth.EthAsset)
+	require.Equal(t, 2, eth.Offers)
+	require.Equal(t, 2, eth.Makers)
+	require.Equal(t, "20", eth.TotalXMR.Text('f'))       // 10 XMR per offer
+	require.Equal(t, "0.9", eth.TotalEthAsset.Text('f')) // 10 * 0.05 + 10 * 0.04
+
+	require.Equal(t, token, summaries[1].EthAsset)
+	require.Equal(t, 1, summaries[1].Makers)
+	require.Equal(t, "1000", summaries[1].TotalEthAsset.Text('f'))
+}
+
+func TestBook_Start(t *testing.T) {
+	n := newMockNet()
+	n.setOffers(alice, newTestOffer("0.05", types.EthAssetETH))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b, err := NewBook(ctx, &Config{Net: n, DB: newMemDB(), RefreshInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	b.Start()
+
+	n.setOffers(bob, newTestOffer("0.06", types.EthAssetETH))
+	require.Eventually(t, func() bool { return b.Len() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	b.Wait()
+}
+
+func TestBook_Merge(t *testing.T) {
+	token := types.EthAsset(ethcommon.Address{0x1})
+	aliceOffer := newTestOffer("0.05", types.EthAssetETH)
+	bobOffer := newTestOffer("0.04", types.EthAssetETH)
+	bobTokenOffer := newTestOffer("100", token)
+
+	n := newMockNet()
+	n.setOffers(bob, bobOffer)
+	db := newMemDB()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b, err := NewBook(ctx, &Config{Net: n, DB: db})
+	require.NoError(t, err)
+	b.Start()
+	require.Eventually(t, func() bool { return b.Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// announcements don't remove the other offers of the maker
+	require.True(t, n.announce(0, types.EthAssetETH, &message.OfferAnnouncement{
+		Action:  message.OfferNew,
+		Maker:   alice,
+		OfferID: aliceOffer.ID,
+		Offer:   aliceOffer,
+	}))
+	b.Merge(&message.OfferAnnouncement{
+		Action:  message.OfferNew,
+		Maker:   bob,
+		OfferID: bobTokenOffer.ID,
+		Offer:   bobTokenOffer,
+	})
+	require.Eventually(t, func() bool { return b.Len() == 3 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 3, db.len())
+
+	// token topics are subscribed to once their offers are looked up
+	require.False(t, n.announce(0, token, &message.OfferAnnouncement{}))
+	require.Len(t, b.Offers(0, token), 1)
+
+	withdrawn := &message.OfferAnnouncement{Action: message.OfferWithdrawn, Maker: bob, OfferID: bobTokenOffer.ID}
+	require.True(t, n.announce(0, token, withdrawn))
+	require.Eventually(t, func() bool { return b.Len() == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, b.Offers(0, token))
+
+	// updates refresh when the offer was last seen
+	lastSeen := b.Offers(0, types.EthAssetETH)[1].LastSeen
+	require.True(t, n.announce(0, types.EthAssetETH, &message.OfferAnnouncement{
+		Action:  message.OfferUpdated,
+		Maker:   alice,
+		OfferID: aliceOffer.ID,
+		Offer:   aliceOffer,
+	}))
+	require.Eventually(t, func() bool {
+		return b.Offers(0, types.EthAssetETH)[1].LastSeen.After(lastSeen)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	b.Wait()
+}
@@ -0,0 +1,262 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+)
+
+const (
+	// DefaultMaxAge is the default age after which a price is stale. It is a
+	// little over the 24 hour heartbeat of chainlink's XMR / USD feeds, which
+	// only update more often when the price moves.
+	DefaultMaxAge = 26 * time.Hour
+
+	// maxClockSkew is how far in the future a price's update time can be
+	maxClockSkew  = 5 * time.Minute
+	sourceTimeout = 15 * time.Second
+)
+
+var (
+	errNoSources        = errors.New("no price sources configured")
+	errNotEnoughPrices  = errors.New("not enough usable prices")
+	errPriceDeviation   = errors.New("price sources disagree")
+	errNonPositivePrice = errors.New("price is not positive")
+	errPriceInFuture    = errors.New("price update time is in the future")
+
+	// defaultMaxDeviation is 5%
+	defaultMaxDeviation = apd.New(5, -2)
+)
+
+// AggregatorConfig configures an Aggregator.
+type AggregatorConfig struct {
+	Sources []Source
+	// MaxAge defaults to DefaultMaxAge.
+	MaxAge time.Duration
+	// MaxDeviation is the maximum relative difference, as a fraction, between
+	// the price of any usable source and the median price. It defaults to
+	// 0.05 (5%).
+	MaxDeviation *apd.Decimal
+	// MinSources is the number of usable prices required, default 1.
+	MinSources int
+}
+
+// Aggregator combines the prices of several sources into their median,
+// after rejecting stale prices. It fails if the remaining prices disagree by
+// more than the maximum deviation.
+type Aggregator struct {
+	cfg AggregatorConfig
+}
+
+// SourcePrice is the result of querying one source.
+type SourcePrice struct {
+	Source    string       `json:"source" validate:"required"`
+	Price     *apd.Decimal `json:"price,omitempty"`
+	UpdatedAt *time.Time   `json:"updatedAt,omitempty"`
+	// Used is true if the price is part of the median
+	Used bool `json:"used"`
+	// Error is why the source failed or its price was rejected
+	Error string `json:"error,omitempty"`
+}
+
+// AggregatedPrice is the median USD price of an asset over the usable
+// sources, along with the result of every source.
+type AggregatedPrice struct {
+	Symbol string
+	Price  *apd.Decimal
+	// UpdatedAt is the oldest update time of the prices used
+	UpdatedAt time.Time
+	Sources   []*SourcePrice
+}
+
+// NewAggregator returns an Aggregator for the given sources.
+func NewAggregator(cfg *AggregatorConfig) (*Aggregator, error) {
+	if len(cfg.Sources) == 0 {
+		return nil, errNoSources
+	}
+
+	c := *cfg
+	if c.MaxAge == 0 {
+		c.MaxAge = DefaultMaxAge
+	}
+	if c.MaxDeviation == nil {
+		c.MaxDeviation = defaultMaxDeviation
+	}
+	if c.MinSources == 0 {
+		c.MinSources = 1
+	}
+	if c.MinSources > len(c.Sources) {
+		return nil, fmt.Errorf("%d sources required, but only %d configured", c.MinSources, len(c.Sources))
+	}
+
+	return &Aggregator{cfg: c}, nil
+}
+
+// Price queries every source concurrently and returns the median of their
+// usable prices for the symbol.
+func (a *Aggregator) Price(ctx context.Context, symbol string) (*AggregatedPrice, error) {
+	symbol = strings.ToUpper(symbol)
+	results := a.query(ctx, symbol)
+	now := time.Now()
+
+	var used []*SourcePrice
+	for _, r := range results {
+		if r.Error != "" {
+			continue
+		}
+
+		switch age := now.Sub(*r.UpdatedAt); {
+		case r.Price.Sign() <= 0:
+			r.Error = errNonPositivePrice.Error()
+		case age > a.cfg.MaxAge:
+			r.Error = fmt.Sprintf("stale, last updated %s ago", age.Round(time.Second))
+		case age < -maxClockSkew:
+			r.Error = errPriceInFuture.Error()
+		default:
+			r.Used = true
+			used = append(used, r)
+		}
+	}
+
+	agg := &AggregatedPrice{
+		Symbol:  symbol,
+		Sources: results,
+	}
+
+	if len(used) < a.cfg.MinSources {
+		return agg, fmt.Errorf("%w for %s: %d of %d required (%s)",
+			errNotEnoughPrices, symbol, len(used), a.cfg.MinSources, summarizeErrors(results))
+	}
+
+	median, err := medianPrice(used)
+	if err != nil {
+		return agg, err
+	}
+
+	for _, r := range used {
+		deviation, err := relativeDifference(r.Price, median) //nolint:govet
+		if err != nil {
+			return agg, err
+		}
+
+		if deviation.Cmp(a.cfg.MaxDeviation) > 0 {
+			return agg, fmt.Errorf("%w for %s: %s price $%s is %s%% away from the median $%s",
+				errPriceDeviation, symbol, r.Source, r.Price, percent(deviation), median)
+		}
+	}
+
+	agg.Price = median
+	agg.UpdatedAt = *used[0].UpdatedAt
+	for _, r := range used[1:] {
+		if r.UpdatedAt.Before(agg.UpdatedAt) {
+			agg.UpdatedAt = *r.UpdatedAt
+		}
+	}
+
+	log.Debugf("%s / USD: $%s, median of %d source(s)", symbol, median, len(used))
+	return agg, nil
+}
+
+// query returns the result of each source, in the order of the sources.
+func (a *Aggregator) query(ctx context.Context, symbol string) []*SourcePrice {
+	ctx, cancel := context.WithTimeout(ctx, sourceTimeout)
+	defer cancel()
+
+	results := make([]*SourcePrice, len(a.cfg.Sources))
+	var wg sync.WaitGroup
+	for i, src := range a.cfg.Sources {
+		wg.Add(1)
+		go func(i int, src Source) {
+			defer wg.Done()
+
+			r := &SourcePrice{Source: src.Name()}
+			feed, err := src.Price(ctx, symbol)
+			if err != nil {
+				log.Debugf("price source %s failed for %s: %s", src.Name(), symbol, err)
+				r.Error = err.Error()
+			} else {
+				r.Price = feed.Price
+				r.UpdatedAt = &feed.UpdatedAt
+			}
+			results[i] = r
+		}(i, src)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// medianPrice returns the median of the prices, which is the mean of the two
+// middle prices when there is an even number of them.
+func medianPrice(prices []*SourcePrice) (*apd.Decimal, error) {
+	sorted := make([]*apd.Decimal, len(prices))
+	for i, p := range prices {
+		sorted[i] = p.Price
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(apd.Decimal).Set(sorted[mid]), nil
+	}
+
+	median := new(apd.Decimal)
+	if _, err := coins.DecimalCtx().Add(median, sorted[mid-1], sorted[mid]); err != nil {
+		return nil, err
+	}
+	if _, err := coins.DecimalCtx().Quo(median, median, apd.New(2, 0)); err != nil {
+		return nil, err
+	}
+	_, _ = median.Reduce(median)
+
+	return median, nil
+}
+
+// relativeDifference returns |price - median| / median.
+func relativeDifference(price *apd.Decimal, median *apd.Decimal) (*apd.Decimal, error) {
+	diff := new(apd.Decimal)
+	if _, err := coins.DecimalCtx().Sub(diff, price, median); err != nil {
+		return nil, err
+	}
+	diff.Abs(diff)
+
+	if _, err := coins.DecimalCtx().Quo(diff, diff, median); err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// percent formats a fraction as a percentage with 2 decimal places.
+func percent(fraction *apd.Decimal) string {
+	p := new(apd.Decimal)
+	_, _ = coins.DecimalCtx().Mul(p, fraction, apd.New(100, 0))
+	_, _ = coins.DecimalCtx().Quantize(p, p, -2)
+	return p.Text('f')
+}
+
+func summarizeErrors(results []*SourcePrice) string {
+	var errs []string
+	for _, r := range results {
+		if r.Error != "" {
+			errs = append(errs, fmt.Sprintf("%s: %s", r.Source, r.Error))
+		}
+	}
+	if len(errs) == 0 {
+		return "no errors"
+	}
+	return strings.Join(errs, "; ")
+}
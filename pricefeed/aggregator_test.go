@@ -0,0 +1,150 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSource struct{}
+
+func (failingSource) Name() string { return "failing" }
+
+func (failingSource) Price(_ context.Context, _ string) (*PriceFeed, error) {
+	return nil, errors.New("unavailable")
+}
+
+func staticXMR(name string, price string, updatedAt time.Time) Source {
+	p, _, err := apd.NewFromString(price)
+	if err != nil {
+		panic(err)
+	}
+	return NewStaticSource(name, map[string]*apd.Decimal{SymbolXMR: p}, updatedAt)
+}
+
+func TestAggregator_median(t *testing.T) {
+	now := time.Now()
+	older := now.Add(-time.Hour)
+
+	a, err := NewAggregator(&AggregatorConfig{
+		Sources: []Source{
+			staticXMR("a", "150", now),
+			staticXMR("b", "152", older),
+			staticXMR("c", "151", now),
+			failingSource{},
+		},
+	})
+	require.NoError(t, err)
+
+	p, err := a.Price(context.Background(), "xmr")
+	require.NoError(t, err)
+	require.Equal(t, SymbolXMR, p.Symbol)
+	require.Equal(t, "151", p.Price.String())
+	require.True(t, older.Equal(p.UpdatedAt))
+
+	require.Len(t, p.Sources, 4)
+	require.True(t, p.Sources[0].Used)
+	require.False(t, p.Sources[3].Used)
+	require.Equal(t, "unavailable", p.Sources[3].Error)
+
+	// with an even number of prices, the median is the mean of the middle two
+	a, err = NewAggregator(&AggregatorConfig{
+		Sources: []Source{
+			staticXMR("a", "150", now),
+			staticXMR("b", "151", now),
+		},
+	})
+	require.NoError(t, err)
+
+	p, err = a.Price(context.Background(), SymbolXMR)
+	require.NoError(t, err)
+	require.Equal(t, "150.5", p.Price.String())
+}
+
+func TestAggregator_staleness(t *testing.T) {
+	now := time.Now()
+
+	a, err := NewAggregator(&AggregatorConfig{
+		Sources: []Source{
+			staticXMR("fresh", "150", now),
+			// would fail the deviation check if it wasn't stale
+			staticXMR("stale", "100", now.Add(-2*time.Hour)),
+			staticXMR("future", "100", now.Add(time.Hour)),
+		},
+		MaxAge: time.Hour,
+	})
+	require.NoError(t, err)
+
+	p, err := a.Price(context.Background(), SymbolXMR)
+	require.NoError(t, err)
+	require.Equal(t, "150", p.Price.String())
+	require.Contains(t, p.Sources[1].Error, "stale")
+	require.Equal(t, errPriceInFuture.Error(), p.Sources[2].Error)
+
+	// all prices stale
+	a, err = NewAggregator(&AggregatorConfig{
+		Sources: []Source{staticXMR("stale", "150", now.Add(-2*time.Hour))},
+		MaxAge:  time.Hour,
+	})
+	require.NoError(t, err)
+	_, err = a.Price(context.Background(), SymbolXMR)
+	require.ErrorIs(t, err, errNotEnoughPrices)
+}
+
+func TestAggregator_deviation(t *testing.T) {
+	now := time.Now()
+	sources := []Source{
+		staticXMR("a", "100", now),
+		staticXMR("b", "104", now),
+		staticXMR("c", "110", now),
+	}
+
+	// c is 5.77% above the median of 104
+	a, err := NewAggregator(&AggregatorConfig{Sources: sources})
+	require.NoError(t, err)
+	_, err = a.Price(context.Background(), SymbolXMR)
+	require.ErrorIs(t, err, errPriceDeviation)
+	require.ErrorContains(t, err, "c price $110 is 5.77% away")
+
+	a, err = NewAggregator(&AggregatorConfig{Sources: sources, MaxDeviation: apd.New(6, -2)})
+	require.NoError(t, err)
+	p, err := a.Price(context.Background(), SymbolXMR)
+	require.NoError(t, err)
+	require.Equal(t, "104", p.Price.String())
+}
+
+func TestAggregator_minSources(t *testing.T) {
+	now := time.Now()
+
+	_, err := NewAggregator(&AggregatorConfig{})
+	require.ErrorIs(t, err, errNoSources)
+
+	_, err = NewAggregator(&AggregatorConfig{
+		Sources:    []Source{staticXMR("a", "150", now)},
+		MinSources: 2,
+	})
+	require.Error(t, err)
+
+	a, err := NewAggregator(&AggregatorConfig{
+		Sources:    []Source{staticXMR("a", "150", now), failingSource{}},
+		MinSources: 2,
+	})
+	require.NoError(t, err)
+	p, err := a.Price(context.Background(), SymbolXMR)
+	require.ErrorIs(t, err, errNotEnoughPrices)
+	require.ErrorContains(t, err, "failing: unavailable")
+	require.Len(t, p.Sources, 2)
+
+	// the static source doesn't have an ETH price
+	a, err = NewAggregator(&AggregatorConfig{Sources: []Source{staticXMR("a", "150", now)}})
+	require.NoError(t, err)
+	_, err = a.Price(context.Background(), SymbolETH)
+	require.ErrorIs(t, err, errNotEnoughPrices)
+}
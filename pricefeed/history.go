@@ -0,0 +1,395 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+)
+
+var (
+	errNoPriceHistory     = errors.New("no price history available for the requested time")
+	errUnsupportedSymbol  = errors.New("unsupported price feed symbol")
+	errPriceHistoryFormat = errors.New("invalid price history entry")
+)
+
+// phaseOffset is the bit position of the phase ID inside a chainlink proxy
+// round ID. The lower 64 bits are the round ID of the phase's aggregator.
+const phaseOffset = 64
+
+// HistoricalPriceSource returns the USD price of an asset at a point in
+// time. Implementations must return the most recent price that was published
+// at or before the requested time.
+type HistoricalPriceSource interface {
+	// PriceAt returns the USD price of the asset with the given symbol ("ETH"
+	// or "XMR") at the given time.
+	PriceAt(ctx context.Context, symbol string, t time.Time) (*PriceFeed, error)
+}
+
+// latestRoundTTL is how long ChainlinkHistory reuses the latest round of a
+// feed before querying it again.
+const latestRoundTTL = time.Minute
+
+// ChainlinkHistory is a HistoricalPriceSource that walks back through the
+// rounds of the chainlink aggregators used by GetETHUSDPrice and
+// GetXMRUSDPrice. The client for the feed network is dialed once and
+// published rounds are cached, so pricing many swaps only queries the rounds
+// that haven't been seen before.
+type ChainlinkHistory struct {
+	ec    *ethclient.Client
+	proxy *netproxy.Proxy
+
+	mu      sync.Mutex
+	feedEC  *ethclient.Client // nil until first use
+	devMode bool              // ganache or hardhat, uses fake prices
+	feeds   map[string]*chainlinkFeed
+}
+
+// chainlinkFeed caches the static data and the rounds of one chainlink proxy.
+type chainlinkFeed struct {
+	proxy       *contracts.AggregatorV3Interface
+	description string
+	decimals    uint8
+
+	latest          *chainlinkRound
+	latestFetchedAt time.Time
+
+	// rounds is keyed by proxy round ID. Published rounds never change.
+	rounds map[string]*chainlinkRound
+}
+
+// chainlinkRound is the data of a single chainlink round that we use.
+type chainlinkRound struct {
+	roundID   *big.Int
+	answer    *big.Int
+	updatedAt *big.Int
+}
+
+var _ HistoricalPriceSource = (*ChainlinkHistory)(nil)
+
+// NewChainlinkHistory returns a new *ChainlinkHistory that uses the network of
+// the passed client to pick the price feed endpoint, the same way the
+// current price functions do. Other endpoints are dialed through the proxy,
+// which can be nil.
+func NewChainlinkHistory(ec *ethclient.Client, proxy *netproxy.Proxy) *ChainlinkHistory {
+	return &ChainlinkHistory{
+		ec:    ec,
+		proxy: proxy,
+		feeds: make(map[string]*chainlinkFeed),
+	}
+}
+
+// PriceAt returns the chainlink round for the symbol that was current at time t.
+func (h *ChainlinkHistory) PriceAt(ctx context.Context, symbol string, t time.Time) (*PriceFeed, error) {
+	symbol = strings.ToUpper(symbol)
+	feedAddress, ok := chainlinkFeeds[common.OpMainnetChainID][symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedSymbol, symbol)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.connect(ctx); err != nil {
+		return nil, err
+	}
+
+	if h.devMode {
+		return fakePriceFeed(symbol, t)
+	}
+
+	feed, err := h.getFeed(ctx, feedAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return feed.priceAt(ctx, t)
+}
+
+// connect sets the client used to query the price feeds. Mainnet and Sepolia
+// swapd instances use an optimism endpoint, since that is where the feeds
+// live. Must be called with the lock held.
+func (h *ChainlinkHistory) connect(ctx context.Context) error {
+	if h.feedEC != nil || h.devMode {
+		return nil
+	}
+
+	chainID, err := h.ec.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch chainID.Uint64() {
+	case common.OpMainnetChainID:
+		h.feedEC = h.ec
+	case common.MainnetChainID, common.SepoliaChainID:
+		h.feedEC, err = h.proxy.DialEthereum(ctx, getOptimismEndpoint())
+		if err != nil {
+			return err
+		}
+	case common.GanacheChainID, common.HardhatChainID:
+		h.devMode = true
+	default:
+		return errUnsupportedNetwork
+	}
+
+	return nil
+}
+
+// getFeed returns the cached feed for the address, fetching its static data
+// the first time. Must be called with the lock held.
+func (h *ChainlinkHistory) getFeed(ctx context.Context, feedAddress string) (*chainlinkFeed, error) {
+	if feed, ok := h.feeds[feedAddress]; ok {
+		return feed, nil
+	}
+
+	proxy, err := contracts.NewAggregatorV3Interface(ethcommon.HexToAddress(feedAddress), h.feedEC)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &bind.CallOpts{
+		Context: ctx,
+	}
+
+	decimals, err := proxy.Decimals(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	description, err := proxy.Description(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &chainlinkFeed{
+		proxy:       proxy,
+		description: description,
+		decimals:    decimals,
+		rounds:      make(map[string]*chainlinkRound),
+	}
+	h.feeds[feedAddress] = feed
+	return feed, nil
+}
+
+// priceAt returns the price of the last round that was updated at or before
+// time t.
+func (f *chainlinkFeed) priceAt(ctx context.Context, t time.Time) (*PriceFeed, error) {
+	opts := &bind.CallOpts{
+		Context: ctx,
+	}
+
+	if f.latest == nil || time.Since(f.latestFetchedAt) > latestRoundTTL {
+		latest, err := f.proxy.LatestRoundData(opts)
+		if err != nil {
+			return nil, err
+		}
+		f.latest = &chainlinkRound{
+			roundID:   latest.RoundId,
+			answer:    latest.Answer,
+			updatedAt: latest.UpdatedAt,
+		}
+		f.latestFetchedAt = time.Now()
+	}
+
+	getRound := func(roundID *big.Int) (*chainlinkRound, error) {
+		if round, ok := f.rounds[roundID.String()]; ok {
+			return round, nil
+		}
+
+		data, err := f.proxy.GetRoundData(opts, roundID)
+		if err != nil {
+			return nil, err
+		}
+
+		round := &chainlinkRound{
+			roundID:   roundID,
+			answer:    data.Answer,
+			updatedAt: data.UpdatedAt,
+		}
+		f.rounds[roundID.String()] = round
+		return round, nil
+	}
+
+	round, err := searchRounds(f.latest, t, getRound)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s", err, f.description, t)
+	}
+
+	price := apd.NewWithBigInt(new(apd.BigInt).SetMathBigInt(round.answer), -int32(f.decimals))
+	_, _ = price.Reduce(price)
+	feed := &PriceFeed{
+		Description: f.description,
+		Price:       price,
+		UpdatedAt:   time.Unix(round.updatedAt.Int64(), 0),
+	}
+
+	log.Debugf("%s at %s: $%s (%s)", f.description, t, feed.Price, feed.UpdatedAt)
+	return feed, nil
+}
+
+// searchRounds binary searches the rounds of the current phase of a chainlink
+// proxy for the last round that was updated at or before time t. The latest
+// round is passed in and getRound fetches any earlier round by proxy round ID.
+func searchRounds(
+	latest *chainlinkRound,
+	t time.Time,
+	getRound func(roundID *big.Int) (*chainlinkRound, error),
+) (*chainlinkRound, error) {
+	if latest.updatedAt.Int64() <= t.Unix() {
+		return latest, nil
+	}
+
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), phaseOffset), big.NewInt(1))
+	phaseBase := new(big.Int).AndNot(latest.roundID, mask)
+	latestAggRound := new(big.Int).And(latest.roundID, mask).Uint64()
+
+	// Aggregator rounds start at 1, so there is nothing before the latest
+	// round in this phase.
+	if latestAggRound <= 1 {
+		return nil, errNoPriceHistory
+	}
+
+	// Invariant: rounds above hi were updated after t. We are looking for the
+	// highest round at or below hi that was updated at or before t.
+	var (
+		lo    uint64 = 1
+		hi           = latestAggRound - 1
+		found *chainlinkRound
+	)
+	for lo <= hi && hi > 0 {
+		mid := lo + (hi-lo)/2
+		round, err := getRound(new(big.Int).Or(phaseBase, new(big.Int).SetUint64(mid)))
+		if err != nil || round.updatedAt == nil || round.updatedAt.Sign() == 0 {
+			// Rounds without data are treated as being too old
+			lo = mid + 1
+			continue
+		}
+
+		if round.updatedAt.Int64() <= t.Unix() {
+			found = round
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	if found == nil {
+		return nil, errNoPriceHistory
+	}
+
+	return found, nil
+}
+
+// PricePoint is a single entry of a FileHistory price file.
+type PricePoint struct {
+	Symbol string       `json:"symbol"`
+	Time   time.Time    `json:"time"`
+	Price  *apd.Decimal `json:"price"`
+}
+
+// FileHistory is a HistoricalPriceSource backed by a list of price points,
+// typically loaded from a JSON file. It is used to backfill valuations for
+// periods where on-chain price history is unavailable.
+type FileHistory struct {
+	points map[string][]*PricePoint // symbol -> points sorted by time
+}
+
+var _ HistoricalPriceSource = (*FileHistory)(nil)
+
+// NewFileHistory returns a *FileHistory from the given price points.
+func NewFileHistory(points []*PricePoint) (*FileHistory, error) {
+	h := &FileHistory{
+		points: make(map[string][]*PricePoint),
+	}
+
+	for _, p := range points {
+		if p.Symbol == "" || p.Price == nil || p.Time.IsZero() {
+			return nil, errPriceHistoryFormat
+		}
+		symbol := strings.ToUpper(p.Symbol)
+		h.points[symbol] = append(h.points[symbol], p)
+	}
+
+	for _, pts := range h.points {
+		sort.Slice(pts, func(i, j int) bool {
+			return pts[i].Time.Before(pts[j].Time)
+		})
+	}
+
+	return h, nil
+}
+
+// LoadFileHistory reads a JSON array of price points from the given file.
+func LoadFileHistory(path string) (*FileHistory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []*PricePoint
+	if err = json.Unmarshal(data, &points); err != nil {
+		return nil, fmt.Errorf("failed to parse price history file %s: %w", path, err)
+	}
+
+	return NewFileHistory(points)
+}
+
+// PriceAt returns the last price point for the symbol at or before time t.
+func (h *FileHistory) PriceAt(_ context.Context, symbol string, t time.Time) (*PriceFeed, error) {
+	symbol = strings.ToUpper(symbol)
+	pts := h.points[symbol]
+
+	// index of the first point after t
+	idx := sort.Search(len(pts), func(i int) bool {
+		return pts[i].Time.After(t)
+	})
+	if idx == 0 {
+		return nil, fmt.Errorf("%w: %s %s", errNoPriceHistory, symbol, t)
+	}
+
+	p := pts[idx-1]
+	return &PriceFeed{
+		Description: fmt.Sprintf("%s / USD", symbol),
+		Price:       p.Price,
+		UpdatedAt:   p.Time,
+	}, nil
+}
+
+// FallbackHistory tries each of its sources in order, returning the first
+// price found.
+type FallbackHistory []HistoricalPriceSource
+
+var _ HistoricalPriceSource = FallbackHistory(nil)
+
+// PriceAt returns the first successful result of the underlying sources.
+func (h FallbackHistory) PriceAt(ctx context.Context, symbol string, t time.Time) (*PriceFeed, error) {
+	var lastErr error = errNoPriceHistory
+	for _, src := range h {
+		feed, err := src.PriceAt(ctx, symbol, t)
+		if err == nil {
+			return feed, nil
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+)
+
+func TestFileHistory_PriceAt(t *testing.T) {
+	historyJSON := `[
+		{"symbol": "XMR", "time": "2023-03-01T00:00:00Z", "price": "150.5"},
+		{"symbol": "xmr", "time": "2023-01-01T00:00:00Z", "price": "160"},
+		{"symbol": "ETH", "time": "2023-01-01T00:00:00Z", "price": "1200"}
+	]`
+	historyFile := path.Join(t.TempDir(), "prices.json")
+	require.NoError(t, os.WriteFile(historyFile, []byte(historyJSON), 0600))
+
+	h, err := LoadFileHistory(historyFile)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	feed, err := h.PriceAt(ctx, "XMR", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "160", feed.Price.String())
+	require.Equal(t, "XMR / USD", feed.Description)
+
+	feed, err = h.PriceAt(ctx, "xmr", time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "150.5", feed.Price.String())
+
+	_, err = h.PriceAt(ctx, "ETH", time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, errNoPriceHistory)
+}
+
+func TestNewFileHistory_invalidEntry(t *testing.T) {
+	_, err := NewFileHistory([]*PricePoint{{Symbol: "XMR", Time: time.Now()}})
+	require.ErrorIs(t, err, errPriceHistoryFormat)
+}
+
+func TestFallbackHistory(t *testing.T) {
+	empty, err := NewFileHistory(nil)
+	require.NoError(t, err)
+
+	full, err := NewFileHistory([]*PricePoint{
+		{Symbol: "ETH", Time: time.Unix(1, 0), Price: coins.StrToDecimal("1000")},
+	})
+	require.NoError(t, err)
+
+	feed, err := FallbackHistory{empty, full}.PriceAt(context.Background(), "ETH", time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "1000", feed.Price.String())
+
+	_, err = FallbackHistory{empty}.PriceAt(context.Background(), "ETH", time.Now())
+	require.ErrorIs(t, err, errNoPriceHistory)
+}
+
+// testRounds returns a getRound function for an aggregator whose round i
+// (1-based) was updated at unix time i*100, along with a count of the calls.
+func testRounds(phaseBase *big.Int, numRounds uint64) (func(*big.Int) (*chainlinkRound, error), *int) {
+	calls := 0
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), phaseOffset), big.NewInt(1))
+	return func(roundID *big.Int) (*chainlinkRound, error) {
+		calls++
+		aggRound := new(big.Int).And(roundID, mask).Uint64()
+		if aggRound == 0 || aggRound > numRounds {
+			return nil, errors.New("no such round")
+		}
+		return &chainlinkRound{
+			roundID:   roundID,
+			answer:    new(big.Int).SetUint64(aggRound),
+			updatedAt: new(big.Int).SetUint64(aggRound * 100),
+		}, nil
+	}, &calls
+}
+
+func TestSearchRounds(t *testing.T) {
+	phaseBase := new(big.Int).Lsh(big.NewInt(2), phaseOffset)
+	getRound, calls := testRounds(phaseBase, 1000)
+	latest, err := getRound(new(big.Int).Or(phaseBase, big.NewInt(1000)))
+	require.NoError(t, err)
+
+	// the latest round is returned without any lookups
+	*calls = 0
+	round, err := searchRounds(latest, time.Unix(200000, 0), getRound)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), round.answer.Uint64())
+	require.Zero(t, *calls)
+
+	round, err = searchRounds(latest, time.Unix(45050, 0), getRound)
+	require.NoError(t, err)
+	require.Equal(t, uint64(450), round.answer.Uint64())
+	require.LessOrEqual(t, *calls, 11)
+
+	_, err = searchRounds(latest, time.Unix(50, 0), getRound)
+	require.ErrorIs(t, err, errNoPriceHistory)
+}
+
+func TestSearchRounds_firstRoundOfPhase(t *testing.T) {
+	phaseBase := new(big.Int).Lsh(big.NewInt(3), phaseOffset)
+	getRound, calls := testRounds(phaseBase, 1)
+
+	for _, aggRound := range []int64{0, 1} {
+		latest := &chainlinkRound{
+			roundID:   new(big.Int).Or(phaseBase, big.NewInt(aggRound)),
+			answer:    big.NewInt(1),
+			updatedAt: big.NewInt(100),
+		}
+		_, err := searchRounds(latest, time.Unix(50, 0), getRound)
+		require.ErrorIs(t, err, errNoPriceHistory)
+	}
+	require.Zero(t, *calls)
+}
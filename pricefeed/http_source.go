@@ -0,0 +1,195 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+)
+
+const (
+	httpSourceTimeout = 10 * time.Second
+	// maxHTTPResponseSize limits how much of a response is read
+	maxHTTPResponseSize = 1 << 20
+)
+
+var errJSONPath = errors.New("invalid JSON path")
+
+// HTTPAssetConfig configures how an HTTPSource gets the price of one asset.
+type HTTPAssetConfig struct {
+	// URL returns a JSON document with the asset's USD price.
+	URL string `json:"url" validate:"required,url"`
+	// PricePath is the dot separated path of the price in the JSON document,
+	// for example "monero.usd" or "data.0.price". The price can be a JSON
+	// number or a string.
+	PricePath string `json:"pricePath" validate:"required"`
+	// UpdatedAtPath is the optional path of the price's update time, in Unix
+	// seconds. If it is not set, the price is considered updated when fetched.
+	UpdatedAtPath string `json:"updatedAtPath,omitempty"`
+}
+
+// HTTPSourceConfig configures an HTTPSource.
+type HTTPSourceConfig struct {
+	Name string `json:"name" validate:"required"`
+	// Assets maps each symbol to the configuration of its price.
+	Assets map[string]*HTTPAssetConfig `json:"assets" validate:"required,dive,required"`
+}
+
+// HTTPSource is a Source that reads prices from JSON documents served over
+// HTTP, such as the APIs of exchanges and price aggregators.
+type HTTPSource struct {
+	cfg    HTTPSourceConfig
+	client *http.Client
+}
+
+var _ Source = (*HTTPSource)(nil)
+
+// NewHTTPSource returns an HTTPSource with the given configuration, whose
+// requests go through the proxy if it isn't nil.
+func NewHTTPSource(cfg *HTTPSourceConfig, proxy *netproxy.Proxy) *HTTPSource {
+	assets := make(map[string]*HTTPAssetConfig, len(cfg.Assets))
+	for symbol, asset := range cfg.Assets {
+		assets[strings.ToUpper(symbol)] = asset
+	}
+
+	return &HTTPSource{
+		cfg: HTTPSourceConfig{
+			Name:   cfg.Name,
+			Assets: assets,
+		},
+		client: proxy.HTTPClient(httpSourceTimeout),
+	}
+}
+
+// Name returns the name of the source.
+func (s *HTTPSource) Name() string {
+	return s.cfg.Name
+}
+
+// Price fetches the JSON document of the symbol and extracts its price.
+func (s *HTTPSource) Price(ctx context.Context, symbol string) (*PriceFeed, error) {
+	symbol = strings.ToUpper(symbol)
+	asset, ok := s.cfg.Assets[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedSymbol, symbol)
+	}
+
+	doc, err := s.fetch(ctx, asset.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	priceVal, err := lookupJSONPath(doc, asset.PricePath)
+	if err != nil {
+		return nil, err
+	}
+
+	price, _, err := apd.NewFromString(jsonValueString(priceVal))
+	if err != nil {
+		return nil, fmt.Errorf("invalid price at %q: %w", asset.PricePath, err)
+	}
+
+	updatedAt := time.Now()
+	if asset.UpdatedAtPath != "" {
+		updatedVal, err := lookupJSONPath(doc, asset.UpdatedAtPath) //nolint:govet
+		if err != nil {
+			return nil, err
+		}
+
+		seconds, err := strconv.ParseInt(jsonValueString(updatedVal), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid update time at %q: %w", asset.UpdatedAtPath, err)
+		}
+		updatedAt = time.Unix(seconds, 0)
+	}
+
+	log.Debugf("%s %s / USD: $%s (%s)", s.cfg.Name, symbol, price, updatedAt)
+	return &PriceFeed{
+		Description: symbol + " / USD",
+		Price:       price,
+		UpdatedAt:   updatedAt,
+	}, nil
+}
+
+func (s *HTTPSource) fetch(ctx context.Context, url string) (any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %s from %s", resp.Status, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // keeps the exact decimal representation of prices
+	if err = dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON from %s: %w", url, err)
+	}
+
+	return doc, nil
+}
+
+// lookupJSONPath returns the value at the dot separated path in a decoded JSON
+// document. Path elements are object keys, or indexes into arrays.
+func lookupJSONPath(doc any, path string) (any, error) {
+	val := doc
+	for _, elem := range strings.Split(path, ".") {
+		switch v := val.(type) {
+		case map[string]any:
+			next, ok := v[elem]
+			if !ok {
+				return nil, fmt.Errorf("%w: key %q of %q not found", errJSONPath, elem, path)
+			}
+			val = next
+		case []any:
+			i, err := strconv.Atoi(elem)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%w: index %q of %q out of range", errJSONPath, elem, path)
+			}
+			val = v[i]
+		default:
+			return nil, fmt.Errorf("%w: %q of %q is not an object or array", errJSONPath, elem, path)
+		}
+	}
+
+	return val, nil
+}
+
+// jsonValueString returns the text of a JSON number or string value.
+func jsonValueString(val any) string {
+	switch v := val.(type) {
+	case json.Number:
+		return v.String()
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSource(t *testing.T) {
+	responses := map[string]string{
+		"/simple":  `{"monero":{"usd":151.234567891,"last_updated_at":1690000000}}`,
+		"/array":   `{"data":[{"symbol":"ETH","price":"1850.12"}]}`,
+		"/invalid": `{"data":`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	defer server.Close()
+
+	src := NewHTTPSource(&HTTPSourceConfig{
+		Name: "test",
+		Assets: map[string]*HTTPAssetConfig{
+			"xmr": {
+				URL:           server.URL + "/simple",
+				PricePath:     "monero.usd",
+				UpdatedAtPath: "monero.last_updated_at",
+			},
+			"ETH": {
+				URL:       server.URL + "/array",
+				PricePath: "data.0.price",
+			},
+		},
+	}, nil)
+	require.Equal(t, "test", src.Name())
+
+	ctx := context.Background()
+
+	// the price keeps all of its decimal places
+	feed, err := src.Price(ctx, SymbolXMR)
+	require.NoError(t, err)
+	require.Equal(t, "151.234567891", feed.Price.String())
+	require.Equal(t, time.Unix(1690000000, 0), feed.UpdatedAt)
+
+	feed, err = src.Price(ctx, "eth")
+	require.NoError(t, err)
+	require.Equal(t, "1850.12", feed.Price.String())
+	require.WithinDuration(t, time.Now(), feed.UpdatedAt, time.Minute)
+
+	_, err = src.Price(ctx, "BTC")
+	require.ErrorIs(t, err, errUnsupportedSymbol)
+
+	for _, tc := range []struct {
+		url  string
+		path string
+	}{
+		{"/simple", "monero.eur"},
+		{"/simple", "monero.usd.value"},
+		{"/array", "data.1.price"},
+		{"/array", "data.x.price"},
+		{"/invalid", "data"},
+		{"/missing", "data"},
+	} {
+		src = NewHTTPSource(&HTTPSourceConfig{
+			Name:   "test",
+			Assets: map[string]*HTTPAssetConfig{SymbolXMR: {URL: server.URL + tc.url, PricePath: tc.path}},
+		}, nil)
+		_, err = src.Price(ctx, SymbolXMR)
+		require.Error(t, err, "%s %s", tc.url, tc.path)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 )
 
@@ -55,7 +56,9 @@ func getOptimismEndpoint() string {
 }
 
 // GetETHUSDPrice returns the current ETH/USD price from the Chainlink oracle.
-func GetETHUSDPrice(ctx context.Context, ec *ethclient.Client) (*PriceFeed, error) {
+// Endpoints other than the client are dialed through the proxy, which can be
+// nil.
+func GetETHUSDPrice(ctx context.Context, ec *ethclient.Client, proxy *netproxy.Proxy) (*PriceFeed, error) {
 	chainID, err := ec.ChainID(ctx)
 	if err != nil {
 		return nil, err
@@ -65,17 +68,13 @@ func GetETHUSDPrice(ctx context.Context, ec *ethclient.Client) (*PriceFeed, erro
 	case common.OpMainnetChainID:
 		// No extra work to do
 	case common.MainnetChainID, common.SepoliaChainID:
-		ec, err = ethclient.Dial(getOptimismEndpoint())
+		ec, err = proxy.DialEthereum(ctx, getOptimismEndpoint())
 		if err != nil {
 			return nil, err
 		}
 		defer ec.Close()
 	case common.GanacheChainID, common.HardhatChainID:
-		return &PriceFeed{
-			Description: "ETH / USD (fake)",
-			Price:       apd.New(123412345678, -8), // 1234.12345678
-			UpdatedAt:   time.Now(),
-		}, nil
+		return fakePriceFeed(SymbolETH, time.Now())
 	default:
 		return nil, errUnsupportedNetwork
 	}
@@ -84,7 +83,9 @@ func GetETHUSDPrice(ctx context.Context, ec *ethclient.Client) (*PriceFeed, erro
 }
 
 // GetXMRUSDPrice returns the current XMR/USD price from the Chainlink oracle.
-func GetXMRUSDPrice(ctx context.Context, ec *ethclient.Client) (*PriceFeed, error) {
+// Endpoints other than the client are dialed through the proxy, which can be
+// nil.
+func GetXMRUSDPrice(ctx context.Context, ec *ethclient.Client, proxy *netproxy.Proxy) (*PriceFeed, error) {
 	chainID, err := ec.ChainID(ctx)
 	if err != nil {
 		return nil, err
@@ -95,17 +96,13 @@ func GetXMRUSDPrice(ctx context.Context, ec *ethclient.Client) (*PriceFeed, erro
 		// No extra work to do
 	case common.MainnetChainID, common.SepoliaChainID:
 		// Push stagenet/sepolia users to a mainnet endpoint
-		ec, err = ethclient.Dial(getOptimismEndpoint())
+		ec, err = proxy.DialEthereum(ctx, getOptimismEndpoint())
 		if err != nil {
 			return nil, err
 		}
 		defer ec.Close()
 	case common.GanacheChainID, common.HardhatChainID:
-		return &PriceFeed{
-			Description: "XMR / USD (fake)",
-			Price:       apd.New(12312345678, -8), // 123.12345678
-			UpdatedAt:   time.Now(),
-		}, nil
+		return fakePriceFeed(SymbolXMR, time.Now())
 	default:
 		return nil, errUnsupportedNetwork
 	}
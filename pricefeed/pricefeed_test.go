@@ -31,7 +31,7 @@ func newOptimismClient(t *testing.T) *ethclient.Client {
 
 func TestGetETHUSDPrice_nonDev(t *testing.T) {
 	for _, ec := range []*ethclient.Client{tests.NewEthSepoliaClient(t), newOptimismClient(t)} {
-		feed, err := GetETHUSDPrice(context.Background(), ec)
+		feed, err := GetETHUSDPrice(context.Background(), ec, nil)
 		require.NoError(t, err)
 		t.Logf("%s is $%s (updated: %s)", feed.Description, feed.Price, feed.UpdatedAt)
 		assert.Equal(t, "ETH / USD", feed.Description)
@@ -42,7 +42,7 @@ func TestGetETHUSDPrice_nonDev(t *testing.T) {
 
 func TestGetETHUSDPrice_dev(t *testing.T) {
 	ec, _ := tests.NewEthClient(t)
-	feed, err := GetETHUSDPrice(context.Background(), ec)
+	feed, err := GetETHUSDPrice(context.Background(), ec, nil)
 	require.NoError(t, err)
 	assert.Equal(t, "ETH / USD (fake)", feed.Description)
 	assert.Equal(t, "1234.12345678", feed.Price.String())
@@ -50,7 +50,7 @@ func TestGetETHUSDPrice_dev(t *testing.T) {
 
 func TestGetXMRUSDPrice_nonDev(t *testing.T) {
 	for _, ec := range []*ethclient.Client{tests.NewEthSepoliaClient(t), newOptimismClient(t)} {
-		feed, err := GetXMRUSDPrice(context.Background(), ec)
+		feed, err := GetXMRUSDPrice(context.Background(), ec, nil)
 		require.NoError(t, err)
 		t.Logf("%s is $%s (updated: %s)", feed.Description, feed.Price, feed.UpdatedAt)
 		assert.Equal(t, "XMR / USD", feed.Description)
@@ -61,7 +61,7 @@ func TestGetXMRUSDPrice_nonDev(t *testing.T) {
 
 func TestGetXMRUSDPrice_dev(t *testing.T) {
 	ec, _ := tests.NewEthClient(t)
-	feed, err := GetXMRUSDPrice(context.Background(), ec)
+	feed, err := GetXMRUSDPrice(context.Background(), ec, nil)
 	require.NoError(t, err)
 	assert.Equal(t, "XMR / USD (fake)", feed.Description)
 	assert.Equal(t, "123.12345678", feed.Price.String())
@@ -0,0 +1,230 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+)
+
+// Symbols of the assets that sources must be able to price in USD
+const (
+	SymbolETH = "ETH"
+	SymbolXMR = "XMR"
+)
+
+// chainlinkFeeds holds the address of the chainlink USD price feed proxy of
+// each symbol, by chain ID.
+var chainlinkFeeds = map[uint64]map[string]string{
+	common.OpMainnetChainID: {
+		SymbolETH: chainlinkETHToUSDProxy,
+		SymbolXMR: chainlinkXMRToUSDProxy,
+		// https://data.chain.link/optimism/mainnet/stablecoins/usdc-usd, etc.
+		SymbolUSDC: "0x16a9fa2fda030272ce99b29cf780dfa30361e0f3",
+		SymbolUSDT: "0xecef79e109e997bca29c1c0897ec9d7b03647f5e",
+		SymbolDAI:  "0x8dba75e83da73cc766a7e5a0ee71f656bab470d6",
+		SymbolBTC:  "0xd702dd976fb76fffc2d3963d037dfdae5b04e593",
+	},
+	common.MainnetChainID: {
+		// https://data.chain.link/ethereum/mainnet/crypto-usd/eth-usd
+		SymbolETH: "0x5f4ec3df9cbd43714fe2740f5e3616155c5b8419",
+		// https://data.chain.link/ethereum/mainnet/crypto-usd/xmr-usd
+		SymbolXMR: "0xfa66458cce7dd15d8650015c4fce4d278271618f",
+		// https://data.chain.link/ethereum/mainnet/stablecoins/usdc-usd, etc.
+		SymbolUSDC: "0x8fffffd4afb6115b954bd326cbe7b4ba576818f6",
+		SymbolUSDT: "0x3e7d1eab13ad0104d2750b8863b489d65364e32d",
+		SymbolDAI:  "0xaed0c38402a5d19df6e4c03f4e2dced6e29c1ee9",
+		SymbolBTC:  "0xf4030086522a5beea4988f8ca5b36dbc97bee88c",
+	},
+	common.ArbitrumChainID: {
+		// https://data.chain.link/arbitrum/mainnet/crypto-usd/eth-usd
+		SymbolETH: "0x639fe6ab55c921f74e7fac1ee960c0b6293ba612",
+	},
+}
+
+// Source is a source of the current USD price of assets.
+type Source interface {
+	// Name identifies the source in logs and price breakdowns.
+	Name() string
+	// Price returns the current USD price of the asset with the given symbol.
+	Price(ctx context.Context, symbol string) (*PriceFeed, error)
+}
+
+// ChainlinkSource is a Source that reads the chainlink price feeds of the
+// chain of its ethereum client. On development chains, it returns fake
+// prices.
+type ChainlinkSource struct {
+	name     string
+	ec       *ethclient.Client
+	endpoint string
+	proxy    *netproxy.Proxy
+}
+
+var _ Source = (*ChainlinkSource)(nil)
+
+// NewChainlinkSource returns a ChainlinkSource that reads the feeds of the
+// chain of the given client.
+func NewChainlinkSource(name string, ec *ethclient.Client) *ChainlinkSource {
+	return &ChainlinkSource{name: name, ec: ec}
+}
+
+// NewChainlinkEndpointSource returns a ChainlinkSource that connects to the
+// given endpoint each time it is queried, through the proxy if it isn't nil.
+func NewChainlinkEndpointSource(name string, endpoint string, proxy *netproxy.Proxy) *ChainlinkSource {
+	return &ChainlinkSource{name: name, endpoint: endpoint, proxy: proxy}
+}
+
+// Name returns the name of the source.
+func (s *ChainlinkSource) Name() string {
+	return s.name
+}
+
+// Price returns the latest round of the chainlink feed for the symbol.
+func (s *ChainlinkSource) Price(ctx context.Context, symbol string) (*PriceFeed, error) {
+	symbol = strings.ToUpper(symbol)
+
+	ec := s.ec
+	if ec == nil {
+		var err error
+		ec, err = s.proxy.DialEthereum(ctx, s.endpoint)
+		if err != nil {
+			return nil, err
+		}
+		defer ec.Close()
+	}
+
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch chainID.Uint64() {
+	case common.GanacheChainID, common.HardhatChainID:
+		return fakePriceFeed(symbol, time.Now())
+	}
+
+	feeds, ok := chainlinkFeeds[chainID.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("%w: chain ID %s", errUnsupportedNetwork, chainID)
+	}
+
+	feedAddress, ok := feeds[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q on chain ID %s", errUnsupportedSymbol, symbol, chainID)
+	}
+
+	feed, err := getChainlinkPriceFeed(ctx, feedAddress, ec)
+	if err != nil {
+		return nil, err
+	}
+
+	// guards against a feed address for the wrong asset
+	if feed.Description != symbol+" / USD" {
+		return nil, fmt.Errorf("chainlink feed %s is for %q, not %s / USD", feedAddress, feed.Description, symbol)
+	}
+
+	return feed, nil
+}
+
+// StaticSource is a Source with fixed prices, for tests and development.
+type StaticSource struct {
+	name      string
+	prices    map[string]*apd.Decimal
+	updatedAt time.Time
+}
+
+var _ Source = (*StaticSource)(nil)
+
+// NewStaticSource returns a StaticSource with the given USD prices by symbol.
+// If updatedAt is zero, the prices are always reported as just updated.
+func NewStaticSource(name string, prices map[string]*apd.Decimal, updatedAt time.Time) *StaticSource {
+	return &StaticSource{name: name, prices: prices, updatedAt: updatedAt}
+}
+
+// Name returns the name of the source.
+func (s *StaticSource) Name() string {
+	return s.name
+}
+
+// Price returns the fixed price of the symbol.
+func (s *StaticSource) Price(_ context.Context, symbol string) (*PriceFeed, error) {
+	symbol = strings.ToUpper(symbol)
+	price, ok := s.prices[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedSymbol, symbol)
+	}
+
+	updatedAt := s.updatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+
+	return &PriceFeed{
+		Description: symbol + " / USD (static)",
+		Price:       new(apd.Decimal).Set(price),
+		UpdatedAt:   updatedAt,
+	}, nil
+}
+
+// DefaultSources returns the chainlink sources used when no sources are
+// configured, which depend on the network of the given client:
+//   - Ethereum mainnet: the feeds on mainnet and on Optimism
+//   - Optimism: the feeds on Optimism
+//   - Sepolia: the mainnet feeds on Optimism
+//   - Development chains: fake prices
+//
+// The Optimism endpoint is dialed through the proxy if it isn't nil.
+func DefaultSources(ctx context.Context, ec *ethclient.Client, proxy *netproxy.Proxy) ([]Source, error) {
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	optimism := NewChainlinkEndpointSource("chainlink-optimism", getOptimismEndpoint(), proxy)
+
+	switch chainID.Uint64() {
+	case common.MainnetChainID:
+		return []Source{NewChainlinkSource("chainlink-ethereum", ec), optimism}, nil
+	case common.OpMainnetChainID:
+		return []Source{NewChainlinkSource("chainlink-optimism", ec)}, nil
+	case common.SepoliaChainID:
+		return []Source{optimism}, nil
+	case common.GanacheChainID, common.HardhatChainID:
+		return []Source{NewChainlinkSource("chainlink-dev", ec)}, nil
+	default:
+		return nil, errUnsupportedNetwork
+	}
+}
+
+// fakePriceFeed returns the fake price of the symbol used on development
+// chains.
+func fakePriceFeed(symbol string, updatedAt time.Time) (*PriceFeed, error) {
+	var price *apd.Decimal
+	switch symbol {
+	case SymbolETH:
+		price = apd.New(123412345678, -8) // 1234.12345678
+	case SymbolXMR:
+		price = apd.New(12312345678, -8) // 123.12345678
+	case SymbolUSDC, SymbolUSDT, SymbolDAI:
+		price = apd.New(1, 0)
+	case SymbolBTC:
+		price = apd.New(3012345678901, -8) // 30123.45678901
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnsupportedSymbol, symbol)
+	}
+
+	return &PriceFeed{
+		Description: symbol + " / USD (fake)",
+		Price:       price,
+		UpdatedAt:   updatedAt,
+	}, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+)
+
+// ChainlinkEndpointConfig is a chainlink source that reads the feeds of the
+// chain of an ethereum endpoint.
+type ChainlinkEndpointConfig struct {
+	Name     string `json:"name" validate:"required"`
+	Endpoint string `json:"endpoint" validate:"required"`
+}
+
+// SourcesConfig configures the price sources and aggregation used for the
+// suggested exchange rate. It is the format of the file passed to swapd with
+// --price-sources-file.
+type SourcesConfig struct {
+	Chainlink []*ChainlinkEndpointConfig `json:"chainlink,omitempty" validate:"dive,required"`
+	HTTP      []*HTTPSourceConfig        `json:"http,omitempty" validate:"dive,required"`
+	// ExcludeDefaults removes the sources returned by DefaultSources
+	ExcludeDefaults bool `json:"excludeDefaults,omitempty"`
+	// MaxAgeSeconds, MaxDeviation and MinSources are the AggregatorConfig
+	// fields of the same name, and use the same defaults when unset.
+	MaxAgeSeconds uint64       `json:"maxAgeSeconds,omitempty"`
+	MaxDeviation  *apd.Decimal `json:"maxDeviation,omitempty"`
+	MinSources    int          `json:"minSources,omitempty" validate:"gte=0"`
+	// Tokens maps ERC20 token addresses on swapd's chain to the symbol their
+	// USD price is queried with, adding to or overriding the built in token
+	// registry. Sources need a price for the symbol, for example from an HTTP
+	// source asset with the same name.
+	Tokens map[ethcommon.Address]string `json:"tokens,omitempty" validate:"dive,required"`
+}
+
+// LoadSourcesFile reads a JSON encoded SourcesConfig from the file.
+func LoadSourcesFile(path string) (*SourcesConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(SourcesConfig)
+	if err = vjson.UnmarshalStruct(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse price sources file %s: %w", path, err)
+	}
+
+	if !cfg.ExcludeDefaults || len(cfg.Chainlink)+len(cfg.HTTP) > 0 {
+		return cfg, nil
+	}
+
+	return nil, fmt.Errorf("price sources file %s excludes the default sources without adding any", path)
+}
+
+// NewAggregatorFromConfig returns an Aggregator for the default sources of
+// the network of the given client, plus the sources of the config. A nil
+// config only uses the default sources. Sources other than the client connect
+// through the proxy, which can be nil.
+func NewAggregatorFromConfig(
+	ctx context.Context,
+	ec *ethclient.Client,
+	proxy *netproxy.Proxy,
+	cfg *SourcesConfig,
+) (*Aggregator, error) {
+	if cfg == nil {
+		cfg = new(SourcesConfig)
+	}
+
+	var sources []Source
+	if !cfg.ExcludeDefaults {
+		defaults, err := DefaultSources(ctx, ec, proxy)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, defaults...)
+	}
+
+	for _, c := range cfg.Chainlink {
+		sources = append(sources, NewChainlinkEndpointSource(c.Name, c.Endpoint, proxy))
+	}
+
+	for _, c := range cfg.HTTP {
+		sources = append(sources, NewHTTPSource(c, proxy))
+	}
+
+	return NewAggregator(&AggregatorConfig{
+		Sources:      sources,
+		MaxAge:       time.Duration(cfg.MaxAgeSeconds) * time.Second,
+		MaxDeviation: cfg.MaxDeviation,
+		MinSources:   cfg.MinSources,
+	})
+}
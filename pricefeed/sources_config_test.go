@@ -0,0 +1,52 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSourcesFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sources.json")
+	contents := `{
+		"http": [{
+			"name": "test",
+			"assets": {"XMR": {"url": "http://127.0.0.1:1/price", "pricePath": "usd"}}
+		}],
+		"excludeDefaults": true,
+		"maxAgeSeconds": 7200,
+		"maxDeviation": "0.03",
+		"minSources": 1
+	}`
+	require.NoError(t, os.WriteFile(file, []byte(contents), 0600))
+
+	cfg, err := LoadSourcesFile(file)
+	require.NoError(t, err)
+	require.Len(t, cfg.HTTP, 1)
+	require.Equal(t, "0.03", cfg.MaxDeviation.String())
+
+	// the default sources are excluded, so no ethereum client is needed
+	a, err := NewAggregatorFromConfig(context.Background(), nil, nil, cfg)
+	require.NoError(t, err)
+	require.Len(t, a.cfg.Sources, 1)
+	require.Equal(t, "test", a.cfg.Sources[0].Name())
+	require.Equal(t, 2*time.Hour, a.cfg.MaxAge)
+
+	// a file can't exclude the defaults without adding sources
+	require.NoError(t, os.WriteFile(file, []byte(`{"excludeDefaults": true}`), 0600))
+	_, err = LoadSourcesFile(file)
+	require.ErrorContains(t, err, "without adding any")
+
+	// HTTP sources must have a price path
+	contents = `{"http": [{"name": "test", "assets": {"XMR": {"url": "http://127.0.0.1:1/price"}}}]}`
+	require.NoError(t, os.WriteFile(file, []byte(contents), 0600))
+	_, err = LoadSourcesFile(file)
+	require.ErrorContains(t, err, "PricePath")
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/athanorlabs/atomic-swap/common"
+)
+
+// Symbols of the ERC20 assets with chainlink USD price feeds
+const (
+	SymbolUSDC = "USDC"
+	SymbolUSDT = "USDT"
+	SymbolDAI  = "DAI"
+	// SymbolBTC is the price symbol of wrapped bitcoin tokens, which are
+	// priced at the BTC / USD rate.
+	SymbolBTC = "BTC"
+)
+
+// ErrNoTokenFeed is returned when there is no USD price feed for a token.
+var ErrNoTokenFeed = errors.New("no USD price feed for token")
+
+// tokenSymbols maps the address of each ERC20 token with a known USD price
+// feed to the symbol of the feed, by chain ID. The feeds of the symbols are in
+// chainlinkFeeds, and HTTP sources can price the same symbols.
+var tokenSymbols = map[uint64]map[ethcommon.Address]string{
+	common.MainnetChainID: {
+		ethcommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"): SymbolUSDC,
+		ethcommon.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7"): SymbolUSDT,
+		ethcommon.HexToAddress("0x6b175474e89094c44da98b954eedeac495271d0f"): SymbolDAI,
+		ethcommon.HexToAddress("0x2260fac5e5542a773aa44fbcfedf7c193bc2c599"): SymbolBTC, // WBTC
+	},
+	common.OpMainnetChainID: {
+		ethcommon.HexToAddress("0x0b2c639c533813f4aa9d7837caf62653d097ff85"): SymbolUSDC,
+		ethcommon.HexToAddress("0x7f5c764cbc14f9669b88837ca1490cca17c31607"): SymbolUSDC, // bridged USDC.e
+		ethcommon.HexToAddress("0x94b008aa00579c1307b0ef2c499ad98a8ce58e58"): SymbolUSDT,
+		ethcommon.HexToAddress("0xda10009cbd5d07dd0cecc66161fc93d7c9000da1"): SymbolDAI,
+		ethcommon.HexToAddress("0x68f180fcce6836688e9084f035309e29bf0a2095"): SymbolBTC, // WBTC
+	},
+}
+
+// TokenSymbol returns the price symbol of the ERC20 token on the given chain.
+// Tokens of the config take precedence over the built in registry. The config
+// can be nil.
+func (c *SourcesConfig) TokenSymbol(chainID uint64, token ethcommon.Address) (string, error) {
+	if c != nil {
+		if symbol, ok := c.Tokens[token]; ok {
+			return strings.ToUpper(symbol), nil
+		}
+	}
+
+	if symbol, ok := tokenSymbols[chainID][token]; ok {
+		return symbol, nil
+	}
+
+	return "", fmt.Errorf("%w %s on chain ID %d", ErrNoTokenFeed, token, chainID)
+}
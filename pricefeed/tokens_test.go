@@ -0,0 +1,51 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package pricefeed
+
+import (
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common"
+)
+
+func TestTokenSymbols_haveFeeds(t *testing.T) {
+	for chainID, tokens := range tokenSymbols {
+		for token, symbol := range tokens {
+			_, ok := chainlinkFeeds[chainID][symbol]
+			require.True(t, ok, "no %s feed for token %s on chain ID %d", symbol, token, chainID)
+		}
+	}
+}
+
+func TestSourcesConfig_TokenSymbol(t *testing.T) {
+	usdc := ethcommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	custom := ethcommon.Address{0x1}
+
+	var cfg *SourcesConfig
+	symbol, err := cfg.TokenSymbol(common.MainnetChainID, usdc)
+	require.NoError(t, err)
+	require.Equal(t, SymbolUSDC, symbol)
+
+	// the mainnet address isn't registered on other chains
+	_, err = cfg.TokenSymbol(common.OpMainnetChainID, usdc)
+	require.ErrorIs(t, err, ErrNoTokenFeed)
+
+	cfg = &SourcesConfig{
+		Tokens: map[ethcommon.Address]string{
+			custom: "wxyz",
+			usdc:   SymbolDAI,
+		},
+	}
+
+	symbol, err = cfg.TokenSymbol(common.GanacheChainID, custom)
+	require.NoError(t, err)
+	require.Equal(t, "WXYZ", symbol)
+
+	symbol, err = cfg.TokenSymbol(common.MainnetChainID, usdc)
+	require.NoError(t, err)
+	require.Equal(t, SymbolDAI, symbol)
+}
@@ -25,9 +25,12 @@ import (
 	"github.com/athanorlabs/atomic-swap/db"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/metrics"
 	"github.com/athanorlabs/atomic-swap/monero"
 	"github.com/athanorlabs/atomic-swap/net/message"
 	"github.com/athanorlabs/atomic-swap/protocol/swap"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
+	"github.com/athanorlabs/atomic-swap/protocol/tokenpolicy"
 	"github.com/athanorlabs/atomic-swap/protocol/txsender"
 	"github.com/athanorlabs/atomic-swap/relayer"
 )
@@ -40,6 +43,7 @@ type NetSender interface {
 	DiscoverRelayers() ([]peer.ID, error)                                                        // Only used by Maker
 	QueryRelayerAddress(peer.ID) (types.Hash, error)                                             // only used by taker
 	SubmitRelayRequest(peer.ID, *message.RelayClaimRequest) (*message.RelayClaimResponse, error) // only used by taker
+	ProtocolVersion(offerID types.Hash) uint16                                                   // message format version of the swap
 }
 
 // RecoveryDB is implemented by *db.RecoveryDB
@@ -56,6 +60,7 @@ type RecoveryDB interface {
 	GetCounterpartySwapKeys(id types.Hash) (*mcrypto.PublicKey, *mcrypto.PrivateViewKey, error)
 	PutNewSwapTxHash(id types.Hash, txHash types.Hash) error
 	GetNewSwapTxHash(id types.Hash) (types.Hash, error)
+	NextSwapKeyIndex() (uint32, error)
 	DeleteSwap(id types.Hash) error
 }
 
@@ -71,6 +76,15 @@ type Backend interface {
 	// NewTxSender creates a new transaction sender, called per-swap
 	NewTxSender(asset ethcommon.Address, erc20Contract *contracts.IERC20) (txsender.Sender, error)
 
+	// ForChain returns the backend for swaps on the chain with the given ID,
+	// where zero is the primary chain. Relayed claims are only supported on
+	// the primary chain.
+	ForChain(chainID uint64) (Backend, error)
+
+	// CheckToken returns the ERC20 info of a token that swaps can be made with
+	// on the backend's chain, or an error if the token policy refuses it.
+	CheckToken(token ethcommon.Address) (*coins.ERC20TokenInfo, error)
+
 	// helpers
 	NewSwapCreator(addr ethcommon.Address) (*contracts.SwapCreator, error)
 	HandleRelayClaimRequest(remotePeer peer.ID, request *message.RelayClaimRequest) (*message.RelayClaimResponse, error)
@@ -90,6 +104,7 @@ type Backend interface {
 	SwapCreator() *contracts.SwapCreator
 	SwapCreatorAddr() ethcommon.Address
 	SwapTimeout() time.Duration
+	SwapKeySeed() *swapkeys.Seed
 	XMRDepositAddress(offerID *types.Hash) *mcrypto.Address
 
 	// setters
@@ -127,6 +142,16 @@ type backend struct {
 	swapCreatorAddr ethcommon.Address
 	swapTimeout     time.Duration
 
+	// master seed that swap keys are derived from, nil if swap keys are
+	// generated randomly
+	swapKeySeed *swapkeys.Seed
+
+	// backends of the chains other than the primary chain, by chain ID
+	chains map[uint64]*chainBackend
+
+	// tokenPolicy decides which ERC20 tokens can be swapped, nil accepts all
+	tokenPolicy *tokenpolicy.Policy
+
 	// network interface
 	NetSender
 
@@ -145,6 +170,9 @@ type Config struct {
 	SwapManager     swap.Manager
 	RecoveryDB      RecoveryDB
 	Net             NetSender
+	SwapKeySeed     *swapkeys.Seed      // optional, swap keys are random if unset
+	Chains          []*ChainConfig      // optional, chains other than the primary chain
+	TokenPolicy     *tokenpolicy.Policy // optional, all ERC20 tokens are accepted if nil
 }
 
 // NewBackend returns a new Backend
@@ -158,7 +186,7 @@ func NewBackend(cfg *Config) (Backend, error) {
 		return nil, err
 	}
 
-	return &backend{
+	b := &backend{
 		ctx:                   cfg.Ctx,
 		env:                   cfg.Environment,
 		moneroWallet:          cfg.MoneroClient,
@@ -171,7 +199,20 @@ func NewBackend(cfg *Config) (Backend, error) {
 		perSwapXMRDepositAddr: make(map[types.Hash]*mcrypto.Address),
 		recoveryDB:            cfg.RecoveryDB,
 		relayerHash:           make(map[types.Hash][4]byte),
-	}, nil
+		swapKeySeed:           cfg.SwapKeySeed,
+		chains:                make(map[uint64]*chainBackend),
+		tokenPolicy:           cfg.TokenPolicy,
+	}
+
+	for _, chainCfg := range cfg.Chains {
+		cb, err := newChainBackend(b, chainCfg)
+		if err != nil {
+			return nil, err
+		}
+		b.chains[cb.chainID] = cb
+	}
+
+	return b, nil
 }
 
 func (b *backend) XMRClient() monero.WalletClient {
@@ -182,12 +223,39 @@ func (b *backend) ETHClient() extethclient.EthClient {
 	return b.ethClient
 }
 
+func (b *backend) CheckToken(token ethcommon.Address) (*coins.ERC20TokenInfo, error) {
+	return checkToken(b.ctx, b.tokenPolicy, b.ethClient, token)
+}
+
+func checkToken(
+	ctx context.Context,
+	policy *tokenpolicy.Policy,
+	ec extethclient.EthClient,
+	token ethcommon.Address,
+) (*coins.ERC20TokenInfo, error) {
+	if policy == nil {
+		return ec.ERC20Info(ctx, token)
+	}
+	return policy.Check(ctx, ec, token)
+}
+
 func (b *backend) NewTxSender(asset ethcommon.Address, erc20Contract *contracts.IERC20) (txsender.Sender, error) {
-	if !b.ethClient.HasPrivateKey() {
-		return txsender.NewExternalSender(b.ctx, b.env, b.ethClient.Raw(), b.swapCreatorAddr, asset)
+	return newTxSender(b, b.ethClient, b.swapCreatorAddr, b.swapCreator, asset, erc20Contract)
+}
+
+func newTxSender(
+	b *backend,
+	ec extethclient.EthClient,
+	swapCreatorAddr ethcommon.Address,
+	swapCreator *contracts.SwapCreator,
+	asset ethcommon.Address,
+	erc20Contract *contracts.IERC20,
+) (txsender.Sender, error) {
+	if !ec.HasPrivateKey() {
+		return txsender.NewExternalSender(b.ctx, b.env, ec.Raw(), swapCreatorAddr, asset)
 	}
 
-	return txsender.NewSenderWithPrivateKey(b.ctx, b.ETHClient(), b.swapCreatorAddr, b.swapCreator, erc20Contract), nil
+	return txsender.NewSenderWithPrivateKey(b.ctx, ec, swapCreatorAddr, swapCreator, erc20Contract), nil
 }
 
 func (b *backend) RecoveryDB() RecoveryDB {
@@ -218,6 +286,12 @@ func (b *backend) SwapTimeout() time.Duration {
 	return b.swapTimeout
 }
 
+// SwapKeySeed returns the master seed that swap keys are derived from, or nil
+// if swap keys are generated randomly.
+func (b *backend) SwapKeySeed() *swapkeys.Seed {
+	return b.swapKeySeed
+}
+
 // SetSwapTimeout sets the duration between the swap being initiated on-chain and the timeout t1,
 // and the duration between t1 and t2.
 func (b *backend) SetSwapTimeout(timeout time.Duration) {
@@ -332,13 +406,30 @@ func (b *backend) HandleRelayClaimRequest(
 	salt := b.relayerHash[request.RelaySwap.RelayerHash]
 	b.relayerHashMu.RUnlock()
 
-	return relayer.ValidateAndSendTransaction(
+	resp, err := relayer.ValidateAndSendTransaction(
 		b.Ctx(),
 		request,
 		b.ETHClient(),
 		b.SwapCreatorAddr(),
 		salt,
 	)
+	metrics.RecordRelayerClaimRelayed(err)
+	return resp, err
+}
+
+// SendSwapMessage sends the message to the swap's counterparty and records
+// it in the swap's journal.
+func (b *backend) SendSwapMessage(msg common.Message, offerID types.Hash) error {
+	err := b.NetSender.SendSwapMessage(msg, offerID)
+	if err != nil {
+		return err
+	}
+
+	b.swapManager.AppendJournal(
+		offerID,
+		swap.NewMessageJournalEntry(swap.JournalMessageSent, message.TypeToString(msg.Type())),
+	)
+	return nil
 }
 
 func (b *backend) GetRelayerAddressHash() (types.Hash, error) {
@@ -0,0 +1,104 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package backend
+
+import (
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/protocol/txsender"
+)
+
+// ChainConfig is the config of a chain, other than the primary chain of the
+// environment, that swaps can be made on.
+type ChainConfig struct {
+	EthereumClient  extethclient.EthClient
+	SwapCreatorAddr ethcommon.Address
+}
+
+// chainBackend is the view of the backend for swaps on a chain other than
+// the primary chain. Everything except the ethereum client and SwapCreator
+// contract is shared with the primary backend.
+type chainBackend struct {
+	*backend
+	chainID         uint64
+	ethClient       extethclient.EthClient
+	swapCreator     *contracts.SwapCreator
+	swapCreatorAddr ethcommon.Address
+}
+
+func newChainBackend(b *backend, cfg *ChainConfig) (*chainBackend, error) {
+	if (cfg.SwapCreatorAddr == ethcommon.Address{}) {
+		return nil, errNilSwapContractOrAddress
+	}
+
+	swapCreator, err := contracts.NewSwapCreator(cfg.SwapCreatorAddr, cfg.EthereumClient.Raw())
+	if err != nil {
+		return nil, err
+	}
+
+	return &chainBackend{
+		backend:         b,
+		chainID:         cfg.EthereumClient.ChainID().Uint64(),
+		ethClient:       cfg.EthereumClient,
+		swapCreator:     swapCreator,
+		swapCreatorAddr: cfg.SwapCreatorAddr,
+	}, nil
+}
+
+// ForChain returns the backend for swaps on the chain with the given ID. Zero
+// is the primary chain.
+func (b *backend) ForChain(chainID uint64) (Backend, error) {
+	if chainID == 0 || chainID == b.ethClient.ChainID().Uint64() {
+		return b, nil
+	}
+
+	cb, ok := b.chains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("swaps on chain ID %d are not enabled", chainID)
+	}
+
+	return cb, nil
+}
+
+func (b *chainBackend) ETHClient() extethclient.EthClient {
+	return b.ethClient
+}
+
+func (b *chainBackend) CheckToken(token ethcommon.Address) (*coins.ERC20TokenInfo, error) {
+	return checkToken(b.ctx, b.tokenPolicy, b.ethClient, token)
+}
+
+func (b *chainBackend) NewTxSender(asset ethcommon.Address, erc20Contract *contracts.IERC20) (txsender.Sender, error) {
+	return newTxSender(b.backend, b.ethClient, b.swapCreatorAddr, b.swapCreator, asset, erc20Contract)
+}
+
+func (b *chainBackend) NewSwapCreator(addr ethcommon.Address) (*contracts.SwapCreator, error) {
+	return contracts.NewSwapCreator(addr, b.ethClient.Raw())
+}
+
+func (b *chainBackend) SwapCreator() *contracts.SwapCreator {
+	return b.swapCreator
+}
+
+func (b *chainBackend) SwapCreatorAddr() ethcommon.Address {
+	return b.swapCreatorAddr
+}
+
+func (b *chainBackend) TransferETH(
+	to ethcommon.Address,
+	amount *coins.WeiAmount,
+	gasLimit *uint64,
+) (*ethtypes.Receipt, error) {
+	return b.ethClient.Transfer(b.ctx, to, amount, gasLimit)
+}
+
+func (b *chainBackend) SweepETH(to ethcommon.Address) (*ethtypes.Receipt, error) {
+	return b.ethClient.Sweep(b.ctx, to)
+}
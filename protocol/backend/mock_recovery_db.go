@@ -143,6 +143,21 @@ func (mr *MockRecoveryDBMockRecorder) GetSwapRelayerInfo(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSwapRelayerInfo", reflect.TypeOf((*MockRecoveryDB)(nil).GetSwapRelayerInfo), arg0)
 }
 
+// NextSwapKeyIndex mocks base method.
+func (m *MockRecoveryDB) NextSwapKeyIndex() (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextSwapKeyIndex")
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextSwapKeyIndex indicates an expected call of NextSwapKeyIndex.
+func (mr *MockRecoveryDBMockRecorder) NextSwapKeyIndex() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextSwapKeyIndex", reflect.TypeOf((*MockRecoveryDB)(nil).NextSwapKeyIndex))
+}
+
 // PutContractSwapInfo mocks base method.
 func (m *MockRecoveryDB) PutContractSwapInfo(arg0 common.Hash, arg1 *db.EthereumSwapInfo) error {
 	m.ctrl.T.Helper()
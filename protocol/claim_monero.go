@@ -92,6 +92,7 @@ func ClaimMonero(
 
 	log.Debugf("got %d sweep receipts", len(transfers))
 	for _, transfer := range transfers {
+		info.AddXMRTxID(transfer.TxID)
 		log.Infof("transferred %s XMR to primary wallet (%s XMR lost to fees)",
 			coins.FmtPiconeroAsXMR(transfer.Amount),
 			coins.FmtPiconeroAsXMR(transfer.Fee),
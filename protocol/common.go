@@ -9,9 +9,11 @@ import (
 	"fmt"
 
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/crypto/secp256k1"
 	"github.com/athanorlabs/atomic-swap/dleq"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
 )
 
 // KeysAndProof contains a DLEq proof, a secp256k1 public key,
@@ -32,6 +34,29 @@ func GenerateKeysAndProof() (*KeysAndProof, error) {
 		return nil, err
 	}
 
+	return keysAndProofFromProof(d, proof)
+}
+
+// DeriveKeysAndProof is like GenerateKeysAndProof, but the keys are derived
+// from the master seed with the swap's offer ID and the given swap key index,
+// so they can be recomputed from the seed alone. An index must never be used
+// twice.
+func DeriveKeysAndProof(seed *swapkeys.Seed, offerID types.Hash, index uint32) (*KeysAndProof, error) {
+	secret, err := seed.DeriveSecret(offerID, index)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dleq.DefaultDLEq{}
+	proof, err := d.ProveWithSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return keysAndProofFromProof(d, proof)
+}
+
+func keysAndProofFromProof(d *dleq.DefaultDLEq, proof *dleq.Proof) (*KeysAndProof, error) {
 	res, err := d.Verify(proof)
 	if err != nil {
 		return nil, err
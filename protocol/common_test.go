@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
 
 	"github.com/stretchr/testify/require"
 )
@@ -25,3 +27,30 @@ func TestKeysAndProof(t *testing.T) {
 	require.Equal(t, kp.PublicKeyPair.SpendKey().String(), res.Ed25519PublicKey.String())
 	require.Equal(t, [32]byte(common.Reverse(kp.PrivateKeyPair.SpendKey().Bytes())), kp.DLEqProof.Secret())
 }
+
+func TestDeriveKeysAndProof(t *testing.T) {
+	seed, err := swapkeys.NewSeed()
+	require.NoError(t, err)
+
+	offerID := types.Hash{0x1}
+
+	kp, err := DeriveKeysAndProof(seed, offerID, 3)
+	require.NoError(t, err)
+
+	_, err = VerifyKeysAndProof(kp.DLEqProof.Proof(), kp.Secp256k1PublicKey, kp.PublicKeyPair.SpendKey())
+	require.NoError(t, err)
+	require.Equal(t, [32]byte(common.Reverse(kp.PrivateKeyPair.SpendKey().Bytes())), kp.DLEqProof.Secret())
+
+	// the same index derives the same keys
+	kp2, err := DeriveKeysAndProof(seed, offerID, 3)
+	require.NoError(t, err)
+	require.Equal(t, kp.PrivateKeyPair.SpendKey().String(), kp2.PrivateKeyPair.SpendKey().String())
+
+	// the seed can find the keys from the commitment stored in the contract
+	commitment := kp.Secp256k1PublicKey.Keccak256()
+	found, next, err := seed.FindSecrets(map[[32]byte]types.Hash{commitment: offerID}, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), next)
+	require.Equal(t, uint32(3), found[commitment].Index)
+	require.Equal(t, kp.PrivateKeyPair.SpendKey().Bytes(), found[commitment].Secret[:])
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package swap
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/metrics"
+)
+
+var errJournalNotSupported = errors.New("swap journal is not supported by the database")
+
+// JournalEntryType is the kind of event recorded in a swap's journal.
+type JournalEntryType string
+
+// Journal entry types
+const (
+	// JournalStatus is recorded each time the swap's status changes.
+	JournalStatus JournalEntryType = "status"
+	// JournalMessageSent is recorded for each p2p message we send.
+	JournalMessageSent JournalEntryType = "message_sent"
+	// JournalMessageReceived is recorded for each p2p message we receive.
+	JournalMessageReceived JournalEntryType = "message_received"
+	// JournalEthTx is recorded for each ethereum transaction we send.
+	JournalEthTx JournalEntryType = "eth_tx"
+	// JournalTimeout is recorded when one of the swap's timeouts fires.
+	JournalTimeout JournalEntryType = "timeout"
+	// JournalError is recorded for each error that caused the swap to exit.
+	JournalError JournalEntryType = "error"
+	// JournalPeerAbort is recorded when the counterparty aborts the swap. The
+	// entry's status is the counterparty's status.
+	JournalPeerAbort JournalEntryType = "peer_abort"
+)
+
+// JournalEntry is a single, timestamped event in a swap's journal. Only the
+// fields relevant to the entry's Type are set. Entries never contain message
+// bodies, keys or secrets.
+type JournalEntry struct {
+	Time        time.Time        `json:"time" validate:"required"`
+	Type        JournalEntryType `json:"type" validate:"required"`
+	Status      *types.Status    `json:"status,omitempty"`
+	MessageType string           `json:"messageType,omitempty"`
+	TxType      string           `json:"txType,omitempty"`
+	TxHash      *types.Hash      `json:"txHash,omitempty"`
+	Detail      string           `json:"detail,omitempty"`
+	// ErrorClass is the metrics.ErrorClass of the error of JournalError entries.
+	ErrorClass string `json:"errorClass,omitempty"`
+}
+
+// JournalDatabase is implemented by databases that can persist swap
+// journals. If the Database passed to NewManager also implements this
+// interface, the manager records each swap's journal.
+type JournalDatabase interface {
+	AppendJournalEntry(id types.Hash, entry *JournalEntry) error
+	GetJournal(id types.Hash) ([]*JournalEntry, error)
+}
+
+// NewStatusJournalEntry returns a journal entry for a status change.
+func NewStatusJournalEntry(status types.Status) *JournalEntry {
+	return &JournalEntry{
+		Time:   time.Now(),
+		Type:   JournalStatus,
+		Status: &status,
+	}
+}
+
+// NewMessageJournalEntry returns a journal entry for a p2p message that was
+// sent or received. Only the message type is recorded.
+func NewMessageJournalEntry(entryType JournalEntryType, msgType string) *JournalEntry {
+	return &JournalEntry{
+		Time:        time.Now(),
+		Type:        entryType,
+		MessageType: msgType,
+	}
+}
+
+// NewEthTxJournalEntry returns a journal entry for an ethereum transaction.
+func NewEthTxJournalEntry(txType string, txHash types.Hash) *JournalEntry {
+	return &JournalEntry{
+		Time:   time.Now(),
+		Type:   JournalEthTx,
+		TxType: txType,
+		TxHash: &txHash,
+	}
+}
+
+// NewTimeoutJournalEntry returns a journal entry for a timeout firing.
+func NewTimeoutJournalEntry(timeout string) *JournalEntry {
+	return &JournalEntry{
+		Time:   time.Now(),
+		Type:   JournalTimeout,
+		Detail: timeout,
+	}
+}
+
+// NewErrorJournalEntry returns a journal entry for an error that caused the
+// swap to exit, with the error's class and its text with every 32-byte hex value
+// redacted. Keys and secrets are formatted like hashes, so hashes are redacted
+// too, but the swap's transactions are recorded by their JournalEthTx entries.
+func NewErrorJournalEntry(err error) *JournalEntry {
+	return &JournalEntry{
+		Time:       time.Now(),
+		Type:       JournalError,
+		Detail:     redactSecrets(err.Error()),
+		ErrorClass: metrics.ErrorClass(err),
+	}
+}
+
+// NewPeerAbortJournalEntry returns a journal entry for the abort of the swap
+// by the counterparty, with their reason and status.
+func NewPeerAbortJournalEntry(reason string, peerStatus types.Status) *JournalEntry {
+	return &JournalEntry{
+		Time:   time.Now(),
+		Type:   JournalPeerAbort,
+		Status: &peerStatus,
+		Detail: reason,
+	}
+}
+
+// secretRegex matches 32-byte values in hex, with or without a 0x prefix,
+// which is how keys and secrets get formatted.
+var secretRegex = regexp.MustCompile(`(0x)?[0-9a-fA-F]{64}`)
+
+// redactSecrets replaces anything in s that could be a private key or swap
+// secret with a placeholder.
+func redactSecrets(s string) string {
+	return secretRegex.ReplaceAllString(s, "[REDACTED]")
+}
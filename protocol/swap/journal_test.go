@@ -0,0 +1,28 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package swap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/ethereum/block"
+	"github.com/athanorlabs/atomic-swap/metrics"
+)
+
+func TestNewErrorJournalEntry_redacts(t *testing.T) {
+	const key = "8ab1b9e1cd0b36b3b0e7b0c5b1b0f32e57bd4a0e1f1e0d4c9a8b7c6d5e4f3a2b"
+	err := fmt.Errorf("claim with secret 0x%s failed, %w", key, block.ErrReverted)
+
+	entry := NewErrorJournalEntry(err)
+	require.Equal(t, JournalError, entry.Type)
+	require.Equal(t, "claim with secret [REDACTED] failed, transaction reverted", entry.Detail)
+	require.Equal(t, metrics.ErrorClassReverted, entry.ErrorClass)
+
+	entry = NewErrorJournalEntry(fmt.Errorf("bad key %s", key))
+	require.Equal(t, "bad key [REDACTED]", entry.Detail)
+	require.Equal(t, metrics.ErrorClassOther, entry.ErrorClass)
+}
@@ -0,0 +1,234 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package swap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+)
+
+// cursorLength is the length of a decoded cursor: the 8-byte start time of a
+// swap, in nanoseconds, followed by its 32-byte offer ID.
+const cursorLength = 8 + len(types.Hash{})
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// SortOrder is the order, by start time, in which past swaps are returned.
+type SortOrder string
+
+// Sort orders
+const (
+	SortNewestFirst SortOrder = "desc"
+	SortOldestFirst SortOrder = "asc"
+)
+
+// PastSwapsQuery filters and paginates past swaps. Zero-valued fields don't
+// filter anything.
+type PastSwapsQuery struct {
+	OfferID  *types.Hash
+	Statuses []types.Status
+	PeerID   peer.ID
+	EthAsset *types.EthAsset
+	// From and To filter on the swap's start time, both are inclusive.
+	From *time.Time
+	To   *time.Time
+	// Order defaults to SortNewestFirst.
+	Order SortOrder
+	// Limit is the maximum number of swaps returned. Zero means no limit.
+	Limit int
+	// Cursor is the NextCursor value of the previous page, or empty for the
+	// first page.
+	Cursor string
+}
+
+// PastSwapsPage is a page of past swaps.
+type PastSwapsPage struct {
+	Swaps []*Info
+	// NextCursor is set if there may be more swaps after this page.
+	NextCursor string
+}
+
+// Validate checks the query's sort order and cursor.
+func (q *PastSwapsQuery) Validate() error {
+	switch q.Order {
+	case "", SortNewestFirst, SortOldestFirst:
+	default:
+		return fmt.Errorf("invalid sort order %q", q.Order)
+	}
+
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", q.Limit)
+	}
+
+	if q.Cursor != "" {
+		if _, err := DecodeCursor(q.Cursor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Descending returns true if swaps should be returned newest first.
+func (q *PastSwapsQuery) Descending() bool {
+	return q.Order != SortOldestFirst
+}
+
+// Matches returns true if the swap is a past swap that passes all of the
+// query's filters. The cursor and limit are not considered.
+func (q *PastSwapsQuery) Matches(info *Info) bool {
+	if info.Status.IsOngoing() {
+		return false
+	}
+
+	if q.OfferID != nil && *q.OfferID != info.OfferID {
+		return false
+	}
+
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, s := range q.Statuses {
+			if s == info.Status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if q.PeerID != "" && q.PeerID != info.PeerID {
+		return false
+	}
+
+	if q.EthAsset != nil && *q.EthAsset != info.EthAsset {
+		return false
+	}
+
+	return q.InTimeRange(info.StartTime)
+}
+
+// InTimeRange returns true if the start time t is within the query's From/To
+// range.
+func (q *PastSwapsQuery) InTimeRange(t time.Time) bool {
+	if q.From != nil && t.Before(*q.From) {
+		return false
+	}
+
+	if q.To != nil && t.After(*q.To) {
+		return false
+	}
+
+	return true
+}
+
+// CursorKey returns the sort key of a swap, which past swaps are ordered by.
+// It's the swap's start time in nanoseconds, big-endian encoded, followed by
+// its offer ID.
+func CursorKey(startTime time.Time, offerID types.Hash) []byte {
+	key := make([]byte, cursorLength)
+	binary.BigEndian.PutUint64(key, uint64(startTime.UnixNano()))
+	copy(key[8:], offerID[:])
+	return key
+}
+
+// EncodeCursor returns the cursor string for the given sort key.
+func EncodeCursor(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+// DecodeCursor returns the sort key encoded in the cursor.
+func DecodeCursor(cursor string) ([]byte, error) {
+	key, err := hex.DecodeString(cursor)
+	if err != nil || len(key) != cursorLength {
+		return nil, errInvalidCursor
+	}
+
+	return key, nil
+}
+
+// AfterCursor returns true if the sort key comes after the cursor key in the
+// query's order. Any key comes after an empty cursor.
+func (q *PastSwapsQuery) AfterCursor(key []byte, cursorKey []byte) bool {
+	if cursorKey == nil {
+		return true
+	}
+
+	cmp := bytes.Compare(key, cursorKey)
+	if q.Descending() {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// PastSwapsDatabase is implemented by databases that index past swaps. If the
+// Database passed to NewManager also implements this interface, the manager
+// uses it to query past swaps instead of loading and filtering every swap.
+type PastSwapsDatabase interface {
+	QueryPastSwaps(q *PastSwapsQuery) (*PastSwapsPage, error)
+}
+
+// FilterPastSwaps filters, sorts and paginates the passed swaps. It is used
+// when the database has no index of past swaps.
+func FilterPastSwaps(swaps []*Info, q *PastSwapsQuery) (*PastSwapsPage, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	var cursorKey []byte
+	if q.Cursor != "" {
+		cursorKey, _ = DecodeCursor(q.Cursor)
+	}
+
+	type keyedInfo struct {
+		key  []byte
+		info *Info
+	}
+
+	matches := make([]keyedInfo, 0, len(swaps))
+	for _, info := range swaps {
+		if !q.Matches(info) {
+			continue
+		}
+
+		key := CursorKey(info.StartTime, info.OfferID)
+		if !q.AfterCursor(key, cursorKey) {
+			continue
+		}
+
+		matches = append(matches, keyedInfo{key: key, info: info})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		cmp := bytes.Compare(matches[i].key, matches[j].key)
+		if q.Descending() {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	page := &PastSwapsPage{Swaps: []*Info{}}
+	for _, m := range matches {
+		if q.Limit > 0 && len(page.Swaps) == q.Limit {
+			page.NextCursor = EncodeCursor(CursorKey(
+				page.Swaps[q.Limit-1].StartTime,
+				page.Swaps[q.Limit-1].OfferID,
+			))
+			break
+		}
+		page.Swaps = append(page.Swaps, m.info)
+	}
+
+	return page, nil
+}
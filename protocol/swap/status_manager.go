@@ -2,21 +2,33 @@ package swap
 
 import (
 	"sync"
+	"time"
 
 	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/metrics"
 )
 
+// statusSince records when a swap entered its current status
+type statusSince struct {
+	status types.Status
+	since  time.Time
+}
+
 // statusManager provides lookup for the status channels. Status channels are
 // ephemeral between runs of swapd.
 type statusManager struct {
 	mu             sync.Mutex
 	statusChannels map[types.Hash]chan Status
+	// current status of each swap whose status was pushed during this run,
+	// used to measure the time spent in each status
+	current map[types.Hash]statusSince
 }
 
 func newStatusManager() *statusManager {
 	return &statusManager{
 		mu:             sync.Mutex{},
 		statusChannels: make(map[types.Hash]chan Status),
+		current:        make(map[types.Hash]statusSince),
 	}
 }
 
@@ -50,6 +62,8 @@ func (sm *statusManager) DeleteStatusChan(offerID types.Hash) {
 
 // PushNewStatus adds a new status to the offer ID's channel
 func (sm *statusManager) PushNewStatus(offerID types.Hash, status types.Status) {
+	sm.observeStatusChange(offerID, status)
+
 	ch := sm.getStatusChan(offerID)
 	ch <- status
 	// If the status is not ongoing, existing subscribers will get the status
@@ -65,6 +79,30 @@ func (sm *statusManager) PushNewStatus(offerID types.Hash, status types.Status)
 	}
 }
 
+// observeStatusChange records the time the swap spent in its previous status,
+// if the previous status was pushed during this run of swapd.
+func (sm *statusManager) observeStatusChange(offerID types.Hash, status types.Status) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	prev, ok := sm.current[offerID]
+	if ok && prev.status == status {
+		return
+	}
+
+	if ok {
+		metrics.ObserveStageDuration(prev.status, now.Sub(prev.since))
+	}
+
+	if !status.IsOngoing() {
+		delete(sm.current, offerID)
+		return
+	}
+
+	sm.current[offerID] = statusSince{status: status, since: now}
+}
+
 // newStatusChannel creates a status channel using the the correct size
 func newStatusChannel() chan Status {
 	// The channel size should be large enough to handle the max number of
@@ -13,9 +13,13 @@ import (
 	"github.com/athanorlabs/atomic-swap/common/types"
 
 	"github.com/ChainSafe/chaindb"
+	logging "github.com/ipfs/go-log/v2"
 )
 
-var errNoSwapWithOfferID = errors.New("unable to find swap with given offer ID")
+var (
+	log                  = logging.Logger("swap")
+	errNoSwapWithOfferID = errors.New("unable to find swap with given offer ID")
+)
 
 // Manager tracks current and past swaps.
 type Manager interface {
@@ -23,6 +27,7 @@ type Manager interface {
 	WriteSwapToDB(info *Info) error
 	GetPastIDs() ([]types.Hash, error)
 	GetPastSwap(types.Hash) (*Info, error)
+	GetPastSwaps(q *PastSwapsQuery) (*PastSwapsPage, error)
 	GetOngoingSwap(hash types.Hash) (*Info, error)
 	GetOngoingSwapSnapshot(types.Hash) (*Info, error)
 	GetOngoingSwapOfferIDs() ([]*types.Hash, error)
@@ -32,6 +37,20 @@ type Manager interface {
 	GetStatusChan(offerID types.Hash) <-chan types.Status
 	DeleteStatusChan(offerID types.Hash)
 	PushNewStatus(offerID types.Hash, status types.Status)
+	AppendJournal(offerID types.Hash, entry *JournalEntry)
+	GetJournal(offerID types.Hash) ([]*JournalEntry, error)
+	AddListener(l Listener)
+}
+
+// Listener is notified of the lifecycle events of swaps. Its methods are
+// called synchronously from the swap's go process, so they must not block.
+type Listener interface {
+	// SwapAdded is called with a copy of each swap added to the manager.
+	SwapAdded(info *Info)
+	// JournalAppended is called with each entry appended to a swap's journal,
+	// which includes every status change, even when the database does not
+	// persist journals.
+	JournalAppended(offerID types.Hash, entry *JournalEntry)
 }
 
 // manager implements Manager.
@@ -39,11 +58,16 @@ type Manager interface {
 // are only stored in memory if they've completed during
 // this swapd run, or if they've recently been retrieved.
 type manager struct {
-	db Database
+	db      Database
+	journal JournalDatabase   // nil if db doesn't support journals
+	index   PastSwapsDatabase // nil if db doesn't index past swaps
 	sync.RWMutex
 	ongoing map[types.Hash]*Info
 	past    map[types.Hash]*Info
 	*statusManager
+
+	listenersMu sync.RWMutex
+	listeners   []Listener
 }
 
 var _ Manager = (*manager)(nil)
@@ -67,8 +91,13 @@ func NewManager(db Database) (Manager, error) {
 		ongoing[s.OfferID] = s
 	}
 
+	journal, _ := db.(JournalDatabase)
+	index, _ := db.(PastSwapsDatabase)
+
 	return &manager{
 		db:            db,
+		journal:       journal,
+		index:         index,
 		ongoing:       ongoing,
 		past:          make(map[types.Hash]*Info),
 		statusManager: newStatusManager(),
@@ -77,6 +106,24 @@ func NewManager(db Database) (Manager, error) {
 
 // AddSwap adds the given swap *Info to the Manager.
 func (m *manager) AddSwap(info *Info) error {
+	if err := m.addSwap(info); err != nil {
+		return err
+	}
+
+	// listeners get a copy, as the swap's go process keeps updating info
+	infoCopy, err := info.DeepCopy()
+	if err != nil {
+		log.Warnf("failed to copy swap %s for listeners: %s", info.OfferID, err)
+		return nil
+	}
+
+	m.forEachListener(func(l Listener) {
+		l.SwapAdded(infoCopy)
+	})
+	return nil
+}
+
+func (m *manager) addSwap(info *Info) error {
 	m.Lock()
 	defer m.Unlock()
 
@@ -147,6 +194,34 @@ func (m *manager) GetPastSwap(id types.Hash) (*Info, error) {
 	return s, nil
 }
 
+// GetPastSwaps returns a page of the past swaps matching the query. If the
+// query has an offer ID and there's no swap with that ID, an error is returned.
+func (m *manager) GetPastSwaps(q *PastSwapsQuery) (*PastSwapsPage, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	if q.OfferID != nil {
+		s, err := m.GetPastSwap(*q.OfferID)
+		if err != nil {
+			return nil, err
+		}
+
+		return FilterPastSwaps([]*Info{s}, q)
+	}
+
+	if m.index != nil {
+		return m.index.QueryPastSwaps(q)
+	}
+
+	stored, err := m.db.GetAllSwaps()
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterPastSwaps(stored, q)
+}
+
 // GetOngoingSwap returns the ongoing swap's *Info, if there is one. The
 // returned Info structure of an active swap can be modified as the swap's state
 // changes and should only be read or written by a single go process.
@@ -234,6 +309,56 @@ func (m *manager) CompleteOngoingSwap(info *Info) error {
 	return m.db.PutSwap(info)
 }
 
+// PushNewStatus records the status in the swap's journal and adds it to the
+// offer ID's status channel.
+func (m *manager) PushNewStatus(offerID types.Hash, status types.Status) {
+	m.AppendJournal(offerID, NewStatusJournalEntry(status))
+	m.statusManager.PushNewStatus(offerID, status)
+}
+
+// AppendJournal appends the entry to the swap's journal. Failures are logged,
+// as the journal is informational and should never interrupt a swap.
+func (m *manager) AppendJournal(offerID types.Hash, entry *JournalEntry) {
+	m.forEachListener(func(l Listener) {
+		l.JournalAppended(offerID, entry)
+	})
+
+	if m.journal == nil {
+		return
+	}
+
+	if err := m.journal.AppendJournalEntry(offerID, entry); err != nil {
+		log.Warnf("failed to append %s entry to journal of swap %s: %s", entry.Type, offerID, err)
+	}
+}
+
+// AddListener registers a listener for the lifecycle events of all swaps.
+func (m *manager) AddListener(l Listener) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+
+	m.listeners = append(m.listeners, l)
+}
+
+func (m *manager) forEachListener(fn func(l Listener)) {
+	m.listenersMu.RLock()
+	defer m.listenersMu.RUnlock()
+
+	for _, l := range m.listeners {
+		fn(l)
+	}
+}
+
+// GetJournal returns the journal of the swap with the given offer ID, oldest
+// entry first.
+func (m *manager) GetJournal(offerID types.Hash) ([]*JournalEntry, error) {
+	if m.journal == nil {
+		return nil, errJournalNotSupported
+	}
+
+	return m.journal.GetJournal(offerID)
+}
+
 // HasOngoingSwap returns true if the given ID is an ongoing swap.
 func (m *manager) HasOngoingSwap(id types.Hash) bool {
 	m.RLock()
@@ -5,6 +5,7 @@ package swap
 
 import (
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/apd/v3"
 
@@ -164,3 +165,112 @@ func TestManager_AddSwap_Past(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 2, len(ids))
 }
+
+func TestManager_GetPastSwaps_NoIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := NewMockDatabase(ctrl)
+
+	db.EXPECT().GetAllSwaps()
+	mgr, err := NewManager(db)
+	require.NoError(t, err)
+
+	newPastInfo := func(id byte, status types.Status, startTime time.Time) *Info {
+		info := NewInfo(
+			testPeerID,
+			types.Hash{id},
+			coins.ProvidesXMR,
+			apd.New(1, 0),
+			apd.New(10, 0),
+			coins.ToExchangeRate(apd.New(1, -1)), // 0.1
+			types.EthAssetETH,
+			status,
+			100,
+		)
+		info.StartTime = startTime
+		return info
+	}
+
+	now := time.Now()
+	infoA := newPastInfo(0x1, types.CompletedSuccess, now.Add(-3*time.Minute))
+	infoB := newPastInfo(0x2, types.CompletedRefund, now.Add(-2*time.Minute))
+	infoC := newPastInfo(0x3, types.CompletedSuccess, now.Add(-1*time.Minute))
+	infoD := newPastInfo(0x4, types.XMRLocked, now)
+	stored := []*Info{infoB, infoD, infoA, infoC}
+
+	// without an index, the manager filters all swaps in the database
+	db.EXPECT().GetAllSwaps().Return(stored, nil)
+	page, err := mgr.GetPastSwaps(&PastSwapsQuery{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []*Info{infoC, infoB}, page.Swaps)
+	require.NotEmpty(t, page.NextCursor)
+
+	db.EXPECT().GetAllSwaps().Return(stored, nil)
+	page, err = mgr.GetPastSwaps(&PastSwapsQuery{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Equal(t, []*Info{infoA}, page.Swaps)
+	require.Empty(t, page.NextCursor)
+
+	db.EXPECT().GetAllSwaps().Return(stored, nil)
+	page, err = mgr.GetPastSwaps(&PastSwapsQuery{
+		Statuses: []types.Status{types.CompletedSuccess},
+		Order:    SortOldestFirst,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*Info{infoA, infoC}, page.Swaps)
+
+	_, err = mgr.GetPastSwaps(&PastSwapsQuery{Order: "sideways"})
+	require.ErrorContains(t, err, "invalid sort order")
+}
+
+type testListener struct {
+	added   []*Info
+	entries []*JournalEntry
+}
+
+func (l *testListener) SwapAdded(info *Info) {
+	l.added = append(l.added, info)
+}
+
+func (l *testListener) JournalAppended(_ types.Hash, entry *JournalEntry) {
+	l.entries = append(l.entries, entry)
+}
+
+func TestManager_AddListener(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := NewMockDatabase(ctrl)
+
+	db.EXPECT().GetAllSwaps()
+	mgr, err := NewManager(db)
+	require.NoError(t, err)
+
+	l := new(testListener)
+	mgr.AddListener(l)
+
+	info := NewInfo(
+		testPeerID,
+		types.Hash{0x1},
+		coins.ProvidesXMR,
+		apd.New(1, 0),
+		apd.New(10, 0),
+		coins.ToExchangeRate(apd.New(1, -1)), // 0.1
+		types.EthAssetETH,
+		types.KeysExchanged,
+		100,
+	)
+	db.EXPECT().PutSwap(info)
+	require.NoError(t, mgr.AddSwap(info))
+
+	// the listener gets a copy of the swap
+	require.Len(t, l.added, 1)
+	require.Equal(t, info.OfferID, l.added[0].OfferID)
+	require.NotSame(t, info, l.added[0])
+
+	// the mock database doesn't support journals, but listeners still get
+	// every entry
+	mgr.PushNewStatus(info.OfferID, types.XMRLocked)
+	require.Len(t, l.entries, 1)
+	require.Equal(t, JournalStatus, l.entries[0].Type)
+	require.Equal(t, types.XMRLocked, *l.entries[0].Status)
+}
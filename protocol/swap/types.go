@@ -33,19 +33,22 @@ type (
 // Info contains the details of the swap as well as its status.
 type Info struct {
 	Version        *semver.Version     `json:"version"`
-	PeerID         peer.ID             `json:"peerID" validate:"required"`
+	PeerID         peer.ID             `json:"peerID,omitempty" validate:"required_unless=Rebuilt true"`
 	OfferID        types.Hash          `json:"offerID" validate:"required"`
 	Provides       coins.ProvidesCoin  `json:"provides" validate:"required"`
-	ProvidedAmount *apd.Decimal        `json:"providedAmount" validate:"required"`
-	ExpectedAmount *apd.Decimal        `json:"expectedAmount" validate:"required"`
+	ProvidedAmount *apd.Decimal        `json:"providedAmount,omitempty" validate:"required_unless=Rebuilt true"`
+	ExpectedAmount *apd.Decimal        `json:"expectedAmount,omitempty" validate:"required_unless=Rebuilt true"`
 	RelayerFee     *apd.Decimal        `json:"relayerFee,omitempty"`
-	ExchangeRate   *coins.ExchangeRate `json:"exchangeRate" validate:"required"`
+	ExchangeRate   *coins.ExchangeRate `json:"exchangeRate,omitempty" validate:"required_unless=Rebuilt true"`
 	EthAsset       types.EthAsset      `json:"ethAsset"`
 	Status         Status              `json:"status" validate:"required"`
+	// ChainID is the ID of the chain that the ETH side of the swap is on. Zero
+	// is the primary chain of the environment.
+	ChainID uint64 `json:"chainID,omitempty"`
 	// LastStatusUpdateTime is the time at which the status was last updated.
 	LastStatusUpdateTime time.Time `json:"lastStatusUpdateTime" validate:"required"`
 	// MoneroStartHeight is the Monero block number when the swap begins.
-	MoneroStartHeight uint64 `json:"moneroStartHeight" validate:"required"`
+	MoneroStartHeight uint64 `json:"moneroStartHeight" validate:"required_unless=Rebuilt true"`
 	// StartTime is the time at which the swap is initiated via
 	// key exchange.
 	// This may vary slightly between the maker/taker.
@@ -64,6 +67,19 @@ type Info struct {
 	// after this timeout, the ETH-taker can no longer claim, only
 	// the ETH-maker can refund.
 	Timeout2 *time.Time `json:"timeout2,omitempty"`
+	// EthTxHashes are the hashes of the Ethereum transactions sent by us (or
+	// by a relayer on our behalf) during the swap, in the order they were sent.
+	EthTxHashes []types.Hash `json:"ethTxHashes,omitempty"`
+	// XMRTxIDs are the IDs of the Monero transactions sent by us during the
+	// swap, including the lock transfer and any sweeps out of the swap wallet.
+	XMRTxIDs []string `json:"xmrTxIDs,omitempty"`
+	// Rebuilt is set on entries that were recreated from SwapCreator events
+	// after the database was lost. Their OfferID is the contract swap ID, and
+	// details that are not on chain are unset: the peer ID, the Monero start
+	// height, the XMR amount and the exchange rate.
+	Rebuilt bool `json:"rebuilt,omitempty"`
+	// PeerAbort is set if the counterparty told us that they aborted the swap.
+	PeerAbort *PeerAbort `json:"peerAbort,omitempty"`
 
 	// rwMu handles synchronization when LastStatusUpdateTime, Timeout1,
 	// Timeout2 and EndTime are updated. This Info struct is modified by the
@@ -77,6 +93,17 @@ type Info struct {
 	rwMu sync.RWMutex
 }
 
+// PeerAbort is the abort of a swap by the counterparty, as they reported it.
+type PeerAbort struct {
+	Time time.Time `json:"time" validate:"required"`
+	// Reason is the reason code of the counterparty, like "cancelled" or
+	// "error".
+	Reason string `json:"reason" validate:"required"`
+	// Status is the status of the swap for the counterparty when they
+	// aborted it.
+	Status Status `json:"status"`
+}
+
 // NewInfo creates a new *Info from the given parameters.
 // Note that the swap ID is the same as the offer ID.
 func NewInfo(
@@ -147,6 +174,37 @@ func (i *Info) SetRelayerFee(relayerFee *apd.Decimal) {
 	i.RelayerFee = relayerFee
 }
 
+// AddEthTxHash appends the hash of an Ethereum transaction sent for this swap,
+// grabbing the needed lock before modifying fields.
+func (i *Info) AddEthTxHash(txHash types.Hash) {
+	i.rwMu.Lock()
+	defer i.rwMu.Unlock()
+
+	i.EthTxHashes = append(i.EthTxHashes, txHash)
+}
+
+// AddXMRTxID appends the ID of a Monero transaction sent for this swap,
+// grabbing the needed lock before modifying fields.
+func (i *Info) AddXMRTxID(txID string) {
+	i.rwMu.Lock()
+	defer i.rwMu.Unlock()
+
+	i.XMRTxIDs = append(i.XMRTxIDs, txID)
+}
+
+// SetPeerAbort records that the counterparty aborted the swap, grabbing the
+// needed lock before modifying fields.
+func (i *Info) SetPeerAbort(reason string, peerStatus Status) {
+	i.rwMu.Lock()
+	defer i.rwMu.Unlock()
+
+	i.PeerAbort = &PeerAbort{
+		Time:   time.Now(),
+		Reason: reason,
+		Status: peerStatus,
+	}
+}
+
 // IsTaker returns true if the node is the xmr-taker in the swap.
 func (i *Info) IsTaker() bool {
 	return i.Provides == coins.ProvidesETH
@@ -71,3 +71,59 @@ func TestUnmarshalInfo_versionTooNew(t *testing.T) {
 	_, err := UnmarshalInfo([]byte(offerJSON))
 	require.ErrorContains(t, err, fmt.Sprintf("info version %q not supported", unsupportedVersion))
 }
+
+func TestInfo_rebuiltWithoutPeerID(t *testing.T) {
+	info := NewInfo(
+		"",
+		types.Hash{0x1},
+		coins.ProvidesETH,
+		apd.New(1, 0),
+		nil,
+		nil,
+		types.EthAssetETH,
+		types.CompletedRefund,
+		0,
+	)
+
+	// only rebuilt entries may omit details that are not on chain
+	_, err := vjson.MarshalStruct(info)
+	require.Error(t, err)
+
+	info.Rebuilt = true
+	infoBytes, err := vjson.MarshalStruct(info)
+	require.NoError(t, err)
+	require.NotContains(t, string(infoBytes), "peerID")
+	require.NotContains(t, string(infoBytes), "exchangeRate")
+
+	res, err := UnmarshalInfo(infoBytes)
+	require.NoError(t, err)
+	require.True(t, res.Rebuilt)
+	require.Empty(t, res.PeerID)
+	require.Nil(t, res.ExpectedAmount)
+}
+
+func TestInfo_SetPeerAbort(t *testing.T) {
+	info := NewInfo(
+		testPeerID,
+		types.Hash{0x1},
+		coins.ProvidesETH,
+		apd.New(1, 0),
+		apd.New(2, 0),
+		coins.ToExchangeRate(apd.New(5, -1)),
+		types.EthAssetETH,
+		types.ETHLocked,
+		100,
+	)
+	info.SetPeerAbort("cancelled", types.XMRLocked)
+
+	infoBytes, err := vjson.MarshalStruct(info)
+	require.NoError(t, err)
+	require.Contains(t, string(infoBytes), `"reason":"cancelled","status":"XMRLocked"`)
+
+	res, err := info.DeepCopy()
+	require.NoError(t, err)
+	require.NotNil(t, res.PeerAbort)
+	require.Equal(t, "cancelled", res.PeerAbort.Reason)
+	require.Equal(t, types.XMRLocked, res.PeerAbort.Status)
+	require.True(t, info.PeerAbort.Time.Equal(res.PeerAbort.Time))
+}
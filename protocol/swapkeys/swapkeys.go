@@ -0,0 +1,239 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package swapkeys derives the per-swap secrets of a swap node from a master
+// seed, so that a node restored from its seed can recompute the secret of
+// every swap it took part in, even without its database.
+package swapkeys
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/crypto/secp256k1"
+)
+
+// SeedSize is the size of a master seed in bytes
+const SeedSize = 32
+
+// DefaultGapLimit is the default number of consecutive unused indexes after
+// which FindSecrets stops searching.
+const DefaultGapLimit = 1000
+
+// secretDomain separates swap secrets from any other use of the seed
+const secretDomain = "atomic-swap/swap-secret/v3"
+
+var (
+	errInvalidSeedLength = fmt.Errorf("seed must be %d bytes", SeedSize)
+	errZeroSecret        = errors.New("derived swap secret is zero")
+	errZeroGapLimit      = errors.New("gap limit must be non-zero")
+	errIndexesExhausted  = errors.New("no swap secret indexes are left")
+)
+
+// Seed is the master seed that swap secrets are derived from.
+type Seed struct {
+	b [SeedSize]byte
+}
+
+// NewSeed returns a new random seed.
+func NewSeed() (*Seed, error) {
+	s := new(Seed)
+	if _, err := rand.Read(s.b[:]); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSeedFromBytes returns the seed with the given bytes.
+func NewSeedFromBytes(b []byte) (*Seed, error) {
+	if len(b) != SeedSize {
+		return nil, errInvalidSeedLength
+	}
+	s := new(Seed)
+	copy(s.b[:], b)
+	return s, nil
+}
+
+// NewSeedFromHex returns the seed with the given hex encoding, with or
+// without a 0x prefix.
+func NewSeedFromHex(h string) (*Seed, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(h), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed: %w", err)
+	}
+	return NewSeedFromBytes(b)
+}
+
+// Hex returns the seed encoded as hex, without a 0x prefix.
+func (s *Seed) Hex() string {
+	return hex.EncodeToString(s.b[:])
+}
+
+// LoadSeedFile reads the hex encoded seed from the given file.
+func LoadSeedFile(file string) (*Seed, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read swap seed file: %w", err)
+	}
+
+	seed, err := NewSeedFromHex(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read swap seed file %s: %w", file, err)
+	}
+
+	return seed, nil
+}
+
+// LoadOrCreateSeedFile reads the hex encoded seed from the given file. If the
+// file does not exist, a new random seed is created and written to it.
+func LoadOrCreateSeedFile(file string) (seed *Seed, created bool, err error) {
+	exists, err := common.FileExists(file)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if exists {
+		seed, err = LoadSeedFile(file)
+		return seed, false, err
+	}
+
+	seed, err = NewSeed()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = os.WriteFile(file, []byte(seed.Hex()), 0600); err != nil {
+		return nil, false, err
+	}
+
+	return seed, true, nil
+}
+
+// DeriveSecret returns the swap secret of the given offer ID and swap key
+// index. The index is global to the node, so it is incremented for every swap
+// whatever the role or offer, and an offer that is taken more than once still
+// gets a new secret every time. Recovery finds the secret of a swap from the
+// offer ID that the taker stores as the nonce of the swap contract, by deriving
+// the secrets of successive indexes until one matches a commitment in the
+// contract.
+//
+// The secret is little-endian and has its top 4 bits cleared, so it is a
+// valid scalar on both the ed25519 and secp256k1 curves, as required by the
+// DLEq proof.
+func (s *Seed) DeriveSecret(offerID types.Hash, index uint32) ([32]byte, error) {
+	var secret [32]byte
+
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+
+	mac := hmac.New(sha512.New, s.b[:])
+	mac.Write([]byte(secretDomain))
+	mac.Write(offerID[:])
+	mac.Write(indexBytes[:])
+	copy(secret[:], mac.Sum(nil))
+
+	// 252 bits, the bit size of the ed25519 group order
+	secret[31] &= 0x0f
+
+	if secret == [32]byte{} {
+		return secret, errZeroSecret
+	}
+
+	return secret, nil
+}
+
+// Commitment returns the commitment to a swap secret that is stored in the
+// swap contract, which is the keccak256 hash of the secret's secp256k1 public
+// key.
+func Commitment(secret [32]byte) ([32]byte, error) {
+	// the secret is little-endian, while go-ethereum expects big-endian
+	key, err := ethcrypto.ToECDSA(common.Reverse(secret[:]))
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	return secp256k1.NewPublicKeyFromBigInt(key.X, key.Y).Keccak256(), nil
+}
+
+// FoundSecret is a swap secret derived from the seed that matched a
+// commitment.
+type FoundSecret struct {
+	Index  uint32
+	Secret [32]byte
+}
+
+// FindSecrets derives the secrets of successive indexes, starting from zero,
+// and returns the ones that match the given commitments from the swap
+// contract. The commitments are mapped to the offer ID of their swap, and at
+// each index, a secret is derived for every offer ID whose commitments weren't
+// all found yet. Swaps that failed before any funds were locked leave no
+// commitment on chain, so the search only stops once every commitment was
+// found, or after gapLimit consecutive indexes without a match.
+//
+// The returned next index is the one after the last match. Indexes after it
+// were at most used by swaps that failed before any funds were locked, which
+// never revealed their secrets, so new secrets can be derived from it.
+func (s *Seed) FindSecrets(
+	commitments map[[32]byte]types.Hash,
+	gapLimit uint32,
+) (found map[[32]byte]*FoundSecret, nextIndex uint32, err error) {
+	if gapLimit == 0 {
+		return nil, 0, errZeroGapLimit
+	}
+
+	// the number of commitments of each offer ID that are left to find
+	left := make(map[types.Hash]int)
+	for _, offerID := range commitments {
+		left[offerID]++
+	}
+
+	found = make(map[[32]byte]*FoundSecret)
+
+	var next uint64
+	for index := uint64(0); index < next+uint64(gapLimit) && index <= math.MaxUint32; index++ {
+		if len(left) == 0 {
+			break
+		}
+
+		for offerID := range left {
+			secret, err := s.DeriveSecret(offerID, uint32(index)) //nolint:govet
+			if err != nil {
+				return nil, 0, err
+			}
+
+			c, err := Commitment(secret)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if cOfferID, has := commitments[c]; !has || cOfferID != offerID {
+				continue
+			}
+
+			found[c] = &FoundSecret{Index: uint32(index), Secret: secret}
+			next = index + 1
+			if left[offerID]--; left[offerID] == 0 {
+				delete(left, offerID)
+			}
+		}
+	}
+
+	if next > math.MaxUint32 {
+		return nil, 0, errIndexesExhausted
+	}
+
+	return found, uint32(next), nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package swapkeys
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/common/types"
+)
+
+func TestSeed_DeriveSecret(t *testing.T) {
+	seed, err := NewSeedFromHex("0x000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	require.NoError(t, err)
+	offerID := types.Hash{0x1}
+
+	secret, err := seed.DeriveSecret(offerID, 0)
+	require.NoError(t, err)
+	require.Zero(t, secret[31]&0xf0)
+
+	again, err := seed.DeriveSecret(offerID, 0)
+	require.NoError(t, err)
+	require.Equal(t, secret, again)
+
+	other, err := seed.DeriveSecret(offerID, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+
+	other, err = seed.DeriveSecret(types.Hash{0x2}, 0)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+
+	otherSeed, err := NewSeed()
+	require.NoError(t, err)
+	other, err = otherSeed.DeriveSecret(offerID, 0)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+}
+
+func testCommitment(t *testing.T, seed *Seed, offerID types.Hash, index uint32) [32]byte {
+	secret, err := seed.DeriveSecret(offerID, index)
+	require.NoError(t, err)
+	c, err := Commitment(secret)
+	require.NoError(t, err)
+	return c
+}
+
+func TestSeed_FindSecrets(t *testing.T) {
+	seed, err := NewSeed()
+	require.NoError(t, err)
+
+	offerA := types.Hash{0xa}
+	offerB := types.Hash{0xb}
+	swaps := []struct {
+		offerID types.Hash
+		index   uint32
+	}{
+		{offerA, 0},
+		{offerB, 3},
+		// offer A was taken again
+		{offerA, 8},
+	}
+
+	// indexes 1, 2, 4 and 7 were used by swaps that never locked funds
+	commitments := map[[32]byte]types.Hash{
+		// a commitment that isn't ours
+		{0x1}: offerA,
+		// one of our commitments, but with another offer ID
+		testCommitment(t, seed, offerB, 5): offerA,
+	}
+	for _, s := range swaps {
+		commitments[testCommitment(t, seed, s.offerID, s.index)] = s.offerID
+	}
+
+	found, next, err := seed.FindSecrets(commitments, 5)
+	require.NoError(t, err)
+	require.Equal(t, uint32(9), next)
+	require.Len(t, found, 3)
+	for _, s := range swaps {
+		secret, err := seed.DeriveSecret(s.offerID, s.index) //nolint:govet
+		require.NoError(t, err)
+		f := found[testCommitment(t, seed, s.offerID, s.index)]
+		require.NotNil(t, f)
+		require.Equal(t, s.index, f.Index)
+		require.Equal(t, secret, f.Secret)
+	}
+
+	// the gap between indexes 3 and 8 is too large
+	found, next, err = seed.FindSecrets(commitments, 4)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), next)
+	require.Len(t, found, 2)
+
+	found, next, err = seed.FindSecrets(nil, 5)
+	require.NoError(t, err)
+	require.Zero(t, next)
+	require.Empty(t, found)
+
+	_, _, err = seed.FindSecrets(commitments, 0)
+	require.ErrorIs(t, err, errZeroGapLimit)
+}
+
+func TestLoadOrCreateSeedFile(t *testing.T) {
+	file := path.Join(t.TempDir(), "swap-seed.key")
+
+	seed, created, err := LoadOrCreateSeedFile(file)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	loaded, created, err := LoadOrCreateSeedFile(file)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, seed.Hex(), loaded.Hex())
+}
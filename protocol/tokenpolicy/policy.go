@@ -0,0 +1,192 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package tokenpolicy decides which ERC20 tokens swaps can be made with. Tokens
+// are checked against per-chain allow and deny lists, and probed for transfer
+// behaviour that the SwapCreator contract can't account for before the first
+// swap with them.
+package tokenpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+)
+
+// probeTTL is how long a probe is cached. Tokens behind upgradeable proxies
+// can change their behaviour, so they are probed again after it.
+const probeTTL = 7 * 24 * time.Hour
+
+var log = logging.Logger("tokenpolicy")
+
+// Database caches the probes of tokens.
+type Database interface {
+	PutTokenProbe(p *Probe) error
+	// GetTokenProbe returns chaindb.ErrKeyNotFound if the token was not probed.
+	GetTokenProbe(chainID uint64, token ethcommon.Address) (*Probe, error)
+}
+
+// Lists are the allowed and denied tokens of a chain. If Allowed is not
+// empty, only its tokens can be swapped. Denied tokens never can.
+type Lists struct {
+	Allowed []ethcommon.Address
+	Denied  []ethcommon.Address
+}
+
+// Config contains the configuration of a Policy.
+type Config struct {
+	DB Database
+	// Lists are the token lists by chain ID. Chains without lists allow every
+	// token that passes the probe.
+	Lists map[uint64]*Lists
+	// AllowUnprobed accepts tokens that could not be probed, eg. because
+	// the ethereum node does not support state overrides. Tokens that are on
+	// the allow list of their chain are always accepted then.
+	AllowUnprobed bool
+}
+
+// Policy decides which ERC20 tokens swaps can be made with.
+type Policy struct {
+	db            Database
+	allowUnprobed bool
+	probe         func(ctx context.Context, ec extethclient.EthClient, token ethcommon.Address) (*Probe, error)
+
+	mu      sync.RWMutex
+	allowed map[uint64]map[ethcommon.Address]struct{}
+	denied  map[uint64]map[ethcommon.Address]struct{}
+}
+
+// NewPolicy returns a new Policy.
+func NewPolicy(cfg *Config) *Policy {
+	p := &Policy{
+		db:            cfg.DB,
+		allowUnprobed: cfg.AllowUnprobed,
+		probe:         ProbeToken,
+	}
+	p.SetLists(cfg.Lists)
+	return p
+}
+
+// SetLists replaces the token lists of all chains.
+func (p *Policy) SetLists(lists map[uint64]*Lists) {
+	allowed := make(map[uint64]map[ethcommon.Address]struct{})
+	denied := make(map[uint64]map[ethcommon.Address]struct{})
+
+	toSet := func(addrs []ethcommon.Address) map[ethcommon.Address]struct{} {
+		set := make(map[ethcommon.Address]struct{}, len(addrs))
+		for _, addr := range addrs {
+			set[addr] = struct{}{}
+		}
+		return set
+	}
+
+	for chainID, l := range lists {
+		allowed[chainID] = toSet(l.Allowed)
+		denied[chainID] = toSet(l.Denied)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.allowed = allowed
+	p.denied = denied
+}
+
+// checkLists returns an error if the token is not allowed on the chain, and
+// whether it is explicitly allowed.
+func (p *Policy) checkLists(chainID uint64, token ethcommon.Address) (bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := p.denied[chainID][token]; ok {
+		return false, fmt.Errorf("token %s is denied on chain ID %d", token, chainID)
+	}
+
+	allowed := p.allowed[chainID]
+	if len(allowed) == 0 {
+		return false, nil
+	}
+
+	if _, ok := allowed[token]; !ok {
+		return false, fmt.Errorf("token %s is not on the allow list of chain ID %d", token, chainID)
+	}
+
+	return true, nil
+}
+
+// Check returns the ERC20 info of the token on the chain of the client, or an
+// error if swaps can't be made with it. The token is probed with the client
+// if it has no recent probe in the database.
+func (p *Policy) Check(
+	ctx context.Context,
+	ec extethclient.EthClient,
+	token ethcommon.Address,
+) (*coins.ERC20TokenInfo, error) {
+	chainID := ec.ChainID().Uint64()
+
+	explicitlyAllowed, err := p.checkLists(chainID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	probe, err := p.getProbe(ctx, ec, chainID, token)
+	if err != nil {
+		if !p.allowUnprobed && !explicitlyAllowed {
+			return nil, err
+		}
+		log.Warnf("accepting token %s that could not be probed: %s", token, err)
+		return ec.ERC20Info(ctx, token)
+	}
+
+	if err = probe.Unsupported(); err != nil {
+		if probe.Error == "" || (!p.allowUnprobed && !explicitlyAllowed) {
+			return nil, err
+		}
+		log.Warnf("accepting token that could not be probed: %s", err)
+	}
+
+	return probe.Token, nil
+}
+
+// getProbe returns the cached probe of the token, or else probes it and caches
+// the result. Failed requests are not cached.
+func (p *Policy) getProbe(
+	ctx context.Context,
+	ec extethclient.EthClient,
+	chainID uint64,
+	token ethcommon.Address,
+) (*Probe, error) {
+	probe, err := p.db.GetTokenProbe(chainID, token)
+	switch {
+	case err == nil && time.Since(probe.ProbedAt) < probeTTL:
+		return probe, nil
+	case err != nil && !errors.Is(err, chaindb.ErrKeyNotFound):
+		return nil, err
+	}
+
+	probe, err = p.probe(ctx, ec, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if probe.Error != "" {
+		log.Warnf("could not probe token %s on chain ID %d: %s", token, chainID, probe.Error)
+	} else {
+		log.Infof("probed token %s (%s) on chain ID %d: fee on transfer=%t, rebasing=%t, transfer returns %s",
+			token, probe.Token.Symbol, chainID, probe.FeeOnTransfer, probe.Rebasing, probe.TransferReturn)
+	}
+
+	if err = p.db.PutTokenProbe(probe); err != nil {
+		return nil, err
+	}
+
+	return probe, nil
+}
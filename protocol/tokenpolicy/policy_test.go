@@ -0,0 +1,179 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tokenpolicy
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+)
+
+type probeKey struct {
+	chainID uint64
+	token   ethcommon.Address
+}
+
+type mockDB struct {
+	probes map[probeKey]*Probe
+}
+
+func (db *mockDB) PutTokenProbe(p *Probe) error {
+	db.probes[probeKey{p.ChainID, p.Token.Address}] = p
+	return nil
+}
+
+func (db *mockDB) GetTokenProbe(chainID uint64, token ethcommon.Address) (*Probe, error) {
+	p, ok := db.probes[probeKey{chainID, token}]
+	if !ok {
+		return nil, chaindb.ErrKeyNotFound
+	}
+	return p, nil
+}
+
+// mockClient only implements the methods of the ethereum client that the
+// policy calls outside of probes
+type mockClient struct {
+	extethclient.EthClient
+	chainID uint64
+}
+
+func (c *mockClient) ChainID() *big.Int {
+	return new(big.Int).SetUint64(c.chainID)
+}
+
+func (c *mockClient) ERC20Info(_ context.Context, token ethcommon.Address) (*coins.ERC20TokenInfo, error) {
+	return coins.NewERC20TokenInfo(token, 18, "Test Token", "TEST"), nil
+}
+
+// newTestPolicy returns a policy whose probes return the probe of the passed
+// function, and counts them.
+func newTestPolicy(cfg *Config, probe func(token ethcommon.Address) (*Probe, error)) (*Policy, *int) {
+	numProbes := 0
+	cfg.DB = &mockDB{probes: make(map[probeKey]*Probe)}
+	p := NewPolicy(cfg)
+	p.probe = func(_ context.Context, ec extethclient.EthClient, token ethcommon.Address) (*Probe, error) {
+		numProbes++
+		res, err := probe(token)
+		if err != nil {
+			return nil, err
+		}
+		res.ChainID = ec.ChainID().Uint64()
+		res.Token = coins.NewERC20TokenInfo(token, 18, "Test Token", "TEST")
+		res.ProbedAt = time.Now()
+		return res, nil
+	}
+	return p, &numProbes
+}
+
+func TestPolicy_Check_lists(t *testing.T) {
+	ctx := context.Background()
+	tokenA := ethcommon.Address{0xa}
+	tokenB := ethcommon.Address{0xb}
+	ec := &mockClient{chainID: 1}
+
+	p, _ := newTestPolicy(&Config{
+		Lists: map[uint64]*Lists{
+			1:  {Allowed: []ethcommon.Address{tokenA}},
+			10: {Denied: []ethcommon.Address{tokenA}},
+		},
+	}, func(_ ethcommon.Address) (*Probe, error) {
+		return &Probe{TransferReturn: ReturnBool}, nil
+	})
+
+	info, err := p.Check(ctx, ec, tokenA)
+	require.NoError(t, err)
+	require.Equal(t, tokenA, info.Address)
+
+	_, err = p.Check(ctx, ec, tokenB)
+	require.ErrorContains(t, err, "is not on the allow list of chain ID 1")
+
+	// lists are per chain
+	_, err = p.Check(ctx, &mockClient{chainID: 10}, tokenA)
+	require.ErrorContains(t, err, "is denied on chain ID 10")
+	_, err = p.Check(ctx, &mockClient{chainID: 10}, tokenB)
+	require.NoError(t, err)
+
+	p.SetLists(map[uint64]*Lists{1: {Denied: []ethcommon.Address{tokenA}}})
+	_, err = p.Check(ctx, ec, tokenA)
+	require.ErrorContains(t, err, "is denied on chain ID 1")
+	_, err = p.Check(ctx, ec, tokenB)
+	require.NoError(t, err)
+}
+
+func TestPolicy_Check_probes(t *testing.T) {
+	ctx := context.Background()
+	feeToken := ethcommon.Address{0xf}
+	unprobedToken := ethcommon.Address{0xe}
+	failingToken := ethcommon.Address{0xd}
+	ec := &mockClient{chainID: 1}
+
+	probe := func(token ethcommon.Address) (*Probe, error) {
+		switch token {
+		case feeToken:
+			return &Probe{FeeOnTransfer: true, TransferReturn: ReturnBool}, nil
+		case unprobedToken:
+			return &Probe{Error: "the transfer reverted"}, nil
+		case failingToken:
+			return nil, errors.New("state overrides are not supported")
+		}
+		return &Probe{TransferReturn: ReturnNone}, nil
+	}
+
+	p, numProbes := newTestPolicy(&Config{}, probe)
+
+	_, err := p.Check(ctx, ec, ethcommon.Address{0x1})
+	require.NoError(t, err)
+	_, err = p.Check(ctx, ec, feeToken)
+	require.ErrorContains(t, err, "charges a fee on transfers")
+	_, err = p.Check(ctx, ec, unprobedToken)
+	require.ErrorContains(t, err, "could not be probed: the transfer reverted")
+	_, err = p.Check(ctx, ec, failingToken)
+	require.ErrorContains(t, err, "state overrides are not supported")
+	require.Equal(t, 4, *numProbes)
+
+	// probes are cached, except for failed requests
+	_, err = p.Check(ctx, ec, ethcommon.Address{0x1})
+	require.NoError(t, err)
+	_, err = p.Check(ctx, ec, feeToken)
+	require.Error(t, err)
+	_, err = p.Check(ctx, ec, unprobedToken)
+	require.Error(t, err)
+	_, err = p.Check(ctx, ec, failingToken)
+	require.Error(t, err)
+	require.Equal(t, 5, *numProbes)
+
+	// old probes are not used
+	p.db.(*mockDB).probes[probeKey{1, feeToken}].ProbedAt = time.Now().Add(-probeTTL)
+	_, err = p.Check(ctx, ec, feeToken)
+	require.Error(t, err)
+	require.Equal(t, 6, *numProbes)
+
+	// tokens that could not be probed are accepted if explicitly allowed, but
+	// tokens with unsupported behaviour never are
+	p.SetLists(map[uint64]*Lists{1: {Allowed: []ethcommon.Address{feeToken, unprobedToken, failingToken}}})
+	_, err = p.Check(ctx, ec, unprobedToken)
+	require.NoError(t, err)
+	_, err = p.Check(ctx, ec, failingToken)
+	require.NoError(t, err)
+	_, err = p.Check(ctx, ec, feeToken)
+	require.ErrorContains(t, err, "charges a fee on transfers")
+
+	// or with AllowUnprobed
+	p, _ = newTestPolicy(&Config{AllowUnprobed: true}, probe)
+	_, err = p.Check(ctx, ec, unprobedToken)
+	require.NoError(t, err)
+	_, err = p.Check(ctx, ec, failingToken)
+	require.NoError(t, err)
+	_, err = p.Check(ctx, ec, feeToken)
+	require.ErrorContains(t, err, "charges a fee on transfers")
+}
@@ -0,0 +1,307 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tokenpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+)
+
+// Return values of a token's transfer function
+const (
+	// ReturnBool is the standard return value, true on success
+	ReturnBool = "bool"
+	// ReturnNone is no return value, like USDT. SwapCreator handles it.
+	ReturnNone = "none"
+	// ReturnInvalid is anything else, including false on success
+	ReturnInvalid = "invalid"
+)
+
+const (
+	// maxBalanceSlot is the highest storage slot of the balance mapping that
+	// probing looks for
+	maxBalanceSlot = 20
+
+	// probeTransferAmount is the amount, in standard units, that the probe
+	// transfers. The probe holder gets probeHolderAmount standard units.
+	probeTransferAmount = 1
+	probeHolderAmount   = 1000
+)
+
+var (
+	// probeHolder is the address that the probe transfers the tokens from.
+	// Its code is replaced with probeCode during the probe.
+	probeHolder = ethcommon.BytesToAddress(crypto.Keccak256([]byte("atomic-swap token probe holder"))[12:])
+	// probeRecipient is the address that the probe transfers the tokens to
+	probeRecipient = ethcommon.BytesToAddress(crypto.Keccak256([]byte("atomic-swap token probe recipient"))[12:])
+
+	balanceOfSelector = crypto.Keccak256([]byte("balanceOf(address)"))[:4]
+)
+
+// probeCode is the code of the probe contract, which is installed at
+// probeHolder for the eth_call. Its calldata is the ABI encoded token address,
+// recipient and amount. It calls transfer(recipient, amount) on the token and
+// returns 5 words: the call's success flag, the first word and the size of its
+// return data, and the balances of the recipient and of itself afterwards.
+//
+//	PUSH4 0xa9059cbb PUSH1 0xe0 SHL PUSH1 0 MSTORE     // transfer selector
+//	PUSH1 0x20 CALLDATALOAD PUSH1 0x04 MSTORE          // recipient
+//	PUSH1 0x40 CALLDATALOAD PUSH1 0x24 MSTORE          // amount
+//	PUSH1 0x20 PUSH1 0xa0 PUSH1 0x44 PUSH1 0 PUSH1 0   // CALL(gas, token, 0, 0, 0x44, 0xa0, 0x20)
+//	PUSH1 0 CALLDATALOAD GAS CALL
+//	PUSH1 0x80 MSTORE                                  // success flag
+//	RETURNDATASIZE PUSH1 0xc0 MSTORE                   // return data size
+//	PUSH4 0x70a08231 PUSH1 0xe0 SHL PUSH1 0 MSTORE     // balanceOf selector
+//	PUSH1 0x20 CALLDATALOAD PUSH1 0x04 MSTORE          // recipient
+//	PUSH1 0x20 PUSH1 0xe0 PUSH1 0x24 PUSH1 0           // STATICCALL(gas, token, 0, 0x24, 0xe0, 0x20)
+//	PUSH1 0 CALLDATALOAD GAS STATICCALL POP
+//	ADDRESS PUSH1 0x04 MSTORE                          // itself
+//	PUSH1 0x20 PUSH2 0x0100 PUSH1 0x24 PUSH1 0         // STATICCALL(gas, token, 0, 0x24, 0x100, 0x20)
+//	PUSH1 0 CALLDATALOAD GAS STATICCALL POP
+//	PUSH1 0xa0 PUSH1 0x80 RETURN                       // the 5 words from 0x80
+var probeCode = hexutil.MustDecode("0x" +
+	"63a9059cbb60e01b600052" +
+	"602035600452" +
+	"604035602452" +
+	"602060a0604460006000" +
+	"6000355af1" +
+	"608052" +
+	"3d60c052" +
+	"6370a0823160e01b600052" +
+	"602035600452" +
+	"602060e060246000" +
+	"6000355afa50" +
+	"30600452" +
+	"6020610100602460006000" +
+	"355afa50" +
+	"60a06080f3",
+)
+
+// Probe is the behaviour of an ERC20 token found by probing it.
+type Probe struct {
+	ChainID uint64                `json:"chainID"`
+	Token   *coins.ERC20TokenInfo `json:"token" validate:"required"`
+	// FeeOnTransfer is set if the recipient of a transfer received less than
+	// the amount, or the sender spent more.
+	FeeOnTransfer bool `json:"feeOnTransfer"`
+	// Rebasing is set if balances are not stored as they are, or change by
+	// other amounts than the transferred amount.
+	Rebasing bool `json:"rebasing"`
+	// TransferReturn is one of ReturnBool, ReturnNone or ReturnInvalid.
+	TransferReturn string `json:"transferReturn,omitempty"`
+	// Error is set if the token's behaviour could not be determined.
+	Error    string    `json:"error,omitempty"`
+	ProbedAt time.Time `json:"probedAt" validate:"required"`
+}
+
+// Unsupported returns why swaps can't be made with the token, or nil if they
+// can. Tokens that could not be probed are unsupported.
+func (p *Probe) Unsupported() error {
+	switch {
+	case p.Error != "":
+		return fmt.Errorf("token %s could not be probed: %s", p.Token.Address, p.Error)
+	case p.FeeOnTransfer:
+		return fmt.Errorf("token %s charges a fee on transfers", p.Token.Address)
+	case p.Rebasing:
+		return fmt.Errorf("token %s has rebasing balances", p.Token.Address)
+	case p.TransferReturn == ReturnInvalid:
+		return fmt.Errorf("token %s returns a non-standard value from transfer", p.Token.Address)
+	}
+	return nil
+}
+
+// caller executes eth_call requests with state overrides.
+type caller interface {
+	call(
+		ctx context.Context,
+		to ethcommon.Address,
+		data []byte,
+		overrides map[ethcommon.Address]gethclient.OverrideAccount,
+	) ([]byte, error)
+}
+
+type rpcCaller struct {
+	gc *gethclient.Client
+}
+
+func (c *rpcCaller) call(
+	ctx context.Context,
+	to ethcommon.Address,
+	data []byte,
+	overrides map[ethcommon.Address]gethclient.OverrideAccount,
+) ([]byte, error) {
+	return c.gc.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil, &overrides)
+}
+
+// ProbeToken probes the transfer behaviour of the token with eth_call
+// simulations, which need an ethereum node that supports state overrides.
+// Nothing is sent on-chain. Errors are only returned for failed requests, the
+// returned probe has its Error set if the behaviour could not be determined.
+func ProbeToken(ctx context.Context, ec extethclient.EthClient, token ethcommon.Address) (*Probe, error) {
+	info, err := ec.ERC20Info(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the ERC20 info of %s: %w", token, err)
+	}
+
+	p, err := probeToken(ctx, &rpcCaller{gc: gethclient.New(ec.Raw().Client())}, info)
+	if err != nil {
+		return nil, err
+	}
+
+	p.ChainID = ec.ChainID().Uint64()
+	return p, nil
+}
+
+func probeToken(ctx context.Context, c caller, info *coins.ERC20TokenInfo) (*Probe, error) {
+	p := &Probe{
+		Token:    info,
+		ProbedAt: time.Now(),
+	}
+
+	// Amounts are kept within uint256 for tokens with absurd decimals
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(min(info.NumDecimals, 40))), nil)
+	amount := new(big.Int).Mul(unit, big.NewInt(probeTransferAmount))
+	holderBalance := new(big.Int).Mul(unit, big.NewInt(probeHolderAmount))
+
+	slot, sharesBased, err := findBalanceSlot(ctx, c, info.Address, holderBalance)
+	if err != nil {
+		return nil, err
+	}
+	if slot == (ethcommon.Hash{}) {
+		p.Error = "the balances are not stored in a known layout"
+		return p, nil
+	}
+	p.Rebasing = sharesBased
+
+	overrides := map[ethcommon.Address]gethclient.OverrideAccount{
+		probeHolder: {Code: probeCode},
+		info.Address: {StateDiff: map[ethcommon.Hash]ethcommon.Hash{
+			slot: ethcommon.BigToHash(holderBalance),
+		}},
+	}
+
+	data := make([]byte, 0, 3*32)
+	data = append(data, ethcommon.LeftPadBytes(info.Address.Bytes(), 32)...)
+	data = append(data, ethcommon.LeftPadBytes(probeRecipient.Bytes(), 32)...)
+	data = append(data, ethcommon.LeftPadBytes(amount.Bytes(), 32)...)
+
+	out, err := c.call(ctx, probeHolder, data, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe token %s: %w", info.Address, err)
+	}
+	if len(out) != 5*32 {
+		return nil, fmt.Errorf("token probe returned %d bytes", len(out))
+	}
+
+	word := func(i int) *big.Int {
+		return new(big.Int).SetBytes(out[i*32 : (i+1)*32])
+	}
+	p.classify(&transferResult{
+		success:          word(0).Sign() != 0,
+		returnWord:       word(1),
+		returnSize:       word(2),
+		recipientBalance: word(3),
+		holderBalance:    word(4),
+	}, amount, holderBalance)
+
+	return p, nil
+}
+
+// transferResult is the output of the probe contract
+type transferResult struct {
+	success          bool
+	returnWord       *big.Int
+	returnSize       *big.Int
+	recipientBalance *big.Int
+	holderBalance    *big.Int
+}
+
+// classify sets the behaviour of the token from the result of transferring
+// amount from a holder with the initial balance.
+func (p *Probe) classify(res *transferResult, amount *big.Int, initialBalance *big.Int) {
+	if !res.success {
+		p.Error = "the transfer reverted"
+		return
+	}
+
+	switch {
+	case res.returnSize.Sign() == 0:
+		p.TransferReturn = ReturnNone
+	case res.returnSize.Cmp(big.NewInt(32)) == 0 && res.returnWord.Cmp(big.NewInt(1)) == 0:
+		p.TransferReturn = ReturnBool
+	default:
+		// a token returning false did not transfer anything, so the balances
+		// say nothing about its behaviour
+		p.TransferReturn = ReturnInvalid
+		return
+	}
+
+	received := res.recipientBalance
+	spent := new(big.Int).Sub(initialBalance, res.holderBalance)
+	switch {
+	case received.Cmp(amount) < 0 || spent.Cmp(amount) > 0:
+		p.FeeOnTransfer = true
+	case received.Cmp(amount) != 0 || spent.Cmp(amount) != 0:
+		p.Rebasing = true
+	}
+}
+
+// findBalanceSlot returns the storage slot of the probe holder's balance in
+// the token's balance mapping, trying the Solidity and Vyper layouts of the
+// first maxBalanceSlot slots. It is found when balanceOf returns the value
+// written to the slot, or, if the balances are derived from stored shares,
+// anything but zero. The slot is zero if it was not found.
+func findBalanceSlot(
+	ctx context.Context,
+	c caller,
+	token ethcommon.Address,
+	value *big.Int,
+) (ethcommon.Hash, bool, error) {
+	holder := ethcommon.LeftPadBytes(probeHolder.Bytes(), 32)
+	data := append(append([]byte{}, balanceOfSelector...), holder...)
+
+	for i := int64(0); i <= maxBalanceSlot; i++ {
+		index := ethcommon.BigToHash(big.NewInt(i)).Bytes()
+		candidates := []ethcommon.Hash{
+			crypto.Keccak256Hash(holder, index), // Solidity
+			crypto.Keccak256Hash(index, holder), // Vyper
+		}
+
+		for _, slot := range candidates {
+			overrides := map[ethcommon.Address]gethclient.OverrideAccount{
+				token: {StateDiff: map[ethcommon.Hash]ethcommon.Hash{slot: ethcommon.BigToHash(value)}},
+			}
+
+			out, err := c.call(ctx, token, data, overrides)
+			if err != nil {
+				return ethcommon.Hash{}, false, fmt.Errorf("failed to probe token %s: %w", token, err)
+			}
+			if len(out) != 32 {
+				return ethcommon.Hash{}, false, errors.New("balanceOf did not return a uint256")
+			}
+
+			balance := new(big.Int).SetBytes(out)
+			if balance.Cmp(value) == 0 {
+				return slot, false, nil
+			}
+			if balance.Sign() != 0 {
+				return slot, true, nil
+			}
+		}
+	}
+
+	return ethcommon.Hash{}, false, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package tokenpolicy
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+)
+
+// evmCaller executes eth_call requests with state overrides in an in-process
+// EVM, the same way an ethereum node does.
+type evmCaller struct {
+	state *state.StateDB
+}
+
+func (c *evmCaller) call(
+	_ context.Context,
+	to ethcommon.Address,
+	data []byte,
+	overrides map[ethcommon.Address]gethclient.OverrideAccount,
+) ([]byte, error) {
+	statedb := c.state.Copy()
+	for addr, account := range overrides {
+		if account.Code != nil {
+			statedb.SetCode(addr, account.Code)
+		}
+		for slot, value := range account.StateDiff {
+			statedb.SetState(addr, slot, value)
+		}
+	}
+
+	out, _, err := runtime.Call(to, data, newRuntimeConfig(statedb))
+	return out, err
+}
+
+// newRuntimeConfig returns an EVM config with the Shanghai rules, which the
+// TestERC20 bytecode needs.
+func newRuntimeConfig(statedb *state.StateDB) *runtime.Config {
+	return &runtime.Config{
+		ChainConfig: params.AllDevChainProtocolChanges,
+		Random:      &ethcommon.Hash{}, // post-merge
+		State:       statedb,
+	}
+}
+
+// newTestERC20 deploys a TestERC20 contract in an in-process EVM.
+func newTestERC20(t *testing.T, decimals uint8) (*evmCaller, *coins.ERC20TokenInfo) {
+	statedb, err := state.New(ethtypes.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+
+	abi, err := contracts.TestERC20MetaData.GetAbi()
+	require.NoError(t, err)
+	args, err := abi.Pack("", "Test Token", "TEST", decimals, ethcommon.Address{0x1}, big.NewInt(1e18))
+	require.NoError(t, err)
+
+	code := append(hexutil.MustDecode(contracts.TestERC20MetaData.Bin), args...)
+	_, addr, _, err := runtime.Create(code, newRuntimeConfig(statedb))
+	require.NoError(t, err)
+
+	return &evmCaller{state: statedb}, coins.NewERC20TokenInfo(addr, decimals, "Test Token", "TEST")
+}
+
+func TestProbeToken_standard(t *testing.T) {
+	c, info := newTestERC20(t, 6)
+
+	p, err := probeToken(context.Background(), c, info)
+	require.NoError(t, err)
+	require.Empty(t, p.Error)
+	require.False(t, p.FeeOnTransfer)
+	require.False(t, p.Rebasing)
+	require.Equal(t, ReturnBool, p.TransferReturn)
+	require.NoError(t, p.Unsupported())
+}
+
+func TestFindBalanceSlot(t *testing.T) {
+	c, info := newTestERC20(t, 18)
+
+	slot, sharesBased, err := findBalanceSlot(context.Background(), c, info.Address, big.NewInt(1000))
+	require.NoError(t, err)
+	require.False(t, sharesBased)
+
+	// OpenZeppelin's balances mapping is in slot 0
+	holder := ethcommon.LeftPadBytes(probeHolder.Bytes(), 32)
+	require.Equal(t, crypto.Keccak256Hash(holder, make([]byte, 32)), slot)
+
+	// the probe holder's code has no balance mapping
+	slot, _, err = findBalanceSlot(context.Background(), c, probeRecipient, big.NewInt(1000))
+	require.ErrorContains(t, err, "balanceOf did not return a uint256")
+	require.Equal(t, ethcommon.Hash{}, slot)
+}
+
+func TestProbe_classify(t *testing.T) {
+	amount := big.NewInt(100)
+	initial := big.NewInt(1000)
+	token := coins.NewERC20TokenInfo(ethcommon.Address{0x1}, 18, "Test Token", "TEST")
+
+	for _, tc := range []struct {
+		name           string
+		res            *transferResult
+		feeOnTransfer  bool
+		rebasing       bool
+		transferReturn string
+		errContains    string
+	}{
+		{
+			name:           "standard",
+			res:            &transferResult{true, big.NewInt(1), big.NewInt(32), big.NewInt(100), big.NewInt(900)},
+			transferReturn: ReturnBool,
+		},
+		{
+			name:           "no return value",
+			res:            &transferResult{true, big.NewInt(0), big.NewInt(0), big.NewInt(100), big.NewInt(900)},
+			transferReturn: ReturnNone,
+		},
+		{
+			name:           "fee deducted from the amount",
+			res:            &transferResult{true, big.NewInt(1), big.NewInt(32), big.NewInt(98), big.NewInt(900)},
+			feeOnTransfer:  true,
+			transferReturn: ReturnBool,
+			errContains:    "charges a fee on transfers",
+		},
+		{
+			name:           "fee charged to the sender",
+			res:            &transferResult{true, big.NewInt(1), big.NewInt(32), big.NewInt(100), big.NewInt(898)},
+			feeOnTransfer:  true,
+			transferReturn: ReturnBool,
+			errContains:    "charges a fee on transfers",
+		},
+		{
+			name:           "rebasing",
+			res:            &transferResult{true, big.NewInt(1), big.NewInt(32), big.NewInt(101), big.NewInt(900)},
+			rebasing:       true,
+			transferReturn: ReturnBool,
+			errContains:    "has rebasing balances",
+		},
+		{
+			name:           "returns false",
+			res:            &transferResult{true, big.NewInt(0), big.NewInt(32), big.NewInt(0), big.NewInt(1000)},
+			transferReturn: ReturnInvalid,
+			errContains:    "returns a non-standard value from transfer",
+		},
+		{
+			name:        "reverts",
+			res:         &transferResult{false, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(1000)},
+			errContains: "could not be probed: the transfer reverted",
+		},
+	} {
+		p := &Probe{Token: token}
+		p.classify(tc.res, amount, initial)
+		require.Equal(t, tc.feeOnTransfer, p.FeeOnTransfer, tc.name)
+		require.Equal(t, tc.rebasing, p.Rebasing, tc.name)
+		require.Equal(t, tc.transferReturn, p.TransferReturn, tc.name)
+		if tc.errContains == "" {
+			require.NoError(t, p.Unsupported(), tc.name)
+		} else {
+			require.ErrorContains(t, p.Unsupported(), tc.errContains, tc.name)
+		}
+	}
+}
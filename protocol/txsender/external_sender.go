@@ -17,6 +17,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/common/types"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/block"
+	"github.com/athanorlabs/atomic-swap/metrics"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	ethcommon "github.com/ethereum/go-ethereum/common"
@@ -106,7 +107,7 @@ func (s *ExternalSender) approve(
 		return nil, err
 	}
 
-	return s.sendAndReceive(input, s.erc20Addr)
+	return s.sendAndReceive(input, s.erc20Addr, metrics.TxApprove)
 }
 
 // NewSwap prompts the external sender to sign a new_swap transaction
@@ -151,7 +152,13 @@ func (s *ExternalSender) NewSwap(
 		return nil, err
 	}
 
-	return block.WaitForReceipt(s.ctx, s.ec, txHash)
+	receipt, err := block.WaitForReceipt(s.ctx, s.ec, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.RecordGas(metrics.TxNewSwap, receipt)
+	return receipt, nil
 }
 
 // SetReady prompts the external sender to sign a set_ready transaction
@@ -161,7 +168,7 @@ func (s *ExternalSender) SetReady(swap *contracts.SwapCreatorSwap) (*ethtypes.Re
 		return nil, err
 	}
 
-	return s.sendAndReceive(input, s.contractAddr)
+	return s.sendAndReceive(input, s.contractAddr, metrics.TxSetReady)
 }
 
 // Claim prompts the external sender to sign a claim transaction
@@ -174,7 +181,7 @@ func (s *ExternalSender) Claim(
 		return nil, err
 	}
 
-	return s.sendAndReceive(input, s.contractAddr)
+	return s.sendAndReceive(input, s.contractAddr, metrics.TxClaim)
 }
 
 // Refund prompts the external sender to sign a refund transaction
@@ -187,10 +194,14 @@ func (s *ExternalSender) Refund(
 		return nil, err
 	}
 
-	return s.sendAndReceive(input, s.contractAddr)
+	return s.sendAndReceive(input, s.contractAddr, metrics.TxRefund)
 }
 
-func (s *ExternalSender) sendAndReceive(input []byte, to ethcommon.Address) (*ethtypes.Receipt, error) {
+func (s *ExternalSender) sendAndReceive(
+	input []byte,
+	to ethcommon.Address,
+	txType metrics.TxType,
+) (*ethtypes.Receipt, error) {
 	tx := &Transaction{To: to, Data: input}
 
 	s.Lock()
@@ -204,5 +215,11 @@ func (s *ExternalSender) sendAndReceive(input []byte, to ethcommon.Address) (*et
 	case txHash = <-s.in:
 	}
 
-	return block.WaitForReceipt(s.ctx, s.ec, txHash)
+	receipt, err := block.WaitForReceipt(s.ctx, s.ec, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.RecordGas(txType, receipt)
+	return receipt, nil
 }
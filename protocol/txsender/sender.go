@@ -25,6 +25,7 @@ import (
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/block"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/metrics"
 )
 
 var (
@@ -117,7 +118,7 @@ func (s *privateKeySender) NewSwap(
 	tx, err := s.swapCreator.NewSwap(txOpts, claimCommitment, refundCommitment, claimer, timeoutDuration, timeoutDuration,
 		amount.TokenAddress(), value, nonce)
 	if err != nil {
-		err = fmt.Errorf("new_swap tx creation failed, %w", err)
+		err = fmt.Errorf("new_swap tx creation failed, %w", block.SendError(err))
 		return nil, err
 	}
 
@@ -131,6 +132,7 @@ func (s *privateKeySender) NewSwap(
 	}
 
 	log.Infof("newSwap TX succeeded, %s", common.ReceiptInfo(receipt))
+	metrics.RecordGas(metrics.TxNewSwap, receipt)
 
 	return receipt, nil
 }
@@ -204,13 +206,14 @@ func (s *privateKeySender) approveNoChecks(amount coins.EthAssetAmount) error {
 	tx, err := s.erc20Contract.Approve(txOpts, s.swapCreatorAddr, amount.BigInt())
 	if err != nil {
 		return fmt.Errorf("token approve tx for %s %s creation failed, %w",
-			amount.AsStdString(), amount.StdSymbol(), err)
+			amount.AsStdString(), amount.StdSymbol(), block.SendError(err))
 	}
 
 	receipt, err := block.WaitForReceipt(s.ctx, s.ethClient.Raw(), tx.Hash())
 	if err != nil {
 		return fmt.Errorf("approveNoChecks tx %s failed waiting for receipt, %w", tx.Hash(), err)
 	}
+	metrics.RecordGas(metrics.TxApprove, receipt)
 
 	log.Infof("%s %s approved for use by SwapCreator's new_swap, %s",
 		amount.AsStdString(), amount.StdSymbol(), common.ReceiptInfo(receipt))
@@ -228,7 +231,7 @@ func (s *privateKeySender) SetReady(swap *contracts.SwapCreatorSwap) (*ethtypes.
 
 	tx, err := s.swapCreator.SetReady(txOpts, *swap)
 	if err != nil {
-		err = fmt.Errorf("set_ready tx creation failed, %w", err)
+		err = fmt.Errorf("set_ready tx creation failed, %w", block.SendError(err))
 		return nil, err
 	}
 
@@ -238,6 +241,7 @@ func (s *privateKeySender) SetReady(swap *contracts.SwapCreatorSwap) (*ethtypes.
 		return nil, err
 	}
 
+	metrics.RecordGas(metrics.TxSetReady, receipt)
 	return receipt, nil
 }
 
@@ -254,7 +258,7 @@ func (s *privateKeySender) Claim(
 
 	tx, err := s.swapCreator.Claim(txOpts, *swap, secret)
 	if err != nil {
-		err = fmt.Errorf("claim tx creation failed, %w", err)
+		err = fmt.Errorf("claim tx creation failed, %w", block.SendError(err))
 		return nil, err
 	}
 
@@ -264,6 +268,7 @@ func (s *privateKeySender) Claim(
 		return nil, err
 	}
 
+	metrics.RecordGas(metrics.TxClaim, receipt)
 	return receipt, nil
 }
 
@@ -280,7 +285,7 @@ func (s *privateKeySender) Refund(
 
 	tx, err := s.swapCreator.Refund(txOpts, *swap, secret)
 	if err != nil {
-		err = fmt.Errorf("refund tx creation failed, %w", err)
+		err = fmt.Errorf("refund tx creation failed, %w", block.SendError(err))
 		return nil, err
 	}
 
@@ -290,5 +295,6 @@ func (s *privateKeySender) Refund(
 		return nil, err
 	}
 
+	metrics.RecordGas(metrics.TxRefund, receipt)
 	return receipt, nil
 }
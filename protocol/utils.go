@@ -29,6 +29,24 @@ func AssetSymbol(b backend.Backend, asset types.EthAsset) (string, error) {
 	return etherSymbol, nil
 }
 
+// GenerateSwapKeys returns our keys for a new swap with the given offer ID. If
+// the backend has a swap key seed, the keys are derived from it with the offer
+// ID and the next swap key index, so they can be recovered from the seed,
+// otherwise they are random.
+func GenerateSwapKeys(b backend.Backend, offerID types.Hash) (*KeysAndProof, error) {
+	seed := b.SwapKeySeed()
+	if seed == nil {
+		return GenerateKeysAndProof()
+	}
+
+	index, err := b.RecoveryDB().NextSwapKeyIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get swap key index: %w", err)
+	}
+
+	return DeriveKeysAndProof(seed, offerID, index)
+}
+
 // CheckSwapID checks if the given log is for the given swap ID.
 func CheckSwapID(log *ethtypes.Log, eventNameTopic [32]byte, contractSwapID types.Hash) error {
 	if len(log.Topics) < 2 {
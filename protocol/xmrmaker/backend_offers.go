@@ -12,10 +12,19 @@ func (inst *Instance) MakeOffer(
 	o *types.Offer,
 	useRelayer bool,
 ) (*types.OfferExtra, error) {
-	err := validateMinBalance(
-		inst.backend.Ctx(),
-		inst.backend.XMRClient(),
-		inst.backend.ETHClient(),
+	b, err := inst.backend.ForChain(o.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if useRelayer && o.ChainID != 0 {
+		return nil, errRelayingOffPrimaryChain
+	}
+
+	err = validateMinBalance(
+		b.Ctx(),
+		b.XMRClient(),
+		b.ETHClient(),
 		o.MaxAmount,
 		o.EthAsset,
 	)
@@ -28,7 +37,7 @@ func (inst *Instance) MakeOffer(
 			return nil, errRelayingWithNonEthAsset
 		}
 
-		token, err := inst.backend.ETHClient().ERC20Info(inst.backend.Ctx(), o.EthAsset.Address()) //nolint:govet
+		token, err := b.CheckToken(o.EthAsset.Address()) //nolint:govet
 		if err != nil {
 			return nil, err
 		}
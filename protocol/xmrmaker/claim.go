@@ -22,6 +22,8 @@ import (
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/block"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/metrics"
+	pswap "github.com/athanorlabs/atomic-swap/protocol/swap"
 )
 
 // claimFunds redeems XMRMaker's ETH funds by calling Claim() on the contract
@@ -47,6 +49,7 @@ func (s *swapState) claimFunds() (*ethtypes.Receipt, error) {
 	}
 
 	var receipt *ethtypes.Receipt
+	txType := metrics.TxClaim
 
 	// call swap.Swap.Claim() w/ b.privkeys.sk, revealing XMRMaker's secret spend key
 	if s.offerExtra.UseRelayer || !hasBalanceToClaim {
@@ -55,6 +58,7 @@ func (s *swapState) claimFunds() (*ethtypes.Receipt, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to claim using relayers: %w", err)
 		}
+		txType = metrics.TxRelayedClaim
 		log.Infof("claim transaction was relayed: %s", common.ReceiptInfo(receipt))
 	} else {
 		// claim and wait for tx to be included
@@ -67,6 +71,7 @@ func (s *swapState) claimFunds() (*ethtypes.Receipt, error) {
 				if err != nil {
 					return nil, fmt.Errorf("failed to claim using relayers: %w", err)
 				}
+				txType = metrics.TxRelayedClaim
 				log.Infof("claim transaction was relayed: %s", common.ReceiptInfo(receipt))
 			} else {
 				return nil, err
@@ -78,6 +83,8 @@ func (s *swapState) claimFunds() (*ethtypes.Receipt, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.info.AddEthTxHash(receipt.TxHash)
+	s.journal(pswap.NewEthTxJournalEntry(string(txType), receipt.TxHash))
 
 	if types.EthAsset(s.contractSwap.Asset) == types.EthAssetETH {
 		balance, err := s.ETHClient().Balance(s.ctx)
@@ -149,6 +156,7 @@ func (s *swapState) relayClaimWithXMRTaker() (*ethtypes.Receipt, error) {
 
 	response, err := s.Backend.SubmitClaimToRelayer(s.info.PeerID, &s.offer.ID, relaySwap, secret)
 	if err != nil {
+		metrics.RecordRelayerClaimSubmitted(metrics.RelayerCounterparty, err)
 		return nil, err
 	}
 
@@ -160,6 +168,7 @@ func (s *swapState) relayClaimWithXMRTaker() (*ethtypes.Receipt, error) {
 		s.contractSwapID,
 		s.getSecret(),
 	)
+	metrics.RecordRelayerClaimSubmitted(metrics.RelayerCounterparty, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get receipt of relayer's tx: %s", err)
 	}
@@ -199,6 +208,7 @@ func (s *swapState) claimWithAdvertisedRelayers() (*ethtypes.Receipt, error) {
 		log.Debugf("submitting claim to relayer with peer ID %s", relayerPeerID)
 		resp, err := s.Backend.SubmitClaimToRelayer(relayerPeerID, nil, relaySwap, secret)
 		if err != nil {
+			metrics.RecordRelayerClaimSubmitted(metrics.RelayerAdvertised, err)
 			log.Warnf("failed to submit tx to relayer: %s", err)
 			continue
 		}
@@ -211,6 +221,7 @@ func (s *swapState) claimWithAdvertisedRelayers() (*ethtypes.Receipt, error) {
 			s.contractSwapID,
 			s.getSecret(),
 		)
+		metrics.RecordRelayerClaimSubmitted(metrics.RelayerAdvertised, err)
 		if err != nil {
 			log.Warnf("failed to get receipt of relayer's tx=%s: %s", resp.TxHash.Hex(), err)
 			continue
@@ -231,6 +242,11 @@ func (s *swapState) claimWithAdvertisedRelayers() (*ethtypes.Receipt, error) {
 // operations more generally. Note that the receipt returned is for a
 // transaction created by the remote relayer, not by us.
 func (s *swapState) claimWithRelay() (*ethtypes.Receipt, error) {
+	// relayers submit claims to the SwapCreator contract of their primary chain
+	if s.info.ChainID != 0 {
+		return nil, errRelayingOffPrimaryChain
+	}
+
 	receipt, err := s.claimWithAdvertisedRelayers()
 	if err != nil {
 		log.Warnf("failed to relay with DHT-advertised relayers: %s", err)
@@ -16,10 +16,12 @@ var (
 	errMissingKeys                   = errors.New("did not receive XMRTaker's public spend or view key")
 	errMissingAddress                = errors.New("got empty contract address")
 	errNilSwapState                  = errors.New("swap state is nil")
+	errAbortOfferIDMismatch          = errors.New("abort is for a different swap")
 	errNilContractSwapID             = errors.New("expected swapID in NotifyETHLocked message")
 	errCannotFindNewLog              = errors.New("cannot find New log")
 	errUnexpectedSwapID              = errors.New("unexpected swap ID was emitted by New log")
 	errSwapIDMismatch                = errors.New("hash of swap struct does not match swap ID")
+	errNonceNotOfferID               = errors.New("swap nonce is not the offer ID")
 	errLockTxReverted                = errors.New("other party failed to lock ETH asset (transaction reverted)")
 	errInvalidETHLockedTransaction   = errors.New("eth locked tx was not to correct contract address")
 	errInvalidT1                     = errors.New("invalid t1 value; asset was locked too far in the past")
@@ -30,6 +32,7 @@ var (
 	errClaimedLogWrongSwapID         = errors.New("log did not have the correct swap ID as its second topic")
 	errClaimedLogWrongSecret         = errors.New("log did not have the correct secret as its third topic")
 	errRelayingWithNonEthAsset       = errors.New("relayers with ERC20 token swaps are not currently supported")
+	errRelayingOffPrimaryChain       = errors.New("relayers are only supported on the primary chain")
 
 	// protocol initiation errors
 	errSwapDoesNotExist          = errors.New("contract swap ID does not exist")
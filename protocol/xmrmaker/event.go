@@ -9,6 +9,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/common/types"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/net/message"
+	pswap "github.com/athanorlabs/atomic-swap/protocol/swap"
 )
 
 // EventType represents an event that occurs which moves the swap
@@ -35,6 +36,13 @@ const (
 	// our XMR. After this event, the only possible event is EventExitType.
 	EventETHRefundedType
 
+	// EventPeerAbortType is triggered when the taker tells us that they aborted
+	// the swap. It causes us to abort right away if we haven't locked our XMR
+	// yet. Otherwise, we have to wait for the taker's refund or for the
+	// contract to be ready, so the other possible events are the same as
+	// before.
+	EventPeerAbortType
+
 	// EventExitType is triggered by the protocol "exiting", which may happen
 	// via a swap cancellation via the RPC endpoint, or from the counterparty
 	// disconnecting from us on the p2p network. It causes us to attempt to
@@ -69,6 +77,8 @@ func (t EventType) String() string {
 		return "EventContractReadyType"
 	case EventETHRefundedType:
 		return "EventETHRefundedType"
+	case EventPeerAbortType:
+		return "EventPeerAbortType"
 	case EventExitType:
 		return "EventExitType"
 	case EventNoneType:
@@ -152,11 +162,34 @@ func newEventETHRefunded(sk *mcrypto.PrivateSpendKey) *EventETHRefunded {
 	}
 }
 
+// EventPeerAbort is an optional event. It occurs when the XMR-taker sends us an
+// AbortSwap message.
+type EventPeerAbort struct {
+	message *message.AbortSwap
+	errCh   chan error
+}
+
+// Type ...
+func (*EventPeerAbort) Type() EventType {
+	return EventPeerAbortType
+}
+
+func newEventPeerAbort(msg *message.AbortSwap) *EventPeerAbort {
+	return &EventPeerAbort{
+		message: msg,
+		errCh:   make(chan error),
+	}
+}
+
 // EventExit is an optional event. It is sent when the protocol should be stopped,
 // for example if the remote peer closes their connection with us before sending all
 // required messages, or we decide to cancel the swap.
 type EventExit struct {
-	errCh chan error
+	// reason is sent to the counterparty if the swap doesn't complete
+	// successfully. It is empty if the counterparty can't be told, for example
+	// because the stream closed.
+	reason message.AbortReason
+	errCh  chan error
 }
 
 // Type ...
@@ -164,9 +197,10 @@ func (*EventExit) Type() EventType {
 	return EventExitType
 }
 
-func newEventExit() *EventExit {
+func newEventExit(reason message.AbortReason) *EventExit {
 	return &EventExit{
-		errCh: make(chan error),
+		reason: reason,
+		errCh:  make(chan error),
 	}
 }
 
@@ -195,18 +229,18 @@ func (s *swapState) handleEvent(event Event) {
 
 		err := s.handleNotifyETHLocked(e.message)
 		if err != nil {
+			s.journal(pswap.NewErrorJournalEntry(err))
 			e.errCh <- fmt.Errorf("failed to handle EventETHLocked: %w", err)
+			s.abortReason = message.AbortError
 			err = s.exit()
 			if err != nil {
 				log.Warnf("failed to exit swap: %s", err)
 			}
 		}
 
-		// close the stream to the remote peer, since we won't be
-		// receiving any more messages.
-		s.Backend.CloseProtocolStream(s.OfferID())
-
-		// nextExpectedEvent was set in s.lockFunds()
+		// nextExpectedEvent was set in s.lockFunds(). The stream stays open
+		// until the swap exits, so that we can tell the taker if we abort, and
+		// they can tell us.
 	case *EventContractReady:
 		log.Infof("EventContractReady")
 		defer close(e.errCh)
@@ -238,11 +272,24 @@ func (s *swapState) handleEvent(event Event) {
 		if err != nil {
 			log.Warnf("failed to exit swap: %s", err)
 		}
+	case *EventPeerAbort:
+		// this can happen at any stage.
+		log.Infof("EventPeerAbort")
+		defer close(e.errCh)
+
+		err := s.handleEventPeerAbort(e)
+		if err != nil {
+			e.errCh <- fmt.Errorf("failed to handle %s: %w", e.Type(), err)
+		}
 	case *EventExit:
 		// this can happen at any stage.
 		log.Infof("EventExit")
 		defer close(e.errCh)
 
+		if s.abortReason == "" {
+			s.abortReason = e.reason
+		}
+
 		err := s.exit()
 		if err != nil {
 			e.errCh <- fmt.Errorf("failed to handle EventExit: %w", err)
@@ -261,6 +308,8 @@ func (s *swapState) handleEventContractReady() error {
 	receipt, err := s.claimFunds()
 	if err != nil {
 		log.Warnf("failed to claim funds from contract, attempting to safely exit: %s", err)
+		s.journal(pswap.NewErrorJournalEntry(err))
+		s.abortReason = message.AbortError
 
 		// TODO: retry claim, depending on error (#162)
 		if err2 := s.exit(); err2 != nil {
@@ -285,3 +334,31 @@ func (s *swapState) handleEventETHRefunded(e *EventETHRefunded) error {
 	s.clearNextExpectedEvent(types.CompletedRefund)
 	return nil
 }
+
+func (s *swapState) handleEventPeerAbort(event *EventPeerAbort) error {
+	if err := s.recordPeerAbort(event.message); err != nil {
+		return err
+	}
+
+	switch s.nextExpectedEvent {
+	case EventETHLockedType:
+		// the taker won't lock their ETH, so we stop waiting for it.
+		return s.exit()
+	default:
+		// our XMR is locked, so as when the stream closes, we wait for the
+		// taker's refund to reclaim it, or for the contract to be ready.
+		return nil
+	}
+}
+
+// recordPeerAbort records the taker's abort in the swap info and journal.
+func (s *swapState) recordPeerAbort(msg *message.AbortSwap) error {
+	if msg.OfferID != s.OfferID() {
+		return errAbortOfferIDMismatch
+	}
+
+	log.Infof("taker aborted the swap: reason=%s status=%s", msg.Reason, msg.Status)
+	s.info.SetPeerAbort(string(msg.Reason), msg.Status)
+	s.journal(pswap.NewPeerAbortJournalEntry(string(msg.Reason), msg.Status))
+	return nil
+}
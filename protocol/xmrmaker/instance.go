@@ -13,6 +13,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/common"
 	"github.com/athanorlabs/atomic-swap/common/types"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
+	"github.com/athanorlabs/atomic-swap/metrics"
 	pcommon "github.com/athanorlabs/atomic-swap/protocol"
 	"github.com/athanorlabs/atomic-swap/protocol/backend"
 	"github.com/athanorlabs/atomic-swap/protocol/swap"
@@ -143,6 +144,7 @@ func (inst *Instance) abortOngoingSwap(s *swap.Info) error {
 	if err != nil {
 		return err
 	}
+	metrics.RecordSwapExit(s.Status, nil)
 
 	return inst.backend.RecoveryDB().DeleteSwap(s.OfferID)
 }
@@ -186,8 +188,13 @@ func (inst *Instance) createOngoingSwap(s *swap.Info) error {
 		relayerInfo = types.NewOfferExtra(false)
 	}
 
+	b, err := inst.backend.ForChain(s.ChainID)
+	if err != nil {
+		return fmt.Errorf("failed to get backend for ongoing swap, offer ID %s: %w", s.OfferID, err)
+	}
+
 	ss, err := newSwapStateFromOngoing(
-		inst.backend,
+		b,
 		offer,
 		relayerInfo,
 		inst.offerManager,
@@ -68,6 +68,10 @@ func (*mockNet) SubmitRelayRequest(_ peer.ID, _ *message.RelayClaimRequest) (*me
 func (*mockNet) CloseProtocolStream(_ types.Hash) {}
 func (*mockNet) DeleteOngoingSwap(_ types.Hash)   {}
 
+func (*mockNet) ProtocolVersion(_ types.Hash) uint16 {
+	return message.ProtocolVersion1
+}
+
 func (n *mockNet) QueryRelayerAddress(_ peer.ID) (types.Hash, error) {
 	return types.Hash{}, nil
 }
@@ -113,7 +117,7 @@ func newBackendAndNet(t *testing.T) (backend.Backend, *mockNet) {
 	rdb.EXPECT().PutCounterpartySwapKeys(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	rdb.EXPECT().DeleteSwap(gomock.Any()).Return(nil).AnyTimes()
 
-	extendedEC, err := extethclient.NewEthClient(ctx, env, common.DefaultGanacheEndpoint, pk)
+	extendedEC, err := extethclient.NewEthClient(ctx, env, common.DefaultGanacheEndpoint, nil, pk)
 	require.NoError(t, err)
 
 	net := new(mockNet)
@@ -173,7 +177,7 @@ func TestInstance_createOngoingSwap(t *testing.T) {
 	ec := inst.backend.ETHClient()
 	contract := inst.backend.SwapCreator()
 	contractSwap, contractSwapID, _ := newTestSwap(
-		t, ec, contract, dummySwapKey, dummySwapKey, big.NewInt(100), time.Minute*10,
+		t, ec, contract, dummySwapKey, dummySwapKey, big.NewInt(100), time.Minute*10, big.NewInt(0),
 	)
 
 	txOpts, err := ec.TxOpts(context.Background())
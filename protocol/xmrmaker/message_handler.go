@@ -21,6 +21,7 @@ import (
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/net/message"
 	pcommon "github.com/athanorlabs/atomic-swap/protocol"
+	pswap "github.com/athanorlabs/atomic-swap/protocol/swap"
 )
 
 // HandleProtocolMessage is called by the network to handle an incoming message.
@@ -35,6 +36,8 @@ func (s *swapState) HandleProtocolMessage(msg common.Message) error {
 		return fmt.Errorf("protocol exited: %w", s.ctx.Err())
 	}
 
+	s.journal(pswap.NewMessageJournalEntry(pswap.JournalMessageReceived, message.TypeToString(msg.Type())))
+
 	switch msg := msg.(type) {
 	case *message.NotifyETHLocked:
 		event := newEventETHLocked(msg)
@@ -43,6 +46,13 @@ func (s *swapState) HandleProtocolMessage(msg common.Message) error {
 		if err != nil {
 			return err
 		}
+	case *message.AbortSwap:
+		event := newEventPeerAbort(msg)
+		s.eventCh <- event
+		err := <-event.errCh
+		if err != nil {
+			return err
+		}
 	default:
 		return errUnexpectedMessageType
 	}
@@ -126,8 +136,22 @@ func (s *swapState) handleNotifyETHLocked(msg *message.NotifyETHLocked) error {
 		return errMissingAddress
 	}
 
+	version := s.Backend.ProtocolVersion(s.OfferID())
 	if types.IsHashZero(msg.ContractSwapID) {
-		return errNilContractSwapID
+		// takers leave out the contract swap ID from version 2 on
+		if version < message.ProtocolVersion2 {
+			return errNilContractSwapID
+		}
+		msg.ContractSwapID = msg.ContractSwap.SwapID()
+	}
+
+	// from version 2 on, our secret can only be recovered from the swap key
+	// seed if the offer ID is on chain
+	if version >= message.ProtocolVersion2 {
+		nonce := msg.ContractSwap.Nonce
+		if nonce == nil || nonce.Cmp(s.OfferID().Big()) != 0 {
+			return errNonceNotOfferID
+		}
 	}
 
 	log.Infof("got NotifyETHLocked; address=%s contract swap ID=%s", msg.Address, msg.ContractSwapID)
@@ -224,6 +248,7 @@ func (s *swapState) runT1ExpirationHandler() {
 			return
 		}
 		log.Debugf("reached t1, time to claim")
+		s.journal(pswap.NewTimeoutJournalEntry("t1"))
 		s.handleT1Expired()
 	}
 }
@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	host "github.com/libp2p/go-libp2p/core/host"
 	network "github.com/libp2p/go-libp2p/core/network"
 	peer "github.com/libp2p/go-libp2p/core/peer"
 	protocol "github.com/libp2p/go-libp2p/core/protocol"
@@ -136,6 +137,20 @@ func (mr *MockP2pHostMockRecorder) Discover(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discover", reflect.TypeOf((*MockP2pHost)(nil).Discover), arg0, arg1)
 }
 
+// Libp2pHost mocks base method.
+func (m *MockP2pHost) Libp2pHost() host.Host {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Libp2pHost")
+	ret0, _ := ret[0].(host.Host)
+	return ret0
+}
+
+// Libp2pHost indicates an expected call of Libp2pHost.
+func (mr *MockP2pHostMockRecorder) Libp2pHost() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Libp2pHost", reflect.TypeOf((*MockP2pHost)(nil).Libp2pHost))
+}
+
 // NewStream mocks base method.
 func (m *MockP2pHost) NewStream(arg0 context.Context, arg1 peer.ID, arg2 protocol.ID) (network.Stream, error) {
 	m.ctrl.T.Helper()
@@ -48,6 +48,11 @@ func (inst *Instance) initiate(
 		}
 	}
 
+	b, err := inst.backend.ForChain(offer.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	// checks passed, delete the offer from memory for now
 	_, _, err = inst.offerManager.TakeOffer(offer.ID)
 	if err != nil {
@@ -55,7 +60,7 @@ func (inst *Instance) initiate(
 	}
 
 	s, err := newSwapStateFromStart(
-		inst.backend,
+		b,
 		takerPeerID,
 		offer,
 		offerExtra,
@@ -74,9 +79,9 @@ func (inst *Instance) initiate(
 		delete(inst.swapStates, offer.ID)
 	}()
 
-	symbol, err := pcommon.AssetSymbol(inst.backend, offer.EthAsset)
+	symbol, err := pcommon.AssetSymbol(b, offer.EthAsset)
 	if err != nil {
-		_ = s.Exit()
+		_ = s.exitWithReason(message.AbortError)
 		return nil, err
 	}
 
@@ -115,10 +120,15 @@ func (inst *Instance) HandleInitiateMessage(
 		return nil, err
 	}
 
+	b, err := inst.backend.ForChain(offer.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	maxDecimals := uint8(coins.NumEtherDecimals)
 	var token *coins.ERC20TokenInfo
 	if offer.EthAsset.IsToken() {
-		token, err = inst.backend.ETHClient().ERC20Info(inst.backend.Ctx(), offer.EthAsset.Address())
+		token, err = b.CheckToken(offer.EthAsset.Address())
 		if err != nil {
 			return nil, err
 		}
@@ -159,14 +169,14 @@ func (inst *Instance) HandleInitiateMessage(
 	}
 
 	if err = state.handleSendKeysMessage(msg); err != nil {
-		_ = state.Exit()
+		_ = state.exitWithReason(message.AbortError)
 		return nil, err
 	}
 
 	resp := state.SendKeysMessage()
 	err = inst.backend.SendSwapMessage(resp, offer.ID)
 	if err != nil {
-		_ = state.Exit()
+		_ = state.exitWithReason(message.AbortError)
 		return nil, fmt.Errorf("failed to send SendKeysMessage to remote peer: %w", err)
 	}
 
@@ -28,6 +28,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/dleq"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/watcher"
+	"github.com/athanorlabs/atomic-swap/metrics"
 	"github.com/athanorlabs/atomic-swap/monero"
 	"github.com/athanorlabs/atomic-swap/net/message"
 	pcommon "github.com/athanorlabs/atomic-swap/protocol"
@@ -77,6 +78,9 @@ type swapState struct {
 
 	// tracks the state of the swap
 	nextExpectedEvent EventType
+	// the reason sent to the taker if we abort the swap, and whether it was sent
+	abortReason message.AbortReason
+	abortSent   bool
 
 	readyWatcher *watcher.EventFilter
 
@@ -144,6 +148,7 @@ func newSwapStateFromStart(
 		stage,
 		moneroStartHeight,
 	)
+	info.ChainID = offer.ChainID
 
 	if err = b.SwapManager().AddSwap(info); err != nil {
 		return nil, err
@@ -249,6 +254,7 @@ func completeSwap(info *swap.Info, b backend.Backend, om *offers.Manager) error
 	if err != nil {
 		return fmt.Errorf("failed to mark swap %s as completed: %s", info.OfferID, err)
 	}
+	metrics.RecordSwapExit(info.Status, nil)
 
 	err = om.DeleteOffer(info.OfferID)
 	if err != nil {
@@ -455,6 +461,11 @@ func (s *swapState) updateStatus(status types.Status) {
 	s.SwapManager().PushNewStatus(s.OfferID(), status)
 }
 
+// journal appends the entry to the swap's journal
+func (s *swapState) journal(entry *pswap.JournalEntry) {
+	s.SwapManager().AppendJournal(s.OfferID(), entry)
+}
+
 // ExpectedAmount returns the amount received, or expected to be received, at the end of the swap
 func (s *swapState) ExpectedAmount() *apd.Decimal {
 	return s.info.ExpectedAmount
@@ -471,7 +482,7 @@ func (s *swapState) NotifyStreamClosed() {
 	case EventETHLockedType:
 		// exit the swap, the remote peer closed the stream
 		// before we received all expected messages
-		err := s.Exit()
+		err := s.exitWithReason("")
 		if err != nil {
 			log.Errorf("failed to exit swap: %s", err)
 		}
@@ -480,11 +491,17 @@ func (s *swapState) NotifyStreamClosed() {
 	}
 }
 
-// Exit is called by the network when the protocol stream closes, or if the swap_refund RPC endpoint is called.
+// Exit is called if the swap_cancel RPC endpoint is called, or if the swap could not be started.
 // It exists the swap by refunding if necessary. If no locking has been done, it simply aborts the swap.
 // If the swap already completed successfully, this function does not do anything regarding the protocol.
 func (s *swapState) Exit() error {
-	event := newEventExit()
+	return s.exitWithReason(message.AbortCancelled)
+}
+
+// exitWithReason is the same as Exit, but the taker is told the given reason
+// if the swap doesn't complete successfully. No reason is sent if it is empty.
+func (s *swapState) exitWithReason(reason message.AbortReason) error {
+	event := newEventExit(reason)
 	s.eventCh <- event
 	err := <-event.errCh
 	if err != nil {
@@ -494,10 +511,11 @@ func (s *swapState) Exit() error {
 }
 
 // exit is the same as Exit, but assumes the calling code block already holds the swapState lock.
-func (s *swapState) exit() error {
+func (s *swapState) exit() (exitErr error) {
 	log.Debugf("attempting to exit swap: nextExpectedEvent=%v", s.nextExpectedEvent)
 
 	defer func() {
+		s.sendAbort()
 		s.CloseProtocolStream(s.OfferID())
 
 		err := s.SwapManager().CompleteOngoingSwap(s.info)
@@ -506,6 +524,11 @@ func (s *swapState) exit() error {
 			return
 		}
 
+		metrics.RecordSwapExit(s.info.Status, exitErr)
+		if exitErr != nil {
+			s.journal(pswap.NewErrorJournalEntry(exitErr))
+		}
+
 		log.Infof("exit status %s", s.info.Status)
 
 		if s.info.Status != types.CompletedSuccess && s.offer.IsSet() {
@@ -563,6 +586,19 @@ func (s *swapState) exit() error {
 		var err error
 		event := <-s.eventCh
 
+		// the taker can tell us that they aborted while we wait
+		for {
+			abort, ok := event.(*EventPeerAbort)
+			if !ok {
+				break
+			}
+			if err := s.recordPeerAbort(abort.message); err != nil {
+				abort.errCh <- err
+			}
+			close(abort.errCh)
+			event = <-s.eventCh
+		}
+
 		switch e := event.(type) {
 		case *EventETHRefunded:
 			defer close(e.errCh)
@@ -588,6 +624,24 @@ func (s *swapState) exit() error {
 	}
 }
 
+// sendAbort tells the taker that we aborted the swap and why, unless the swap
+// completed successfully, we have no reason to give, or the taker aborted it.
+func (s *swapState) sendAbort() {
+	if s.abortReason == "" || s.abortSent || s.info.Status == types.CompletedSuccess || s.info.PeerAbort != nil {
+		return
+	}
+
+	s.abortSent = true
+	msg := &message.AbortSwap{
+		OfferID: s.OfferID(),
+		Reason:  s.abortReason,
+		Status:  s.info.Status,
+	}
+	if err := s.SendSwapMessage(msg, s.OfferID()); err != nil {
+		log.Debugf("failed to send AbortSwap to taker: %s", err)
+	}
+}
+
 func (s *swapState) reclaimMonero(skA *mcrypto.PrivateSpendKey) error {
 	// write counterparty swap privkey to disk in case something goes wrong
 	err := s.Backend.RecoveryDB().PutCounterpartySwapPrivateKey(s.OfferID(), skA)
@@ -627,7 +681,7 @@ func (s *swapState) generateAndSetKeys() error {
 		panic("generateAndSetKeys should only be called once")
 	}
 
-	keysAndProof, err := generateKeys()
+	keysAndProof, err := pcommon.GenerateSwapKeys(s.Backend, s.OfferID())
 	if err != nil {
 		return err
 	}
@@ -710,6 +764,7 @@ func (s *swapState) lockFunds(amount *coins.PiconeroAmount) error {
 	if err != nil {
 		return err
 	}
+	s.info.AddXMRTxID(transfer.TxID)
 
 	log.Infof("Successfully locked XMR funds: txID=%s address=%s block=%d",
 		transfer.TxID, swapDestAddr, transfer.Height)
@@ -21,6 +21,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
 	"github.com/athanorlabs/atomic-swap/net/message"
 	pcommon "github.com/athanorlabs/atomic-swap/protocol"
+	"github.com/athanorlabs/atomic-swap/protocol/backend"
 	"github.com/athanorlabs/atomic-swap/protocol/xmrmaker/offers"
 	"github.com/athanorlabs/atomic-swap/tests"
 
@@ -38,7 +39,12 @@ var (
 )
 
 func newTestSwapStateAndDB(t *testing.T) (*Instance, *swapState, *offers.MockDatabase) {
-	xmrmaker, db := newTestInstanceAndDB(t)
+	xmrmaker, swapState, db, _ := newTestSwapStateAndDBAndNet(t)
+	return xmrmaker, swapState, db
+}
+
+func newTestSwapStateAndDBAndNet(t *testing.T) (*Instance, *swapState, *offers.MockDatabase, *mockNet) {
+	xmrmaker, db, net := newTestInstanceAndDBAndNet(t)
 
 	swapState, err := newSwapStateFromStart(
 		xmrmaker.backend,
@@ -50,7 +56,7 @@ func newTestSwapStateAndDB(t *testing.T) (*Instance, *swapState, *offers.MockDat
 		desiredAmount,
 	)
 	require.NoError(t, err)
-	return xmrmaker, swapState, db
+	return xmrmaker, swapState, db, net
 }
 
 func newTestSwapState(t *testing.T) (*Instance, *swapState) {
@@ -79,6 +85,7 @@ func newTestSwap(
 	claimKey, refundKey types.Hash,
 	amount *big.Int,
 	timeout time.Duration,
+	nonce *big.Int,
 ) (*contracts.SwapCreatorSwap, [32]byte, ethcommon.Hash) {
 	tm := big.NewInt(int64(timeout.Seconds()))
 	txOpts, err := ec.TxOpts(context.Background())
@@ -86,7 +93,6 @@ func newTestSwap(
 	txOpts.Value = amount
 
 	ethAddr := ec.Address()
-	nonce := big.NewInt(0)
 	asset := types.EthAssetETH
 	tx, err := contract.NewSwap(txOpts, claimKey, refundKey, ethAddr, tm, tm,
 		ethcommon.Address(asset), amount, nonce)
@@ -128,7 +134,7 @@ func newSwap(
 	}
 
 	contractSwap, contractSwapID, txHash := newTestSwap(
-		t, ss.ETHClient(), ss.SwapCreator(), claimKey, refundKey, amount, timeout,
+		t, ss.ETHClient(), ss.SwapCreator(), claimKey, refundKey, amount, timeout, ss.OfferID().Big(),
 	)
 
 	ss.contractSwapID = contractSwapID
@@ -242,6 +248,85 @@ func TestSwapState_HandleProtocolMessage_NotifyETHLocked_invalid(t *testing.T) {
 	require.True(t, errors.Is(err, errMissingAddress))
 }
 
+// versionedBackend is a backend whose peers negotiated the given protocol
+// version.
+type versionedBackend struct {
+	backend.Backend
+	version uint16
+}
+
+func (b *versionedBackend) ProtocolVersion(_ types.Hash) uint16 {
+	return b.version
+}
+
+func TestSwapState_HandleProtocolMessage_NotifyETHLocked_protocolVersion(t *testing.T) {
+	// the contract swap ID is left out from version 2 on, and derived from the
+	// contract swap instead, whose nonce must be the offer ID
+	for _, version := range []uint16{message.ProtocolVersion1, message.ProtocolVersion2} {
+		_, s := newTestSwapState(t)
+		s.Backend = &versionedBackend{Backend: s.Backend, version: version}
+		s.nextExpectedEvent = EventETHLockedType
+
+		xmrtakerKeysAndProof, err := generateKeys()
+		require.NoError(t, err)
+		err = s.setXMRTakerKeys(
+			xmrtakerKeysAndProof.PublicKeyPair.SpendKey(),
+			xmrtakerKeysAndProof.PrivateKeyPair.ViewKey(),
+			xmrtakerKeysAndProof.Secp256k1PublicKey,
+		)
+		require.NoError(t, err)
+
+		duration := common.SwapTimeoutFromEnv(common.Development)
+		hash := newSwap(t, s, s.secp256k1Pub.Keccak256(), s.xmrtakerSecp256K1PublicKey.Keccak256(),
+			desiredAmount.BigInt(), duration)
+		swapID := s.contractSwapID
+		s.contractSwapID = types.Hash{}
+
+		msg := &message.NotifyETHLocked{
+			Address:      s.SwapCreatorAddr(),
+			TxHash:       hash,
+			ContractSwap: s.contractSwap,
+		}
+		err = s.HandleProtocolMessage(msg)
+		if version == message.ProtocolVersion1 {
+			require.ErrorIs(t, err, errNilContractSwapID)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, swapID, s.contractSwapID)
+		}
+		s.cancel()
+	}
+}
+
+func TestSwapState_HandleProtocolMessage_NotifyETHLocked_nonceNotOfferID(t *testing.T) {
+	_, s := newTestSwapState(t)
+	defer s.cancel()
+	s.Backend = &versionedBackend{Backend: s.Backend, version: message.ProtocolVersion2}
+	s.nextExpectedEvent = EventETHLockedType
+
+	xmrtakerKeysAndProof, err := generateKeys()
+	require.NoError(t, err)
+	err = s.setXMRTakerKeys(
+		xmrtakerKeysAndProof.PublicKeyPair.SpendKey(),
+		xmrtakerKeysAndProof.PrivateKeyPair.ViewKey(),
+		xmrtakerKeysAndProof.Secp256k1PublicKey,
+	)
+	require.NoError(t, err)
+
+	// a taker of version 2 must use the offer ID as nonce, so that our
+	// secret can be recovered from the swap key seed
+	contractSwap, _, hash := newTestSwap(t, s.ETHClient(), s.SwapCreator(), s.secp256k1Pub.Keccak256(),
+		s.xmrtakerSecp256K1PublicKey.Keccak256(), desiredAmount.BigInt(),
+		common.SwapTimeoutFromEnv(common.Development), contracts.GenerateNewSwapNonce())
+
+	err = s.HandleProtocolMessage(&message.NotifyETHLocked{
+		Address:      s.SwapCreatorAddr(),
+		TxHash:       hash,
+		ContractSwap: contractSwap,
+	})
+	require.ErrorIs(t, err, errNonceNotOfferID)
+}
+
 func TestSwapState_HandleProtocolMessage_NotifyETHLocked_timeout(t *testing.T) {
 	_, s := newTestSwapState(t)
 	defer s.cancel()
@@ -412,6 +497,42 @@ func TestSwapState_Exit_Aborted_1(t *testing.T) {
 	require.Equal(t, types.CompletedAbort, s.info.Status)
 }
 
+func TestSwapState_Exit_sendsAbort(t *testing.T) {
+	_, s, db, net := newTestSwapStateAndDBAndNet(t)
+	db.EXPECT().PutOffer(s.offer)
+
+	s.nextExpectedEvent = EventETHLockedType
+	err := s.Exit()
+	require.NoError(t, err)
+
+	abort, ok := net.LastSentMessage().(*message.AbortSwap)
+	require.True(t, ok)
+	require.Equal(t, s.OfferID(), abort.OfferID)
+	require.Equal(t, message.AbortCancelled, abort.Reason)
+	require.Equal(t, types.CompletedAbort, abort.Status)
+}
+
+func TestSwapState_HandleProtocolMessage_AbortSwap(t *testing.T) {
+	_, s, db, net := newTestSwapStateAndDBAndNet(t)
+	db.EXPECT().PutOffer(s.offer)
+
+	// the taker won't lock their ETH, so we stop waiting for it
+	s.nextExpectedEvent = EventETHLockedType
+	err := s.HandleProtocolMessage(&message.AbortSwap{
+		OfferID: s.OfferID(),
+		Reason:  message.AbortCancelled,
+		Status:  types.ExpectingKeys,
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.CompletedAbort, s.info.Status)
+	require.NotNil(t, s.info.PeerAbort)
+	require.Equal(t, "cancelled", s.info.PeerAbort.Reason)
+	require.Equal(t, types.ExpectingKeys, s.info.PeerAbort.Status)
+
+	// the taker isn't told about their own abort
+	require.Nil(t, net.LastSentMessage())
+}
+
 func TestSwapState_Exit_Success(t *testing.T) {
 	b, s := newTestSwapState(t)
 	s.nextExpectedEvent = EventNoneType
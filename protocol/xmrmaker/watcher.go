@@ -111,7 +111,7 @@ func (s *swapState) handleClaimedLogs(ethlog *ethtypes.Log) (bool, error) {
 
 	log.Infof("got Claimed logs in tx hash %s, exiting swap", ethlog.TxHash)
 	s.clearNextExpectedEvent(types.CompletedSuccess)
-	event := newEventExit()
+	event := newEventExit("")
 	s.eventCh <- event
 	return true, <-event.errCh
 }
@@ -18,6 +18,7 @@ var (
 	errSenderIsNotExternal     = errors.New("swap is not using an external transaction sender")
 	errUnexpectedMessageType   = errors.New("unexpected message type")
 	errUnexpectedEventType     = errors.New("unexpected event type")
+	errAbortOfferIDMismatch    = errors.New("abort is for a different swap")
 	errMissingKeys             = errors.New("did not receive XMRMaker's public spend or private view key")
 	errMissingProvidedAmount   = errors.New("did not receive provided amount")
 	errMissingAddress          = errors.New("did not receive XMRMaker's address")
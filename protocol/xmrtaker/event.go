@@ -10,6 +10,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/common/types"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/net/message"
+	pswap "github.com/athanorlabs/atomic-swap/protocol/swap"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
 )
@@ -49,6 +50,12 @@ const (
 	// represents is used, we never actually use the constant for its type.
 	EventShouldRefundType
 
+	// EventPeerAbortType is triggered when the maker tells us that they aborted
+	// the swap. It causes us to abort or refund right away if the maker hasn't
+	// locked their XMR yet. Otherwise, the maker can still claim the ETH, so
+	// the other possible events are the same as before.
+	EventPeerAbortType
+
 	// EventExitType is triggered by the protocol "exiting", which may happen
 	// via a swap cancellation via the RPC endpoint, or from the counterparty
 	// disconnecting from us on the p2p network. It causes us to attempt to
@@ -85,6 +92,8 @@ func (t EventType) String() string {
 		return "EventETHClaimedType"
 	case EventShouldRefundType:
 		return "EventShouldRefundType"
+	case EventPeerAbortType:
+		return "EventPeerAbortType"
 	case EventExitType:
 		return "EventExitType"
 	case EventNoneType:
@@ -186,11 +195,34 @@ func newEventShouldRefund() *EventShouldRefund {
 	}
 }
 
+// EventPeerAbort is an optional event. It occurs when the XMR-maker sends us an
+// AbortSwap message.
+type EventPeerAbort struct {
+	message *message.AbortSwap
+	errCh   chan error
+}
+
+// Type ...
+func (*EventPeerAbort) Type() EventType {
+	return EventPeerAbortType
+}
+
+func newEventPeerAbort(msg *message.AbortSwap) *EventPeerAbort {
+	return &EventPeerAbort{
+		message: msg,
+		errCh:   make(chan error),
+	}
+}
+
 // EventExit is an optional event. It is sent when the protocol should be stopped,
 // for example if the remote peer closes their connection with us before sending all
 // required messages, or we decide to cancel the swap.
 type EventExit struct {
-	errCh chan error
+	// reason is sent to the counterparty if the swap doesn't complete
+	// successfully. It is empty if the counterparty can't be told, for example
+	// because the stream closed.
+	reason message.AbortReason
+	errCh  chan error
 }
 
 // Type ...
@@ -198,9 +230,10 @@ func (*EventExit) Type() EventType {
 	return EventExitType
 }
 
-func newEventExit() *EventExit {
+func newEventExit(reason message.AbortReason) *EventExit {
 	return &EventExit{
-		errCh: make(chan error),
+		reason: reason,
+		errCh:  make(chan error),
 	}
 }
 
@@ -229,6 +262,11 @@ func (s *swapState) handleEvent(event Event) {
 
 		err := s.handleEventKeysReceived(e)
 		if err != nil {
+			if !s.fundsLocked {
+				// tell the maker before the error closes the stream
+				s.abortReason = message.AbortError
+				s.sendAbort()
+			}
 			e.errCh <- fmt.Errorf("failed to handle %s: %w", e.Type(), err)
 			if !s.fundsLocked {
 				return
@@ -271,6 +309,8 @@ func (s *swapState) handleEvent(event Event) {
 
 		err := s.handleEventETHClaimed(e)
 		if err != nil {
+			s.journal(pswap.NewErrorJournalEntry(err))
+			s.abortReason = message.AbortError
 			e.errCh <- fmt.Errorf("failed to handle %s: %w", e.Type(), err)
 		}
 
@@ -290,8 +330,10 @@ func (s *swapState) handleEvent(event Event) {
 			e.errCh <- fmt.Errorf("nextExpectedEvent was %s", e.Type())
 		}
 
+		s.abortReason = message.AbortTimeout
 		err := s.handleEventShouldRefund(e)
 		if err != nil {
+			s.journal(pswap.NewErrorJournalEntry(err))
 			e.errCh <- fmt.Errorf("failed to handle %s: %w", e.Type(), err)
 		}
 
@@ -299,11 +341,24 @@ func (s *swapState) handleEvent(event Event) {
 		if err != nil {
 			log.Warnf("failed to exit swap: %s", err)
 		}
+	case *EventPeerAbort:
+		// this can happen at any stage.
+		log.Infof("EventPeerAbort")
+		defer close(e.errCh)
+
+		err := s.handleEventPeerAbort(e)
+		if err != nil {
+			e.errCh <- fmt.Errorf("failed to handle %s: %w", e.Type(), err)
+		}
 	case *EventExit:
 		// this can happen at any stage.
 		log.Infof("EventExit")
 		defer close(e.errCh)
 
+		if s.abortReason == "" {
+			s.abortReason = e.reason
+		}
+
 		err := s.exit()
 		if err != nil {
 			e.errCh <- fmt.Errorf("failed to handle EventExit: %w", err)
@@ -319,15 +374,14 @@ func (s *swapState) handleEventKeysReceived(event *EventKeysReceived) error {
 		return err
 	}
 
-	// send NotifyETHLocked message
-	err = s.SendSwapMessage(resp, s.OfferID())
-	if err != nil {
-		return err
+	// send NotifyETHLocked message. The stream stays open until the swap
+	// exits, so that we can tell the maker if we abort, and they can tell us.
+	// If the stream dropped, the message is sent again when the swap is resumed
+	// on a new stream, so we keep waiting for the XMR either way.
+	if err = s.SendSwapMessage(resp, s.OfferID()); err != nil {
+		log.Warnf("failed to send NotifyETHLocked to maker, it is sent again if the stream resumes: %s", err)
 	}
 
-	// close the stream to the remote peer, since we won't be
-	// sending any more messages.
-	s.Backend.CloseProtocolStream(s.OfferID())
 	return nil
 }
 
@@ -341,6 +395,35 @@ func (s *swapState) handleEventETHClaimed(event *EventETHClaimed) error {
 	return nil
 }
 
+func (s *swapState) handleEventPeerAbort(event *EventPeerAbort) error {
+	if err := s.recordPeerAbort(event.message); err != nil {
+		return err
+	}
+
+	switch s.nextExpectedEvent {
+	case EventKeysReceivedType, EventXMRLockedType:
+		// the maker won't lock their XMR, so we stop waiting for their keys,
+		// or refund our ETH now instead of close to timeout1.
+		return s.exit()
+	default:
+		// the contract is ready, so the maker can still claim the ETH. As when
+		// the stream closes, we wait for their claim or refund after timeout2.
+		return nil
+	}
+}
+
+// recordPeerAbort records the maker's abort in the swap info and journal.
+func (s *swapState) recordPeerAbort(msg *message.AbortSwap) error {
+	if msg.OfferID != s.OfferID() {
+		return errAbortOfferIDMismatch
+	}
+
+	log.Infof("maker aborted the swap: reason=%s status=%s", msg.Reason, msg.Status)
+	s.info.SetPeerAbort(string(msg.Reason), msg.Status)
+	s.journal(pswap.NewPeerAbortJournalEntry(string(msg.Reason), msg.Status))
+	return nil
+}
+
 func (s *swapState) handleEventShouldRefund(event *EventShouldRefund) error {
 	if !s.info.Status.IsOngoing() {
 		return nil
@@ -17,12 +17,15 @@ import (
 	"github.com/athanorlabs/atomic-swap/monero"
 	"github.com/athanorlabs/atomic-swap/net/message"
 	pcommon "github.com/athanorlabs/atomic-swap/protocol"
+	pswap "github.com/athanorlabs/atomic-swap/protocol/swap"
 )
 
 // HandleProtocolMessage is called by the network to handle an incoming message.
 // If the message received is not the expected type for the point in the protocol we're at,
 // this function will return an error.
 func (s *swapState) HandleProtocolMessage(msg common.Message) error {
+	s.journal(pswap.NewMessageJournalEntry(pswap.JournalMessageReceived, message.TypeToString(msg.Type())))
+
 	switch msg := msg.(type) {
 	case *message.SendKeysMessage:
 		event := newEventKeysReceived(msg)
@@ -31,6 +34,13 @@ func (s *swapState) HandleProtocolMessage(msg common.Message) error {
 		if err != nil {
 			return err
 		}
+	case *message.AbortSwap:
+		event := newEventPeerAbort(msg)
+		s.eventCh <- event
+		err := <-event.errCh
+		if err != nil {
+			return err
+		}
 	default:
 		return errUnexpectedMessageType
 	}
@@ -125,10 +135,13 @@ func (s *swapState) handleSendKeysMessage(msg *message.SendKeysMessage) (common.
 	go s.checkForXMRLock()
 
 	out := &message.NotifyETHLocked{
-		Address:        s.SwapCreatorAddr(),
-		TxHash:         receipt.TxHash,
-		ContractSwapID: s.contractSwapID,
-		ContractSwap:   s.contractSwap,
+		Address:      s.SwapCreatorAddr(),
+		TxHash:       receipt.TxHash,
+		ContractSwap: s.contractSwap,
+	}
+	// makers derive the contract swap ID themselves from version 2 on
+	if s.ProtocolVersion(s.OfferID()) < message.ProtocolVersion2 {
+		out.ContractSwapID = s.contractSwapID
 	}
 
 	return out, nil
@@ -215,6 +228,7 @@ func (s *swapState) runT1ExpirationHandler() {
 		return
 	case <-giveUpAndRefundTimer.C:
 		log.Infof("approaching T1, attempting to refund ETH")
+		s.journal(pswap.NewTimeoutJournalEntry("t1"))
 		event := newEventShouldRefund()
 		s.eventCh <- event
 		err := <-event.errCh
@@ -278,6 +292,7 @@ func (s *swapState) runT2ExpirationHandler() {
 
 func (s *swapState) handleT2Expired() {
 	log.Debugf("handling T2")
+	s.journal(pswap.NewTimeoutJournalEntry("t2"))
 	event := newEventShouldRefund()
 	s.eventCh <- event
 	err := <-event.errCh
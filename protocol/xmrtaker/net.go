@@ -12,6 +12,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
 	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/backend"
 )
 
 // Provides returns types.ProvidesETH
@@ -26,18 +27,22 @@ func (inst *Instance) InitiateProtocol(
 	providesAmount *apd.Decimal,
 	offer *types.Offer,
 ) (common.SwapState, error) {
+	b, err := inst.backend.ForChain(offer.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	maxDecimals := uint8(coins.NumEtherDecimals)
 	var token *coins.ERC20TokenInfo
 	if offer.EthAsset.IsToken() {
-		var err error
-		token, err = inst.backend.ETHClient().ERC20Info(inst.backend.Ctx(), offer.EthAsset.Address())
+		token, err = b.CheckToken(offer.EthAsset.Address())
 		if err != nil {
 			return nil, err
 		}
 		maxDecimals = token.NumDecimals
 	}
 
-	err := coins.ValidatePositive("providesAmount", maxDecimals, providesAmount)
+	err = coins.ValidatePositive("providesAmount", maxDecimals, providesAmount)
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +73,8 @@ func (inst *Instance) InitiateProtocol(
 	}
 
 	err = validateMinBalance(
-		inst.backend.Ctx(),
-		inst.backend.ETHClient(),
+		b.Ctx(),
+		b.ETHClient(),
 		providesAmount,
 		offer.EthAsset,
 	)
@@ -77,7 +82,7 @@ func (inst *Instance) InitiateProtocol(
 		return nil, err
 	}
 
-	state, err := inst.initiate(makerPeerID, providedAssetAmount, offer.ExchangeRate, offer.EthAsset, offer.ID)
+	state, err := inst.initiate(b, makerPeerID, providedAssetAmount, offer)
 	if err != nil {
 		return nil, err
 	}
@@ -86,27 +91,27 @@ func (inst *Instance) InitiateProtocol(
 }
 
 func (inst *Instance) initiate(
+	b backend.Backend,
 	makerPeerID peer.ID,
 	providesAmount coins.EthAssetAmount,
-	exchangeRate *coins.ExchangeRate,
-	ethAsset types.EthAsset,
-	offerID types.Hash,
+	offer *types.Offer,
 ) (*swapState, error) {
 	inst.swapMu.Lock()
 	defer inst.swapMu.Unlock()
 
-	if inst.swapStates[offerID] != nil {
+	if inst.swapStates[offer.ID] != nil {
 		return nil, errProtocolAlreadyInProgress
 	}
 
 	s, err := newSwapStateFromStart(
-		inst.backend,
+		b,
 		makerPeerID,
-		offerID,
+		offer.ID,
+		offer.ChainID,
 		inst.noTransferBack,
 		providesAmount,
-		exchangeRate,
-		ethAsset,
+		offer.ExchangeRate,
+		offer.EthAsset,
 	)
 	if err != nil {
 		return nil, err
@@ -116,11 +121,11 @@ func (inst *Instance) initiate(
 		<-s.done
 		inst.swapMu.Lock()
 		defer inst.swapMu.Unlock()
-		delete(inst.swapStates, offerID)
+		delete(inst.swapStates, offer.ID)
 	}()
 
 	log.Info(color.New(color.Bold).Sprintf("**initiated swap with offer ID=%s**", s.info.OfferID))
 	log.Info(color.New(color.Bold).Sprint("DO NOT EXIT THIS PROCESS OR THE SWAP MAY BE CANCELLED!"))
-	inst.swapStates[offerID] = s
+	inst.swapStates[offer.ID] = s
 	return s, nil
 }
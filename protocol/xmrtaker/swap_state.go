@@ -25,6 +25,7 @@ import (
 	"github.com/athanorlabs/atomic-swap/dleq"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/watcher"
+	"github.com/athanorlabs/atomic-swap/metrics"
 	"github.com/athanorlabs/atomic-swap/monero"
 	"github.com/athanorlabs/atomic-swap/net/message"
 	pcommon "github.com/athanorlabs/atomic-swap/protocol"
@@ -78,6 +79,9 @@ type swapState struct {
 	nextExpectedEvent EventType
 	// set to true once funds are locked
 	fundsLocked bool
+	// the reason sent to the maker if we abort the swap, and whether it was sent
+	abortReason message.AbortReason
+	abortSent   bool
 
 	// channels
 
@@ -98,6 +102,7 @@ func newSwapStateFromStart(
 	b backend.Backend,
 	makerPeerID peer.ID,
 	offerID types.Hash,
+	chainID uint64,
 	noTransferBack bool,
 	providedAmount coins.EthAssetAmount,
 	exchangeRate *coins.ExchangeRate,
@@ -136,6 +141,7 @@ func newSwapStateFromStart(
 		stage,
 		moneroStartNumber,
 	)
+	info.ChainID = chainID
 	if err = b.SwapManager().AddSwap(info); err != nil {
 		return nil, err
 	}
@@ -322,6 +328,11 @@ func (s *swapState) updateStatus(status types.Status) {
 	s.SwapManager().PushNewStatus(s.OfferID(), status)
 }
 
+// journal appends the entry to the swap's journal
+func (s *swapState) journal(entry *pswap.JournalEntry) {
+	s.SwapManager().AppendJournal(s.OfferID(), entry)
+}
+
 // ExpectedAmount returns the amount received, or expected to be received, at the end of the swap
 func (s *swapState) ExpectedAmount() *apd.Decimal {
 	return s.info.ExpectedAmount
@@ -342,7 +353,7 @@ func (s *swapState) NotifyStreamClosed() {
 	case EventKeysReceivedType:
 		// exit the swap, the remote peer closed the stream
 		// before we received all expected messages
-		err := s.Exit()
+		err := s.exitWithReason("")
 		if err != nil {
 			log.Errorf("failed to exit swap: %s", err)
 		}
@@ -351,11 +362,17 @@ func (s *swapState) NotifyStreamClosed() {
 	}
 }
 
-// Exit is called by the network when the protocol stream closes, or if the swap_refund RPC endpoint is called.
+// Exit is called if the swap_cancel RPC endpoint is called, or if the swap could not be started.
 // It exists the swap by refunding if necessary. If no locking has been done, it simply aborts the swap.
 // If the swap already completed successfully, this function does not do anything regarding the protocol.
 func (s *swapState) Exit() error {
-	event := newEventExit()
+	return s.exitWithReason(message.AbortCancelled)
+}
+
+// exitWithReason is the same as Exit, but the maker is told the given reason
+// if the swap doesn't complete successfully. No reason is sent if it is empty.
+func (s *swapState) exitWithReason(reason message.AbortReason) error {
+	event := newEventExit(reason)
 	s.eventCh <- event
 	err := <-event.errCh
 	if err != nil {
@@ -365,8 +382,9 @@ func (s *swapState) Exit() error {
 }
 
 // exit is the same as Exit, but assumes the calling code block already holds the swapState lock.
-func (s *swapState) exit() error {
+func (s *swapState) exit() (exitErr error) {
 	defer func() {
+		s.sendAbort()
 		s.CloseProtocolStream(s.OfferID())
 
 		err := s.SwapManager().CompleteOngoingSwap(s.info)
@@ -375,6 +393,11 @@ func (s *swapState) exit() error {
 			return
 		}
 
+		metrics.RecordSwapExit(s.info.Status, exitErr)
+		if exitErr != nil {
+			s.journal(pswap.NewErrorJournalEntry(exitErr))
+		}
+
 		// delete from network state
 		s.Backend.DeleteOngoingSwap(s.OfferID())
 
@@ -452,6 +475,24 @@ func (s *swapState) exit() error {
 	}
 }
 
+// sendAbort tells the maker that we aborted the swap and why, unless the swap
+// completed successfully, we have no reason to give, or the maker aborted it.
+func (s *swapState) sendAbort() {
+	if s.abortReason == "" || s.abortSent || s.info.Status == types.CompletedSuccess || s.info.PeerAbort != nil {
+		return
+	}
+
+	s.abortSent = true
+	msg := &message.AbortSwap{
+		OfferID: s.OfferID(),
+		Reason:  s.abortReason,
+		Status:  s.info.Status,
+	}
+	if err := s.SendSwapMessage(msg, s.OfferID()); err != nil {
+		log.Debugf("failed to send AbortSwap to maker: %s", err)
+	}
+}
+
 func (s *swapState) tryRefund() (*ethtypes.Receipt, error) {
 	stage, err := s.SwapCreator().Swaps(s.ETHClient().CallOpts(s.ctx), s.contractSwapID)
 	if err != nil {
@@ -518,7 +559,7 @@ func (s *swapState) tryRefund() (*ethtypes.Receipt, error) {
 		select {
 		case event := <-s.eventCh:
 			log.Debugf("got event %s while waiting for T2", event.Type())
-			switch event.(type) {
+			switch e := event.(type) {
 			case *EventShouldRefund:
 				return s.refund()
 			case *EventETHClaimed:
@@ -527,6 +568,12 @@ func (s *swapState) tryRefund() (*ethtypes.Receipt, error) {
 				return nil, fmt.Errorf(revertSwapCompleted)
 			case *EventExit:
 				// do nothing, we're already exiting
+			case *EventPeerAbort:
+				// the maker can still claim, so we keep waiting
+				if err := s.recordPeerAbort(e.message); err != nil {
+					e.errCh <- err
+				}
+				close(e.errCh)
 			default:
 				panic(fmt.Sprintf("got unexpected event while waiting for Claimed/T2: %s", event.Type()))
 			}
@@ -554,7 +601,7 @@ func (s *swapState) generateAndSetKeys() error {
 		panic("generateAndSetKeys should only be called once")
 	}
 
-	keysAndProof, err := pcommon.GenerateKeysAndProof()
+	keysAndProof, err := pcommon.GenerateSwapKeys(s.Backend, s.OfferID())
 	if err != nil {
 		return err
 	}
@@ -603,11 +650,19 @@ func (s *swapState) lockAsset() (*ethtypes.Receipt, error) {
 	cmtXMRMaker := s.xmrmakerSecp256k1PublicKey.Keccak256()
 	log.Debugf("locking %s %s in contract", s.providedAmount.AsStd(), s.providedAmount.StdSymbol())
 
+	// From version 2 on, the nonce is the offer ID, so that a maker or taker
+	// that lost its database can derive its swap secret from the swap key
+	// seed and the offer ID found on chain.
 	nonce := contracts.GenerateNewSwapNonce()
+	if s.ProtocolVersion(s.OfferID()) >= message.ProtocolVersion2 {
+		nonce = s.OfferID().Big()
+	}
 	receipt, err := s.lockAndWaitForReceipt(cmtXMRMaker, cmtXMRTaker, nonce)
 	if err != nil {
 		return nil, err
 	}
+	s.info.AddEthTxHash(receipt.TxHash)
+	s.journal(pswap.NewEthTxJournalEntry(string(metrics.TxNewSwap), receipt.TxHash))
 
 	log.Infof("instantiated swap on-chain: amount=%s asset=%s %s",
 		s.providedAmount, s.info.EthAsset, common.ReceiptInfo(receipt))
@@ -720,7 +775,7 @@ func (s *swapState) setReady() error {
 		if stage == contracts.StageCompleted {
 			log.Infof("contract aleady set to completed, ignoring call to ready() and sending EventExit")
 			go func() {
-				err = s.Exit()
+				err = s.exitWithReason("")
 				if err != nil {
 					log.Errorf("failed to handle EventExit: %s", err)
 				}
@@ -738,6 +793,8 @@ func (s *swapState) setReady() error {
 		}
 		return err
 	}
+	s.info.AddEthTxHash(receipt.TxHash)
+	s.journal(pswap.NewEthTxJournalEntry(string(metrics.TxSetReady), receipt.TxHash))
 
 	log.Infof("contract set to ready %s", common.ReceiptInfo(receipt))
 
@@ -755,6 +812,8 @@ func (s *swapState) refund() (*ethtypes.Receipt, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.info.AddEthTxHash(receipt.TxHash)
+	s.journal(pswap.NewEthTxJournalEntry(string(metrics.TxRefund), receipt.TxHash))
 	log.Infof("refund succeeded %s", common.ReceiptInfo(receipt))
 
 	s.clearNextExpectedEvent(types.CompletedRefund)
@@ -72,6 +72,10 @@ func (*mockNet) SubmitRelayRequest(_ peer.ID, _ *message.RelayClaimRequest) (*me
 func (*mockNet) CloseProtocolStream(_ types.Hash) {}
 func (*mockNet) DeleteOngoingSwap(_ types.Hash)   {}
 
+func (*mockNet) ProtocolVersion(_ types.Hash) uint16 {
+	return message.ProtocolVersion1
+}
+
 func (*mockNet) QueryRelayerAddress(_ peer.ID) (types.Hash, error) {
 	return types.Hash{99}, nil
 }
@@ -141,7 +145,7 @@ func newTestSwapStateAndNet(t *testing.T) (*swapState, *mockNet) {
 	b, net := newBackendAndNet(t)
 	providedAmt := coins.EtherToWei(coins.StrToDecimal("1"))
 	exchangeRate := coins.ToExchangeRate(coins.StrToDecimal("1.0")) // 100%
-	swapState, err := newSwapStateFromStart(b, testPeerID, types.Hash{}, true,
+	swapState, err := newSwapStateFromStart(b, testPeerID, types.Hash{}, 0, true,
 		providedAmt, exchangeRate, types.EthAssetETH)
 	require.NoError(t, err)
 	return swapState, net
@@ -191,7 +195,7 @@ func newTestSwapStateWithERC20(t *testing.T, providesAmt *apd.Decimal) (*swapSta
 	providesEthAssetAmt := coins.NewTokenAmountFromDecimals(providesAmt, tokenInfo)
 
 	exchangeRate := coins.ToExchangeRate(apd.New(1, 0)) // 100%
-	swapState, err := newSwapStateFromStart(b, testPeerID, types.Hash{}, false,
+	swapState, err := newSwapStateFromStart(b, testPeerID, types.Hash{}, 0, false,
 		providesEthAssetAmt, exchangeRate, types.EthAsset(addr))
 	require.NoError(t, err)
 	return swapState, contract
@@ -456,6 +460,92 @@ func TestExit_invalidNextMessageType(t *testing.T) {
 	require.Equal(t, types.CompletedAbort, info.Status)
 }
 
+func TestExit_sendsAbort(t *testing.T) {
+	s, net := newTestSwapStateAndNet(t)
+	defer s.cancel()
+	s.nextExpectedEvent = EventKeysReceivedType
+
+	err := s.Exit()
+	require.NoError(t, err)
+
+	abort, ok := net.LastSentMessage().(*message.AbortSwap)
+	require.True(t, ok)
+	require.Equal(t, s.OfferID(), abort.OfferID)
+	require.Equal(t, message.AbortCancelled, abort.Reason)
+	require.Equal(t, types.CompletedAbort, abort.Status)
+}
+
+func TestSwapState_HandleProtocolMessage_AbortSwap(t *testing.T) {
+	s, net := newTestSwapStateAndNet(t)
+	defer s.cancel()
+	s.nextExpectedEvent = EventKeysReceivedType
+
+	err := s.HandleProtocolMessage(&message.AbortSwap{
+		OfferID: s.OfferID(),
+		Reason:  message.AbortError,
+		Status:  types.KeysExchanged,
+	})
+	require.NoError(t, err)
+
+	info, err := s.SwapManager().GetPastSwap(s.OfferID())
+	require.NoError(t, err)
+	require.Equal(t, types.CompletedAbort, info.Status)
+	require.NotNil(t, info.PeerAbort)
+	require.Equal(t, "error", info.PeerAbort.Reason)
+	require.Equal(t, types.KeysExchanged, info.PeerAbort.Status)
+
+	// the maker isn't told about their own abort
+	require.Nil(t, net.LastSentMessage())
+}
+
+func TestSwapState_HandleProtocolMessage_AbortSwap_refund(t *testing.T) {
+	s := newTestSwapState(t)
+	defer s.cancel()
+	s.nextExpectedEvent = EventXMRLockedType
+
+	xmrmakerKeysAndProof, err := pcommon.GenerateKeysAndProof()
+	require.NoError(t, err)
+
+	err = s.setXMRMakerKeys(
+		xmrmakerKeysAndProof.PublicKeyPair.SpendKey(),
+		xmrmakerKeysAndProof.PrivateKeyPair.ViewKey(),
+		xmrmakerKeysAndProof.Secp256k1PublicKey,
+	)
+	require.NoError(t, err)
+	s.xmrmakerAddress = fakeAddress
+
+	_, err = s.lockAsset()
+	require.NoError(t, err)
+
+	// the maker won't lock their XMR, so we refund right away
+	err = s.HandleProtocolMessage(&message.AbortSwap{
+		OfferID: s.OfferID(),
+		Reason:  message.AbortError,
+		Status:  types.CompletedAbort,
+	})
+	require.NoError(t, err)
+
+	info, err := s.SwapManager().GetPastSwap(s.OfferID())
+	require.NoError(t, err)
+	require.Equal(t, types.CompletedRefund, info.Status)
+	require.Equal(t, types.CompletedAbort, info.PeerAbort.Status)
+}
+
+func TestSwapState_HandleProtocolMessage_AbortSwap_wrongOffer(t *testing.T) {
+	s := newTestSwapState(t)
+	defer s.cancel()
+	s.nextExpectedEvent = EventKeysReceivedType
+
+	err := s.HandleProtocolMessage(&message.AbortSwap{
+		OfferID: types.Hash{0x1},
+		Reason:  message.AbortCancelled,
+		Status:  types.KeysExchanged,
+	})
+	require.ErrorIs(t, err, errAbortOfferIDMismatch)
+	require.Nil(t, s.info.PeerAbort)
+	require.Equal(t, EventKeysReceivedType, s.nextExpectedEvent)
+}
+
 func TestSwapState_ApproveToken(t *testing.T) {
 	const expectedAmtStr = "5678"
 	providesAmt := coins.StrToDecimal(expectedAmtStr)
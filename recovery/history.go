@@ -0,0 +1,680 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/cockroachdb/apd/v3"
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
+	"github.com/athanorlabs/atomic-swap/db"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
+)
+
+// DefaultBlockRange is the default maximum number of blocks queried for
+// events at once.
+const DefaultBlockRange = 5000
+
+var (
+	newTopic   = contracts.SwapCreatorParsedABI.Events["New"].ID
+	readyTopic = common.GetTopic(contracts.ReadyEventSignature)
+
+	errSwapStillLocked = errors.New("swaps with funds still locked in the contract can only be rebuilt " +
+		"with our secret from the swap key seed, and not as the XMR maker before the swap is ready")
+)
+
+// HistoryConfig is the block range of a SwapCreator event scan.
+type HistoryConfig struct {
+	SwapCreatorAddr ethcommon.Address
+	FromBlock       uint64
+	// ToBlock is the last block scanned, 0 scans up to the latest block
+	ToBlock uint64
+	// BlockRange is the maximum number of blocks queried for events at once,
+	// DefaultBlockRange is used if it is 0.
+	BlockRange uint64
+}
+
+// ChainSwap is a swap of our ethereum address, as the owner or the claimer,
+// that was found in the events of a SwapCreator contract.
+type ChainSwap struct {
+	SwapID          types.Hash
+	SwapCreatorAddr ethcommon.Address
+	Swap            *contracts.SwapCreatorSwap
+	// Provides is ProvidesETH if we are the owner of the swap, and
+	// ProvidesXMR if we are the claimer.
+	Provides    coins.ProvidesCoin
+	Stage       byte
+	BlockNumber uint64
+	StartTime   time.Time
+	// EndTime is the time of the Claimed or Refunded event, if any.
+	EndTime    *time.Time
+	NewTx      types.Hash
+	ReadyTx    *types.Hash
+	ClaimedTx  *types.Hash
+	RefundedTx *types.Hash
+	// KeyIndex is the swap key index of our secret, set by FindSeedSecrets
+	// if our commitment was derived from the swap key seed.
+	KeyIndex *uint32
+	secret   *mcrypto.PrivateSpendKey
+}
+
+// Status returns the swap status implied by the swap's events.
+func (c *ChainSwap) Status() types.Status {
+	switch {
+	case c.ClaimedTx != nil:
+		return types.CompletedSuccess
+	case c.RefundedTx != nil:
+		return types.CompletedRefund
+	case c.ReadyTx != nil:
+		return types.ContractReady
+	default:
+		return types.ETHLocked
+	}
+}
+
+// ourCommitment returns the commitment to our secret in the swap contract.
+func (c *ChainSwap) ourCommitment() [32]byte {
+	if c.Provides == coins.ProvidesETH {
+		return c.Swap.RefundCommitment
+	}
+	return c.Swap.ClaimCommitment
+}
+
+// Rebuildable returns true if RebuildSwap can add the swap to the database.
+// Swaps whose funds are still locked are restored as ongoing swaps, which
+// needs our secret. As the XMR maker, they must also be ready, as we can't
+// tell from the chain whether we locked our XMR before that.
+func (c *ChainSwap) Rebuildable() bool {
+	if !c.Status().IsOngoing() {
+		return true
+	}
+	return c.secret != nil && (c.Provides == coins.ProvidesETH || c.Stage == contracts.StageReady)
+}
+
+// FindingKind is the result of comparing a swap on chain with the database.
+type FindingKind string
+
+// Kinds of history findings
+const (
+	// FindingConsistent means the swap's database record matches the chain.
+	FindingConsistent FindingKind = "consistent"
+	// FindingMissing means a swap on chain has no database record.
+	FindingMissing FindingKind = "missing"
+	// FindingInconsistent means the status of a swap's database record does
+	// not match the chain.
+	FindingInconsistent FindingKind = "inconsistent"
+	// FindingNotOnChain means a database record has a contract swap that was
+	// created in the scanned blocks, but no New event was found for it.
+	FindingNotOnChain FindingKind = "not-on-chain"
+)
+
+// HistoryFinding is the result of comparing one swap on chain, or one
+// database record, with the other side.
+type HistoryFinding struct {
+	Kind FindingKind
+	// Chain is nil for FindingNotOnChain
+	Chain *ChainSwap
+	// OfferID and LocalStatus are unset for FindingMissing
+	OfferID     *types.Hash
+	LocalStatus types.Status
+	Reason      string
+}
+
+// String returns a one line description of the finding.
+func (f *HistoryFinding) String() string {
+	var s string
+	switch {
+	case f.Chain == nil:
+		s = fmt.Sprintf("swap %s (local status %s)", f.OfferID, f.LocalStatus)
+	case f.OfferID == nil:
+		s = fmt.Sprintf("contract swap %s (provides %s, chain status %s, block %d)",
+			f.Chain.SwapID, f.Chain.Provides, f.Chain.Status(), f.Chain.BlockNumber)
+	default:
+		s = fmt.Sprintf("swap %s, contract swap %s (local status %s, chain status %s)",
+			f.OfferID, f.Chain.SwapID, f.LocalStatus, f.Chain.Status())
+	}
+
+	if f.Reason == "" {
+		return fmt.Sprintf("%s: %s", f.Kind, s)
+	}
+	return fmt.Sprintf("%s: %s, %s", f.Kind, s, f.Reason)
+}
+
+// ScanChainSwaps returns the swaps of our ethereum address that were created
+// in the configured block range, with the state given by their events up to
+// the end of the range.
+func (r *Recoverer) ScanChainSwaps(hc *HistoryConfig) ([]*ChainSwap, error) {
+	toBlock := hc.ToBlock
+	if toBlock == 0 {
+		header, err := r.cfg.EthClient.Raw().HeaderByNumber(r.ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest block: %w", err)
+		}
+		toBlock = header.Number.Uint64()
+	}
+
+	if hc.FromBlock > toBlock {
+		return nil, fmt.Errorf("from block %d is after to block %d", hc.FromBlock, toBlock)
+	}
+
+	blockRange := hc.BlockRange
+	if blockRange == 0 {
+		blockRange = DefaultBlockRange
+	}
+
+	var swaps []*ChainSwap
+	byID := make(map[types.Hash]*ChainSwap)
+	blockTimes := make(map[uint64]time.Time)
+
+	for start := hc.FromBlock; start <= toBlock; start += blockRange {
+		end := start + blockRange - 1
+		if end > toBlock {
+			end = toBlock
+		}
+
+		log.Debugf("scanning blocks %d to %d", start, end)
+		logs, err := r.cfg.EthClient.Raw().FilterLogs(r.ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []ethcommon.Address{hc.SwapCreatorAddr},
+			Topics:    [][]ethcommon.Hash{{newTopic, readyTopic, claimedTopic, refundedTopic}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter logs of blocks %d to %d: %w", start, end, err)
+		}
+
+		for i := range logs {
+			l := &logs[i]
+			if l.Removed || len(l.Topics) == 0 {
+				continue
+			}
+
+			if l.Topics[0] == newTopic {
+				cs, err := r.chainSwapFromNewLog(l, hc.SwapCreatorAddr) //nolint:govet
+				if err != nil {
+					return nil, err
+				}
+				if cs == nil {
+					continue
+				}
+				if cs.StartTime, err = r.blockTime(blockTimes, l.BlockNumber); err != nil {
+					return nil, err
+				}
+				swaps = append(swaps, cs)
+				byID[cs.SwapID] = cs
+				continue
+			}
+
+			if len(l.Topics) < 2 {
+				continue
+			}
+			cs, has := byID[l.Topics[1]]
+			if !has {
+				continue
+			}
+
+			txHash := l.TxHash
+			switch l.Topics[0] {
+			case readyTopic:
+				cs.Stage = contracts.StageReady
+				cs.ReadyTx = &txHash
+			case claimedTopic, refundedTopic:
+				cs.Stage = contracts.StageCompleted
+				if l.Topics[0] == claimedTopic {
+					cs.ClaimedTx = &txHash
+				} else {
+					cs.RefundedTx = &txHash
+				}
+				endTime, err := r.blockTime(blockTimes, l.BlockNumber) //nolint:govet
+				if err != nil {
+					return nil, err
+				}
+				cs.EndTime = &endTime
+			}
+		}
+	}
+
+	return swaps, nil
+}
+
+// chainSwapFromNewLog returns the swap created by the given New event, or nil
+// if our address is neither its owner nor its claimer. The owner and claimer
+// are not part of the event, so they are read from the newSwap transaction.
+func (r *Recoverer) chainSwapFromNewLog(l *ethtypes.Log, swapCreatorAddr ethcommon.Address) (*ChainSwap, error) {
+	swapID, err := contracts.GetIDFromLog(l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse New event in tx %s: %w", l.TxHash, err)
+	}
+
+	t1, t2, err := contracts.GetTimeoutsFromLog(l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse New event in tx %s: %w", l.TxHash, err)
+	}
+
+	tx, _, err := r.cfg.EthClient.Raw().TransactionByHash(r.ctx, l.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction %s: %w", l.TxHash, err)
+	}
+
+	newSwapMethod := contracts.SwapCreatorParsedABI.Methods["newSwap"]
+	data := tx.Data()
+	if tx.To() == nil || *tx.To() != swapCreatorAddr || len(data) < 4 || !bytes.Equal(data[:4], newSwapMethod.ID) {
+		// the swap was created by another contract, so we can't tell who the
+		// owner is without tracing the transaction
+		log.Debugf("skipping contract swap %s, created indirectly by tx %s", types.Hash(swapID), l.TxHash)
+		return nil, nil
+	}
+
+	args, err := newSwapMethod.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode newSwap transaction %s: %w", l.TxHash, err)
+	}
+
+	owner, err := r.cfg.EthClient.Raw().TransactionSender(r.ctx, tx, l.BlockHash, l.TxIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender of transaction %s: %w", l.TxHash, err)
+	}
+
+	// newSwap(claimCommitment, refundCommitment, claimer, timeoutDuration1,
+	// timeoutDuration2, asset, value, nonce)
+	s := &contracts.SwapCreatorSwap{
+		Owner:            owner,
+		Claimer:          args[2].(ethcommon.Address),
+		ClaimCommitment:  args[0].([32]byte),
+		RefundCommitment: args[1].([32]byte),
+		Timeout1:         t1,
+		Timeout2:         t2,
+		Asset:            args[5].(ethcommon.Address),
+		Value:            args[6].(*big.Int),
+		Nonce:            args[7].(*big.Int),
+	}
+
+	if s.SwapID() != swapID {
+		return nil, fmt.Errorf("swap ID of newSwap transaction %s does not match its New event", l.TxHash)
+	}
+
+	ourAddr := r.cfg.EthClient.Address()
+	var provides coins.ProvidesCoin
+	switch ourAddr {
+	case s.Owner:
+		provides = coins.ProvidesETH
+	case s.Claimer:
+		provides = coins.ProvidesXMR
+	default:
+		return nil, nil
+	}
+
+	return &ChainSwap{
+		SwapID:          swapID,
+		SwapCreatorAddr: swapCreatorAddr,
+		Swap:            s,
+		Provides:        provides,
+		Stage:           contracts.StagePending,
+		BlockNumber:     l.BlockNumber,
+		NewTx:           l.TxHash,
+	}, nil
+}
+
+func (r *Recoverer) blockTime(cache map[uint64]time.Time, number uint64) (time.Time, error) {
+	if t, has := cache[number]; has {
+		return t, nil
+	}
+
+	header, err := r.cfg.EthClient.Raw().HeaderByNumber(r.ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get block %d: %w", number, err)
+	}
+
+	t := time.Unix(int64(header.Time), 0)
+	cache[number] = t
+	return t, nil
+}
+
+// localSwap is a swap from the database, with its contract swap info if the
+// recovery DB has it.
+type localSwap struct {
+	info        *swap.Info
+	swapID      *types.Hash
+	startNumber *big.Int
+}
+
+// localSwaps returns all swaps in the database.
+func (r *Recoverer) localSwaps() ([]*localSwap, error) {
+	infos, err := r.cfg.SwapManager.GetOngoingSwapsSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	pastIDs, err := r.cfg.SwapManager.GetPastIDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range pastIDs {
+		info, err := r.cfg.SwapManager.GetPastSwap(id) //nolint:govet
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	swaps := make([]*localSwap, len(infos))
+	for i, info := range infos {
+		ls := &localSwap{info: info}
+		ethSwapInfo, err := r.cfg.RecoveryDB.GetContractSwapInfo(info.OfferID)
+		switch {
+		case err == nil:
+			ls.swapID = &ethSwapInfo.SwapID
+			ls.startNumber = ethSwapInfo.StartNumber
+		case !errors.Is(err, chaindb.ErrKeyNotFound):
+			return nil, err
+		case info.Rebuilt:
+			// rebuilt swaps use the contract swap ID as their offer ID
+			id := info.OfferID
+			ls.swapID = &id
+		}
+		swaps[i] = ls
+	}
+
+	return swaps, nil
+}
+
+// AuditHistory compares the swaps of our ethereum address found in the
+// configured block range with the database, returning a finding for each swap
+// on chain and for each database record whose contract swap should have been
+// found, but wasn't.
+func (r *Recoverer) AuditHistory(hc *HistoryConfig) ([]*HistoryFinding, error) {
+	chainSwaps, err := r.ScanChainSwaps(hc)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := r.localSwaps()
+	if err != nil {
+		return nil, err
+	}
+
+	bySwapID := make(map[types.Hash]*localSwap)
+	byTxHash := make(map[types.Hash]*localSwap)
+	for _, ls := range local {
+		if ls.swapID != nil {
+			bySwapID[*ls.swapID] = ls
+		}
+		for _, h := range ls.info.EthTxHashes {
+			byTxHash[h] = ls
+		}
+	}
+
+	findings := make([]*HistoryFinding, 0, len(chainSwaps))
+	found := make(map[types.Hash]struct{})
+	for _, cs := range chainSwaps {
+		ls, has := bySwapID[cs.SwapID]
+		if !has {
+			ls, has = byTxHash[cs.NewTx]
+		}
+		if !has {
+			findings = append(findings, &HistoryFinding{
+				Kind:   FindingMissing,
+				Chain:  cs,
+				Reason: missingReason(cs),
+			})
+			continue
+		}
+
+		offerID := ls.info.OfferID
+		found[offerID] = struct{}{}
+		f := &HistoryFinding{
+			Kind:        FindingConsistent,
+			Chain:       cs,
+			OfferID:     &offerID,
+			LocalStatus: ls.info.Status,
+		}
+		f.Reason = inconsistencyReason(ls.info.Status, cs)
+		if f.Reason != "" {
+			f.Kind = FindingInconsistent
+		}
+		findings = append(findings, f)
+	}
+
+	for _, ls := range local {
+		if _, has := found[ls.info.OfferID]; has || ls.swapID == nil || ls.startNumber == nil {
+			continue
+		}
+		if !inRange(ls.startNumber.Uint64(), hc) {
+			continue
+		}
+		offerID := ls.info.OfferID
+		findings = append(findings, &HistoryFinding{
+			Kind:        FindingNotOnChain,
+			OfferID:     &offerID,
+			LocalStatus: ls.info.Status,
+			Reason:      fmt.Sprintf("no New event was found for contract swap %s", ls.swapID),
+		})
+	}
+
+	return findings, nil
+}
+
+// inRange returns true if the given block of a newSwap transaction was
+// scanned.
+func inRange(startNumber uint64, hc *HistoryConfig) bool {
+	return startNumber >= hc.FromBlock && (hc.ToBlock == 0 || startNumber <= hc.ToBlock)
+}
+
+func missingReason(cs *ChainSwap) string {
+	switch {
+	case !cs.Status().IsOngoing():
+		return "the swap can be rebuilt"
+	case cs.Rebuildable():
+		return fmt.Sprintf("funds are still locked in the contract, the swap can be rebuilt with swap key %d "+
+			"and recovered", *cs.KeyIndex)
+	case cs.secret != nil:
+		return "funds are still locked in the contract, but the swap is not ready, so it's unknown whether " +
+			"our XMR was locked; the swap needs to be recovered manually"
+	default:
+		return "funds are still locked in the contract, the swap needs to be recovered manually"
+	}
+}
+
+// FindSeedSecrets finds our secrets of the swaps on chain by deriving secrets
+// from the swap key seed, as described in swapkeys.Seed.FindSecrets, so that
+// swaps whose funds are still locked can be rebuilt. The reasons of missing
+// swaps are updated, and the swap key index that new swaps can derive keys
+// with is returned.
+func (r *Recoverer) FindSeedSecrets(findings []*HistoryFinding, seed *swapkeys.Seed, gapLimit uint32) (uint32, error) {
+	var swaps []*ChainSwap
+	for _, f := range findings {
+		if f.Chain != nil {
+			swaps = append(swaps, f.Chain)
+		}
+	}
+
+	found, next, err := seed.FindSecrets(seedCommitments(swaps), gapLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, f := range findings {
+		if f.Chain == nil {
+			continue
+		}
+
+		fs, has := found[f.Chain.ourCommitment()]
+		if !has {
+			continue
+		}
+
+		sk, err := mcrypto.NewPrivateSpendKey(fs.Secret[:]) //nolint:govet
+		if err != nil {
+			return 0, fmt.Errorf("failed to create private spend key: %w", err)
+		}
+
+		index := fs.Index
+		f.Chain.KeyIndex = &index
+		f.Chain.secret = sk
+		if f.Kind == FindingMissing {
+			f.Reason = missingReason(f.Chain)
+		}
+	}
+
+	return next, nil
+}
+
+// NextSwapKeyIndex returns the swap key index that new swaps can derive keys
+// with, which is the one after the last index of our secrets in the given
+// swaps, as found by swapkeys.Seed.FindSecrets.
+func NextSwapKeyIndex(swaps []*ChainSwap, seed *swapkeys.Seed, gapLimit uint32) (uint32, error) {
+	_, next, err := seed.FindSecrets(seedCommitments(swaps), gapLimit)
+	return next, err
+}
+
+// seedCommitments maps our commitments in the given swaps to the offer ID
+// that our secret would be derived with, which takers store as the swap's
+// nonce from protocol version 2 on. Secrets of older swaps, whose nonce is
+// random, can't be found from the seed.
+func seedCommitments(swaps []*ChainSwap) map[[32]byte]types.Hash {
+	commitments := make(map[[32]byte]types.Hash, len(swaps))
+	for _, cs := range swaps {
+		commitments[cs.ourCommitment()] = ethcommon.BigToHash(cs.Swap.Nonce)
+	}
+	return commitments
+}
+
+// inconsistencyReason returns why the local status doesn't match the chain,
+// or an empty string if it does.
+func inconsistencyReason(local types.Status, cs *ChainSwap) string {
+	chainStatus := cs.Status()
+
+	switch {
+	case chainStatus.IsOngoing() && !local.IsOngoing():
+		return "funds are still locked in the contract, but the swap is completed locally"
+	case chainStatus.IsOngoing():
+		return ""
+	case local.IsOngoing():
+		return "the swap is completed on chain, but still ongoing locally; run swaprecover to finish it"
+	case local != chainStatus:
+		return fmt.Sprintf("the swap ended with %s on chain, but with %s locally", chainStatus, local)
+	default:
+		return ""
+	}
+}
+
+// RebuildSwap adds a minimal swap record for a swap found on chain that has no
+// database record. The record's offer ID is the contract swap ID, as offer IDs
+// are only on chain, as the nonce, for swaps of protocol version 2 on.
+// Completed swaps are added as past swaps. Swaps whose funds are still locked
+// are added as ongoing swaps, with the recovery info needed to plan and
+// execute their recovery, if they are Rebuildable.
+func (r *Recoverer) RebuildSwap(cs *ChainSwap) (*swap.Info, error) {
+	if !cs.Rebuildable() {
+		return nil, errSwapStillLocked
+	}
+	status := cs.Status()
+
+	ethAmount, err := r.ethAssetAmount(cs.Swap)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &swap.Info{
+		Version:              swap.CurInfoVersion,
+		OfferID:              cs.SwapID,
+		Provides:             cs.Provides,
+		EthAsset:             types.EthAsset(cs.Swap.Asset),
+		Status:               status,
+		LastStatusUpdateTime: time.Now(),
+		StartTime:            cs.StartTime,
+		EndTime:              cs.EndTime,
+		Rebuilt:              true,
+	}
+	if cs.EndTime != nil {
+		info.LastStatusUpdateTime = *cs.EndTime
+	}
+
+	t1 := time.Unix(cs.Swap.Timeout1.Int64(), 0)
+	t2 := time.Unix(cs.Swap.Timeout2.Int64(), 0)
+	info.Timeout1 = &t1
+	info.Timeout2 = &t2
+
+	// only the transactions that we sent are recorded
+	if cs.Provides == coins.ProvidesETH {
+		info.ProvidedAmount = ethAmount
+		info.EthTxHashes = append(info.EthTxHashes, cs.NewTx)
+		if cs.ReadyTx != nil {
+			info.EthTxHashes = append(info.EthTxHashes, *cs.ReadyTx)
+		}
+		if cs.RefundedTx != nil {
+			info.EthTxHashes = append(info.EthTxHashes, *cs.RefundedTx)
+		}
+	} else {
+		info.ExpectedAmount = ethAmount
+		if cs.ClaimedTx != nil {
+			info.EthTxHashes = append(info.EthTxHashes, *cs.ClaimedTx)
+		}
+	}
+
+	if status.IsOngoing() {
+		if err = r.putRecoveryInfo(cs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = r.cfg.SwapManager.AddSwap(info); err != nil {
+		return nil, fmt.Errorf("failed to add rebuilt swap %s: %w", cs.SwapID, err)
+	}
+
+	return info, nil
+}
+
+// putRecoveryInfo stores the recovery info of a rebuilt ongoing swap that the
+// recovery plan needs: our secret and the contract swap.
+func (r *Recoverer) putRecoveryInfo(cs *ChainSwap) error {
+	if err := r.cfg.RecoveryDB.PutSwapPrivateKey(cs.SwapID, cs.secret); err != nil {
+		return err
+	}
+
+	err := r.cfg.RecoveryDB.PutContractSwapInfo(cs.SwapID, &db.EthereumSwapInfo{
+		StartNumber:     new(big.Int).SetUint64(cs.BlockNumber),
+		SwapID:          cs.SwapID,
+		Swap:            cs.Swap,
+		SwapCreatorAddr: cs.SwapCreatorAddr,
+	})
+	if err != nil {
+		return err
+	}
+
+	if cs.Provides == coins.ProvidesETH {
+		return r.cfg.RecoveryDB.PutNewSwapTxHash(cs.SwapID, cs.NewTx)
+	}
+
+	return nil
+}
+
+// ethAssetAmount returns the swap's value in standard units of its asset.
+func (r *Recoverer) ethAssetAmount(s *contracts.SwapCreatorSwap) (*apd.Decimal, error) {
+	asset := types.EthAsset(s.Asset)
+	if !asset.IsToken() {
+		return coins.NewWeiAmount(s.Value).AsStd(), nil
+	}
+
+	tokenInfo, err := r.cfg.EthClient.ERC20Info(r.ctx, asset.Address())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token info for %s: %w", asset, err)
+	}
+
+	return coins.NewERC20TokenAmountFromBigInt(s.Value, tokenInfo).AsStd(), nil
+}
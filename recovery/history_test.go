@@ -0,0 +1,213 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package recovery
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/db"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	pcommon "github.com/athanorlabs/atomic-swap/protocol"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+	"github.com/athanorlabs/atomic-swap/protocol/swapkeys"
+	"github.com/athanorlabs/atomic-swap/tests"
+)
+
+func TestChainSwap_Status(t *testing.T) {
+	tx := &types.Hash{0x1}
+
+	require.Equal(t, types.ETHLocked, (&ChainSwap{}).Status())
+	require.Equal(t, types.ContractReady, (&ChainSwap{ReadyTx: tx}).Status())
+	require.Equal(t, types.CompletedSuccess, (&ChainSwap{ReadyTx: tx, ClaimedTx: tx}).Status())
+	require.Equal(t, types.CompletedRefund, (&ChainSwap{RefundedTx: tx}).Status())
+}
+
+func TestInconsistencyReason(t *testing.T) {
+	tx := &types.Hash{0x1}
+	locked := &ChainSwap{}
+	claimed := &ChainSwap{ClaimedTx: tx}
+	refunded := &ChainSwap{RefundedTx: tx}
+
+	require.Empty(t, inconsistencyReason(types.XMRLocked, locked))
+	require.Empty(t, inconsistencyReason(types.CompletedSuccess, claimed))
+	require.Empty(t, inconsistencyReason(types.CompletedRefund, refunded))
+
+	require.NotEmpty(t, inconsistencyReason(types.CompletedAbort, locked))
+	require.NotEmpty(t, inconsistencyReason(types.ContractReady, claimed))
+	require.NotEmpty(t, inconsistencyReason(types.CompletedSuccess, refunded))
+}
+
+func TestInRange(t *testing.T) {
+	require.True(t, inRange(10, &HistoryConfig{}))
+	require.True(t, inRange(10, &HistoryConfig{FromBlock: 10, ToBlock: 10}))
+	require.False(t, inRange(9, &HistoryConfig{FromBlock: 10}))
+	require.False(t, inRange(11, &HistoryConfig{FromBlock: 10, ToBlock: 10}))
+}
+
+func TestRecoverer_FindSeedSecrets_rebuildable(t *testing.T) {
+	seed, err := swapkeys.NewSeed()
+	require.NoError(t, err)
+
+	offerA := types.Hash{0xa}
+	offerB := types.Hash{0xb}
+	commitment := func(offerID types.Hash, index uint32) [32]byte {
+		kp, err := pcommon.DeriveKeysAndProof(seed, offerID, index) //nolint:govet
+		require.NoError(t, err)
+		return kp.Secp256k1PublicKey.Keccak256()
+	}
+
+	tx := &types.Hash{0x1}
+	owned := &ChainSwap{
+		Provides: coins.ProvidesETH,
+		Swap:     &contracts.SwapCreatorSwap{RefundCommitment: commitment(offerA, 0), Nonce: offerA.Big()},
+	}
+	claimedPending := &ChainSwap{
+		Provides: coins.ProvidesXMR,
+		Swap:     &contracts.SwapCreatorSwap{ClaimCommitment: commitment(offerB, 2), Nonce: offerB.Big()},
+	}
+	claimedReady := &ChainSwap{
+		Provides: coins.ProvidesXMR,
+		Stage:    contracts.StageReady,
+		ReadyTx:  tx,
+		Swap:     &contracts.SwapCreatorSwap{ClaimCommitment: commitment(offerA, 3), Nonce: offerA.Big()},
+	}
+	// a swap of protocol version 1, whose nonce isn't the offer ID
+	randomNonce := &ChainSwap{
+		Provides: coins.ProvidesETH,
+		Swap:     &contracts.SwapCreatorSwap{RefundCommitment: commitment(offerB, 1), Nonce: big.NewInt(1)},
+	}
+	notOurs := &ChainSwap{
+		Provides: coins.ProvidesETH,
+		Swap:     &contracts.SwapCreatorSwap{RefundCommitment: [32]byte{0x1}, Nonce: offerA.Big()},
+	}
+	swaps := []*ChainSwap{owned, claimedPending, claimedReady, randomNonce, notOurs}
+
+	var findings []*HistoryFinding
+	for _, cs := range swaps {
+		require.False(t, cs.Rebuildable())
+		findings = append(findings, &HistoryFinding{Kind: FindingMissing, Chain: cs, Reason: missingReason(cs)})
+	}
+
+	r := NewRecoverer(context.Background(), &Config{})
+	next, err := r.FindSeedSecrets(findings, seed, 5)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), next)
+
+	kp, err := pcommon.DeriveKeysAndProof(seed, offerA, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), *owned.KeyIndex)
+	require.Equal(t, kp.PrivateKeyPair.SpendKey().String(), owned.secret.String())
+	require.True(t, owned.Rebuildable())
+
+	// as the XMR maker, we can't tell if we locked XMR before the swap is ready
+	require.Equal(t, uint32(2), *claimedPending.KeyIndex)
+	require.False(t, claimedPending.Rebuildable())
+	require.True(t, claimedReady.Rebuildable())
+
+	for i, cs := range []*ChainSwap{randomNonce, notOurs} {
+		require.Nil(t, cs.KeyIndex)
+		require.False(t, cs.Rebuildable())
+		require.Equal(t, missingReason(cs), findings[3+i].Reason)
+		require.NotEqual(t, findings[3+i].Reason, findings[0].Reason)
+	}
+
+	next, err = NextSwapKeyIndex(swaps, seed, 5)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), next)
+}
+
+func TestRecoverer_FindSeedSecrets(t *testing.T) {
+	ctx := context.Background()
+	pk := tests.GetTakerTestKey(t)
+	ec, _ := tests.NewEthClient(t)
+	swapCreatorAddr, swapCreator := contracts.DevDeploySwapCreator(t, ec, pk)
+
+	// swap key 0 was used by a swap that failed before any ETH was locked,
+	// and swap key 1 by a swap whose ETH is still locked
+	seed, err := swapkeys.NewSeed()
+	require.NoError(t, err)
+	offerID := types.Hash{0x2}
+	kp, err := pcommon.DeriveKeysAndProof(seed, offerID, 1)
+	require.NoError(t, err)
+
+	value := big.NewInt(1e15)
+	timeout := big.NewInt(3600)
+	claimer := common.EthereumPrivateKeyToAddress(tests.GetMakerTestKey(t))
+	tx, err := swapCreator.NewSwap(
+		tests.TxOptsWithValue(t, pk, value),
+		[32]byte{0x1},
+		kp.Secp256k1PublicKey.Keccak256(),
+		claimer,
+		timeout,
+		timeout,
+		types.EthAssetETH.Address(),
+		value,
+		offerID.Big(),
+	)
+	require.NoError(t, err)
+	receipt := tests.MineTransaction(t, ec, tx)
+
+	// the database was lost, so recovery starts from an empty one
+	sdb, err := db.NewDatabase(&chaindb.Config{DataDir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, sdb.Close())
+	})
+	sm, err := swap.NewManager(sdb)
+	require.NoError(t, err)
+	extEC, err := extethclient.NewEthClient(ctx, common.Development, common.DefaultGanacheEndpoint, nil, pk)
+	require.NoError(t, err)
+	t.Cleanup(extEC.Close)
+
+	r := NewRecoverer(ctx, &Config{
+		Env:         common.Development,
+		SwapManager: sm,
+		RecoveryDB:  sdb.RecoveryDB(),
+		EthClient:   extEC,
+	})
+
+	findings, err := r.AuditHistory(&HistoryConfig{
+		SwapCreatorAddr: swapCreatorAddr,
+		FromBlock:       receipt.BlockNumber.Uint64(),
+	})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	f := findings[0]
+	require.Equal(t, FindingMissing, f.Kind)
+	require.False(t, f.Chain.Rebuildable())
+
+	next, err := r.FindSeedSecrets(findings, seed, swapkeys.DefaultGapLimit)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), next)
+	require.Equal(t, uint32(1), *f.Chain.KeyIndex)
+	require.True(t, f.Chain.Rebuildable())
+
+	info, err := r.RebuildSwap(f.Chain)
+	require.NoError(t, err)
+	require.Equal(t, types.ETHLocked, info.Status)
+
+	sk, err := sdb.RecoveryDB().GetSwapPrivateKey(info.OfferID)
+	require.NoError(t, err)
+	require.Equal(t, kp.PrivateKeyPair.SpendKey().String(), sk.String())
+
+	// the rebuilt swap is refunded with the secret found from the seed
+	plans, err := r.Plans()
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	require.Equal(t, ActionRefundETH, plans[0].Action)
+	require.NoError(t, r.Execute(plans[0]))
+
+	past, err := sm.GetPastSwap(info.OfferID)
+	require.NoError(t, err)
+	require.Equal(t, types.CompletedRefund, past.Status)
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package recovery works out, and optionally performs, the action needed to
+// recover the funds of swaps that a swapd node did not finish. It only needs
+// swapd's database, an ethereum endpoint and a monerod; no p2p network
+// connection is used.
+package recovery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+// Action is what needs to be done to recover a swap's funds.
+type Action string
+
+// Recovery actions
+const (
+	// ActionWait means nothing can be done yet. If the plan has a WaitUntil
+	// time, the action will change after that time, otherwise it changes when
+	// the counterparty acts.
+	ActionWait Action = "wait"
+	// ActionRefundETH means we are the XMR taker and can refund our ETH.
+	ActionRefundETH Action = "refund-eth"
+	// ActionClaimETH means we are the XMR maker and can claim the ETH.
+	ActionClaimETH Action = "claim-eth"
+	// ActionClaimXMR means we are the XMR taker, the XMR maker revealed their
+	// secret by claiming the ETH, and we can claim the XMR.
+	ActionClaimXMR Action = "claim-xmr"
+	// ActionReclaimXMR means we are the XMR maker, the XMR taker revealed
+	// their secret by refunding the ETH, and we can take back our XMR.
+	ActionReclaimXMR Action = "reclaim-xmr"
+	// ActionMarkCompleted means there are no funds to recover, and the swap
+	// only needs to be marked as completed in the database.
+	ActionMarkCompleted Action = "mark-completed"
+	// ActionManual means the swap is in a state the tool can't safely handle.
+	ActionManual Action = "manual"
+)
+
+// Plan is the recovery action for a single unfinished swap.
+type Plan struct {
+	OfferID  types.Hash
+	Provides coins.ProvidesCoin
+	Status   types.Status
+	// Stage is the stage of the swap in the swap contract, or StageInvalid if
+	// no funds were locked in the contract.
+	Stage  byte
+	Action Action
+	Reason string
+	// WaitUntil is set for ActionWait, if the action changes at a known time.
+	WaitUntil *time.Time
+	// FinalStatus is the status the swap is marked with after the action is
+	// performed.
+	FinalStatus types.Status
+
+	// counterpartySecret is the counterparty's swap secret, needed for
+	// ActionClaimXMR and ActionReclaimXMR.
+	counterpartySecret *mcrypto.PrivateSpendKey
+}
+
+// String returns a one line description of the plan.
+func (p *Plan) String() string {
+	s := fmt.Sprintf("swap %s (provides %s, status %s, contract stage %s): %s",
+		p.OfferID, p.Provides, p.Status, contracts.StageToString(p.Stage), p.Action)
+	if p.WaitUntil != nil {
+		s += fmt.Sprintf(" until %s", p.WaitUntil.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s, %s", s, p.Reason)
+}
+
+// chainState is the state of a swap's funds that is read from the databases
+// and the ethereum chain.
+type chainState struct {
+	// hasContractSwap is true if the recovery DB has the contract swap
+	hasContractSwap bool
+	// hasNewSwapTx is true if, as the XMR taker, we sent a newSwap transaction
+	hasNewSwapTx bool
+	stage        byte
+	timeout1     time.Time
+	timeout2     time.Time
+	// now is the timestamp of the latest block
+	now time.Time
+	// claimedSecret or refundedSecret is set if the swap's Claimed or Refunded
+	// event was found.
+	claimedSecret  *mcrypto.PrivateSpendKey
+	refundedSecret *mcrypto.PrivateSpendKey
+	// storedSecret is the counterparty secret saved in the recovery DB, set if
+	// swapd found it before it stopped.
+	storedSecret *mcrypto.PrivateSpendKey
+}
+
+// xmrLocked returns true if the XMR maker may have locked its XMR.
+func xmrLocked(status types.Status) bool {
+	switch status {
+	case types.XMRLocked, types.ContractReady, types.SweepingXMR:
+		return true
+	default:
+		return false
+	}
+}
+
+// decide returns the recovery plan for a swap, given the state of its funds.
+func decide(info *swap.Info, cs *chainState) *Plan {
+	p := &Plan{
+		OfferID:  info.OfferID,
+		Provides: info.Provides,
+		Status:   info.Status,
+		Stage:    contracts.StageInvalid,
+	}
+	if cs.hasContractSwap {
+		p.Stage = cs.stage
+	}
+
+	if info.Provides == coins.ProvidesETH {
+		decideXMRTaker(p, cs)
+	} else {
+		decideXMRMaker(p, info.Status, cs)
+	}
+
+	return p
+}
+
+// decideXMRTaker fills in the plan when we provided ETH.
+func decideXMRTaker(p *Plan, cs *chainState) {
+	if cs.storedSecret != nil {
+		p.Action = ActionClaimXMR
+		p.Reason = "the counterparty's secret is stored in the database"
+		p.FinalStatus = types.CompletedSuccess
+		p.counterpartySecret = cs.storedSecret
+		return
+	}
+
+	if !cs.hasContractSwap {
+		if cs.hasNewSwapTx {
+			p.Action = ActionManual
+			p.Reason = "a newSwap transaction was sent, but its swap was not recorded; check the transaction " +
+				"and refund it with `swapcli recovery refund` once swapd is running"
+			return
+		}
+		p.Action = ActionMarkCompleted
+		p.Reason = "no ETH was locked"
+		p.FinalStatus = types.CompletedAbort
+		return
+	}
+
+	switch cs.stage {
+	case contracts.StageInvalid:
+		p.Action = ActionManual
+		p.Reason = "the swap was not found in the swap contract"
+	case contracts.StageCompleted:
+		switch {
+		case cs.claimedSecret != nil:
+			p.Action = ActionClaimXMR
+			p.Reason = "the counterparty claimed the ETH, revealing their secret"
+			p.FinalStatus = types.CompletedSuccess
+			p.counterpartySecret = cs.claimedSecret
+		case cs.refundedSecret != nil:
+			p.Action = ActionMarkCompleted
+			p.Reason = "the ETH was already refunded"
+			p.FinalStatus = types.CompletedRefund
+		default:
+			p.Action = ActionManual
+			p.Reason = "the swap is completed, but no Claimed or Refunded event was found"
+		}
+	default:
+		canRefund := (cs.now.Before(cs.timeout1) && cs.stage != contracts.StageReady) ||
+			!cs.now.Before(cs.timeout2)
+		if canRefund {
+			p.Action = ActionRefundETH
+			p.Reason = "the ETH can be refunded"
+			p.FinalStatus = types.CompletedRefund
+			return
+		}
+		p.Action = ActionWait
+		p.Reason = "the counterparty can claim the ETH until the second timeout, after which it can be refunded"
+		t2 := cs.timeout2
+		p.WaitUntil = &t2
+	}
+}
+
+// decideXMRMaker fills in the plan when we provided XMR.
+func decideXMRMaker(p *Plan, status types.Status, cs *chainState) {
+	if cs.storedSecret != nil {
+		p.Action = ActionReclaimXMR
+		p.Reason = "the counterparty's secret is stored in the database"
+		p.FinalStatus = types.CompletedRefund
+		p.counterpartySecret = cs.storedSecret
+		return
+	}
+
+	if !cs.hasContractSwap {
+		p.Action = ActionMarkCompleted
+		p.Reason = "no ETH was locked by the counterparty, so no XMR was locked"
+		p.FinalStatus = types.CompletedAbort
+		return
+	}
+
+	locked := xmrLocked(status)
+
+	switch cs.stage {
+	case contracts.StageInvalid:
+		p.Action = ActionManual
+		p.Reason = "the swap was not found in the swap contract"
+	case contracts.StageCompleted:
+		switch {
+		case cs.claimedSecret != nil:
+			p.Action = ActionMarkCompleted
+			p.Reason = "the ETH was already claimed"
+			p.FinalStatus = types.CompletedSuccess
+		case cs.refundedSecret != nil && locked:
+			p.Action = ActionReclaimXMR
+			p.Reason = "the counterparty refunded the ETH, revealing their secret"
+			p.FinalStatus = types.CompletedRefund
+			p.counterpartySecret = cs.refundedSecret
+		case cs.refundedSecret != nil:
+			p.Action = ActionMarkCompleted
+			p.Reason = "the counterparty refunded the ETH and no XMR was locked"
+			p.FinalStatus = types.CompletedRefund
+		default:
+			p.Action = ActionManual
+			p.Reason = "the swap is completed, but no Claimed or Refunded event was found"
+		}
+	default:
+		switch {
+		case !locked:
+			// Claiming without having locked XMR would take the
+			// counterparty's ETH, so we leave it for them to refund.
+			p.Action = ActionMarkCompleted
+			p.Reason = "no XMR was locked, the counterparty can refund their ETH"
+			p.FinalStatus = types.CompletedAbort
+		case !cs.now.Before(cs.timeout2):
+			p.Action = ActionWait
+			p.Reason = "it's too late to claim the ETH; the XMR can be reclaimed once the counterparty refunds"
+		case cs.stage == contracts.StageReady || !cs.now.Before(cs.timeout1):
+			p.Action = ActionClaimETH
+			p.Reason = "the ETH can be claimed"
+			p.FinalStatus = types.CompletedSuccess
+		default:
+			p.Action = ActionWait
+			p.Reason = "the counterparty has not set the swap ready, the ETH can be claimed after the first timeout"
+			t1 := cs.timeout1
+			p.WaitUntil = &t1
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package recovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+func TestDecide(t *testing.T) {
+	kp, err := mcrypto.GenerateKeys()
+	require.NoError(t, err)
+	secret := kp.SpendKey()
+
+	t1 := time.Now()
+	t2 := t1.Add(time.Hour)
+	beforeT1 := t1.Add(-time.Minute)
+	betweenTimeouts := t1.Add(time.Minute)
+	afterT2 := t2.Add(time.Minute)
+
+	onChain := func(stage byte, now time.Time) *chainState {
+		return &chainState{
+			hasContractSwap: true,
+			stage:           stage,
+			timeout1:        t1,
+			timeout2:        t2,
+			now:             now,
+		}
+	}
+
+	type testCase struct {
+		name        string
+		provides    coins.ProvidesCoin
+		status      types.Status
+		cs          *chainState
+		action      Action
+		finalStatus types.Status
+		waitUntil   *time.Time
+		hasSecret   bool
+	}
+
+	testCases := []testCase{
+		// XMR taker
+		{
+			name:        "taker, nothing locked",
+			provides:    coins.ProvidesETH,
+			status:      types.ExpectingKeys,
+			cs:          &chainState{},
+			action:      ActionMarkCompleted,
+			finalStatus: types.CompletedAbort,
+		},
+		{
+			name:     "taker, newSwap sent but not recorded",
+			provides: coins.ProvidesETH,
+			status:   types.ExpectingKeys,
+			cs:       &chainState{hasNewSwapTx: true},
+			action:   ActionManual,
+		},
+		{
+			name:        "taker, pending before t1",
+			provides:    coins.ProvidesETH,
+			status:      types.ETHLocked,
+			cs:          onChain(contracts.StagePending, beforeT1),
+			action:      ActionRefundETH,
+			finalStatus: types.CompletedRefund,
+		},
+		{
+			name:      "taker, ready before t1",
+			provides:  coins.ProvidesETH,
+			status:    types.ContractReady,
+			cs:        onChain(contracts.StageReady, beforeT1),
+			action:    ActionWait,
+			waitUntil: &t2,
+		},
+		{
+			name:      "taker, pending between timeouts",
+			provides:  coins.ProvidesETH,
+			status:    types.ETHLocked,
+			cs:        onChain(contracts.StagePending, betweenTimeouts),
+			action:    ActionWait,
+			waitUntil: &t2,
+		},
+		{
+			name:        "taker, ready after t2",
+			provides:    coins.ProvidesETH,
+			status:      types.ContractReady,
+			cs:          onChain(contracts.StageReady, afterT2),
+			action:      ActionRefundETH,
+			finalStatus: types.CompletedRefund,
+		},
+		{
+			name:     "taker, claimed by maker",
+			provides: coins.ProvidesETH,
+			status:   types.ContractReady,
+			cs: func() *chainState {
+				cs := onChain(contracts.StageCompleted, betweenTimeouts)
+				cs.claimedSecret = secret
+				return cs
+			}(),
+			action:      ActionClaimXMR,
+			finalStatus: types.CompletedSuccess,
+			hasSecret:   true,
+		},
+		{
+			name:     "taker, already refunded",
+			provides: coins.ProvidesETH,
+			status:   types.ETHLocked,
+			cs: func() *chainState {
+				cs := onChain(contracts.StageCompleted, afterT2)
+				cs.refundedSecret = secret
+				return cs
+			}(),
+			action:      ActionMarkCompleted,
+			finalStatus: types.CompletedRefund,
+		},
+		{
+			name:        "taker, interrupted sweep",
+			provides:    coins.ProvidesETH,
+			status:      types.SweepingXMR,
+			cs:          &chainState{storedSecret: secret},
+			action:      ActionClaimXMR,
+			finalStatus: types.CompletedSuccess,
+			hasSecret:   true,
+		},
+		// XMR maker
+		{
+			name:        "maker, no contract swap",
+			provides:    coins.ProvidesXMR,
+			status:      types.KeysExchanged,
+			cs:          &chainState{},
+			action:      ActionMarkCompleted,
+			finalStatus: types.CompletedAbort,
+		},
+		{
+			name:        "maker, XMR not locked",
+			provides:    coins.ProvidesXMR,
+			status:      types.ETHLocked,
+			cs:          onChain(contracts.StagePending, betweenTimeouts),
+			action:      ActionMarkCompleted,
+			finalStatus: types.CompletedAbort,
+		},
+		{
+			name:      "maker, pending before t1",
+			provides:  coins.ProvidesXMR,
+			status:    types.XMRLocked,
+			cs:        onChain(contracts.StagePending, beforeT1),
+			action:    ActionWait,
+			waitUntil: &t1,
+		},
+		{
+			name:        "maker, ready before t1",
+			provides:    coins.ProvidesXMR,
+			status:      types.ContractReady,
+			cs:          onChain(contracts.StageReady, beforeT1),
+			action:      ActionClaimETH,
+			finalStatus: types.CompletedSuccess,
+		},
+		{
+			name:        "maker, pending between timeouts",
+			provides:    coins.ProvidesXMR,
+			status:      types.XMRLocked,
+			cs:          onChain(contracts.StagePending, betweenTimeouts),
+			action:      ActionClaimETH,
+			finalStatus: types.CompletedSuccess,
+		},
+		{
+			name:     "maker, too late to claim",
+			provides: coins.ProvidesXMR,
+			status:   types.XMRLocked,
+			cs:       onChain(contracts.StageReady, afterT2),
+			action:   ActionWait,
+		},
+		{
+			name:     "maker, refunded by taker",
+			provides: coins.ProvidesXMR,
+			status:   types.XMRLocked,
+			cs: func() *chainState {
+				cs := onChain(contracts.StageCompleted, afterT2)
+				cs.refundedSecret = secret
+				return cs
+			}(),
+			action:      ActionReclaimXMR,
+			finalStatus: types.CompletedRefund,
+			hasSecret:   true,
+		},
+		{
+			name:        "maker, completed without events",
+			provides:    coins.ProvidesXMR,
+			status:      types.ContractReady,
+			cs:          onChain(contracts.StageCompleted, afterT2),
+			action:      ActionManual,
+			finalStatus: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		info := &swap.Info{
+			OfferID:  types.Hash{0x1},
+			Provides: tc.provides,
+			Status:   tc.status,
+		}
+
+		p := decide(info, tc.cs)
+		require.Equal(t, tc.action, p.Action, tc.name)
+		require.Equal(t, tc.finalStatus, p.FinalStatus, tc.name)
+		require.Equal(t, tc.waitUntil, p.WaitUntil, tc.name)
+		require.Equal(t, tc.hasSecret, p.counterpartySecret != nil, tc.name)
+		require.NotEmpty(t, p.Reason, tc.name)
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
+	"github.com/athanorlabs/atomic-swap/db"
+	contracts "github.com/athanorlabs/atomic-swap/ethereum"
+	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/metrics"
+	"github.com/athanorlabs/atomic-swap/monero"
+	pcommon "github.com/athanorlabs/atomic-swap/protocol"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+	"github.com/athanorlabs/atomic-swap/protocol/txsender"
+)
+
+var (
+	log = logging.Logger("recovery")
+
+	claimedTopic  = common.GetTopic(contracts.ClaimedEventSignature)
+	refundedTopic = common.GetTopic(contracts.RefundedEventSignature)
+
+	errNoXMRClient = errors.New("a monero wallet is required to recover XMR")
+)
+
+// Config contains the values needed by a Recoverer. The config is not copied,
+// so MoneroClient can be set after the Recoverer is created.
+type Config struct {
+	Env          common.Environment
+	SwapManager  swap.Manager
+	RecoveryDB   *db.RecoveryDB
+	EthClient    extethclient.EthClient
+	MoneroClient monero.WalletClient // optional, only needed to recover XMR
+	// NoTransferBack leaves recovered XMR in the rebuilt swap wallet, instead
+	// of sweeping it to the primary wallet.
+	NoTransferBack bool
+}
+
+// Recoverer works out and performs the recovery actions for unfinished swaps.
+type Recoverer struct {
+	ctx context.Context
+	cfg *Config
+}
+
+// NewRecoverer returns a new *Recoverer.
+func NewRecoverer(ctx context.Context, cfg *Config) *Recoverer {
+	return &Recoverer{
+		ctx: ctx,
+		cfg: cfg,
+	}
+}
+
+// Plans returns the recovery plan of each unfinished swap in the database.
+func (r *Recoverer) Plans() ([]*Plan, error) {
+	swaps, err := r.cfg.SwapManager.GetOngoingSwapsSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]*Plan, 0, len(swaps))
+	for _, info := range swaps {
+		p, err := r.plan(info) //nolint:govet
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan recovery of swap %s: %w", info.OfferID, err)
+		}
+		plans = append(plans, p)
+	}
+
+	return plans, nil
+}
+
+// Plan returns the recovery plan of the unfinished swap with the given offer
+// ID.
+func (r *Recoverer) Plan(offerID types.Hash) (*Plan, error) {
+	info, err := r.cfg.SwapManager.GetOngoingSwapSnapshot(offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.plan(info)
+}
+
+func (r *Recoverer) plan(info *swap.Info) (*Plan, error) {
+	cs, err := r.chainState(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return decide(info, cs), nil
+}
+
+// chainState reads the state of the swap's funds from the recovery DB and the
+// swap contract.
+func (r *Recoverer) chainState(info *swap.Info) (*chainState, error) {
+	cs := new(chainState)
+
+	secret, err := r.cfg.RecoveryDB.GetCounterpartySwapPrivateKey(info.OfferID)
+	if err == nil {
+		cs.storedSecret = secret
+	} else if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	_, err = r.cfg.RecoveryDB.GetNewSwapTxHash(info.OfferID)
+	if err == nil {
+		cs.hasNewSwapTx = true
+	} else if !errors.Is(err, chaindb.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	ethSwapInfo, err := r.cfg.RecoveryDB.GetContractSwapInfo(info.OfferID)
+	if errors.Is(err, chaindb.ErrKeyNotFound) {
+		return cs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cs.hasContractSwap = true
+	cs.timeout1 = time.Unix(ethSwapInfo.Swap.Timeout1.Int64(), 0)
+	cs.timeout2 = time.Unix(ethSwapInfo.Swap.Timeout2.Int64(), 0)
+
+	swapCreator, err := contracts.NewSwapCreator(ethSwapInfo.SwapCreatorAddr, r.cfg.EthClient.Raw())
+	if err != nil {
+		return nil, err
+	}
+
+	cs.stage, err = swapCreator.Swaps(r.cfg.EthClient.CallOpts(r.ctx), ethSwapInfo.SwapID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get swap stage: %w", err)
+	}
+
+	header, err := r.cfg.EthClient.Raw().HeaderByNumber(r.ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	cs.now = time.Unix(int64(header.Time), 0)
+
+	if cs.stage == contracts.StageCompleted {
+		cs.claimedSecret, err = r.findSecret(ethSwapInfo, claimedTopic)
+		if err != nil {
+			return nil, err
+		}
+		cs.refundedSecret, err = r.findSecret(ethSwapInfo, refundedTopic)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cs, nil
+}
+
+// findSecret returns the secret revealed by the swap's Claimed or Refunded
+// event, or nil if there is no such event.
+func (r *Recoverer) findSecret(
+	ethSwapInfo *db.EthereumSwapInfo,
+	topic ethcommon.Hash,
+) (*mcrypto.PrivateSpendKey, error) {
+	logs, err := r.cfg.EthClient.Raw().FilterLogs(r.ctx, ethereum.FilterQuery{
+		FromBlock: ethSwapInfo.StartNumber,
+		Addresses: []ethcommon.Address{ethSwapInfo.SwapCreatorAddr},
+		Topics:    [][]ethcommon.Hash{{topic}, {ethSwapInfo.SwapID}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter logs: %w", err)
+	}
+
+	if len(logs) == 0 {
+		return nil, nil
+	}
+
+	return contracts.GetSecretFromLog(&logs[0], topic)
+}
+
+// Execute performs the plan's action and marks the swap with the plan's
+// final status. Plans with ActionWait or ActionManual can't be executed.
+func (r *Recoverer) Execute(p *Plan) error {
+	info, err := r.cfg.SwapManager.GetOngoingSwap(p.OfferID)
+	if err != nil {
+		return err
+	}
+
+	switch p.Action {
+	case ActionRefundETH:
+		err = r.sendContractTx(info, metrics.TxRefund)
+	case ActionClaimETH:
+		err = r.sendContractTx(info, metrics.TxClaim)
+	case ActionClaimXMR, ActionReclaimXMR:
+		err = r.recoverXMR(info, p.counterpartySecret)
+	case ActionMarkCompleted:
+	default:
+		return fmt.Errorf("swap %s can't be recovered automatically: %s", p.OfferID, p.Reason)
+	}
+	if err != nil {
+		return err
+	}
+
+	info.Status = p.FinalStatus
+	if err = r.cfg.SwapManager.CompleteOngoingSwap(info); err != nil {
+		return fmt.Errorf("failed to mark swap %s as completed: %w", p.OfferID, err)
+	}
+
+	return r.cfg.RecoveryDB.DeleteSwap(p.OfferID)
+}
+
+func (r *Recoverer) sender(ethSwapInfo *db.EthereumSwapInfo) (txsender.Sender, error) {
+	if !r.cfg.EthClient.HasPrivateKey() {
+		return nil, errors.New("an ethereum private key is required to send transactions")
+	}
+
+	swapCreator, err := contracts.NewSwapCreator(ethSwapInfo.SwapCreatorAddr, r.cfg.EthClient.Raw())
+	if err != nil {
+		return nil, err
+	}
+
+	return txsender.NewSenderWithPrivateKey(
+		r.ctx,
+		r.cfg.EthClient,
+		ethSwapInfo.SwapCreatorAddr,
+		swapCreator,
+		nil, // only needed for newSwap
+	), nil
+}
+
+// ourSecret returns our swap secret in the form taken by the swap contract
+func (r *Recoverer) ourSecret(offerID types.Hash) ([32]byte, error) {
+	var secret [32]byte
+
+	sk, err := r.cfg.RecoveryDB.GetSwapPrivateKey(offerID)
+	if err != nil {
+		return secret, fmt.Errorf("failed to get swap private key: %w", err)
+	}
+
+	copy(secret[:], common.Reverse(sk.Bytes()))
+	return secret, nil
+}
+
+// sendContractTx sends a claim or refund transaction for the swap, revealing
+// our secret, and records the transaction in the swap's info and journal.
+func (r *Recoverer) sendContractTx(info *swap.Info, txType metrics.TxType) error {
+	ethSwapInfo, err := r.cfg.RecoveryDB.GetContractSwapInfo(info.OfferID)
+	if err != nil {
+		return fmt.Errorf("failed to get contract swap info: %w", err)
+	}
+
+	sender, err := r.sender(ethSwapInfo)
+	if err != nil {
+		return err
+	}
+
+	secret, err := r.ourSecret(info.OfferID)
+	if err != nil {
+		return err
+	}
+
+	var receipt *ethtypes.Receipt
+	switch txType {
+	case metrics.TxClaim:
+		receipt, err = sender.Claim(ethSwapInfo.Swap, secret)
+	case metrics.TxRefund:
+		receipt, err = sender.Refund(ethSwapInfo.Swap, secret)
+	default:
+		panic(fmt.Sprintf("unexpected transaction type %q", txType))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Infof("sent %s transaction for swap %s: %s", txType, info.OfferID, common.ReceiptInfo(receipt))
+	info.AddEthTxHash(receipt.TxHash)
+	r.cfg.SwapManager.AppendJournal(info.OfferID, swap.NewEthTxJournalEntry(string(txType), receipt.TxHash))
+	return nil
+}
+
+// recoverXMR rebuilds the shared swap wallet from our secret and the
+// counterparty's secret, and sweeps it to our primary wallet unless
+// NoTransferBack is set.
+func (r *Recoverer) recoverXMR(info *swap.Info, counterpartySecret *mcrypto.PrivateSpendKey) error {
+	if r.cfg.MoneroClient == nil {
+		return errNoXMRClient
+	}
+
+	// save the secret, so a failed sweep can be retried without the chain
+	if err := r.cfg.RecoveryDB.PutCounterpartySwapPrivateKey(info.OfferID, counterpartySecret); err != nil {
+		return err
+	}
+
+	sk, err := r.cfg.RecoveryDB.GetSwapPrivateKey(info.OfferID)
+	if err != nil {
+		return fmt.Errorf("failed to get swap private key: %w", err)
+	}
+
+	vk, err := sk.View()
+	if err != nil {
+		return err
+	}
+
+	// rebuilt swaps don't have the counterparty's keys, but their view key is
+	// derived from their secret
+	_, counterpartyVK, err := r.cfg.RecoveryDB.GetCounterpartySwapKeys(info.OfferID)
+	if errors.Is(err, chaindb.ErrKeyNotFound) && info.Rebuilt {
+		counterpartyVK, err = counterpartySecret.View()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get counterparty swap keys: %w", err)
+	}
+
+	kpAB := pcommon.GetClaimKeypair(sk, counterpartySecret, vk, counterpartyVK)
+
+	return pcommon.ClaimMonero(
+		r.ctx,
+		r.cfg.Env,
+		info,
+		r.cfg.MoneroClient,
+		kpAB,
+		r.cfg.MoneroClient.PrimaryAddress(),
+		r.cfg.NoTransferBack,
+		r.cfg.SwapManager,
+	)
+}
@@ -19,6 +19,7 @@ import (
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/ethereum/block"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/metrics"
 	"github.com/athanorlabs/atomic-swap/net/message"
 )
 
@@ -105,6 +106,7 @@ func ValidateAndSendTransaction(
 	}
 
 	log.Infof("relayed claim %s", common.ReceiptInfo(receipt))
+	metrics.RecordGas(metrics.TxRelayedClaim, receipt)
 	return &message.RelayClaimResponse{TxHash: tx.Hash()}, nil
 }
 
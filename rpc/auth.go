@@ -0,0 +1,44 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+)
+
+// Credentials are the username and password that HTTP and websocket requests
+// must provide, using basic authentication, when set in the server's config.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// basicAuth wraps the handler so that requests without matching credentials
+// are rejected. Hashes of the values are compared, so the comparison time
+// depends on neither the expected nor the provided lengths.
+func basicAuth(creds *Credentials, next http.Handler) http.Handler {
+	expectedUser := sha256.Sum256([]byte(creds.Username))
+	expectedPass := sha256.Sum256([]byte(creds.Password))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if ok {
+			user := sha256.Sum256([]byte(username))
+			pass := sha256.Sum256([]byte(password))
+			userMatch := subtle.ConstantTimeCompare(user[:], expectedUser[:])
+			passMatch := subtle.ConstantTimeCompare(pass[:], expectedPass[:])
+			ok = userMatch&passMatch == 1
+		}
+
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="swapd", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"errors"
 	"net/http"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
@@ -15,15 +16,29 @@ type DaemonService struct {
 	stopServer      func()
 	env             common.Environment
 	swapCreatorAddr *ethcommon.Address
+	reload          ReloadFunc
 }
 
+// ReloadFunc re-reads the configuration of swapd and applies the settings
+// that can change without a restart. It returns the names of the settings
+// that changed.
+type ReloadFunc func() ([]string, error)
+
+var errReloadNotSupported = errors.New("reloading the configuration is not supported")
+
 // NewDaemonService creates a new daemon service. `swapCreatorAddr` is optional
-// and not set by bootnodes.
-func NewDaemonService(stopServer func(), env common.Environment, swapCreatorAddr *ethcommon.Address) *DaemonService {
+// and not set by bootnodes. `reload` is optional.
+func NewDaemonService(
+	stopServer func(),
+	env common.Environment,
+	swapCreatorAddr *ethcommon.Address,
+	reload ReloadFunc,
+) *DaemonService {
 	return &DaemonService{
 		stopServer:      stopServer,
 		env:             env,
 		swapCreatorAddr: swapCreatorAddr,
+		reload:          reload,
 	}
 }
 
@@ -50,3 +65,29 @@ func (s *DaemonService) Version(_ *http.Request, _ *any, resp *VersionResponse)
 	resp.SwapCreatorAddr = s.swapCreatorAddr
 	return nil
 }
+
+// ReloadResponse lists the settings that changed when the configuration was
+// reloaded.
+type ReloadResponse struct {
+	Changed []string `json:"changed" validate:"dive,required"`
+}
+
+// Reload re-reads the configuration file and applies the settings that can
+// change without a restart. It fails, without applying anything, if a setting
+// that needs a restart changed.
+func (s *DaemonService) Reload(_ *http.Request, _ *any, resp *ReloadResponse) error {
+	if s.reload == nil {
+		return errReloadNotSupported
+	}
+
+	changed, err := s.reload()
+	if err != nil {
+		return err
+	}
+
+	resp.Changed = changed
+	if resp.Changed == nil {
+		resp.Changed = []string{}
+	}
+	return nil
+}
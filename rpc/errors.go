@@ -11,6 +11,10 @@ var (
 	// net_ errors
 	errNoOfferWithID          = errors.New("peer does not have offer with given ID")
 	errUnsupportedForBootnode = errors.New("unsupported for bootnode")
+	errNoOrderBook            = errors.New("the order book is not enabled")
+
+	// index_ errors
+	errNoOfferIndex = errors.New("the index namespace needs an offer index")
 
 	// ws errors
 	errInvalidMethod       = errors.New("invalid method")
@@ -0,0 +1,93 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"context"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
+)
+
+// suggestedExchangeRate returns the exchange rate of XMR in the given asset,
+// along with the aggregated USD prices that it is calculated from. The price
+// of a token is queried with the symbol of its feed in the token registry.
+func suggestedExchangeRate(
+	ctx context.Context,
+	pb ProtocolBackend,
+	sources *pricefeed.SourcesConfig,
+	proxy *netproxy.Proxy,
+	asset types.EthAsset,
+) (*SuggestedExchangeRateResponse, error) {
+	var (
+		token       *coins.ERC20TokenInfo
+		tokenSymbol string
+		err         error
+	)
+
+	// Look up the token first, so that a token without a price feed fails
+	// without querying the price sources.
+	if asset.IsToken() {
+		tokenSymbol, err = sources.TokenSymbol(pb.ETHClient().ChainID().Uint64(), asset.Address())
+		if err != nil {
+			return nil, err
+		}
+
+		token, err = pb.ETHClient().ERC20Info(ctx, asset.Address())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	aggregator, err := pricefeed.NewAggregatorFromConfig(ctx, pb.ETHClient().Raw(), proxy, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	xmrPrice, err := aggregator.Price(ctx, pricefeed.SymbolXMR)
+	if err != nil {
+		return nil, err
+	}
+
+	ethPrice, err := aggregator.Price(ctx, pricefeed.SymbolETH)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SuggestedExchangeRateResponse{
+		ETHUpdatedAt: ethPrice.UpdatedAt,
+		ETHPrice:     ethPrice.Price,
+		XMRUpdatedAt: xmrPrice.UpdatedAt,
+		XMRPrice:     xmrPrice.Price,
+		ETHSources:   ethPrice.Sources,
+		XMRSources:   xmrPrice.Sources,
+	}
+
+	if token == nil {
+		resp.ExchangeRate, err = coins.CalcExchangeRate(xmrPrice.Price, ethPrice.Price)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	tokenPrice, err := aggregator.Price(ctx, tokenSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.ExchangeRate, err = coins.CalcTokenExchangeRate(xmrPrice.Price, tokenPrice.Price, token)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Token = token
+	resp.TokenSymbol = tokenSymbol
+	resp.TokenUpdatedAt = &tokenPrice.UpdatedAt
+	resp.TokenPrice = tokenPrice.Price
+	resp.TokenSources = tokenPrice.Sources
+	return resp, nil
+}
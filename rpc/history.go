@@ -0,0 +1,192 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+// HistoryEntry is a past swap with its fiat valuation, as returned by
+// swap_exportHistory.
+type HistoryEntry struct {
+	ID             types.Hash          `json:"id" validate:"required"`
+	Provided       coins.ProvidesCoin  `json:"provided" validate:"required"`
+	EthAsset       types.EthAsset      `json:"ethAsset"`
+	EthAssetSymbol string              `json:"ethAssetSymbol" validate:"required"`
+	ProvidedAmount *apd.Decimal        `json:"providedAmount" validate:"required_unless=Rebuilt true"`
+	ExpectedAmount *apd.Decimal        `json:"expectedAmount" validate:"required_unless=Rebuilt true"`
+	RelayerFee     *apd.Decimal        `json:"relayerFee"`
+	ExchangeRate   *coins.ExchangeRate `json:"exchangeRate" validate:"required_unless=Rebuilt true"`
+	Status         types.Status        `json:"status" validate:"required"`
+	StartTime      time.Time           `json:"startTime" validate:"required"`
+	EndTime        *time.Time          `json:"endTime"`
+	EthTxHashes    []types.Hash        `json:"ethTxHashes"`
+	XMRTxIDs       []string            `json:"xmrTxIDs"`
+	// XMRUSDPrice is the XMR/USD price at the time the swap completed. It is
+	// nil if no price could be found for that time.
+	XMRUSDPrice *apd.Decimal `json:"xmrUSDPrice"`
+	// USDValue is the USD value of the XMR side of the swap at completion
+	// time. It is nil if XMRUSDPrice is nil or the XMR amount is unknown.
+	USDValue *apd.Decimal `json:"usdValue"`
+	// Rebuilt is true if the swap was recreated from chain events, in which
+	// case the XMR amount and exchange rate are unknown.
+	Rebuilt bool `json:"rebuilt,omitempty"`
+	// PeerAbort is set if the counterparty aborted the swap, with the reason
+	// and status that they gave.
+	PeerAbort *swap.PeerAbort `json:"peerAbort,omitempty"`
+}
+
+// ExportHistoryRequest ...
+type ExportHistoryRequest struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+// ExportHistoryResponse ...
+type ExportHistoryResponse struct {
+	Swaps []*HistoryEntry `json:"swaps" validate:"dive,required"`
+}
+
+// exportHistoryPageSize is the number of past swaps that ExportHistory reads
+// from the swap index at a time.
+const exportHistoryPageSize = 100
+
+// ExportHistory returns all past swaps that completed within the requested
+// time range along with their USD value at completion time. The swaps are
+// sorted from oldest to newest.
+func (s *SwapService) ExportHistory(_ *http.Request, req *ExportHistoryRequest, resp *ExportHistoryResponse) error {
+	// The index is ordered by start time, and a swap completes after it
+	// starts, so only the end of the range bounds the query. Swaps that
+	// started before the range but completed within it are still exported.
+	query := &swap.PastSwapsQuery{
+		To:    req.To,
+		Order: swap.SortOldestFirst,
+		Limit: exportHistoryPageSize,
+	}
+
+	history := s.priceHistorySource()
+
+	resp.Swaps = []*HistoryEntry{}
+	for {
+		page, err := s.sm.GetPastSwaps(query)
+		if err != nil {
+			return err
+		}
+
+		for _, info := range page.Swaps {
+			completedAt := info.StartTime
+			if info.EndTime != nil {
+				completedAt = *info.EndTime
+			}
+			if req.From != nil && completedAt.Before(*req.From) {
+				continue
+			}
+			if req.To != nil && completedAt.After(*req.To) {
+				continue
+			}
+
+			entry, err := s.newHistoryEntry(info, completedAt, history)
+			if err != nil {
+				return err
+			}
+
+			resp.Swaps = append(resp.Swaps, entry)
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func (s *SwapService) newHistoryEntry(
+	info *swap.Info,
+	completedAt time.Time,
+	history pricefeed.HistoricalPriceSource,
+) (*HistoryEntry, error) {
+	symbol := "ETH"
+	if info.EthAsset.IsToken() {
+		tokenInfo, err := s.backend.ETHClient().ERC20Info(s.ctx, info.EthAsset.Address())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token info for swap %s: %w", info.OfferID, err)
+		}
+		symbol = tokenInfo.Symbol
+	}
+
+	entry := &HistoryEntry{
+		ID:             info.OfferID,
+		Provided:       info.Provides,
+		EthAsset:       info.EthAsset,
+		EthAssetSymbol: symbol,
+		ProvidedAmount: info.ProvidedAmount,
+		ExpectedAmount: info.ExpectedAmount,
+		RelayerFee:     info.RelayerFee,
+		ExchangeRate:   info.ExchangeRate,
+		Status:         info.Status,
+		StartTime:      info.StartTime,
+		EndTime:        info.EndTime,
+		EthTxHashes:    info.EthTxHashes,
+		XMRTxIDs:       info.XMRTxIDs,
+		Rebuilt:        info.Rebuilt,
+		PeerAbort:      info.PeerAbort,
+	}
+
+	// Aborted swaps moved no funds, so they have no value to report
+	if info.Status == types.CompletedAbort {
+		return entry, nil
+	}
+
+	xmrAmount := info.ProvidedAmount
+	if info.IsTaker() {
+		xmrAmount = info.ExpectedAmount
+	}
+	if xmrAmount == nil {
+		return entry, nil
+	}
+
+	feed, err := history.PriceAt(s.ctx, "XMR", completedAt)
+	if err != nil {
+		log.Warnf("no XMR/USD price for swap %s at %s: %s", info.OfferID, completedAt, err)
+		return entry, nil
+	}
+
+	usdValue := new(apd.Decimal)
+	if _, err = coins.DecimalCtx().Mul(usdValue, xmrAmount, feed.Price); err != nil {
+		return nil, err
+	}
+
+	entry.XMRUSDPrice = feed.Price
+	entry.USDValue = usdValue
+	return entry, nil
+}
+
+// priceHistorySource returns the configured historical price source, falling
+// back to the chainlink oracles used by swap_suggestedExchangeRate.
+func (s *SwapService) priceHistorySource() pricefeed.HistoricalPriceSource {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	if s.history != nil {
+		return s.history
+	}
+
+	chainlink := pricefeed.NewChainlinkHistory(s.backend.ETHClient().Raw(), s.proxy)
+	if s.priceHistory == nil {
+		s.history = chainlink
+	} else {
+		s.history = pricefeed.FallbackHistory{chainlink, s.priceHistory}
+	}
+
+	return s.history
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+// pastSwapsManager is a swap.Manager that only serves past swaps.
+type pastSwapsManager struct {
+	swap.Manager
+	past    map[types.Hash]*swap.Info
+	queries int
+}
+
+func (m *pastSwapsManager) GetPastSwaps(q *swap.PastSwapsQuery) (*swap.PastSwapsPage, error) {
+	m.queries++
+	swaps := make([]*swap.Info, 0, len(m.past))
+	for _, info := range m.past {
+		swaps = append(swaps, info)
+	}
+	return swap.FilterPastSwaps(swaps, q)
+}
+
+func newTestPastSwap(
+	id types.Hash,
+	provides coins.ProvidesCoin,
+	status types.Status,
+	start time.Time,
+) *swap.Info {
+	info := swap.NewInfo(
+		"",
+		id,
+		provides,
+		apd.New(2, 0),
+		apd.New(3, 0),
+		coins.ToExchangeRate(apd.New(15, -1)),
+		types.EthAssetETH,
+		status,
+		0,
+	)
+	info.StartTime = start
+	end := start.Add(time.Hour)
+	info.EndTime = &end
+	return info
+}
+
+func TestSwapService_ExportHistory(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2023, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	makerSwap := newTestPastSwap(types.Hash{0x1}, coins.ProvidesXMR, types.CompletedSuccess, day(2))
+	takerSwap := newTestPastSwap(types.Hash{0x2}, coins.ProvidesETH, types.CompletedSuccess, day(4))
+	abortedSwap := newTestPastSwap(types.Hash{0x3}, coins.ProvidesXMR, types.CompletedAbort, day(3))
+	earlySwap := newTestPastSwap(types.Hash{0x4}, coins.ProvidesXMR, types.CompletedSuccess, day(1))
+
+	history, err := pricefeed.NewFileHistory([]*pricefeed.PricePoint{
+		{Symbol: "XMR", Time: day(1).Add(-time.Hour), Price: coins.StrToDecimal("150")},
+		{Symbol: "XMR", Time: day(4), Price: coins.StrToDecimal("160")},
+	})
+	require.NoError(t, err)
+
+	s := &SwapService{
+		ctx: context.Background(),
+		sm: &pastSwapsManager{
+			past: map[types.Hash]*swap.Info{
+				makerSwap.OfferID:   makerSwap,
+				takerSwap.OfferID:   takerSwap,
+				abortedSwap.OfferID: abortedSwap,
+				earlySwap.OfferID:   earlySwap,
+			},
+		},
+		history: history,
+	}
+
+	from := day(2)
+	resp := new(ExportHistoryResponse)
+	err = s.ExportHistory(nil, &ExportHistoryRequest{From: &from}, resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Swaps, 3)
+
+	// sorted by start time, the early swap is outside the range
+	require.Equal(t, makerSwap.OfferID, resp.Swaps[0].ID)
+	require.Equal(t, abortedSwap.OfferID, resp.Swaps[1].ID)
+	require.Equal(t, takerSwap.OfferID, resp.Swaps[2].ID)
+
+	// the maker provided 2 XMR at $150
+	require.Equal(t, "ETH", resp.Swaps[0].EthAssetSymbol)
+	require.Equal(t, "150", resp.Swaps[0].XMRUSDPrice.String())
+	require.Equal(t, "300", resp.Swaps[0].USDValue.Text('f'))
+
+	// aborted swaps have no valuation
+	require.Nil(t, resp.Swaps[1].XMRUSDPrice)
+	require.Nil(t, resp.Swaps[1].USDValue)
+
+	// the taker received 3 XMR at $160
+	require.Equal(t, "160", resp.Swaps[2].XMRUSDPrice.String())
+	require.Equal(t, "480", resp.Swaps[2].USDValue.Text('f'))
+
+	to := day(2).Add(-time.Nanosecond)
+	resp = new(ExportHistoryResponse)
+	err = s.ExportHistory(nil, &ExportHistoryRequest{To: &to}, resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Swaps, 1)
+	require.Equal(t, earlySwap.OfferID, resp.Swaps[0].ID)
+}
+
+func TestSwapService_ExportHistory_noPrice(t *testing.T) {
+	info := newTestPastSwap(types.Hash{0x1}, coins.ProvidesXMR, types.CompletedSuccess, time.Now())
+
+	history, err := pricefeed.NewFileHistory(nil)
+	require.NoError(t, err)
+
+	s := &SwapService{
+		ctx:     context.Background(),
+		sm:      &pastSwapsManager{past: map[types.Hash]*swap.Info{info.OfferID: info}},
+		history: history,
+	}
+
+	resp := new(ExportHistoryResponse)
+	require.NoError(t, s.ExportHistory(nil, new(ExportHistoryRequest), resp))
+	require.Len(t, resp.Swaps, 1)
+	require.Nil(t, resp.Swaps[0].XMRUSDPrice)
+	require.Nil(t, resp.Swaps[0].USDValue)
+}
+
+func TestSwapService_ExportHistory_pages(t *testing.T) {
+	history, err := pricefeed.NewFileHistory(nil)
+	require.NoError(t, err)
+
+	start := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	sm := &pastSwapsManager{past: map[types.Hash]*swap.Info{}}
+	for i := 0; i <= exportHistoryPageSize; i++ {
+		id := types.Hash{byte(i), byte(i >> 8)}
+		sm.past[id] = newTestPastSwap(id, coins.ProvidesXMR, types.CompletedAbort, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	s := &SwapService{
+		ctx:     context.Background(),
+		sm:      sm,
+		history: history,
+	}
+
+	resp := new(ExportHistoryResponse)
+	require.NoError(t, s.ExportHistory(nil, new(ExportHistoryRequest), resp))
+	require.Len(t, resp.Swaps, exportHistoryPageSize+1)
+	require.Equal(t, 2, sm.queries)
+	for i := 1; i < len(resp.Swaps); i++ {
+		require.True(t, resp.Swaps[i-1].StartTime.Before(resp.Swaps[i].StartTime))
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"net/http"
+
+	"github.com/athanorlabs/atomic-swap/common/rpctypes"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/orderbook"
+)
+
+// OfferIndex is the offer index of a bootnode. It is implemented by
+// *orderbook.Book.
+type OfferIndex interface {
+	Offers(chainID uint64, asset types.EthAsset) []*orderbook.Entry
+	Summaries() ([]*orderbook.AssetSummary, error)
+	Makers() []*orderbook.Maker
+	Len() int
+}
+
+// IndexService is the read-only RPC service prefixed by index_, which serves
+// the offer index of a bootnode.
+type IndexService struct {
+	index OfferIndex
+}
+
+// NewIndexService ...
+func NewIndexService(index OfferIndex) *IndexService {
+	return &IndexService{index: index}
+}
+
+// GetOffers returns the offers for an asset in the index, best exchange rate
+// first.
+func (s *IndexService) GetOffers(
+	_ *http.Request,
+	req *rpctypes.GetIndexOffersRequest,
+	resp *rpctypes.GetIndexOffersResponse,
+) error {
+	resp.Offers = []*rpctypes.OrderBookOffer{}
+	for _, e := range s.index.Offers(req.ChainID, req.EthAsset) {
+		resp.Offers = append(resp.Offers, &rpctypes.OrderBookOffer{
+			PeerID:    e.PeerID,
+			Offer:     e.Offer,
+			FirstSeen: e.FirstSeen,
+			LastSeen:  e.LastSeen,
+		})
+	}
+	return nil
+}
+
+// Stats returns the number of offers and makers in the index, and the offers
+// and advertised liquidity of each asset.
+func (s *IndexService) Stats(_ *http.Request, _ *interface{}, resp *rpctypes.IndexStatsResponse) error {
+	summaries, err := s.index.Summaries()
+	if err != nil {
+		return err
+	}
+
+	resp.Offers = s.index.Len()
+	for _, m := range s.index.Makers() {
+		resp.Makers++
+		if m.Online() {
+			resp.OnlineMakers++
+		}
+	}
+
+	resp.Assets = make([]*rpctypes.IndexAssetStats, 0, len(summaries))
+	for _, summary := range summaries {
+		resp.Assets = append(resp.Assets, &rpctypes.IndexAssetStats{
+			ChainID:       summary.ChainID,
+			EthAsset:      summary.EthAsset,
+			Offers:        summary.Offers,
+			Makers:        summary.Makers,
+			TotalXMR:      summary.TotalXMR,
+			TotalEthAsset: summary.TotalEthAsset,
+		})
+	}
+
+	return nil
+}
+
+// Makers returns the liveness history of the makers in the index.
+func (s *IndexService) Makers(_ *http.Request, _ *interface{}, resp *rpctypes.IndexMakersResponse) error {
+	makers := s.index.Makers()
+	resp.Makers = make([]*rpctypes.IndexMaker, 0, len(makers))
+	for _, m := range makers {
+		maker := &rpctypes.IndexMaker{
+			PeerID:    m.PeerID,
+			FirstSeen: m.FirstSeen,
+			LastSeen:  m.LastSeen,
+			Online:    m.Online(),
+			Checks:    make([]*rpctypes.IndexLivenessCheck, 0, len(m.Checks)),
+		}
+		for _, c := range m.Checks {
+			maker.Checks = append(maker.Checks, &rpctypes.IndexLivenessCheck{Time: c.Time, Online: c.Online})
+		}
+		resp.Makers = append(resp.Makers, maker)
+	}
+
+	return nil
+}
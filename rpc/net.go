@@ -17,7 +17,9 @@ import (
 	"github.com/athanorlabs/atomic-swap/common"
 	"github.com/athanorlabs/atomic-swap/common/rpctypes"
 	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/net"
 	"github.com/athanorlabs/atomic-swap/net/message"
+	"github.com/athanorlabs/atomic-swap/orderbook"
 	"github.com/athanorlabs/atomic-swap/protocol/swap"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
@@ -30,12 +32,20 @@ type Net interface {
 	PeerID() peer.ID
 	ConnectedPeers() []string
 	Addresses() []ma.Multiaddr
+	Reachability() net.Reachability
 	Discover(provides string, searchTime time.Duration) ([]peer.ID, error)
 	Query(who peer.ID) (*message.QueryResponse, error)
 	Initiate(who peer.AddrInfo, sendKeysMessage common.Message, s common.SwapStateNet) error
 	CloseProtocolStream(types.Hash)
 }
 
+// OrderBook is the book of the offers of remote peers. It is implemented by
+// *orderbook.Book.
+type OrderBook interface {
+	Update(peerID peer.ID, offers []*types.Offer)
+	Offers(chainID uint64, asset types.EthAsset) []*orderbook.Entry
+}
+
 // NetService is the RPC service prefixed by net_.
 type NetService struct {
 	ctx        context.Context
@@ -44,6 +54,8 @@ type NetService struct {
 	xmrmaker   XMRMaker
 	pb         ProtocolBackend
 	sm         swap.Manager
+	policy     *OfferPolicy
+	book       OrderBook
 	isBootnode bool
 }
 
@@ -55,6 +67,8 @@ func NewNetService(
 	xmrmaker XMRMaker,
 	pb ProtocolBackend,
 	sm swap.Manager,
+	policy *OfferPolicy,
+	book OrderBook,
 	isBootnode bool,
 ) *NetService {
 	return &NetService{
@@ -64,13 +78,16 @@ func NewNetService(
 		xmrmaker:   xmrmaker,
 		pb:         pb,
 		sm:         sm,
+		policy:     policy,
+		book:       book,
 		isBootnode: isBootnode,
 	}
 }
 
 // Addresses returns the local listening multi-addresses. Note that local listening
 // addresses do not correspond to what remote peers connect to unless your host has a
-// public IP directly attached to a local interface.
+// public IP directly attached to a local interface. The reachability says whether
+// peers could connect to any of the addresses.
 func (s *NetService) Addresses(_ *http.Request, _ *interface{}, resp *rpctypes.AddressesResponse) error {
 	// Multiaddr is an interface that you can serialize, but you need a concrete
 	// type to deserialize, so we just use strings in the AddressesResponse.
@@ -79,6 +96,7 @@ func (s *NetService) Addresses(_ *http.Request, _ *interface{}, resp *rpctypes.A
 	for _, a := range addresses {
 		resp.Addrs = append(resp.Addrs, a.String())
 	}
+	resp.Reachability = string(s.net.Reachability())
 	return nil
 }
 
@@ -110,6 +128,7 @@ func (s *NetService) Pairs(_ *http.Request, req *rpctypes.PairsRequest, resp *rp
 			log.Debugf("Failed to query peer ID %s", p)
 			continue
 		}
+		s.updateBook(p, msg.Offers)
 
 		if len(msg.Offers) == 0 {
 			continue
@@ -175,14 +194,109 @@ func (s *NetService) QueryAll(_ *http.Request, req *rpctypes.QueryAllRequest, re
 			log.Debugf("Failed to query peer ID %s", p)
 			continue
 		}
+		s.updateBook(p, msg.Offers)
 		if len(msg.Offers) > 0 {
 			resp.PeersWithOffers = append(resp.PeersWithOffers, &rpctypes.PeerWithOffers{
-				PeerID: p,
-				Offers: msg.Offers,
+				PeerID:       p,
+				Offers:       msg.Offers,
+				Capabilities: peerCapabilities(msg.Capabilities),
 			})
 		}
 	}
 
+	var offers []*types.Offer
+	for _, po := range resp.PeersWithOffers {
+		offers = append(offers, po.Offers...)
+	}
+	premiums := s.policy.premiums(s.ctx, offers)
+	for _, po := range resp.PeersWithOffers {
+		po.Premiums = filterPremiums(premiums, po.Offers)
+	}
+
+	return nil
+}
+
+// filterPremiums returns the premiums of the given offers, or nil if there
+// are none.
+func filterPremiums(premiums map[types.Hash]*apd.Decimal, offers []*types.Offer) map[types.Hash]*apd.Decimal {
+	var filtered map[types.Hash]*apd.Decimal
+	for _, o := range offers {
+		if p, ok := premiums[o.ID]; ok {
+			if filtered == nil {
+				filtered = make(map[types.Hash]*apd.Decimal)
+			}
+			filtered[o.ID] = p
+		}
+	}
+	return filtered
+}
+
+// updateBook records the offers of a query response in the order book.
+func (s *NetService) updateBook(peerID peer.ID, offers []*types.Offer) {
+	if s.book != nil {
+		s.book.Update(peerID, offers)
+	}
+}
+
+// GetOrderBook returns the offers for an asset in the order book, which is
+// refreshed in the background, without querying peers.
+func (s *NetService) GetOrderBook(
+	_ *http.Request,
+	req *rpctypes.GetOrderBookRequest,
+	resp *rpctypes.GetOrderBookResponse,
+) error {
+	if s.isBootnode {
+		return errUnsupportedForBootnode
+	}
+	if s.book == nil {
+		return errNoOrderBook
+	}
+
+	// offers on the primary chain don't have a chain ID
+	chainID := req.ChainID
+	if chainID != 0 && chainID == s.pb.ETHClient().ChainID().Uint64() {
+		chainID = 0
+	}
+
+	var token *coins.ERC20TokenInfo
+	if req.ProvidesAmount != nil && req.EthAsset.IsToken() {
+		b, err := s.pb.ForChain(chainID)
+		if err != nil {
+			return err
+		}
+		token, err = b.ETHClient().ERC20Info(s.ctx, req.EthAsset.Address())
+		if err != nil {
+			return err
+		}
+	}
+
+	var offers []*types.Offer
+	resp.Offers = []*rpctypes.OrderBookOffer{}
+	for _, e := range s.book.Offers(chainID, req.EthAsset) {
+		o := &rpctypes.OrderBookOffer{
+			PeerID:    e.PeerID,
+			Offer:     e.Offer,
+			FirstSeen: e.FirstSeen,
+			LastSeen:  e.LastSeen,
+		}
+
+		if req.ProvidesAmount != nil {
+			xmrAmount, err := e.Offer.ExchangeRate.ToXMR(coins.NewEthAssetAmount(req.ProvidesAmount, token))
+			if err != nil || xmrAmount.Cmp(e.Offer.MinAmount) < 0 || xmrAmount.Cmp(e.Offer.MaxAmount) > 0 {
+				continue
+			}
+			o.ExpectedAmount = xmrAmount
+		}
+
+		offers = append(offers, e.Offer)
+		resp.Offers = append(resp.Offers, o)
+	}
+
+	premiums := s.policy.premiums(s.ctx, offers)
+	for _, o := range resp.Offers {
+		o.Premium = premiums[o.Offer.ID]
+	}
+
 	return nil
 }
 
@@ -232,11 +346,30 @@ func (s *NetService) QueryPeer(
 	if err != nil {
 		return err
 	}
+	s.updateBook(req.PeerID, msg.Offers)
 
 	resp.Offers = msg.Offers
+	resp.Premiums = s.policy.premiums(s.ctx, msg.Offers)
+	resp.Capabilities = peerCapabilities(msg.Capabilities)
 	return nil
 }
 
+func peerCapabilities(caps *message.Capabilities) *rpctypes.PeerCapabilities {
+	if caps == nil {
+		return nil
+	}
+
+	features := make([]string, 0, len(caps.Features))
+	for _, f := range caps.Features {
+		features = append(features, string(f))
+	}
+
+	return &rpctypes.PeerCapabilities{
+		Versions: caps.Versions,
+		Features: features,
+	}
+}
+
 // TakeOffer initiates a swap with the given peer by taking an offer they've made.
 func (s *NetService) TakeOffer(
 	_ *http.Request,
@@ -247,7 +380,7 @@ func (s *NetService) TakeOffer(
 		return errUnsupportedForBootnode
 	}
 
-	err := s.takeOffer(req.PeerID, req.OfferID, req.ProvidesAmount)
+	err := s.takeOffer(req.PeerID, req.OfferID, req.ProvidesAmount, req.AllowOffMarket)
 	if err != nil {
 		return err
 	}
@@ -255,7 +388,12 @@ func (s *NetService) TakeOffer(
 	return nil
 }
 
-func (s *NetService) takeOffer(makerPeerID peer.ID, offerID types.Hash, providesAmount *apd.Decimal) error {
+func (s *NetService) takeOffer(
+	makerPeerID peer.ID,
+	offerID types.Hash,
+	providesAmount *apd.Decimal,
+	allowOffMarket bool,
+) error {
 	queryResp, err := s.net.Query(makerPeerID)
 	if err != nil {
 		return err
@@ -272,6 +410,12 @@ func (s *NetService) takeOffer(makerPeerID peer.ID, offerID types.Hash, provides
 		return errNoOfferWithID
 	}
 
+	if !allowOffMarket {
+		if err = s.policy.check(s.ctx, offer); err != nil {
+			return fmt.Errorf("%w (set allowOffMarket to take the offer anyway)", err)
+		}
+	}
+
 	swapState, err := s.xmrtaker.InitiateProtocol(makerPeerID, providesAmount, offer)
 	if err != nil {
 		return err
@@ -310,7 +454,15 @@ func (s *NetService) MakeOffer(
 }
 
 func (s *NetService) makeOffer(req *rpctypes.MakeOfferRequest) (*rpctypes.MakeOfferResponse, error) {
-	offer := types.NewOffer(
+	// offers on the primary chain don't have a chain ID, so that they can be
+	// taken by peers that don't support other chains
+	chainID := req.ChainID
+	if chainID != 0 && chainID == s.pb.ETHClient().ChainID().Uint64() {
+		chainID = 0
+	}
+
+	offer := types.NewOfferOnChain(
+		chainID,
 		coins.ProvidesXMR,
 		req.MinAmount,
 		req.MaxAmount,
@@ -0,0 +1,189 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
+)
+
+var errOffMarket = errors.New("offer exchange rate is too far from the market rate")
+
+// OfferPolicy is the taker side check of the exchange rate of an offer
+// against the market rate derived from the price feeds.
+type OfferPolicy struct {
+	// MaxPremium is the maximum relative difference, as a fraction, between
+	// the exchange rate of an offer and the market rate. Offers beyond it can
+	// only be taken with an explicit override. Nil disables the check, but
+	// premiums are still reported.
+	MaxPremium *apd.Decimal
+	// MarketRate returns the current market exchange rate of XMR in the asset
+	// on the chain, where a chain ID of zero is the primary chain. It returns
+	// an error wrapping pricefeed.ErrNoTokenFeed for assets without a price
+	// feed.
+	MarketRate func(ctx context.Context, chainID uint64, asset types.EthAsset) (*coins.ExchangeRate, error)
+
+	// mu guards MaxPremium once the policy is in use, see SetMaxPremium
+	mu sync.RWMutex
+}
+
+// SetMaxPremium replaces the maximum premium of the policy in use. Nil
+// disables the check.
+func (p *OfferPolicy) SetMaxPremium(maxPremium *apd.Decimal) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.MaxPremium = maxPremium
+}
+
+func (p *OfferPolicy) maxPremium() *apd.Decimal {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.MaxPremium
+}
+
+// NewPriceFeedOfferPolicy returns an OfferPolicy whose market rate is the
+// suggested exchange rate of the configured price sources. On chains other
+// than the primary chain, only native ETH is priced.
+func NewPriceFeedOfferPolicy(
+	pb ProtocolBackend,
+	sources *pricefeed.SourcesConfig,
+	proxy *netproxy.Proxy,
+	maxPremium *apd.Decimal,
+) *OfferPolicy {
+	return &OfferPolicy{
+		MaxPremium: maxPremium,
+		MarketRate: func(ctx context.Context, chainID uint64, asset types.EthAsset) (*coins.ExchangeRate, error) {
+			if chainID != 0 {
+				chain, err := common.ChainByID(pb.Env(), chainID)
+				if err != nil {
+					return nil, err
+				}
+				if asset.IsToken() || chain.NativeSymbol != pricefeed.SymbolETH {
+					return nil, fmt.Errorf("%w %s on %s", pricefeed.ErrNoTokenFeed, asset, chain)
+				}
+			}
+
+			rate, err := suggestedExchangeRate(ctx, pb, sources, proxy, asset)
+			if err != nil {
+				return nil, err
+			}
+			return rate.ExchangeRate, nil
+		},
+	}
+}
+
+// check returns an error if the offer's exchange rate can't be compared with
+// the market rate, or is further from it than the maximum premium. The check
+// is skipped for tokens without a price feed.
+func (p *OfferPolicy) check(ctx context.Context, offer *types.Offer) error {
+	if p == nil {
+		return nil
+	}
+
+	maxPremium := p.maxPremium()
+	if maxPremium == nil {
+		return nil
+	}
+
+	marketRate, err := p.MarketRate(ctx, offer.ChainID, offer.EthAsset)
+	if errors.Is(err, pricefeed.ErrNoTokenFeed) {
+		log.Infof("Not checking the exchange rate of offer %s: %s", offer.ID, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to verify the exchange rate against the market rate: %w", err)
+	}
+
+	premium, err := offerPremium(offer.ExchangeRate, marketRate)
+	if err != nil {
+		return err
+	}
+
+	limit := new(apd.Decimal)
+	if _, err = coins.DecimalCtx().Mul(limit, maxPremium, apd.New(100, 0)); err != nil {
+		return err
+	}
+
+	if new(apd.Decimal).Abs(premium).Cmp(limit) > 0 {
+		return fmt.Errorf("%w: rate %s has a %s%% premium over the market rate %s, the limit is %s%%",
+			errOffMarket, offer.ExchangeRate, premium.Text('f'), marketRate, limit.Text('f'))
+	}
+
+	return nil
+}
+
+// premiums returns the premium of each offer over the market rate of its
+// asset, keyed by offer ID. Offers whose market rate is unavailable are left
+// out.
+func (p *OfferPolicy) premiums(ctx context.Context, offers []*types.Offer) map[types.Hash]*apd.Decimal {
+	if p == nil || len(offers) == 0 {
+		return nil
+	}
+
+	type chainAsset struct {
+		chainID uint64
+		asset   types.EthAsset
+	}
+
+	// the market rate of each asset, nil if it is unavailable
+	marketRates := make(map[chainAsset]*coins.ExchangeRate)
+	premiums := make(map[types.Hash]*apd.Decimal)
+	for _, o := range offers {
+		key := chainAsset{o.ChainID, o.EthAsset}
+		marketRate, ok := marketRates[key]
+		if !ok {
+			var err error
+			marketRate, err = p.MarketRate(ctx, o.ChainID, o.EthAsset)
+			if err != nil {
+				log.Debugf("Unable to get the market rate of %s for offer premiums: %s", o.EthAsset, err)
+			}
+			marketRates[key] = marketRate
+		}
+		if marketRate == nil {
+			continue
+		}
+
+		premium, err := offerPremium(o.ExchangeRate, marketRate)
+		if err != nil {
+			log.Warnf("Unable to calculate the premium of offer %s: %s", o.ID, err)
+			continue
+		}
+		premiums[o.ID] = premium
+	}
+
+	if len(premiums) == 0 {
+		return nil
+	}
+	return premiums
+}
+
+// offerPremium returns the percentage, rounded to 2 decimal places, by which
+// the offer's rate is above (positive) or below (negative) the market rate.
+func offerPremium(offerRate *coins.ExchangeRate, marketRate *coins.ExchangeRate) (*apd.Decimal, error) {
+	premium := new(apd.Decimal)
+	if _, err := coins.DecimalCtx().Sub(premium, offerRate.Decimal(), marketRate.Decimal()); err != nil {
+		return nil, err
+	}
+	if _, err := coins.DecimalCtx().Quo(premium, premium, marketRate.Decimal()); err != nil {
+		return nil, err
+	}
+	if _, err := coins.DecimalCtx().Mul(premium, premium, apd.New(100, 0)); err != nil {
+		return nil, err
+	}
+	if _, err := coins.DecimalCtx().Quantize(premium, premium, -2); err != nil {
+		return nil, err
+	}
+
+	return premium, nil
+}
@@ -73,7 +73,16 @@ func (s *PersonalService) TokenInfo(
 	req *rpctypes.TokenInfoRequest,
 	resp *rpctypes.TokenInfoResponse,
 ) error {
-	tokenInfo, err := s.pb.ETHClient().ERC20Info(s.ctx, req.TokenAddr)
+	ec := s.pb.ETHClient()
+	if req.ChainID != 0 {
+		b, err := s.pb.ForChain(req.ChainID)
+		if err != nil {
+			return err
+		}
+		ec = b.ETHClient()
+	}
+
+	tokenInfo, err := ec.ERC20Info(s.ctx, req.TokenAddr)
 	if err != nil {
 		return err
 	}
@@ -10,6 +10,7 @@ import (
 	"golang.org/x/exp/slices"
 
 	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/metrics"
 	"github.com/athanorlabs/atomic-swap/protocol/swap"
 )
 
@@ -76,6 +77,9 @@ func SetupMetrics(
 	factory := promauto.With(reg)
 	swapManager := pb.SwapManager()
 
+	// Event-driven metrics recorded by the protocol layer
+	reg.MustRegister(metrics.Collectors()...)
+
 	return &Metrics{
 		peersCount: factory.NewGaugeFunc(
 			prometheus.GaugeOpts{
@@ -27,9 +27,12 @@ import (
 
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	"github.com/athanorlabs/atomic-swap/common/types"
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
+	"github.com/athanorlabs/atomic-swap/protocol/backend"
 	"github.com/athanorlabs/atomic-swap/protocol/swap"
 	"github.com/athanorlabs/atomic-swap/protocol/txsender"
 )
@@ -37,6 +40,7 @@ import (
 const (
 	DaemonNamespace   = "daemon"   //nolint:revive
 	DatabaseNamespace = "database" //nolint:revive
+	IndexNamespace    = "index"    //nolint:revive
 	NetNamespace      = "net"      //nolint:revive
 	PersonalName      = "personal" //nolint:revive
 	SwapNamespace     = "swap"     //nolint:revive
@@ -46,9 +50,11 @@ var log = logging.Logger("rpc")
 
 // Server represents the JSON-RPC server
 type Server struct {
-	ctx        context.Context
-	listener   net.Listener
-	httpServer *http.Server
+	ctx         context.Context
+	cancel      context.CancelFunc
+	listener    net.Listener
+	httpServer  *http.Server
+	offerPolicy *OfferPolicy // nil on bootnodes
 }
 
 // Config ...
@@ -57,14 +63,23 @@ type Config struct {
 	Env             common.Environment
 	Address         string // "IP:port"
 	Net             Net
-	XMRTaker        XMRTaker        // nil on bootnodes
-	XMRMaker        XMRMaker        // nil on bootnodes
-	ProtocolBackend ProtocolBackend // nil on bootnodes
-	RecoveryDB      RecoveryDB      // nil on bootnodes
+	XMRTaker        XMRTaker                        // nil on bootnodes
+	XMRMaker        XMRMaker                        // nil on bootnodes
+	ProtocolBackend ProtocolBackend                 // nil on bootnodes
+	RecoveryDB      RecoveryDB                      // nil on bootnodes
+	PriceHistory    pricefeed.HistoricalPriceSource // optional
+	PriceSources    *pricefeed.SourcesConfig        // optional
+	Proxy           *netproxy.Proxy                 // optional, price sources are queried directly if nil
+	MaxOfferPremium *apd.Decimal                    // optional, see OfferPolicy.MaxPremium
+	Credentials     *Credentials                    // optional, requests are unauthenticated if nil
+	Reload          ReloadFunc                      // optional, daemon_reload fails if nil
+	OrderBook       OrderBook                       // optional, net_getOrderBook fails if nil
+	OfferIndex      OfferIndex                      // required by the index namespace
 	Namespaces      map[string]struct{}
 }
 
-// AllNamespaces returns a map with all RPC namespaces set for usage in the config.
+// AllNamespaces returns a map with all RPC namespaces of swapd set for usage in
+// the config. The index namespace is only served by bootnodes.
 func AllNamespaces() map[string]struct{} {
 	return map[string]struct{}{
 		DaemonNamespace:   {},
@@ -88,11 +103,6 @@ func NewServer(cfg *Config) (*Server, error) {
 		addr := cfg.ProtocolBackend.SwapCreatorAddr()
 		swapCreatorAddr = &addr
 	}
-	daemonService := NewDaemonService(serverCancel, cfg.Env, swapCreatorAddr)
-	err := rpcServer.RegisterService(daemonService, "daemon")
-	if err != nil {
-		return nil, err
-	}
 
 	var swapManager swap.Manager
 	if !isBootnode {
@@ -100,13 +110,19 @@ func NewServer(cfg *Config) (*Server, error) {
 	}
 
 	var netService *NetService
+	var policy *OfferPolicy
+	var err error
 	for ns := range cfg.Namespaces {
 		switch ns {
 		case DaemonNamespace:
-			continue
+			daemonService := NewDaemonService(serverCancel, cfg.Env, swapCreatorAddr, cfg.Reload)
+			err = rpcServer.RegisterService(daemonService, DaemonNamespace)
 		case DatabaseNamespace:
 			err = rpcServer.RegisterService(NewDatabaseService(cfg.RecoveryDB), DatabaseNamespace)
 		case NetNamespace:
+			if !isBootnode {
+				policy = NewPriceFeedOfferPolicy(cfg.ProtocolBackend, cfg.PriceSources, cfg.Proxy, cfg.MaxOfferPremium)
+			}
 			netService = NewNetService(
 				serverCtx,
 				cfg.Net,
@@ -114,6 +130,8 @@ func NewServer(cfg *Config) (*Server, error) {
 				cfg.XMRMaker,
 				cfg.ProtocolBackend,
 				swapManager,
+				policy,
+				cfg.OrderBook,
 				isBootnode,
 			)
 			err = rpcServer.RegisterService(netService, NetNamespace)
@@ -129,20 +147,30 @@ func NewServer(cfg *Config) (*Server, error) {
 					cfg.Net,
 					cfg.ProtocolBackend,
 					cfg.RecoveryDB,
+					cfg.PriceHistory,
+					cfg.PriceSources,
+					cfg.Proxy,
 				),
 				SwapNamespace,
 			)
+		case IndexNamespace:
+			if cfg.OfferIndex == nil {
+				err = errNoOfferIndex
+				break
+			}
+			err = rpcServer.RegisterService(NewIndexService(cfg.OfferIndex), IndexNamespace)
 		default:
 			err = fmt.Errorf("unknown namespace %s", ns)
 		}
+		if err != nil {
+			break
+		}
 	}
 	if err != nil {
 		serverCancel()
 		return nil, err
 	}
 
-	wsServer := newWsServer(serverCtx, swapManager, netService, cfg.ProtocolBackend, cfg.XMRTaker)
-
 	lc := net.ListenConfig{}
 	ln, err := lc.Listen(serverCtx, "tcp", cfg.Address)
 	if err != nil {
@@ -152,36 +180,55 @@ func NewServer(cfg *Config) (*Server, error) {
 
 	r := mux.NewRouter()
 	r.Handle("/", rpcServer)
-	r.Handle("/ws", wsServer)
+	// the websocket methods belong to the net and swap namespaces
+	if netService != nil || swapManager != nil {
+		r.Handle("/ws", newWsServer(serverCtx, swapManager, netService, cfg.ProtocolBackend, cfg.XMRTaker))
+	}
 
 	if !isBootnode {
 		reg, err := NewPrometheusRegistry()
 		if err != nil {
+			serverCancel()
 			return nil, err
 		}
 		SetupMetrics(serverCtx, reg, cfg.Net, cfg.ProtocolBackend, cfg.XMRMaker)
 		r.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
 	}
 
-	headersOk := handlers.AllowedHeaders([]string{"content-type", "username", "password"})
+	var handler http.Handler = r
+	if cfg.Credentials != nil {
+		handler = basicAuth(cfg.Credentials, r)
+	}
+
+	headersOk := handlers.AllowedHeaders([]string{"content-type", "authorization", "username", "password"})
 	methodsOk := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "OPTIONS"})
 	originsOk := handlers.AllowedOrigins([]string{"*"})
 	server := &http.Server{
 		Addr:              ln.Addr().String(),
 		ReadHeaderTimeout: time.Second,
-		Handler:           handlers.CORS(headersOk, methodsOk, originsOk)(r),
+		Handler:           handlers.CORS(headersOk, methodsOk, originsOk)(handler),
 		BaseContext: func(listener net.Listener) context.Context {
 			return serverCtx
 		},
 	}
 
 	return &Server{
-		ctx:        serverCtx,
-		listener:   ln,
-		httpServer: server,
+		ctx:         serverCtx,
+		cancel:      serverCancel,
+		listener:    ln,
+		httpServer:  server,
+		offerPolicy: policy,
 	}, nil
 }
 
+// SetMaxOfferPremium replaces the maximum premium of the offers that can be
+// taken, see OfferPolicy.MaxPremium.
+func (s *Server) SetMaxOfferPremium(maxPremium *apd.Decimal) {
+	if s.offerPolicy != nil {
+		s.offerPolicy.SetMaxPremium(maxPremium)
+	}
+}
+
 // Port returns the localhost port used for HTTP and websocket requests
 func (s *Server) Port() uint16 {
 	return uint16(s.listener.Addr().(*net.TCPAddr).Port)
@@ -193,7 +240,9 @@ func (s *Server) Start() error {
 		return s.ctx.Err()
 	}
 
-	log.Infof("Starting RPC/websockets server on 127.0.0.1:%d", s.Port())
+	log.Infof("Starting RPC/websockets server on %s", s.listener.Addr())
+	// stops the services of the server once it is no longer serving
+	defer s.cancel()
 
 	serverErr := make(chan error, 1)
 	go func() {
@@ -250,6 +299,7 @@ type ProtocolBackend interface {
 	SweepXMR(to *mcrypto.Address) ([]string, error)
 	TransferETH(to ethcommon.Address, amount *coins.WeiAmount, gasLimit *uint64) (*ethtypes.Receipt, error)
 	SweepETH(to ethcommon.Address) (*ethtypes.Receipt, error)
+	ForChain(chainID uint64) (backend.Backend, error)
 }
 
 // XMRTaker ...
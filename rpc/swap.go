@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/apd/v3"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
 	"github.com/athanorlabs/atomic-swap/common/types"
 	contracts "github.com/athanorlabs/atomic-swap/ethereum"
 	"github.com/athanorlabs/atomic-swap/pricefeed"
@@ -30,6 +32,23 @@ type SwapService struct {
 	net      Net
 	backend  ProtocolBackend
 	rdb      RecoveryDB
+
+	// priceHistory is an optional source of historical prices used to backfill
+	// valuations that the chainlink oracles can no longer provide.
+	priceHistory pricefeed.HistoricalPriceSource
+
+	// history is the combined historical price source, created on first use
+	// and kept so that its client and cached chainlink rounds are reused.
+	historyMu sync.Mutex
+	history   pricefeed.HistoricalPriceSource
+
+	// priceSources optionally adds price sources to the defaults used for
+	// the suggested exchange rate.
+	priceSources *pricefeed.SourcesConfig
+
+	// proxy is the proxy of the price source connections, nil if they are
+	// direct
+	proxy *netproxy.Proxy
 }
 
 // NewSwapService ...
@@ -41,15 +60,21 @@ func NewSwapService(
 	net Net,
 	b ProtocolBackend,
 	rdb RecoveryDB,
+	priceHistory pricefeed.HistoricalPriceSource,
+	priceSources *pricefeed.SourcesConfig,
+	proxy *netproxy.Proxy,
 ) *SwapService {
 	return &SwapService{
-		ctx:      ctx,
-		sm:       sm,
-		xmrtaker: xmrtaker,
-		xmrmaker: xmrmaker,
-		net:      net,
-		backend:  b,
-		rdb:      rdb,
+		ctx:          ctx,
+		sm:           sm,
+		xmrtaker:     xmrtaker,
+		xmrmaker:     xmrmaker,
+		net:          net,
+		backend:      b,
+		rdb:          rdb,
+		priceHistory: priceHistory,
+		priceSources: priceSources,
+		proxy:        proxy,
 	}
 }
 
@@ -58,60 +83,70 @@ type PastSwap struct {
 	ID             types.Hash          `json:"id" validate:"required"`
 	Provided       coins.ProvidesCoin  `json:"provided" validate:"required"`
 	EthAsset       types.EthAsset      `json:"ethAsset"`
-	ProvidedAmount *apd.Decimal        `json:"providedAmount" validate:"required"`
-	ExpectedAmount *apd.Decimal        `json:"expectedAmount" validate:"required"`
+	ChainID        uint64              `json:"chainID,omitempty"`
+	ProvidedAmount *apd.Decimal        `json:"providedAmount" validate:"required_unless=Rebuilt true"`
+	ExpectedAmount *apd.Decimal        `json:"expectedAmount" validate:"required_unless=Rebuilt true"`
 	RelayerFee     *apd.Decimal        `json:"relayerFee"`
-	ExchangeRate   *coins.ExchangeRate `json:"exchangeRate" validate:"required"`
+	ExchangeRate   *coins.ExchangeRate `json:"exchangeRate" validate:"required_unless=Rebuilt true"`
 	Status         types.Status        `json:"status" validate:"required"`
 	StartTime      time.Time           `json:"startTime" validate:"required"`
 	EndTime        *time.Time          `json:"endTime"`
+	// Rebuilt is true if the swap was recreated from chain events, in which
+	// case the XMR amount and exchange rate are unknown.
+	Rebuilt bool `json:"rebuilt,omitempty"`
+	// PeerAbort is set if the counterparty aborted the swap, with the reason
+	// and status that they gave.
+	PeerAbort *swap.PeerAbort `json:"peerAbort,omitempty"`
 }
 
 // GetPastRequest ...
 type GetPastRequest struct {
-	OfferID *types.Hash `json:"offerID,omitempty"`
+	OfferID  *types.Hash     `json:"offerID,omitempty"`
+	Statuses []types.Status  `json:"statuses,omitempty"`
+	PeerID   peer.ID         `json:"peerID,omitempty"`
+	EthAsset *types.EthAsset `json:"ethAsset,omitempty"`
+	From     *time.Time      `json:"from,omitempty"`
+	To       *time.Time      `json:"to,omitempty"`
+	Order    swap.SortOrder  `json:"order,omitempty"`
+	Limit    int             `json:"limit,omitempty"`
+	Cursor   string          `json:"cursor,omitempty"`
 }
 
 // GetPastResponse ...
 type GetPastResponse struct {
-	Swaps []*PastSwap `json:"swaps" validate:"dive,required"`
+	Swaps      []*PastSwap `json:"swaps" validate:"dive,required"`
+	NextCursor string      `json:"nextCursor,omitempty"`
 }
 
-// GetPast returns information about a past swap given its ID.
-// If no ID is provided, all past swaps are returned.
-// It sorts them in order from newest to oldest.
+// GetPast returns information about past swaps. If an offer ID is provided,
+// only that swap is returned. Swaps can be filtered by status, peer ID, ETH
+// asset and start time. They are sorted by start time, newest first unless
+// the order is "asc". If a limit is set and there may be more swaps, the
+// response's next cursor can be passed in the next request to get the next
+// page.
 func (s *SwapService) GetPast(_ *http.Request, req *GetPastRequest, resp *GetPastResponse) error {
-	var swaps []*swap.Info
-
-	if req.OfferID == nil {
-		ids, err := s.sm.GetPastIDs()
-		if err != nil {
-			return err
-		}
-
-		for _, id := range ids {
-			info, err := s.sm.GetPastSwap(id)
-			if err != nil {
-				return fmt.Errorf("failed to get past swap %s: %w", id, err)
-			}
-
-			swaps = append(swaps, info)
-		}
-	} else {
-		info, err := s.sm.GetPastSwap(*req.OfferID)
-		if err != nil {
-			return err
-		}
-
-		swaps = append(swaps, info)
+	page, err := s.sm.GetPastSwaps(&swap.PastSwapsQuery{
+		OfferID:  req.OfferID,
+		Statuses: req.Statuses,
+		PeerID:   req.PeerID,
+		EthAsset: req.EthAsset,
+		From:     req.From,
+		To:       req.To,
+		Order:    req.Order,
+		Limit:    req.Limit,
+		Cursor:   req.Cursor,
+	})
+	if err != nil {
+		return err
 	}
 
-	resp.Swaps = make([]*PastSwap, len(swaps))
-	for i, info := range swaps {
+	resp.Swaps = make([]*PastSwap, len(page.Swaps))
+	for i, info := range page.Swaps {
 		resp.Swaps[i] = &PastSwap{
 			ID:             info.OfferID,
 			Provided:       info.Provides,
 			EthAsset:       info.EthAsset,
+			ChainID:        info.ChainID,
 			ProvidedAmount: info.ProvidedAmount,
 			ExpectedAmount: info.ExpectedAmount,
 			RelayerFee:     info.RelayerFee,
@@ -119,12 +154,11 @@ func (s *SwapService) GetPast(_ *http.Request, req *GetPastRequest, resp *GetPas
 			Status:         info.Status,
 			StartTime:      info.StartTime,
 			EndTime:        info.EndTime,
+			Rebuilt:        info.Rebuilt,
+			PeerAbort:      info.PeerAbort,
 		}
 	}
-
-	sort.Slice(resp.Swaps, func(i, j int) bool {
-		return resp.Swaps[j].StartTime.Before(resp.Swaps[i].StartTime)
-	})
+	resp.NextCursor = page.NextCursor
 
 	return nil
 }
@@ -134,6 +168,7 @@ type OngoingSwap struct {
 	ID                        types.Hash          `json:"id" validate:"required"`
 	Provided                  coins.ProvidesCoin  `json:"provided" validate:"required"`
 	EthAsset                  types.EthAsset      `json:"ethAsset"`
+	ChainID                   uint64              `json:"chainID,omitempty"`
 	ProvidedAmount            *apd.Decimal        `json:"providedAmount" validate:"required"`
 	ExpectedAmount            *apd.Decimal        `json:"expectedAmount" validate:"required"`
 	ExchangeRate              *coins.ExchangeRate `json:"exchangeRate" validate:"required"`
@@ -186,6 +221,7 @@ func (s *SwapService) GetOngoing(_ *http.Request, req *GetOngoingRequest, resp *
 		swap.ID = info.OfferID
 		swap.Provided = info.Provides
 		swap.EthAsset = info.EthAsset
+		swap.ChainID = info.ChainID
 		swap.ProvidedAmount = info.ProvidedAmount
 		swap.ExpectedAmount = info.ExpectedAmount
 		swap.ExchangeRate = info.ExchangeRate
@@ -234,6 +270,28 @@ func (s *SwapService) GetStatus(_ *http.Request, req *GetStatusRequest, resp *Ge
 	return nil
 }
 
+// GetJournalRequest ...
+type GetJournalRequest struct {
+	ID types.Hash `json:"id" validate:"required"`
+}
+
+// GetJournalResponse ...
+type GetJournalResponse struct {
+	Entries []*swap.JournalEntry `json:"entries" validate:"dive,required"`
+}
+
+// GetJournal returns the journal of an ongoing or past swap, oldest entry
+// first.
+func (s *SwapService) GetJournal(_ *http.Request, req *GetJournalRequest, resp *GetJournalResponse) error {
+	entries, err := s.sm.GetJournal(req.ID)
+	if err != nil {
+		return err
+	}
+
+	resp.Entries = entries
+	return nil
+}
+
 // GetOffersResponse ...
 type GetOffersResponse struct {
 	PeerID peer.ID        `json:"peerID" validate:"required"`
@@ -397,41 +455,47 @@ func (s *SwapService) Refund(_ *http.Request, req *ManualTransactionRequest, res
 	return nil
 }
 
+// SuggestedExchangeRateRequest ...
+type SuggestedExchangeRateRequest struct {
+	// EthAsset is the asset to suggest an exchange rate for. It defaults to
+	// ETH.
+	EthAsset types.EthAsset `json:"ethAsset,omitempty"`
+}
+
 // SuggestedExchangeRateResponse ...
 type SuggestedExchangeRateResponse struct {
-	ETHUpdatedAt time.Time           `json:"ethUpdatedAt" validate:"required"`
-	ETHPrice     *apd.Decimal        `json:"ethPrice" validate:"required"`
-	XMRUpdatedAt time.Time           `json:"xmrUpdatedAt" validate:"required"`
-	XMRPrice     *apd.Decimal        `json:"xmrPrice" validate:"required"`
+	ETHUpdatedAt time.Time    `json:"ethUpdatedAt" validate:"required"`
+	ETHPrice     *apd.Decimal `json:"ethPrice" validate:"required"`
+	XMRUpdatedAt time.Time    `json:"xmrUpdatedAt" validate:"required"`
+	XMRPrice     *apd.Decimal `json:"xmrPrice" validate:"required"`
+	// ExchangeRate is the price of 1 XMR in the requested asset
 	ExchangeRate *coins.ExchangeRate `json:"exchangeRate" validate:"required"`
+	// ETHSources and XMRSources are the results of each price source that
+	// the median ETH and XMR prices were aggregated from.
+	ETHSources []*pricefeed.SourcePrice `json:"ethSources" validate:"dive,required"`
+	XMRSources []*pricefeed.SourcePrice `json:"xmrSources" validate:"dive,required"`
+	// The token fields are only set if the requested asset is an ERC20 token.
+	// TokenSymbol is the symbol of the USD price feed of the token.
+	Token          *coins.ERC20TokenInfo    `json:"token,omitempty"`
+	TokenSymbol    string                   `json:"tokenSymbol,omitempty"`
+	TokenUpdatedAt *time.Time               `json:"tokenUpdatedAt,omitempty"`
+	TokenPrice     *apd.Decimal             `json:"tokenPrice,omitempty"`
+	TokenSources   []*pricefeed.SourcePrice `json:"tokenSources,omitempty" validate:"dive,required"`
 }
 
-// SuggestedExchangeRate returns the current mainnet exchange rate, expressed as the XMR/ETH price.
-func (s *SwapService) SuggestedExchangeRate(_ *http.Request, _ *interface{}, resp *SuggestedExchangeRateResponse) error { //nolint:lll
-	ec := s.backend.ETHClient().Raw()
-
-	xmrFeed, err := pricefeed.GetXMRUSDPrice(s.ctx, ec)
-	if err != nil {
-		return err
-	}
-
-	ethFeed, err := pricefeed.GetETHUSDPrice(s.ctx, ec)
+// SuggestedExchangeRate returns the current exchange rate of XMR in ETH, or
+// in an ERC20 token, based on the USD prices of the configured price sources.
+func (s *SwapService) SuggestedExchangeRate(
+	_ *http.Request,
+	req *SuggestedExchangeRateRequest,
+	resp *SuggestedExchangeRateResponse,
+) error {
+	rate, err := suggestedExchangeRate(s.ctx, s.backend, s.priceSources, s.proxy, req.EthAsset)
 	if err != nil {
 		return err
 	}
 
-	exchangeRate, err := coins.CalcExchangeRate(xmrFeed.Price, ethFeed.Price)
-	if err != nil {
-		return err
-	}
-
-	resp.XMRUpdatedAt = xmrFeed.UpdatedAt
-	resp.XMRPrice = xmrFeed.Price
-
-	resp.ETHUpdatedAt = ethFeed.UpdatedAt
-	resp.ETHPrice = ethFeed.Price
-
-	resp.ExchangeRate = exchangeRate
+	*resp = *rate
 	return nil
 }
 
@@ -108,7 +108,7 @@ func (s *wsServer) handleRequest(conn *websocket.Conn, req *rpctypes.Request) er
 			return fmt.Errorf("failed to unmarshal parameters: %w", err)
 		}
 
-		err := s.ns.takeOffer(params.PeerID, params.OfferID, params.ProvidesAmount)
+		err := s.ns.takeOffer(params.PeerID, params.OfferID, params.ProvidesAmount, params.AllowOffMarket)
 		if err != nil {
 			return err
 		}
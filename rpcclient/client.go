@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/rpc/v2/json2"
@@ -32,16 +33,19 @@ var (
 		Transport: transport,
 		Timeout:   httpClientTimeout,
 	}
+
+	errUnauthorized = errors.New("swapd rejected the RPC credentials")
 )
 
 // Client primarily exists to be a JSON-RPC client to swapd instances, but it can be used
 // to POST JSON-RPC requests to any JSON-RPC server. Its current use case assumes swapd is
-// running on the local host of a single use system. TLS and authentication are not
-// currently supported.
+// running on the local host of a single use system. TLS is not currently supported.
 type Client struct {
 	ctx        context.Context
 	endpoint   string
 	wsEndpoint string
+	username   string
+	password   string
 }
 
 // NewClient creates a new JSON-RPC client for the specified endpoint. The passed context
@@ -54,6 +58,37 @@ func NewClient(ctx context.Context, port uint16) *Client {
 	}
 }
 
+// NewClientWithEndpoint creates a new JSON-RPC client for a server that is not on the
+// local host, like the offer index of a bootnode. The endpoint is an http:// or https://
+// URL without a path.
+func NewClientWithEndpoint(ctx context.Context, endpoint string) *Client {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	return &Client{
+		ctx:        ctx,
+		endpoint:   endpoint,
+		wsEndpoint: "ws" + strings.TrimPrefix(endpoint, "http") + "/ws",
+	}
+}
+
+// SetCredentials sets the username and password that the client authenticates
+// its HTTP and websocket requests with, for servers that require them.
+func (c *Client) SetCredentials(username string, password string) {
+	c.username = username
+	c.password = password
+}
+
+// authHeader returns the header of websocket handshakes, which carries the
+// client's credentials if it has any.
+func (c *Client) authHeader() http.Header {
+	if c.username == "" && c.password == "" {
+		return nil
+	}
+
+	req := &http.Request{Header: make(http.Header)}
+	req.SetBasicAuth(c.username, c.password)
+	return req.Header
+}
+
 // post makes a JSON-RPC call to the client's endpoint, serializing any passed request
 // object and deserializing any passed response object from the POST response body. Nil
 // can be passed as the request or response when no data needs to be serialized or
@@ -69,6 +104,9 @@ func (c *Client) post(method string, request any, response any) error {
 		return fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", contentTypeJSON)
+	if c.username != "" || c.password != "" {
+		httpReq.SetBasicAuth(c.username, c.password)
+	}
 
 	ctx, cancel := context.WithTimeout(c.ctx, callTimeout)
 	defer cancel()
@@ -81,6 +119,10 @@ func (c *Client) post(method string, request any, response any) error {
 
 	defer func() { _ = httpResp.Body.Close() }()
 
+	if httpResp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%q failed: %w", method, errUnauthorized)
+	}
+
 	// Even if the response is nil, we still need to parse the outer JSON-RPC
 	// shell to get any error that the server may have returned.
 	err = json2.DecodeClientResponse(httpResp.Body, response)
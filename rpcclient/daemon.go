@@ -23,3 +23,16 @@ func (c *Client) Version() (*rpc.VersionResponse, error) {
 	}
 	return resp, nil
 }
+
+// Reload makes swapd re-read its config file and apply the settings that can
+// change without a restart
+func (c *Client) Reload() (*rpc.ReloadResponse, error) {
+	const (
+		method = "daemon_reload"
+	)
+	resp := &rpc.ReloadResponse{}
+	if err := c.post(method, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -47,3 +47,18 @@ func (c *Client) QueryAll(provides coins.ProvidesCoin, searchTime uint64) ([]*rp
 
 	return res.PeersWithOffers, nil
 }
+
+// GetOrderBook calls net_getOrderBook.
+func (c *Client) GetOrderBook(req *rpctypes.GetOrderBookRequest) ([]*rpctypes.OrderBookOffer, error) {
+	const (
+		method = "net_getOrderBook"
+	)
+
+	res := &rpctypes.GetOrderBookResponse{}
+
+	if err := c.post(method, req, res); err != nil {
+		return nil, err
+	}
+
+	return res.Offers, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpcclient
+
+import (
+	"github.com/athanorlabs/atomic-swap/common/rpctypes"
+)
+
+// IndexOffers calls index_getOffers.
+func (c *Client) IndexOffers(req *rpctypes.GetIndexOffersRequest) ([]*rpctypes.OrderBookOffer, error) {
+	const (
+		method = "index_getOffers"
+	)
+
+	res := &rpctypes.GetIndexOffersResponse{}
+
+	if err := c.post(method, req, res); err != nil {
+		return nil, err
+	}
+
+	return res.Offers, nil
+}
+
+// IndexStats calls index_stats.
+func (c *Client) IndexStats() (*rpctypes.IndexStatsResponse, error) {
+	const (
+		method = "index_stats"
+	)
+
+	res := &rpctypes.IndexStatsResponse{}
+
+	if err := c.post(method, nil, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// IndexMakers calls index_makers.
+func (c *Client) IndexMakers() ([]*rpctypes.IndexMaker, error) {
+	const (
+		method = "index_makers"
+	)
+
+	res := &rpctypes.IndexMakersResponse{}
+
+	if err := c.post(method, nil, res); err != nil {
+		return nil, err
+	}
+
+	return res.Makers, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package rpcclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ChainSafe/chaindb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/rpctypes"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/db"
+	"github.com/athanorlabs/atomic-swap/net/message"
+	"github.com/athanorlabs/atomic-swap/orderbook"
+	"github.com/athanorlabs/atomic-swap/rpc"
+)
+
+// indexNet discovers a single maker with the given offers.
+type indexNet struct {
+	mockNet
+	offers []*types.Offer
+}
+
+func (*indexNet) Discover(_ string, _ time.Duration) ([]peer.ID, error) {
+	return []peer.ID{testPeerID}, nil
+}
+
+func (n *indexNet) Query(_ peer.ID) (*message.QueryResponse, error) {
+	return &message.QueryResponse{Offers: n.offers}, nil
+}
+
+func TestIndex(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	sdb, err := db.NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, sdb.Close()) })
+
+	cheap := types.NewOffer(coins.ProvidesXMR, coins.StrToDecimal("1"), coins.StrToDecimal("2"),
+		coins.StrToExchangeRate("0.08"), types.EthAssetETH)
+	pricey := types.NewOffer(coins.ProvidesXMR, coins.StrToDecimal("5"), coins.StrToDecimal("10"),
+		coins.StrToExchangeRate("0.1"), types.EthAssetETH)
+	book, err := orderbook.NewBook(ctx, &orderbook.Config{
+		Net: &indexNet{offers: []*types.Offer{pricey, cheap}},
+		DB:  sdb,
+	})
+	require.NoError(t, err)
+	book.Refresh()
+
+	// the index server of a bootnode only serves the index namespace
+	s, _ := newServerWithConfig(t, func(cfg *rpc.Config) {
+		cfg.Env = common.Bootnode
+		cfg.Net = nil
+		cfg.ProtocolBackend = nil
+		cfg.XMRTaker = nil
+		cfg.XMRMaker = nil
+		cfg.OfferIndex = book
+		cfg.Namespaces = map[string]struct{}{rpc.IndexNamespace: {}}
+	})
+	c := NewClientWithEndpoint(ctx, fmt.Sprintf("http://127.0.0.1:%d/", s.Port()))
+
+	offers, err := c.IndexOffers(&rpctypes.GetIndexOffersRequest{EthAsset: types.EthAssetETH})
+	require.NoError(t, err)
+	require.Len(t, offers, 2)
+	require.Equal(t, cheap.ID, offers[0].Offer.ID)
+	require.Equal(t, testPeerID, offers[0].PeerID)
+	require.Equal(t, pricey.ID, offers[1].Offer.ID)
+
+	offers, err = c.IndexOffers(&rpctypes.GetIndexOffersRequest{EthAsset: types.EthAsset{0x1}})
+	require.NoError(t, err)
+	require.Empty(t, offers)
+
+	stats, err := c.IndexStats()
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Offers)
+	require.Equal(t, 1, stats.Makers)
+	require.Equal(t, 1, stats.OnlineMakers)
+	require.Len(t, stats.Assets, 1)
+	require.Equal(t, types.EthAssetETH, stats.Assets[0].EthAsset)
+	require.Equal(t, 1, stats.Assets[0].Makers)
+	require.Equal(t, "12", stats.Assets[0].TotalXMR.String())
+	// 2 XMR at 0.08 and 10 XMR at 0.1
+	require.Equal(t, "1.16", stats.Assets[0].TotalEthAsset.Text('f'))
+
+	makers, err := c.IndexMakers()
+	require.NoError(t, err)
+	require.Len(t, makers, 1)
+	require.Equal(t, testPeerID, makers[0].PeerID)
+	require.True(t, makers[0].Online)
+	require.Len(t, makers[0].Checks, 1)
+
+	// daemon_shutdown and the other namespaces are not served
+	require.ErrorContains(t, c.Shutdown(), `can't find service "daemon.Shutdown"`)
+	_, err = c.Addresses()
+	require.ErrorContains(t, err, `can't find service "net.Addresses"`)
+}
+
+func TestIndex_noOfferIndex(t *testing.T) {
+	_, err := rpc.NewServer(&rpc.Config{
+		Ctx:        context.Background(),
+		Env:        common.Bootnode,
+		Address:    "127.0.0.1:0",
+		Namespaces: map[string]struct{}{rpc.IndexNamespace: {}},
+	})
+	require.ErrorContains(t, err, "the index namespace needs an offer index")
+}
@@ -17,6 +17,7 @@ func (c *Client) MakeOffer(
 	exchangeRate *coins.ExchangeRate,
 	ethAsset types.EthAsset,
 	useRelayer bool,
+	chainID uint64,
 ) (*rpctypes.MakeOfferResponse, error) {
 	const (
 		method = "net_makeOffer"
@@ -28,6 +29,7 @@ func (c *Client) MakeOffer(
 		ExchangeRate: exchangeRate,
 		EthAsset:     ethAsset,
 		UseRelayer:   useRelayer,
+		ChainID:      chainID,
 	}
 	res := &rpctypes.MakeOfferResponse{}
 
@@ -24,7 +24,9 @@ import (
 	mcrypto "github.com/athanorlabs/atomic-swap/crypto/monero"
 	"github.com/athanorlabs/atomic-swap/db"
 	"github.com/athanorlabs/atomic-swap/ethereum/extethclient"
+	"github.com/athanorlabs/atomic-swap/net"
 	"github.com/athanorlabs/atomic-swap/net/message"
+	"github.com/athanorlabs/atomic-swap/protocol/backend"
 	"github.com/athanorlabs/atomic-swap/protocol/swap"
 	"github.com/athanorlabs/atomic-swap/protocol/txsender"
 )
@@ -38,7 +40,11 @@ type mockNet struct {
 }
 
 func (*mockNet) Addresses() []ma.Multiaddr {
-	panic("not implemented")
+	return []ma.Multiaddr{ma.StringCast("/ip4/127.0.0.1/tcp/9900")}
+}
+
+func (*mockNet) Reachability() net.Reachability {
+	return net.ReachabilityPublic
 }
 
 func (m *mockNet) PeerID() peer.ID {
@@ -61,7 +67,20 @@ func (*mockNet) Discover(_ string, _ time.Duration) ([]peer.ID, error) {
 }
 
 func (*mockNet) Query(_ peer.ID) (*message.QueryResponse, error) {
-	return &message.QueryResponse{Offers: []*types.Offer{{ID: testSwapID}}}, nil
+	return &message.QueryResponse{
+		Offers: []*types.Offer{{
+			ID:           testSwapID,
+			ExchangeRate: coins.StrToExchangeRate("0.1"),
+		}},
+		Capabilities: &message.Capabilities{
+			Versions: message.SupportedProtocolVersions,
+			Features: []message.Feature{message.FeatureRelayer},
+		},
+	}, nil
+}
+
+func (*mockNet) SubscribeOffers(_ uint64, _ types.EthAsset) <-chan *message.OfferAnnouncement {
+	return make(chan *message.OfferAnnouncement)
 }
 
 func (*mockNet) Initiate(_ peer.AddrInfo, _ common.Message, _ common.SwapStateNet) error {
@@ -235,3 +254,7 @@ func (*mockProtocolBackend) TransferETH(_ ethcommon.Address, _ *coins.WeiAmount,
 func (*mockProtocolBackend) SweepETH(_ ethcommon.Address) (*ethtypes.Receipt, error) {
 	panic("not implemented")
 }
+
+func (*mockProtocolBackend) ForChain(_ uint64) (backend.Backend, error) {
+	panic("not implemented")
+}
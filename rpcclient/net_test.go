@@ -5,23 +5,66 @@ package rpcclient
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/ChainSafe/chaindb"
 	"github.com/cockroachdb/apd/v3"
 
+	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common/rpctypes"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/db"
+	"github.com/athanorlabs/atomic-swap/orderbook"
+	"github.com/athanorlabs/atomic-swap/pricefeed"
 	"github.com/athanorlabs/atomic-swap/rpc"
 
 	"github.com/stretchr/testify/require"
 )
 
-func TestNet_Discover(t *testing.T) {
+func newTestNetService(t *testing.T, policy *rpc.OfferPolicy) *rpc.NetService {
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(func() {
 		cancel()
 	})
 
-	ns := rpc.NewNetService(ctx, new(mockNet), new(mockXMRTaker), nil, new(mockProtocolBackend), mockSwapManager(t), false)
+	return rpc.NewNetService(
+		ctx,
+		new(mockNet),
+		new(mockXMRTaker),
+		nil,
+		new(mockProtocolBackend),
+		mockSwapManager(t),
+		policy,
+		nil,
+		false,
+	)
+}
+
+// fixedRatePolicy returns an offer policy with a market rate of 0.08, which
+// is 20% below the 0.1 rate of the mock offer.
+func fixedRatePolicy(maxPremium *apd.Decimal) *rpc.OfferPolicy {
+	return &rpc.OfferPolicy{
+		MaxPremium: maxPremium,
+		MarketRate: func(_ context.Context, _ uint64, _ types.EthAsset) (*coins.ExchangeRate, error) {
+			return coins.StrToExchangeRate("0.08"), nil
+		},
+	}
+}
+
+func TestNet_Addresses(t *testing.T) {
+	ns := newTestNetService(t, nil)
+
+	resp := new(rpctypes.AddressesResponse)
+	err := ns.Addresses(nil, nil, resp)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/ip4/127.0.0.1/tcp/9900"}, resp.Addrs)
+	require.Equal(t, "public", resp.Reachability)
+}
+
+func TestNet_Discover(t *testing.T) {
+	ns := newTestNetService(t, nil)
 
 	req := &rpctypes.DiscoverRequest{
 		Provides: "",
@@ -35,12 +78,7 @@ func TestNet_Discover(t *testing.T) {
 }
 
 func TestNet_Query(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	t.Cleanup(func() {
-		cancel()
-	})
-
-	ns := rpc.NewNetService(ctx, new(mockNet), new(mockXMRTaker), nil, new(mockProtocolBackend), mockSwapManager(t), false)
+	ns := newTestNetService(t, nil)
 
 	req := &rpctypes.QueryPeerRequest{
 		PeerID: "12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5",
@@ -51,22 +89,148 @@ func TestNet_Query(t *testing.T) {
 	err := ns.QueryPeer(nil, req, resp)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(resp.Offers))
+	require.Equal(t, &rpctypes.PeerCapabilities{Versions: []uint16{1, 2}, Features: []string{"relayer"}},
+		resp.Capabilities)
 }
 
 func TestNet_TakeOffer(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	t.Cleanup(func() {
-		cancel()
-	})
+	ns := newTestNetService(t, nil)
+
+	req := &rpctypes.TakeOfferRequest{
+		PeerID:         "12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5",
+		OfferID:        testSwapID,
+		ProvidesAmount: apd.New(1, 0),
+	}
+
+	err := ns.TakeOffer(nil, req, nil)
+	require.NoError(t, err)
+}
 
-	ns := rpc.NewNetService(ctx, new(mockNet), new(mockXMRTaker), nil, new(mockProtocolBackend), mockSwapManager(t), false)
+func TestNet_QueryPremiums(t *testing.T) {
+	ns := newTestNetService(t, fixedRatePolicy(nil))
 
+	req := &rpctypes.QueryPeerRequest{
+		PeerID: "12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5",
+	}
+
+	resp := new(rpctypes.QueryPeerResponse)
+	err := ns.QueryPeer(nil, req, resp)
+	require.NoError(t, err)
+	require.Equal(t, "25.00", resp.Premiums[testSwapID].String())
+}
+
+func TestNet_TakeOffer_offMarket(t *testing.T) {
 	req := &rpctypes.TakeOfferRequest{
 		PeerID:         "12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5",
 		OfferID:        testSwapID,
 		ProvidesAmount: apd.New(1, 0),
 	}
 
+	// 25% is over the 10% limit
+	ns := newTestNetService(t, fixedRatePolicy(apd.New(1, -1)))
 	err := ns.TakeOffer(nil, req, nil)
+	require.ErrorContains(t, err, "too far from the market rate")
+
+	req.AllowOffMarket = true
+	err = ns.TakeOffer(nil, req, nil)
 	require.NoError(t, err)
+
+	// within the 30% limit
+	req.AllowOffMarket = false
+	ns = newTestNetService(t, fixedRatePolicy(apd.New(3, -1)))
+	err = ns.TakeOffer(nil, req, nil)
+	require.NoError(t, err)
+
+	// the maximum premium can change while the policy is in use
+	policy := fixedRatePolicy(apd.New(1, -1))
+	ns = newTestNetService(t, policy)
+	require.Error(t, ns.TakeOffer(nil, req, nil))
+	policy.SetMaxPremium(apd.New(3, -1))
+	require.NoError(t, ns.TakeOffer(nil, req, nil))
+	policy.SetMaxPremium(nil)
+	require.NoError(t, ns.TakeOffer(nil, req, nil))
+
+	// the offer can't be checked if the market rate is unavailable
+	ns = newTestNetService(t, &rpc.OfferPolicy{
+		MaxPremium: apd.New(3, -1),
+		MarketRate: func(_ context.Context, _ uint64, _ types.EthAsset) (*coins.ExchangeRate, error) {
+			return nil, errors.New("no prices")
+		},
+	})
+	err = ns.TakeOffer(nil, req, nil)
+	require.ErrorContains(t, err, "no prices")
+
+	// but it can be taken if its asset has no price feed to check against
+	ns = newTestNetService(t, &rpc.OfferPolicy{
+		MaxPremium: apd.New(3, -1),
+		MarketRate: func(_ context.Context, _ uint64, _ types.EthAsset) (*coins.ExchangeRate, error) {
+			return nil, fmt.Errorf("%w 0x1", pricefeed.ErrNoTokenFeed)
+		},
+	})
+	err = ns.TakeOffer(nil, req, nil)
+	require.NoError(t, err)
+}
+
+func TestNet_GetOrderBook(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	sdb, err := db.NewDatabase(&chaindb.Config{
+		DataDir:  t.TempDir(),
+		InMemory: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, sdb.Close()) })
+
+	net := new(mockNet)
+	book, err := orderbook.NewBook(ctx, &orderbook.Config{Net: net, DB: sdb})
+	require.NoError(t, err)
+
+	ns := rpc.NewNetService(
+		ctx,
+		net,
+		new(mockXMRTaker),
+		nil,
+		new(mockProtocolBackend),
+		mockSwapManager(t),
+		fixedRatePolicy(nil),
+		book,
+		false,
+	)
+
+	cheap := types.NewOffer(coins.ProvidesXMR, coins.StrToDecimal("1"), coins.StrToDecimal("2"),
+		coins.StrToExchangeRate("0.08"), types.EthAssetETH)
+	pricey := types.NewOffer(coins.ProvidesXMR, coins.StrToDecimal("5"), coins.StrToDecimal("10"),
+		coins.StrToExchangeRate("0.1"), types.EthAssetETH)
+	book.Update(testPeerID, []*types.Offer{pricey, cheap})
+
+	resp := new(rpctypes.GetOrderBookResponse)
+	err = ns.GetOrderBook(nil, &rpctypes.GetOrderBookRequest{}, resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Offers, 2)
+	require.Equal(t, cheap.ID, resp.Offers[0].Offer.ID)
+	require.Equal(t, testPeerID, resp.Offers[0].PeerID)
+	require.Nil(t, resp.Offers[0].ExpectedAmount)
+	require.Equal(t, pricey.ID, resp.Offers[1].Offer.ID)
+	require.Equal(t, "25.00", resp.Offers[1].Premium.String())
+
+	// 0.6 ETH is 6 XMR at the rate of the pricier offer, and 7.5 XMR at the
+	// rate of the cheaper one, which is over its maximum
+	err = ns.GetOrderBook(nil, &rpctypes.GetOrderBookRequest{ProvidesAmount: coins.StrToDecimal("0.6")}, resp)
+	require.NoError(t, err)
+	require.Len(t, resp.Offers, 1)
+	require.Equal(t, pricey.ID, resp.Offers[0].Offer.ID)
+	require.Zero(t, resp.Offers[0].ExpectedAmount.Cmp(apd.New(6, 0)))
+
+	// query responses are recorded in the book
+	req := &rpctypes.QueryPeerRequest{
+		PeerID: "12D3KooWDqCzbjexHEa8Rut7bzxHFpRMZyDRW1L6TGkL1KY24JH5",
+	}
+	err = ns.QueryPeer(nil, req, new(rpctypes.QueryPeerResponse))
+	require.NoError(t, err)
+	require.Equal(t, 3, book.Len())
+
+	// the order book is optional
+	err = newTestNetService(t, nil).GetOrderBook(nil, &rpctypes.GetOrderBookRequest{}, resp)
+	require.ErrorContains(t, err, "the order book is not enabled")
 }
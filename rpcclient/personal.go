@@ -38,14 +38,14 @@ func (c *Client) GetSwapTimeout() (*rpc.GetSwapTimeoutResponse, error) {
 	return swapTimeout, nil
 }
 
-// TokenInfo calls personal_tokenInfo
-func (c *Client) TokenInfo(tokenAddr ethcommon.Address) (*coins.ERC20TokenInfo, error) {
+// TokenInfo calls personal_tokenInfo. A chain ID of zero is the primary chain.
+func (c *Client) TokenInfo(tokenAddr ethcommon.Address, chainID uint64) (*coins.ERC20TokenInfo, error) {
 	const (
 		method = "personal_tokenInfo"
 	)
 
 	// Note: coins.ERC20TokenInfo and rpctypes.TokenInfoRequest are aliases
-	request := &rpctypes.TokenInfoRequest{TokenAddr: tokenAddr}
+	request := &rpctypes.TokenInfoRequest{TokenAddr: tokenAddr, ChainID: chainID}
 	tokenInfo := new(rpctypes.TokenInfoResponse)
 
 	if err := c.post(method, request, tokenInfo); err != nil {
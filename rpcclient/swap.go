@@ -5,6 +5,7 @@ package rpcclient
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/athanorlabs/atomic-swap/common/types"
 	"github.com/athanorlabs/atomic-swap/rpc"
@@ -47,6 +48,22 @@ func (c *Client) GetPastSwap(id *types.Hash) (*rpc.GetPastResponse, error) {
 	return res, nil
 }
 
+// GetPastSwaps calls swap_getPast with the filters and pagination options in
+// the request.
+func (c *Client) GetPastSwaps(req *rpc.GetPastRequest) (*rpc.GetPastResponse, error) {
+	const (
+		method = "swap_getPast"
+	)
+
+	res := &rpc.GetPastResponse{}
+
+	if err := c.post(method, req, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // GetStatus calls swap_getStatus
 func (c *Client) GetStatus(id types.Hash) (*rpc.GetStatusResponse, error) {
 	const (
@@ -65,6 +82,24 @@ func (c *Client) GetStatus(id types.Hash) (*rpc.GetStatusResponse, error) {
 	return res, nil
 }
 
+// GetJournal calls swap_getJournal
+func (c *Client) GetJournal(id types.Hash) (*rpc.GetJournalResponse, error) {
+	const (
+		method = "swap_getJournal"
+	)
+
+	req := &rpc.GetJournalRequest{
+		ID: id,
+	}
+	res := &rpc.GetJournalResponse{}
+
+	if err := c.post(method, req, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // ClearOffers calls swap_clearOffers
 func (c *Client) ClearOffers(offerIDs []types.Hash) error {
 	const (
@@ -121,13 +156,36 @@ func (c *Client) Refund(offerID types.Hash) (*rpc.ManualTransactionResponse, err
 }
 
 // SuggestedExchangeRate calls swap_suggestedExchangeRate
-func (c *Client) SuggestedExchangeRate() (*rpc.SuggestedExchangeRateResponse, error) {
+func (c *Client) SuggestedExchangeRate(ethAsset types.EthAsset) (*rpc.SuggestedExchangeRateResponse, error) {
 	const (
 		method = "swap_suggestedExchangeRate"
 	)
 
+	req := &rpc.SuggestedExchangeRateRequest{
+		EthAsset: ethAsset,
+	}
+
 	res := &rpc.SuggestedExchangeRateResponse{}
-	if err := c.post(method, nil, res); err != nil {
+	if err := c.post(method, req, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ExportHistory calls swap_exportHistory
+func (c *Client) ExportHistory(from *time.Time, to *time.Time) (*rpc.ExportHistoryResponse, error) {
+	const (
+		method = "swap_exportHistory"
+	)
+
+	req := &rpc.ExportHistoryRequest{
+		From: from,
+		To:   to,
+	}
+
+	res := &rpc.ExportHistoryResponse{}
+	if err := c.post(method, req, res); err != nil {
 		return nil, err
 	}
 
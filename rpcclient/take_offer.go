@@ -13,15 +13,19 @@ import (
 
 // TakeOffer calls net_takeOffer.
 func (c *Client) TakeOffer(peerID peer.ID, offerID types.Hash, providesAmount *apd.Decimal) error {
-	const (
-		method = "net_takeOffer"
-	)
-
-	req := &rpctypes.TakeOfferRequest{
+	return c.TakeOfferWithRequest(&rpctypes.TakeOfferRequest{
 		PeerID:         peerID,
 		OfferID:        offerID,
 		ProvidesAmount: providesAmount,
-	}
+	})
+}
+
+// TakeOfferWithRequest calls net_takeOffer with the given request, which
+// allows setting options such as AllowOffMarket.
+func (c *Client) TakeOfferWithRequest(req *rpctypes.TakeOfferRequest) error {
+	const (
+		method = "net_takeOffer"
+	)
 
 	return c.post(method, req, nil)
 }
@@ -20,7 +20,7 @@ import (
 var log = logging.Logger("rpcclient")
 
 func (c *Client) wsConnect() (*websocket.Conn, error) {
-	conn, resp, err := websocket.DefaultDialer.DialContext(c.ctx, c.wsEndpoint, nil)
+	conn, resp, err := websocket.DefaultDialer.DialContext(c.ctx, c.wsEndpoint, c.authHeader())
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial WS endpoint: %w", err)
 	}
@@ -124,12 +124,19 @@ func (c *Client) TakeOfferAndSubscribe(
 	offerID types.Hash,
 	providesAmount *apd.Decimal,
 ) (ch <-chan types.Status, err error) {
-	params := &rpctypes.TakeOfferRequest{
+	return c.TakeOfferAndSubscribeWithRequest(&rpctypes.TakeOfferRequest{
 		PeerID:         peerID,
 		OfferID:        offerID,
 		ProvidesAmount: providesAmount,
-	}
+	})
+}
 
+// TakeOfferAndSubscribeWithRequest is like TakeOfferAndSubscribe, but takes
+// the offer with the given request, which allows setting options such as
+// AllowOffMarket.
+func (c *Client) TakeOfferAndSubscribeWithRequest(
+	params *rpctypes.TakeOfferRequest,
+) (ch <-chan types.Status, err error) {
 	bz, err := vjson.MarshalStruct(params)
 	if err != nil {
 		return nil, err
@@ -215,6 +222,7 @@ func (c *Client) MakeOfferAndSubscribe(
 	exchangeRate *coins.ExchangeRate,
 	ethAsset types.EthAsset,
 	useRelayer bool,
+	chainID uint64,
 ) (*rpctypes.MakeOfferResponse, <-chan types.Status, error) {
 	params := &rpctypes.MakeOfferRequest{
 		MinAmount:    min,
@@ -222,6 +230,7 @@ func (c *Client) MakeOfferAndSubscribe(
 		ExchangeRate: exchangeRate,
 		EthAsset:     ethAsset,
 		UseRelayer:   useRelayer,
+		ChainID:      chainID,
 	}
 
 	bz, err := vjson.MarshalStruct(params)
@@ -5,6 +5,7 @@ package rpcclient
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/athanorlabs/atomic-swap/coins"
 	"github.com/athanorlabs/atomic-swap/common"
+	"github.com/athanorlabs/atomic-swap/common/rpctypes"
 	"github.com/athanorlabs/atomic-swap/common/types"
 	"github.com/athanorlabs/atomic-swap/rpc"
 )
@@ -27,6 +29,17 @@ var (
 )
 
 func newServer(t *testing.T) (*rpc.Server, *rpc.Config) {
+	return newServerWithCredentials(t, nil)
+}
+
+func newServerWithCredentials(t *testing.T, creds *rpc.Credentials) (*rpc.Server, *rpc.Config) {
+	return newServerWithConfig(t, func(cfg *rpc.Config) {
+		cfg.Credentials = creds
+	})
+}
+
+// newServerWithConfig starts a test server with the config changed by update.
+func newServerWithConfig(t *testing.T, update func(cfg *rpc.Config)) (*rpc.Server, *rpc.Config) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	cfg := &rpc.Config{
@@ -39,6 +52,7 @@ func newServer(t *testing.T) (*rpc.Server, *rpc.Config) {
 		XMRMaker:        new(mockXMRMaker),
 		Namespaces:      rpc.AllNamespaces(),
 	}
+	update(cfg)
 
 	s, err := rpc.NewServer(cfg)
 	require.NoError(t, err)
@@ -80,6 +94,67 @@ func TestSubscribeSwapStatus(t *testing.T) {
 	}
 }
 
+func TestCredentials(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newServerWithCredentials(t, &rpc.Credentials{Username: "swap", Password: "secret"})
+
+	c := NewClient(ctx, s.Port())
+	_, err := c.Version()
+	require.ErrorIs(t, err, errUnauthorized)
+	_, err = c.SubscribeSwapStatus(testSwapID)
+	require.ErrorContains(t, err, "bad handshake")
+
+	c.SetCredentials("swap", "wrong")
+	_, err = c.Version()
+	require.ErrorIs(t, err, errUnauthorized)
+
+	c.SetCredentials("swap", "secret")
+	_, err = c.Version()
+	require.NoError(t, err)
+
+	ch, err := c.SubscribeSwapStatus(testSwapID)
+	require.NoError(t, err)
+
+	select {
+	case status := <-ch:
+		require.Equal(t, types.CompletedSuccess.String(), status.String())
+	case <-time.After(testTimeout):
+		t.Fatal("test timed out")
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := newServer(t)
+	_, err := NewClient(ctx, s.Port()).Reload()
+	require.ErrorContains(t, err, "reloading the configuration is not supported")
+
+	var changed []string
+	s, _ = newServerWithConfig(t, func(cfg *rpc.Config) {
+		cfg.Reload = func() ([]string, error) {
+			if changed == nil {
+				return nil, errors.New("changing rpc-port requires a restart")
+			}
+			return changed, nil
+		}
+	})
+	c := NewClient(ctx, s.Port())
+
+	_, err = c.Reload()
+	require.ErrorContains(t, err, "changing rpc-port requires a restart")
+
+	changed = []string{}
+	resp, err := c.Reload()
+	require.NoError(t, err)
+	require.Empty(t, resp.Changed)
+
+	changed = []string{"log-level", "relayer"}
+	resp, err = c.Reload()
+	require.NoError(t, err)
+	require.Equal(t, changed, resp.Changed)
+}
+
 func TestSubscribeMakeOffer(t *testing.T) {
 	ctx := context.Background()
 	s, cfg := newServer(t)
@@ -89,7 +164,7 @@ func TestSubscribeMakeOffer(t *testing.T) {
 	min := coins.StrToDecimal("0.1")
 	max := coins.StrToDecimal("1")
 	exRate := coins.ToExchangeRate(coins.StrToDecimal("0.05"))
-	offerResp, ch, err := c.MakeOfferAndSubscribe(min, max, exRate, types.EthAssetETH, false)
+	offerResp, ch, err := c.MakeOfferAndSubscribe(min, max, exRate, types.EthAssetETH, false, 0)
 	require.NoError(t, err)
 	require.NotEqual(t, offerResp.OfferID, testSwapID)
 
@@ -122,3 +197,35 @@ func TestSubscribeTakeOffer(t *testing.T) {
 		t.Fatal("test timed out")
 	}
 }
+
+func TestSubscribeTakeOfferWithRequest(t *testing.T) {
+	// the offer is taken without checking the market rate
+	s, _ := newServerWithConfig(t, func(cfg *rpc.Config) {
+		cfg.MaxOfferPremium = apd.New(1, -1)
+	})
+
+	cliCtx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+	})
+	c := NewClient(cliCtx, s.Port())
+
+	req := &rpctypes.TakeOfferRequest{
+		PeerID:         testPeerID,
+		OfferID:        testSwapID,
+		ProvidesAmount: apd.New(1, 0),
+		AllowOffMarket: true,
+	}
+
+	require.NoError(t, c.TakeOfferWithRequest(req))
+
+	ch, err := c.TakeOfferAndSubscribeWithRequest(req)
+	require.NoError(t, err)
+
+	select {
+	case status := <-ch:
+		require.Equal(t, types.CompletedSuccess, status)
+	case <-time.After(testTimeout):
+		t.Fatal("test timed out")
+	}
+}
@@ -48,7 +48,7 @@ var testPackages = []struct {
 	{"protocol/xmrmaker", 3},
 	{"protocol/xmrtaker", 3},
 	{"protocol/txsender", 2},
-	{"recover", 2},
+	{"recovery", 2},
 	{"relayer", 2},
 	{"tests", 3},
 }
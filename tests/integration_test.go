@@ -109,7 +109,7 @@ func mineMinXMRMakerBalance(t *testing.T, minBalance *coins.PiconeroAmount) {
 func (s *IntegrationTestSuite) TestXMRTaker_Discover() {
 	ctx := context.Background()
 	bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
-	_, err := bc.MakeOffer(xmrmakerProvideAmount, xmrmakerProvideAmount, exchangeRate, types.EthAssetETH, false)
+	_, err := bc.MakeOffer(xmrmakerProvideAmount, xmrmakerProvideAmount, exchangeRate, types.EthAssetETH, false, 0)
 	require.NoError(s.T(), err)
 
 	// Give offer advertisement time to propagate
@@ -138,7 +138,7 @@ func (s *IntegrationTestSuite) TestXMRMaker_Pairs() {
 		coins.StrToDecimal("2"),
 		coins.StrToExchangeRate("200"),
 		s.testToken,
-		false)
+		false, 0)
 
 	require.NoError(s.T(), err)
 
@@ -147,7 +147,7 @@ func (s *IntegrationTestSuite) TestXMRMaker_Pairs() {
 		coins.StrToDecimal("2"),
 		coins.StrToExchangeRate("200"),
 		types.EthAssetETH,
-		false)
+		false, 0)
 
 	require.NoError(s.T(), err)
 
@@ -156,7 +156,7 @@ func (s *IntegrationTestSuite) TestXMRMaker_Pairs() {
 		coins.StrToDecimal("2"),
 		coins.StrToExchangeRate("200"),
 		types.EthAssetETH,
-		false)
+		false, 0)
 
 	require.NoError(s.T(), err)
 
@@ -184,7 +184,7 @@ func (s *IntegrationTestSuite) TestXMRTaker_Query() {
 func (s *IntegrationTestSuite) testXMRTakerQuery(asset types.EthAsset) {
 	ctx := context.Background()
 	bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
-	offerResp, err := bc.MakeOffer(xmrmakerProvideAmount, xmrmakerProvideAmount, exchangeRate, asset, false)
+	offerResp, err := bc.MakeOffer(xmrmakerProvideAmount, xmrmakerProvideAmount, exchangeRate, asset, false, 0)
 	require.NoError(s.T(), err)
 
 	require.NoError(s.T(), common.SleepWithContext(ctx, time.Second)) // Give offer advertisement time to propagate
@@ -224,7 +224,7 @@ func (s *IntegrationTestSuite) testSuccessOneSwap(asset types.EthAsset, useRelay
 	bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
 	min := coins.StrToDecimal("0.21")
 	offerResp, statusCh, err := bc.MakeOfferAndSubscribe(min, xmrmakerProvideAmount,
-		exchangeRate, asset, useRelayer)
+		exchangeRate, asset, useRelayer, 0)
 	require.NoError(s.T(), err)
 
 	beforeResp, err := bc.GetOffers()
@@ -316,7 +316,7 @@ func (s *IntegrationTestSuite) testRefundXMRTakerCancels(asset types.EthAsset) {
 
 	bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
 	offerResp, statusCh, err := bc.MakeOfferAndSubscribe(xmrmakerProvideAmount, xmrmakerProvideAmount,
-		exchangeRate, asset, false)
+		exchangeRate, asset, false, 0)
 	require.NoError(s.T(), err)
 
 	beforeResp, err := bc.GetOffers()
@@ -450,7 +450,7 @@ func (s *IntegrationTestSuite) testRefundXMRMakerCancels(
 	bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
 
 	offerResp, statusCh, err := bc.MakeOfferAndSubscribe(xmrmakerProvideAmount, xmrmakerProvideAmount,
-		exchangeRate, types.EthAssetETH, false)
+		exchangeRate, types.EthAssetETH, false, 0)
 	require.NoError(s.T(), err)
 
 	beforeResp, err := bc.GetOffers()
@@ -560,7 +560,7 @@ func (s *IntegrationTestSuite) testAbortXMRTakerCancels(asset types.EthAsset) {
 
 	min := coins.StrToDecimal("0.21")
 	offerResp, statusCh, err := bc.MakeOfferAndSubscribe(min, xmrmakerProvideAmount,
-		exchangeRate, asset, false)
+		exchangeRate, asset, false, 0)
 	require.NoError(s.T(), err)
 
 	beforeResp, err := bc.GetOffers()
@@ -667,7 +667,7 @@ func (s *IntegrationTestSuite) testAbortXMRMakerCancels(asset types.EthAsset) {
 	bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
 
 	offerResp, statusCh, err := bc.MakeOfferAndSubscribe(xmrmakerProvideAmount, xmrmakerProvideAmount,
-		exchangeRate, asset, false)
+		exchangeRate, asset, false, 0)
 	require.NoError(s.T(), err)
 
 	beforeResp, err := bc.GetOffers()
@@ -764,7 +764,7 @@ func (s *IntegrationTestSuite) testErrorShouldOnlyTakeOfferOnce(asset types.EthA
 	defer cancel()
 
 	bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
-	offerResp, err := bc.MakeOffer(xmrmakerProvideAmount, xmrmakerProvideAmount, exchangeRate, asset, false)
+	offerResp, err := bc.MakeOffer(xmrmakerProvideAmount, xmrmakerProvideAmount, exchangeRate, asset, false, 0)
 	require.NoError(s.T(), err)
 
 	// Give offer advertisement time to propagate
@@ -883,7 +883,7 @@ func (s *IntegrationTestSuite) testSuccessConcurrentSwaps(asset types.EthAsset)
 	for i := 0; i < numConcurrentSwaps; i++ {
 		bc := rpcclient.NewClient(ctx, defaultXMRMakerSwapdPort)
 		offerResp, statusCh, err := bc.MakeOfferAndSubscribe(xmrmakerProvideAmount, xmrmakerProvideAmount, //nolint:govet
-			exchangeRate, asset, false)
+			exchangeRate, asset, false, 0)
 		require.NoError(s.T(), err)
 
 		s.T().Logf("XMRMaker[%d] made offer %s", i, offerResp.OfferID)
@@ -0,0 +1,92 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package webhook
+
+import (
+	"crypto/rand"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+)
+
+// EventType is the kind of event a webhook notification is sent for.
+type EventType string
+
+// Event types
+const (
+	// EventOfferTaken is sent when one of our offers is taken and its swap
+	// begins.
+	EventOfferTaken EventType = "offer_taken"
+	// EventSwapStatus is sent each time the status of a swap changes.
+	EventSwapStatus EventType = "swap_status"
+	// EventSwapRefunded is sent, in addition to EventSwapStatus, when a swap
+	// ends with a refund.
+	EventSwapRefunded EventType = "swap_refunded"
+	// EventSwapAborted is sent, in addition to EventSwapStatus, when a swap
+	// ends before any funds were locked.
+	EventSwapAborted EventType = "swap_aborted"
+	// EventTimeoutApproaching is sent once for each of a swap's timeouts
+	// when the timeout is near.
+	EventTimeoutApproaching EventType = "timeout_approaching"
+	// EventLowBalance is sent when the ETH balance drops below the configured
+	// threshold. It is sent again only after the balance has recovered.
+	EventLowBalance EventType = "low_balance"
+	// EventRelayerClaimCompleted is sent when our claim of a swap's ETH was
+	// submitted by a relayer and included in a block.
+	EventRelayerClaimCompleted EventType = "relayer_claim_completed"
+)
+
+// Event is the JSON payload POSTed to the webhook URLs. Only the fields
+// relevant to the event's Type are set.
+type Event struct {
+	ID       types.Hash         `json:"id" validate:"required"`
+	Type     EventType          `json:"type" validate:"required"`
+	Time     time.Time          `json:"time" validate:"required"`
+	OfferID  *types.Hash        `json:"offerID,omitempty"`
+	Provides coins.ProvidesCoin `json:"provides,omitempty"`
+	Status   *types.Status      `json:"status,omitempty"`
+
+	// ProvidedAmount and ExpectedAmount are set on EventOfferTaken
+	ProvidedAmount *apd.Decimal    `json:"providedAmount,omitempty"`
+	ExpectedAmount *apd.Decimal    `json:"expectedAmount,omitempty"`
+	EthAsset       *types.EthAsset `json:"ethAsset,omitempty"`
+
+	// Timeout is "t1" or "t2" and Deadline its time on EventTimeoutApproaching
+	Timeout  string     `json:"timeout,omitempty"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+
+	TxHash *types.Hash `json:"txHash,omitempty"`
+
+	// Balance and Threshold are in ETH and set on EventLowBalance
+	Balance   *apd.Decimal `json:"balance,omitempty"`
+	Threshold *apd.Decimal `json:"threshold,omitempty"`
+}
+
+// newEvent returns an event of the given type with a random ID.
+func newEvent(eventType EventType) *Event {
+	return &Event{
+		ID:   randomID(),
+		Type: eventType,
+		Time: time.Now(),
+	}
+}
+
+// newSwapEvent returns an event of the given type for the swap with the given
+// offer ID.
+func newSwapEvent(eventType EventType, offerID types.Hash) *Event {
+	e := newEvent(eventType)
+	e.OfferID = &offerID
+	return e
+}
+
+func randomID() types.Hash {
+	var id types.Hash
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err) // only fails if the OS has no source of randomness
+	}
+	return id
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package webhook
+
+import (
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/metrics"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+var _ swap.Listener = (*Notifier)(nil)
+
+// SwapAdded sends EventOfferTaken when a swap of one of our offers begins.
+func (n *Notifier) SwapAdded(info *swap.Info) {
+	// the XMR maker is the one making offers
+	if info.Provides != coins.ProvidesXMR || !info.Status.IsOngoing() {
+		return
+	}
+
+	ethAsset := info.EthAsset
+	e := newSwapEvent(EventOfferTaken, info.OfferID)
+	e.Provides = info.Provides
+	e.Status = &info.Status
+	e.ProvidedAmount = info.ProvidedAmount
+	e.ExpectedAmount = info.ExpectedAmount
+	e.EthAsset = &ethAsset
+	n.Notify(e)
+}
+
+// JournalAppended sends EventSwapStatus for each status change, followed by
+// EventSwapRefunded or EventSwapAborted when the swap ends that way, and
+// EventRelayerClaimCompleted for relayed claims.
+func (n *Notifier) JournalAppended(offerID types.Hash, entry *swap.JournalEntry) {
+	switch entry.Type {
+	case swap.JournalStatus:
+		status := *entry.Status
+
+		e := newSwapEvent(EventSwapStatus, offerID)
+		e.Status = &status
+		n.Notify(e)
+
+		switch status {
+		case types.CompletedRefund:
+			e = newSwapEvent(EventSwapRefunded, offerID)
+		case types.CompletedAbort:
+			e = newSwapEvent(EventSwapAborted, offerID)
+		default:
+			return
+		}
+		e.Status = &status
+		n.Notify(e)
+
+	case swap.JournalEthTx:
+		if entry.TxType != string(metrics.TxRelayedClaim) {
+			return
+		}
+
+		e := newSwapEvent(EventRelayerClaimCompleted, offerID)
+		e.TxHash = entry.TxHash
+		n.Notify(e)
+	}
+}
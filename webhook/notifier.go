@@ -0,0 +1,404 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+// Package webhook sends signed notifications of swap and offer lifecycle events
+// to HTTP endpoints, retrying failed deliveries from a queue persisted in the
+// database.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/athanorlabs/atomic-swap/common/netproxy"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/common/vjson"
+)
+
+// HTTP headers set on each delivery
+const (
+	HeaderEvent     = "X-Swapd-Event"
+	HeaderDelivery  = "X-Swapd-Delivery"
+	HeaderTimestamp = "X-Swapd-Timestamp"
+	HeaderSignature = "X-Swapd-Signature"
+)
+
+const (
+	// DefaultMaxAttempts is the number of times a delivery is attempted
+	// before it is dropped.
+	DefaultMaxAttempts = 10
+
+	defaultMinBackoff = 5 * time.Second
+	defaultMaxBackoff = 30 * time.Minute
+	requestTimeout    = 10 * time.Second
+	signaturePrefix   = "sha256="
+)
+
+var (
+	log = logging.Logger("webhook")
+
+	errNoURLs   = errors.New("at least one webhook URL is required")
+	errNoSecret = errors.New("webhook secret is required")
+)
+
+// Delivery is an event queued for delivery to one URL.
+type Delivery struct {
+	ID          types.Hash `json:"id" validate:"required"`
+	URL         string     `json:"url" validate:"required"`
+	Event       *Event     `json:"event" validate:"required"`
+	Attempts    int        `json:"attempts"`
+	NextAttempt time.Time  `json:"nextAttempt"`
+}
+
+// Database persists the deliveries that have not succeeded yet, so that they
+// survive a restart.
+type Database interface {
+	PutWebhookDelivery(d *Delivery) error
+	DeleteWebhookDelivery(id types.Hash) error
+	GetAllWebhookDeliveries() ([]*Delivery, error)
+}
+
+// Config contains the configuration of a Notifier.
+type Config struct {
+	URLs   []string
+	Secret []byte
+	DB     Database
+	// Client defaults to an http.Client with a 10 second timeout, whose
+	// connections go through Proxy. Proxy can be nil for direct connections.
+	Client *http.Client
+	Proxy  *netproxy.Proxy
+	// MaxAttempts defaults to DefaultMaxAttempts.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, which doubles with
+	// each failed attempt up to MaxBackoff. They default to 5 seconds and 30
+	// minutes.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Notifier queues events for each configured URL and delivers them in the
+// background.
+type Notifier struct {
+	ctx  context.Context
+	cfg  Config
+	wake chan struct{}
+	wg   sync.WaitGroup
+
+	mu    sync.Mutex
+	queue map[types.Hash]*Delivery
+	// lowBalance is the threshold of the watcher's low balance check
+	lowBalance *apd.Decimal
+}
+
+// NewNotifier returns a Notifier with the deliveries that were still queued
+// when swapd last stopped. Call Start to begin delivering them.
+func NewNotifier(ctx context.Context, cfg *Config) (*Notifier, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, errNoURLs
+	}
+	if len(cfg.Secret) == 0 {
+		return nil, errNoSecret
+	}
+
+	c := *cfg
+	if c.Client == nil {
+		c.Client = c.Proxy.HTTPClient(requestTimeout)
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.MinBackoff == 0 {
+		c.MinBackoff = defaultMinBackoff
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+
+	stored, err := c.DB.GetAllWebhookDeliveries()
+	if err != nil {
+		return nil, err
+	}
+
+	queue := make(map[types.Hash]*Delivery, len(stored))
+	for _, d := range stored {
+		queue[d.ID] = d
+	}
+	if len(queue) > 0 {
+		log.Infof("loaded %d queued webhook deliveries", len(queue))
+	}
+
+	return &Notifier{
+		ctx:   ctx,
+		cfg:   c,
+		wake:  make(chan struct{}, 1),
+		queue: queue,
+	}, nil
+}
+
+// Start delivers queued events in the background until the context passed to
+// NewNotifier is cancelled.
+func (n *Notifier) Start() {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.run()
+	}()
+}
+
+// Wait blocks until the background routines of the Notifier have exited,
+// after its context is cancelled. The database must not be closed before.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// Notify queues the event for delivery to every URL. The event is persisted
+// before Notify returns.
+func (n *Notifier) Notify(e *Event) {
+	n.mu.Lock()
+	for _, url := range n.cfg.URLs {
+		d := &Delivery{
+			ID:          randomID(),
+			URL:         url,
+			Event:       e,
+			NextAttempt: time.Now(),
+		}
+
+		if err := n.cfg.DB.PutWebhookDelivery(d); err != nil {
+			// still try to deliver it during this run
+			log.Warnf("failed to persist %s webhook delivery to %s: %s", e.Type, url, err)
+		}
+		n.queue[d.ID] = d
+	}
+	n.mu.Unlock()
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SetTargets replaces the URLs that events are delivered to and the secret
+// that they are signed with. Queued deliveries to URLs that are no longer
+// targets are dropped.
+func (n *Notifier) SetTargets(urls []string, secret []byte) error {
+	if len(urls) == 0 {
+		return errNoURLs
+	}
+	if len(secret) == 0 {
+		return errNoSecret
+	}
+
+	targets := make(map[string]struct{}, len(urls))
+	for _, url := range urls {
+		targets[url] = struct{}{}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.cfg.URLs = append([]string{}, urls...)
+	n.cfg.Secret = append([]byte{}, secret...)
+
+	for id, d := range n.queue {
+		if _, ok := targets[d.URL]; ok {
+			continue
+		}
+		delete(n.queue, id)
+		if err := n.cfg.DB.DeleteWebhookDelivery(id); err != nil {
+			log.Warnf("failed to delete webhook delivery %s: %s", id, err)
+		}
+	}
+
+	return nil
+}
+
+// Pending returns the number of deliveries that have not succeeded yet.
+func (n *Notifier) Pending() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.queue)
+}
+
+func (n *Notifier) run() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-n.wake:
+		case <-timer.C:
+		}
+
+		next := n.deliverDue()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(next))
+	}
+}
+
+// deliverDue attempts every delivery whose time has come, and returns the
+// time of the next attempt.
+func (n *Notifier) deliverDue() time.Time {
+	now := time.Now()
+	next := now.Add(n.cfg.MaxBackoff)
+
+	for _, d := range n.dueDeliveries(now) {
+		if n.ctx.Err() != nil {
+			break
+		}
+
+		err := n.send(d)
+		if n.ctx.Err() != nil {
+			// shutting down, the delivery stays queued as it was
+			break
+		}
+		n.finishAttempt(d, err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, d := range n.queue {
+		if d.NextAttempt.Before(next) {
+			next = d.NextAttempt
+		}
+	}
+
+	return next
+}
+
+func (n *Notifier) dueDeliveries(now time.Time) []*Delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var due []*Delivery
+	for _, d := range n.queue {
+		if !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	return due
+}
+
+// finishAttempt removes a delivery from the queue once it succeeded or ran
+// out of attempts, otherwise it schedules the next attempt.
+func (n *Notifier) finishAttempt(d *Delivery, sendErr error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.queue[d.ID]; !ok {
+		return // dropped by SetTargets during the attempt
+	}
+
+	d.Attempts++
+
+	if sendErr == nil || d.Attempts >= n.cfg.MaxAttempts {
+		if sendErr != nil {
+			log.Warnf("dropping %s webhook delivery %s to %s after %d attempts: %s",
+				d.Event.Type, d.ID, d.URL, d.Attempts, sendErr)
+		}
+
+		delete(n.queue, d.ID)
+		if err := n.cfg.DB.DeleteWebhookDelivery(d.ID); err != nil {
+			log.Warnf("failed to delete webhook delivery %s: %s", d.ID, err)
+		}
+		return
+	}
+
+	d.NextAttempt = time.Now().Add(n.backoff(d.Attempts))
+	log.Debugf("%s webhook delivery %s to %s failed (attempt %d), retrying at %s: %s",
+		d.Event.Type, d.ID, d.URL, d.Attempts, d.NextAttempt.Format(time.RFC3339), sendErr)
+
+	if err := n.cfg.DB.PutWebhookDelivery(d); err != nil {
+		log.Warnf("failed to persist webhook delivery %s: %s", d.ID, err)
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (n *Notifier) backoff(attempts int) time.Duration {
+	delay := n.cfg.MinBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= n.cfg.MaxBackoff {
+			return n.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+func (n *Notifier) send(d *Delivery) error {
+	body, err := vjson.MarshalStruct(d.Event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(n.ctx, requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	secret := n.cfg.Secret
+	n.mu.Unlock()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(d.Event.Type))
+	req.Header.Set(HeaderDelivery, d.ID.Hex())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := n.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Sign returns the value of the signature header for the given timestamp
+// header and body: "sha256=" followed by the hex encoded HMAC-SHA256, keyed by
+// the shared secret, of the timestamp, a period and the body. Receivers should
+// compute it over the raw body and compare it with hmac.Equal.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature header is valid for the timestamp
+// header and body.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
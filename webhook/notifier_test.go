@@ -0,0 +1,360 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+var (
+	testSecret    = []byte("shared-secret")
+	testPeerID, _ = peer.Decode("12D3KooWQQRJuKTZ35eiHGNPGDpQqjpJSdaxEMJRxi6NWFrrvQVi")
+)
+
+type memDB struct {
+	mu         sync.Mutex
+	deliveries map[types.Hash]Delivery
+}
+
+func newMemDB() *memDB {
+	return &memDB{deliveries: make(map[types.Hash]Delivery)}
+}
+
+func (db *memDB) PutWebhookDelivery(d *Delivery) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.deliveries[d.ID] = *d
+	return nil
+}
+
+func (db *memDB) DeleteWebhookDelivery(id types.Hash) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.deliveries, id)
+	return nil
+}
+
+func (db *memDB) GetAllWebhookDeliveries() ([]*Delivery, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var deliveries []*Delivery
+	for _, d := range db.deliveries {
+		d := d
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, nil
+}
+
+func (db *memDB) len() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return len(db.deliveries)
+}
+
+// testServer records the events it receives after verifying their signature.
+// The first failures requests are answered with an error.
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	secret   []byte
+	failures int
+	requests int
+	events   chan *Event
+}
+
+func newTestServer(t *testing.T, failures int) *testServer {
+	s := &testServer{secret: testSecret, failures: failures, events: make(chan *Event, 10)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		s.mu.Lock()
+		secret := s.secret
+		s.mu.Unlock()
+
+		if !Verify(secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		s.mu.Lock()
+		s.requests++
+		fail := s.requests <= s.failures
+		s.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		e := new(Event)
+		require.NoError(t, json.Unmarshal(body, e))
+		require.Equal(t, string(e.Type), r.Header.Get(HeaderEvent))
+		s.events <- e
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestNotifier(t *testing.T, db Database, urls ...string) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	n, err := NewNotifier(ctx, &Config{
+		URLs:       urls,
+		Secret:     testSecret,
+		DB:         db,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return n
+}
+
+func receive(t *testing.T, s *testServer) *Event {
+	select {
+	case e := <-s.events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook delivery")
+		return nil
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"type":"swap_status"}`)
+	sig := Sign(testSecret, "1700000000", body)
+	require.True(t, Verify(testSecret, "1700000000", body, sig))
+	require.False(t, Verify(testSecret, "1700000001", body, sig))
+	require.False(t, Verify([]byte("other"), "1700000000", body, sig))
+	require.False(t, Verify(testSecret, "1700000000", []byte(`{}`), sig))
+}
+
+func TestNotifier_retries(t *testing.T) {
+	s := newTestServer(t, 2)
+	db := newMemDB()
+	n := newTestNotifier(t, db, s.URL)
+	n.Start()
+
+	offerID := types.Hash{0x1}
+	n.JournalAppended(offerID, swap.NewStatusJournalEntry(types.XMRLocked))
+
+	e := receive(t, s)
+	require.Equal(t, EventSwapStatus, e.Type)
+	require.Equal(t, offerID, *e.OfferID)
+	require.Equal(t, types.XMRLocked, *e.Status)
+
+	require.Eventually(t, func() bool { return n.Pending() == 0 && db.len() == 0 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, 3, s.requests)
+}
+
+func TestNotifier_dropsAfterMaxAttempts(t *testing.T) {
+	s := newTestServer(t, 1000)
+	db := newMemDB()
+	n := newTestNotifier(t, db, s.URL)
+	n.cfg.MaxAttempts = 3
+	n.Start()
+
+	n.Notify(newEvent(EventLowBalance))
+	require.Eventually(t, func() bool { return n.Pending() == 0 && db.len() == 0 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 3, s.requests)
+}
+
+func TestNotifier_persistedQueue(t *testing.T) {
+	db := newMemDB()
+
+	// nothing is delivered until the notifier is started
+	n := newTestNotifier(t, db, "http://127.0.0.1:1/unused")
+	n.JournalAppended(types.Hash{0x1}, swap.NewStatusJournalEntry(types.CompletedRefund))
+	require.Equal(t, 2, n.Pending())
+	require.Equal(t, 2, db.len())
+
+	// a new notifier, as after a restart, delivers the queued events
+	s := newTestServer(t, 0)
+	for id, d := range db.deliveries {
+		d.URL = s.URL
+		db.deliveries[id] = d
+	}
+	n = newTestNotifier(t, db, s.URL)
+	require.Equal(t, 2, n.Pending())
+	n.Start()
+
+	received := map[EventType]bool{}
+	received[receive(t, s).Type] = true
+	received[receive(t, s).Type] = true
+	require.Equal(t, map[EventType]bool{EventSwapStatus: true, EventSwapRefunded: true}, received)
+	require.Eventually(t, func() bool { return db.len() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestNotifier_SetTargets(t *testing.T) {
+	db := newMemDB()
+	n := newTestNotifier(t, db, "http://127.0.0.1:1/old")
+	n.Notify(newEvent(EventLowBalance))
+	require.Equal(t, 1, n.Pending())
+
+	require.ErrorIs(t, n.SetTargets(nil, testSecret), errNoURLs)
+	require.ErrorIs(t, n.SetTargets([]string{"http://127.0.0.1:1/new"}, nil), errNoSecret)
+
+	// deliveries queued to the old URL are dropped
+	s := newTestServer(t, 0)
+	newSecret := []byte("new secret")
+	s.secret = newSecret
+	require.NoError(t, n.SetTargets([]string{s.URL}, newSecret))
+	require.Equal(t, 0, n.Pending())
+	require.Equal(t, 0, db.len())
+
+	n.Start()
+	n.Notify(newEvent(EventLowBalance))
+	require.Equal(t, EventLowBalance, receive(t, s).Type)
+}
+
+func TestNotifier_events(t *testing.T) {
+	db := newMemDB()
+	n := newTestNotifier(t, db, "http://127.0.0.1:1/a", "http://127.0.0.1:1/b")
+
+	makerSwap := swap.NewInfo(
+		testPeerID,
+		types.Hash{0x1},
+		coins.ProvidesXMR,
+		apd.New(1, 0),
+		apd.New(10, 0),
+		coins.ToExchangeRate(apd.New(1, -1)),
+		types.EthAssetETH,
+		types.KeysExchanged,
+		100,
+	)
+	n.SwapAdded(makerSwap)
+
+	// we took this swap's offer
+	takerSwap, err := makerSwap.DeepCopy()
+	require.NoError(t, err)
+	takerSwap.Provides = coins.ProvidesETH
+	n.SwapAdded(takerSwap)
+
+	n.JournalAppended(makerSwap.OfferID, swap.NewStatusJournalEntry(types.CompletedAbort))
+	n.JournalAppended(makerSwap.OfferID, swap.NewEthTxJournalEntry("relayed_claim", types.Hash{0x2}))
+	n.JournalAppended(makerSwap.OfferID, swap.NewEthTxJournalEntry("claim", types.Hash{0x3}))
+	n.JournalAppended(makerSwap.OfferID, swap.NewTimeoutJournalEntry("t1"))
+
+	counts := map[EventType]int{}
+	for _, d := range db.deliveries {
+		counts[d.Event.Type]++
+	}
+
+	// each event is queued once per URL
+	require.Equal(t, map[EventType]int{
+		EventOfferTaken:            2,
+		EventSwapStatus:            2,
+		EventSwapAborted:           2,
+		EventRelayerClaimCompleted: 2,
+	}, counts)
+}
+
+type testBalance struct {
+	wei *coins.WeiAmount
+}
+
+func (b *testBalance) Balance(_ context.Context) (*coins.WeiAmount, error) {
+	return b.wei, nil
+}
+
+func TestWatcher(t *testing.T) {
+	db := newMemDB()
+	n := newTestNotifier(t, db, "http://127.0.0.1:1/unused")
+
+	now := time.Now()
+	info := swap.NewInfo(
+		testPeerID,
+		types.Hash{0x1},
+		coins.ProvidesETH,
+		apd.New(1, 0),
+		apd.New(10, 0),
+		coins.ToExchangeRate(apd.New(1, -1)),
+		types.EthAssetETH,
+		types.ETHLocked,
+		100,
+	)
+	t1 := now.Add(5 * time.Minute)
+	t2 := now.Add(time.Hour)
+	info.SetTimeouts(&t1, &t2)
+
+	sm := &testSwaps{swaps: []*swap.Info{info}}
+	balance := &testBalance{wei: coins.EtherToWei(apd.New(1, -2))} // 0.01 ETH
+	w := &watcher{
+		n: n,
+		cfg: WatcherConfig{
+			Swaps:          sm,
+			TimeoutWarning: DefaultTimeoutWarning,
+			Balance:        balance,
+		},
+		warned: make(map[types.Hash]map[string]struct{}),
+	}
+	n.SetLowBalance(apd.New(1, -1)) // 0.1 ETH
+
+	countEvents := func(eventType EventType) int {
+		count := 0
+		for _, d := range db.deliveries {
+			if d.Event.Type == eventType {
+				count++
+			}
+		}
+		return count
+	}
+
+	// t1 is near, but not t2, and the balance is low
+	w.check(now)
+	require.Equal(t, 1, countEvents(EventTimeoutApproaching))
+	require.Equal(t, 1, countEvents(EventLowBalance))
+
+	// nothing is sent twice
+	w.check(now.Add(time.Minute))
+	require.Equal(t, 1, countEvents(EventTimeoutApproaching))
+	require.Equal(t, 1, countEvents(EventLowBalance))
+
+	// t2 is near, and the balance recovers then drops again
+	balance.wei = coins.EtherToWei(apd.New(1, 0))
+	w.check(t2.Add(-time.Minute))
+	require.Equal(t, 2, countEvents(EventTimeoutApproaching))
+	balance.wei = coins.EtherToWei(apd.New(1, -2))
+	w.check(t2.Add(-time.Minute))
+	require.Equal(t, 2, countEvents(EventLowBalance))
+
+	// completed swaps are forgotten
+	sm.swaps = nil
+	w.check(now)
+	require.Empty(t, w.warned)
+
+	// the threshold can be changed, or the check disabled, while running
+	balance.wei = coins.EtherToWei(apd.New(1, 0))
+	w.check(now)
+	n.SetLowBalance(apd.New(2, 0))
+	w.check(now)
+	require.Equal(t, 3, countEvents(EventLowBalance))
+	n.SetLowBalance(nil)
+	balance.wei = coins.EtherToWei(apd.New(1, -2))
+	w.check(now)
+	require.Equal(t, 3, countEvents(EventLowBalance))
+}
+
+type testSwaps struct {
+	swaps []*swap.Info
+}
+
+func (s *testSwaps) GetOngoingSwapsSnapshot() ([]*swap.Info, error) {
+	return s.swaps, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 The AthanorLabs/atomic-swap Authors
+// SPDX-License-Identifier: LGPL-3.0-only
+
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/athanorlabs/atomic-swap/coins"
+	"github.com/athanorlabs/atomic-swap/common/types"
+	"github.com/athanorlabs/atomic-swap/protocol/swap"
+)
+
+const (
+	// DefaultTimeoutWarning is how long before a swap timeout
+	// EventTimeoutApproaching is sent.
+	DefaultTimeoutWarning = 15 * time.Minute
+
+	watchInterval = time.Minute
+)
+
+// OngoingSwaps returns copies of the ongoing swaps. It is implemented by
+// swap.Manager.
+type OngoingSwaps interface {
+	GetOngoingSwapsSnapshot() ([]*swap.Info, error)
+}
+
+// BalanceSource returns our ETH balance. It is implemented by
+// extethclient.EthClient.
+type BalanceSource interface {
+	Balance(ctx context.Context) (*coins.WeiAmount, error)
+}
+
+// WatcherConfig configures the events that the Notifier sends from periodic
+// checks, rather than from swap lifecycle events.
+type WatcherConfig struct {
+	Swaps OngoingSwaps
+	// TimeoutWarning defaults to DefaultTimeoutWarning.
+	TimeoutWarning time.Duration
+
+	// Balance and LowBalance are optional. If both are set, EventLowBalance is
+	// sent when the balance drops below LowBalance, in ETH.
+	Balance    BalanceSource
+	LowBalance *apd.Decimal
+}
+
+// watcher sends the events of the periodic checks.
+type watcher struct {
+	n   *Notifier
+	cfg WatcherConfig
+
+	// warned holds the timeouts of each swap that were already notified
+	warned     map[types.Hash]map[string]struct{}
+	lowBalance bool
+}
+
+// StartWatcher periodically checks for approaching swap timeouts and a low
+// balance until the context passed to NewNotifier is cancelled.
+func (n *Notifier) StartWatcher(cfg *WatcherConfig) {
+	w := &watcher{
+		n:      n,
+		cfg:    *cfg,
+		warned: make(map[types.Hash]map[string]struct{}),
+	}
+	if w.cfg.TimeoutWarning == 0 {
+		w.cfg.TimeoutWarning = DefaultTimeoutWarning
+	}
+	n.SetLowBalance(cfg.LowBalance)
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		for {
+			w.check(time.Now())
+
+			select {
+			case <-n.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (w *watcher) check(now time.Time) {
+	if err := w.checkTimeouts(now); err != nil {
+		log.Warnf("failed to check swap timeouts: %s", err)
+	}
+
+	threshold := w.n.lowBalanceThreshold()
+	if w.cfg.Balance == nil || threshold == nil {
+		return
+	}
+
+	if err := w.checkBalance(threshold); err != nil {
+		log.Warnf("failed to check balance: %s", err)
+	}
+}
+
+func (w *watcher) checkTimeouts(now time.Time) error {
+	swaps, err := w.cfg.Swaps.GetOngoingSwapsSnapshot()
+	if err != nil {
+		return err
+	}
+
+	ongoing := make(map[types.Hash]struct{}, len(swaps))
+	for _, info := range swaps {
+		ongoing[info.OfferID] = struct{}{}
+		w.checkTimeout(now, info, "t1", info.Timeout1)
+		w.checkTimeout(now, info, "t2", info.Timeout2)
+	}
+
+	// forget the swaps that have completed
+	for id := range w.warned {
+		if _, ok := ongoing[id]; !ok {
+			delete(w.warned, id)
+		}
+	}
+
+	return nil
+}
+
+func (w *watcher) checkTimeout(now time.Time, info *swap.Info, name string, deadline *time.Time) {
+	if deadline == nil || !now.Before(*deadline) || deadline.Sub(now) > w.cfg.TimeoutWarning {
+		return
+	}
+
+	if _, ok := w.warned[info.OfferID][name]; ok {
+		return
+	}
+	if w.warned[info.OfferID] == nil {
+		w.warned[info.OfferID] = make(map[string]struct{})
+	}
+	w.warned[info.OfferID][name] = struct{}{}
+
+	t := *deadline
+	status := info.Status
+	e := newSwapEvent(EventTimeoutApproaching, info.OfferID)
+	e.Provides = info.Provides
+	e.Status = &status
+	e.Timeout = name
+	e.Deadline = &t
+	w.n.Notify(e)
+}
+
+func (w *watcher) checkBalance(threshold *apd.Decimal) error {
+	wei, err := w.cfg.Balance.Balance(w.n.ctx)
+	if err != nil {
+		return err
+	}
+
+	balance := wei.AsEther()
+	if balance.Cmp(threshold) >= 0 {
+		w.lowBalance = false
+		return nil
+	}
+
+	if w.lowBalance {
+		return nil
+	}
+	w.lowBalance = true
+
+	e := newEvent(EventLowBalance)
+	e.Balance = balance
+	e.Threshold = threshold
+	w.n.Notify(e)
+	return nil
+}
+
+// SetLowBalance replaces the ETH balance below which the watcher sends
+// EventLowBalance. Nil disables the check.
+func (n *Notifier) SetLowBalance(threshold *apd.Decimal) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lowBalance = threshold
+}
+
+func (n *Notifier) lowBalanceThreshold() *apd.Decimal {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.lowBalance
+}